@@ -0,0 +1,33 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// embeddedAssets are the web templates and static files, built into the binary.
+//
+//go:embed assets
+var embeddedAssets embed.FS
+
+// assetsFS returns the web templates and static files. If a directory is given, the assets are read from it instead of
+// the binary, so that they can be edited without a rebuild.
+func assetsFS(dir string) (fs.FS, error) {
+	if dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("error reading assets directory: %w", err)
+		} else if !info.IsDir() {
+			return nil, fmt.Errorf("assets directory %s is not a directory", dir)
+		}
+		return os.DirFS(dir), nil
+	}
+
+	assets, err := fs.Sub(embeddedAssets, "assets")
+	if err != nil {
+		return nil, fmt.Errorf("error reading embedded assets: %w", err)
+	}
+	return assets, nil
+}
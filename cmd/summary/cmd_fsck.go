@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/reconcile"
+	"github.com/google/subcommands"
+	"github.com/spf13/viper"
+)
+
+type fsckCmd struct {
+	// dbType is the type of database to connect to.
+	dbType string
+
+	// gcs is whether to connect to Files.
+	gcs string
+
+	// repair is how the orphans are repaired.
+	repair string
+}
+
+func (c *fsckCmd) Name() string {
+	return "fsck"
+}
+
+func (c *fsckCmd) Synopsis() string {
+	return "Find reports and report files that are missing their counterpart"
+}
+
+func (c *fsckCmd) Usage() string {
+	return `fsck:
+  Find reports in the database whose file is missing from storage, and files in storage with no report in the database.
+`
+}
+
+func (c *fsckCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.dbType, "db", dataaccess.DbSqlite.String(), "The type of database to connect to.")
+	f.StringVar(&c.gcs, "gcs", "", "The name of the Google Cloud Storage bucket to use. (Setting this will enable GCS)")
+	f.StringVar(&c.repair, "repair", string(reconcile.RepairNone), "How to repair the orphans. Valid values are 'none', 'delete', and 'import'.")
+}
+
+func (c *fsckCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	// Setup logging
+	if err := setupLogging(); err != nil {
+		slog.Error("Error setting up logging", slog.String(logging.KeyError, err.Error()))
+		return subcommands.ExitFailure
+	}
+
+	repair, err := reconcile.ParseRepair(c.repair)
+	if err != nil {
+		slog.Error("Invalid repair option", slog.String("repair", c.repair))
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	c.dbType = strings.TrimSpace(c.dbType)
+	c.dbType = strings.ToUpper(c.dbType)
+	if !dataaccess.DbOpt(c.dbType).Valid() {
+		slog.Error("Invalid database option", slog.String("dbType", c.dbType))
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	v := viper.New()
+	err = v.BindEnv("db.conn_str", "DB_CONN_STR")
+	if err != nil {
+		slog.Error("Error binding environment variable", slog.String(logging.KeyError, err.Error()))
+		return subcommands.ExitFailure
+	}
+
+	db, err := dataaccess.ConnectDatabase(ctx, c.dbType, v)
+	if err != nil {
+		slog.Error("Error connecting to database", slog.String(logging.KeyError, err.Error()))
+		return subcommands.ExitFailure
+	}
+	if c.gcs != "" {
+		err = dataaccess.ConnectStorage(ctx, dataaccess.StoreTypeGCS, c.gcs)
+		if err != nil {
+			slog.Error("Error connecting to Google Cloud Storage", slog.String(logging.KeyError, err.Error()))
+			return subcommands.ExitFailure
+		}
+	} else {
+		err = dataaccess.ConnectStorage(ctx, dataaccess.StoreTypeLocal, "")
+		if err != nil {
+			slog.Error("Error connecting to local storage", slog.String(logging.KeyError, err.Error()))
+			return subcommands.ExitFailure
+		}
+	}
+
+	res, err := reconcile.NewService(db).Reconcile(ctx, repair)
+	if err != nil {
+		slog.Error("Error reconciling database and storage", slog.String(logging.KeyError, err.Error()))
+		return subcommands.ExitFailure
+	}
+
+	if err := printReconcileResult(os.Stdout, res, repair); err != nil {
+		slog.Error("Error printing reconcile result", slog.String(logging.KeyError, err.Error()))
+		return subcommands.ExitFailure
+	}
+
+	if res.Failed > 0 {
+		return subcommands.ExitFailure
+	}
+
+	return subcommands.ExitSuccess
+}
+
+// printReconcileResult writes the orphans found by the reconciliation to the given writer as a table.
+func printReconcileResult(w io.Writer, res *reconcile.Result, repair reconcile.Repair) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Reports missing their file:\t%d\n", len(res.MissingFiles))
+	fmt.Fprintf(tw, "Files missing their report:\t%d\n", len(res.OrphanFiles))
+	if repair != reconcile.RepairNone {
+		fmt.Fprintf(tw, "Repaired (%s):\t%d\n", repair, res.Repaired)
+		fmt.Fprintf(tw, "Failed to repair:\t%d\n", res.Failed)
+	}
+
+	if len(res.MissingFiles) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "ID\tENVIRONMENT\tFQDN\tEXECUTED AT")
+		for _, run := range res.MissingFiles {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", run.ID, run.Env, run.Fqdn, run.ExecTime.Time().Format(time.RFC3339))
+		}
+	}
+
+	if len(res.OrphanFiles) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "FILE")
+		for _, file := range res.OrphanFiles {
+			fmt.Fprintln(tw, file)
+		}
+	}
+
+	return tw.Flush()
+}
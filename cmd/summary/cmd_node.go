@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+	"github.com/google/subcommands"
+	"github.com/spf13/viper"
+)
+
+type nodeCmd struct{}
+
+func (c *nodeCmd) Name() string {
+	return "node"
+}
+
+func (c *nodeCmd) Synopsis() string {
+	return "Manage the nodes reporting to the application"
+}
+
+func (c *nodeCmd) Usage() string {
+	return `node <subcommand>:
+  Manage the nodes reporting to the application.
+
+Subcommands:
+  delete  Delete or decommission a node
+`
+}
+
+func (c *nodeCmd) SetFlags(_ *flag.FlagSet) {}
+
+func (c *nodeCmd) Execute(ctx context.Context, f *flag.FlagSet, args ...interface{}) subcommands.ExitStatus {
+	cdr := subcommands.NewCommander(f, "node")
+	cdr.Register(cdr.HelpCommand(), "")
+	cdr.Register(new(nodeDeleteCmd), "")
+	return cdr.Execute(ctx, args...)
+}
+
+type nodeDeleteCmd struct {
+	// dbType is the type of database to connect to.
+	dbType string
+
+	// gcs is whether to connect to Files.
+	gcs string
+
+	// decommission is whether to decommission the node rather than delete it.
+	decommission bool
+}
+
+func (c *nodeDeleteCmd) Name() string {
+	return "delete"
+}
+
+func (c *nodeDeleteCmd) Synopsis() string {
+	return "Delete or decommission a node"
+}
+
+func (c *nodeDeleteCmd) Usage() string {
+	return `node delete [-decommission] <fqdn>:
+  Delete the reports of a node from the database and storage. With -decommission, the node is hidden from the listings
+  instead, keeping its reports, until it reports again.
+`
+}
+
+func (c *nodeDeleteCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.dbType, "db", dataaccess.DbSqlite.String(), "The type of database to connect to.")
+	f.StringVar(&c.gcs, "gcs", "", "The name of the Google Cloud Storage bucket to use. (Setting this will enable GCS)")
+	f.BoolVar(&c.decommission, "decommission", false, "Hide the node from the listings rather than deleting its reports.")
+}
+
+func (c *nodeDeleteCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	// Setup logging
+	if err := setupLogging(); err != nil {
+		slog.Error("Error setting up logging", slog.String(logging.KeyError, err.Error()))
+		return subcommands.ExitFailure
+	}
+
+	if f.NArg() != 1 {
+		slog.Error("Expected the fqdn of the node")
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+	fqdn := f.Arg(0)
+
+	c.dbType = strings.TrimSpace(c.dbType)
+	c.dbType = strings.ToUpper(c.dbType)
+	if !dataaccess.DbOpt(c.dbType).Valid() {
+		slog.Error("Invalid database option", slog.String("dbType", c.dbType))
+		f.Usage()
+		return subcommands.ExitUsageError
+	}
+
+	v := viper.New()
+	err := v.BindEnv("db.conn_str", "DB_CONN_STR")
+	if err != nil {
+		slog.Error("Error binding environment variable", slog.String(logging.KeyError, err.Error()))
+		return subcommands.ExitFailure
+	}
+
+	db, err := dataaccess.ConnectDatabase(ctx, c.dbType, v)
+	if err != nil {
+		slog.Error("Error connecting to database", slog.String(logging.KeyError, err.Error()))
+		return subcommands.ExitFailure
+	}
+	if c.gcs != "" {
+		err = dataaccess.ConnectStorage(ctx, dataaccess.StoreTypeGCS, c.gcs)
+		if err != nil {
+			slog.Error("Error connecting to Google Cloud Storage", slog.String(logging.KeyError, err.Error()))
+			return subcommands.ExitFailure
+		}
+	} else {
+		err = dataaccess.ConnectStorage(ctx, dataaccess.StoreTypeLocal, "")
+		if err != nil {
+			slog.Error("Error connecting to local storage", slog.String(logging.KeyError, err.Error()))
+			return subcommands.ExitFailure
+		}
+	}
+
+	svc := nodes.NewService(db)
+
+	if c.decommission {
+		err = svc.Decommission(ctx, fqdn)
+		if err == nil {
+			fmt.Printf("Decommissioned %s\n", fqdn)
+		}
+	} else {
+		var res *nodes.Result
+		res, err = svc.Delete(ctx, fqdn)
+		if err == nil {
+			fmt.Printf("Deleted %s: %d reports, %d files\n", fqdn, res.ReportsDeleted, res.FilesDeleted)
+		}
+	}
+
+	switch {
+	case errors.Is(err, dataaccess.ErrNotFound):
+		slog.Error("No reports found for node", slog.String(logging.KeyFqdn, fqdn))
+		return subcommands.ExitFailure
+	case err != nil:
+		slog.Error("Error deleting node", slog.String(logging.KeyError, err.Error()))
+		return subcommands.ExitFailure
+	}
+
+	return subcommands.ExitSuccess
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
@@ -23,6 +27,12 @@ type purgeCmd struct {
 
 	// gcs is whether to connect to Files.
 	gcs string
+
+	// configLocation is the location of the config file containing the retention policies.
+	configLocation string
+
+	// dryRun is whether to only report what would be purged.
+	dryRun bool
 }
 
 func (p *purgeCmd) Name() string {
@@ -40,16 +50,39 @@ func (p *purgeCmd) Usage() string {
 }
 
 func (p *purgeCmd) SetFlags(f *flag.FlagSet) {
-	f.IntVar(&p.days, "days", 0, "The number of days to purge. 0 will not purge any data, <0 will purge all data.")
+	f.IntVar(&p.days, "days", 0, "The number of days to keep data for. 0 will only apply the retention policies, <0 will purge all data.")
 	f.StringVar(&p.dbType, "db", dataaccess.DbSqlite.String(), "The type of database to connect to.")
 	f.StringVar(&p.gcs, "gcs", "", "The name of the Google Cloud Storage bucket to use. (Setting this will enable GCS)")
+	f.StringVar(&p.configLocation, "config", "", "The location of the config file containing the retention policies.")
+	f.BoolVar(&p.dryRun, "dry-run", false, "Report what would be purged without purging anything.")
 }
 
 func (p *purgeCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
-	if p.days == 0 {
-		slog.Warn("Days not set, will not purge any data")
+	// Setup logging
+	if err := setupLogging(); err != nil {
+		slog.Error("Error setting up logging", slog.String(logging.KeyError, err.Error()))
+		return subcommands.ExitFailure
+	}
+
+	v := viper.New()
+	if p.configLocation != "" {
+		v.SetConfigFile(p.configLocation)
+		if err := v.ReadInConfig(); err != nil {
+			slog.Error("Error reading config file", slog.String(logging.KeyError, err.Error()))
+			return subcommands.ExitFailure
+		}
+	}
+
+	retention, err := purge.RetentionFromViper(v)
+	if err != nil {
+		slog.Error("Error reading retention policies", slog.String(logging.KeyError, err.Error()))
+		return subcommands.ExitFailure
+	}
+
+	if p.days == 0 && !retention.Enabled() {
+		slog.Warn("Days not set and no retention policies configured, will not purge any data")
 		return subcommands.ExitUsageError
-	} else if p.days < 0 {
+	} else if p.days < 0 && !p.dryRun {
 		// Get confirmation that this will purge all data
 		fmt.Println("Purging all data")
 		fmt.Print("Are you sure? (yes/no): ")
@@ -72,14 +105,7 @@ func (p *purgeCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{
 		return subcommands.ExitUsageError
 	}
 
-	// Setup logging
-	if err := setupLogging(); err != nil {
-		slog.Error("Error setting up logging", slog.String(logging.KeyError, err.Error()))
-		return subcommands.ExitFailure
-	}
-
-	v := viper.New()
-	err := v.BindEnv("db.conn_str", "DB_CONN_STR")
+	err = v.BindEnv("db.conn_str", "DB_CONN_STR")
 	if err != nil {
 		slog.Error("Error binding environment variable", slog.String(logging.KeyError, err.Error()))
 		return subcommands.ExitFailure
@@ -105,9 +131,25 @@ func (p *purgeCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{
 		}
 	}
 
+	purgeSvc := purge.NewService(db, retention)
+
+	if p.dryRun {
+		preview, err := purgeSvc.PreviewPurge(ctx, p.days)
+		if err != nil {
+			slog.Error("Error previewing purge", slog.String(logging.KeyError, err.Error()))
+			return subcommands.ExitFailure
+		}
+
+		if err := printPreview(os.Stdout, preview); err != nil {
+			slog.Error("Error printing purge preview", slog.String(logging.KeyError, err.Error()))
+			return subcommands.ExitFailure
+		}
+
+		return subcommands.ExitSuccess
+	}
+
 	// Purge the reports
-	purgeSvc := purge.NewService(db)
-	err = purgeSvc.PurgePuppetReports(p.days)
+	err = purgeSvc.PurgePuppetReports(ctx, p.days, nil)
 	if err != nil {
 		slog.Error("Error purging data", slog.String(logging.KeyError, err.Error()))
 		return subcommands.ExitFailure
@@ -115,3 +157,25 @@ func (p *purgeCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{
 
 	return subcommands.ExitSuccess
 }
+
+// printPreview writes the purge preview to the given writer as a table.
+func printPreview(w io.Writer, preview *purge.Preview) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Reports to remove:\t%d\n", preview.Reports)
+	fmt.Fprintf(tw, "Files to remove:\t%d\n", preview.Files)
+	if !preview.Oldest.IsZero() {
+		fmt.Fprintf(tw, "Oldest:\t%s\n", preview.Oldest.Format(time.RFC3339))
+		fmt.Fprintf(tw, "Newest:\t%s\n", preview.Newest.Format(time.RFC3339))
+	}
+
+	if len(preview.Groups) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "ENVIRONMENT\tSTATE\tREPORTS\tFILES")
+		for _, group := range preview.Groups {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", group.Environment, group.State, group.Reports, group.Files)
+		}
+	}
+
+	return tw.Flush()
+}
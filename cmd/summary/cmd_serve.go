@@ -11,18 +11,32 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	svc "github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/events"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/jobs"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/scheduler"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/alerting"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/anomalies"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/services/api"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/failures"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/flapping"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/incidents"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/services/purge"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/reconcile"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/rollout"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/timings"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/services/web"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/vault"
 	"github.com/google/subcommands"
 	"github.com/gorilla/mux"
 	vault2 "github.com/hashicorp/vault/api"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/viper"
 )
@@ -45,6 +59,44 @@ type serveCmd struct {
 
 	// gcs is the name of the Google Cloud Storage bucket to use. Setting this will enable GCS.
 	gcs string
+
+	// purgeSchedule is the cron schedule the auto purge runs on.
+	purgeSchedule string
+
+	// adminToken is the token used to authenticate requests to the admin endpoints.
+	adminToken string
+
+	// fsckSchedule is the cron schedule the reconciliation of the database and storage runs on. If empty, the
+	// reconciliation is not scheduled.
+	fsckSchedule string
+
+	// fsckRepair is how the scheduled reconciliation repairs the orphans it finds.
+	fsckRepair string
+
+	// assetsDir is the directory to read the web templates and static files from, instead of the ones built into the
+	// binary. The templates are reloaded on every request.
+	assetsDir string
+
+	// basePath is the path the application is served under, such as when it is behind a reverse proxy.
+	basePath string
+
+	// staleAfter is how long a node can go without reporting before it is stale.
+	staleAfter time.Duration
+
+	// incidentWindow is how far apart in time the failed runs of an incident can be.
+	incidentWindow time.Duration
+
+	// anomalyThreshold is the score above which the runtime of a run is anomalous.
+	anomalyThreshold float64
+
+	// anomalyAlerts is whether the runtime anomalies are posted to the webhook.
+	anomalyAlerts bool
+
+	// webhookURL is the URL the incidents are posted to. If empty, they are not notified.
+	webhookURL string
+
+	// webhookSecret is the key the webhook notifications are signed with.
+	webhookSecret string
 }
 
 func (s *serveCmd) Name() string {
@@ -66,8 +118,20 @@ func (s *serveCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&s.configLocation, "config", "config.json", "The location of the config file")
 	f.StringVar(&s.authToken, "auth-token", "", "The Bearer token used to authenticate requests to the upload endpoint.")
 	f.IntVar(&s.autoPurge, "auto-purge", 0, "The number of days to keep data for. If 0 (or not set), data will not be purged.")
-	f.StringVar(&s.dbType, "db", dataaccess.DbSqlite.String(), "The type of database to use. Valid values are 'sqlite', 'mysql', and 'mongodb'.")
+	f.StringVar(&s.dbType, "db", dataaccess.DbSqlite.String(), "The type of database to use. Valid values are 'sqlite', 'mysql', 'mongodb', and 'memory'.")
 	f.StringVar(&s.gcs, "gcs", "", "The name of the Google Cloud Storage bucket to use. (Setting this will enable GCS)")
+	f.StringVar(&s.purgeSchedule, "purge-schedule", "", "The cron schedule the auto purge runs on. (Defaults to '0 3 * * *')")
+	f.StringVar(&s.adminToken, "admin-token", "", "The Bearer token used to authenticate requests to the admin endpoints. (Defaults to the auth token)")
+	f.StringVar(&s.fsckSchedule, "fsck-schedule", "", "The cron schedule the reconciliation of the database and storage runs on. (If empty, it is not scheduled)")
+	f.StringVar(&s.fsckRepair, "fsck-repair", "", "How the scheduled reconciliation repairs orphans. Valid values are 'none', 'delete', and 'import'. (Defaults to 'none')")
+	f.StringVar(&s.assetsDir, "assets-dir", "", "The directory to read the web templates and static files from, reloading them on every request. (Defaults to the ones built into the binary)")
+	f.StringVar(&s.basePath, "base-path", "", "The path the application is served under, such as '/puppet' behind a reverse proxy. (Defaults to the root)")
+	f.DurationVar(&s.staleAfter, "stale-after", 0, "How long a node can go without reporting before it is stale. (Defaults to 24h)")
+	f.DurationVar(&s.incidentWindow, "incident-window", 0, "How far apart in time the failed runs of an incident can be. (Defaults to 30m)")
+	f.Float64Var(&s.anomalyThreshold, "anomaly-threshold", 0, "The score above which the runtime of a run is anomalous, in deviations from the median of the runs of the node before it. (Defaults to 3.5)")
+	f.BoolVar(&s.anomalyAlerts, "anomaly-alerts", false, "Whether the runtime anomalies are posted to the webhook, as well as the incidents.")
+	f.StringVar(&s.webhookURL, "webhook-url", "", "The URL the incidents are posted to. (If empty, they are not notified)")
+	f.StringVar(&s.webhookSecret, "webhook-secret", "", "The key the webhook notifications are signed with. (If empty, they are not signed)")
 }
 
 func (s *serveCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
@@ -126,29 +190,50 @@ func (s *serveCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{
 	return subcommands.ExitSuccess
 }
 
-func (s *serveCmd) generateConfig(ctx context.Context) error {
+func (s *serveCmd) generateConfig(_ context.Context) error {
+	if s.authToken != "" {
+		slog.Info("Upload token set, security on upload endpoint is enabled")
+		authToken.Set(s.authToken)
+	} else {
+		slog.Info("Upload token not set, upload endpoint is not secure")
+	}
+	if s.adminToken != "" {
+		adminToken.Set(s.adminToken)
+	}
+	if s.webhookSecret != "" {
+		webhookSecret.Set(s.webhookSecret)
+	}
+	if s.autoPurge != 0 {
+		slog.Info(fmt.Sprintf("Auto purge set to %d days", s.autoPurge))
+	} else {
+		slog.Info("Auto purge not set, data will only be purged by the configured retention policies")
+	}
+	return nil
+}
+
+// connectStorage connects to the configured storage. If the storage credentials have already been retrieved from
+// vault, the storage will already be connected.
+func (s *serveCmd) connectStorage(ctx context.Context) error {
+	if dataaccess.Files != nil {
+		return nil
+	}
 	if s.gcs != "" {
 		err := dataaccess.ConnectStorage(ctx, dataaccess.StoreTypeGCS, s.gcs)
 		if err != nil {
 			return fmt.Errorf("error connecting to Files: %w", err)
 		}
+	} else if dataaccess.DbOpt(s.dbType) == dataaccess.DbMemory {
+		// Nothing is persisted with the in-memory database, so the reports are not written to disk either.
+		err := dataaccess.ConnectStorage(ctx, dataaccess.StoreTypeMemory, "")
+		if err != nil {
+			return fmt.Errorf("error connecting to memory storage: %w", err)
+		}
 	} else {
 		err := dataaccess.ConnectStorage(ctx, dataaccess.StoreTypeLocal, "")
 		if err != nil {
 			return fmt.Errorf("error connecting to local storage: %w", err)
 		}
 	}
-	if s.authToken != "" {
-		slog.Info("Upload token set, security on upload endpoint is enabled")
-		authToken = s.authToken
-	} else {
-		slog.Info("Upload token not set, upload endpoint is not secure")
-	}
-	if s.autoPurge != 0 {
-		slog.Info(fmt.Sprintf("Auto purge set to %d days", s.autoPurge))
-	} else {
-		slog.Info("Auto purge not set, data will not be purged")
-	}
 	return nil
 }
 
@@ -171,6 +256,11 @@ func (s *serveCmd) setup(ctx context.Context, r *mux.Router) {
 			os.Exit(1)
 		}
 
+		if err := watchVaultSecrets(ctx, v, vc, s.vaultSecrets()...); err != nil {
+			slog.Error("Error getting secrets from vault", slog.String(logging.KeyError, err.Error()))
+			os.Exit(1)
+		}
+
 		dbSec, err = vc.GetSecrets(v.GetString("vault.database.path"))
 		if err != nil {
 			slog.Error("Error getting database secrets", slog.String(logging.KeyError, err.Error()))
@@ -189,6 +279,11 @@ func (s *serveCmd) setup(ctx context.Context, r *mux.Router) {
 		}
 	}
 
+	if err := s.connectStorage(ctx); err != nil {
+		slog.Error("Error connecting to storage", slog.String(logging.KeyError, err.Error()))
+		os.Exit(1)
+	}
+
 	// Connect to the database
 	db, err := dataaccess.ConnectDatabase(context.Background(), s.dbType, v)
 	if err != nil {
@@ -224,31 +319,170 @@ func (s *serveCmd) setup(ctx context.Context, r *mux.Router) {
 		}()
 	}
 
-	purgeSvc := purge.NewService(db)
+	retention, err := purge.RetentionFromViper(v)
+	if err != nil {
+		slog.Error("Error reading retention policies", slog.String(logging.KeyError, err.Error()))
+		os.Exit(1)
+	}
+
+	purgeSvc := purge.NewService(db, retention)
+
+	// The database is used to elect the instance that runs the scheduled jobs.
+	sched := scheduler.New(db)
 
 	// Set up the purge routine
-	if s.autoPurge != 0 {
-		if err := purgeSvc.SetupPurge(s.autoPurge); err != nil {
-			slog.Error("Error setting up purge goroutine", slog.String(logging.KeyError, err.Error()))
+	if s.autoPurge != 0 || retention.Enabled() {
+		schedule := s.purgeSchedule
+		if schedule == "" {
+			schedule = v.GetString("purge.schedule")
+		}
+		if err := purgeSvc.SetupPurge(sched, schedule, s.autoPurge); err != nil {
+			slog.Error("Error setting up purge job", slog.String(logging.KeyError, err.Error()))
+			os.Exit(1)
 		}
 	} else {
 		slog.Info("Auto purge not set, data will not be purged")
 	}
 
-	apiSvc := api.NewService(db, purgeSvc)
+	// The registry of the jobs started in the background through the API.
+	registry := jobs.NewRegistry(sched.Holder())
+
+	// Set up the reconciliation of the database and storage
+	fsckSchedule := s.fsckSchedule
+	if fsckSchedule == "" {
+		fsckSchedule = v.GetString("fsck.schedule")
+	}
+	if fsckSchedule != "" {
+		fsckRepair := s.fsckRepair
+		if fsckRepair == "" {
+			fsckRepair = v.GetString("fsck.repair")
+		}
+		repair, err := reconcile.ParseRepair(fsckRepair)
+		if err != nil {
+			slog.Error("Error reading reconcile repair mode", slog.String(logging.KeyError, err.Error()))
+			os.Exit(1)
+		}
+		if err := reconcile.NewService(db).SetupReconcile(sched, fsckSchedule, repair); err != nil {
+			slog.Error("Error setting up reconcile job", slog.String(logging.KeyError, err.Error()))
+			os.Exit(1)
+		}
+	}
+
+	sched.Start()
+	go func() {
+		<-ctx.Done()
+		sched.Stop(context.Background())
+		registry.CancelAll(context.Background())
+	}()
+
+	staleAfter := s.staleAfter
+	if staleAfter == 0 {
+		staleAfter = v.GetDuration("stale_after")
+	}
+	if staleAfter <= 0 {
+		staleAfter = api.DefaultStaleAfter
+	}
+
+	silencer := alerting.NewService(db)
+	prometheus.MustRegister(alerting.NewFailingNodesCollector(ctx, db, silencer))
+
+	webhookURL := s.webhookURL
+	if webhookURL == "" {
+		webhookURL = v.GetString("webhook.url")
+	}
+	if webhookSecret.Get() == "" {
+		webhookSecret.Set(v.GetString("webhook.secret"))
+	}
+
+	// The notifiers are left nil when no webhook is configured, so the incidents and anomalies are not notified.
+	var (
+		notifier        alerting.Notifier
+		anomalyNotifier alerting.AnomalyNotifier
+	)
+	if webhookURL != "" {
+		slog.Info("Webhook set, incidents will be notified")
+		webhook := alerting.NewWebhook(webhookURL, webhookSecret.Get, silencer)
+		notifier = webhook
+
+		if s.anomalyAlerts || v.GetBool("anomaly_alerts") {
+			slog.Info("Anomaly alerts enabled, runtime anomalies will be notified")
+			anomalyNotifier = webhook
+		}
+	}
+
+	incidentWindow := s.incidentWindow
+	if incidentWindow == 0 {
+		incidentWindow = v.GetDuration("incident_window")
+	}
+	correlator := incidents.NewService(db, incidentWindow, notifier)
+
+	anomalyThreshold := s.anomalyThreshold
+	if anomalyThreshold == 0 {
+		anomalyThreshold = v.GetFloat64("anomaly_threshold")
+	}
+	anomalyDetector := anomalies.NewService(db, anomalyThreshold, anomalyNotifier)
+
+	prometheus.MustRegister(flapping.NewFlappingNodesCollector(ctx, db))
+
+	broker := events.NewBroker(events.DefaultBuffer)
+
+	apiSvc := api.NewService(api.Deps{
+		DB:         db,
+		Purger:     purgeSvc,
+		Nodes:      nodes.NewService(db),
+		Silencer:   silencer,
+		Incidents:  correlator,
+		Anomalies:  anomalyDetector,
+		Flapping:   flapping.NewService(db),
+		Failures:   failures.NewService(db),
+		Rollout:    rollout.NewService(db),
+		Timings:    timings.NewService(db),
+		Broker:     broker,
+		Scheduler:  sched,
+		Jobs:       registry,
+		StaleAfter: staleAfter,
+	})
+
+	assets, err := assetsFS(s.assetsDir)
+	if err != nil {
+		slog.Error("Error reading assets", slog.String(logging.KeyError, err.Error()))
+		os.Exit(1)
+	}
+
+	templates, err := web.NewTemplates(assets, s.assetsDir != "")
+	if err != nil {
+		slog.Error("Error parsing templates", slog.String(logging.KeyError, err.Error()))
+		os.Exit(1)
+	}
+
+	basePath := s.basePath
+	if basePath == "" {
+		basePath = v.GetString("base_path")
+	}
+	basePath = cleanBasePath(basePath)
+
+	// The routes are registered on base, which is served under the base path.
+	base := r
+	if basePath != "" {
+		slog.Info("Serving under base path", slog.String("base_path", basePath))
+
+		// The index is registered with a trailing slash, so the base path on its own is redirected to it.
+		r.Handle(basePath, http.RedirectHandler(basePath+"/", http.StatusMovedPermanently))
+		base = r.PathPrefix(basePath).Subrouter()
+	}
 
-	r.HandleFunc(pathMetrics, promhttp.Handler().ServeHTTP).Methods(http.MethodGet)
-	r.HandleFunc(pathHealth, healthHandler(db).ServeHTTP).Methods(http.MethodGet)
+	base.HandleFunc(pathMetrics, promhttp.Handler().ServeHTTP).Methods(http.MethodGet)
+	base.HandleFunc(pathHealth, healthHandler(db).ServeHTTP).Methods(http.MethodGet)
 
 	r.NotFoundHandler = request.NotFoundHandler()
 	r.MethodNotAllowedHandler = request.MethodNotAllowedHandler()
 
-	r.PathPrefix(pathAssets).Handler(http.StripPrefix(pathAssets, http.FileServer(http.Dir("./assets"))))
+	base.PathPrefix(pathAssets).Handler(http.StripPrefix(basePath+pathAssets, http.FileServerFS(assets)))
 
 	svc.HandlerWithOptions(
 		apiSvc,
 		svc.GorillaServerOptions{
-			BaseRouter: r,
+			BaseRouter: base,
 			BaseURL:    pathApi,
 			ErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
 				w.WriteHeader(http.StatusBadRequest)
@@ -263,8 +497,10 @@ func (s *serveCmd) setup(ctx context.Context, r *mux.Router) {
 		})
 
 	web.NewServiceFromRouter(
-		r,
+		base,
 		db,
+		templates,
+		basePath,
 		metricsWrapper,
 	)
 }
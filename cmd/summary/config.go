@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
 )
@@ -12,9 +13,37 @@ const (
 )
 
 var (
-	authToken string
+	// authToken is the token used to authenticate requests to the secured endpoints. This can be rotated at runtime
+	// when it is sourced from vault.
+	authToken = new(secretValue)
+
+	// adminToken is the token used to authenticate requests to the admin endpoints. If empty, the auth token is used.
+	adminToken = new(secretValue)
+
+	// webhookSecret is the key the webhook notifications are signed with. If empty, they are not signed.
+	webhookSecret = new(secretValue)
 )
 
+// secretValue is a string value that can be safely replaced while it is being read.
+type secretValue struct {
+	mtx   sync.RWMutex
+	value string
+}
+
+// Get returns the current value.
+func (s *secretValue) Get() string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.value
+}
+
+// Set replaces the current value.
+func (s *secretValue) Set(value string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.value = value
+}
+
 func setupLogging() error {
 	lCfg := logging.NewConfig(appName)
 
@@ -25,6 +25,8 @@ func main() {
 	subcommands.Register(new(versionCmd), "")
 	subcommands.Register(new(serveCmd), "")
 	subcommands.Register(new(purgeCmd), "")
+	subcommands.Register(new(fsckCmd), "")
+	subcommands.Register(new(nodeCmd), "")
 
 	flag.Parse()
 
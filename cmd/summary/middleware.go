@@ -54,10 +54,26 @@ func middlewareHttp(handler http.Handler, authOption summary.AuthOption) http.Ha
 		case summary.AuthOptionNone:
 		// Do nothing.
 		case summary.AuthOptionRequired:
-			if authToken != "" {
+			if want := authToken.Get(); want != "" {
 				// Check if the request has the correct token.
 				token := r.Context().Value(summary.BearerAuthScopes)
-				if token == nil || len(token.(string)) == 0 || token.(string) != authToken {
+				if token == nil || len(token.(string)) == 0 || token.(string) != want {
+					w.WriteHeader(http.StatusUnauthorized)
+					if err := json.NewEncoder(w).Encode(request.NewMessage(messages.ErrUnauthorized)); err != nil {
+						slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+					}
+					return
+				}
+			}
+		case summary.AuthOptionAdmin:
+			want := adminToken.Get()
+			if want == "" {
+				want = authToken.Get()
+			}
+			if want != "" {
+				// Check if the request has the correct token.
+				token := r.Context().Value(summary.AdminAuthScopes)
+				if token == nil || len(token.(string)) == 0 || token.(string) != want {
 					w.WriteHeader(http.StatusUnauthorized)
 					if err := json.NewEncoder(w).Encode(request.NewMessage(messages.ErrUnauthorized)); err != nil {
 						slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
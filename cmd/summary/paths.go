@@ -1,5 +1,10 @@
 package main
 
+import (
+	"path"
+	"strings"
+)
+
 const (
 	pathApi = "/api"
 
@@ -8,3 +13,18 @@ const (
 	pathMetrics = "/metrics"
 	pathHealth  = "/health"
 )
+
+// cleanBasePath cleans the path the application is served under, so that it has a leading slash and no trailing slash.
+// The root is returned as an empty string, as the routes already start with a slash.
+func cleanBasePath(p string) string {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return ""
+	}
+
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return ""
+	}
+	return p
+}
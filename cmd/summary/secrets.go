@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/vault"
+	vault2 "github.com/hashicorp/vault/api"
+	"github.com/spf13/viper"
+)
+
+// vaultSecret is a secret that is read from a vault KV path and applied to the application. The secret is re-applied
+// whenever its value is rotated in vault.
+type vaultSecret struct {
+	// name is the name of the secret. This is used for the config keys vault.<name>.path and vault.<name>.key.
+	name string
+
+	// defaultKey is the key within the secret to read if vault.<name>.key is not set.
+	defaultKey string
+
+	// apply applies the value of the secret to the application.
+	apply func(ctx context.Context, value string) error
+}
+
+// vaultSecrets returns the secrets that can be sourced from vault.
+func (s *serveCmd) vaultSecrets() []*vaultSecret {
+	return []*vaultSecret{
+		{
+			name:       "auth_token",
+			defaultKey: "token",
+			apply: func(_ context.Context, value string) error {
+				authToken.Set(value)
+				return nil
+			},
+		},
+		{
+			name:       "admin_token",
+			defaultKey: "token",
+			apply: func(_ context.Context, value string) error {
+				adminToken.Set(value)
+				return nil
+			},
+		},
+		{
+			name:       "webhook",
+			defaultKey: "secret",
+			apply: func(_ context.Context, value string) error {
+				webhookSecret.Set(value)
+				return nil
+			},
+		},
+		{
+			name:       "gcs",
+			defaultKey: "credentials",
+			apply: func(ctx context.Context, value string) error {
+				if s.gcs == "" {
+					slog.Warn("GCS credentials found in vault but GCS is not enabled, ignoring")
+					return nil
+				}
+				return dataaccess.SetStorageCredentials(ctx, dataaccess.StoreTypeGCS, s.gcs, []byte(value))
+			},
+		},
+	}
+}
+
+// watchVaultSecrets reads each configured secret from vault and applies it. The secrets are then watched in the
+// background, and re-applied when they are rotated.
+func watchVaultSecrets(ctx context.Context, v *viper.Viper, vc vault.Client, secrets ...*vaultSecret) error {
+	for _, sec := range secrets {
+		path := v.GetString(fmt.Sprintf("vault.%s.path", sec.name))
+		if path == "" {
+			continue
+		}
+
+		key := v.GetString(fmt.Sprintf("vault.%s.key", sec.name))
+		if key == "" {
+			key = sec.defaultKey
+		}
+
+		vs, value, err := readVaultSecret(vc, path, key)
+		if err != nil {
+			return fmt.Errorf("error reading %s secret: %w", sec.name, err)
+		}
+
+		if err := sec.apply(ctx, value); err != nil {
+			return fmt.Errorf("error applying %s secret: %w", sec.name, err)
+		}
+
+		slog.Debug("Secret retrieved from vault", slog.String("secret", sec.name))
+
+		go func(sec *vaultSecret, vs *vault.Secrets, current string) {
+			err := vc.RenewLease(ctx, sec.name, vs.Secret, func() (*vault2.Secret, error) {
+				newVs, value, err := readVaultSecret(vc, path, key)
+				if err != nil {
+					return nil, fmt.Errorf("error reading %s secret: %w", sec.name, err)
+				}
+
+				// Only re-apply the secret if it has been rotated.
+				if value != current {
+					if err := sec.apply(ctx, value); err != nil {
+						return nil, fmt.Errorf("error applying %s secret: %w", sec.name, err)
+					}
+					current = value
+					slog.Info("Secret rotated", slog.String("secret", sec.name))
+				}
+
+				return newVs.Secret, nil
+			})
+			if err != nil {
+				// The last applied value is kept, so the application can carry on serving with it.
+				slog.Error("Error watching vault secret", slog.String("secret", sec.name),
+					slog.String(logging.KeyError, err.Error()))
+			}
+		}(sec, vs, value)
+	}
+
+	return nil
+}
+
+// readVaultSecret reads the value of the given key from the secret at the given path.
+func readVaultSecret(vc vault.Client, path, key string) (*vault.Secrets, string, error) {
+	vs, err := vc.GetSecrets(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting secrets from vault: %w", err)
+	}
+
+	value, ok := vs.GetString(key)
+	if !ok {
+		return nil, "", fmt.Errorf("key %q not found in secret %s", key, path)
+	}
+
+	return vs, value, nil
+}
@@ -24,6 +24,7 @@ require (
 	go.mongodb.org/mongo-driver v1.17.2
 	golang.org/x/time v0.6.0
 	google.golang.org/api v0.199.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -105,5 +106,4 @@ require (
 	google.golang.org/protobuf v1.34.2 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -16,24 +16,99 @@ import (
 
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
+	// Get the status of the scheduled jobs
+	// (GET /admin/jobs)
+	GetScheduledJobs(w http.ResponseWriter, r *http.Request)
+	// Get the runtime anomalies
+	// (GET /anomalies)
+	GetAnomalies(w http.ResponseWriter, r *http.Request, params GetAnomaliesParams)
+	// Get all environments
+	// (GET /environments)
+	GetEnvironments(w http.ResponseWriter, r *http.Request, params GetEnvironmentsParams)
+	// Stream the ingested reports
+	// (GET /events)
+	GetEvents(w http.ResponseWriter, r *http.Request, params GetEventsParams)
+	// Get the failing resources leaderboard
+	// (GET /failures)
+	GetFailureLeaderboard(w http.ResponseWriter, r *http.Request, params GetFailureLeaderboardParams)
+	// Get the nodes failing on resources
+	// (GET /failures/nodes)
+	GetFailureNodes(w http.ResponseWriter, r *http.Request, params GetFailureNodesParams)
+	// Get the history of the runs
+	// (GET /history)
+	GetHistory(w http.ResponseWriter, r *http.Request, params GetHistoryParams)
+	// Get the incidents
+	// (GET /incidents)
+	GetIncidents(w http.ResponseWriter, r *http.Request, params GetIncidentsParams)
+	// Get an incident
+	// (GET /incidents/{id})
+	GetIncident(w http.ResponseWriter, r *http.Request, id string)
+	// Cancel a background job by id
+	// (DELETE /jobs/{id})
+	CancelJob(w http.ResponseWriter, r *http.Request, id string)
+	// Get a background job by id
+	// (GET /jobs/{id})
+	GetJob(w http.ResponseWriter, r *http.Request, id string)
 	// Get all nodes
 	// (GET /nodes)
-	GetAllNodes(w http.ResponseWriter, r *http.Request)
+	GetAllNodes(w http.ResponseWriter, r *http.Request, params GetAllNodesParams)
 	// Get all nodes by environment
 	// (GET /nodes/enviroment/{env})
-	GetAllNodesByEnvironment(w http.ResponseWriter, r *http.Request, env Environment)
+	GetAllNodesByEnvironment(w http.ResponseWriter, r *http.Request, env Environment, params GetAllNodesByEnvironmentParams)
+	// Get the flapping nodes
+	// (GET /nodes/flapping)
+	GetFlappingNodes(w http.ResponseWriter, r *http.Request, params GetFlappingNodesParams)
+	// Delete or decommission a node by fqdn
+	// (DELETE /nodes/{fqdn})
+	DeleteNode(w http.ResponseWriter, r *http.Request, fqdn string, params DeleteNodeParams)
 	// Get a node by fqdn
 	// (GET /nodes/{fqdn})
 	GetNodeByFqdn(w http.ResponseWriter, r *http.Request, fqdn string)
+	// Remove the acknowledgement of a node
+	// (DELETE /nodes/{fqdn}/ack)
+	UnacknowledgeNode(w http.ResponseWriter, r *http.Request, fqdn string)
+	// Acknowledge the failure of a node
+	// (PUT /nodes/{fqdn}/ack)
+	AcknowledgeNode(w http.ResponseWriter, r *http.Request, fqdn string)
+	// Delete the metadata of a node
+	// (DELETE /nodes/{fqdn}/metadata)
+	DeleteNodeMetadata(w http.ResponseWriter, r *http.Request, fqdn string)
+	// Get the metadata of a node
+	// (GET /nodes/{fqdn}/metadata)
+	GetNodeMetadata(w http.ResponseWriter, r *http.Request, fqdn string)
+	// Set the metadata of a node
+	// (PUT /nodes/{fqdn}/metadata)
+	PutNodeMetadata(w http.ResponseWriter, r *http.Request, fqdn string)
 	// Purge Puppet Reports from a specified date
 	// (DELETE /purge)
 	PurgePuppetReports(w http.ResponseWriter, r *http.Request)
+	// Preview the Puppet Reports that would be purged from a specified date
+	// (GET /purge/preview)
+	PreviewPurgePuppetReports(w http.ResponseWriter, r *http.Request, params PreviewPurgePuppetReportsParams)
 	// Get a report by id
 	// (GET /reports/{id})
 	GetReportById(w http.ResponseWriter, r *http.Request, id string)
+	// Get the rollout of the code in an environment
+	// (GET /rollout/{env})
+	GetRollout(w http.ResponseWriter, r *http.Request, env Environment, params GetRolloutParams)
+	// Get the silences
+	// (GET /silences)
+	GetSilences(w http.ResponseWriter, r *http.Request, params GetSilencesParams)
+	// Create a silence
+	// (POST /silences)
+	CreateSilence(w http.ResponseWriter, r *http.Request)
+	// Delete a silence
+	// (DELETE /silences/{id})
+	DeleteSilence(w http.ResponseWriter, r *http.Request, id string)
 	// Get all nodes by state
 	// (GET /states/{state})
-	GetAllNodesByState(w http.ResponseWriter, r *http.Request, state State)
+	GetAllNodesByState(w http.ResponseWriter, r *http.Request, state State, params GetAllNodesByStateParams)
+	// Get the summary of the fleet
+	// (GET /summary)
+	GetFleetSummary(w http.ResponseWriter, r *http.Request, params GetFleetSummaryParams)
+	// Get the breakdown of the time of the runs
+	// (GET /timings)
+	GetTimings(w http.ResponseWriter, r *http.Request, params GetTimingsParams)
 	// Upload a puppet report
 	// (POST /upload)
 	UploadPuppetReport(w http.ResponseWriter, r *http.Request)
@@ -50,25 +125,1154 @@ const (
 
 	// AuthOptionRequired is the option for required authentication.
 	AuthOptionRequired
+
+	// AuthOptionAdmin is the option for required admin authentication.
+	AuthOptionAdmin
 )
 
-// ServerInterfaceWrapper converts contexts to parameters.
-type ServerInterfaceWrapper struct {
-	Handler            ServerInterface
-	HandlerMiddlewares []MiddlewareFunc
-	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+// ServerInterfaceWrapper converts contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler            ServerInterface
+	HandlerMiddlewares []MiddlewareFunc
+	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type MiddlewareFunc func(http.Handler, AuthOption) http.HandlerFunc
+
+// GetScheduledJobs operation middleware
+func (siw *ServerInterfaceWrapper) GetScheduledJobs(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	token := r.Header.Get("Authorization")
+	token = strings.TrimPrefix(token, "Bearer ")
+	ctx = context.WithValue(ctx, AdminAuthScopes, token)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetScheduledJobs(cw, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionAdmin
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetAnomalies operation middleware
+func (siw *ServerInterfaceWrapper) GetAnomalies(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAnomaliesParams
+
+	// ------------- Optional query parameter "env" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "env", r.URL.Query(), &params.Env)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "env", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "fqdn" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "fqdn", r.URL.Query(), &params.Fqdn)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "fqdn", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAnomalies(cw, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetEnvironments operation middleware
+func (siw *ServerInterfaceWrapper) GetEnvironments(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetEnvironmentsParams
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetEnvironments(cw, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetEvents operation middleware
+func (siw *ServerInterfaceWrapper) GetEvents(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetEventsParams
+
+	// ------------- Optional query parameter "fqdn" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "fqdn", r.URL.Query(), &params.Fqdn)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "fqdn", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "env" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "env", r.URL.Query(), &params.Env)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "env", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "state" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "state", r.URL.Query(), &params.State)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "state", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetEvents(cw, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetFailureLeaderboard operation middleware
+func (siw *ServerInterfaceWrapper) GetFailureLeaderboard(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetFailureLeaderboardParams
+
+	// ------------- Optional query parameter "env" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "env", r.URL.Query(), &params.Env)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "env", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "limit" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "limit", r.URL.Query(), &params.Limit)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "limit", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "metric" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "metric", r.URL.Query(), &params.Metric)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "metric", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetFailureLeaderboard(cw, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetFailureNodes operation middleware
+func (siw *ServerInterfaceWrapper) GetFailureNodes(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetFailureNodesParams
+
+	// ------------- Optional query parameter "type" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "type", r.URL.Query(), &params.Type)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "type", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "name" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "name", r.URL.Query(), &params.Name)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "name", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "file" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "file", r.URL.Query(), &params.File)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "file", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "line" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "line", r.URL.Query(), &params.Line)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "line", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "env" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "env", r.URL.Query(), &params.Env)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "env", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetFailureNodes(cw, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetHistory operation middleware
+func (siw *ServerInterfaceWrapper) GetHistory(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetHistoryParams
+
+	// ------------- Optional query parameter "env" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "env", r.URL.Query(), &params.Env)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "env", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "bucket" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "bucket", r.URL.Query(), &params.Bucket)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "bucket", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "metric" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "metric", r.URL.Query(), &params.Metric)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "metric", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetHistory(cw, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetIncidents operation middleware
+func (siw *ServerInterfaceWrapper) GetIncidents(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetIncidentsParams
+
+	// ------------- Optional query parameter "env" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "env", r.URL.Query(), &params.Env)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "env", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetIncidents(cw, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetIncident operation middleware
+func (siw *ServerInterfaceWrapper) GetIncident(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", mux.Vars(r)["id"], &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetIncident(cw, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// CancelJob operation middleware
+func (siw *ServerInterfaceWrapper) CancelJob(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", mux.Vars(r)["id"], &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	token := r.Header.Get("Authorization")
+	token = strings.TrimPrefix(token, "Bearer ")
+	ctx = context.WithValue(ctx, BearerAuthScopes, token)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.CancelJob(cw, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionRequired
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetJob operation middleware
+func (siw *ServerInterfaceWrapper) GetJob(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", mux.Vars(r)["id"], &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
+	token := r.Header.Get("Authorization")
+	token = strings.TrimPrefix(token, "Bearer ")
+	ctx = context.WithValue(ctx, BearerAuthScopes, token)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetJob(cw, r, id)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionRequired
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetAllNodes operation middleware
+func (siw *ServerInterfaceWrapper) GetAllNodes(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAllNodesParams
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAllNodes(cw, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetAllNodesByEnvironment operation middleware
+func (siw *ServerInterfaceWrapper) GetAllNodesByEnvironment(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "env" -------------
+	var env Environment
+
+	err = runtime.BindStyledParameterWithOptions("simple", "env", mux.Vars(r)["env"], &env, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "env", Err: err})
+		return
+	}
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAllNodesByEnvironmentParams
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAllNodesByEnvironment(cw, r, env, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetFlappingNodes operation middleware
+func (siw *ServerInterfaceWrapper) GetFlappingNodes(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetFlappingNodesParams
+
+	// ------------- Optional query parameter "window" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "window", r.URL.Query(), &params.Window)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "window", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "threshold" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "threshold", r.URL.Query(), &params.Threshold)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "threshold", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetFlappingNodes(cw, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// DeleteNode operation middleware
+func (siw *ServerInterfaceWrapper) DeleteNode(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "fqdn" -------------
+	var fqdn string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fqdn", mux.Vars(r)["fqdn"], &fqdn, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "fqdn", Err: err})
+		return
+	}
+
+	token := r.Header.Get("Authorization")
+	token = strings.TrimPrefix(token, "Bearer ")
+	ctx = context.WithValue(ctx, AdminAuthScopes, token)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params DeleteNodeParams
+
+	// ------------- Optional query parameter "decommission" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "decommission", r.URL.Query(), &params.Decommission)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "decommission", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteNode(cw, r, fqdn, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionAdmin
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetNodeByFqdn operation middleware
+func (siw *ServerInterfaceWrapper) GetNodeByFqdn(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "fqdn" -------------
+	var fqdn string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fqdn", mux.Vars(r)["fqdn"], &fqdn, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "fqdn", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetNodeByFqdn(cw, r, fqdn)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// UnacknowledgeNode operation middleware
+func (siw *ServerInterfaceWrapper) UnacknowledgeNode(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "fqdn" -------------
+	var fqdn string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fqdn", mux.Vars(r)["fqdn"], &fqdn, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "fqdn", Err: err})
+		return
+	}
+
+	token := r.Header.Get("Authorization")
+	token = strings.TrimPrefix(token, "Bearer ")
+	ctx = context.WithValue(ctx, BearerAuthScopes, token)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.UnacknowledgeNode(cw, r, fqdn)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionRequired
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// AcknowledgeNode operation middleware
+func (siw *ServerInterfaceWrapper) AcknowledgeNode(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "fqdn" -------------
+	var fqdn string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fqdn", mux.Vars(r)["fqdn"], &fqdn, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "fqdn", Err: err})
+		return
+	}
+
+	token := r.Header.Get("Authorization")
+	token = strings.TrimPrefix(token, "Bearer ")
+	ctx = context.WithValue(ctx, BearerAuthScopes, token)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.AcknowledgeNode(cw, r, fqdn)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionRequired
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// DeleteNodeMetadata operation middleware
+func (siw *ServerInterfaceWrapper) DeleteNodeMetadata(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "fqdn" -------------
+	var fqdn string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fqdn", mux.Vars(r)["fqdn"], &fqdn, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "fqdn", Err: err})
+		return
+	}
+
+	token := r.Header.Get("Authorization")
+	token = strings.TrimPrefix(token, "Bearer ")
+	ctx = context.WithValue(ctx, BearerAuthScopes, token)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.DeleteNodeMetadata(cw, r, fqdn)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionRequired
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetNodeMetadata operation middleware
+func (siw *ServerInterfaceWrapper) GetNodeMetadata(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "fqdn" -------------
+	var fqdn string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fqdn", mux.Vars(r)["fqdn"], &fqdn, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "fqdn", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetNodeMetadata(cw, r, fqdn)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// PutNodeMetadata operation middleware
+func (siw *ServerInterfaceWrapper) PutNodeMetadata(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// ------------- Path parameter "fqdn" -------------
+	var fqdn string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "fqdn", mux.Vars(r)["fqdn"], &fqdn, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "fqdn", Err: err})
+		return
+	}
+
+	token := r.Header.Get("Authorization")
+	token = strings.TrimPrefix(token, "Bearer ")
+	ctx = context.WithValue(ctx, BearerAuthScopes, token)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PutNodeMetadata(cw, r, fqdn)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionRequired
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
 }
 
-type MiddlewareFunc func(http.Handler, AuthOption) http.HandlerFunc
+// PurgePuppetReports operation middleware
+func (siw *ServerInterfaceWrapper) PurgePuppetReports(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
 
-// GetAllNodes operation middleware
-func (siw *ServerInterfaceWrapper) GetAllNodes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	token := r.Header.Get("Authorization")
+	token = strings.TrimPrefix(token, "Bearer ")
+	ctx = context.WithValue(ctx, BearerAuthScopes, token)
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PurgePuppetReports(cw, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionRequired
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// PreviewPurgePuppetReports operation middleware
+func (siw *ServerInterfaceWrapper) PreviewPurgePuppetReports(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	token := r.Header.Get("Authorization")
+	token = strings.TrimPrefix(token, "Bearer ")
+	ctx = context.WithValue(ctx, BearerAuthScopes, token)
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params PreviewPurgePuppetReportsParams
+
+	// ------------- Required query parameter "date" -------------
+
+	if paramValue := r.URL.Query().Get("date"); paramValue != "" {
+
+	} else {
+		siw.ErrorHandlerFunc(cw, r, &RequiredParamError{ParamName: "date"})
+		return
+	}
+
+	err = runtime.BindQueryParameter("form", true, true, "date", r.URL.Query(), &params.Date)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "date", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.PreviewPurgePuppetReports(cw, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionRequired
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetReportById operation middleware
+func (siw *ServerInterfaceWrapper) GetReportById(w http.ResponseWriter, r *http.Request) {
 	cw := request.NewClientWriter(w)
 
 	ctx := r.Context()
 
+	var err error
+
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", mux.Vars(r)["id"], &id, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "id", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetAllNodes(cw, r)
+		siw.Handler.GetReportById(cw, r, id)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -82,8 +1286,8 @@ func (siw *ServerInterfaceWrapper) GetAllNodes(w http.ResponseWriter, r *http.Re
 	handler.ServeHTTP(cw, r.WithContext(ctx))
 }
 
-// GetAllNodesByEnvironment operation middleware
-func (siw *ServerInterfaceWrapper) GetAllNodesByEnvironment(w http.ResponseWriter, r *http.Request) {
+// GetRollout operation middleware
+func (siw *ServerInterfaceWrapper) GetRollout(w http.ResponseWriter, r *http.Request) {
 	cw := request.NewClientWriter(w)
 
 	ctx := r.Context()
@@ -99,8 +1303,35 @@ func (siw *ServerInterfaceWrapper) GetAllNodesByEnvironment(w http.ResponseWrite
 		return
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetRolloutParams
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "bucket" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "bucket", r.URL.Query(), &params.Bucket)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "bucket", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetAllNodesByEnvironment(cw, r, env)
+		siw.Handler.GetRollout(cw, r, env, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -114,25 +1345,27 @@ func (siw *ServerInterfaceWrapper) GetAllNodesByEnvironment(w http.ResponseWrite
 	handler.ServeHTTP(cw, r.WithContext(ctx))
 }
 
-// GetNodeByFqdn operation middleware
-func (siw *ServerInterfaceWrapper) GetNodeByFqdn(w http.ResponseWriter, r *http.Request) {
+// GetSilences operation middleware
+func (siw *ServerInterfaceWrapper) GetSilences(w http.ResponseWriter, r *http.Request) {
 	cw := request.NewClientWriter(w)
 
 	ctx := r.Context()
 
 	var err error
 
-	// ------------- Path parameter "fqdn" -------------
-	var fqdn string
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetSilencesParams
 
-	err = runtime.BindStyledParameterWithOptions("simple", "fqdn", mux.Vars(r)["fqdn"], &fqdn, runtime.BindStyledParameterOptions{Explode: false, Required: true})
+	// ------------- Optional query parameter "expired" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "expired", r.URL.Query(), &params.Expired)
 	if err != nil {
-		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "fqdn", Err: err})
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "expired", Err: err})
 		return
 	}
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetNodeByFqdn(cw, r, fqdn)
+		siw.Handler.GetSilences(cw, r, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -146,8 +1379,8 @@ func (siw *ServerInterfaceWrapper) GetNodeByFqdn(w http.ResponseWriter, r *http.
 	handler.ServeHTTP(cw, r.WithContext(ctx))
 }
 
-// PurgePuppetReports operation middleware
-func (siw *ServerInterfaceWrapper) PurgePuppetReports(w http.ResponseWriter, r *http.Request) {
+// CreateSilence operation middleware
+func (siw *ServerInterfaceWrapper) CreateSilence(w http.ResponseWriter, r *http.Request) {
 	cw := request.NewClientWriter(w)
 
 	ctx := r.Context()
@@ -157,7 +1390,7 @@ func (siw *ServerInterfaceWrapper) PurgePuppetReports(w http.ResponseWriter, r *
 	ctx = context.WithValue(ctx, BearerAuthScopes, token)
 
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.PurgePuppetReports(cw, r)
+		siw.Handler.CreateSilence(cw, r)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -171,8 +1404,8 @@ func (siw *ServerInterfaceWrapper) PurgePuppetReports(w http.ResponseWriter, r *
 	handler.ServeHTTP(cw, r.WithContext(ctx))
 }
 
-// GetReportById operation middleware
-func (siw *ServerInterfaceWrapper) GetReportById(w http.ResponseWriter, r *http.Request) {
+// DeleteSilence operation middleware
+func (siw *ServerInterfaceWrapper) DeleteSilence(w http.ResponseWriter, r *http.Request) {
 	cw := request.NewClientWriter(w)
 
 	ctx := r.Context()
@@ -188,14 +1421,18 @@ func (siw *ServerInterfaceWrapper) GetReportById(w http.ResponseWriter, r *http.
 		return
 	}
 
+	token := r.Header.Get("Authorization")
+	token = strings.TrimPrefix(token, "Bearer ")
+	ctx = context.WithValue(ctx, BearerAuthScopes, token)
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetReportById(cw, r, id)
+		siw.Handler.DeleteSilence(cw, r, id)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
 		// Check to see what kind of authentication is required
 
-		opt := AuthOptionNone
+		opt := AuthOptionRequired
 
 		handler = middleware(handler, opt)
 	}
@@ -220,8 +1457,135 @@ func (siw *ServerInterfaceWrapper) GetAllNodesByState(w http.ResponseWriter, r *
 		return
 	}
 
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetAllNodesByStateParams
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetAllNodesByState(cw, r, state, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetFleetSummary operation middleware
+func (siw *ServerInterfaceWrapper) GetFleetSummary(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetFleetSummaryParams
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.GetFleetSummary(cw, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		// Check to see what kind of authentication is required
+
+		opt := AuthOptionNone
+
+		handler = middleware(handler, opt)
+	}
+
+	handler.ServeHTTP(cw, r.WithContext(ctx))
+}
+
+// GetTimings operation middleware
+func (siw *ServerInterfaceWrapper) GetTimings(w http.ResponseWriter, r *http.Request) {
+	cw := request.NewClientWriter(w)
+
+	ctx := r.Context()
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params GetTimingsParams
+
+	// ------------- Optional query parameter "env" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "env", r.URL.Query(), &params.Env)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "env", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "from" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "from", r.URL.Query(), &params.From)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "from", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "to" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "to", r.URL.Query(), &params.To)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "to", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "owner" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "owner", r.URL.Query(), &params.Owner)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "owner", Err: err})
+		return
+	}
+
+	// ------------- Optional query parameter "label" -------------
+
+	err = runtime.BindQueryParameter("form", true, false, "label", r.URL.Query(), &params.Label)
+	if err != nil {
+		siw.ErrorHandlerFunc(cw, r, &InvalidParamFormatError{ParamName: "label", Err: err})
+		return
+	}
+
 	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		siw.Handler.GetAllNodesByState(cw, r, state)
+		siw.Handler.GetTimings(cw, r, params)
 	}))
 
 	for _, middleware := range siw.HandlerMiddlewares {
@@ -373,18 +1737,68 @@ func HandlerWithOptions(si ServerInterface, options GorillaServerOptions) http.H
 		ErrorHandlerFunc:   options.ErrorHandlerFunc,
 	}
 
+	r.HandleFunc(options.BaseURL+"/admin/jobs", wrapper.GetScheduledJobs).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/anomalies", wrapper.GetAnomalies).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/environments", wrapper.GetEnvironments).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/events", wrapper.GetEvents).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/failures", wrapper.GetFailureLeaderboard).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/failures/nodes", wrapper.GetFailureNodes).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/history", wrapper.GetHistory).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/incidents", wrapper.GetIncidents).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/incidents/{id}", wrapper.GetIncident).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/jobs/{id}", wrapper.CancelJob).Methods("DELETE")
+
+	r.HandleFunc(options.BaseURL+"/jobs/{id}", wrapper.GetJob).Methods("GET")
+
 	r.HandleFunc(options.BaseURL+"/nodes", wrapper.GetAllNodes).Methods("GET")
 
 	r.HandleFunc(options.BaseURL+"/nodes/enviroment/{env}", wrapper.GetAllNodesByEnvironment).Methods("GET")
 
+	r.HandleFunc(options.BaseURL+"/nodes/flapping", wrapper.GetFlappingNodes).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/nodes/{fqdn}", wrapper.DeleteNode).Methods("DELETE")
+
 	r.HandleFunc(options.BaseURL+"/nodes/{fqdn}", wrapper.GetNodeByFqdn).Methods("GET")
 
+	r.HandleFunc(options.BaseURL+"/nodes/{fqdn}/ack", wrapper.UnacknowledgeNode).Methods("DELETE")
+
+	r.HandleFunc(options.BaseURL+"/nodes/{fqdn}/ack", wrapper.AcknowledgeNode).Methods("PUT")
+
+	r.HandleFunc(options.BaseURL+"/nodes/{fqdn}/metadata", wrapper.DeleteNodeMetadata).Methods("DELETE")
+
+	r.HandleFunc(options.BaseURL+"/nodes/{fqdn}/metadata", wrapper.GetNodeMetadata).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/nodes/{fqdn}/metadata", wrapper.PutNodeMetadata).Methods("PUT")
+
 	r.HandleFunc(options.BaseURL+"/purge", wrapper.PurgePuppetReports).Methods("DELETE")
 
+	r.HandleFunc(options.BaseURL+"/purge/preview", wrapper.PreviewPurgePuppetReports).Methods("GET")
+
 	r.HandleFunc(options.BaseURL+"/reports/{id}", wrapper.GetReportById).Methods("GET")
 
+	r.HandleFunc(options.BaseURL+"/rollout/{env}", wrapper.GetRollout).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/silences", wrapper.GetSilences).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/silences", wrapper.CreateSilence).Methods("POST")
+
+	r.HandleFunc(options.BaseURL+"/silences/{id}", wrapper.DeleteSilence).Methods("DELETE")
+
 	r.HandleFunc(options.BaseURL+"/states/{state}", wrapper.GetAllNodesByState).Methods("GET")
 
+	r.HandleFunc(options.BaseURL+"/summary", wrapper.GetFleetSummary).Methods("GET")
+
+	r.HandleFunc(options.BaseURL+"/timings", wrapper.GetTimings).Methods("GET")
+
 	r.HandleFunc(options.BaseURL+"/upload", wrapper.UploadPuppetReport).Methods("POST")
 
 	return r
@@ -10,6 +10,7 @@ import (
 )
 
 const (
+	AdminAuthScopes  = "adminAuth.Scopes"
 	BearerAuthScopes = "bearerAuth.Scopes"
 )
 
@@ -25,6 +26,18 @@ type Resource struct {
 	Type *string `json:"type,omitempty"`
 }
 
+// AcknowledgementInput defines the model for acknowledgementInput.
+type AcknowledgementInput struct {
+	// By Who is handling the failure.
+	By string `json:"by"`
+
+	// ExpiresAt The time the acknowledgement expires.
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// Reason Why the failure is acknowledged.
+	Reason string `json:"reason"`
+}
+
 // Environment defines the model for environment.
 type Environment string
 
@@ -56,11 +69,345 @@ func (t Environment) IsValid() bool {
 	return t.IsIn(Environments...)
 }
 
+// EnvironmentSummary defines the model for environmentSummary.
+type EnvironmentSummary struct {
+	// Changed The number of nodes whose latest report changed.
+	Changed *int `json:"changed,omitempty"`
+
+	// Env The environment that a machine is reporting from.
+	Env *Environment `json:"env,omitempty"`
+
+	// Failed The number of nodes whose latest report failed.
+	Failed *int `json:"failed,omitempty"`
+
+	// Nodes The number of nodes in the environment.
+	Nodes *int `json:"nodes,omitempty"`
+
+	// Skipped The number of nodes whose latest report was skipped.
+	Skipped *int `json:"skipped,omitempty"`
+
+	// Stale The number of nodes that have not reported within the stale threshold.
+	Stale *int `json:"stale,omitempty"`
+
+	// Unchanged The number of nodes whose latest report was unchanged.
+	Unchanged *int `json:"unchanged,omitempty"`
+}
+
+// Event A report that has been ingested.
+type Event struct {
+	Changed *int `json:"changed,omitempty"`
+
+	// Env The environment that a machine is reporting from.
+	Env      *Environment `json:"env,omitempty"`
+	ExecTime *time.Time   `json:"exec_time,omitempty"`
+	Failed   *int         `json:"failed,omitempty"`
+	Fqdn     *string      `json:"fqdn,omitempty"`
+	Id       *string      `json:"id,omitempty"`
+	Runtime  *string      `json:"runtime,omitempty"`
+
+	// State The estate of the machine from the report.
+	State *State `json:"state,omitempty"`
+	Total *int   `json:"total,omitempty"`
+}
+
+// FailureEntry defines the model for failureEntry.
+type FailureEntry struct {
+	// Failures The number of reports the resources failed in.
+	Failures *int `json:"failures,omitempty"`
+
+	// File The manifest the resources are declared in. Only set for the manifest locations.
+	File *string `json:"file,omitempty"`
+
+	// LastFailed The time of the latest report the resources failed in.
+	LastFailed *time.Time `json:"last_failed,omitempty"`
+
+	// Line The line of the manifest the resources are declared on. Only set for the manifest locations.
+	Line *string `json:"line,omitempty"`
+
+	// Name The title of the resources. Only set for the resources.
+	Name *string `json:"name,omitempty"`
+
+	// Nodes The number of nodes the resources failed on.
+	Nodes *int `json:"nodes,omitempty"`
+
+	// Type The type of the resources. Not set for the manifest locations.
+	Type *string `json:"type,omitempty"`
+}
+
+// FailureLeaderboard defines the model for failureLeaderboard.
+type FailureLeaderboard struct {
+	// From The start of the window, inclusive.
+	From *time.Time `json:"from,omitempty"`
+
+	// Locations The manifest locations that failed in the most reports.
+	Locations *[]FailureEntry `json:"locations,omitempty"`
+
+	// Nodes The nodes with the highest sums of the requested metrics. Only set if any metrics are requested.
+	Nodes *[]MetricNode `json:"nodes,omitempty"`
+
+	// Resources The resources that failed in the most reports.
+	Resources *[]FailureEntry `json:"resources,omitempty"`
+
+	// To The end of the window, exclusive.
+	To *time.Time `json:"to,omitempty"`
+
+	// Types The resource types that failed in the most reports.
+	Types *[]FailureEntry `json:"types,omitempty"`
+}
+
+// FailureNode defines the model for failureNode.
+type FailureNode struct {
+	// Env The environment that a machine is reporting from.
+	Env *Environment `json:"env,omitempty"`
+
+	// Failures The number of reports the resources failed in.
+	Failures *int    `json:"failures,omitempty"`
+	Fqdn     *string `json:"fqdn,omitempty"`
+
+	// Reports The reports the resources failed in, newest first.
+	Reports *[]FailureReport `json:"reports,omitempty"`
+}
+
+// FailureReport defines the model for failureReport.
+type FailureReport struct {
+	ExecTime *time.Time `json:"exec_time,omitempty"`
+	Id       *string    `json:"id,omitempty"`
+
+	// Resources The resources that failed in the report.
+	Resources *[]Resource `json:"resources,omitempty"`
+}
+
+// FlappingNode defines the model for flappingNode.
+type FlappingNode struct {
+	// ChangingResources The resources changed by every scored run that changed any resource.
+	ChangingResources *[]Resource `json:"changing_resources,omitempty"`
+
+	// Env The environment that a machine is reporting from.
+	Env  *Environment `json:"env,omitempty"`
+	Fqdn *string      `json:"fqdn,omitempty"`
+
+	// Runs The scored runs, oldest first.
+	Runs *[]PuppetReportSummary `json:"runs,omitempty"`
+
+	// Score The share of the scored runs whose state differs from the run before, between 0 and 1.
+	Score *float64 `json:"score,omitempty"`
+
+	// Transitions The number of times the state changed between the scored runs.
+	Transitions *int `json:"transitions,omitempty"`
+}
+
+// FleetSummary defines the model for fleetSummary.
+type FleetSummary struct {
+	// Changed The number of nodes whose latest report changed.
+	Changed *int `json:"changed,omitempty"`
+
+	// Failing The number of nodes whose latest report failed.
+	Failing *int `json:"failing,omitempty"`
+
+	// MedianRuntime The median runtime of the latest report of the nodes.
+	MedianRuntime *string `json:"median_runtime,omitempty"`
+
+	// Nodes The number of nodes.
+	Nodes *int `json:"nodes,omitempty"`
+
+	// ReportsLast24h The number of reports received in the last 24 hours.
+	ReportsLast24h *int `json:"reports_last_24h,omitempty"`
+
+	// Stale The number of nodes that have not reported within the stale threshold.
+	Stale *int `json:"stale,omitempty"`
+
+	// Unchanged The number of nodes whose latest report was unchanged.
+	Unchanged *int `json:"unchanged,omitempty"`
+}
+
+// History defines the model for history.
+type History struct {
+	// Changed The number of runs that changed.
+	Changed *int `json:"changed,omitempty"`
+
+	// Date The start of the bucket. A date for day and week buckets, which start on a Monday, or a date and hour for hour buckets.
+	Date *string `json:"date,omitempty"`
+
+	// Failed The number of runs that failed.
+	Failed *int `json:"failed,omitempty"`
+
+	// Metrics The sums of the requested metrics over the runs, keyed as group.name.
+	Metrics *map[string]float64 `json:"metrics,omitempty"`
+
+	// Unchanged The number of runs that were unchanged.
+	Unchanged *int `json:"unchanged,omitempty"`
+}
+
+// HistoryBucket defines the model for historyBucket.
+type HistoryBucket string
+
+// List of HistoryBucket
+const (
+	HistoryBucket_day  HistoryBucket = "day"
+	HistoryBucket_hour HistoryBucket = "hour"
+	HistoryBucket_week HistoryBucket = "week"
+)
+
+var HistoryBuckets = []HistoryBucket{
+	HistoryBucket_day,
+	HistoryBucket_hour,
+	HistoryBucket_week,
+}
+
+// IsIn checks if the value is in the list of HistoryBucket
+func (t HistoryBucket) IsIn(values ...HistoryBucket) bool {
+	for _, v := range values {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid checks if the value is valid
+func (t HistoryBucket) IsValid() bool {
+	return t.IsIn(HistoryBuckets...)
+}
+
+// Incident defines the model for incident.
+type Incident struct {
+	// ConfigurationVersion The configuration version the failed runs applied.
+	ConfigurationVersion *string      `json:"configuration_version,omitempty"`
+	Env                  *Environment `json:"env,omitempty"`
+
+	// FirstSeen The time of the earliest failed run.
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+
+	// Id The ID of the incident, which is the ID of the report that opened it.
+	Id *string `json:"id,omitempty"`
+
+	// LastSeen The time of the latest failed run.
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+
+	// Message A log message representative of the failure.
+	Message *string `json:"message,omitempty"`
+
+	// NodeCount The number of nodes that failed.
+	NodeCount *int `json:"node_count,omitempty"`
+
+	// Nodes The FQDNs of the nodes that failed.
+	Nodes *[]string `json:"nodes,omitempty"`
+
+	// Notified Whether the incident has been notified to the webhook.
+	Notified *bool `json:"notified,omitempty"`
+
+	// Reports The number of failed runs.
+	Reports *int `json:"reports,omitempty"`
+
+	// Resources The resources that failed in the report that opened the incident, as Type[title].
+	Resources *[]string `json:"resources,omitempty"`
+}
+
+// Job defines the model for job.
+type Job struct {
+	// Error The error returned by the job, if it failed.
+	Error *string `json:"error,omitempty"`
+
+	// FinishedAt The time the job finished.
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Id         *string    `json:"id,omitempty"`
+
+	// Instance The instance running the job. Only this instance knows of the job.
+	Instance *string `json:"instance,omitempty"`
+
+	// Progress The progress counters of the job.
+	Progress *map[string]int `json:"progress,omitempty"`
+
+	// StartedAt The time the job was started.
+	StartedAt *time.Time `json:"started_at,omitempty"`
+
+	// Status The status of a background job.
+	Status *JobStatus `json:"status,omitempty"`
+	Type   *string    `json:"type,omitempty"`
+}
+
+// JobOutcome defines the model for jobOutcome.
+type JobOutcome string
+
+// List of JobOutcome
+const (
+	JobOutcome_FAILURE JobOutcome = "FAILURE"
+	JobOutcome_SKIPPED JobOutcome = "SKIPPED"
+	JobOutcome_SUCCESS JobOutcome = "SUCCESS"
+)
+
+var JobOutcomes = []JobOutcome{
+	JobOutcome_FAILURE,
+	JobOutcome_SKIPPED,
+	JobOutcome_SUCCESS,
+}
+
+// IsIn checks if the value is in the list of JobOutcome
+func (t JobOutcome) IsIn(values ...JobOutcome) bool {
+	for _, v := range values {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid checks if the value is valid
+func (t JobOutcome) IsValid() bool {
+	return t.IsIn(JobOutcomes...)
+}
+
+// JobStatus defines the model for jobStatus.
+type JobStatus string
+
+// List of JobStatus
+const (
+	JobStatus_CANCELLED JobStatus = "CANCELLED"
+	JobStatus_FAILED    JobStatus = "FAILED"
+	JobStatus_RUNNING   JobStatus = "RUNNING"
+	JobStatus_SUCCEEDED JobStatus = "SUCCEEDED"
+)
+
+var JobStatuss = []JobStatus{
+	JobStatus_CANCELLED,
+	JobStatus_FAILED,
+	JobStatus_RUNNING,
+	JobStatus_SUCCEEDED,
+}
+
+// IsIn checks if the value is in the list of JobStatus
+func (t JobStatus) IsIn(values ...JobStatus) bool {
+	for _, v := range values {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid checks if the value is valid
+func (t JobStatus) IsValid() bool {
+	return t.IsIn(JobStatuss...)
+}
+
 // Message defines the model for message.
 type Message struct {
 	Message *string `json:"message,omitempty"`
 }
 
+// MetricNode defines the model for metricNode.
+type MetricNode struct {
+	// Env The environment that a machine is reporting from.
+	Env  *Environment `json:"env,omitempty"`
+	Fqdn *string      `json:"fqdn,omitempty"`
+
+	// Metrics The sums of the metrics over the reports of the node, keyed as group.name.
+	Metrics *map[string]float64 `json:"metrics,omitempty"`
+
+	// Reports The number of reports of the node with any of the metrics.
+	Reports *int `json:"reports,omitempty"`
+}
+
 // Node defines the model for node.
 type Node struct {
 	// Env The environment that a machine is reporting from.
@@ -79,6 +426,60 @@ type Node struct {
 	State *State `json:"state,omitempty"`
 }
 
+// NodeDeletion defines the model for nodeDeletion.
+type NodeDeletion struct {
+	// Decommissioned Whether the node was decommissioned rather than deleted.
+	Decommissioned *bool `json:"decommissioned,omitempty"`
+
+	// FilesDeleted The number of files removed from storage.
+	FilesDeleted *int `json:"files_deleted,omitempty"`
+
+	// FilesFailed The number of files that could not be removed from storage.
+	FilesFailed *int `json:"files_failed,omitempty"`
+
+	// Fqdn The Hostname of the machine.
+	Fqdn *string `json:"fqdn,omitempty"`
+
+	// ReportsDeleted The number of reports removed from the database.
+	ReportsDeleted *int `json:"reports_deleted,omitempty"`
+}
+
+// NodeMetadata defines the model for nodeMetadata.
+type NodeMetadata struct {
+	// Fqdn The Hostname of the machine.
+	Fqdn *string `json:"fqdn,omitempty"`
+
+	// Labels The key/value labels of the node.
+	Labels *map[string]string `json:"labels,omitempty"`
+
+	// Notes Free-form notes about the node.
+	Notes *string `json:"notes,omitempty"`
+
+	// Owner The team that owns the node.
+	Owner *string `json:"owner,omitempty"`
+
+	// Role The role of the node.
+	Role *string `json:"role,omitempty"`
+
+	// UpdatedAt The time the metadata was last updated.
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// NodeMetadataInput defines the model for nodeMetadataInput.
+type NodeMetadataInput struct {
+	// Labels The key/value labels of the node.
+	Labels *map[string]string `json:"labels,omitempty"`
+
+	// Notes Free-form notes about the node.
+	Notes *string `json:"notes,omitempty"`
+
+	// Owner The team that owns the node.
+	Owner *string `json:"owner,omitempty"`
+
+	// Role The role of the node.
+	Role *string `json:"role,omitempty"`
+}
+
 // NodesResponse defines the model for nodesResponse.
 type NodesResponse struct {
 	Nodes *[]Node `json:"nodes,omitempty"`
@@ -89,23 +490,29 @@ type PuppetReport struct {
 	Changed *int `json:"changed,omitempty"`
 
 	// Env The environment that a machine is reporting from.
-	Env              *Environment `json:"env,omitempty"`
-	ExecTime         *time.Time   `json:"exec_time,omitempty"`
-	Failed           *int         `json:"failed,omitempty"`
-	Fqdn             *string      `json:"fqdn,omitempty"`
-	Id               *string      `json:"id,omitempty"`
-	LogMessages      *[]string    `json:"log_messages,omitempty"`
-	PuppetVersion    *float32     `json:"puppet_version,omitempty"`
-	ResourcesChanged *[]Resource  `json:"resources_changed,omitempty"`
-	ResourcesFailed  *[]Resource  `json:"resources_failed,omitempty"`
-	ResourcesOk      *[]Resource  `json:"resources_ok,omitempty"`
-	ResourcesSkipped *[]Resource  `json:"resources_skipped,omitempty"`
-	Runtime          *string      `json:"runtime,omitempty"`
-	Skipped          *int         `json:"skipped,omitempty"`
+	Env         *Environment `json:"env,omitempty"`
+	ExecTime    *time.Time   `json:"exec_time,omitempty"`
+	Failed      *int         `json:"failed,omitempty"`
+	Fqdn        *string      `json:"fqdn,omitempty"`
+	Id          *string      `json:"id,omitempty"`
+	LogMessages *[]string    `json:"log_messages,omitempty"`
+
+	// Metrics The metrics of the run, keyed by their group, such as resources, events or changes, then their name.
+	Metrics          *map[string]map[string]float64 `json:"metrics,omitempty"`
+	PuppetVersion    *float32                       `json:"puppet_version,omitempty"`
+	ResourcesChanged *[]Resource                    `json:"resources_changed,omitempty"`
+	ResourcesFailed  *[]Resource                    `json:"resources_failed,omitempty"`
+	ResourcesOk      *[]Resource                    `json:"resources_ok,omitempty"`
+	ResourcesSkipped *[]Resource                    `json:"resources_skipped,omitempty"`
+	Runtime          *string                        `json:"runtime,omitempty"`
+	Skipped          *int                           `json:"skipped,omitempty"`
 
 	// State The estate of the machine from the report.
 	State *State `json:"state,omitempty"`
-	Total *int   `json:"total,omitempty"`
+
+	// Timings The time the run spent per resource type and phase of the run, as reported by puppet.
+	Timings *[]Timing `json:"timings,omitempty"`
+	Total   *int      `json:"total,omitempty"`
 }
 
 // PuppetReportSummary defines the model for puppetReportSummary.
@@ -126,6 +533,269 @@ type PuppetReportSummary struct {
 	Total *int   `json:"total,omitempty"`
 }
 
+// PurgePreview defines the model for purgePreview.
+type PurgePreview struct {
+	// Files The number of files that would be removed from storage.
+	Files  *int                 `json:"files,omitempty"`
+	Groups *[]PurgePreviewGroup `json:"groups,omitempty"`
+
+	// Newest The execution time of the newest report that would be removed.
+	Newest *time.Time `json:"newest,omitempty"`
+
+	// Oldest The execution time of the oldest report that would be removed.
+	Oldest *time.Time `json:"oldest,omitempty"`
+
+	// Reports The number of reports that would be removed from the database.
+	Reports *int `json:"reports,omitempty"`
+}
+
+// PurgePreviewGroup defines the model for purgePreviewGroup.
+type PurgePreviewGroup struct {
+	// Env The environment that a machine is reporting from.
+	Env *Environment `json:"env,omitempty"`
+
+	// Files The number of files that would be removed from storage.
+	Files *int `json:"files,omitempty"`
+
+	// Reports The number of reports that would be removed from the database.
+	Reports *int `json:"reports,omitempty"`
+
+	// State The estate of the machine from the report.
+	State *State `json:"state,omitempty"`
+}
+
+// Rollout defines the model for rollout.
+type Rollout struct {
+	// Bucket The size of the buckets of time the history is counted in.
+	Bucket *HistoryBucket `json:"bucket,omitempty"`
+
+	// Env The environment that a machine is reporting from.
+	Env *Environment `json:"env,omitempty"`
+
+	// Failures The runs in the window that failed on the first run of a node on a new version, newest first.
+	Failures *[]RolloutFailure `json:"failures,omitempty"`
+
+	// From The start of the window, inclusive.
+	From *time.Time `json:"from,omitempty"`
+
+	// Newest The configuration version that was first seen last. Not set if no run reported a version.
+	Newest *string `json:"newest,omitempty"`
+
+	// Nodes The nodes with the version of their latest run, those not on the newest version first.
+	Nodes *[]RolloutNode `json:"nodes,omitempty"`
+
+	// Timeline The share of the nodes on the newest version at the end of every step of the window.
+	Timeline *[]RolloutPoint `json:"timeline,omitempty"`
+
+	// To The end of the window, exclusive.
+	To *time.Time `json:"to,omitempty"`
+
+	// Versions The versions that nodes are on, or that were first seen in the window, newest first.
+	Versions *[]RolloutVersion `json:"versions,omitempty"`
+}
+
+// RolloutFailure defines the model for rolloutFailure.
+type RolloutFailure struct {
+	// ConfigurationVersion The version the run was on.
+	ConfigurationVersion *string    `json:"configuration_version,omitempty"`
+	ExecTime             *time.Time `json:"exec_time,omitempty"`
+	Fqdn                 *string    `json:"fqdn,omitempty"`
+
+	// PreviousVersion The version of the run of the node before.
+	PreviousVersion *string `json:"previous_version,omitempty"`
+	ReportId        *string `json:"report_id,omitempty"`
+}
+
+// RolloutNode defines the model for rolloutNode.
+type RolloutNode struct {
+	CodeId               *string `json:"code_id,omitempty"`
+	ConfigurationVersion *string `json:"configuration_version,omitempty"`
+
+	// ExecTime The time of the latest run.
+	ExecTime *time.Time `json:"exec_time,omitempty"`
+	Fqdn     *string    `json:"fqdn,omitempty"`
+
+	// ReportId The ID of the report of the latest run.
+	ReportId *string `json:"report_id,omitempty"`
+
+	// State The state of the puppet run.
+	State *State `json:"state,omitempty"`
+}
+
+// RolloutPoint defines the model for rolloutPoint.
+type RolloutPoint struct {
+	// Nodes The number of nodes that had run.
+	Nodes *int `json:"nodes,omitempty"`
+
+	// OnNewest The number of nodes whose latest run was on the newest version.
+	OnNewest *int `json:"on_newest,omitempty"`
+
+	// Share The share of the nodes whose latest run was on the newest version, between 0 and 1.
+	Share *float64 `json:"share,omitempty"`
+
+	// Time The time of the point. The runs before it are counted.
+	Time *time.Time `json:"time,omitempty"`
+}
+
+// RolloutVersion defines the model for rolloutVersion.
+type RolloutVersion struct {
+	// CodeId The latest code ID reported with the version. Only set by servers using static catalogs.
+	CodeId               *string `json:"code_id,omitempty"`
+	ConfigurationVersion *string `json:"configuration_version,omitempty"`
+
+	// FirstSeen The time of the first run on the version.
+	FirstSeen *time.Time `json:"first_seen,omitempty"`
+
+	// LastSeen The time of the latest run on the version.
+	LastSeen *time.Time `json:"last_seen,omitempty"`
+
+	// Nodes The number of nodes whose latest run is on the version.
+	Nodes *int `json:"nodes,omitempty"`
+
+	// Share The share of the nodes whose latest run is on the version, between 0 and 1.
+	Share *float64 `json:"share,omitempty"`
+}
+
+// RuntimeAnomaly defines the model for runtimeAnomaly.
+type RuntimeAnomaly struct {
+	// Deviation The median absolute deviation of the runtimes of the latest runs of the node before the run.
+	Deviation *string      `json:"deviation,omitempty"`
+	Env       *Environment `json:"env,omitempty"`
+
+	// ExecTime The time the run was executed.
+	ExecTime *time.Time `json:"exec_time,omitempty"`
+
+	// Fqdn The FQDN of the node.
+	Fqdn *string `json:"fqdn,omitempty"`
+
+	// Median The median runtime of the latest runs of the node before the run.
+	Median *string `json:"median,omitempty"`
+
+	// ReportId The ID of the report of the run.
+	ReportId *string `json:"report_id,omitempty"`
+
+	// Runtime The runtime of the run.
+	Runtime *string `json:"runtime,omitempty"`
+
+	// Score How many deviations the runtime is above the median.
+	Score *float64 `json:"score,omitempty"`
+}
+
+// ScheduledJob defines the model for scheduledJob.
+type ScheduledJob struct {
+	// LastDuration How long the last run of the job took.
+	LastDuration *string `json:"last_duration,omitempty"`
+
+	// LastError The error returned by the last run of the job, if it failed.
+	LastError *string `json:"last_error,omitempty"`
+
+	// LastInstance The instance that last ran the job.
+	LastInstance *string `json:"last_instance,omitempty"`
+
+	// LastOutcome The outcome of the last run of a scheduled job.
+	LastOutcome *JobOutcome `json:"last_outcome,omitempty"`
+
+	// LastRun The time the job was last triggered, on any instance.
+	LastRun *time.Time `json:"last_run,omitempty"`
+	Name    *string    `json:"name,omitempty"`
+
+	// NextRun The time the job will next be triggered.
+	NextRun  *time.Time `json:"next_run,omitempty"`
+	Schedule *string    `json:"schedule,omitempty"`
+}
+
+// ScheduledJobsResponse defines the model for scheduledJobsResponse.
+type ScheduledJobsResponse struct {
+	Jobs *[]ScheduledJob `json:"jobs,omitempty"`
+}
+
+// Silence defines the model for silence.
+type Silence struct {
+	// Active Whether the silence is in effect now.
+	Active *bool `json:"active,omitempty"`
+
+	// CreatedAt The time the silence was created.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+
+	// CreatedBy Who created the silence.
+	CreatedBy *string `json:"created_by,omitempty"`
+
+	// EndsAt The time the silence ends.
+	EndsAt *time.Time `json:"ends_at,omitempty"`
+
+	// Fqdn The Hostname of the machine silenced, if the silence is of a single node.
+	Fqdn *string `json:"fqdn,omitempty"`
+
+	// Id The ID of the silence.
+	Id   *string      `json:"id,omitempty"`
+	Kind *SilenceKind `json:"kind,omitempty"`
+
+	// Labels The labels of the nodes silenced.
+	Labels *map[string]string `json:"labels,omitempty"`
+
+	// Owner The team owning the nodes silenced.
+	Owner *string `json:"owner,omitempty"`
+
+	// Reason Why the nodes are silenced.
+	Reason *string `json:"reason,omitempty"`
+
+	// StartsAt The time the silence starts.
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+}
+
+// SilenceInput defines the model for silenceInput.
+type SilenceInput struct {
+	// By Who created the silence.
+	By string `json:"by"`
+
+	// EndsAt The time the silence ends.
+	EndsAt time.Time `json:"ends_at"`
+
+	// Fqdn The Hostname of the machine to silence. If not set, the nodes with the owner and labels are silenced.
+	Fqdn *string `json:"fqdn,omitempty"`
+
+	// Labels Silence the nodes with all of these labels.
+	Labels *map[string]string `json:"labels,omitempty"`
+
+	// Owner Silence the nodes owned by this team.
+	Owner *string `json:"owner,omitempty"`
+
+	// Reason Why the nodes are silenced.
+	Reason string `json:"reason"`
+
+	// StartsAt The time the silence starts. Defaults to now.
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+}
+
+// SilenceKind defines the model for silenceKind.
+type SilenceKind string
+
+// List of SilenceKind
+const (
+	SilenceKind_ack     SilenceKind = "ack"
+	SilenceKind_silence SilenceKind = "silence"
+)
+
+var SilenceKinds = []SilenceKind{
+	SilenceKind_ack,
+	SilenceKind_silence,
+}
+
+// IsIn checks if the value is in the list of SilenceKind
+func (t SilenceKind) IsIn(values ...SilenceKind) bool {
+	for _, v := range values {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid checks if the value is valid
+func (t SilenceKind) IsValid() bool {
+	return t.IsIn(SilenceKinds...)
+}
+
 // State defines the model for state.
 type State string
 
@@ -159,10 +829,320 @@ func (t State) IsValid() bool {
 	return t.IsIn(States...)
 }
 
+// Timing defines the model for timing.
+type Timing struct {
+	// Label The human-readable name of the timing.
+	Label *string `json:"label,omitempty"`
+
+	// Name The name of the timing, as reported by puppet.
+	Name *string `json:"name,omitempty"`
+
+	// Seconds The time spent, in seconds.
+	Seconds *float64 `json:"seconds,omitempty"`
+}
+
+// TimingBreakdown defines the model for timingBreakdown.
+type TimingBreakdown struct {
+	// From The start of the window, inclusive.
+	From *time.Time `json:"from,omitempty"`
+
+	// Timings The resource types and phases of the runs, most time spent first. The total is left out.
+	Timings *[]TimingEntry `json:"timings,omitempty"`
+
+	// To The end of the window, exclusive.
+	To    *time.Time   `json:"to,omitempty"`
+	Total *TimingEntry `json:"total,omitempty"`
+}
+
+// TimingEntry defines the model for timingEntry.
+type TimingEntry struct {
+	// Average The time spent by a run that reported the timing, on average, in seconds.
+	Average *float64 `json:"average,omitempty"`
+
+	// Label The human-readable name of the timing.
+	Label *string `json:"label,omitempty"`
+
+	// Max The most time spent by a single run, in seconds.
+	Max *float64 `json:"max,omitempty"`
+
+	// Name The name of the timing, as reported by puppet.
+	Name *string `json:"name,omitempty"`
+
+	// Runs The number of runs that reported the timing.
+	Runs *int `json:"runs,omitempty"`
+
+	// Seconds The time spent across the runs, in seconds.
+	Seconds *float64 `json:"seconds,omitempty"`
+
+	// Share The share of the total time of the runs that was spent, between 0 and 1.
+	Share *float64 `json:"share,omitempty"`
+}
+
+// Label defines the model for label.
+type Label = []string
+
+// Owner defines the model for owner.
+type Owner = string
+
+// GetAnomaliesParams defines parameters for GetAnomalies.
+type GetAnomaliesParams struct {
+	// Env The environments of the anomalies. All environments if not set.
+	Env *[]Environment `form:"env,omitempty" json:"env,omitempty"`
+
+	// Fqdn The FQDN of the node of the anomalies. All nodes if not set.
+	Fqdn *string `form:"fqdn,omitempty" json:"fqdn,omitempty"`
+
+	// From The time the runs were executed from, inclusive. Defaults to 7 days before to.
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To The time the runs were executed to, exclusive. Defaults to now.
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
+}
+
+// GetEnvironmentsParams defines parameters for GetEnvironments.
+type GetEnvironmentsParams struct {
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
+}
+
+// GetEventsParams defines parameters for GetEvents.
+type GetEventsParams struct {
+	// Fqdn Only stream the reports of this node.
+	Fqdn *string `form:"fqdn,omitempty" json:"fqdn,omitempty"`
+
+	// Env Only stream the reports of these environments. All environments if not set.
+	Env *[]Environment `form:"env,omitempty" json:"env,omitempty"`
+
+	// State Only stream the reports in these states. All states if not set.
+	State *[]State `form:"state,omitempty" json:"state,omitempty"`
+
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
+}
+
+// GetFailureLeaderboardParams defines parameters for GetFailureLeaderboard.
+type GetFailureLeaderboardParams struct {
+	// Env The environments to count the failures in. All environments if not set.
+	Env *[]Environment `form:"env,omitempty" json:"env,omitempty"`
+
+	// From The time to count the failures from, inclusive. Defaults to 7 days before to.
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To The time to count the failures to, exclusive. Defaults to now.
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Limit The number of entries of each ranking. Defaults to 10.
+	Limit *int `form:"limit,omitempty" json:"limit,omitempty"`
+
+	// Metric The metrics, as group.name, to rank the nodes by, the first metric first. The nodes are not ranked if not set.
+	Metric *[]string `form:"metric,omitempty" json:"metric,omitempty"`
+
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
+}
+
+// GetFailureNodesParams defines parameters for GetFailureNodes.
+type GetFailureNodesParams struct {
+	// Type The type of the resources, such as File or Exec.
+	Type *string `form:"type,omitempty" json:"type,omitempty"`
+
+	// Name The title of the resources.
+	Name *string `form:"name,omitempty" json:"name,omitempty"`
+
+	// File The manifest the resources are declared in.
+	File *string `form:"file,omitempty" json:"file,omitempty"`
+
+	// Line The line of the manifest the resources are declared on.
+	Line *string `form:"line,omitempty" json:"line,omitempty"`
+
+	// Env The environments to get the failures in. All environments if not set.
+	Env *[]Environment `form:"env,omitempty" json:"env,omitempty"`
+
+	// From The time to get the failures from, inclusive. Defaults to 7 days before to.
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To The time to get the failures to, exclusive. Defaults to now.
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
+}
+
+// GetHistoryParams defines parameters for GetHistory.
+type GetHistoryParams struct {
+	// Env The environments to get the history of. All environments if not set.
+	Env *[]Environment `form:"env,omitempty" json:"env,omitempty"`
+
+	// From The time to get the history from, inclusive. Defaults to 30 days before to.
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To The time to get the history to, exclusive. Defaults to now.
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Bucket The size of the buckets the runs are counted in. Defaults to day.
+	Bucket *HistoryBucket `form:"bucket,omitempty" json:"bucket,omitempty"`
+
+	// Metric The metrics, as group.name, to sum in each bucket. No metrics if not set.
+	Metric *[]string `form:"metric,omitempty" json:"metric,omitempty"`
+
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
+}
+
+// GetIncidentsParams defines parameters for GetIncidents.
+type GetIncidentsParams struct {
+	// Env The environments of the incidents. All environments if not set.
+	Env *[]Environment `form:"env,omitempty" json:"env,omitempty"`
+
+	// From The time the incidents were last seen from, inclusive. Defaults to 7 days before to.
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To The time the incidents were last seen to, exclusive. Defaults to now.
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
+}
+
+// GetAllNodesParams defines parameters for GetAllNodes.
+type GetAllNodesParams struct {
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
+}
+
+// GetAllNodesByEnvironmentParams defines parameters for GetAllNodesByEnvironment.
+type GetAllNodesByEnvironmentParams struct {
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
+}
+
+// GetFlappingNodesParams defines parameters for GetFlappingNodes.
+type GetFlappingNodesParams struct {
+	// Window The number of the latest runs of each node to score. Defaults to 10.
+	Window *int `form:"window,omitempty" json:"window,omitempty"`
+
+	// Threshold The flap score, the share of the runs whose state differs from the run before, from which a node is flapping. Defaults to 0.5.
+	Threshold *float64 `form:"threshold,omitempty" json:"threshold,omitempty"`
+
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
+}
+
+// DeleteNodeParams defines parameters for DeleteNode.
+type DeleteNodeParams struct {
+	// Decommission Hide the node from the listings until it reports again, rather than deleting its reports.
+	Decommission *bool `form:"decommission,omitempty" json:"decommission,omitempty"`
+}
+
 // PurgePuppetReportsJSONBody defines parameters for PurgePuppetReports.
 type PurgePuppetReportsJSONBody struct {
 	Date *openapi_types.Date `json:"date,omitempty"`
+
+	// DryRun Report what would be purged without purging anything.
+	DryRun *bool `json:"dry_run,omitempty"`
+}
+
+// PreviewPurgePuppetReportsParams defines parameters for PreviewPurgePuppetReports.
+type PreviewPurgePuppetReportsParams struct {
+	// Date The date to purge reports from
+	Date openapi_types.Date `form:"date" json:"date"`
+}
+
+// GetRolloutParams defines parameters for GetRollout.
+type GetRolloutParams struct {
+	// From The start of the window, inclusive. Defaults to 7 days before to.
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To The end of the window, exclusive. Defaults to now.
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Bucket The step of the timeline. Defaults to hour.
+	Bucket *HistoryBucket `form:"bucket,omitempty" json:"bucket,omitempty"`
+}
+
+// GetSilencesParams defines parameters for GetSilences.
+type GetSilencesParams struct {
+	// Expired Include the silences and acknowledgements that have ended.
+	Expired *bool `form:"expired,omitempty" json:"expired,omitempty"`
+}
+
+// GetAllNodesByStateParams defines parameters for GetAllNodesByState.
+type GetAllNodesByStateParams struct {
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
 }
 
+// GetFleetSummaryParams defines parameters for GetFleetSummary.
+type GetFleetSummaryParams struct {
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
+}
+
+// GetTimingsParams defines parameters for GetTimings.
+type GetTimingsParams struct {
+	// Env The environments of the runs. All environments if not set.
+	Env *[]Environment `form:"env,omitempty" json:"env,omitempty"`
+
+	// From The time the runs were executed from, inclusive. Defaults to 7 days before to.
+	From *time.Time `form:"from,omitempty" json:"from,omitempty"`
+
+	// To The time the runs were executed to, exclusive. Defaults to now.
+	To *time.Time `form:"to,omitempty" json:"to,omitempty"`
+
+	// Owner Only include the nodes owned by this team.
+	Owner *Owner `form:"owner,omitempty" json:"owner,omitempty"`
+
+	// Label Only include the nodes with this label, given as key=value. Can be repeated to require more than one label.
+	Label *Label `form:"label,omitempty" json:"label,omitempty"`
+}
+
+// AcknowledgeNodeJSONRequestBody defines body for AcknowledgeNode for application/json ContentType.
+type AcknowledgeNodeJSONRequestBody = AcknowledgementInput
+
+// PutNodeMetadataJSONRequestBody defines body for PutNodeMetadata for application/json ContentType.
+type PutNodeMetadataJSONRequestBody = NodeMetadataInput
+
 // PurgePuppetReportsJSONRequestBody defines body for PurgePuppetReports for application/json ContentType.
 type PurgePuppetReportsJSONRequestBody PurgePuppetReportsJSONBody
+
+// CreateSilenceJSONRequestBody defines body for CreateSilence for application/json ContentType.
+type CreateSilenceJSONRequestBody = SilenceInput
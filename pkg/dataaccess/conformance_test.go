@@ -0,0 +1,81 @@
+package dataaccess_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess/dbtest"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteConformance(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) dataaccess.Database {
+		db, err := dataaccess.NewSQLiteFile(filepath.Join(t.TempDir(), "puppet-summary.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, db.Close(context.Background()))
+		})
+		return db
+	})
+}
+
+func TestMemoryConformance(t *testing.T) {
+	dbtest.Run(t, func(t *testing.T) dataaccess.Database {
+		return dataaccess.NewMemory()
+	})
+}
+
+// TestMySQLConformance runs the conformance suite against the MySQL database at MYSQL_TEST_DSN. The tables are emptied
+// before each test, so the database should be one used only for testing.
+func TestMySQLConformance(t *testing.T) {
+	dsn := os.Getenv("MYSQL_TEST_DSN")
+	if dsn == "" {
+		t.Skip("MYSQL_TEST_DSN not set")
+	}
+
+	dbtest.Run(t, func(t *testing.T) dataaccess.Database {
+		ctx := context.Background()
+
+		v := viper.New()
+		v.Set("db.conn_str", dsn)
+
+		db, err := dataaccess.NewMySQL(v)
+		require.NoError(t, err)
+		require.NoError(t, dataaccess.TruncateMySQLTables(ctx, db))
+		t.Cleanup(func() {
+			require.NoError(t, db.Close(ctx))
+		})
+		return db
+	})
+}
+
+// TestMongoConformance runs the conformance suite against the MongoDB server at MONGO_TEST_URI. Each test uses its
+// own database, which is dropped afterwards.
+func TestMongoConformance(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+
+	dbtest.Run(t, func(t *testing.T) dataaccess.Database {
+		ctx := context.Background()
+
+		v := viper.New()
+		v.Set("db.conn_str", uri)
+		v.Set("db.name", fmt.Sprintf("puppet-summary-test-%d", time.Now().UnixNano()))
+
+		db, err := dataaccess.NewMongo(ctx, v)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			require.NoError(t, dataaccess.DropMongoDatabase(ctx, db))
+			require.NoError(t, db.Close(ctx))
+		})
+		return db
+	})
+}
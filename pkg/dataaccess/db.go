@@ -2,14 +2,19 @@ package dataaccess
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/vault"
 	"github.com/jmoiron/sqlx"
 	"github.com/spf13/viper"
@@ -17,6 +22,12 @@ import (
 
 const EnvDbConnStr = "DB_CONN_STR"
 
+// historyDays is the number of days of history returned by GetHistory.
+const historyDays = 30
+
+// historyHourLayout is the layout of the dates of hour buckets of history.
+const historyHourLayout = "2006-01-02T15:00"
+
 type Database interface {
 	// Ping pings the database.
 	Ping(ctx context.Context) error
@@ -39,17 +50,120 @@ type Database interface {
 	// GetReports returns all PuppetReports from the database for the given fqdn.
 	GetReports(ctx context.Context, fqdn string) ([]*entities.PuppetReportSummary, error)
 
+	// GetLatestReports returns the latest reports of the given fqdn executed before the given time, newest first, up
+	// to the limit.
+	GetLatestReports(ctx context.Context, fqdn string, before time.Time, limit int) ([]*entities.PuppetReportSummary, error)
+
 	// GetReport returns the PuppetReport from the database for the given id.
 	GetReport(ctx context.Context, id string) (*entities.PuppetReport, error)
 
 	// GetHistory returns the PuppetHistory from the database for the given environment.
 	GetHistory(ctx context.Context, environment ...summary.Environment) ([]*entities.PuppetHistory, error)
 
+	// GetHistoryBuckets returns the number of runs in each state for the given environments, per bucket of time, oldest
+	// first. Only the runs executed from (inclusive) to (exclusive) are counted, and a zero time leaves that end of the
+	// range open. If fqdns is not nil, only the runs of those nodes are counted. Buckets with no runs are left out.
+	GetHistoryBuckets(ctx context.Context, bucket summary.HistoryBucket, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.PuppetHistory, error)
+
+	// GetResourceFailures returns the resources that failed in the reports of the given environments, newest first.
+	// Only the reports executed from (inclusive) to (exclusive) are included, and a zero time leaves that end of the
+	// range open.
+	GetResourceFailures(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.ResourceFailure, error)
+
+	// GetRunVersions returns the versions of the code applied by the runs of the given environments, oldest first. Only
+	// the runs executed from (inclusive) to (exclusive) that reported a configuration version are included, and a zero
+	// time leaves that end of the range open.
+	GetRunVersions(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.RunVersion, error)
+
+	// GetTimings returns the timings reported by the runs of the given environments, aggregated per timing, most time
+	// spent first. Only the runs executed from (inclusive) to (exclusive) are included, and a zero time leaves that end
+	// of the range open. If fqdns is not nil, only the runs of those nodes are included.
+	GetTimings(ctx context.Context, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.TimingSummary, error)
+
+	// GetRunMetrics returns the values of the given metrics, keyed as group.name, reported by the runs of the given
+	// environments, oldest first. Every metric is returned if none are given. Only the runs executed from (inclusive)
+	// to (exclusive) are included, and a zero time leaves that end of the range open.
+	GetRunMetrics(ctx context.Context, from, to time.Time, metrics []string, environment ...summary.Environment) ([]*entities.RunMetric, error)
+
 	// GetEnvironments returns all environments from the database.
 	GetEnvironments(ctx context.Context) ([]summary.Environment, error)
 
 	// Purge purges the data from the database out of the given range.
 	Purge(ctx context.Context, from time.Time) (int, error)
+
+	// DeleteReports deletes the reports with the given ids from the database.
+	DeleteReports(ctx context.Context, ids ...string) (int, error)
+
+	// AcquireLock acquires the named lock for the holder, until the ttl expires. If the lock is already held by the
+	// holder, the ttl is extended. Returns whether the lock is held by the holder.
+	AcquireLock(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+
+	// ReleaseLock releases the named lock, if it is held by the holder.
+	ReleaseLock(ctx context.Context, name, holder string) error
+
+	// SaveJobRun saves the outcome of the last run of a scheduled job, replacing the previous run of the job.
+	SaveJobRun(ctx context.Context, run *entities.JobRun) error
+
+	// GetJobRuns returns the outcome of the last run of each scheduled job, ordered by name.
+	GetJobRuns(ctx context.Context) ([]*entities.JobRun, error)
+
+	// DecommissionNode marks the node with the given fqdn as decommissioned at the given time. The node is hidden from
+	// the listings until it reports again after that time.
+	DecommissionNode(ctx context.Context, fqdn string, at time.Time) error
+
+	// RecommissionNode removes the decommission of the node with the given fqdn, if there is one.
+	RecommissionNode(ctx context.Context, fqdn string) error
+
+	// GetDecommissions returns the time each decommissioned node was decommissioned at, keyed by the fqdn.
+	GetDecommissions(ctx context.Context) (map[string]time.Time, error)
+
+	// GetNodeMetadata returns the metadata of the node with the given fqdn. Returns ErrNotFound if the node has no
+	// metadata.
+	GetNodeMetadata(ctx context.Context, fqdn string) (*entities.NodeMetadata, error)
+
+	// GetAllNodeMetadata returns the metadata of every node that has any.
+	GetAllNodeMetadata(ctx context.Context) ([]*entities.NodeMetadata, error)
+
+	// SaveNodeMetadata saves the metadata of a node, replacing any existing metadata of the node.
+	SaveNodeMetadata(ctx context.Context, metadata *entities.NodeMetadata) error
+
+	// DeleteNodeMetadata deletes the metadata of the node with the given fqdn. Returns ErrNotFound if the node has no
+	// metadata.
+	DeleteNodeMetadata(ctx context.Context, fqdn string) error
+
+	// SaveSilence saves a silence, replacing any existing silence with the same ID.
+	SaveSilence(ctx context.Context, silence *entities.Silence) error
+
+	// GetSilences returns every silence, including those that have ended, ordered by the time they start.
+	GetSilences(ctx context.Context) ([]*entities.Silence, error)
+
+	// DeleteSilence deletes the silence with the given ID. Returns ErrNotFound if there is no such silence.
+	DeleteSilence(ctx context.Context, id string) error
+
+	// SaveIncident saves an incident, replacing any existing incident with the same ID. Whether an existing incident
+	// has been notified is kept, as it is only changed by SetIncidentNotified.
+	SaveIncident(ctx context.Context, incident *entities.Incident) error
+
+	// SetIncidentNotified sets whether the incident with the given ID has been notified. Returns whether it was
+	// changed, which is false if the incident already had the given value, so that only one caller claims the
+	// notification of an incident. Returns ErrNotFound if there is no such incident.
+	SetIncidentNotified(ctx context.Context, id string, notified bool) (bool, error)
+
+	// GetIncidents returns the incidents of the given environments, most recently seen first. Only the incidents last
+	// seen from (inclusive) to (exclusive) are included, and a zero time leaves that end of the range open.
+	GetIncidents(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.Incident, error)
+
+	// GetIncident returns the incident with the given ID. Returns ErrNotFound if there is no such incident.
+	GetIncident(ctx context.Context, id string) (*entities.Incident, error)
+
+	// SaveRuntimeAnomaly saves the runtime anomaly of a run, replacing any existing anomaly of the same report. The
+	// anomaly is deleted with its report.
+	SaveRuntimeAnomaly(ctx context.Context, anomaly *entities.RuntimeAnomaly) error
+
+	// GetRuntimeAnomalies returns the runtime anomalies of the runs of the given environments, newest first. If fqdn is
+	// not empty, only the anomalies of that node are returned. Only the runs executed from (inclusive) to (exclusive)
+	// are included, and a zero time leaves that end of the range open.
+	GetRuntimeAnomalies(ctx context.Context, fqdn string, from, to time.Time, environment ...summary.Environment) ([]*entities.RuntimeAnomaly, error)
 }
 
 func ConnectDatabase(ctx context.Context, dbType string, v *viper.Viper) (Database, error) {
@@ -80,6 +194,8 @@ func ConnectDatabase(ctx context.Context, dbType string, v *viper.Viper) (Databa
 			return nil, fmt.Errorf("connect to sqlite: %w", err)
 		}
 		return sqlite, nil
+	case DbMemory:
+		return NewMemory(), nil
 	default:
 		return nil, fmt.Errorf("invalid database option, %s", dbType)
 	}
@@ -174,3 +290,883 @@ func (d *Db) PingContext(ctx context.Context) error {
 
 	return d.DB.PingContext(ctx)
 }
+
+// insertResourceFailures saves the resources that failed in the report to the failed_resources table of a SQL database,
+// in the transaction the report is saved in.
+func insertResourceFailures(ctx context.Context, tx *sqlx.Tx, run *entities.PuppetReport) error {
+	if len(run.ResourcesFailed) == 0 {
+		return nil
+	}
+
+	sqlStmt := `
+	INSERT INTO failed_resources(
+	                             report_hash,
+	                             fqdn,
+	                             environment,
+	                             executed_at,
+	                             resource_type,
+	                             title,
+	                             file,
+	                             line
+	                             )
+	values(?,?,?,?,?,?,?,?);
+`
+
+	stmt, err := tx.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	for _, res := range run.ResourcesFailed {
+		_, err = stmt.ExecContext(ctx,
+			run.ID,
+			run.Fqdn,
+			run.Env,
+			run.ExecTime.Time().UTC().Format(time.DateTime),
+			res.Type,
+			res.Name,
+			res.File,
+			res.Line,
+		)
+		if err != nil {
+			return fmt.Errorf("error executing statement: %w", err)
+		}
+	}
+	return nil
+}
+
+// queryResourceFailures returns the resources that failed in the reports executed in the range, from the
+// failed_resources table of a SQL database.
+func queryResourceFailures(ctx context.Context, client *Db, from, to time.Time, environment ...summary.Environment) ([]*entities.ResourceFailure, error) {
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	where := make([]string, 0)
+	args := make([]any, 0)
+	if !from.IsZero() {
+		where = append(where, "executed_at >= ?")
+		args = append(args, from.UTC().Format(time.DateTime))
+	}
+	if !to.IsZero() {
+		where = append(where, "executed_at < ?")
+		args = append(args, to.UTC().Format(time.DateTime))
+	}
+	if len(environment) > 0 {
+		where = append(where, "environment IN (?)")
+		args = append(args, environment)
+	}
+
+	sqlStmt := "SELECT report_hash, fqdn, environment, executed_at, resource_type, title, file, line FROM failed_resources"
+	if len(where) > 0 {
+		sqlStmt += " WHERE " + strings.Join(where, " AND ")
+	}
+	sqlStmt += " ORDER BY executed_at DESC, id;"
+
+	query, args, err := sqlx.In(sqlStmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error building query: %w", err)
+	}
+
+	stmt, err := client.PrepareContext(ctx, client.Rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	failures := make([]*entities.ResourceFailure, 0)
+	for rows.Next() {
+		failure := &entities.ResourceFailure{Resource: new(entities.PuppetResource)}
+		if err := rows.Scan(&failure.ReportID, &failure.Fqdn, &failure.Env, &failure.ExecTime, &failure.Resource.Type,
+			&failure.Resource.Name, &failure.Resource.File, &failure.Resource.Line); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		failures = append(failures, failure)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return failures, nil
+}
+
+// insertRunVersion saves the version of the code applied by the run of the report to the run_versions table of a SQL
+// database, in the transaction the report is saved in.
+func insertRunVersion(ctx context.Context, tx *sqlx.Tx, run *entities.PuppetReport) error {
+	if run.ConfigVersion == "" {
+		return nil
+	}
+
+	sqlStmt := `
+	INSERT INTO run_versions(
+	                         report_hash,
+	                         fqdn,
+	                         environment,
+	                         state,
+	                         executed_at,
+	                         config_version,
+	                         code_id
+	                         )
+	values(?,?,?,?,?,?,?);
+`
+
+	_, err := tx.ExecContext(ctx, sqlStmt,
+		run.ID,
+		run.Fqdn,
+		run.Env,
+		run.State,
+		run.ExecTime.Time().UTC().Format(time.DateTime),
+		run.ConfigVersion,
+		run.CodeID,
+	)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+	return nil
+}
+
+// queryRunVersions returns the versions of the code applied by the runs executed in the range, oldest first, from the
+// run_versions table of a SQL database.
+func queryRunVersions(ctx context.Context, client *Db, from, to time.Time, environment ...summary.Environment) ([]*entities.RunVersion, error) {
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	where := make([]string, 0)
+	args := make([]any, 0)
+	if !from.IsZero() {
+		where = append(where, "executed_at >= ?")
+		args = append(args, from.UTC().Format(time.DateTime))
+	}
+	if !to.IsZero() {
+		where = append(where, "executed_at < ?")
+		args = append(args, to.UTC().Format(time.DateTime))
+	}
+	if len(environment) > 0 {
+		where = append(where, "environment IN (?)")
+		args = append(args, environment)
+	}
+
+	sqlStmt := "SELECT report_hash, fqdn, environment, state, executed_at, config_version, code_id FROM run_versions"
+	if len(where) > 0 {
+		sqlStmt += " WHERE " + strings.Join(where, " AND ")
+	}
+	sqlStmt += " ORDER BY executed_at, report_hash;"
+
+	query, args, err := sqlx.In(sqlStmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error building query: %w", err)
+	}
+
+	stmt, err := client.PrepareContext(ctx, client.Rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	versions := make([]*entities.RunVersion, 0)
+	for rows.Next() {
+		version := new(entities.RunVersion)
+		if err := rows.Scan(&version.ReportID, &version.Fqdn, &version.Env, &version.State, &version.ExecTime,
+			&version.ConfigVersion, &version.CodeID); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return versions, nil
+}
+
+// insertRunTimings saves the timings reported by the run of the report to the run_timings table of a SQL database, in
+// the transaction the report is saved in.
+func insertRunTimings(ctx context.Context, tx *sqlx.Tx, run *entities.PuppetReport) error {
+	if len(run.Timings) == 0 {
+		return nil
+	}
+
+	sqlStmt := `
+	INSERT INTO run_timings(
+	                        report_hash,
+	                        fqdn,
+	                        environment,
+	                        executed_at,
+	                        name,
+	                        label,
+	                        seconds
+	                        )
+	values(?,?,?,?,?,?,?);
+`
+
+	stmt, err := tx.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	for _, timing := range run.Timings {
+		_, err = stmt.ExecContext(ctx,
+			run.ID,
+			run.Fqdn,
+			run.Env,
+			run.ExecTime.Time().UTC().Format(time.DateTime),
+			timing.Name,
+			timing.Label,
+			timing.Seconds,
+		)
+		if err != nil {
+			return fmt.Errorf("error executing statement: %w", err)
+		}
+	}
+	return nil
+}
+
+// queryTimings returns the timings reported by the runs executed in the range, aggregated per timing, most time spent
+// first, from the run_timings table of a SQL database. If fqdns is not nil, only the runs of those nodes are included.
+func queryTimings(ctx context.Context, client *Db, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.TimingSummary, error) {
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	where := make([]string, 0)
+	args := make([]any, 0)
+	if !from.IsZero() {
+		where = append(where, "executed_at >= ?")
+		args = append(args, from.UTC().Format(time.DateTime))
+	}
+	if !to.IsZero() {
+		where = append(where, "executed_at < ?")
+		args = append(args, to.UTC().Format(time.DateTime))
+	}
+	if len(environment) > 0 {
+		where = append(where, "environment IN (?)")
+		args = append(args, environment)
+	}
+	if fqdns != nil {
+		if len(fqdns) == 0 {
+			return make([]*entities.TimingSummary, 0), nil
+		}
+		where = append(where, "fqdn IN (?)")
+		args = append(args, fqdns)
+	}
+
+	sqlStmt := "SELECT name, MAX(label), COUNT(*), SUM(seconds), MAX(seconds) FROM run_timings"
+	if len(where) > 0 {
+		sqlStmt += " WHERE " + strings.Join(where, " AND ")
+	}
+	sqlStmt += " GROUP BY name ORDER BY SUM(seconds) DESC, name;"
+
+	query, args, err := sqlx.In(sqlStmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error building query: %w", err)
+	}
+
+	stmt, err := client.PrepareContext(ctx, client.Rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	timings := make([]*entities.TimingSummary, 0)
+	for rows.Next() {
+		timing := new(entities.TimingSummary)
+		if err := rows.Scan(&timing.Name, &timing.Label, &timing.Runs, &timing.Seconds, &timing.Max); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		timings = append(timings, timing)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return timings, nil
+}
+
+// insertRunMetrics saves the metrics reported by the run of the report to the run_metrics table of a SQL database, in
+// the transaction the report is saved in.
+func insertRunMetrics(ctx context.Context, tx *sqlx.Tx, run *entities.PuppetReport) error {
+	if len(run.Metrics) == 0 {
+		return nil
+	}
+
+	sqlStmt := `
+	INSERT INTO run_metrics(
+	                        report_hash,
+	                        fqdn,
+	                        environment,
+	                        executed_at,
+	                        metric,
+	                        value
+	                        )
+	values(?,?,?,?,?,?);
+`
+
+	stmt, err := tx.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	for _, metric := range runMetrics(run, nil) {
+		_, err = stmt.ExecContext(ctx,
+			metric.ReportID,
+			metric.Fqdn,
+			metric.Env,
+			metric.ExecTime.Time().UTC().Format(time.DateTime),
+			metric.Metric,
+			metric.Value,
+		)
+		if err != nil {
+			return fmt.Errorf("error executing statement: %w", err)
+		}
+	}
+	return nil
+}
+
+// queryRunMetrics returns the values of the metrics reported by the runs executed in the range, oldest first, from the
+// run_metrics table of a SQL database.
+func queryRunMetrics(ctx context.Context, client *Db, from, to time.Time, metrics []string, environment ...summary.Environment) ([]*entities.RunMetric, error) {
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	where := make([]string, 0)
+	args := make([]any, 0)
+	if !from.IsZero() {
+		where = append(where, "executed_at >= ?")
+		args = append(args, from.UTC().Format(time.DateTime))
+	}
+	if !to.IsZero() {
+		where = append(where, "executed_at < ?")
+		args = append(args, to.UTC().Format(time.DateTime))
+	}
+	if len(metrics) > 0 {
+		where = append(where, "metric IN (?)")
+		args = append(args, metrics)
+	}
+	if len(environment) > 0 {
+		where = append(where, "environment IN (?)")
+		args = append(args, environment)
+	}
+
+	sqlStmt := "SELECT report_hash, fqdn, environment, executed_at, metric, value FROM run_metrics"
+	if len(where) > 0 {
+		sqlStmt += " WHERE " + strings.Join(where, " AND ")
+	}
+	sqlStmt += " ORDER BY executed_at, report_hash, metric;"
+
+	query, args, err := sqlx.In(sqlStmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error building query: %w", err)
+	}
+
+	stmt, err := client.PrepareContext(ctx, client.Rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	values := make([]*entities.RunMetric, 0)
+	for rows.Next() {
+		value := new(entities.RunMetric)
+		if err := rows.Scan(&value.ReportID, &value.Fqdn, &value.Env, &value.ExecTime, &value.Metric, &value.Value); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return values, nil
+}
+
+// runMetrics flattens the metrics of the given run into one value per metric, in order of their keys. Only the given
+// metrics are returned, or every metric if none are given.
+func runMetrics(run *entities.PuppetReport, metrics []string) []*entities.RunMetric {
+	values := make([]*entities.RunMetric, 0)
+	for group, groupMetrics := range run.Metrics {
+		for name, value := range groupMetrics {
+			key := entities.MetricKey(group, name)
+			if len(metrics) > 0 && !slices.Contains(metrics, key) {
+				continue
+			}
+
+			values = append(values, &entities.RunMetric{
+				ReportID: run.ID,
+				Fqdn:     run.Fqdn,
+				Env:      run.Env,
+				ExecTime: run.ExecTime,
+				Metric:   key,
+				Value:    value,
+			})
+		}
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Metric < values[j].Metric
+	})
+
+	return values
+}
+
+// queryLatestReports returns the latest reports of the given fqdn executed before the given time, newest first, up to
+// the limit, on a SQL database.
+func queryLatestReports(ctx context.Context, client *Db, fqdn string, before time.Time, limit int) ([]*entities.PuppetReportSummary, error) {
+	sqlStmt := `
+	SELECT
+		hash,
+		fqdn,
+		environment,
+		state,
+		executed_at,
+		runtime,
+		failed,
+		changed,
+		total,
+		yaml_file
+	FROM reports
+	WHERE fqdn = ? AND executed_at < ?
+	ORDER BY executed_at DESC
+	LIMIT ?;
+`
+
+	stmt, err := client.PrepareContext(ctx, client.Rebind(sqlStmt))
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	rows, err := stmt.QueryContext(ctx, fqdn, before.UTC().Format(time.DateTime), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	reports := make([]*entities.PuppetReportSummary, 0)
+	for rows.Next() {
+		report := new(entities.PuppetReportSummary)
+		if err := rows.Scan(&report.ID, &report.Fqdn, &report.Env, &report.State, &report.ExecTime, &report.Runtime,
+			&report.Failed, &report.Changed, &report.Total, &report.YamlFile); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+
+		report.CalculateTimeSince()
+
+		reports = append(reports, report)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return reports, nil
+}
+
+// incidentColumns are the columns of the incidents table, in the order they are scanned by queryIncidents.
+const incidentColumns = "id, environment, config_version, resources, message, first_seen, last_seen, nodes, reports, notified"
+
+// queryIncidents returns the incidents of the given environments, last seen from (inclusive) to (exclusive), most
+// recently seen first, on a SQL database. If id is not empty, only the incident with that ID is returned.
+func queryIncidents(ctx context.Context, client *Db, id string, from, to time.Time, environment ...summary.Environment) ([]*entities.Incident, error) {
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	where := make([]string, 0)
+	args := make([]any, 0)
+	if id != "" {
+		where = append(where, "id = ?")
+		args = append(args, id)
+	}
+	if !from.IsZero() {
+		where = append(where, "last_seen >= ?")
+		args = append(args, from.UTC().Format(time.DateTime))
+	}
+	if !to.IsZero() {
+		where = append(where, "last_seen < ?")
+		args = append(args, to.UTC().Format(time.DateTime))
+	}
+	if len(environment) > 0 {
+		where = append(where, "environment IN (?)")
+		args = append(args, environment)
+	}
+
+	sqlStmt := "SELECT " + incidentColumns + " FROM incidents"
+	if len(where) > 0 {
+		sqlStmt += " WHERE " + strings.Join(where, " AND ")
+	}
+	sqlStmt += " ORDER BY last_seen DESC, id;"
+
+	query, args, err := sqlx.In(sqlStmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error building query: %w", err)
+	}
+
+	stmt, err := client.PrepareContext(ctx, client.Rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	incidents := make([]*entities.Incident, 0)
+	for rows.Next() {
+		inc := new(entities.Incident)
+		if err := rows.Scan(&inc.ID, &inc.Env, &inc.ConfigVersion, &inc.Resources, &inc.Message, &inc.FirstSeen,
+			&inc.LastSeen, &inc.Nodes, &inc.Reports, &inc.Notified); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		incidents = append(incidents, inc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return incidents, nil
+}
+
+// getIncident returns the incident with the given ID on a SQL database, or ErrNotFound.
+func getIncident(ctx context.Context, client *Db, id string) (*entities.Incident, error) {
+	incidents, err := queryIncidents(ctx, client, id, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	} else if len(incidents) == 0 {
+		return nil, ErrNotFound
+	}
+	return incidents[0], nil
+}
+
+// setIncidentNotified sets whether the incident with the given ID has been notified on a SQL database, returning
+// whether it was changed. The incident is only updated if it does not have the value yet, so that of the callers
+// setting it at the same time, only one changes it.
+func setIncidentNotified(ctx context.Context, client *Db, id string, notified bool) (bool, error) {
+	stmt, err := client.PrepareContext(ctx, "UPDATE incidents SET notified = ? WHERE id = ? AND notified <> ?;")
+	if err != nil {
+		return false, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	res, err := stmt.ExecContext(ctx, notified, id, notified)
+	if err != nil {
+		return false, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error getting rows affected: %w", err)
+	} else if n > 0 {
+		return true, nil
+	}
+
+	// Nothing was changed, either because the incident already has the value or because there is no such incident.
+	if _, err := getIncident(ctx, client, id); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// queryRuntimeAnomalies returns the runtime anomalies of the runs of the given environments, executed from (inclusive)
+// to (exclusive), newest first, on a SQL database. If fqdn is not empty, only the anomalies of that node are returned.
+func queryRuntimeAnomalies(ctx context.Context, client *Db, fqdn string, from, to time.Time, environment ...summary.Environment) ([]*entities.RuntimeAnomaly, error) {
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	where := make([]string, 0)
+	args := make([]any, 0)
+	if fqdn != "" {
+		where = append(where, "fqdn = ?")
+		args = append(args, fqdn)
+	}
+	if !from.IsZero() {
+		where = append(where, "executed_at >= ?")
+		args = append(args, from.UTC().Format(time.DateTime))
+	}
+	if !to.IsZero() {
+		where = append(where, "executed_at < ?")
+		args = append(args, to.UTC().Format(time.DateTime))
+	}
+	if len(environment) > 0 {
+		where = append(where, "environment IN (?)")
+		args = append(args, environment)
+	}
+
+	sqlStmt := "SELECT report_hash, fqdn, environment, executed_at, runtime, median, deviation, score FROM runtime_anomalies"
+	if len(where) > 0 {
+		sqlStmt += " WHERE " + strings.Join(where, " AND ")
+	}
+	sqlStmt += " ORDER BY executed_at DESC, report_hash;"
+
+	query, args, err := sqlx.In(sqlStmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error building query: %w", err)
+	}
+
+	stmt, err := client.PrepareContext(ctx, client.Rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+	defer func() {
+		if err := stmt.Close(); err != nil {
+			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	anomalies := make([]*entities.RuntimeAnomaly, 0)
+	for rows.Next() {
+		anomaly := new(entities.RuntimeAnomaly)
+		if err := rows.Scan(&anomaly.ReportID, &anomaly.Fqdn, &anomaly.Env, &anomaly.ExecTime, &anomaly.Runtime,
+			&anomaly.Median, &anomaly.Deviation, &anomaly.Score); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return anomalies, nil
+}
+
+// rollback rolls back the transaction, if it has not been committed.
+func rollback(tx *sqlx.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		slog.Error("Error rolling back transaction", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// migrationExecTimesUTC is the name of the migration moving the execution times stored in the time of the nodes to UTC.
+const migrationExecTimesUTC = "exec_times_utc"
+
+// execTimeTables are the tables, other than reports, that store the execution time of a report beside its hash.
+var execTimeTables = []string{"failed_resources", "run_versions", "run_timings", "run_metrics", "runtime_anomalies"}
+
+// migrateExecTimesToUTC moves the execution times stored in the time of the nodes, before they were stored in UTC, to
+// UTC on a SQL database. The offset of each report is taken from the name of its file, which keeps the time reported
+// by the node. The claim statement records the migration in the migrations table, affecting no rows if it is already
+// there, so the migration only runs once.
+func migrateExecTimesToUTC(ctx context.Context, client *Db, claimStmt string) error {
+	tx, err := client.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer rollback(tx)
+
+	res, err := tx.ExecContext(ctx, claimStmt, migrationExecTimesUTC, time.Now().UTC().Format(time.DateTime))
+	if err != nil {
+		return fmt.Errorf("error claiming migration: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	} else if affected == 0 {
+		return nil
+	}
+
+	var reports []struct {
+		Hash     string         `db:"hash"`
+		YamlFile sql.NullString `db:"yaml_file"`
+	}
+	if err := tx.SelectContext(ctx, &reports, "SELECT hash, yaml_file FROM reports;"); err != nil {
+		return fmt.Errorf("error getting reports: %w", err)
+	}
+
+	stmts := make([]*sql.Stmt, 0, len(execTimeTables)+1)
+	defer func() {
+		for _, stmt := range stmts {
+			if err := stmt.Close(); err != nil {
+				slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
+			}
+		}
+	}()
+	for _, table := range append([]string{"reports"}, execTimeTables...) {
+		column := "report_hash"
+		if table == "reports" {
+			column = "hash"
+		}
+
+		stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("UPDATE %s SET executed_at = ? WHERE %s = ?;", table, column))
+		if err != nil {
+			return fmt.Errorf("error preparing statement: %w", err)
+		}
+		stmts = append(stmts, stmt)
+	}
+
+	migrated := 0
+	for _, report := range reports {
+		execTime, ok := ReportFileTime(report.YamlFile.String)
+		if !ok {
+			slog.Warn("Report file has no execution time, leaving it as stored", slog.String(logging.KeyHash, report.Hash))
+			continue
+		} else if _, offset := execTime.Zone(); offset == 0 {
+			// The time of the node is already UTC.
+			continue
+		}
+
+		for _, stmt := range stmts {
+			if _, err := stmt.ExecContext(ctx, execTime.UTC().Format(time.DateTime), report.Hash); err != nil {
+				return fmt.Errorf("error executing statement: %w", err)
+			}
+		}
+		migrated++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	slog.Info("Execution times migrated to UTC", slog.Int("reports", migrated))
+	return nil
+}
+
+// validateHistoryQuery checks the bucket and environments of a history query are valid.
+func validateHistoryQuery(bucket summary.HistoryBucket, environment ...summary.Environment) error {
+	if !bucket.IsValid() {
+		return fmt.Errorf("invalid history bucket: %s", bucket)
+	}
+
+	for _, env := range environment {
+		if !env.IsValid() {
+			return fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	return nil
+}
+
+// HistoryBucketDate returns the date of the bucket of history the time is in. The buckets are in UTC, like the times
+// stored by the SQL databases, and weeks start on a Monday.
+func HistoryBucketDate(t time.Time, bucket summary.HistoryBucket) string {
+	t = t.UTC()
+	switch bucket {
+	case summary.HistoryBucket_hour:
+		return t.Format(historyHourLayout)
+	case summary.HistoryBucket_week:
+		// Weekday counts from Sunday, so it is shifted to count from Monday.
+		offset := (int(t.Weekday()) + 6) % 7
+		return t.AddDate(0, 0, -offset).Format(time.DateOnly)
+	default:
+		return t.Format(time.DateOnly)
+	}
+}
+
+// latestHistory returns the most recent buckets of the history, which is oldest first.
+func latestHistory(history []*entities.PuppetHistory, buckets int) []*entities.PuppetHistory {
+	if len(history) > buckets {
+		return history[len(history)-buckets:]
+	}
+	return history
+}
@@ -0,0 +1,975 @@
+package dataaccess
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// memoryLock is a lock held in memory.
+type memoryLock struct {
+	// holder is the holder of the lock.
+	holder string
+
+	// expiresAt is the time the lock expires.
+	expiresAt time.Time
+}
+
+// memoryImpl is a database held in memory. Nothing is persisted, so the data is lost when the process exits.
+type memoryImpl struct {
+	// mtx guards the reports, locks, job runs, decommissions, metadata and silences.
+	mtx sync.RWMutex
+
+	// reports are the reports, keyed by the report ID.
+	reports map[string]*entities.PuppetReport
+
+	// locks are the locks, keyed by the lock name.
+	locks map[string]*memoryLock
+
+	// jobRuns are the last runs of the scheduled jobs, keyed by the job name.
+	jobRuns map[string]*entities.JobRun
+
+	// decommissions are the times the decommissioned nodes were decommissioned at, keyed by the fqdn.
+	decommissions map[string]time.Time
+
+	// metadata is the metadata of the nodes, keyed by the fqdn.
+	metadata map[string]*entities.NodeMetadata
+
+	// silences are the silences, keyed by the silence ID.
+	silences map[string]*entities.Silence
+
+	// incidents are the incidents, keyed by the incident ID.
+	incidents map[string]*entities.Incident
+
+	// anomalies are the runtime anomalies, keyed by the report ID.
+	anomalies map[string]*entities.RuntimeAnomaly
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// NewMemory creates a new, empty, database held in memory.
+func NewMemory() Database {
+	return &memoryImpl{
+		reports:       make(map[string]*entities.PuppetReport),
+		locks:         make(map[string]*memoryLock),
+		jobRuns:       make(map[string]*entities.JobRun),
+		decommissions: make(map[string]time.Time),
+		metadata:      make(map[string]*entities.NodeMetadata),
+		silences:      make(map[string]*entities.Silence),
+		incidents:     make(map[string]*entities.Incident),
+		anomalies:     make(map[string]*entities.RuntimeAnomaly),
+		now:           time.Now,
+	}
+}
+
+func (m *memoryImpl) Ping(_ context.Context) error {
+	return nil
+}
+
+func (m *memoryImpl) Close(_ context.Context) error {
+	return nil
+}
+
+func (m *memoryImpl) Reconnect(_ context.Context, _ string) error {
+	return nil
+}
+
+func (m *memoryImpl) SaveRun(_ context.Context, run *entities.PuppetReport) error {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_run"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.reports[run.ID]; ok {
+		return ErrDuplicate
+	}
+
+	run.ReportFilePath()
+
+	rep := *run
+	m.reports[run.ID] = &rep
+
+	return nil
+}
+
+func (m *memoryImpl) GetRuns(_ context.Context) ([]*entities.PuppetRun, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_runs"))
+	defer t.ObserveDuration()
+
+	return m.runs(func(*entities.PuppetReport) bool { return true }), nil
+}
+
+func (m *memoryImpl) GetRunsByState(_ context.Context, states ...summary.State) ([]*entities.PuppetRun, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_runs_by_state"))
+	defer t.ObserveDuration()
+
+	return m.runs(func(rep *entities.PuppetReport) bool {
+		return slices.Contains(states, rep.State)
+	}), nil
+}
+
+// runs returns the runs of the reports matching the filter, newest first.
+func (m *memoryImpl) runs(filter func(*entities.PuppetReport) bool) []*entities.PuppetRun {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	runs := make([]*entities.PuppetRun, 0)
+	for _, rep := range m.sorted() {
+		if !filter(rep) {
+			continue
+		}
+
+		runs = append(runs, &entities.PuppetRun{
+			ID:       rep.ID,
+			Fqdn:     rep.Fqdn,
+			Env:      rep.Env,
+			State:    rep.State,
+			ExecTime: rep.ExecTime,
+			Runtime:  rep.Runtime,
+			YamlFile: rep.YamlFile,
+		})
+	}
+
+	return runs
+}
+
+func (m *memoryImpl) GetReports(_ context.Context, fqdn string) ([]*entities.PuppetReportSummary, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_reports"))
+	defer t.ObserveDuration()
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	reports := make([]*entities.PuppetReportSummary, 0)
+	for _, rep := range m.sorted() {
+		if rep.Fqdn != fqdn {
+			continue
+		}
+
+		report := &entities.PuppetReportSummary{
+			ID:       rep.ID,
+			Fqdn:     rep.Fqdn,
+			Env:      rep.Env,
+			State:    rep.State,
+			ExecTime: rep.ExecTime,
+			Runtime:  rep.Runtime,
+			Failed:   int(rep.Failed),
+			Changed:  int(rep.Changed),
+			Skipped:  int(rep.Skipped),
+			Total:    int(rep.Total),
+			YamlFile: rep.YamlFile,
+		}
+
+		report.CalculateTimeSince()
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func (m *memoryImpl) GetLatestReports(_ context.Context, fqdn string, before time.Time, limit int) ([]*entities.PuppetReportSummary, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_latest_reports"))
+	defer t.ObserveDuration()
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	reports := make([]*entities.PuppetReportSummary, 0)
+	for _, rep := range m.sorted() {
+		if len(reports) == limit {
+			break
+		}
+		if rep.Fqdn != fqdn || !rep.ExecTime.Time().Before(before) {
+			continue
+		}
+
+		report := &entities.PuppetReportSummary{
+			ID:       rep.ID,
+			Fqdn:     rep.Fqdn,
+			Env:      rep.Env,
+			State:    rep.State,
+			ExecTime: rep.ExecTime,
+			Runtime:  rep.Runtime,
+			Failed:   int(rep.Failed),
+			Changed:  int(rep.Changed),
+			Skipped:  int(rep.Skipped),
+			Total:    int(rep.Total),
+			YamlFile: rep.YamlFile,
+		}
+
+		report.CalculateTimeSince()
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func (m *memoryImpl) GetReport(_ context.Context, id string) (*entities.PuppetReport, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_report"))
+	defer t.ObserveDuration()
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	rep, ok := m.reports[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	report := *rep
+	return &report, nil
+}
+
+func (m *memoryImpl) GetHistory(ctx context.Context, environment ...summary.Environment) ([]*entities.PuppetHistory, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_history"))
+	defer t.ObserveDuration()
+
+	history, err := m.GetHistoryBuckets(ctx, summary.HistoryBucket_day, time.Time{}, time.Time{}, nil, environment...)
+	if err != nil {
+		return nil, err
+	}
+
+	return latestHistory(history, historyDays), nil
+}
+
+func (m *memoryImpl) GetHistoryBuckets(_ context.Context, bucket summary.HistoryBucket, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.PuppetHistory, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_history_buckets"))
+	defer t.ObserveDuration()
+
+	if err := validateHistoryQuery(bucket, environment...); err != nil {
+		return nil, err
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	buckets := make(map[string]*entities.PuppetHistory)
+	for _, rep := range m.reports {
+		if len(environment) > 0 && !slices.Contains(environment, rep.Env) {
+			continue
+		}
+		if fqdns != nil && !slices.Contains(fqdns, rep.Fqdn) {
+			continue
+		}
+
+		execTime := rep.ExecTime.Time()
+		if (!from.IsZero() && execTime.Before(from)) || (!to.IsZero() && !execTime.Before(to)) {
+			continue
+		}
+
+		date := HistoryBucketDate(execTime, bucket)
+		h, ok := buckets[date]
+		if !ok {
+			h = &entities.PuppetHistory{Date: date}
+			buckets[date] = h
+		}
+		h.AddCount(rep.State, 1)
+	}
+
+	history := make([]*entities.PuppetHistory, 0, len(buckets))
+	for _, h := range buckets {
+		history = append(history, h)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Date < history[j].Date
+	})
+
+	return history, nil
+}
+
+func (m *memoryImpl) GetResourceFailures(_ context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.ResourceFailure, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_resource_failures"))
+	defer t.ObserveDuration()
+
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	failures := make([]*entities.ResourceFailure, 0)
+	for _, rep := range m.sorted() {
+		if len(environment) > 0 && !slices.Contains(environment, rep.Env) {
+			continue
+		}
+
+		execTime := rep.ExecTime.Time()
+		if (!from.IsZero() && execTime.Before(from)) || (!to.IsZero() && !execTime.Before(to)) {
+			continue
+		}
+
+		for _, res := range rep.ResourcesFailed {
+			resource := *res
+			failures = append(failures, &entities.ResourceFailure{
+				ReportID: rep.ID,
+				Fqdn:     rep.Fqdn,
+				Env:      rep.Env,
+				ExecTime: rep.ExecTime,
+				Resource: &resource,
+			})
+		}
+	}
+
+	return failures, nil
+}
+
+func (m *memoryImpl) GetRunVersions(_ context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.RunVersion, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_run_versions"))
+	defer t.ObserveDuration()
+
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	versions := make([]*entities.RunVersion, 0)
+	for _, rep := range m.reports {
+		if rep.ConfigVersion == "" || (len(environment) > 0 && !slices.Contains(environment, rep.Env)) {
+			continue
+		}
+
+		execTime := rep.ExecTime.Time()
+		if (!from.IsZero() && execTime.Before(from)) || (!to.IsZero() && !execTime.Before(to)) {
+			continue
+		}
+
+		versions = append(versions, &entities.RunVersion{
+			ReportID:      rep.ID,
+			Fqdn:          rep.Fqdn,
+			Env:           rep.Env,
+			State:         rep.State,
+			ExecTime:      rep.ExecTime,
+			ConfigVersion: rep.ConfigVersion,
+			CodeID:        rep.CodeID,
+		})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		if !versions[i].ExecTime.Time().Equal(versions[j].ExecTime.Time()) {
+			return versions[i].ExecTime.Time().Before(versions[j].ExecTime.Time())
+		}
+		return versions[i].ReportID < versions[j].ReportID
+	})
+
+	return versions, nil
+}
+
+func (m *memoryImpl) GetTimings(_ context.Context, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.TimingSummary, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_timings"))
+	defer t.ObserveDuration()
+
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	byName := make(map[string]*entities.TimingSummary)
+	for _, rep := range m.reports {
+		if len(environment) > 0 && !slices.Contains(environment, rep.Env) {
+			continue
+		}
+		if fqdns != nil && !slices.Contains(fqdns, rep.Fqdn) {
+			continue
+		}
+
+		execTime := rep.ExecTime.Time()
+		if (!from.IsZero() && execTime.Before(from)) || (!to.IsZero() && !execTime.Before(to)) {
+			continue
+		}
+
+		for _, timing := range rep.Timings {
+			sum, ok := byName[timing.Name]
+			if !ok {
+				sum = &entities.TimingSummary{
+					Name:  timing.Name,
+					Label: timing.Label,
+				}
+				byName[timing.Name] = sum
+			}
+
+			sum.Runs++
+			sum.Seconds += timing.Seconds
+			sum.Max = max(sum.Max, timing.Seconds)
+		}
+	}
+
+	timings := make([]*entities.TimingSummary, 0, len(byName))
+	for _, timing := range byName {
+		timings = append(timings, timing)
+	}
+
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].Seconds != timings[j].Seconds {
+			return timings[i].Seconds > timings[j].Seconds
+		}
+		return timings[i].Name < timings[j].Name
+	})
+
+	return timings, nil
+}
+
+func (m *memoryImpl) GetRunMetrics(_ context.Context, from, to time.Time, metrics []string, environment ...summary.Environment) ([]*entities.RunMetric, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_run_metrics"))
+	defer t.ObserveDuration()
+
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	values := make([]*entities.RunMetric, 0)
+	for _, rep := range m.reports {
+		if len(environment) > 0 && !slices.Contains(environment, rep.Env) {
+			continue
+		}
+
+		execTime := rep.ExecTime.Time()
+		if (!from.IsZero() && execTime.Before(from)) || (!to.IsZero() && !execTime.Before(to)) {
+			continue
+		}
+
+		values = append(values, runMetrics(rep, metrics)...)
+	}
+
+	// The metrics of each report are already in order, so a stable sort keeps them that way.
+	sort.SliceStable(values, func(i, j int) bool {
+		ti, tj := values[i].ExecTime.Time(), values[j].ExecTime.Time()
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return values[i].ReportID < values[j].ReportID
+	})
+
+	return values, nil
+}
+
+func (m *memoryImpl) GetEnvironments(_ context.Context) ([]summary.Environment, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_environments"))
+	defer t.ObserveDuration()
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	envs := make([]summary.Environment, 0)
+	for _, rep := range m.reports {
+		if !slices.Contains(envs, rep.Env) {
+			envs = append(envs, rep.Env)
+		}
+	}
+
+	return envs, nil
+}
+
+func (m *memoryImpl) Purge(_ context.Context, from time.Time) (int, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("purge"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	affected := 0
+	for id, rep := range m.reports {
+		if rep.ExecTime.Time().Before(from) {
+			delete(m.reports, id)
+			affected++
+		}
+	}
+
+	for id, inc := range m.incidents {
+		if inc.LastSeen.Time().Before(from) {
+			delete(m.incidents, id)
+		}
+	}
+
+	for id, anomaly := range m.anomalies {
+		if anomaly.ExecTime.Time().Before(from) {
+			delete(m.anomalies, id)
+		}
+	}
+
+	return affected, nil
+}
+
+func (m *memoryImpl) DeleteReports(_ context.Context, ids ...string) (int, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("delete_reports"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	affected := 0
+	for _, id := range ids {
+		if _, ok := m.reports[id]; ok {
+			delete(m.reports, id)
+			affected++
+		}
+		delete(m.anomalies, id)
+	}
+
+	return affected, nil
+}
+
+func (m *memoryImpl) AcquireLock(_ context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("acquire_lock"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	now := m.now()
+
+	// Take over the lock if it has expired, or extend it if it is already held by the holder.
+	if lock, ok := m.locks[name]; ok && lock.holder != holder && lock.expiresAt.After(now) {
+		return false, nil
+	}
+
+	m.locks[name] = &memoryLock{
+		holder:    holder,
+		expiresAt: now.Add(ttl),
+	}
+
+	return true, nil
+}
+
+func (m *memoryImpl) ReleaseLock(_ context.Context, name, holder string) error {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("release_lock"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if lock, ok := m.locks[name]; ok && lock.holder == holder {
+		delete(m.locks, name)
+	}
+
+	return nil
+}
+
+func (m *memoryImpl) SaveJobRun(_ context.Context, run *entities.JobRun) error {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_job_run"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	cp := *run
+	cp.StartedAt = entities.Datetime(cp.StartedAt.Time().UTC().Truncate(time.Second))
+	m.jobRuns[run.Name] = &cp
+
+	return nil
+}
+
+func (m *memoryImpl) GetJobRuns(_ context.Context) ([]*entities.JobRun, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_job_runs"))
+	defer t.ObserveDuration()
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	runs := make([]*entities.JobRun, 0, len(m.jobRuns))
+	for _, run := range m.jobRuns {
+		cp := *run
+		runs = append(runs, &cp)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Name < runs[j].Name
+	})
+
+	return runs, nil
+}
+
+func (m *memoryImpl) DecommissionNode(_ context.Context, fqdn string, at time.Time) error {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("decommission_node"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.decommissions[fqdn] = at.UTC().Truncate(time.Second)
+
+	return nil
+}
+
+func (m *memoryImpl) RecommissionNode(_ context.Context, fqdn string) error {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("recommission_node"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	delete(m.decommissions, fqdn)
+
+	return nil
+}
+
+func (m *memoryImpl) GetDecommissions(_ context.Context) (map[string]time.Time, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_decommissions"))
+	defer t.ObserveDuration()
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	return maps.Clone(m.decommissions), nil
+}
+
+func (m *memoryImpl) GetNodeMetadata(_ context.Context, fqdn string) (*entities.NodeMetadata, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_node_metadata"))
+	defer t.ObserveDuration()
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	md, ok := m.metadata[fqdn]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyNodeMetadata(md), nil
+}
+
+func (m *memoryImpl) GetAllNodeMetadata(_ context.Context) ([]*entities.NodeMetadata, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_all_node_metadata"))
+	defer t.ObserveDuration()
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	metadata := make([]*entities.NodeMetadata, 0, len(m.metadata))
+	for _, md := range m.metadata {
+		metadata = append(metadata, copyNodeMetadata(md))
+	}
+
+	sort.Slice(metadata, func(i, j int) bool {
+		return metadata[i].Fqdn < metadata[j].Fqdn
+	})
+
+	return metadata, nil
+}
+
+func (m *memoryImpl) SaveNodeMetadata(_ context.Context, metadata *entities.NodeMetadata) error {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_node_metadata"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	md := copyNodeMetadata(metadata)
+	md.UpdatedAt = entities.Datetime(md.UpdatedAt.Time().UTC().Truncate(time.Second))
+	m.metadata[md.Fqdn] = md
+
+	return nil
+}
+
+func (m *memoryImpl) DeleteNodeMetadata(_ context.Context, fqdn string) error {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("delete_node_metadata"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.metadata[fqdn]; !ok {
+		return ErrNotFound
+	}
+	delete(m.metadata, fqdn)
+
+	return nil
+}
+
+// copyNodeMetadata returns a copy of the metadata, so that the callers can not change what is held.
+func copyNodeMetadata(md *entities.NodeMetadata) *entities.NodeMetadata {
+	cp := *md
+	cp.Labels = maps.Clone(md.Labels)
+	if cp.Labels == nil {
+		cp.Labels = make(entities.Labels)
+	}
+	return &cp
+}
+
+func (m *memoryImpl) SaveSilence(_ context.Context, silence *entities.Silence) error {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_silence"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	sil := copySilence(silence)
+	sil.StartsAt = entities.Datetime(sil.StartsAt.Time().UTC().Truncate(time.Second))
+	sil.EndsAt = entities.Datetime(sil.EndsAt.Time().UTC().Truncate(time.Second))
+	sil.CreatedAt = entities.Datetime(sil.CreatedAt.Time().UTC().Truncate(time.Second))
+	m.silences[sil.ID] = sil
+
+	return nil
+}
+
+func (m *memoryImpl) GetSilences(_ context.Context) ([]*entities.Silence, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_silences"))
+	defer t.ObserveDuration()
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	silences := make([]*entities.Silence, 0, len(m.silences))
+	for _, sil := range m.silences {
+		silences = append(silences, copySilence(sil))
+	}
+
+	sort.Slice(silences, func(i, j int) bool {
+		if !silences[i].StartsAt.Time().Equal(silences[j].StartsAt.Time()) {
+			return silences[i].StartsAt.Time().Before(silences[j].StartsAt.Time())
+		}
+		return silences[i].ID < silences[j].ID
+	})
+
+	return silences, nil
+}
+
+func (m *memoryImpl) DeleteSilence(_ context.Context, id string) error {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("delete_silence"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.silences[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.silences, id)
+
+	return nil
+}
+
+// copySilence returns a copy of the silence, so that the callers can not change what is held.
+func copySilence(sil *entities.Silence) *entities.Silence {
+	cp := *sil
+	cp.Labels = maps.Clone(sil.Labels)
+	if cp.Labels == nil {
+		cp.Labels = make(entities.Labels)
+	}
+	return &cp
+}
+
+func (m *memoryImpl) SaveIncident(_ context.Context, incident *entities.Incident) error {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_incident"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	inc := copyIncident(incident)
+	inc.FirstSeen = entities.Datetime(inc.FirstSeen.Time().UTC().Truncate(time.Second))
+	inc.LastSeen = entities.Datetime(inc.LastSeen.Time().UTC().Truncate(time.Second))
+	if existing, ok := m.incidents[inc.ID]; ok {
+		inc.Notified = existing.Notified
+	}
+	m.incidents[inc.ID] = inc
+
+	return nil
+}
+
+func (m *memoryImpl) SetIncidentNotified(_ context.Context, id string, notified bool) (bool, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("set_incident_notified"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	inc, ok := m.incidents[id]
+	if !ok {
+		return false, ErrNotFound
+	} else if inc.Notified == notified {
+		return false, nil
+	}
+
+	inc.Notified = notified
+	return true, nil
+}
+
+func (m *memoryImpl) GetIncidents(_ context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.Incident, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_incidents"))
+	defer t.ObserveDuration()
+
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	incidents := make([]*entities.Incident, 0)
+	for _, inc := range m.incidents {
+		if len(environment) > 0 && !slices.Contains(environment, inc.Env) {
+			continue
+		}
+
+		lastSeen := inc.LastSeen.Time()
+		if (!from.IsZero() && lastSeen.Before(from)) || (!to.IsZero() && !lastSeen.Before(to)) {
+			continue
+		}
+
+		incidents = append(incidents, copyIncident(inc))
+	}
+
+	sort.Slice(incidents, func(i, j int) bool {
+		if !incidents[i].LastSeen.Time().Equal(incidents[j].LastSeen.Time()) {
+			return incidents[i].LastSeen.Time().After(incidents[j].LastSeen.Time())
+		}
+		return incidents[i].ID < incidents[j].ID
+	})
+
+	return incidents, nil
+}
+
+func (m *memoryImpl) GetIncident(_ context.Context, id string) (*entities.Incident, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_incident"))
+	defer t.ObserveDuration()
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	inc, ok := m.incidents[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyIncident(inc), nil
+}
+
+// copyIncident returns a copy of the incident, so that the callers can not change what is held.
+func copyIncident(inc *entities.Incident) *entities.Incident {
+	cp := *inc
+	cp.Resources = append(make(entities.Strings, 0, len(inc.Resources)), inc.Resources...)
+	cp.Nodes = append(make(entities.Strings, 0, len(inc.Nodes)), inc.Nodes...)
+	return &cp
+}
+
+func (m *memoryImpl) SaveRuntimeAnomaly(_ context.Context, anomaly *entities.RuntimeAnomaly) error {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_runtime_anomaly"))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	cp := *anomaly
+	cp.ExecTime = entities.Datetime(cp.ExecTime.Time().UTC().Truncate(time.Second))
+	m.anomalies[cp.ReportID] = &cp
+
+	return nil
+}
+
+func (m *memoryImpl) GetRuntimeAnomalies(_ context.Context, fqdn string, from, to time.Time, environment ...summary.Environment) ([]*entities.RuntimeAnomaly, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_runtime_anomalies"))
+	defer t.ObserveDuration()
+
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	anomalies := make([]*entities.RuntimeAnomaly, 0)
+	for _, anomaly := range m.anomalies {
+		if fqdn != "" && anomaly.Fqdn != fqdn {
+			continue
+		}
+		if len(environment) > 0 && !slices.Contains(environment, anomaly.Env) {
+			continue
+		}
+
+		execTime := anomaly.ExecTime.Time()
+		if (!from.IsZero() && execTime.Before(from)) || (!to.IsZero() && !execTime.Before(to)) {
+			continue
+		}
+
+		cp := *anomaly
+		anomalies = append(anomalies, &cp)
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		if !anomalies[i].ExecTime.Time().Equal(anomalies[j].ExecTime.Time()) {
+			return anomalies[i].ExecTime.Time().After(anomalies[j].ExecTime.Time())
+		}
+		return anomalies[i].ReportID < anomalies[j].ReportID
+	})
+
+	return anomalies, nil
+}
+
+// sorted returns the reports, newest first. The caller must hold the lock.
+func (m *memoryImpl) sorted() []*entities.PuppetReport {
+	reports := make([]*entities.PuppetReport, 0, len(m.reports))
+	for _, rep := range m.reports {
+		reports = append(reports, rep)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].ExecTime.Time().After(reports[j].ExecTime.Time())
+	})
+
+	return reports
+}
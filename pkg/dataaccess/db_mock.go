@@ -43,11 +43,36 @@ func (m *MockDb) GetRunsByState(ctx context.Context, states ...summary.State) ([
 	return args.Get(0).([]*entities.PuppetRun), args.Error(1)
 }
 
+func (m *MockDb) GetResourceFailures(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.ResourceFailure, error) {
+	args := m.Called(ctx, from, to, environment)
+	return args.Get(0).([]*entities.ResourceFailure), args.Error(1)
+}
+
+func (m *MockDb) GetRunVersions(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.RunVersion, error) {
+	args := m.Called(ctx, from, to, environment)
+	return args.Get(0).([]*entities.RunVersion), args.Error(1)
+}
+
+func (m *MockDb) GetTimings(ctx context.Context, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.TimingSummary, error) {
+	args := m.Called(ctx, from, to, fqdns, environment)
+	return args.Get(0).([]*entities.TimingSummary), args.Error(1)
+}
+
+func (m *MockDb) GetRunMetrics(ctx context.Context, from, to time.Time, metrics []string, environment ...summary.Environment) ([]*entities.RunMetric, error) {
+	args := m.Called(ctx, from, to, metrics, environment)
+	return args.Get(0).([]*entities.RunMetric), args.Error(1)
+}
+
 func (m *MockDb) GetReports(ctx context.Context, fqdn string) ([]*entities.PuppetReportSummary, error) {
 	args := m.Called(ctx, fqdn)
 	return args.Get(0).([]*entities.PuppetReportSummary), args.Error(1)
 }
 
+func (m *MockDb) GetLatestReports(ctx context.Context, fqdn string, before time.Time, limit int) ([]*entities.PuppetReportSummary, error) {
+	args := m.Called(ctx, fqdn, before, limit)
+	return args.Get(0).([]*entities.PuppetReportSummary), args.Error(1)
+}
+
 func (m *MockDb) GetReport(ctx context.Context, id string) (*entities.PuppetReport, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*entities.PuppetReport), args.Error(1)
@@ -58,6 +83,11 @@ func (m *MockDb) GetHistory(ctx context.Context, environment ...summary.Environm
 	return args.Get(0).([]*entities.PuppetHistory), args.Error(1)
 }
 
+func (m *MockDb) GetHistoryBuckets(ctx context.Context, bucket summary.HistoryBucket, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.PuppetHistory, error) {
+	args := m.Called(ctx, bucket, from, to, fqdns, environment)
+	return args.Get(0).([]*entities.PuppetHistory), args.Error(1)
+}
+
 func (m *MockDb) GetEnvironments(ctx context.Context) ([]summary.Environment, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]summary.Environment), args.Error(1)
@@ -67,3 +97,108 @@ func (m *MockDb) Purge(ctx context.Context, from time.Time) (int, error) {
 	args := m.Called(ctx, from)
 	return args.Int(0), args.Error(1)
 }
+
+func (m *MockDb) DeleteReports(ctx context.Context, ids ...string) (int, error) {
+	args := m.Called(ctx, ids)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDb) AcquireLock(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, name, holder, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDb) ReleaseLock(ctx context.Context, name, holder string) error {
+	args := m.Called(ctx, name, holder)
+	return args.Error(0)
+}
+
+func (m *MockDb) DecommissionNode(ctx context.Context, fqdn string, at time.Time) error {
+	args := m.Called(ctx, fqdn, at)
+	return args.Error(0)
+}
+
+func (m *MockDb) RecommissionNode(ctx context.Context, fqdn string) error {
+	args := m.Called(ctx, fqdn)
+	return args.Error(0)
+}
+
+func (m *MockDb) GetDecommissions(ctx context.Context) (map[string]time.Time, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[string]time.Time), args.Error(1)
+}
+
+func (m *MockDb) GetNodeMetadata(ctx context.Context, fqdn string) (*entities.NodeMetadata, error) {
+	args := m.Called(ctx, fqdn)
+	return args.Get(0).(*entities.NodeMetadata), args.Error(1)
+}
+
+func (m *MockDb) GetAllNodeMetadata(ctx context.Context) ([]*entities.NodeMetadata, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.NodeMetadata), args.Error(1)
+}
+
+func (m *MockDb) SaveNodeMetadata(ctx context.Context, metadata *entities.NodeMetadata) error {
+	args := m.Called(ctx, metadata)
+	return args.Error(0)
+}
+
+func (m *MockDb) DeleteNodeMetadata(ctx context.Context, fqdn string) error {
+	args := m.Called(ctx, fqdn)
+	return args.Error(0)
+}
+
+func (m *MockDb) SaveJobRun(ctx context.Context, run *entities.JobRun) error {
+	args := m.Called(ctx, run)
+	return args.Error(0)
+}
+
+func (m *MockDb) GetJobRuns(ctx context.Context) ([]*entities.JobRun, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.JobRun), args.Error(1)
+}
+
+func (m *MockDb) SaveSilence(ctx context.Context, silence *entities.Silence) error {
+	args := m.Called(ctx, silence)
+	return args.Error(0)
+}
+
+func (m *MockDb) GetSilences(ctx context.Context) ([]*entities.Silence, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entities.Silence), args.Error(1)
+}
+
+func (m *MockDb) DeleteSilence(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDb) SaveIncident(ctx context.Context, incident *entities.Incident) error {
+	args := m.Called(ctx, incident)
+	return args.Error(0)
+}
+
+func (m *MockDb) SetIncidentNotified(ctx context.Context, id string, notified bool) (bool, error) {
+	args := m.Called(ctx, id, notified)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDb) GetIncidents(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.Incident, error) {
+	args := m.Called(ctx, from, to, environment)
+	return args.Get(0).([]*entities.Incident), args.Error(1)
+}
+
+func (m *MockDb) GetIncident(ctx context.Context, id string) (*entities.Incident, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*entities.Incident), args.Error(1)
+}
+
+func (m *MockDb) SaveRuntimeAnomaly(ctx context.Context, anomaly *entities.RuntimeAnomaly) error {
+	args := m.Called(ctx, anomaly)
+	return args.Error(0)
+}
+
+func (m *MockDb) GetRuntimeAnomalies(ctx context.Context, fqdn string, from, to time.Time, environment ...summary.Environment) ([]*entities.RuntimeAnomaly, error) {
+	args := m.Called(ctx, fqdn, from, to, environment)
+	return args.Get(0).([]*entities.RuntimeAnomaly), args.Error(1)
+}
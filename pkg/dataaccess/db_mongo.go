@@ -5,10 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"sort"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
@@ -24,6 +24,14 @@ const mongoDatabase = "puppet-summary"
 type mongodbImpl struct {
 	// client is the database.
 	client *mongo.Client
+
+	// database is the name of the database the collections are stored in.
+	database string
+}
+
+// collection returns the named collection.
+func (m *mongodbImpl) collection(name string) *mongo.Collection {
+	return m.client.Database(m.database).Collection(name)
 }
 
 func (m *mongodbImpl) Reconnect(ctx context.Context, connStr string) error {
@@ -54,27 +62,579 @@ func (m *mongodbImpl) Close(ctx context.Context) error {
 }
 
 func (m *mongodbImpl) Purge(ctx context.Context, from time.Time) (int, error) {
-	collection := m.client.Database(mongoDatabase).Collection("reports")
+	collection := m.collection("reports")
 
 	// Start the prometheus metrics.
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("purge"))
 	defer t.ObserveDuration()
 
-	// Delete the reports from the database out of the given range.
+	// The execution times are stored as RFC3339 strings in UTC, so they can be compared as strings.
 	res, err := collection.DeleteMany(ctx, bson.M{
 		"exec_time": bson.M{
-			"$lt": from.String(),
+			"$lt": from.UTC().Format(time.RFC3339),
 		},
 	})
 	if err != nil {
 		return 0, fmt.Errorf("error purging data: %w", err)
 	}
 
+	_, err = m.collection("incidents").DeleteMany(ctx, bson.M{
+		"last_seen": bson.M{
+			"$lt": from.UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error deleting incidents: %w", err)
+	}
+
+	_, err = m.collection("anomalies").DeleteMany(ctx, bson.M{
+		"exec_time": bson.M{
+			"$lt": from.UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error deleting runtime anomalies: %w", err)
+	}
+
+	return int(res.DeletedCount), nil
+}
+
+func (m *mongodbImpl) DeleteReports(ctx context.Context, ids ...string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	collection := m.collection("reports")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("delete_reports"))
+	defer t.ObserveDuration()
+
+	res, err := collection.DeleteMany(ctx, bson.M{
+		"id": bson.M{
+			"$in": ids,
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error deleting reports: %w", err)
+	}
+
+	_, err = m.collection("anomalies").DeleteMany(ctx, bson.M{
+		"id": bson.M{
+			"$in": ids,
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error deleting runtime anomalies: %w", err)
+	}
+
 	return int(res.DeletedCount), nil
 }
 
+func (m *mongodbImpl) AcquireLock(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	collection := m.collection("locks")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("acquire_lock"))
+	defer t.ObserveDuration()
+
+	now := time.Now().UTC()
+
+	// Take over the lock if it has expired, or extend it if it is already held by the holder. If the lock is held by
+	// someone else, the upsert fails on the unique name index.
+	_, err := collection.UpdateOne(
+		ctx,
+		bson.M{
+			"name": name,
+			"$or": bson.A{
+				bson.M{"holder": holder},
+				bson.M{"expires_at": bson.M{"$lt": now}},
+			},
+		},
+		bson.M{"$set": bson.M{
+			"holder":     holder,
+			"expires_at": now.Add(ttl),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error acquiring lock: %w", err)
+	}
+
+	return true, nil
+}
+
+func (m *mongodbImpl) ReleaseLock(ctx context.Context, name, holder string) error {
+	collection := m.collection("locks")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("release_lock"))
+	defer t.ObserveDuration()
+
+	_, err := collection.DeleteOne(ctx, bson.M{
+		"name":   name,
+		"holder": holder,
+	})
+	if err != nil {
+		return fmt.Errorf("error releasing lock: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongodbImpl) SaveJobRun(ctx context.Context, run *entities.JobRun) error {
+	collection := m.collection("job_runs")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_job_run"))
+	defer t.ObserveDuration()
+
+	cp := *run
+	cp.StartedAt = entities.Datetime(cp.StartedAt.Time().UTC().Truncate(time.Second))
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"name": cp.Name}, &cp, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("error saving job run: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongodbImpl) GetJobRuns(ctx context.Context) ([]*entities.JobRun, error) {
+	collection := m.collection("job_runs")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_job_runs"))
+	defer t.ObserveDuration()
+
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding job runs: %w", err)
+	}
+
+	runs := make([]*entities.JobRun, 0)
+	if err := cursor.All(ctx, &runs); err != nil {
+		return nil, fmt.Errorf("error decoding job runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+func (m *mongodbImpl) DecommissionNode(ctx context.Context, fqdn string, at time.Time) error {
+	collection := m.collection("decommissions")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("decommission_node"))
+	defer t.ObserveDuration()
+
+	_, err := collection.UpdateOne(
+		ctx,
+		bson.M{"fqdn": fqdn},
+		bson.M{"$set": bson.M{"decommissioned_at": at.UTC().Truncate(time.Second)}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("error decommissioning node: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongodbImpl) RecommissionNode(ctx context.Context, fqdn string) error {
+	collection := m.collection("decommissions")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("recommission_node"))
+	defer t.ObserveDuration()
+
+	_, err := collection.DeleteOne(ctx, bson.M{"fqdn": fqdn})
+	if err != nil {
+		return fmt.Errorf("error recommissioning node: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongodbImpl) GetDecommissions(ctx context.Context) (map[string]time.Time, error) {
+	collection := m.collection("decommissions")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_decommissions"))
+	defer t.ObserveDuration()
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("error finding decommissions: %w", err)
+	}
+
+	var docs []struct {
+		Fqdn             string    `bson:"fqdn"`
+		DecommissionedAt time.Time `bson:"decommissioned_at"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("error decoding decommissions: %w", err)
+	}
+
+	decommissions := make(map[string]time.Time, len(docs))
+	for _, doc := range docs {
+		decommissions[doc.Fqdn] = doc.DecommissionedAt.UTC()
+	}
+
+	return decommissions, nil
+}
+
+func (m *mongodbImpl) GetNodeMetadata(ctx context.Context, fqdn string) (*entities.NodeMetadata, error) {
+	collection := m.collection("node_metadata")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_node_metadata"))
+	defer t.ObserveDuration()
+
+	md := new(entities.NodeMetadata)
+	err := collection.FindOne(ctx, bson.M{"fqdn": fqdn}).Decode(md)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error finding node metadata: %w", err)
+	}
+
+	if md.Labels == nil {
+		md.Labels = make(entities.Labels)
+	}
+
+	return md, nil
+}
+
+func (m *mongodbImpl) GetAllNodeMetadata(ctx context.Context) ([]*entities.NodeMetadata, error) {
+	collection := m.collection("node_metadata")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_all_node_metadata"))
+	defer t.ObserveDuration()
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "fqdn", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("error finding node metadata: %w", err)
+	}
+
+	metadata := make([]*entities.NodeMetadata, 0)
+	if err := cursor.All(ctx, &metadata); err != nil {
+		return nil, fmt.Errorf("error decoding node metadata: %w", err)
+	}
+
+	for _, md := range metadata {
+		if md.Labels == nil {
+			md.Labels = make(entities.Labels)
+		}
+	}
+
+	return metadata, nil
+}
+
+func (m *mongodbImpl) SaveNodeMetadata(ctx context.Context, metadata *entities.NodeMetadata) error {
+	collection := m.collection("node_metadata")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_node_metadata"))
+	defer t.ObserveDuration()
+
+	md := *metadata
+	md.UpdatedAt = entities.Datetime(md.UpdatedAt.Time().UTC().Truncate(time.Second))
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"fqdn": md.Fqdn}, &md, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("error saving node metadata: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongodbImpl) DeleteNodeMetadata(ctx context.Context, fqdn string) error {
+	collection := m.collection("node_metadata")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("delete_node_metadata"))
+	defer t.ObserveDuration()
+
+	res, err := collection.DeleteOne(ctx, bson.M{"fqdn": fqdn})
+	if err != nil {
+		return fmt.Errorf("error deleting node metadata: %w", err)
+	} else if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (m *mongodbImpl) SaveSilence(ctx context.Context, silence *entities.Silence) error {
+	collection := m.collection("silences")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_silence"))
+	defer t.ObserveDuration()
+
+	sil := *silence
+	sil.StartsAt = entities.Datetime(sil.StartsAt.Time().UTC().Truncate(time.Second))
+	sil.EndsAt = entities.Datetime(sil.EndsAt.Time().UTC().Truncate(time.Second))
+	sil.CreatedAt = entities.Datetime(sil.CreatedAt.Time().UTC().Truncate(time.Second))
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"id": sil.ID}, &sil, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("error saving silence: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongodbImpl) GetSilences(ctx context.Context) ([]*entities.Silence, error) {
+	collection := m.collection("silences")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_silences"))
+	defer t.ObserveDuration()
+
+	// The times are stored in the RFC3339 format in UTC, so they sort in order.
+	opts := options.Find().SetSort(bson.D{{Key: "starts_at", Value: 1}, {Key: "id", Value: 1}})
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding silences: %w", err)
+	}
+
+	silences := make([]*entities.Silence, 0)
+	if err := cursor.All(ctx, &silences); err != nil {
+		return nil, fmt.Errorf("error decoding silences: %w", err)
+	}
+
+	for _, sil := range silences {
+		if sil.Labels == nil {
+			sil.Labels = make(entities.Labels)
+		}
+	}
+
+	return silences, nil
+}
+
+func (m *mongodbImpl) DeleteSilence(ctx context.Context, id string) error {
+	collection := m.collection("silences")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("delete_silence"))
+	defer t.ObserveDuration()
+
+	res, err := collection.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return fmt.Errorf("error deleting silence: %w", err)
+	} else if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (m *mongodbImpl) SaveIncident(ctx context.Context, incident *entities.Incident) error {
+	collection := m.collection("incidents")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_incident"))
+	defer t.ObserveDuration()
+
+	inc := *incident
+	inc.FirstSeen = entities.Datetime(inc.FirstSeen.Time().UTC().Truncate(time.Second))
+	inc.LastSeen = entities.Datetime(inc.LastSeen.Time().UTC().Truncate(time.Second))
+
+	// Whether an existing incident has been notified is only changed by SetIncidentNotified.
+	update := bson.M{
+		"$set": bson.M{
+			"env":                   inc.Env,
+			"configuration_version": inc.ConfigVersion,
+			"resources":             inc.Resources,
+			"message":               inc.Message,
+			"first_seen":            inc.FirstSeen,
+			"last_seen":             inc.LastSeen,
+			"nodes":                 inc.Nodes,
+			"reports":               inc.Reports,
+		},
+		"$setOnInsert": bson.M{
+			"notified": inc.Notified,
+		},
+	}
+
+	_, err := collection.UpdateOne(ctx, bson.M{"id": inc.ID}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("error saving incident: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongodbImpl) SetIncidentNotified(ctx context.Context, id string, notified bool) (bool, error) {
+	collection := m.collection("incidents")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("set_incident_notified"))
+	defer t.ObserveDuration()
+
+	// The incident is only updated if it does not have the value yet, so that of the callers setting it at the same
+	// time, only one changes it.
+	res, err := collection.UpdateOne(ctx,
+		bson.M{"id": id, "notified": bson.M{"$ne": notified}},
+		bson.M{"$set": bson.M{"notified": notified}},
+	)
+	if err != nil {
+		return false, fmt.Errorf("error setting incident notified: %w", err)
+	} else if res.ModifiedCount > 0 {
+		return true, nil
+	}
+
+	// Nothing was changed, either because the incident already has the value or because there is no such incident.
+	n, err := collection.CountDocuments(ctx, bson.M{"id": id})
+	if err != nil {
+		return false, fmt.Errorf("error counting incidents: %w", err)
+	} else if n == 0 {
+		return false, ErrNotFound
+	}
+	return false, nil
+}
+
+func (m *mongodbImpl) GetIncidents(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.Incident, error) {
+	collection := m.collection("incidents")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_incidents"))
+	defer t.ObserveDuration()
+
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	filter := bson.M{}
+	if len(environment) > 0 {
+		filter["env"] = bson.M{
+			"$in": environment,
+		}
+	}
+
+	// The times are stored as RFC3339 strings in UTC, so they can be compared as strings.
+	lastSeen := bson.M{}
+	if !from.IsZero() {
+		lastSeen["$gte"] = from.UTC().Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		lastSeen["$lt"] = to.UTC().Format(time.RFC3339)
+	}
+	if len(lastSeen) > 0 {
+		filter["last_seen"] = lastSeen
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen", Value: -1}, {Key: "id", Value: 1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding incidents: %w", err)
+	}
+
+	incidents := make([]*entities.Incident, 0)
+	if err := cursor.All(ctx, &incidents); err != nil {
+		return nil, fmt.Errorf("error decoding incidents: %w", err)
+	}
+
+	return incidents, nil
+}
+
+func (m *mongodbImpl) GetIncident(ctx context.Context, id string) (*entities.Incident, error) {
+	collection := m.collection("incidents")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_incident"))
+	defer t.ObserveDuration()
+
+	var incident entities.Incident
+	err := collection.FindOne(ctx, bson.M{"id": bson.M{
+		"$eq": id,
+		"$ne": "",
+	}}).Decode(&incident)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error getting incident: %w", err)
+	}
+
+	return &incident, nil
+}
+
+func (m *mongodbImpl) SaveRuntimeAnomaly(ctx context.Context, anomaly *entities.RuntimeAnomaly) error {
+	collection := m.collection("anomalies")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_runtime_anomaly"))
+	defer t.ObserveDuration()
+
+	cp := *anomaly
+	cp.ExecTime = entities.Datetime(cp.ExecTime.Time().UTC().Truncate(time.Second))
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"id": cp.ReportID}, &cp, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("error saving runtime anomaly: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mongodbImpl) GetRuntimeAnomalies(ctx context.Context, fqdn string, from, to time.Time, environment ...summary.Environment) ([]*entities.RuntimeAnomaly, error) {
+	collection := m.collection("anomalies")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_runtime_anomalies"))
+	defer t.ObserveDuration()
+
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	filter := bson.M{}
+	if fqdn != "" {
+		filter["fqdn"] = fqdn
+	}
+	if len(environment) > 0 {
+		filter["env"] = bson.M{
+			"$in": environment,
+		}
+	}
+
+	// The times are stored as RFC3339 strings in UTC, so they can be compared as strings.
+	execTime := bson.M{}
+	if !from.IsZero() {
+		execTime["$gte"] = from.UTC().Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		execTime["$lt"] = to.UTC().Format(time.RFC3339)
+	}
+	if len(execTime) > 0 {
+		filter["exec_time"] = execTime
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "exec_time", Value: -1}, {Key: "id", Value: 1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding runtime anomalies: %w", err)
+	}
+
+	anomalies := make([]*entities.RuntimeAnomaly, 0)
+	if err := cursor.All(ctx, &anomalies); err != nil {
+		return nil, fmt.Errorf("error decoding runtime anomalies: %w", err)
+	}
+
+	return anomalies, nil
+}
+
 func (m *mongodbImpl) GetEnvironments(ctx context.Context) ([]summary.Environment, error) {
-	collection := m.client.Database(mongoDatabase).Collection("reports")
+	collection := m.collection("reports")
 
 	// Start the prometheus metrics.
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_environments"))
@@ -101,124 +661,435 @@ func (m *mongodbImpl) GetEnvironments(ctx context.Context) ([]summary.Environmen
 	return environments, nil
 }
 
-func (m *mongodbImpl) GetHistory(ctx context.Context, environment ...summary.Environment) ([]*entities.PuppetHistory, error) {
-	// First get the distinct dates from the database.
-	collection := m.client.Database(mongoDatabase).Collection("reports")
+// mongoHistoryBucket is a bucket of history, as returned by the history aggregation pipeline.
+type mongoHistoryBucket struct {
+	// Date is the date of the bucket.
+	Date string `bson:"_id"`
+
+	// States is the number of reports for each state in the bucket.
+	States []struct {
+		State summary.State `bson:"state"`
+		Count int           `bson:"count"`
+	} `bson:"states"`
+}
+
+// mongoHistoryBuckets are the expressions of the dates of the buckets of history, keyed by the bucket. The execution
+// times are stored as RFC3339 strings, so the date is the first 10 characters and the hour the next 3.
+var mongoHistoryBuckets = map[summary.HistoryBucket]any{
+	summary.HistoryBucket_hour: bson.D{{Key: "$concat", Value: bson.A{
+		bson.D{{Key: "$substrBytes", Value: bson.A{"$exec_time", 0, 13}}},
+		":00",
+	}}},
+	summary.HistoryBucket_day: bson.D{{Key: "$substrBytes", Value: bson.A{"$exec_time", 0, 10}}},
+	summary.HistoryBucket_week: bson.D{{Key: "$dateToString", Value: bson.D{
+		{Key: "format", Value: "%Y-%m-%d"},
+		{Key: "date", Value: bson.D{{Key: "$dateTrunc", Value: bson.D{
+			{Key: "date", Value: bson.D{{Key: "$dateFromString", Value: bson.D{
+				{Key: "dateString", Value: bson.D{{Key: "$substrBytes", Value: bson.A{"$exec_time", 0, 10}}}},
+			}}}},
+			{Key: "unit", Value: "week"},
+			{Key: "startOfWeek", Value: "monday"},
+		}}}},
+	}}},
+}
 
+func (m *mongodbImpl) GetHistory(ctx context.Context, environment ...summary.Environment) ([]*entities.PuppetHistory, error) {
 	// Start the prometheus metrics.
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_history"))
+	defer t.ObserveDuration()
+
+	history, err := m.GetHistoryBuckets(ctx, summary.HistoryBucket_day, time.Time{}, time.Time{}, nil, environment...)
+	if err != nil {
+		return nil, err
+	}
+
+	return latestHistory(history, historyDays), nil
+}
+
+func (m *mongodbImpl) GetHistoryBuckets(ctx context.Context, bucket summary.HistoryBucket, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.PuppetHistory, error) {
+	collection := m.collection("reports")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_history_buckets"))
+	defer t.ObserveDuration()
+
+	if err := validateHistoryQuery(bucket, environment...); err != nil {
+		return nil, err
+	}
 
-	envFilter := bson.M{}
-	if environment != nil {
-		envFilter["env"] = bson.M{
+	match := bson.M{}
+	if len(environment) > 0 {
+		match["env"] = bson.M{
 			"$in": environment,
 		}
 	}
+	if fqdns != nil {
+		match["fqdn"] = bson.M{
+			"$in": fqdns,
+		}
+	}
+
+	// The execution times are stored as RFC3339 strings in UTC, so they can be compared as strings.
+	execTime := bson.M{}
+	if !from.IsZero() {
+		execTime["$gte"] = from.UTC().Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		execTime["$lt"] = to.UTC().Format(time.RFC3339)
+	}
+	if len(execTime) > 0 {
+		match["exec_time"] = execTime
+	}
+
+	// The reports are counted by bucket and state, and the buckets are returned oldest first.
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "date", Value: mongoHistoryBuckets[bucket]},
+				{Key: "state", Value: "$state"},
+			}},
+			{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+		}}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$_id.date"},
+			{Key: "states", Value: bson.D{{Key: "$push", Value: bson.D{
+				{Key: "state", Value: "$_id.state"},
+				{Key: "count", Value: "$count"},
+			}}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	}
 
-	cursor, err := collection.Distinct(ctx, "exec_time", envFilter)
+	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, fmt.Errorf("error getting history: %w", err)
 	}
 
-	t.ObserveDuration()
+	buckets := make([]*mongoHistoryBucket, 0)
+	if err := cursor.All(ctx, &buckets); err != nil {
+		return nil, fmt.Errorf("error getting history: %w", err)
+	}
 
-	datesMap := make(map[string]bool)
-	for _, date := range cursor {
-		// Convert the date-times to a time value.
-		dateTime, err := time.Parse(time.RFC3339, date.(string))
-		if err != nil {
-			slog.Error("Error parsing date", slog.String(logging.KeyError, err.Error()))
-			continue
+	history := make([]*entities.PuppetHistory, 0, len(buckets))
+	for _, b := range buckets {
+		h := &entities.PuppetHistory{
+			Date: b.Date,
 		}
+		for _, state := range b.States {
+			h.AddCount(state.State, state.Count)
+		}
+		history = append(history, h)
+	}
+
+	return history, nil
+}
+
+func (m *mongodbImpl) GetResourceFailures(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.ResourceFailure, error) {
+	collection := m.collection("reports")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_resource_failures"))
+	defer t.ObserveDuration()
 
-		// Convert the time value to a date string.
-		dateString := dateTime.Format(time.DateOnly)
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
 
-		// Add the date to the map.
-		datesMap[dateString] = true
+	// The failed resources are stored in the reports, so only the reports with any are read.
+	filter := bson.M{
+		"resources_failed.0": bson.M{
+			"$exists": true,
+		},
+	}
+	if len(environment) > 0 {
+		filter["env"] = bson.M{
+			"$in": environment,
+		}
 	}
 
-	// Convert the map to a slice.
-	dates := make([]string, 0, len(datesMap))
-	for date := range datesMap {
-		dates = append(dates, date)
+	// The execution times are stored as RFC3339 strings in UTC, so they can be compared as strings.
+	execTime := bson.M{}
+	if !from.IsZero() {
+		execTime["$gte"] = from.UTC().Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		execTime["$lt"] = to.UTC().Format(time.RFC3339)
 	}
+	if len(execTime) > 0 {
+		filter["exec_time"] = execTime
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "exec_time", Value: -1}}).
+		SetProjection(bson.M{"id": 1, "fqdn": 1, "env": 1, "exec_time": 1, "resources_failed": 1})
 
-	limit := 30
-	if len(dates) < limit {
-		limit = len(dates)
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting failed resources: %w", err)
 	}
 
-	// Sort the dates in reverse order.
-	sort.Slice(dates, func(i, j int) bool {
-		// Parse the dates.
-		iDate, err := time.Parse(time.DateOnly, dates[i])
-		if err != nil {
-			slog.Error("Error parsing date", slog.String(logging.KeyError, err.Error()))
-			return false
-		}
-		jDate, err := time.Parse(time.DateOnly, dates[j])
-		if err != nil {
-			slog.Error("Error parsing date", slog.String(logging.KeyError, err.Error()))
-			return false
+	reports := make([]*entities.PuppetReport, 0)
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, fmt.Errorf("error getting failed resources: %w", err)
+	}
+
+	failures := make([]*entities.ResourceFailure, 0)
+	for _, rep := range reports {
+		for _, res := range rep.ResourcesFailed {
+			failures = append(failures, &entities.ResourceFailure{
+				ReportID: rep.ID,
+				Fqdn:     rep.Fqdn,
+				Env:      rep.Env,
+				ExecTime: rep.ExecTime,
+				Resource: res,
+			})
 		}
+	}
 
-		return iDate.After(jDate)
-	})
+	return failures, nil
+}
 
-	// Get the last "limit" dates.
-	dates = dates[:limit]
+func (m *mongodbImpl) GetRunVersions(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.RunVersion, error) {
+	collection := m.collection("reports")
 
-	// Get the reports for each date.
-	historyMap := make(map[string]*entities.PuppetHistory)
-	for _, date := range dates {
-		// Get the reports for the date. This has to be done between midnight and midnight.
-		startTime, err := time.Parse(time.DateOnly, date)
-		if err != nil {
-			slog.Error("Error parsing date", slog.String(logging.KeyError, err.Error()))
-			continue
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_run_versions"))
+	defer t.ObserveDuration()
+
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
+		}
+	}
+
+	// The versions are stored in the reports, so only the reports with one are read.
+	filter := bson.M{
+		"configuration_version": bson.M{
+			"$nin": bson.A{"", nil},
+		},
+	}
+	if len(environment) > 0 {
+		filter["env"] = bson.M{
+			"$in": environment,
 		}
+	}
+
+	// The execution times are stored as RFC3339 strings in UTC, so they can be compared as strings.
+	execTime := bson.M{}
+	if !from.IsZero() {
+		execTime["$gte"] = from.UTC().Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		execTime["$lt"] = to.UTC().Format(time.RFC3339)
+	}
+	if len(execTime) > 0 {
+		filter["exec_time"] = execTime
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "exec_time", Value: 1}, {Key: "id", Value: 1}}).
+		SetProjection(bson.M{
+			"id":                    1,
+			"fqdn":                  1,
+			"env":                   1,
+			"state":                 1,
+			"exec_time":             1,
+			"configuration_version": 1,
+			"code_id":               1,
+		})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting run versions: %w", err)
+	}
+
+	versions := make([]*entities.RunVersion, 0)
+	if err := cursor.All(ctx, &versions); err != nil {
+		return nil, fmt.Errorf("error getting run versions: %w", err)
+	}
+
+	return versions, nil
+}
+
+func (m *mongodbImpl) GetTimings(ctx context.Context, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.TimingSummary, error) {
+	collection := m.collection("reports")
 
-		endTime := startTime.AddDate(0, 0, 1)
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_timings"))
+	defer t.ObserveDuration()
 
-		filter := envFilter
-		filter["exec_time"] = bson.M{
-			"$gte": startTime.Format(time.RFC3339),
-			"$lt":  endTime.Format(time.RFC3339),
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
 		}
+	}
+
+	// The timings are stored in the reports, so only the reports with any are read.
+	match := bson.M{
+		"timings.0": bson.M{
+			"$exists": true,
+		},
+	}
+	if len(environment) > 0 {
+		match["env"] = bson.M{
+			"$in": environment,
+		}
+	}
+	if fqdns != nil {
+		match["fqdn"] = bson.M{
+			"$in": fqdns,
+		}
+	}
+
+	// The execution times are stored as RFC3339 strings in UTC, so they can be compared as strings.
+	execTime := bson.M{}
+	if !from.IsZero() {
+		execTime["$gte"] = from.UTC().Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		execTime["$lt"] = to.UTC().Format(time.RFC3339)
+	}
+	if len(execTime) > 0 {
+		match["exec_time"] = execTime
+	}
+
+	// The timings are aggregated by name, and returned most time spent first.
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$timings"}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$timings.name"},
+			{Key: "label", Value: bson.D{{Key: "$max", Value: "$timings.label"}}},
+			{Key: "runs", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "seconds", Value: bson.D{{Key: "$sum", Value: "$timings.seconds"}}},
+			{Key: "max", Value: bson.D{{Key: "$max", Value: "$timings.seconds"}}},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "seconds", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("error getting timings: %w", err)
+	}
 
-		// For each state, count the number of reports.
-		for _, state := range []summary.State{summary.State_CHANGED, summary.State_FAILED, summary.State_SKIPPED, summary.State_UNCHANGED} {
-			f := filter
-			f["state"] = state
+	timings := make([]*entities.TimingSummary, 0)
+	if err := cursor.All(ctx, &timings); err != nil {
+		return nil, fmt.Errorf("error getting timings: %w", err)
+	}
 
-			cur, err := collection.CountDocuments(ctx, f)
-			if err != nil {
-				return nil, fmt.Errorf("error getting history: %w", err)
-			}
+	return timings, nil
+}
 
-			// Retrieve the count for the state.
-			count := int(cur)
+func (m *mongodbImpl) GetRunMetrics(ctx context.Context, from, to time.Time, metrics []string, environment ...summary.Environment) ([]*entities.RunMetric, error) {
+	collection := m.collection("reports")
 
-			// Create the history object for this date if it doesn't exist.
-			if _, ok := historyMap[date]; !ok {
-				historyMap[date] = &entities.PuppetHistory{
-					Date: date,
-				}
-			}
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_run_metrics"))
+	defer t.ObserveDuration()
 
-			// Add the count to the history object.
-			historyMap[date].AddCount(state, count)
+	for _, env := range environment {
+		if !env.IsValid() {
+			return nil, fmt.Errorf("invalid environment: %s", env)
 		}
 	}
 
-	// Convert the map to a slice.
-	history := make([]*entities.PuppetHistory, 0, len(historyMap))
-	for _, h := range historyMap {
-		history = append(history, h)
+	// The metrics are stored in the reports, so only the reports with any are read.
+	filter := bson.M{
+		"metrics": bson.M{
+			"$exists": true,
+			"$ne":     nil,
+		},
+	}
+	if len(environment) > 0 {
+		filter["env"] = bson.M{
+			"$in": environment,
+		}
 	}
 
-	return history, nil
+	// The execution times are stored as RFC3339 strings in UTC, so they can be compared as strings.
+	execTime := bson.M{}
+	if !from.IsZero() {
+		execTime["$gte"] = from.UTC().Format(time.RFC3339)
+	}
+	if !to.IsZero() {
+		execTime["$lt"] = to.UTC().Format(time.RFC3339)
+	}
+	if len(execTime) > 0 {
+		filter["exec_time"] = execTime
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "exec_time", Value: 1}, {Key: "id", Value: 1}}).
+		SetProjection(bson.M{
+			"id":        1,
+			"fqdn":      1,
+			"env":       1,
+			"exec_time": 1,
+			"metrics":   1,
+		})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting run metrics: %w", err)
+	}
+
+	reports := make([]*entities.PuppetReport, 0)
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, fmt.Errorf("error getting run metrics: %w", err)
+	}
+
+	values := make([]*entities.RunMetric, 0)
+	for _, rep := range reports {
+		values = append(values, runMetrics(rep, metrics)...)
+	}
+
+	return values, nil
+}
+
+func (m *mongodbImpl) GetLatestReports(ctx context.Context, fqdn string, before time.Time, limit int) ([]*entities.PuppetReportSummary, error) {
+	if fqdn == "" {
+		return nil, errors.New("fqdn cannot be empty")
+	}
+
+	collection := m.collection("reports")
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_latest_reports"))
+	defer t.ObserveDuration()
+
+	// The execution times are stored as RFC3339 strings in UTC, so they can be compared as strings.
+	filter := bson.M{
+		"fqdn": bson.M{
+			"$eq": fqdn,
+			"$ne": "",
+		},
+		"exec_time": bson.M{
+			"$lt": before.UTC().Format(time.RFC3339),
+		},
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "exec_time", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reports: %w", err)
+	}
+
+	reports := make([]*entities.PuppetReportSummary, 0)
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, fmt.Errorf("error getting reports: %w", err)
+	}
+
+	for _, report := range reports {
+		report.CalculateTimeSince()
+	}
+
+	return reports, nil
 }
 
 func (m *mongodbImpl) GetReport(ctx context.Context, id string) (*entities.PuppetReport, error) {
@@ -226,7 +1097,7 @@ func (m *mongodbImpl) GetReport(ctx context.Context, id string) (*entities.Puppe
 		return nil, errors.New("id cannot be empty")
 	}
 
-	collection := m.client.Database(mongoDatabase).Collection("reports")
+	collection := m.collection("reports")
 
 	// Start the prometheus metrics.
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_report"))
@@ -251,7 +1122,7 @@ func (m *mongodbImpl) GetReports(ctx context.Context, fqdn string) ([]*entities.
 		return nil, errors.New("fqdn cannot be empty")
 	}
 
-	collection := m.client.Database(mongoDatabase).Collection("reports")
+	collection := m.collection("reports")
 
 	// Start the prometheus metrics.
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_reports"))
@@ -259,7 +1130,7 @@ func (m *mongodbImpl) GetReports(ctx context.Context, fqdn string) ([]*entities.
 	cursor, err := collection.Find(ctx, bson.M{"fqdn": bson.M{
 		"$eq": fqdn,
 		"$ne": "", // This is to ensure that the fqdn is not empty. AKA NOSQL injection.
-	}})
+	}}, options.Find().SetSort(bson.D{{Key: "exec_time", Value: -1}}))
 	if err != nil {
 		return nil, fmt.Errorf("error getting reports: %w", err)
 	}
@@ -295,7 +1166,7 @@ func (m *mongodbImpl) Ping(ctx context.Context) error {
 }
 
 func (m *mongodbImpl) GetRunsByState(ctx context.Context, states ...summary.State) ([]*entities.PuppetRun, error) {
-	collection := m.client.Database(mongoDatabase).Collection("reports")
+	collection := m.collection("reports")
 
 	// Start the prometheus metrics.
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_nodes_by_state"))
@@ -308,7 +1179,7 @@ func (m *mongodbImpl) GetRunsByState(ctx context.Context, states ...summary.Stat
 		},
 	}
 
-	cursor, err := collection.Find(ctx, filter)
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "exec_time", Value: -1}}))
 	if err != nil {
 		return nil, fmt.Errorf("error getting nodes: %w", err)
 	}
@@ -322,13 +1193,13 @@ func (m *mongodbImpl) GetRunsByState(ctx context.Context, states ...summary.Stat
 }
 
 func (m *mongodbImpl) GetRuns(ctx context.Context) ([]*entities.PuppetRun, error) {
-	collection := m.client.Database(mongoDatabase).Collection("reports")
+	collection := m.collection("reports")
 
 	// Start the prometheus metrics.
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_nodes"))
 	defer t.ObserveDuration()
 
-	cursor, err := collection.Find(ctx, bson.M{})
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "exec_time", Value: -1}}))
 	if err != nil {
 		return nil, fmt.Errorf("error getting nodes: %w", err)
 	}
@@ -342,20 +1213,21 @@ func (m *mongodbImpl) GetRuns(ctx context.Context) ([]*entities.PuppetRun, error
 }
 
 func (m *mongodbImpl) SaveRun(ctx context.Context, report *entities.PuppetReport) error {
-	collection := m.client.Database(mongoDatabase).Collection("reports")
+	collection := m.collection("reports")
 
 	// Start the prometheus metrics.
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_run"))
 	defer t.ObserveDuration()
 
-	opts := options.Update().SetUpsert(true)
-	_, err := collection.UpdateOne(
-		ctx,
-		bson.M{"id": report.ID},
-		bson.M{"$set": report},
-		opts,
-	)
-	if err != nil {
+	// Store the file the report is saved to, as the SQL databases do.
+	report.ReportFilePath()
+
+	// The id is unique, so a duplicate report fails on the index rather than replacing the existing report.
+	_, err := collection.InsertOne(ctx, report)
+	if mongo.IsDuplicateKeyError(err) {
+		slog.Warn("Hash already exists, ignoring", slog.String(logging.KeyHash, report.ID))
+		return ErrDuplicate
+	} else if err != nil {
 		return fmt.Errorf("error saving run: %w", err)
 	}
 	return nil
@@ -385,9 +1257,147 @@ func NewMongo(ctx context.Context, v *viper.Viper) (Database, error) {
 		return nil, errors.New("nil MongoDB client")
 	}
 
+	database := v.GetString("db.name")
+	if database == "" {
+		database = mongoDatabase
+	}
+
 	impl := &mongodbImpl{
-		client: client,
+		client:   client,
+		database: database,
+	}
+
+	if err := impl.setup(ctx); err != nil {
+		return nil, fmt.Errorf("error setting up database: %w", err)
 	}
 
 	return impl, nil
 }
+
+func (m *mongodbImpl) setup(ctx context.Context) error {
+	// The id must be unique, so that duplicate reports are rejected. The other indexes cover the queries made.
+	_, err := m.collection("reports").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "fqdn", Value: 1}, {Key: "exec_time", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "env", Value: 1}, {Key: "exec_time", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "state", Value: 1}, {Key: "exec_time", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "exec_time", Value: -1}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating reports indexes: %w", err)
+	}
+
+	// The lock name must be unique, so that only one holder can hold a lock.
+	_, err = m.collection("locks").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating locks index: %w", err)
+	}
+
+	// A job only has one last run.
+	_, err = m.collection("job_runs").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating job_runs index: %w", err)
+	}
+
+	// A node is only decommissioned once.
+	_, err = m.collection("decommissions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "fqdn", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating decommissions index: %w", err)
+	}
+
+	// A node only has one set of metadata.
+	_, err = m.collection("node_metadata").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "fqdn", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating node_metadata index: %w", err)
+	}
+
+	_, err = m.collection("silences").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating silences index: %w", err)
+	}
+
+	return m.migrateDatetimesToUTC(ctx)
+}
+
+// migrationDatetimesUTC is the name of the migration moving the times stored with the offset of the nodes to UTC.
+const migrationDatetimesUTC = "datetimes_utc"
+
+// mongoDatetimeFields are the fields stored as RFC3339 strings, by collection.
+var mongoDatetimeFields = []struct {
+	collection string
+	field      string
+}{
+	{"reports", "exec_time"},
+	{"anomalies", "exec_time"},
+	{"incidents", "first_seen"},
+	{"incidents", "last_seen"},
+	{"job_runs", "started_at"},
+	{"node_metadata", "updated_at"},
+	{"silences", "starts_at"},
+	{"silences", "ends_at"},
+	{"silences", "created_at"},
+}
+
+// migrateDatetimesToUTC rewrites the times stored with the offset they were reported with, before they were stored in
+// UTC, so that they compare as strings with the times stored since. Times already in UTC are left alone, so the
+// migration is safe to run again if it is interrupted; once it completes it is recorded in the migrations collection.
+func (m *mongodbImpl) migrateDatetimesToUTC(ctx context.Context) error {
+	migrations := m.collection("migrations")
+
+	err := migrations.FindOne(ctx, bson.M{"_id": migrationDatetimesUTC}).Err()
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return fmt.Errorf("error getting migration: %w", err)
+	}
+
+	migrated := int64(0)
+	for _, f := range mongoDatetimeFields {
+		res, err := m.collection(f.collection).UpdateMany(ctx,
+			bson.M{f.field: bson.M{"$type": "string", "$not": primitive.Regex{Pattern: "Z$"}}},
+			mongo.Pipeline{{{Key: "$set", Value: bson.M{f.field: bson.M{"$dateToString": bson.M{
+				"date":     bson.M{"$dateFromString": bson.M{"dateString": "$" + f.field}},
+				"format":   "%Y-%m-%dT%H:%M:%SZ",
+				"timezone": "UTC",
+			}}}}}},
+		)
+		if err != nil {
+			return fmt.Errorf("error migrating %s.%s: %w", f.collection, f.field, err)
+		}
+		migrated += res.ModifiedCount
+	}
+
+	_, err = migrations.InsertOne(ctx, bson.M{"_id": migrationDatetimesUTC, "applied_at": time.Now().UTC()})
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("error saving migration: %w", err)
+	}
+
+	slog.Info("Times migrated to UTC", slog.Int64("documents", migrated))
+	return nil
+}
@@ -0,0 +1,92 @@
+package dataaccess
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TestMongoSetup_MigratesDatetimesToUTC runs against the MongoDB server at MONGO_TEST_URI, in a database of its own
+// that is dropped afterwards.
+func TestMongoSetup_MigratesDatetimesToUTC(t *testing.T) {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+
+	ctx := context.Background()
+
+	v := viper.New()
+	v.Set("db.conn_str", uri)
+	v.Set("db.name", fmt.Sprintf("puppet-summary-test-%d", time.Now().UnixNano()))
+
+	db, err := NewMongo(ctx, v)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, DropMongoDatabase(ctx, db))
+		require.NoError(t, db.Close(ctx))
+	})
+
+	impl := db.(*mongodbImpl)
+
+	// Store the times as they were before they were stored in UTC, and forget the migration ran.
+	_, err = impl.collection("migrations").DeleteMany(ctx, bson.M{})
+	require.NoError(t, err)
+	_, err = impl.collection("reports").InsertMany(ctx, []any{
+		bson.M{"id": "local", "exec_time": "2024-02-13T12:00:00+02:00"},
+		bson.M{"id": "utc", "exec_time": "2024-02-13T12:00:00Z"},
+	})
+	require.NoError(t, err)
+	_, err = impl.collection("silences").InsertOne(ctx, bson.M{
+		"id":         "silence",
+		"starts_at":  "2024-02-13T12:00:00-05:00",
+		"ends_at":    "2024-02-14T12:00:00-05:00",
+		"created_at": "2024-02-13T12:00:00Z",
+	})
+	require.NoError(t, err)
+
+	execTimes := func() map[string]string {
+		cursor, err := impl.collection("reports").Find(ctx, bson.M{})
+		require.NoError(t, err)
+
+		var docs []struct {
+			ID       string `bson:"id"`
+			ExecTime string `bson:"exec_time"`
+		}
+		require.NoError(t, cursor.All(ctx, &docs))
+
+		got := make(map[string]string, len(docs))
+		for _, doc := range docs {
+			got[doc.ID] = doc.ExecTime
+		}
+		return got
+	}
+
+	require.NoError(t, impl.setup(ctx))
+	require.Equal(t, map[string]string{
+		"local": "2024-02-13T10:00:00Z",
+		"utc":   "2024-02-13T12:00:00Z",
+	}, execTimes())
+
+	var silence struct {
+		StartsAt  string `bson:"starts_at"`
+		EndsAt    string `bson:"ends_at"`
+		CreatedAt string `bson:"created_at"`
+	}
+	require.NoError(t, impl.collection("silences").FindOne(ctx, bson.M{"id": "silence"}).Decode(&silence))
+	require.Equal(t, "2024-02-13T17:00:00Z", silence.StartsAt)
+	require.Equal(t, "2024-02-14T17:00:00Z", silence.EndsAt)
+	require.Equal(t, "2024-02-13T12:00:00Z", silence.CreatedAt)
+
+	// The migration only runs once.
+	_, err = impl.collection("reports").InsertOne(ctx, bson.M{"id": "later", "exec_time": "2024-02-13T12:00:00+02:00"})
+	require.NoError(t, err)
+	require.NoError(t, impl.setup(ctx))
+	require.Equal(t, "2024-02-13T12:00:00+02:00", execTimes()["later"])
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
@@ -51,22 +52,644 @@ func (m *mysqlImpl) Purge(ctx context.Context, from time.Time) (int, error) {
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("purge"))
 	defer t.ObserveDuration()
 
+	// The failed resources, versions, timings, metrics and runtime anomalies of the reports are deleted with them, as
+	// are the incidents last seen before the reports.
+	tx, err := m.client.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer rollback(tx)
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM failed_resources
+	WHERE executed_at < ?;
+`, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error deleting failed resources: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM run_versions
+	WHERE executed_at < ?;
+`, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error deleting run versions: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM run_timings
+	WHERE executed_at < ?;
+`, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error deleting run timings: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM run_metrics
+	WHERE executed_at < ?;
+`, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error deleting run metrics: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM runtime_anomalies
+	WHERE executed_at < ?;
+`, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error deleting runtime anomalies: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM incidents
+	WHERE last_seen < ?;
+`, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error deleting incidents: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return 0, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	res, err := stmt.ExecContext(ctx, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return int(affected), nil
+}
+
+func (m *mysqlImpl) DeleteReports(ctx context.Context, ids ...string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	sqlStmt := `
+	DELETE FROM reports
+	WHERE hash IN (?);
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("delete_reports"))
+	defer t.ObserveDuration()
+
+	query, args, err := sqlx.In(sqlStmt, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	failedQuery, failedArgs, err := sqlx.In(`
+	DELETE FROM failed_resources
+	WHERE report_hash IN (?);
+`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	versionsQuery, versionsArgs, err := sqlx.In(`
+	DELETE FROM run_versions
+	WHERE report_hash IN (?);
+`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	timingsQuery, timingsArgs, err := sqlx.In(`
+	DELETE FROM run_timings
+	WHERE report_hash IN (?);
+`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	metricsQuery, metricsArgs, err := sqlx.In(`
+	DELETE FROM run_metrics
+	WHERE report_hash IN (?);
+`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	anomaliesQuery, anomaliesArgs, err := sqlx.In(`
+	DELETE FROM runtime_anomalies
+	WHERE report_hash IN (?);
+`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	// The failed resources, versions, timings, metrics and runtime anomalies of the reports are deleted with them.
+	tx, err := m.client.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer rollback(tx)
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(failedQuery), failedArgs...); err != nil {
+		return 0, fmt.Errorf("error deleting failed resources: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(versionsQuery), versionsArgs...); err != nil {
+		return 0, fmt.Errorf("error deleting run versions: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(timingsQuery), timingsArgs...); err != nil {
+		return 0, fmt.Errorf("error deleting run timings: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(metricsQuery), metricsArgs...); err != nil {
+		return 0, fmt.Errorf("error deleting run metrics: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(anomaliesQuery), anomaliesArgs...); err != nil {
+		return 0, fmt.Errorf("error deleting runtime anomalies: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, tx.Rebind(query))
+	if err != nil {
+		return 0, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return int(affected), nil
+}
+
+func (m *mysqlImpl) AcquireLock(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	// The lock is taken over if it has expired, or extended if it is already held by the holder. The holder is
+	// updated first, so the expiry is only extended if the holder now holds the lock.
+	sqlStmt := `
+	INSERT INTO locks (name, holder, expires_at)
+	VALUES (?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		holder = IF(expires_at < ? OR holder = VALUES(holder), VALUES(holder), holder),
+		expires_at = IF(holder = VALUES(holder), VALUES(expires_at), expires_at);
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("acquire_lock"))
+	defer t.ObserveDuration()
+
+	now := time.Now().UTC()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return false, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, name, holder, now.Add(ttl).Format(time.DateTime), now.Format(time.DateTime))
+	if err != nil {
+		return false, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	sqlStmt = `
+	SELECT holder
+	FROM locks
+	WHERE name = ?;
+`
+
+	stmt, err = m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return false, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	var current string
+	if err := stmt.QueryRowContext(ctx, name).Scan(&current); err != nil {
+		return false, fmt.Errorf("error scanning row: %w", err)
+	}
+
+	return current == holder, nil
+}
+
+func (m *mysqlImpl) ReleaseLock(ctx context.Context, name, holder string) error {
+	sqlStmt := `
+	DELETE FROM locks
+	WHERE name = ? AND holder = ?;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("release_lock"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	if _, err := stmt.ExecContext(ctx, name, holder); err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mysqlImpl) SaveJobRun(ctx context.Context, run *entities.JobRun) error {
+	sqlStmt := `
+	INSERT INTO job_runs (name, instance, started_at, duration, outcome, error)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		instance = VALUES(instance),
+		started_at = VALUES(started_at),
+		duration = VALUES(duration),
+		outcome = VALUES(outcome),
+		error = VALUES(error);
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_job_run"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, run.Name, run.Instance, run.StartedAt.Time().UTC().Format(time.DateTime),
+		run.Duration.Time().String(), run.Outcome, run.Error)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mysqlImpl) GetJobRuns(ctx context.Context) ([]*entities.JobRun, error) {
+	sqlStmt := `
+	SELECT name, instance, started_at, duration, outcome, error
+	FROM job_runs
+	ORDER BY name;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_job_runs"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}(rows)
+
+	runs := make([]*entities.JobRun, 0)
+	for rows.Next() {
+		run := new(entities.JobRun)
+		if err := rows.Scan(&run.Name, &run.Instance, &run.StartedAt, &run.Duration, &run.Outcome, &run.Error); err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+func (m *mysqlImpl) DecommissionNode(ctx context.Context, fqdn string, at time.Time) error {
+	sqlStmt := `
+	INSERT INTO decommissions (fqdn, decommissioned_at)
+	VALUES (?, ?)
+	ON DUPLICATE KEY UPDATE decommissioned_at = VALUES(decommissioned_at);
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("decommission_node"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, fqdn, at.UTC().Format(time.DateTime))
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mysqlImpl) RecommissionNode(ctx context.Context, fqdn string) error {
+	sqlStmt := `
+	DELETE FROM decommissions
+	WHERE fqdn = ?;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("recommission_node"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, fqdn)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mysqlImpl) GetDecommissions(ctx context.Context) (map[string]time.Time, error) {
+	sqlStmt := `
+	SELECT fqdn, decommissioned_at
+	FROM decommissions;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_decommissions"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}(rows)
+
+	decommissions := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			fqdn string
+			at   entities.Datetime
+		)
+		if err := rows.Scan(&fqdn, &at); err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+		decommissions[fqdn] = at.Time()
+	}
+
+	return decommissions, nil
+}
+
+func (m *mysqlImpl) GetNodeMetadata(ctx context.Context, fqdn string) (*entities.NodeMetadata, error) {
+	sqlStmt := `
+	SELECT fqdn, owner, role, notes, labels, updated_at
+	FROM node_metadata
+	WHERE fqdn = ?;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_node_metadata"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	md := new(entities.NodeMetadata)
+	err = stmt.QueryRowContext(ctx, fqdn).Scan(&md.Fqdn, &md.Owner, &md.Role, &md.Notes, &md.Labels, &md.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error scanning row: %w", err)
+	}
+
+	return md, nil
+}
+
+func (m *mysqlImpl) GetAllNodeMetadata(ctx context.Context) ([]*entities.NodeMetadata, error) {
+	sqlStmt := `
+	SELECT fqdn, owner, role, notes, labels, updated_at
+	FROM node_metadata
+	ORDER BY fqdn;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_all_node_metadata"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}(rows)
+
+	metadata := make([]*entities.NodeMetadata, 0)
+	for rows.Next() {
+		md := new(entities.NodeMetadata)
+		if err := rows.Scan(&md.Fqdn, &md.Owner, &md.Role, &md.Notes, &md.Labels, &md.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+		metadata = append(metadata, md)
+	}
+
+	return metadata, nil
+}
+
+func (m *mysqlImpl) SaveNodeMetadata(ctx context.Context, metadata *entities.NodeMetadata) error {
+	sqlStmt := `
+	INSERT INTO node_metadata (fqdn, owner, role, notes, labels, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		owner = VALUES(owner),
+		role = VALUES(role),
+		notes = VALUES(notes),
+		labels = VALUES(labels),
+		updated_at = VALUES(updated_at);
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_node_metadata"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, metadata.Fqdn, metadata.Owner, metadata.Role, metadata.Notes, metadata.Labels,
+		metadata.UpdatedAt.Time().UTC().Format(time.DateTime))
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mysqlImpl) DeleteNodeMetadata(ctx context.Context, fqdn string) error {
+	sqlStmt := `
+	DELETE FROM node_metadata
+	WHERE fqdn = ?;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("delete_node_metadata"))
+	defer t.ObserveDuration()
+
 	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
 	if err != nil {
-		return 0, fmt.Errorf("error preparing statement: %w", err)
+		return fmt.Errorf("error preparing statement: %w", err)
 	}
 
-	res, err := stmt.ExecContext(ctx, from.Format(time.DateTime))
+	res, err := stmt.ExecContext(ctx, fqdn)
 	if err != nil {
-		return 0, fmt.Errorf("error executing statement: %w", err)
+		return fmt.Errorf("error executing statement: %w", err)
 	}
 
 	affected, err := res.RowsAffected()
 	if err != nil {
-		return 0, fmt.Errorf("error getting rows affected: %w", err)
+		return fmt.Errorf("error getting rows affected: %w", err)
+	} else if affected == 0 {
+		return ErrNotFound
 	}
 
-	return int(affected), nil
+	return nil
+}
+
+func (m *mysqlImpl) SaveSilence(ctx context.Context, silence *entities.Silence) error {
+	sqlStmt := `
+	INSERT INTO silences (id, kind, fqdn, owner, labels, created_by, reason, starts_at, ends_at, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		kind = VALUES(kind),
+		fqdn = VALUES(fqdn),
+		owner = VALUES(owner),
+		labels = VALUES(labels),
+		created_by = VALUES(created_by),
+		reason = VALUES(reason),
+		starts_at = VALUES(starts_at),
+		ends_at = VALUES(ends_at),
+		created_at = VALUES(created_at);
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_silence"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, silence.ID, silence.Kind, silence.Fqdn, silence.Owner, silence.Labels,
+		silence.CreatedBy, silence.Reason,
+		silence.StartsAt.Time().UTC().Format(time.DateTime),
+		silence.EndsAt.Time().UTC().Format(time.DateTime),
+		silence.CreatedAt.Time().UTC().Format(time.DateTime))
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+func (m *mysqlImpl) GetSilences(ctx context.Context) ([]*entities.Silence, error) {
+	sqlStmt := `
+	SELECT id, kind, fqdn, owner, labels, created_by, reason, starts_at, ends_at, created_at
+	FROM silences
+	ORDER BY starts_at, id;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_silences"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}(rows)
+
+	silences := make([]*entities.Silence, 0)
+	for rows.Next() {
+		sil := new(entities.Silence)
+		if err := rows.Scan(&sil.ID, &sil.Kind, &sil.Fqdn, &sil.Owner, &sil.Labels, &sil.CreatedBy, &sil.Reason,
+			&sil.StartsAt, &sil.EndsAt, &sil.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+		silences = append(silences, sil)
+	}
+
+	return silences, nil
+}
+
+func (m *mysqlImpl) DeleteSilence(ctx context.Context, id string) error {
+	sqlStmt := `
+	DELETE FROM silences
+	WHERE id = ?;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("delete_silence"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	} else if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
 }
 
 func (m *mysqlImpl) GetEnvironments(ctx context.Context) ([]summary.Environment, error) {
@@ -106,151 +729,244 @@ func (m *mysqlImpl) GetEnvironments(ctx context.Context) ([]summary.Environment,
 	return envs, nil
 }
 
+// mysqlHistoryBuckets are the expressions of the dates of the buckets of history, keyed by the bucket.
+var mysqlHistoryBuckets = map[summary.HistoryBucket]string{
+	summary.HistoryBucket_hour: "DATE_FORMAT(executed_at, '%Y-%m-%dT%H:00')",
+	summary.HistoryBucket_day:  "DATE_FORMAT(executed_at, '%Y-%m-%d')",
+	summary.HistoryBucket_week: "DATE_FORMAT(DATE_SUB(executed_at, INTERVAL WEEKDAY(executed_at) DAY), '%Y-%m-%d')",
+}
+
 func (m *mysqlImpl) GetHistory(ctx context.Context, environment ...summary.Environment) ([]*entities.PuppetHistory, error) {
-	res := make([]*entities.PuppetHistory, 0)
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_history"))
+	defer t.ObserveDuration()
+
+	history, err := m.GetHistoryBuckets(ctx, summary.HistoryBucket_day, time.Time{}, time.Time{}, nil, environment...)
+	if err != nil {
+		return nil, err
+	}
 
-	limit := 30
+	return latestHistory(history, historyDays), nil
+}
 
-	// Check the environments are valid.
-	whereClause := ""
-	envStrSlice := make([]any, len(environment))
-	if len(environment) > 0 {
-		for i, env := range environment {
-			if !env.IsValid() {
-				return nil, fmt.Errorf("invalid environment: %s", env)
-			}
+func (m *mysqlImpl) GetHistoryBuckets(ctx context.Context, bucket summary.HistoryBucket, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.PuppetHistory, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_history_buckets"))
+	defer t.ObserveDuration()
 
-			whereClause += "?"
-			if i != len(environment)-1 {
-				whereClause += ","
-			}
+	if err := validateHistoryQuery(bucket, environment...); err != nil {
+		return nil, err
+	}
 
-			envStrSlice[i] = string(env)
+	where := make([]string, 0)
+	args := make([]any, 0)
+	if !from.IsZero() {
+		where = append(where, "executed_at >= ?")
+		args = append(args, from.UTC().Format(time.DateTime))
+	}
+	if !to.IsZero() {
+		where = append(where, "executed_at < ?")
+		args = append(args, to.UTC().Format(time.DateTime))
+	}
+	if len(environment) > 0 {
+		where = append(where, "environment IN (?)")
+		args = append(args, environment)
+	}
+	if fqdns != nil {
+		if len(fqdns) == 0 {
+			return make([]*entities.PuppetHistory, 0), nil
 		}
+		where = append(where, "fqdn IN (?)")
+		args = append(args, fqdns)
 	}
 
-	query := "SELECT DISTINCT DATE(executed_at) FROM reports;"
-	if len(environment) > 0 {
-		query = "SELECT DISTINCT DATE(executed_at) FROM reports WHERE environment IN (" + whereClause + ");"
+	// The runs are counted by bucket and state in a single query.
+	sqlStmt := "SELECT " + mysqlHistoryBuckets[bucket] + " AS bucket, state, COUNT(*) FROM reports"
+	if len(where) > 0 {
+		sqlStmt += " WHERE " + strings.Join(where, " AND ")
 	}
+	sqlStmt += " GROUP BY bucket, state ORDER BY bucket;"
 
-	stmt, err := m.client.PrepareContext(ctx, query)
+	query, args, err := sqlx.In(sqlStmt, args...)
 	if err != nil {
-		return nil, fmt.Errorf("error preparing statement: %w", err)
+		return nil, fmt.Errorf("error building query: %w", err)
 	}
 
-	rows, err := stmt.QueryContext(ctx, envStrSlice...)
+	stmt, err := m.client.PrepareContext(ctx, m.client.Rebind(query))
 	if err != nil {
-		return nil, fmt.Errorf("error executing statement: %w", err)
+		return nil, fmt.Errorf("error preparing statement: %w", err)
 	}
-	defer func(stmt *sql.Stmt) {
+	defer func() {
 		if err := stmt.Close(); err != nil {
 			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
 		}
-	}(stmt)
-	defer func(rows *sql.Rows) {
+	}()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func() {
 		if err := rows.Close(); err != nil {
 			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
 		}
-	}(rows)
-
-	dates := make([]string, 0)
+	}()
 
+	history := make([]*entities.PuppetHistory, 0)
 	for rows.Next() {
-		var d string
-		err = rows.Scan(&d)
-		if err != nil {
-			return nil, errors.New("failed to scan SQL")
+		var (
+			date  string
+			state summary.State
+			count int
+		)
+		if err := rows.Scan(&date, &state, &count); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
 
-		// Parse the date.
-		dt, err := time.Parse(time.RFC3339, d)
-		if err != nil {
-			slog.Error("Error parsing date", slog.String(logging.KeyError, err.Error()))
-			continue
+		// The rows are ordered by bucket, so a new bucket starts when the date changes.
+		if len(history) == 0 || history[len(history)-1].Date != date {
+			history = append(history, &entities.PuppetHistory{Date: date})
 		}
+		history[len(history)-1].AddCount(state, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return history, nil
+}
+
+func (m *mysqlImpl) GetResourceFailures(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.ResourceFailure, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_resource_failures"))
+	defer t.ObserveDuration()
+
+	return queryResourceFailures(ctx, m.client, from, to, environment...)
+}
+
+func (m *mysqlImpl) GetRunVersions(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.RunVersion, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_run_versions"))
+	defer t.ObserveDuration()
+
+	return queryRunVersions(ctx, m.client, from, to, environment...)
+}
+
+func (m *mysqlImpl) GetTimings(ctx context.Context, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.TimingSummary, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_timings"))
+	defer t.ObserveDuration()
 
-		dates = append(dates, dt.Format(time.DateOnly))
+	return queryTimings(ctx, m.client, from, to, fqdns, environment...)
+}
+
+func (m *mysqlImpl) GetRunMetrics(ctx context.Context, from, to time.Time, metrics []string, environment ...summary.Environment) ([]*entities.RunMetric, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_run_metrics"))
+	defer t.ObserveDuration()
+
+	return queryRunMetrics(ctx, m.client, from, to, metrics, environment...)
+}
+
+func (m *mysqlImpl) SaveIncident(ctx context.Context, incident *entities.Incident) error {
+	sqlStmt := `
+	INSERT INTO incidents (id, environment, config_version, resources, message, first_seen, last_seen, nodes, reports, notified)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		environment = VALUES(environment),
+		config_version = VALUES(config_version),
+		resources = VALUES(resources),
+		message = VALUES(message),
+		first_seen = VALUES(first_seen),
+		last_seen = VALUES(last_seen),
+		nodes = VALUES(nodes),
+		reports = VALUES(reports);
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_incident"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
 	}
-	if len(dates) < limit {
-		limit = len(dates)
+
+	_, err = stmt.ExecContext(ctx, incident.ID, incident.Env, incident.ConfigVersion, incident.Resources,
+		incident.Message,
+		incident.FirstSeen.Time().UTC().Format(time.DateTime),
+		incident.LastSeen.Time().UTC().Format(time.DateTime),
+		incident.Nodes, incident.Reports, incident.Notified)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
 	}
 
-	for _, date := range dates[:limit] {
-		x := new(entities.PuppetHistory)
-		x.Changed = 0
-		x.Unchanged = 0
-		x.Failed = 0
-		x.Date = date
+	return nil
+}
 
-		startTime, err := time.Parse(time.DateOnly, date)
-		if err != nil {
-			slog.Error("Error parsing date", slog.String(logging.KeyError, err.Error()))
-			continue
-		}
-		endTime := startTime.AddDate(0, 0, 1)
-
-		locQuery := "SELECT DISTINCT state, COUNT('state') FROM reports WHERE executed_at BETWEEN ? AND ?"
-		if len(environment) > 0 {
-			locQuery += " AND environment IN ("
-			for i := range environment {
-				locQuery += "?"
-				if i != len(environment)-1 {
-					locQuery += ","
-				}
-			}
-			locQuery += ")"
-		}
+func (m *mysqlImpl) SetIncidentNotified(ctx context.Context, id string, notified bool) (bool, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("set_incident_notified"))
+	defer t.ObserveDuration()
 
-		locQuery += " GROUP BY state;"
-		stmt, err = m.client.PrepareContext(ctx, locQuery)
-		if err != nil {
-			return nil, fmt.Errorf("error preparing statement: %w", err)
-		}
+	return setIncidentNotified(ctx, m.client, id, notified)
+}
 
-		locWhere := make([]any, 0)
-		locWhere = append(locWhere, startTime.Format(time.DateOnly))
-		locWhere = append(locWhere, endTime.Format(time.DateOnly))
-		if len(environment) > 0 {
-			locWhere = append(locWhere, envStrSlice...)
-		}
+func (m *mysqlImpl) GetIncidents(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.Incident, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_incidents"))
+	defer t.ObserveDuration()
 
-		rows, err = stmt.QueryContext(ctx, locWhere...)
-		if err != nil {
-			return nil, fmt.Errorf("error executing statement: %w", err)
-		}
+	return queryIncidents(ctx, m.client, "", from, to, environment...)
+}
 
-		for rows.Next() {
-			var state summary.State
-			var count int
-
-			err = rows.Scan(&state, &count)
-			if err != nil {
-				return nil, errors.New("failed to scan SQL")
-			}
-			if state.IsIn(summary.State_CHANGED) {
-				x.Changed += count
-			}
-			if state.IsIn(summary.State_UNCHANGED) {
-				x.Unchanged += count
-			}
-			if state.IsIn(summary.State_FAILED) {
-				x.Failed += count
-			}
-		}
+func (m *mysqlImpl) GetIncident(ctx context.Context, id string) (*entities.Incident, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_incident"))
+	defer t.ObserveDuration()
 
-		if err := stmt.Close(); err != nil {
-			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
-		}
+	return getIncident(ctx, m.client, id)
+}
 
-		if err := rows.Close(); err != nil {
-			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
-		}
+func (m *mysqlImpl) SaveRuntimeAnomaly(ctx context.Context, anomaly *entities.RuntimeAnomaly) error {
+	sqlStmt := `
+	INSERT INTO runtime_anomalies (report_hash, fqdn, environment, executed_at, runtime, median, deviation, score)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		fqdn = VALUES(fqdn),
+		environment = VALUES(environment),
+		executed_at = VALUES(executed_at),
+		runtime = VALUES(runtime),
+		median = VALUES(median),
+		deviation = VALUES(deviation),
+		score = VALUES(score);
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_runtime_anomaly"))
+	defer t.ObserveDuration()
+
+	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
 
-		res = append(res, x)
+	_, err = stmt.ExecContext(ctx, anomaly.ReportID, anomaly.Fqdn, anomaly.Env,
+		anomaly.ExecTime.Time().UTC().Format(time.DateTime),
+		anomaly.Runtime.Time().String(), anomaly.Median.Time().String(), anomaly.Deviation.Time().String(), anomaly.Score)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
 	}
 
-	return res, nil
+	return nil
+}
+
+func (m *mysqlImpl) GetRuntimeAnomalies(ctx context.Context, fqdn string, from, to time.Time, environment ...summary.Environment) ([]*entities.RuntimeAnomaly, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_runtime_anomalies"))
+	defer t.ObserveDuration()
+
+	return queryRuntimeAnomalies(ctx, m.client, fqdn, from, to, environment...)
 }
 
 func (m *mysqlImpl) GetReport(ctx context.Context, id string) (*entities.PuppetReport, error) {
@@ -350,7 +1066,9 @@ func (m *mysqlImpl) GetRunsByState(ctx context.Context, states ...summary.State)
 		   fqdn,
 		   state,
 		   executed_at,
-		   runtime
+		   runtime,
+		   environment,
+		   yaml_file
 	FROM reports
 	WHERE state IN (?)
 	ORDER BY executed_at DESC;
@@ -360,21 +1078,22 @@ func (m *mysqlImpl) GetRunsByState(ctx context.Context, states ...summary.State)
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_runs_by_state"))
 	defer t.ObserveDuration()
 
-	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	runs := make([]*entities.PuppetRun, 0)
+	if len(states) == 0 {
+		return runs, nil
+	}
+
+	query, args, err := sqlx.In(sqlStmt, states)
 	if err != nil {
-		return nil, fmt.Errorf("error preparing statement: %w", err)
+		return nil, fmt.Errorf("error building query: %w", err)
 	}
 
-	// Convert the states to a string csv.
-	statesStr := ""
-	for i, state := range states {
-		statesStr += string(state)
-		if i != len(states)-1 {
-			statesStr += ","
-		}
+	stmt, err := m.client.PrepareContext(ctx, m.client.Rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
 	}
 
-	rows, err := stmt.QueryContext(ctx, statesStr)
+	rows, err := stmt.QueryContext(ctx, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error executing statement: %w", err)
 	}
@@ -385,10 +1104,9 @@ func (m *mysqlImpl) GetRunsByState(ctx context.Context, states ...summary.State)
 		}
 	}()
 
-	runs := make([]*entities.PuppetRun, 0)
 	for rows.Next() {
 		run := new(entities.PuppetRun)
-		if err := rows.Scan(&run.ID, &run.Fqdn, &run.State, &run.ExecTime, &run.Runtime); err != nil {
+		if err := rows.Scan(&run.ID, &run.Fqdn, &run.State, &run.ExecTime, &run.Runtime, &run.Env, &run.YamlFile); err != nil {
 			return nil, fmt.Errorf("error scanning rows: %w", err)
 		}
 		runs = append(runs, run)
@@ -405,7 +1123,8 @@ func (m *mysqlImpl) GetRuns(ctx context.Context) ([]*entities.PuppetRun, error)
 		state,
 		executed_at,
 		runtime,
-		environment
+		environment,
+		yaml_file
 	FROM reports
 	ORDER BY executed_at DESC;
 `
@@ -433,7 +1152,7 @@ func (m *mysqlImpl) GetRuns(ctx context.Context) ([]*entities.PuppetRun, error)
 	runs := make([]*entities.PuppetRun, 0)
 	for rows.Next() {
 		run := new(entities.PuppetRun)
-		if err := rows.Scan(&run.ID, &run.Fqdn, &run.State, &run.ExecTime, &run.Runtime, &run.Env); err != nil {
+		if err := rows.Scan(&run.ID, &run.Fqdn, &run.State, &run.ExecTime, &run.Runtime, &run.Env, &run.YamlFile); err != nil {
 			return nil, fmt.Errorf("error scanning rows: %w", err)
 		}
 		runs = append(runs, run)
@@ -464,7 +1183,14 @@ func (m *mysqlImpl) SaveRun(ctx context.Context, run *entities.PuppetReport) err
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_run"))
 	defer t.ObserveDuration()
 
-	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+	// The report, its failed resources and its version are saved together.
+	tx, err := m.client.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer rollback(tx)
+
+	stmt, err := tx.PrepareContext(ctx, sqlStmt)
 	if err != nil {
 		return fmt.Errorf("error preparing statement: %w", err)
 	}
@@ -480,7 +1206,7 @@ func (m *mysqlImpl) SaveRun(ctx context.Context, run *entities.PuppetReport) err
 		run.Env,
 		run.State,
 		run.ReportFilePath(),
-		run.ExecTime.Time().Format(time.DateTime),
+		run.ExecTime.Time().UTC().Format(time.DateTime),
 		run.Runtime.String(),
 		run.Failed,
 		run.Changed,
@@ -497,9 +1223,37 @@ func (m *mysqlImpl) SaveRun(ctx context.Context, run *entities.PuppetReport) err
 	} else if err != nil {
 		return fmt.Errorf("error executing statement: %w", err)
 	}
+
+	if err := insertResourceFailures(ctx, tx, run); err != nil {
+		return fmt.Errorf("error saving failed resources: %w", err)
+	}
+
+	if err := insertRunVersion(ctx, tx, run); err != nil {
+		return fmt.Errorf("error saving run version: %w", err)
+	}
+
+	if err := insertRunTimings(ctx, tx, run); err != nil {
+		return fmt.Errorf("error saving run timings: %w", err)
+	}
+
+	if err := insertRunMetrics(ctx, tx, run); err != nil {
+		return fmt.Errorf("error saving run metrics: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
 	return nil
 }
 
+func (m *mysqlImpl) GetLatestReports(ctx context.Context, fqdn string, before time.Time, limit int) ([]*entities.PuppetReportSummary, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_latest_reports"))
+	defer t.ObserveDuration()
+
+	return queryLatestReports(ctx, m.client, fqdn, before, limit)
+}
+
 func (m *mysqlImpl) Ping(ctx context.Context) error {
 	// Start the prometheus metrics.
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("ping"))
@@ -515,7 +1269,7 @@ func (m *mysqlImpl) setup() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
-	sqlStmt := `
+	sqlStmts := []string{`
 CREATE TABLE IF NOT EXISTS reports
 (
     id          INTEGER PRIMARY KEY AUTO_INCREMENT,
@@ -531,18 +1285,159 @@ CREATE TABLE IF NOT EXISTS reports
     failed      integer,
     changed     integer
 )
-`
+`, `
+CREATE TABLE IF NOT EXISTS migrations
+(
+    name       VARCHAR(255) PRIMARY KEY,
+    applied_at DATETIME     NOT NULL
+)
+`, `
+CREATE TABLE IF NOT EXISTS locks
+(
+    name       VARCHAR(255) PRIMARY KEY,
+    holder     VARCHAR(255) NOT NULL,
+    expires_at DATETIME     NOT NULL
+)
+`, `
+CREATE TABLE IF NOT EXISTS job_runs
+(
+    name       VARCHAR(255) PRIMARY KEY,
+    instance   VARCHAR(255) NOT NULL,
+    started_at DATETIME     NOT NULL,
+    duration   VARCHAR(32)  NOT NULL,
+    outcome    VARCHAR(16)  NOT NULL,
+    error      TEXT         NOT NULL
+)
+`, `
+CREATE TABLE IF NOT EXISTS decommissions
+(
+    fqdn              VARCHAR(255) PRIMARY KEY,
+    decommissioned_at DATETIME     NOT NULL
+)
+`, `
+CREATE TABLE IF NOT EXISTS node_metadata
+(
+    fqdn       VARCHAR(255) PRIMARY KEY,
+    owner      VARCHAR(255) NOT NULL DEFAULT '',
+    role       VARCHAR(255) NOT NULL DEFAULT '',
+    notes      TEXT         NOT NULL,
+    labels     JSON         NOT NULL,
+    updated_at DATETIME     NOT NULL
+)
+`, `
+CREATE TABLE IF NOT EXISTS silences
+(
+    id         VARCHAR(300) PRIMARY KEY,
+    kind       VARCHAR(16)  NOT NULL,
+    fqdn       VARCHAR(255) NOT NULL DEFAULT '',
+    owner      VARCHAR(255) NOT NULL DEFAULT '',
+    labels     JSON         NOT NULL,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    reason     TEXT         NOT NULL,
+    starts_at  DATETIME     NOT NULL,
+    ends_at    DATETIME     NOT NULL,
+    created_at DATETIME     NOT NULL
+)
+`, `
+CREATE TABLE IF NOT EXISTS failed_resources
+(
+    id            INTEGER PRIMARY KEY AUTO_INCREMENT,
+    report_hash   VARCHAR(255) NOT NULL,
+    fqdn          VARCHAR(255) NOT NULL,
+    environment   VARCHAR(32)  NOT NULL,
+    executed_at   DATETIME     NOT NULL,
+    resource_type VARCHAR(255) NOT NULL,
+    title         TEXT         NOT NULL,
+    file          TEXT         NOT NULL,
+    line          VARCHAR(16)  NOT NULL,
+    INDEX failed_resources_report_hash (report_hash),
+    INDEX failed_resources_executed_at (executed_at)
+)
+`, `
+CREATE TABLE IF NOT EXISTS incidents
+(
+    id             VARCHAR(255) PRIMARY KEY,
+    environment    VARCHAR(32)  NOT NULL,
+    config_version VARCHAR(255) NOT NULL DEFAULT '',
+    resources      JSON         NOT NULL,
+    message        TEXT         NOT NULL,
+    first_seen     DATETIME     NOT NULL,
+    last_seen      DATETIME     NOT NULL,
+    nodes          JSON         NOT NULL,
+    reports        INTEGER      NOT NULL DEFAULT 0,
+    notified       BOOLEAN      NOT NULL DEFAULT FALSE,
+    INDEX incidents_last_seen (last_seen)
+)
+`, `
+CREATE TABLE IF NOT EXISTS run_versions
+(
+    report_hash    VARCHAR(255) PRIMARY KEY,
+    fqdn           VARCHAR(255) NOT NULL,
+    environment    VARCHAR(32)  NOT NULL,
+    state          VARCHAR(32)  NOT NULL,
+    executed_at    DATETIME     NOT NULL,
+    config_version VARCHAR(255) NOT NULL,
+    code_id        VARCHAR(255) NOT NULL DEFAULT '',
+    INDEX run_versions_executed_at (executed_at)
+)
+`, `
+CREATE TABLE IF NOT EXISTS run_timings
+(
+    report_hash VARCHAR(255) NOT NULL,
+    fqdn        VARCHAR(255) NOT NULL,
+    environment VARCHAR(32)  NOT NULL,
+    executed_at DATETIME     NOT NULL,
+    name        VARCHAR(64)  NOT NULL,
+    label       VARCHAR(64)  NOT NULL,
+    seconds     DOUBLE       NOT NULL,
+    PRIMARY KEY (report_hash, name),
+    INDEX run_timings_executed_at (executed_at)
+)
+`, `
+CREATE TABLE IF NOT EXISTS run_metrics
+(
+    report_hash VARCHAR(255) NOT NULL,
+    fqdn        VARCHAR(255) NOT NULL,
+    environment VARCHAR(32)  NOT NULL,
+    executed_at DATETIME     NOT NULL,
+    metric      VARCHAR(128) NOT NULL,
+    value       DOUBLE       NOT NULL,
+    PRIMARY KEY (report_hash, metric),
+    INDEX run_metrics_executed_at (executed_at)
+)
+`, `
+CREATE TABLE IF NOT EXISTS runtime_anomalies
+(
+    report_hash VARCHAR(255) PRIMARY KEY,
+    fqdn        VARCHAR(255) NOT NULL,
+    environment VARCHAR(32)  NOT NULL,
+    executed_at DATETIME     NOT NULL,
+    runtime     VARCHAR(32)  NOT NULL,
+    median      VARCHAR(32)  NOT NULL,
+    deviation   VARCHAR(32)  NOT NULL,
+    score       DOUBLE       NOT NULL,
+    INDEX runtime_anomalies_executed_at (executed_at),
+    INDEX runtime_anomalies_fqdn (fqdn)
+)
+`}
 
-	stmt, err := m.client.PrepareContext(ctx, sqlStmt)
-	if err != nil {
-		return fmt.Errorf("error preparing statement: %w", err)
-	}
+	for _, sqlStmt := range sqlStmts {
+		stmt, err := m.client.PrepareContext(ctx, sqlStmt)
+		if err != nil {
+			return fmt.Errorf("error preparing statement: %w", err)
+		}
 
-	_, err = stmt.ExecContext(ctx)
-	if err != nil {
-		return err
+		_, err = stmt.ExecContext(ctx)
+		if err != nil {
+			return err
+		}
 	}
-	return nil
+
+	// The migration is not bound by the timeout of the setup, as it goes through every report.
+	return migrateExecTimesToUTC(context.Background(), m.client, `
+	INSERT IGNORE INTO migrations (name, applied_at)
+	VALUES (?, ?);
+`)
 }
 
 func NewMySQL(v *viper.Viper) (Database, error) {
@@ -80,11 +80,32 @@ func (s *mysqlSuite) TestPurge() {
 	from, err := time.Parse(time.DateTime, "2023-02-21 00:00:00")
 	s.Require().NoError(err)
 
-	// Expect the database to be purged.
+	// Expect the database to be purged, with the failed resources, versions, timings, metrics and runtime anomalies of
+	// the reports and the incidents.
+	s.mockDB.ExpectBegin()
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM failed_resources WHERE executed_at < ?;`)).
+		WithArgs(from.Format(time.DateTime)).
+		WillReturnResult(sqlmock.NewResult(0, 7))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM run_versions WHERE executed_at < ?;`)).
+		WithArgs(from.Format(time.DateTime)).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM run_timings WHERE executed_at < ?;`)).
+		WithArgs(from.Format(time.DateTime)).
+		WillReturnResult(sqlmock.NewResult(0, 13))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM run_metrics WHERE executed_at < ?;`)).
+		WithArgs(from.Format(time.DateTime)).
+		WillReturnResult(sqlmock.NewResult(0, 30))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM runtime_anomalies WHERE executed_at < ?;`)).
+		WithArgs(from.Format(time.DateTime)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM incidents WHERE last_seen < ?;`)).
+		WithArgs(from.Format(time.DateTime)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
 	s.mockDB.ExpectPrepare(expSql)
 	s.mockDB.ExpectExec(expSql).
 		WithArgs(from.Format(time.DateTime)).
 		WillReturnResult(sqlmock.NewResult(0, 5))
+	s.mockDB.ExpectCommit()
 
 	affected, err := s.dbObject.Purge(context.Background(), from)
 	s.Require().NoError(err)
@@ -92,107 +113,288 @@ func (s *mysqlSuite) TestPurge() {
 	s.Require().Equal(5, affected)
 }
 
-func (s *mysqlSuite) TestGetEnvironments() {
+func (s *mysqlSuite) TestDeleteReports() {
 	expSql := regexp.QuoteMeta(`
-		SELECT DISTINCT environment
-		FROM reports;
+		DELETE FROM reports
+		WHERE hash IN (?, ?);
 	`)
 
-	// Expect the environments to be retrieved.
+	// Expect the reports to be deleted, with their failed resources, versions, timings, metrics and runtime anomalies.
+	s.mockDB.ExpectBegin()
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM failed_resources WHERE report_hash IN (?, ?);`)).
+		WithArgs("hash1", "hash2").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM run_versions WHERE report_hash IN (?, ?);`)).
+		WithArgs("hash1", "hash2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM run_timings WHERE report_hash IN (?, ?);`)).
+		WithArgs("hash1", "hash2").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM run_metrics WHERE report_hash IN (?, ?);`)).
+		WithArgs("hash1", "hash2").
+		WillReturnResult(sqlmock.NewResult(0, 8))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM runtime_anomalies WHERE report_hash IN (?, ?);`)).
+		WithArgs("hash1", "hash2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
 	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("hash1", "hash2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mockDB.ExpectCommit()
 
-	rows := sqlmock.NewRows([]string{"environment"}).
-		AddRow("PRODUCTION").
-		AddRow("STAGING").
-		AddRow("DEVELOPMENT")
-
-	s.mockDB.ExpectQuery(expSql).
-		WillReturnRows(rows)
+	affected, err := s.dbObject.DeleteReports(context.Background(), "hash1", "hash2")
+	s.Require().NoError(err)
 
-	s.mockDB.ExpectClose()
+	s.Require().Equal(2, affected)
+}
 
-	environments, err := s.dbObject.GetEnvironments(context.Background())
+func (s *mysqlSuite) TestDeleteReportsNoIDs() {
+	affected, err := s.dbObject.DeleteReports(context.Background())
 	s.Require().NoError(err)
 
-	s.Require().Equal([]summary.Environment{
-		summary.Environment_PRODUCTION,
-		summary.Environment_STAGING,
-		summary.Environment_DEVELOPMENT,
-	}, environments)
+	s.Require().Equal(0, affected)
 }
 
-func (s *mysqlSuite) TestGetHistoryAllEnvs() {
-	expSql1 := regexp.QuoteMeta(`SELECT DISTINCT DATE(executed_at) FROM reports;`)
+func (s *mysqlSuite) TestAcquireLock() {
+	expInsert := regexp.QuoteMeta(`
+	INSERT INTO locks (name, holder, expires_at)
+	VALUES (?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		holder = IF(expires_at < ? OR holder = VALUES(holder), VALUES(holder), holder),
+		expires_at = IF(holder = VALUES(holder), VALUES(expires_at), expires_at);
+	`)
+	expSelect := regexp.QuoteMeta(`
+	SELECT holder
+	FROM locks
+	WHERE name = ?;
+	`)
 
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql1)
+	s.mockDB.ExpectPrepare(expInsert)
+	s.mockDB.ExpectExec(expInsert).
+		WithArgs("purge", "host-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectPrepare(expSelect)
+	s.mockDB.ExpectQuery(expSelect).
+		WithArgs("purge").
+		WillReturnRows(sqlmock.NewRows([]string{"holder"}).AddRow("host-1"))
 
-	rows1 := sqlmock.NewRows([]string{"DATE(executed_at)"}).
-		AddRow("2023-02-21T00:00:00Z").
-		AddRow("2023-02-22T00:00:00Z").
-		AddRow("2023-02-23T00:00:00Z")
+	held, err := s.dbObject.AcquireLock(context.Background(), "purge", "host-1", time.Hour)
+	s.Require().NoError(err)
+	s.Require().True(held)
+}
 
-	s.mockDB.ExpectQuery(expSql1).
-		WillReturnRows(rows1)
+func (s *mysqlSuite) TestAcquireLockHeldByOther() {
+	expInsert := regexp.QuoteMeta(`INSERT INTO locks (name, holder, expires_at)`)
+	expSelect := regexp.QuoteMeta(`SELECT holder FROM locks WHERE name = ?;`)
 
-	expSql2 := regexp.QuoteMeta(`SELECT DISTINCT state, COUNT('state') FROM reports WHERE executed_at BETWEEN ? AND ? GROUP BY state;`)
+	s.mockDB.ExpectPrepare(expInsert)
+	s.mockDB.ExpectExec(expInsert).
+		WithArgs("purge", "host-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mockDB.ExpectPrepare(expSelect)
+	s.mockDB.ExpectQuery(expSelect).
+		WithArgs("purge").
+		WillReturnRows(sqlmock.NewRows([]string{"holder"}).AddRow("host-2"))
 
-	from1, err := time.Parse(time.DateTime, "2023-02-21 00:00:00")
+	held, err := s.dbObject.AcquireLock(context.Background(), "purge", "host-1", time.Hour)
 	s.Require().NoError(err)
+	s.Require().False(held)
+}
+
+func (s *mysqlSuite) TestReleaseLock() {
+	expSql := regexp.QuoteMeta(`
+	DELETE FROM locks
+	WHERE name = ? AND holder = ?;
+	`)
+
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("purge", "host-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	to1, err := time.Parse(time.DateTime, "2023-02-22 00:00:00")
+	err := s.dbObject.ReleaseLock(context.Background(), "purge", "host-1")
 	s.Require().NoError(err)
+}
 
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
+func (s *mysqlSuite) TestDecommissionNode() {
+	expSql := regexp.QuoteMeta(`
+	INSERT INTO decommissions (fqdn, decommissioned_at)
+	VALUES (?, ?)
+	ON DUPLICATE KEY UPDATE decommissioned_at = VALUES(decommissioned_at);
+	`)
 
-	rows2 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 5).
-		AddRow("FAILURE", 1).
-		AddRow("UNCHANGED", 3)
+	at := time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
 
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from1.Format(time.DateOnly), to1.Format(time.DateOnly)).
-		WillReturnRows(rows2)
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("host-1", "2024-02-13 10:00:00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	from2, err := time.Parse(time.DateTime, "2023-02-22 00:00:00")
+	err := s.dbObject.DecommissionNode(context.Background(), "host-1", at)
 	s.Require().NoError(err)
+}
 
-	to2, err := time.Parse(time.DateTime, "2023-02-23 00:00:00")
+func (s *mysqlSuite) TestGetDecommissions() {
+	expSql := regexp.QuoteMeta(`
+	SELECT fqdn, decommissioned_at
+	FROM decommissions;
+	`)
+
+	at := time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectQuery(expSql).
+		WillReturnRows(sqlmock.NewRows([]string{"fqdn", "decommissioned_at"}).AddRow("host-1", at))
+
+	decommissions, err := s.dbObject.GetDecommissions(context.Background())
 	s.Require().NoError(err)
+	s.Require().Equal(map[string]time.Time{"host-1": at}, decommissions)
+}
 
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
+func (s *mysqlSuite) TestSaveNodeMetadata() {
+	expSql := regexp.QuoteMeta(`
+	INSERT INTO node_metadata (fqdn, owner, role, notes, labels, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		owner = VALUES(owner),
+		role = VALUES(role),
+		notes = VALUES(notes),
+		labels = VALUES(labels),
+		updated_at = VALUES(updated_at);
+	`)
 
-	rows3 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 3).
-		AddRow("FAILURE", 0).
-		AddRow("UNCHANGED", 6)
+	md := &entities.NodeMetadata{
+		Fqdn:      "host-1",
+		Owner:     "team-a",
+		Role:      "web",
+		Labels:    entities.Labels{"dc": "lon"},
+		UpdatedAt: entities.Datetime(time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)),
+	}
 
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from2.Format(time.DateOnly), to2.Format(time.DateOnly)).
-		WillReturnRows(rows3)
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("host-1", "team-a", "web", "", `{"dc":"lon"}`, "2024-02-13 10:00:00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	from3, err := time.Parse(time.DateTime, "2023-02-23 00:00:00")
+	err := s.dbObject.SaveNodeMetadata(context.Background(), md)
 	s.Require().NoError(err)
+}
+
+func (s *mysqlSuite) TestDeleteNodeMetadataNotFound() {
+	expSql := regexp.QuoteMeta(`
+	DELETE FROM node_metadata
+	WHERE fqdn = ?;
+	`)
+
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("host-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := s.dbObject.DeleteNodeMetadata(context.Background(), "host-1")
+	s.Require().ErrorIs(err, ErrNotFound)
+}
 
-	to3, err := time.Parse(time.DateTime, "2023-02-24 00:00:00")
+func (s *mysqlSuite) TestSaveSilence() {
+	expSql := regexp.QuoteMeta(`
+	INSERT INTO silences (id, kind, fqdn, owner, labels, created_by, reason, starts_at, ends_at, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		kind = VALUES(kind),
+		fqdn = VALUES(fqdn),
+		owner = VALUES(owner),
+		labels = VALUES(labels),
+		created_by = VALUES(created_by),
+		reason = VALUES(reason),
+		starts_at = VALUES(starts_at),
+		ends_at = VALUES(ends_at),
+		created_at = VALUES(created_at);
+	`)
+
+	at := time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+	sil := &entities.Silence{
+		ID:        "abc123",
+		Kind:      entities.SilenceKindSilence,
+		Labels:    entities.Labels{"dc": "lon"},
+		CreatedBy: "alice",
+		Reason:    "Maintenance",
+		StartsAt:  entities.Datetime(at),
+		EndsAt:    entities.Datetime(at.Add(time.Hour)),
+		CreatedAt: entities.Datetime(at),
+	}
+
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("abc123", "silence", "", "", `{"dc":"lon"}`, "alice", "Maintenance", "2024-02-13 10:00:00",
+			"2024-02-13 11:00:00", "2024-02-13 10:00:00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := s.dbObject.SaveSilence(context.Background(), sil)
 	s.Require().NoError(err)
+}
 
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
+func (s *mysqlSuite) TestDeleteSilenceNotFound() {
+	expSql := regexp.QuoteMeta(`
+	DELETE FROM silences
+	WHERE id = ?;
+	`)
+
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("abc123").
+		WillReturnResult(sqlmock.NewResult(0, 0))
 
-	rows4 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 2).
-		AddRow("FAILURE", 0).
-		AddRow("UNCHANGED", 7)
+	err := s.dbObject.DeleteSilence(context.Background(), "abc123")
+	s.Require().ErrorIs(err, ErrNotFound)
+}
 
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from3.Format(time.DateOnly), to3.Format(time.DateOnly)).
-		WillReturnRows(rows4)
+func (s *mysqlSuite) TestGetEnvironments() {
+	expSql := regexp.QuoteMeta(`
+		SELECT DISTINCT environment
+		FROM reports;
+	`)
+
+	// Expect the environments to be retrieved.
+	s.mockDB.ExpectPrepare(expSql)
+
+	rows := sqlmock.NewRows([]string{"environment"}).
+		AddRow("PRODUCTION").
+		AddRow("STAGING").
+		AddRow("DEVELOPMENT")
+
+	s.mockDB.ExpectQuery(expSql).
+		WillReturnRows(rows)
 
 	s.mockDB.ExpectClose()
 
+	environments, err := s.dbObject.GetEnvironments(context.Background())
+	s.Require().NoError(err)
+
+	s.Require().Equal([]summary.Environment{
+		summary.Environment_PRODUCTION,
+		summary.Environment_STAGING,
+		summary.Environment_DEVELOPMENT,
+	}, environments)
+}
+
+func (s *mysqlSuite) TestGetHistoryAllEnvs() {
+	expSql := regexp.QuoteMeta(`SELECT DATE_FORMAT(executed_at, '%Y-%m-%d') AS bucket, state, COUNT(*) FROM reports GROUP BY bucket, state ORDER BY bucket;`)
+
+	// Expect the history to be counted in a single query.
+	s.mockDB.ExpectPrepare(expSql)
+
+	rows := sqlmock.NewRows([]string{"bucket", "state", "COUNT(*)"}).
+		AddRow("2023-02-21", "CHANGED", 5).
+		AddRow("2023-02-21", "FAILURE", 1).
+		AddRow("2023-02-21", "UNCHANGED", 3).
+		AddRow("2023-02-22", "CHANGED", 3).
+		AddRow("2023-02-22", "UNCHANGED", 6).
+		AddRow("2023-02-23", "CHANGED", 2).
+		AddRow("2023-02-23", "UNCHANGED", 7)
+
+	s.mockDB.ExpectQuery(expSql).
+		WillReturnRows(rows)
+
 	history, err := s.dbObject.GetHistory(context.Background())
 	s.Require().NoError(err)
 
@@ -219,76 +421,23 @@ func (s *mysqlSuite) TestGetHistoryAllEnvs() {
 }
 
 func (s *mysqlSuite) TestGetHistoryMultipleEnv() {
-	expSql1 := regexp.QuoteMeta(`SELECT DISTINCT DATE(executed_at) FROM reports WHERE environment IN (?,?);`)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql1)
-
-	rows1 := sqlmock.NewRows([]string{"DATE(executed_at)"}).
-		AddRow("2023-02-21T00:00:00Z").
-		AddRow("2023-02-22T00:00:00Z").
-		AddRow("2023-02-23T00:00:00Z")
+	expSql := regexp.QuoteMeta(`SELECT DATE_FORMAT(executed_at, '%Y-%m-%d') AS bucket, state, COUNT(*) FROM reports WHERE environment IN (?, ?) GROUP BY bucket, state ORDER BY bucket;`)
 
-	s.mockDB.ExpectQuery(expSql1).
-		WillReturnRows(rows1)
-
-	expSql2 := regexp.QuoteMeta(`SELECT DISTINCT state, COUNT('state') FROM reports WHERE executed_at BETWEEN ? AND ? AND environment IN (?,?) GROUP BY state;`)
-
-	from1, err := time.Parse(time.DateTime, "2023-02-21 00:00:00")
-	s.Require().NoError(err)
-
-	to1, err := time.Parse(time.DateTime, "2023-02-22 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows2 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 5).
-		AddRow("FAILURE", 1).
-		AddRow("UNCHANGED", 3)
-
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from1.Format(time.DateOnly), to1.Format(time.DateOnly), summary.Environment_PRODUCTION, summary.Environment_DEVELOPMENT).
-		WillReturnRows(rows2)
-
-	from2, err := time.Parse(time.DateTime, "2023-02-22 00:00:00")
-	s.Require().NoError(err)
-
-	to2, err := time.Parse(time.DateTime, "2023-02-23 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows3 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 3).
-		AddRow("FAILURE", 0).
-		AddRow("UNCHANGED", 6)
-
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from2.Format(time.DateOnly), to2.Format(time.DateOnly), summary.Environment_PRODUCTION, summary.Environment_DEVELOPMENT).
-		WillReturnRows(rows3)
-
-	from3, err := time.Parse(time.DateTime, "2023-02-23 00:00:00")
-	s.Require().NoError(err)
-
-	to3, err := time.Parse(time.DateTime, "2023-02-24 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows4 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 2).
-		AddRow("FAILURE", 0).
-		AddRow("UNCHANGED", 7)
+	// Expect the history to be counted in a single query.
+	s.mockDB.ExpectPrepare(expSql)
 
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from3.Format(time.DateOnly), to3.Format(time.DateOnly), summary.Environment_PRODUCTION, summary.Environment_DEVELOPMENT).
-		WillReturnRows(rows4)
+	rows := sqlmock.NewRows([]string{"bucket", "state", "COUNT(*)"}).
+		AddRow("2023-02-21", "CHANGED", 5).
+		AddRow("2023-02-21", "FAILURE", 1).
+		AddRow("2023-02-21", "UNCHANGED", 3).
+		AddRow("2023-02-22", "CHANGED", 3).
+		AddRow("2023-02-22", "UNCHANGED", 6).
+		AddRow("2023-02-23", "CHANGED", 2).
+		AddRow("2023-02-23", "UNCHANGED", 7)
 
-	s.mockDB.ExpectClose()
+	s.mockDB.ExpectQuery(expSql).
+		WithArgs(summary.Environment_PRODUCTION, summary.Environment_DEVELOPMENT).
+		WillReturnRows(rows)
 
 	history, err := s.dbObject.GetHistory(context.Background(), summary.Environment_PRODUCTION, summary.Environment_DEVELOPMENT)
 	s.Require().NoError(err)
@@ -316,76 +465,23 @@ func (s *mysqlSuite) TestGetHistoryMultipleEnv() {
 }
 
 func (s *mysqlSuite) TestGetHistorySingleEnv() {
-	expSql1 := regexp.QuoteMeta(`SELECT DISTINCT DATE(executed_at) FROM reports WHERE environment IN (?);`)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql1)
-
-	rows1 := sqlmock.NewRows([]string{"DATE(executed_at)"}).
-		AddRow("2023-02-21T00:00:00Z").
-		AddRow("2023-02-22T00:00:00Z").
-		AddRow("2023-02-23T00:00:00Z")
-
-	s.mockDB.ExpectQuery(expSql1).
-		WillReturnRows(rows1)
-
-	expSql2 := regexp.QuoteMeta(`SELECT DISTINCT state, COUNT('state') FROM reports WHERE executed_at BETWEEN ? AND ? AND environment IN (?) GROUP BY state;`)
-
-	from1, err := time.Parse(time.DateTime, "2023-02-21 00:00:00")
-	s.Require().NoError(err)
-
-	to1, err := time.Parse(time.DateTime, "2023-02-22 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows2 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 5).
-		AddRow("FAILURE", 1).
-		AddRow("UNCHANGED", 3)
-
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from1.Format(time.DateOnly), to1.Format(time.DateOnly), summary.Environment_PRODUCTION).
-		WillReturnRows(rows2)
-
-	from2, err := time.Parse(time.DateTime, "2023-02-22 00:00:00")
-	s.Require().NoError(err)
-
-	to2, err := time.Parse(time.DateTime, "2023-02-23 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows3 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 3).
-		AddRow("FAILURE", 0).
-		AddRow("UNCHANGED", 6)
+	expSql := regexp.QuoteMeta(`SELECT DATE_FORMAT(executed_at, '%Y-%m-%d') AS bucket, state, COUNT(*) FROM reports WHERE environment IN (?) GROUP BY bucket, state ORDER BY bucket;`)
 
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from2.Format(time.DateOnly), to2.Format(time.DateOnly), summary.Environment_PRODUCTION).
-		WillReturnRows(rows3)
-
-	from3, err := time.Parse(time.DateTime, "2023-02-23 00:00:00")
-	s.Require().NoError(err)
-
-	to3, err := time.Parse(time.DateTime, "2023-02-24 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows4 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 2).
-		AddRow("FAILURE", 0).
-		AddRow("UNCHANGED", 7)
+	// Expect the history to be counted in a single query.
+	s.mockDB.ExpectPrepare(expSql)
 
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from3.Format(time.DateOnly), to3.Format(time.DateOnly), summary.Environment_PRODUCTION).
-		WillReturnRows(rows4)
+	rows := sqlmock.NewRows([]string{"bucket", "state", "COUNT(*)"}).
+		AddRow("2023-02-21", "CHANGED", 5).
+		AddRow("2023-02-21", "FAILURE", 1).
+		AddRow("2023-02-21", "UNCHANGED", 3).
+		AddRow("2023-02-22", "CHANGED", 3).
+		AddRow("2023-02-22", "UNCHANGED", 6).
+		AddRow("2023-02-23", "CHANGED", 2).
+		AddRow("2023-02-23", "UNCHANGED", 7)
 
-	s.mockDB.ExpectClose()
+	s.mockDB.ExpectQuery(expSql).
+		WithArgs(summary.Environment_PRODUCTION).
+		WillReturnRows(rows)
 
 	history, err := s.dbObject.GetHistory(context.Background(), summary.Environment_PRODUCTION)
 	s.Require().NoError(err)
@@ -412,6 +508,51 @@ func (s *mysqlSuite) TestGetHistorySingleEnv() {
 	}, history)
 }
 
+func (s *mysqlSuite) TestGetHistoryBuckets() {
+	expSql := regexp.QuoteMeta(`SELECT DATE_FORMAT(DATE_SUB(executed_at, INTERVAL WEEKDAY(executed_at) DAY), '%Y-%m-%d') AS bucket, state, COUNT(*) FROM reports WHERE executed_at >= ? AND executed_at < ? AND environment IN (?) GROUP BY bucket, state ORDER BY bucket;`)
+
+	from, err := time.Parse(time.DateTime, "2023-02-01 00:00:00")
+	s.Require().NoError(err)
+
+	to, err := time.Parse(time.DateTime, "2023-03-01 00:00:00")
+	s.Require().NoError(err)
+
+	// Expect the history to be counted in a single query.
+	s.mockDB.ExpectPrepare(expSql)
+
+	rows := sqlmock.NewRows([]string{"bucket", "state", "COUNT(*)"}).
+		AddRow("2023-02-13", "CHANGED", 4).
+		AddRow("2023-02-13", "FAILED", 1).
+		AddRow("2023-02-20", "UNCHANGED", 9)
+
+	s.mockDB.ExpectQuery(expSql).
+		WithArgs("2023-02-01 00:00:00", "2023-03-01 00:00:00", summary.Environment_PRODUCTION).
+		WillReturnRows(rows)
+
+	history, err := s.dbObject.GetHistoryBuckets(context.Background(), summary.HistoryBucket_week, from, to, nil, summary.Environment_PRODUCTION)
+	s.Require().NoError(err)
+
+	s.Require().Equal([]*entities.PuppetHistory{
+		{
+			Date:    "2023-02-13",
+			Changed: 4,
+			Failed:  1,
+		},
+		{
+			Date:      "2023-02-20",
+			Unchanged: 9,
+		},
+	}, history)
+}
+
+func (s *mysqlSuite) TestGetHistoryBucketsInvalid() {
+	_, err := s.dbObject.GetHistoryBuckets(context.Background(), "month", time.Time{}, time.Time{}, nil)
+	s.Require().EqualError(err, "invalid history bucket: month")
+
+	_, err = s.dbObject.GetHistoryBuckets(context.Background(), summary.HistoryBucket_day, time.Time{}, time.Time{}, nil, "INVALID")
+	s.Require().EqualError(err, "invalid environment: INVALID")
+}
+
 func (s *mysqlSuite) TestGetReport() {
 	expSql := regexp.QuoteMeta(`
 SELECT hash,
@@ -545,7 +686,9 @@ func (s *mysqlSuite) TestGetRunsByStateSingleState() {
 				fqdn,
 				state,
 				executed_at,
-				runtime
+				runtime,
+				environment,
+				yaml_file
 			FROM reports
 			WHERE state IN (?)
 			ORDER BY executed_at DESC;
@@ -558,9 +701,9 @@ func (s *mysqlSuite) TestGetRunsByStateSingleState() {
 
 	now := time.Now()
 
-	rows := sqlmock.NewRows([]string{"hash", "fqdn", "state", "executed_at", "runtime"}).
-		AddRow("hash1", "fqdn1", "CHANGED", now, "10s").
-		AddRow("hash2", "fqdn2", "CHANGED", now, "11s")
+	rows := sqlmock.NewRows([]string{"hash", "fqdn", "state", "executed_at", "runtime", "environment", "yaml_file"}).
+		AddRow("hash1", "fqdn1", "CHANGED", now, "10s", "PRODUCTION", "hash1.yaml").
+		AddRow("hash2", "fqdn2", "CHANGED", now, "11s", "PRODUCTION", "hash2.yaml")
 
 	s.mockDB.ExpectQuery(expSql).
 		WithArgs("CHANGED").
@@ -575,16 +718,20 @@ func (s *mysqlSuite) TestGetRunsByStateSingleState() {
 		{
 			ID:       "hash1",
 			Fqdn:     "fqdn1",
+			Env:      summary.Environment_PRODUCTION,
 			State:    summary.State_CHANGED,
 			ExecTime: entities.Datetime(now),
 			Runtime:  entities.Duration(10 * time.Second),
+			YamlFile: "hash1.yaml",
 		},
 		{
 			ID:       "hash2",
 			Fqdn:     "fqdn2",
+			Env:      summary.Environment_PRODUCTION,
 			State:    summary.State_CHANGED,
 			ExecTime: entities.Datetime(now),
 			Runtime:  entities.Duration(11 * time.Second),
+			YamlFile: "hash2.yaml",
 		},
 	}, report)
 }
@@ -596,9 +743,11 @@ func (s *mysqlSuite) TestGetRunsByStateMultipleStates() {
 				fqdn,
 				state,
 				executed_at,
-				runtime
+				runtime,
+				environment,
+				yaml_file
 			FROM reports
-			WHERE state IN (?)
+			WHERE state IN (?, ?)
 			ORDER BY executed_at DESC;
 		`)
 
@@ -609,12 +758,12 @@ func (s *mysqlSuite) TestGetRunsByStateMultipleStates() {
 
 	now := time.Now()
 
-	rows := sqlmock.NewRows([]string{"hash", "fqdn", "state", "executed_at", "runtime"}).
-		AddRow("hash1", "fqdn1", "CHANGED", now, "10s").
-		AddRow("hash2", "fqdn2", "UNCHANGED", now, "11s")
+	rows := sqlmock.NewRows([]string{"hash", "fqdn", "state", "executed_at", "runtime", "environment", "yaml_file"}).
+		AddRow("hash1", "fqdn1", "CHANGED", now, "10s", "PRODUCTION", "hash1.yaml").
+		AddRow("hash2", "fqdn2", "UNCHANGED", now, "11s", "PRODUCTION", "hash2.yaml")
 
 	s.mockDB.ExpectQuery(expSql).
-		WithArgs("CHANGED,UNCHANGED").
+		WithArgs("CHANGED", "UNCHANGED").
 		WillReturnRows(rows)
 
 	s.mockDB.ExpectClose()
@@ -626,16 +775,20 @@ func (s *mysqlSuite) TestGetRunsByStateMultipleStates() {
 		{
 			ID:       "hash1",
 			Fqdn:     "fqdn1",
+			Env:      summary.Environment_PRODUCTION,
 			State:    summary.State_CHANGED,
 			ExecTime: entities.Datetime(now),
 			Runtime:  entities.Duration(10 * time.Second),
+			YamlFile: "hash1.yaml",
 		},
 		{
 			ID:       "hash2",
 			Fqdn:     "fqdn2",
+			Env:      summary.Environment_PRODUCTION,
 			State:    summary.State_UNCHANGED,
 			ExecTime: entities.Datetime(now),
 			Runtime:  entities.Duration(11 * time.Second),
+			YamlFile: "hash2.yaml",
 		},
 	}, report)
 }
@@ -648,7 +801,8 @@ func (s *mysqlSuite) TestGetRuns() {
 		state,
 		executed_at,
 		runtime,
-		environment
+		environment,
+		yaml_file
 	FROM reports
 	ORDER BY executed_at DESC;
 	`)
@@ -660,9 +814,9 @@ func (s *mysqlSuite) TestGetRuns() {
 
 	now := time.Now()
 
-	rows := sqlmock.NewRows([]string{"hash", "fqdn", "state", "executed_at", "runtime", "environment"}).
-		AddRow("hash1", "fqdn1", "CHANGED", now, "10s", "PRODUCTION").
-		AddRow("hash2", "fqdn2", "UNCHANGED", now, "11s", "DEVELOPMENT")
+	rows := sqlmock.NewRows([]string{"hash", "fqdn", "state", "executed_at", "runtime", "environment", "yaml_file"}).
+		AddRow("hash1", "fqdn1", "CHANGED", now, "10s", "PRODUCTION", "hash1.yaml").
+		AddRow("hash2", "fqdn2", "UNCHANGED", now, "11s", "DEVELOPMENT", "hash2.yaml")
 
 	s.mockDB.ExpectQuery(expSql).
 		WillReturnRows(rows)
@@ -680,6 +834,7 @@ func (s *mysqlSuite) TestGetRuns() {
 			ExecTime: entities.Datetime(now),
 			Runtime:  entities.Duration(10 * time.Second),
 			Env:      summary.Environment_PRODUCTION,
+			YamlFile: "hash1.yaml",
 		},
 		{
 			ID:       "hash2",
@@ -688,6 +843,7 @@ func (s *mysqlSuite) TestGetRuns() {
 			ExecTime: entities.Datetime(now),
 			Runtime:  entities.Duration(11 * time.Second),
 			Env:      summary.Environment_DEVELOPMENT,
+			YamlFile: "hash2.yaml",
 		},
 	}, report)
 }
@@ -715,12 +871,28 @@ func (s *mysqlSuite) TestSaveRunSuccess() {
 	now, err := time.Parse(time.DateTime, "2024-02-21 10:20:53")
 	s.Require().NoError(err)
 
-	// Expect the report to be saved.
+	// Expect the report to be saved, with its version.
+	s.mockDB.ExpectBegin()
 	s.mockDB.ExpectPrepare(expSql)
 	s.mockDB.ExpectExec(expSql).
 		WithArgs("hash", "fqdn", "PRODUCTION", "CHANGED", "reports/PRODUCTION/fqdn/2024-02-21T10:20:53Z.yaml",
 			now.Format(time.DateTime), "10s", 1, 2, 3, 0).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`
+	INSERT INTO run_versions(
+	                         report_hash,
+	                         fqdn,
+	                         environment,
+	                         state,
+	                         executed_at,
+	                         config_version,
+	                         code_id
+	                         )
+	values(?,?,?,?,?,?,?);
+	`)).
+		WithArgs("hash", "fqdn", "PRODUCTION", "CHANGED", now.Format(time.DateTime), "1708135209", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectCommit()
 
 	s.mockDB.ExpectClose()
 
@@ -729,14 +901,223 @@ func (s *mysqlSuite) TestSaveRunSuccess() {
 		Fqdn:     "fqdn",
 		Env:      summary.Environment_PRODUCTION,
 		State:    summary.State_CHANGED,
-		YamlFile: "yaml_file",
 		ExecTime: entities.Datetime(now),
 		Runtime:  entities.Duration(10 * time.Second),
 		Failed:   1,
 		Changed:  2,
 		Total:    3,
+
+		ConfigVersion: "1708135209",
+	})
+	s.Require().NoError(err)
+}
+
+func (s *mysqlSuite) TestSaveRunFailedResources() {
+	expSql := regexp.QuoteMeta(`
+	INSERT INTO reports(
+	                    hash,
+	                    fqdn,
+	                    environment,
+	                    state,
+	                    yaml_file,
+	                    executed_at,
+	                    runtime,
+	                    failed,
+	                    changed,
+	                    total,
+	                    skipped
+	                    )
+	values(?,?,?,?,?,?,?,?,?,?,?);
+	`)
+
+	expFailedSql := regexp.QuoteMeta(`
+	INSERT INTO failed_resources(
+	                             report_hash,
+	                             fqdn,
+	                             environment,
+	                             executed_at,
+	                             resource_type,
+	                             title,
+	                             file,
+	                             line
+	                             )
+	values(?,?,?,?,?,?,?,?);
+	`)
+
+	ctx := context.Background()
+
+	now, err := time.Parse(time.DateTime, "2024-02-21 10:20:53")
+	s.Require().NoError(err)
+
+	// Expect the report and its failed resources to be saved together.
+	s.mockDB.ExpectBegin()
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", "FAILED", "reports/PRODUCTION/fqdn/2024-02-21T10:20:53Z.yaml",
+			now.Format(time.DateTime), "10s", 2, 0, 3, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectPrepare(expFailedSql)
+	s.mockDB.ExpectExec(expFailedSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", now.Format(time.DateTime), "Exec", "/usr/bin/deploy", "init.pp", "12").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mockDB.ExpectExec(expFailedSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", now.Format(time.DateTime), "File", "/etc/app.conf", "config.pp", "4").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	s.mockDB.ExpectCommit()
+
+	err = s.dbObject.SaveRun(ctx, &entities.PuppetReport{
+		ID:       "hash",
+		Fqdn:     "fqdn",
+		Env:      summary.Environment_PRODUCTION,
+		State:    summary.State_FAILED,
+		ExecTime: entities.Datetime(now),
+		Runtime:  entities.Duration(10 * time.Second),
+		Failed:   2,
+		Total:    3,
+		ResourcesFailed: []*entities.PuppetResource{
+			{Name: "/usr/bin/deploy", Type: "Exec", File: "init.pp", Line: "12"},
+			{Name: "/etc/app.conf", Type: "File", File: "config.pp", Line: "4"},
+		},
+	})
+	s.Require().NoError(err)
+	s.Require().NoError(s.mockDB.ExpectationsWereMet())
+}
+
+func (s *mysqlSuite) TestSaveRunTimings() {
+	expSql := regexp.QuoteMeta(`
+	INSERT INTO reports(
+	                    hash,
+	                    fqdn,
+	                    environment,
+	                    state,
+	                    yaml_file,
+	                    executed_at,
+	                    runtime,
+	                    failed,
+	                    changed,
+	                    total,
+	                    skipped
+	                    )
+	values(?,?,?,?,?,?,?,?,?,?,?);
+	`)
+
+	expTimingsSql := regexp.QuoteMeta(`
+	INSERT INTO run_timings(
+	                        report_hash,
+	                        fqdn,
+	                        environment,
+	                        executed_at,
+	                        name,
+	                        label,
+	                        seconds
+	                        )
+	values(?,?,?,?,?,?,?);
+	`)
+
+	ctx := context.Background()
+
+	now, err := time.Parse(time.DateTime, "2024-02-21 10:20:53")
+	s.Require().NoError(err)
+
+	// Expect the report and its timings to be saved together.
+	s.mockDB.ExpectBegin()
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", "UNCHANGED", "reports/PRODUCTION/fqdn/2024-02-21T10:20:53Z.yaml",
+			now.Format(time.DateTime), "10s", 0, 0, 3, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectPrepare(expTimingsSql)
+	s.mockDB.ExpectExec(expTimingsSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", now.Format(time.DateTime), "config_retrieval", "Config retrieval", 7.5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectExec(expTimingsSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", now.Format(time.DateTime), "total", "Total", 10.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectCommit()
+
+	err = s.dbObject.SaveRun(ctx, &entities.PuppetReport{
+		ID:       "hash",
+		Fqdn:     "fqdn",
+		Env:      summary.Environment_PRODUCTION,
+		State:    summary.State_UNCHANGED,
+		ExecTime: entities.Datetime(now),
+		Runtime:  entities.Duration(10 * time.Second),
+		Total:    3,
+		Timings: []*entities.Timing{
+			{Name: "config_retrieval", Label: "Config retrieval", Seconds: 7.5},
+			{Name: entities.TimingTotal, Label: "Total", Seconds: 10},
+		},
+	})
+	s.Require().NoError(err)
+	s.Require().NoError(s.mockDB.ExpectationsWereMet())
+}
+
+func (s *mysqlSuite) TestSaveRunMetrics() {
+	expSql := regexp.QuoteMeta(`
+	INSERT INTO reports(
+	                    hash,
+	                    fqdn,
+	                    environment,
+	                    state,
+	                    yaml_file,
+	                    executed_at,
+	                    runtime,
+	                    failed,
+	                    changed,
+	                    total,
+	                    skipped
+	                    )
+	values(?,?,?,?,?,?,?,?,?,?,?);
+	`)
+
+	expMetricsSql := regexp.QuoteMeta(`
+	INSERT INTO run_metrics(
+	                        report_hash,
+	                        fqdn,
+	                        environment,
+	                        executed_at,
+	                        metric,
+	                        value
+	                        )
+	values(?,?,?,?,?,?);
+	`)
+
+	ctx := context.Background()
+
+	now, err := time.Parse(time.DateTime, "2024-02-21 10:20:53")
+	s.Require().NoError(err)
+
+	// Expect the report and its metrics to be saved together, in order of their keys.
+	s.mockDB.ExpectBegin()
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", "UNCHANGED", "reports/PRODUCTION/fqdn/2024-02-21T10:20:53Z.yaml",
+			now.Format(time.DateTime), "10s", 0, 0, 3, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectPrepare(expMetricsSql)
+	s.mockDB.ExpectExec(expMetricsSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", now.Format(time.DateTime), "events.failure", 0.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectExec(expMetricsSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", now.Format(time.DateTime), "resources.out_of_sync", 3.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectCommit()
+
+	err = s.dbObject.SaveRun(ctx, &entities.PuppetReport{
+		ID:       "hash",
+		Fqdn:     "fqdn",
+		Env:      summary.Environment_PRODUCTION,
+		State:    summary.State_UNCHANGED,
+		ExecTime: entities.Datetime(now),
+		Runtime:  entities.Duration(10 * time.Second),
+		Total:    3,
+		Metrics: entities.Metrics{
+			"resources": {"out_of_sync": 3},
+			"events":    {"failure": 0},
+		},
 	})
 	s.Require().NoError(err)
+	s.Require().NoError(s.mockDB.ExpectationsWereMet())
 }
 
 func (s *mysqlSuite) TestSaveRunDuplicate() {
@@ -762,7 +1143,8 @@ func (s *mysqlSuite) TestSaveRunDuplicate() {
 	now, err := time.Parse(time.DateTime, "2024-02-21 10:20:53")
 	s.Require().NoError(err)
 
-	// Expect the report to be saved.
+	// Expect the report to be saved, and the transaction rolled back.
+	s.mockDB.ExpectBegin()
 	s.mockDB.ExpectPrepare(expSql)
 	s.mockDB.ExpectExec(expSql).
 		WithArgs("hash", "fqdn", "PRODUCTION", "CHANGED", "reports/PRODUCTION/fqdn/2024-02-21T10:20:53Z.yaml",
@@ -773,6 +1155,8 @@ func (s *mysqlSuite) TestSaveRunDuplicate() {
 			Message:  "Duplicate entry 'hash' for key 'PRIMARY'",
 		})
 
+	s.mockDB.ExpectRollback()
+
 	s.mockDB.ExpectClose()
 
 	err = s.dbObject.SaveRun(ctx, &entities.PuppetReport{
@@ -780,7 +1164,6 @@ func (s *mysqlSuite) TestSaveRunDuplicate() {
 		Fqdn:     "fqdn",
 		Env:      summary.Environment_PRODUCTION,
 		State:    summary.State_CHANGED,
-		YamlFile: "yaml_file",
 		ExecTime: entities.Datetime(now),
 		Runtime:  entities.Duration(10 * time.Second),
 		Failed:   1,
@@ -11,6 +11,9 @@ const (
 
 	// DbMongo is the MongoDB database.
 	DbMongo DbOpt = "MONGO"
+
+	// DbMemory is the in-memory database, which is lost when the process exits.
+	DbMemory DbOpt = "MEMORY"
 )
 
 func (d DbOpt) String() string {
@@ -31,5 +34,6 @@ func (d DbOpt) Valid() bool {
 		DbSqlite,
 		DbMySQL,
 		DbMongo,
+		DbMemory,
 	)
 }
@@ -6,7 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
@@ -17,55 +17,680 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// sqliteDbFile is the file the SQLite database is stored in.
+const sqliteDbFile = "puppet-summary.db"
+
 type sqliteImpl struct {
 	// client is the database.
 	client *Db
 }
 
-func (s *sqliteImpl) Reconnect(ctx context.Context, connStr string) error {
-	dbLite, err := sqlx.Open("sqlite3", connStr)
+func (s *sqliteImpl) Reconnect(ctx context.Context, connStr string) error {
+	dbLite, err := sqlx.Open("sqlite3", connStr)
+	if err != nil {
+		return fmt.Errorf("error opening database: %w", err)
+	}
+
+	err = s.client.Reconnect(ctx, dbLite)
+	if err != nil {
+		return fmt.Errorf("error reconnecting: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqliteImpl) Close(_ context.Context) error {
+	return s.client.Close()
+}
+
+func (s *sqliteImpl) Purge(ctx context.Context, from time.Time) (int, error) {
+	sqlStmt := `
+	DELETE FROM reports
+	WHERE executed_at < ?;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("purge"))
+	defer t.ObserveDuration()
+
+	// The failed resources, versions, timings, metrics and runtime anomalies of the reports are deleted with them, as
+	// are the incidents last seen before the reports.
+	tx, err := s.client.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer rollback(tx)
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM failed_resources
+	WHERE executed_at < ?;
+`, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error deleting failed resources: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM run_versions
+	WHERE executed_at < ?;
+`, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error deleting run versions: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM run_timings
+	WHERE executed_at < ?;
+`, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error deleting run timings: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM run_metrics
+	WHERE executed_at < ?;
+`, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error deleting run metrics: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM runtime_anomalies
+	WHERE executed_at < ?;
+`, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error deleting runtime anomalies: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+	DELETE FROM incidents
+	WHERE last_seen < ?;
+`, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error deleting incidents: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return 0, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	res, err := stmt.ExecContext(ctx, from.UTC().Format(time.DateTime))
+	if err != nil {
+		return 0, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return int(rows), nil
+}
+
+func (s *sqliteImpl) DeleteReports(ctx context.Context, ids ...string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	sqlStmt := `
+	DELETE FROM reports
+	WHERE hash IN (?);
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("delete_reports"))
+	defer t.ObserveDuration()
+
+	query, args, err := sqlx.In(sqlStmt, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	failedQuery, failedArgs, err := sqlx.In(`
+	DELETE FROM failed_resources
+	WHERE report_hash IN (?);
+`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	versionsQuery, versionsArgs, err := sqlx.In(`
+	DELETE FROM run_versions
+	WHERE report_hash IN (?);
+`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	timingsQuery, timingsArgs, err := sqlx.In(`
+	DELETE FROM run_timings
+	WHERE report_hash IN (?);
+`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	metricsQuery, metricsArgs, err := sqlx.In(`
+	DELETE FROM run_metrics
+	WHERE report_hash IN (?);
+`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	anomaliesQuery, anomaliesArgs, err := sqlx.In(`
+	DELETE FROM runtime_anomalies
+	WHERE report_hash IN (?);
+`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("error building query: %w", err)
+	}
+
+	// The failed resources, versions, timings, metrics and runtime anomalies of the reports are deleted with them.
+	tx, err := s.client.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer rollback(tx)
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(failedQuery), failedArgs...); err != nil {
+		return 0, fmt.Errorf("error deleting failed resources: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(versionsQuery), versionsArgs...); err != nil {
+		return 0, fmt.Errorf("error deleting run versions: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(timingsQuery), timingsArgs...); err != nil {
+		return 0, fmt.Errorf("error deleting run timings: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(metricsQuery), metricsArgs...); err != nil {
+		return 0, fmt.Errorf("error deleting run metrics: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(anomaliesQuery), anomaliesArgs...); err != nil {
+		return 0, fmt.Errorf("error deleting runtime anomalies: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, tx.Rebind(query))
+	if err != nil {
+		return 0, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return 0, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error getting rows affected: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return int(affected), nil
+}
+
+func (s *sqliteImpl) AcquireLock(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	// The lock is taken over if it has expired, or extended if it is already held by the holder.
+	sqlStmt := `
+	INSERT INTO locks (name, holder, expires_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(name) DO UPDATE SET
+		holder = excluded.holder,
+		expires_at = excluded.expires_at
+	WHERE locks.expires_at < ? OR locks.holder = excluded.holder;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("acquire_lock"))
+	defer t.ObserveDuration()
+
+	now := time.Now().UTC()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return false, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, name, holder, now.Add(ttl).Format(time.DateTime), now.Format(time.DateTime))
+	if err != nil {
+		return false, fmt.Errorf("error executing statement: %w", err)
+	}
+
+	sqlStmt = `
+	SELECT holder
+	FROM locks
+	WHERE name = ?;
+`
+
+	stmt, err = s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return false, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	var current string
+	if err := stmt.QueryRowContext(ctx, name).Scan(&current); err != nil {
+		return false, fmt.Errorf("error scanning row: %w", err)
+	}
+
+	return current == holder, nil
+}
+
+func (s *sqliteImpl) ReleaseLock(ctx context.Context, name, holder string) error {
+	sqlStmt := `
+	DELETE FROM locks
+	WHERE name = ? AND holder = ?;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("release_lock"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	if _, err := stmt.ExecContext(ctx, name, holder); err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqliteImpl) SaveJobRun(ctx context.Context, run *entities.JobRun) error {
+	sqlStmt := `
+	INSERT INTO job_runs (name, instance, started_at, duration, outcome, error)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT(name) DO UPDATE SET
+		instance = excluded.instance,
+		started_at = excluded.started_at,
+		duration = excluded.duration,
+		outcome = excluded.outcome,
+		error = excluded.error;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_job_run"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, run.Name, run.Instance, run.StartedAt.Time().UTC().Format(time.DateTime),
+		run.Duration.Time().String(), run.Outcome, run.Error)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqliteImpl) GetJobRuns(ctx context.Context) ([]*entities.JobRun, error) {
+	sqlStmt := `
+	SELECT name, instance, started_at, duration, outcome, error
+	FROM job_runs
+	ORDER BY name;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_job_runs"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}(rows)
+
+	runs := make([]*entities.JobRun, 0)
+	for rows.Next() {
+		run := new(entities.JobRun)
+		if err := rows.Scan(&run.Name, &run.Instance, &run.StartedAt, &run.Duration, &run.Outcome, &run.Error); err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+func (s *sqliteImpl) DecommissionNode(ctx context.Context, fqdn string, at time.Time) error {
+	sqlStmt := `
+	INSERT INTO decommissions (fqdn, decommissioned_at)
+	VALUES (?, ?)
+	ON CONFLICT(fqdn) DO UPDATE SET decommissioned_at = excluded.decommissioned_at;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("decommission_node"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, fqdn, at.UTC().Format(time.DateTime))
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqliteImpl) RecommissionNode(ctx context.Context, fqdn string) error {
+	sqlStmt := `
+	DELETE FROM decommissions
+	WHERE fqdn = ?;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("recommission_node"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, fqdn)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqliteImpl) GetDecommissions(ctx context.Context) (map[string]time.Time, error) {
+	sqlStmt := `
+	SELECT fqdn, decommissioned_at
+	FROM decommissions;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_decommissions"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}(rows)
+
+	decommissions := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			fqdn string
+			at   entities.Datetime
+		)
+		if err := rows.Scan(&fqdn, &at); err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+		decommissions[fqdn] = at.Time()
+	}
+
+	return decommissions, nil
+}
+
+func (s *sqliteImpl) GetNodeMetadata(ctx context.Context, fqdn string) (*entities.NodeMetadata, error) {
+	sqlStmt := `
+	SELECT fqdn, owner, role, notes, labels, updated_at
+	FROM node_metadata
+	WHERE fqdn = ?;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_node_metadata"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	md := new(entities.NodeMetadata)
+	err = stmt.QueryRowContext(ctx, fqdn).Scan(&md.Fqdn, &md.Owner, &md.Role, &md.Notes, &md.Labels, &md.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error scanning row: %w", err)
+	}
+
+	return md, nil
+}
+
+func (s *sqliteImpl) GetAllNodeMetadata(ctx context.Context) ([]*entities.NodeMetadata, error) {
+	sqlStmt := `
+	SELECT fqdn, owner, role, notes, labels, updated_at
+	FROM node_metadata
+	ORDER BY fqdn;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_all_node_metadata"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}(rows)
+
+	metadata := make([]*entities.NodeMetadata, 0)
+	for rows.Next() {
+		md := new(entities.NodeMetadata)
+		if err := rows.Scan(&md.Fqdn, &md.Owner, &md.Role, &md.Notes, &md.Labels, &md.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+		metadata = append(metadata, md)
+	}
+
+	return metadata, nil
+}
+
+func (s *sqliteImpl) SaveNodeMetadata(ctx context.Context, metadata *entities.NodeMetadata) error {
+	sqlStmt := `
+	INSERT INTO node_metadata (fqdn, owner, role, notes, labels, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT(fqdn) DO UPDATE SET
+		owner = excluded.owner,
+		role = excluded.role,
+		notes = excluded.notes,
+		labels = excluded.labels,
+		updated_at = excluded.updated_at;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_node_metadata"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, metadata.Fqdn, metadata.Owner, metadata.Role, metadata.Notes, metadata.Labels,
+		metadata.UpdatedAt.Time().UTC().Format(time.DateTime))
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqliteImpl) DeleteNodeMetadata(ctx context.Context, fqdn string) error {
+	sqlStmt := `
+	DELETE FROM node_metadata
+	WHERE fqdn = ?;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("delete_node_metadata"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
 	if err != nil {
-		return fmt.Errorf("error opening database: %w", err)
+		return fmt.Errorf("error preparing statement: %w", err)
 	}
 
-	err = s.client.Reconnect(ctx, dbLite)
+	res, err := stmt.ExecContext(ctx, fqdn)
 	if err != nil {
-		return fmt.Errorf("error reconnecting: %w", err)
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	} else if affected == 0 {
+		return ErrNotFound
 	}
 
 	return nil
 }
 
-func (s *sqliteImpl) Close(_ context.Context) error {
-	return s.client.Close()
+func (s *sqliteImpl) SaveSilence(ctx context.Context, silence *entities.Silence) error {
+	sqlStmt := `
+	INSERT INTO silences (id, kind, fqdn, owner, labels, created_by, reason, starts_at, ends_at, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET
+		kind = excluded.kind,
+		fqdn = excluded.fqdn,
+		owner = excluded.owner,
+		labels = excluded.labels,
+		created_by = excluded.created_by,
+		reason = excluded.reason,
+		starts_at = excluded.starts_at,
+		ends_at = excluded.ends_at,
+		created_at = excluded.created_at;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_silence"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+
+	_, err = stmt.ExecContext(ctx, silence.ID, silence.Kind, silence.Fqdn, silence.Owner, silence.Labels,
+		silence.CreatedBy, silence.Reason,
+		silence.StartsAt.Time().UTC().Format(time.DateTime),
+		silence.EndsAt.Time().UTC().Format(time.DateTime),
+		silence.CreatedAt.Time().UTC().Format(time.DateTime))
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	return nil
 }
 
-func (s *sqliteImpl) Purge(ctx context.Context, from time.Time) (int, error) {
+func (s *sqliteImpl) GetSilences(ctx context.Context) ([]*entities.Silence, error) {
 	sqlStmt := `
-	DELETE FROM reports
-	WHERE executed_at < ?;
+	SELECT id, kind, fqdn, owner, labels, created_by, reason, starts_at, ends_at, created_at
+	FROM silences
+	ORDER BY starts_at, id;
 `
 
 	// Start the prometheus metrics.
-	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("purge"))
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_silences"))
 	defer t.ObserveDuration()
 
 	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
 	if err != nil {
-		return 0, fmt.Errorf("error preparing statement: %w", err)
+		return nil, fmt.Errorf("error preparing statement: %w", err)
 	}
 
-	res, err := stmt.ExecContext(ctx, from.Format(time.DateTime))
+	rows, err := stmt.QueryContext(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("error executing statement: %w", err)
+		return nil, fmt.Errorf("error executing statement: %w", err)
 	}
+	defer func(rows *sql.Rows) {
+		if err := rows.Close(); err != nil {
+			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
+		}
+	}(rows)
 
-	rows, err := res.RowsAffected()
+	silences := make([]*entities.Silence, 0)
+	for rows.Next() {
+		sil := new(entities.Silence)
+		if err := rows.Scan(&sil.ID, &sil.Kind, &sil.Fqdn, &sil.Owner, &sil.Labels, &sil.CreatedBy, &sil.Reason,
+			&sil.StartsAt, &sil.EndsAt, &sil.CreatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning rows: %w", err)
+		}
+		silences = append(silences, sil)
+	}
+
+	return silences, nil
+}
+
+func (s *sqliteImpl) DeleteSilence(ctx context.Context, id string) error {
+	sqlStmt := `
+	DELETE FROM silences
+	WHERE id = ?;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("delete_silence"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
 	if err != nil {
-		return 0, fmt.Errorf("error getting rows affected: %w", err)
+		return fmt.Errorf("error preparing statement: %w", err)
 	}
 
-	return int(rows), nil
+	res, err := stmt.ExecContext(ctx, id)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error getting rows affected: %w", err)
+	} else if affected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
 }
 
 func (s *sqliteImpl) GetEnvironments(ctx context.Context) ([]summary.Environment, error) {
@@ -105,151 +730,244 @@ func (s *sqliteImpl) GetEnvironments(ctx context.Context) ([]summary.Environment
 	return envs, nil
 }
 
+// sqliteHistoryBuckets are the expressions of the dates of the buckets of history, keyed by the bucket.
+var sqliteHistoryBuckets = map[summary.HistoryBucket]string{
+	summary.HistoryBucket_hour: "STRFTIME('%Y-%m-%dT%H:00', executed_at)",
+	summary.HistoryBucket_day:  "DATE(executed_at)",
+	summary.HistoryBucket_week: "DATE(executed_at, 'weekday 0', '-6 days')",
+}
+
 func (s *sqliteImpl) GetHistory(ctx context.Context, environment ...summary.Environment) ([]*entities.PuppetHistory, error) {
-	res := make([]*entities.PuppetHistory, 0)
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_history"))
+	defer t.ObserveDuration()
 
-	limit := 30
+	history, err := s.GetHistoryBuckets(ctx, summary.HistoryBucket_day, time.Time{}, time.Time{}, nil, environment...)
+	if err != nil {
+		return nil, err
+	}
 
-	// Check the environments are valid.
-	whereClause := ""
-	envStrSlice := make([]any, len(environment))
-	if len(environment) > 0 {
-		for i, env := range environment {
-			if !env.IsValid() {
-				return nil, fmt.Errorf("invalid environment: %s", env)
-			}
+	return latestHistory(history, historyDays), nil
+}
 
-			whereClause += "?"
-			if i != len(environment)-1 {
-				whereClause += ","
-			}
+func (s *sqliteImpl) GetHistoryBuckets(ctx context.Context, bucket summary.HistoryBucket, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.PuppetHistory, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_history_buckets"))
+	defer t.ObserveDuration()
 
-			envStrSlice[i] = string(env)
-		}
+	if err := validateHistoryQuery(bucket, environment...); err != nil {
+		return nil, err
 	}
 
-	query := "SELECT DISTINCT DATE(executed_at) FROM reports;"
+	where := make([]string, 0)
+	args := make([]any, 0)
+	if !from.IsZero() {
+		where = append(where, "executed_at >= ?")
+		args = append(args, from.UTC().Format(time.DateTime))
+	}
+	if !to.IsZero() {
+		where = append(where, "executed_at < ?")
+		args = append(args, to.UTC().Format(time.DateTime))
+	}
 	if len(environment) > 0 {
-		query = "SELECT DISTINCT DATE(executed_at) FROM reports WHERE environment IN (" + whereClause + ");"
+		where = append(where, "environment IN (?)")
+		args = append(args, environment)
+	}
+	if fqdns != nil {
+		if len(fqdns) == 0 {
+			return make([]*entities.PuppetHistory, 0), nil
+		}
+		where = append(where, "fqdn IN (?)")
+		args = append(args, fqdns)
 	}
 
-	stmt, err := s.client.PrepareContext(ctx, query)
+	// The runs are counted by bucket and state in a single query.
+	sqlStmt := "SELECT " + sqliteHistoryBuckets[bucket] + " AS bucket, state, COUNT(*) FROM reports"
+	if len(where) > 0 {
+		sqlStmt += " WHERE " + strings.Join(where, " AND ")
+	}
+	sqlStmt += " GROUP BY bucket, state ORDER BY bucket;"
+
+	query, args, err := sqlx.In(sqlStmt, args...)
 	if err != nil {
-		return nil, fmt.Errorf("error preparing statement: %w", err)
+		return nil, fmt.Errorf("error building query: %w", err)
 	}
 
-	rows, err := stmt.QueryContext(ctx)
+	stmt, err := s.client.PrepareContext(ctx, s.client.Rebind(query))
 	if err != nil {
-		return nil, fmt.Errorf("error executing statement: %w", err)
+		return nil, fmt.Errorf("error preparing statement: %w", err)
 	}
-	defer func(stmt *sql.Stmt) {
+	defer func() {
 		if err := stmt.Close(); err != nil {
 			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
 		}
-	}(stmt)
-	defer func(rows *sql.Rows) {
+	}()
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error executing statement: %w", err)
+	}
+	defer func() {
 		if err := rows.Close(); err != nil {
 			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
 		}
-	}(rows)
-
-	dates := make([]string, 0)
+	}()
 
+	history := make([]*entities.PuppetHistory, 0)
 	for rows.Next() {
-		var d string
-		err = rows.Scan(&d)
-		if err != nil {
-			return nil, errors.New("failed to scan SQL")
+		var (
+			date  string
+			state summary.State
+			count int
+		)
+		if err := rows.Scan(&date, &state, &count); err != nil {
+			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
 
-		// Parse the date.
-		dt, err := time.Parse(time.DateOnly, d)
-		if err != nil {
-			slog.Error("Error parsing date", slog.String(logging.KeyError, err.Error()))
-			continue
+		// The rows are ordered by bucket, so a new bucket starts when the date changes.
+		if len(history) == 0 || history[len(history)-1].Date != date {
+			history = append(history, &entities.PuppetHistory{Date: date})
 		}
+		history[len(history)-1].AddCount(state, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading rows: %w", err)
+	}
+
+	return history, nil
+}
+
+func (s *sqliteImpl) GetResourceFailures(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.ResourceFailure, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_resource_failures"))
+	defer t.ObserveDuration()
+
+	return queryResourceFailures(ctx, s.client, from, to, environment...)
+}
+
+func (s *sqliteImpl) GetRunVersions(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.RunVersion, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_run_versions"))
+	defer t.ObserveDuration()
 
-		dates = append(dates, dt.Format(time.DateOnly))
+	return queryRunVersions(ctx, s.client, from, to, environment...)
+}
+
+func (s *sqliteImpl) GetTimings(ctx context.Context, from, to time.Time, fqdns []string, environment ...summary.Environment) ([]*entities.TimingSummary, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_timings"))
+	defer t.ObserveDuration()
+
+	return queryTimings(ctx, s.client, from, to, fqdns, environment...)
+}
+
+func (s *sqliteImpl) GetRunMetrics(ctx context.Context, from, to time.Time, metrics []string, environment ...summary.Environment) ([]*entities.RunMetric, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_run_metrics"))
+	defer t.ObserveDuration()
+
+	return queryRunMetrics(ctx, s.client, from, to, metrics, environment...)
+}
+
+func (s *sqliteImpl) SaveIncident(ctx context.Context, incident *entities.Incident) error {
+	sqlStmt := `
+	INSERT INTO incidents (id, environment, config_version, resources, message, first_seen, last_seen, nodes, reports, notified)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET
+		environment = excluded.environment,
+		config_version = excluded.config_version,
+		resources = excluded.resources,
+		message = excluded.message,
+		first_seen = excluded.first_seen,
+		last_seen = excluded.last_seen,
+		nodes = excluded.nodes,
+		reports = excluded.reports;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_incident"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
 	}
-	if len(dates) < limit {
-		limit = len(dates)
+
+	_, err = stmt.ExecContext(ctx, incident.ID, incident.Env, incident.ConfigVersion, incident.Resources,
+		incident.Message,
+		incident.FirstSeen.Time().UTC().Format(time.DateTime),
+		incident.LastSeen.Time().UTC().Format(time.DateTime),
+		incident.Nodes, incident.Reports, incident.Notified)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
 	}
 
-	for _, date := range dates[:limit] {
-		x := new(entities.PuppetHistory)
-		x.Changed = 0
-		x.Unchanged = 0
-		x.Failed = 0
-		x.Date = date
+	return nil
+}
 
-		startTime, err := time.Parse(time.DateOnly, date)
-		if err != nil {
-			slog.Error("Error parsing date", slog.String(logging.KeyError, err.Error()))
-			continue
-		}
-		endTime := startTime.AddDate(0, 0, 1)
-
-		locQuery := "SELECT DISTINCT state, COUNT('state') FROM reports WHERE executed_at BETWEEN ? AND ?"
-		if len(environment) > 0 {
-			locQuery += " AND environment IN ("
-			for i := range environment {
-				locQuery += "?"
-				if i != len(environment)-1 {
-					locQuery += ","
-				}
-			}
-			locQuery += ")"
-		}
+func (s *sqliteImpl) SetIncidentNotified(ctx context.Context, id string, notified bool) (bool, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("set_incident_notified"))
+	defer t.ObserveDuration()
 
-		locWhere := make([]any, 0)
-		locWhere = append(locWhere, startTime.Format(time.DateOnly))
-		locWhere = append(locWhere, endTime.Format(time.DateOnly))
-		if len(environment) > 0 {
-			locWhere = append(locWhere, envStrSlice...)
-		}
+	return setIncidentNotified(ctx, s.client, id, notified)
+}
 
-		locQuery += " GROUP BY state;"
-		stmt, err = s.client.PrepareContext(ctx, locQuery)
-		if err != nil {
-			return nil, fmt.Errorf("error preparing statement: %w", err)
-		}
+func (s *sqliteImpl) GetIncidents(ctx context.Context, from, to time.Time, environment ...summary.Environment) ([]*entities.Incident, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_incidents"))
+	defer t.ObserveDuration()
 
-		rows, err = stmt.QueryContext(ctx, locWhere...)
-		if err != nil {
-			return nil, fmt.Errorf("error executing statement: %w", err)
-		}
+	return queryIncidents(ctx, s.client, "", from, to, environment...)
+}
 
-		for rows.Next() {
-			var state summary.State
-			var count int
-
-			err = rows.Scan(&state, &count)
-			if err != nil {
-				return nil, errors.New("failed to scan SQL")
-			}
-			if state.IsIn(summary.State_CHANGED) {
-				x.Changed += count
-			}
-			if state.IsIn(summary.State_UNCHANGED) {
-				x.Unchanged += count
-			}
-			if state.IsIn(summary.State_FAILED) {
-				x.Failed += count
-			}
-		}
+func (s *sqliteImpl) GetIncident(ctx context.Context, id string) (*entities.Incident, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_incident"))
+	defer t.ObserveDuration()
 
-		if err := stmt.Close(); err != nil {
-			slog.Error("Error closing statement", slog.String(logging.KeyError, err.Error()))
-		}
+	return getIncident(ctx, s.client, id)
+}
 
-		if err := rows.Close(); err != nil {
-			slog.Error("Error closing rows", slog.String(logging.KeyError, err.Error()))
-		}
+func (s *sqliteImpl) SaveRuntimeAnomaly(ctx context.Context, anomaly *entities.RuntimeAnomaly) error {
+	sqlStmt := `
+	INSERT INTO runtime_anomalies (report_hash, fqdn, environment, executed_at, runtime, median, deviation, score)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(report_hash) DO UPDATE SET
+		fqdn = excluded.fqdn,
+		environment = excluded.environment,
+		executed_at = excluded.executed_at,
+		runtime = excluded.runtime,
+		median = excluded.median,
+		deviation = excluded.deviation,
+		score = excluded.score;
+`
+
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_runtime_anomaly"))
+	defer t.ObserveDuration()
+
+	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
 
-		res = append(res, x)
+	_, err = stmt.ExecContext(ctx, anomaly.ReportID, anomaly.Fqdn, anomaly.Env,
+		anomaly.ExecTime.Time().UTC().Format(time.DateTime),
+		anomaly.Runtime.Time().String(), anomaly.Median.Time().String(), anomaly.Deviation.Time().String(), anomaly.Score)
+	if err != nil {
+		return fmt.Errorf("error executing statement: %w", err)
 	}
 
-	return res, nil
+	return nil
+}
+
+func (s *sqliteImpl) GetRuntimeAnomalies(ctx context.Context, fqdn string, from, to time.Time, environment ...summary.Environment) ([]*entities.RuntimeAnomaly, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_runtime_anomalies"))
+	defer t.ObserveDuration()
+
+	return queryRuntimeAnomalies(ctx, s.client, fqdn, from, to, environment...)
 }
 
 func (s *sqliteImpl) GetReport(ctx context.Context, id string) (*entities.PuppetReport, error) {
@@ -346,6 +1064,14 @@ func (s *sqliteImpl) GetReports(ctx context.Context, fqdn string) ([]*entities.P
 	return reports, nil
 }
 
+func (s *sqliteImpl) GetLatestReports(ctx context.Context, fqdn string, before time.Time, limit int) ([]*entities.PuppetReportSummary, error) {
+	// Start the prometheus metrics.
+	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_latest_reports"))
+	defer t.ObserveDuration()
+
+	return queryLatestReports(ctx, s.client, fqdn, before, limit)
+}
+
 func (s *sqliteImpl) Ping(ctx context.Context) error {
 	// Start the prometheus metrics.
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("ping"))
@@ -361,7 +1087,9 @@ func (s *sqliteImpl) GetRunsByState(ctx context.Context, states ...summary.State
 		fqdn,
 		state,
 		executed_at,
-		runtime
+		runtime,
+		environment,
+		yaml_file
 	FROM reports
 	WHERE state IN (?)
 	ORDER BY executed_at DESC;
@@ -371,21 +1099,22 @@ func (s *sqliteImpl) GetRunsByState(ctx context.Context, states ...summary.State
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("get_runs_by_state"))
 	defer t.ObserveDuration()
 
-	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	runs := make([]*entities.PuppetRun, 0)
+	if len(states) == 0 {
+		return runs, nil
+	}
+
+	query, args, err := sqlx.In(sqlStmt, states)
 	if err != nil {
-		return nil, fmt.Errorf("error preparing statement: %w", err)
+		return nil, fmt.Errorf("error building query: %w", err)
 	}
 
-	// Convert the states to a string csv.
-	statesStr := ""
-	for i, state := range states {
-		statesStr += string(state)
-		if i != len(states)-1 {
-			statesStr += ","
-		}
+	stmt, err := s.client.PrepareContext(ctx, s.client.Rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("error preparing statement: %w", err)
 	}
 
-	rows, err := stmt.QueryContext(ctx, statesStr)
+	rows, err := stmt.QueryContext(ctx, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error executing statement: %w", err)
 	}
@@ -396,10 +1125,9 @@ func (s *sqliteImpl) GetRunsByState(ctx context.Context, states ...summary.State
 		}
 	}()
 
-	runs := make([]*entities.PuppetRun, 0)
 	for rows.Next() {
 		run := new(entities.PuppetRun)
-		if err := rows.Scan(&run.ID, &run.Fqdn, &run.State, &run.ExecTime, &run.Runtime); err != nil {
+		if err := rows.Scan(&run.ID, &run.Fqdn, &run.State, &run.ExecTime, &run.Runtime, &run.Env, &run.YamlFile); err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
 		runs = append(runs, run)
@@ -416,7 +1144,8 @@ func (s *sqliteImpl) GetRuns(ctx context.Context) ([]*entities.PuppetRun, error)
 		state,
 		executed_at,
 		runtime,
-		environment
+		environment,
+		yaml_file
 	FROM reports
 	ORDER BY executed_at DESC;
 `
@@ -444,7 +1173,7 @@ func (s *sqliteImpl) GetRuns(ctx context.Context) ([]*entities.PuppetRun, error)
 	runs := make([]*entities.PuppetRun, 0)
 	for rows.Next() {
 		run := new(entities.PuppetRun)
-		if err := rows.Scan(&run.ID, &run.Fqdn, &run.State, &run.ExecTime, &run.Runtime, &run.Env); err != nil {
+		if err := rows.Scan(&run.ID, &run.Fqdn, &run.State, &run.ExecTime, &run.Runtime, &run.Env, &run.YamlFile); err != nil {
 			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
 		runs = append(runs, run)
@@ -475,7 +1204,14 @@ func (s *sqliteImpl) SaveRun(ctx context.Context, run *entities.PuppetReport) er
 	t := prometheus.NewTimer(DatabaseLatency.WithLabelValues("save_run"))
 	defer t.ObserveDuration()
 
-	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+	// The report, its failed resources and its version are saved together.
+	tx, err := s.client.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer rollback(tx)
+
+	stmt, err := tx.PrepareContext(ctx, sqlStmt)
 	if err != nil {
 		return fmt.Errorf("error preparing statement: %w", err)
 	}
@@ -491,7 +1227,7 @@ func (s *sqliteImpl) SaveRun(ctx context.Context, run *entities.PuppetReport) er
 		run.Env,
 		run.State,
 		run.ReportFilePath(),
-		run.ExecTime.Time().Format(time.DateTime),
+		run.ExecTime.Time().UTC().Format(time.DateTime),
 		run.Runtime.String(),
 		run.Failed,
 		run.Changed,
@@ -505,6 +1241,26 @@ func (s *sqliteImpl) SaveRun(ctx context.Context, run *entities.PuppetReport) er
 	} else if err != nil {
 		return fmt.Errorf("error executing statement: %w", err)
 	}
+
+	if err := insertResourceFailures(ctx, tx, run); err != nil {
+		return fmt.Errorf("error saving failed resources: %w", err)
+	}
+
+	if err := insertRunVersion(ctx, tx, run); err != nil {
+		return fmt.Errorf("error saving run version: %w", err)
+	}
+
+	if err := insertRunTimings(ctx, tx, run); err != nil {
+		return fmt.Errorf("error saving run timings: %w", err)
+	}
+
+	if err := insertRunMetrics(ctx, tx, run); err != nil {
+		return fmt.Errorf("error saving run metrics: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
 	return nil
 }
 
@@ -512,7 +1268,7 @@ func (s *sqliteImpl) setup() error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
-	sqlStmt := `
+	sqlStmts := []string{`
         CREATE TABLE IF NOT EXISTS reports (
           id          INTEGER PRIMARY KEY AUTOINCREMENT,
 		  hash 	      text NOT NULL UNIQUE,
@@ -527,22 +1283,164 @@ func (s *sqliteImpl) setup() error {
           failed      integer,
           changed     integer
         )
-`
+`, `
+        CREATE TABLE IF NOT EXISTS decommissions (
+          fqdn              text PRIMARY KEY,
+          decommissioned_at DATETIME NOT NULL
+        )
+`, `
+        CREATE TABLE IF NOT EXISTS node_metadata (
+          fqdn       text PRIMARY KEY,
+          owner      text NOT NULL DEFAULT '',
+          role       text NOT NULL DEFAULT '',
+          notes      text NOT NULL DEFAULT '',
+          labels     text NOT NULL DEFAULT '{}',
+          updated_at DATETIME NOT NULL
+        )
+`, `
+        CREATE TABLE IF NOT EXISTS silences (
+          id         text PRIMARY KEY,
+          kind       text NOT NULL,
+          fqdn       text NOT NULL DEFAULT '',
+          owner      text NOT NULL DEFAULT '',
+          labels     text NOT NULL DEFAULT '{}',
+          created_by text NOT NULL DEFAULT '',
+          reason     text NOT NULL DEFAULT '',
+          starts_at  DATETIME NOT NULL,
+          ends_at    DATETIME NOT NULL,
+          created_at DATETIME NOT NULL
+        )
+`, `
+        CREATE TABLE IF NOT EXISTS failed_resources (
+          id            INTEGER PRIMARY KEY AUTOINCREMENT,
+          report_hash   text NOT NULL,
+          fqdn          text NOT NULL,
+          environment   text NOT NULL,
+          executed_at   DATETIME NOT NULL,
+          resource_type text NOT NULL,
+          title         text NOT NULL,
+          file          text NOT NULL DEFAULT '',
+          line          text NOT NULL DEFAULT ''
+        )
+`, `
+        CREATE INDEX IF NOT EXISTS failed_resources_report_hash ON failed_resources (report_hash)
+`, `
+        CREATE INDEX IF NOT EXISTS failed_resources_executed_at ON failed_resources (executed_at)
+`, `
+        CREATE TABLE IF NOT EXISTS incidents (
+          id             text PRIMARY KEY,
+          environment    text NOT NULL,
+          config_version text NOT NULL DEFAULT '',
+          resources      text NOT NULL DEFAULT '[]',
+          message        text NOT NULL DEFAULT '',
+          first_seen     DATETIME NOT NULL,
+          last_seen      DATETIME NOT NULL,
+          nodes          text NOT NULL DEFAULT '[]',
+          reports        integer NOT NULL DEFAULT 0,
+          notified       boolean NOT NULL DEFAULT 0
+        )
+`, `
+        CREATE INDEX IF NOT EXISTS incidents_last_seen ON incidents (last_seen)
+`, `
+        CREATE TABLE IF NOT EXISTS run_versions (
+          report_hash    text PRIMARY KEY,
+          fqdn           text NOT NULL,
+          environment    text NOT NULL,
+          state          text NOT NULL,
+          executed_at    DATETIME NOT NULL,
+          config_version text NOT NULL,
+          code_id        text NOT NULL DEFAULT ''
+        )
+`, `
+        CREATE INDEX IF NOT EXISTS run_versions_executed_at ON run_versions (executed_at)
+`, `
+        CREATE TABLE IF NOT EXISTS run_timings (
+          report_hash text NOT NULL,
+          fqdn        text NOT NULL,
+          environment text NOT NULL,
+          executed_at DATETIME NOT NULL,
+          name        text NOT NULL,
+          label       text NOT NULL,
+          seconds     REAL NOT NULL,
+          PRIMARY KEY (report_hash, name)
+        )
+`, `
+        CREATE INDEX IF NOT EXISTS run_timings_executed_at ON run_timings (executed_at)
+`, `
+        CREATE TABLE IF NOT EXISTS run_metrics (
+          report_hash text NOT NULL,
+          fqdn        text NOT NULL,
+          environment text NOT NULL,
+          executed_at DATETIME NOT NULL,
+          metric      text NOT NULL,
+          value       REAL NOT NULL,
+          PRIMARY KEY (report_hash, metric)
+        )
+`, `
+        CREATE INDEX IF NOT EXISTS run_metrics_executed_at ON run_metrics (executed_at)
+`, `
+        CREATE TABLE IF NOT EXISTS runtime_anomalies (
+          report_hash text PRIMARY KEY,
+          fqdn        text NOT NULL,
+          environment text NOT NULL,
+          executed_at DATETIME NOT NULL,
+          runtime     text NOT NULL,
+          median      text NOT NULL,
+          deviation   text NOT NULL,
+          score       REAL NOT NULL
+        )
+`, `
+        CREATE INDEX IF NOT EXISTS runtime_anomalies_executed_at ON runtime_anomalies (executed_at)
+`, `
+        CREATE INDEX IF NOT EXISTS runtime_anomalies_fqdn ON runtime_anomalies (fqdn)
+`, `
+        CREATE TABLE IF NOT EXISTS migrations (
+          name       text PRIMARY KEY,
+          applied_at DATETIME NOT NULL
+        )
+`, `
+        CREATE TABLE IF NOT EXISTS locks (
+          name       text PRIMARY KEY,
+          holder     text NOT NULL,
+          expires_at DATETIME NOT NULL
+        )
+`, `
+        CREATE TABLE IF NOT EXISTS job_runs (
+          name       text PRIMARY KEY,
+          instance   text NOT NULL,
+          started_at DATETIME NOT NULL,
+          duration   text NOT NULL,
+          outcome    text NOT NULL,
+          error      text NOT NULL DEFAULT ''
+        )
+`}
 
-	stmt, err := s.client.PrepareContext(ctx, sqlStmt)
-	if err != nil {
-		return fmt.Errorf("error preparing statement: %w", err)
-	}
+	for _, sqlStmt := range sqlStmts {
+		stmt, err := s.client.PrepareContext(ctx, sqlStmt)
+		if err != nil {
+			return fmt.Errorf("error preparing statement: %w", err)
+		}
 
-	_, err = stmt.ExecContext(ctx)
-	if err != nil {
-		return err
+		_, err = stmt.ExecContext(ctx)
+		if err != nil {
+			return err
+		}
 	}
-	return nil
+
+	// The migration is not bound by the timeout of the setup, as it goes through every report.
+	return migrateExecTimesToUTC(context.Background(), s.client, `
+	INSERT OR IGNORE INTO migrations (name, applied_at)
+	VALUES (?, ?);
+`)
 }
 
 func NewSQLite() (Database, error) {
-	dbLite, err := sqlx.Open("sqlite3", "file:puppet-summary.db?_journal_mode=WAL")
+	return NewSQLiteFile(sqliteDbFile)
+}
+
+// NewSQLiteFile opens the SQLite database stored in the given file, creating it if it does not exist.
+func NewSQLiteFile(file string) (Database, error) {
+	dbLite, err := sqlx.Open("sqlite3", "file:"+file+"?_journal_mode=WAL")
 	if err != nil {
 		return nil, fmt.Errorf("error opening database: %w", err)
 	}
@@ -554,8 +1452,7 @@ func NewSQLite() (Database, error) {
 	}
 
 	if err := impl.setup(); err != nil {
-		slog.Error("Error setting up SQLite", slog.String(logging.KeyError, err.Error()))
-		os.Exit(1)
+		return nil, fmt.Errorf("error setting up database: %w", err)
 	}
 
 	return impl, nil
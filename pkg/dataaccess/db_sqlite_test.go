@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"path/filepath"
 	"regexp"
 	"testing"
 	"time"
@@ -80,11 +82,32 @@ func (s *sqliteSuite) TestPurge() {
 	from, err := time.Parse(time.DateTime, "2023-02-21 00:00:00")
 	s.Require().NoError(err)
 
-	// Expect the database to be purged.
+	// Expect the database to be purged, with the failed resources, versions, timings, metrics and runtime anomalies of
+	// the reports and the incidents.
+	s.mockDB.ExpectBegin()
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM failed_resources WHERE executed_at < ?;`)).
+		WithArgs(from.Format(time.DateTime)).
+		WillReturnResult(sqlmock.NewResult(0, 7))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM run_versions WHERE executed_at < ?;`)).
+		WithArgs(from.Format(time.DateTime)).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM run_timings WHERE executed_at < ?;`)).
+		WithArgs(from.Format(time.DateTime)).
+		WillReturnResult(sqlmock.NewResult(0, 13))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM run_metrics WHERE executed_at < ?;`)).
+		WithArgs(from.Format(time.DateTime)).
+		WillReturnResult(sqlmock.NewResult(0, 30))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM runtime_anomalies WHERE executed_at < ?;`)).
+		WithArgs(from.Format(time.DateTime)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM incidents WHERE last_seen < ?;`)).
+		WithArgs(from.Format(time.DateTime)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
 	s.mockDB.ExpectPrepare(expSql)
 	s.mockDB.ExpectExec(expSql).
 		WithArgs(from.Format(time.DateTime)).
 		WillReturnResult(sqlmock.NewResult(0, 5))
+	s.mockDB.ExpectCommit()
 
 	affected, err := s.dbObject.Purge(context.Background(), from)
 	s.Require().NoError(err)
@@ -92,6 +115,77 @@ func (s *sqliteSuite) TestPurge() {
 	s.Require().Equal(5, affected)
 }
 
+func (s *sqliteSuite) TestDeleteReports() {
+	expSql := regexp.QuoteMeta(`
+		DELETE FROM reports
+		WHERE hash IN (?, ?);
+	`)
+
+	// Expect the reports to be deleted, with their failed resources, versions, timings, metrics and runtime anomalies.
+	s.mockDB.ExpectBegin()
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM failed_resources WHERE report_hash IN (?, ?);`)).
+		WithArgs("hash1", "hash2").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM run_versions WHERE report_hash IN (?, ?);`)).
+		WithArgs("hash1", "hash2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM run_timings WHERE report_hash IN (?, ?);`)).
+		WithArgs("hash1", "hash2").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM run_metrics WHERE report_hash IN (?, ?);`)).
+		WithArgs("hash1", "hash2").
+		WillReturnResult(sqlmock.NewResult(0, 8))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`DELETE FROM runtime_anomalies WHERE report_hash IN (?, ?);`)).
+		WithArgs("hash1", "hash2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("hash1", "hash2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	s.mockDB.ExpectCommit()
+
+	affected, err := s.dbObject.DeleteReports(context.Background(), "hash1", "hash2")
+	s.Require().NoError(err)
+
+	s.Require().Equal(2, affected)
+}
+
+func (s *sqliteSuite) TestDeleteReportsNoIDs() {
+	affected, err := s.dbObject.DeleteReports(context.Background())
+	s.Require().NoError(err)
+
+	s.Require().Equal(0, affected)
+}
+
+func (s *sqliteSuite) TestAcquireLock() {
+	expInsert := regexp.QuoteMeta(`
+	INSERT INTO locks (name, holder, expires_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(name) DO UPDATE SET
+		holder = excluded.holder,
+		expires_at = excluded.expires_at
+	WHERE locks.expires_at < ? OR locks.holder = excluded.holder;
+	`)
+	expSelect := regexp.QuoteMeta(`
+	SELECT holder
+	FROM locks
+	WHERE name = ?;
+	`)
+
+	s.mockDB.ExpectPrepare(expInsert)
+	s.mockDB.ExpectExec(expInsert).
+		WithArgs("purge", "host-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mockDB.ExpectPrepare(expSelect)
+	s.mockDB.ExpectQuery(expSelect).
+		WithArgs("purge").
+		WillReturnRows(sqlmock.NewRows([]string{"holder"}).AddRow("host-2"))
+
+	held, err := s.dbObject.AcquireLock(context.Background(), "purge", "host-1", time.Hour)
+	s.Require().NoError(err)
+	s.Require().False(held)
+}
+
 func (s *sqliteSuite) TestGetEnvironments() {
 	expSql := regexp.QuoteMeta(`
 		SELECT DISTINCT environment
@@ -122,76 +216,22 @@ func (s *sqliteSuite) TestGetEnvironments() {
 }
 
 func (s *sqliteSuite) TestGetHistoryAllEnvs() {
-	expSql1 := regexp.QuoteMeta(`SELECT DISTINCT DATE(executed_at) FROM reports;`)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql1)
+	expSql := regexp.QuoteMeta(`SELECT DATE(executed_at) AS bucket, state, COUNT(*) FROM reports GROUP BY bucket, state ORDER BY bucket;`)
 
-	rows1 := sqlmock.NewRows([]string{"DATE(executed_at)"}).
-		AddRow("2023-02-21").
-		AddRow("2023-02-22").
-		AddRow("2023-02-23")
-
-	s.mockDB.ExpectQuery(expSql1).
-		WillReturnRows(rows1)
-
-	expSql2 := regexp.QuoteMeta(`SELECT DISTINCT state, COUNT('state') FROM reports WHERE executed_at BETWEEN ? AND ? GROUP BY state;`)
-
-	from1, err := time.Parse(time.DateTime, "2023-02-21 00:00:00")
-	s.Require().NoError(err)
-
-	to1, err := time.Parse(time.DateTime, "2023-02-22 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows2 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 5).
-		AddRow("FAILURE", 1).
-		AddRow("UNCHANGED", 3)
-
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from1.Format(time.DateOnly), to1.Format(time.DateOnly)).
-		WillReturnRows(rows2)
-
-	from2, err := time.Parse(time.DateTime, "2023-02-22 00:00:00")
-	s.Require().NoError(err)
-
-	to2, err := time.Parse(time.DateTime, "2023-02-23 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows3 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 3).
-		AddRow("FAILURE", 0).
-		AddRow("UNCHANGED", 6)
-
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from2.Format(time.DateOnly), to2.Format(time.DateOnly)).
-		WillReturnRows(rows3)
-
-	from3, err := time.Parse(time.DateTime, "2023-02-23 00:00:00")
-	s.Require().NoError(err)
-
-	to3, err := time.Parse(time.DateTime, "2023-02-24 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows4 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 2).
-		AddRow("FAILURE", 0).
-		AddRow("UNCHANGED", 7)
+	// Expect the history to be counted in a single query.
+	s.mockDB.ExpectPrepare(expSql)
 
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from3.Format(time.DateOnly), to3.Format(time.DateOnly)).
-		WillReturnRows(rows4)
+	rows := sqlmock.NewRows([]string{"bucket", "state", "COUNT(*)"}).
+		AddRow("2023-02-21", "CHANGED", 5).
+		AddRow("2023-02-21", "FAILURE", 1).
+		AddRow("2023-02-21", "UNCHANGED", 3).
+		AddRow("2023-02-22", "CHANGED", 3).
+		AddRow("2023-02-22", "UNCHANGED", 6).
+		AddRow("2023-02-23", "CHANGED", 2).
+		AddRow("2023-02-23", "UNCHANGED", 7)
 
-	s.mockDB.ExpectClose()
+	s.mockDB.ExpectQuery(expSql).
+		WillReturnRows(rows)
 
 	history, err := s.dbObject.GetHistory(context.Background())
 	s.Require().NoError(err)
@@ -219,76 +259,23 @@ func (s *sqliteSuite) TestGetHistoryAllEnvs() {
 }
 
 func (s *sqliteSuite) TestGetHistoryMultipleEnv() {
-	expSql1 := regexp.QuoteMeta(`SELECT DISTINCT DATE(executed_at) FROM reports WHERE environment IN (?,?);`)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql1)
-
-	rows1 := sqlmock.NewRows([]string{"DATE(executed_at)"}).
-		AddRow("2023-02-21").
-		AddRow("2023-02-22").
-		AddRow("2023-02-23")
-
-	s.mockDB.ExpectQuery(expSql1).
-		WillReturnRows(rows1)
-
-	expSql2 := regexp.QuoteMeta(`SELECT DISTINCT state, COUNT('state') FROM reports WHERE executed_at BETWEEN ? AND ? AND environment IN (?,?) GROUP BY state;`)
+	expSql := regexp.QuoteMeta(`SELECT DATE(executed_at) AS bucket, state, COUNT(*) FROM reports WHERE environment IN (?, ?) GROUP BY bucket, state ORDER BY bucket;`)
 
-	from1, err := time.Parse(time.DateTime, "2023-02-21 00:00:00")
-	s.Require().NoError(err)
-
-	to1, err := time.Parse(time.DateTime, "2023-02-22 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows2 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 5).
-		AddRow("FAILURE", 1).
-		AddRow("UNCHANGED", 3)
-
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from1.Format(time.DateOnly), to1.Format(time.DateOnly), summary.Environment_PRODUCTION, summary.Environment_DEVELOPMENT).
-		WillReturnRows(rows2)
-
-	from2, err := time.Parse(time.DateTime, "2023-02-22 00:00:00")
-	s.Require().NoError(err)
-
-	to2, err := time.Parse(time.DateTime, "2023-02-23 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows3 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 3).
-		AddRow("FAILURE", 0).
-		AddRow("UNCHANGED", 6)
-
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from2.Format(time.DateOnly), to2.Format(time.DateOnly), summary.Environment_PRODUCTION, summary.Environment_DEVELOPMENT).
-		WillReturnRows(rows3)
-
-	from3, err := time.Parse(time.DateTime, "2023-02-23 00:00:00")
-	s.Require().NoError(err)
-
-	to3, err := time.Parse(time.DateTime, "2023-02-24 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows4 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 2).
-		AddRow("FAILURE", 0).
-		AddRow("UNCHANGED", 7)
+	// Expect the history to be counted in a single query.
+	s.mockDB.ExpectPrepare(expSql)
 
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from3.Format(time.DateOnly), to3.Format(time.DateOnly), summary.Environment_PRODUCTION, summary.Environment_DEVELOPMENT).
-		WillReturnRows(rows4)
+	rows := sqlmock.NewRows([]string{"bucket", "state", "COUNT(*)"}).
+		AddRow("2023-02-21", "CHANGED", 5).
+		AddRow("2023-02-21", "FAILURE", 1).
+		AddRow("2023-02-21", "UNCHANGED", 3).
+		AddRow("2023-02-22", "CHANGED", 3).
+		AddRow("2023-02-22", "UNCHANGED", 6).
+		AddRow("2023-02-23", "CHANGED", 2).
+		AddRow("2023-02-23", "UNCHANGED", 7)
 
-	s.mockDB.ExpectClose()
+	s.mockDB.ExpectQuery(expSql).
+		WithArgs(summary.Environment_PRODUCTION, summary.Environment_DEVELOPMENT).
+		WillReturnRows(rows)
 
 	history, err := s.dbObject.GetHistory(context.Background(), summary.Environment_PRODUCTION, summary.Environment_DEVELOPMENT)
 	s.Require().NoError(err)
@@ -316,76 +303,23 @@ func (s *sqliteSuite) TestGetHistoryMultipleEnv() {
 }
 
 func (s *sqliteSuite) TestGetHistorySingleEnv() {
-	expSql1 := regexp.QuoteMeta(`SELECT DISTINCT DATE(executed_at) FROM reports WHERE environment IN (?);`)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql1)
-
-	rows1 := sqlmock.NewRows([]string{"DATE(executed_at)"}).
-		AddRow("2023-02-21").
-		AddRow("2023-02-22").
-		AddRow("2023-02-23")
-
-	s.mockDB.ExpectQuery(expSql1).
-		WillReturnRows(rows1)
-
-	expSql2 := regexp.QuoteMeta(`SELECT DISTINCT state, COUNT('state') FROM reports WHERE executed_at BETWEEN ? AND ? AND environment IN (?) GROUP BY state;`)
-
-	from1, err := time.Parse(time.DateTime, "2023-02-21 00:00:00")
-	s.Require().NoError(err)
-
-	to1, err := time.Parse(time.DateTime, "2023-02-22 00:00:00")
-	s.Require().NoError(err)
+	expSql := regexp.QuoteMeta(`SELECT DATE(executed_at) AS bucket, state, COUNT(*) FROM reports WHERE environment IN (?) GROUP BY bucket, state ORDER BY bucket;`)
 
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows2 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 5).
-		AddRow("FAILURE", 1).
-		AddRow("UNCHANGED", 3)
-
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from1.Format(time.DateOnly), to1.Format(time.DateOnly), summary.Environment_PRODUCTION).
-		WillReturnRows(rows2)
-
-	from2, err := time.Parse(time.DateTime, "2023-02-22 00:00:00")
-	s.Require().NoError(err)
-
-	to2, err := time.Parse(time.DateTime, "2023-02-23 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows3 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 3).
-		AddRow("FAILURE", 0).
-		AddRow("UNCHANGED", 6)
-
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from2.Format(time.DateOnly), to2.Format(time.DateOnly), summary.Environment_PRODUCTION).
-		WillReturnRows(rows3)
-
-	from3, err := time.Parse(time.DateTime, "2023-02-23 00:00:00")
-	s.Require().NoError(err)
-
-	to3, err := time.Parse(time.DateTime, "2023-02-24 00:00:00")
-	s.Require().NoError(err)
-
-	// Expect the history to be retrieved.
-	s.mockDB.ExpectPrepare(expSql2)
-
-	rows4 := sqlmock.NewRows([]string{"state", "COUNT('state')"}).
-		AddRow("CHANGED", 2).
-		AddRow("FAILURE", 0).
-		AddRow("UNCHANGED", 7)
+	// Expect the history to be counted in a single query.
+	s.mockDB.ExpectPrepare(expSql)
 
-	s.mockDB.ExpectQuery(expSql2).
-		WithArgs(from3.Format(time.DateOnly), to3.Format(time.DateOnly), summary.Environment_PRODUCTION).
-		WillReturnRows(rows4)
+	rows := sqlmock.NewRows([]string{"bucket", "state", "COUNT(*)"}).
+		AddRow("2023-02-21", "CHANGED", 5).
+		AddRow("2023-02-21", "FAILURE", 1).
+		AddRow("2023-02-21", "UNCHANGED", 3).
+		AddRow("2023-02-22", "CHANGED", 3).
+		AddRow("2023-02-22", "UNCHANGED", 6).
+		AddRow("2023-02-23", "CHANGED", 2).
+		AddRow("2023-02-23", "UNCHANGED", 7)
 
-	s.mockDB.ExpectClose()
+	s.mockDB.ExpectQuery(expSql).
+		WithArgs(summary.Environment_PRODUCTION).
+		WillReturnRows(rows)
 
 	history, err := s.dbObject.GetHistory(context.Background(), summary.Environment_PRODUCTION)
 	s.Require().NoError(err)
@@ -412,6 +346,51 @@ func (s *sqliteSuite) TestGetHistorySingleEnv() {
 	}, history)
 }
 
+func (s *sqliteSuite) TestGetHistoryBuckets() {
+	expSql := regexp.QuoteMeta(`SELECT DATE(executed_at, 'weekday 0', '-6 days') AS bucket, state, COUNT(*) FROM reports WHERE executed_at >= ? AND executed_at < ? AND environment IN (?) GROUP BY bucket, state ORDER BY bucket;`)
+
+	from, err := time.Parse(time.DateTime, "2023-02-01 00:00:00")
+	s.Require().NoError(err)
+
+	to, err := time.Parse(time.DateTime, "2023-03-01 00:00:00")
+	s.Require().NoError(err)
+
+	// Expect the history to be counted in a single query.
+	s.mockDB.ExpectPrepare(expSql)
+
+	rows := sqlmock.NewRows([]string{"bucket", "state", "COUNT(*)"}).
+		AddRow("2023-02-13", "CHANGED", 4).
+		AddRow("2023-02-13", "FAILED", 1).
+		AddRow("2023-02-20", "UNCHANGED", 9)
+
+	s.mockDB.ExpectQuery(expSql).
+		WithArgs("2023-02-01 00:00:00", "2023-03-01 00:00:00", summary.Environment_PRODUCTION).
+		WillReturnRows(rows)
+
+	history, err := s.dbObject.GetHistoryBuckets(context.Background(), summary.HistoryBucket_week, from, to, nil, summary.Environment_PRODUCTION)
+	s.Require().NoError(err)
+
+	s.Require().Equal([]*entities.PuppetHistory{
+		{
+			Date:    "2023-02-13",
+			Changed: 4,
+			Failed:  1,
+		},
+		{
+			Date:      "2023-02-20",
+			Unchanged: 9,
+		},
+	}, history)
+}
+
+func (s *sqliteSuite) TestGetHistoryBucketsInvalid() {
+	_, err := s.dbObject.GetHistoryBuckets(context.Background(), "month", time.Time{}, time.Time{}, nil)
+	s.Require().EqualError(err, "invalid history bucket: month")
+
+	_, err = s.dbObject.GetHistoryBuckets(context.Background(), summary.HistoryBucket_day, time.Time{}, time.Time{}, nil, "INVALID")
+	s.Require().EqualError(err, "invalid environment: INVALID")
+}
+
 func (s *sqliteSuite) TestGetReport() {
 	expSql := regexp.QuoteMeta(`
 SELECT hash,
@@ -546,7 +525,9 @@ func (s *sqliteSuite) TestGetRunsByStateSingleState() {
 				fqdn,
 				state,
 				executed_at,
-				runtime
+				runtime,
+				environment,
+				yaml_file
 			FROM reports
 			WHERE state IN (?)
 			ORDER BY executed_at DESC;
@@ -559,9 +540,9 @@ func (s *sqliteSuite) TestGetRunsByStateSingleState() {
 
 	now := time.Now()
 
-	rows := sqlmock.NewRows([]string{"hash", "fqdn", "state", "executed_at", "runtime"}).
-		AddRow("hash1", "fqdn1", "CHANGED", now, "10s").
-		AddRow("hash2", "fqdn2", "CHANGED", now, "11s")
+	rows := sqlmock.NewRows([]string{"hash", "fqdn", "state", "executed_at", "runtime", "environment", "yaml_file"}).
+		AddRow("hash1", "fqdn1", "CHANGED", now, "10s", "PRODUCTION", "hash1.yaml").
+		AddRow("hash2", "fqdn2", "CHANGED", now, "11s", "PRODUCTION", "hash2.yaml")
 
 	s.mockDB.ExpectQuery(expSql).
 		WithArgs("CHANGED").
@@ -576,16 +557,20 @@ func (s *sqliteSuite) TestGetRunsByStateSingleState() {
 		{
 			ID:       "hash1",
 			Fqdn:     "fqdn1",
+			Env:      summary.Environment_PRODUCTION,
 			State:    summary.State_CHANGED,
 			ExecTime: entities.Datetime(now),
 			Runtime:  entities.Duration(10 * time.Second),
+			YamlFile: "hash1.yaml",
 		},
 		{
 			ID:       "hash2",
 			Fqdn:     "fqdn2",
+			Env:      summary.Environment_PRODUCTION,
 			State:    summary.State_CHANGED,
 			ExecTime: entities.Datetime(now),
 			Runtime:  entities.Duration(11 * time.Second),
+			YamlFile: "hash2.yaml",
 		},
 	}, report)
 }
@@ -597,9 +582,11 @@ func (s *sqliteSuite) TestGetRunsByStateMultipleStates() {
 				fqdn,
 				state,
 				executed_at,
-				runtime
+				runtime,
+				environment,
+				yaml_file
 			FROM reports
-			WHERE state IN (?)
+			WHERE state IN (?, ?)
 			ORDER BY executed_at DESC;
 		`)
 
@@ -610,12 +597,12 @@ func (s *sqliteSuite) TestGetRunsByStateMultipleStates() {
 
 	now := time.Now()
 
-	rows := sqlmock.NewRows([]string{"hash", "fqdn", "state", "executed_at", "runtime"}).
-		AddRow("hash1", "fqdn1", "CHANGED", now, "10s").
-		AddRow("hash2", "fqdn2", "UNCHANGED", now, "11s")
+	rows := sqlmock.NewRows([]string{"hash", "fqdn", "state", "executed_at", "runtime", "environment", "yaml_file"}).
+		AddRow("hash1", "fqdn1", "CHANGED", now, "10s", "PRODUCTION", "hash1.yaml").
+		AddRow("hash2", "fqdn2", "UNCHANGED", now, "11s", "PRODUCTION", "hash2.yaml")
 
 	s.mockDB.ExpectQuery(expSql).
-		WithArgs("CHANGED,UNCHANGED").
+		WithArgs("CHANGED", "UNCHANGED").
 		WillReturnRows(rows)
 
 	s.mockDB.ExpectClose()
@@ -627,16 +614,20 @@ func (s *sqliteSuite) TestGetRunsByStateMultipleStates() {
 		{
 			ID:       "hash1",
 			Fqdn:     "fqdn1",
+			Env:      summary.Environment_PRODUCTION,
 			State:    summary.State_CHANGED,
 			ExecTime: entities.Datetime(now),
 			Runtime:  entities.Duration(10 * time.Second),
+			YamlFile: "hash1.yaml",
 		},
 		{
 			ID:       "hash2",
 			Fqdn:     "fqdn2",
+			Env:      summary.Environment_PRODUCTION,
 			State:    summary.State_UNCHANGED,
 			ExecTime: entities.Datetime(now),
 			Runtime:  entities.Duration(11 * time.Second),
+			YamlFile: "hash2.yaml",
 		},
 	}, report)
 }
@@ -649,7 +640,8 @@ func (s *sqliteSuite) TestGetRuns() {
 		state,
 		executed_at,
 		runtime,
-		environment
+		environment,
+		yaml_file
 	FROM reports
 	ORDER BY executed_at DESC;
 	`)
@@ -661,9 +653,9 @@ func (s *sqliteSuite) TestGetRuns() {
 
 	now := time.Now()
 
-	rows := sqlmock.NewRows([]string{"hash", "fqdn", "state", "executed_at", "runtime", "environment"}).
-		AddRow("hash1", "fqdn1", "CHANGED", now, "10s", "PRODUCTION").
-		AddRow("hash2", "fqdn2", "UNCHANGED", now, "11s", "DEVELOPMENT")
+	rows := sqlmock.NewRows([]string{"hash", "fqdn", "state", "executed_at", "runtime", "environment", "yaml_file"}).
+		AddRow("hash1", "fqdn1", "CHANGED", now, "10s", "PRODUCTION", "hash1.yaml").
+		AddRow("hash2", "fqdn2", "UNCHANGED", now, "11s", "DEVELOPMENT", "hash2.yaml")
 
 	s.mockDB.ExpectQuery(expSql).
 		WillReturnRows(rows)
@@ -681,6 +673,7 @@ func (s *sqliteSuite) TestGetRuns() {
 			ExecTime: entities.Datetime(now),
 			Runtime:  entities.Duration(10 * time.Second),
 			Env:      summary.Environment_PRODUCTION,
+			YamlFile: "hash1.yaml",
 		},
 		{
 			ID:       "hash2",
@@ -689,6 +682,7 @@ func (s *sqliteSuite) TestGetRuns() {
 			ExecTime: entities.Datetime(now),
 			Runtime:  entities.Duration(11 * time.Second),
 			Env:      summary.Environment_DEVELOPMENT,
+			YamlFile: "hash2.yaml",
 		},
 	}, report)
 }
@@ -716,12 +710,28 @@ func (s *sqliteSuite) TestSaveRunSuccess() {
 	now, err := time.Parse(time.DateTime, "2024-02-21 10:20:53")
 	s.Require().NoError(err)
 
-	// Expect the report to be saved.
+	// Expect the report to be saved, with its version.
+	s.mockDB.ExpectBegin()
 	s.mockDB.ExpectPrepare(expSql)
 	s.mockDB.ExpectExec(expSql).
 		WithArgs("hash", "fqdn", "PRODUCTION", "CHANGED", "reports/PRODUCTION/fqdn/2024-02-21T10:20:53Z.yaml",
 			now.Format(time.DateTime), "10s", 1, 2, 3, 0).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectExec(regexp.QuoteMeta(`
+	INSERT INTO run_versions(
+	                         report_hash,
+	                         fqdn,
+	                         environment,
+	                         state,
+	                         executed_at,
+	                         config_version,
+	                         code_id
+	                         )
+	values(?,?,?,?,?,?,?);
+	`)).
+		WithArgs("hash", "fqdn", "PRODUCTION", "CHANGED", now.Format(time.DateTime), "1708135209", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectCommit()
 
 	s.mockDB.ExpectClose()
 
@@ -730,14 +740,152 @@ func (s *sqliteSuite) TestSaveRunSuccess() {
 		Fqdn:     "fqdn",
 		Env:      summary.Environment_PRODUCTION,
 		State:    summary.State_CHANGED,
-		YamlFile: "yaml_file",
 		ExecTime: entities.Datetime(now),
 		Runtime:  entities.Duration(10 * time.Second),
 		Failed:   1,
 		Changed:  2,
 		Total:    3,
+
+		ConfigVersion: "1708135209",
+	})
+	s.Require().NoError(err)
+}
+
+func (s *sqliteSuite) TestSaveRunTimings() {
+	expSql := regexp.QuoteMeta(`
+	INSERT INTO reports(
+	                    hash,
+	                    fqdn,
+	                    environment,
+	                    state,
+	                    yaml_file,
+	                    executed_at,
+	                    runtime,
+	                    failed,
+	                    changed,
+	                    total,
+	                    skipped
+	                    )
+	values(?,?,?,?,?,?,?,?,?,?,?);
+	`)
+
+	expTimingsSql := regexp.QuoteMeta(`
+	INSERT INTO run_timings(
+	                        report_hash,
+	                        fqdn,
+	                        environment,
+	                        executed_at,
+	                        name,
+	                        label,
+	                        seconds
+	                        )
+	values(?,?,?,?,?,?,?);
+	`)
+
+	ctx := context.Background()
+
+	now, err := time.Parse(time.DateTime, "2024-02-21 10:20:53")
+	s.Require().NoError(err)
+
+	// Expect the report and its timings to be saved together.
+	s.mockDB.ExpectBegin()
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", "UNCHANGED", "reports/PRODUCTION/fqdn/2024-02-21T10:20:53Z.yaml",
+			now.Format(time.DateTime), "10s", 0, 0, 3, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectPrepare(expTimingsSql)
+	s.mockDB.ExpectExec(expTimingsSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", now.Format(time.DateTime), "config_retrieval", "Config retrieval", 7.5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectExec(expTimingsSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", now.Format(time.DateTime), "total", "Total", 10.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectCommit()
+
+	err = s.dbObject.SaveRun(ctx, &entities.PuppetReport{
+		ID:       "hash",
+		Fqdn:     "fqdn",
+		Env:      summary.Environment_PRODUCTION,
+		State:    summary.State_UNCHANGED,
+		ExecTime: entities.Datetime(now),
+		Runtime:  entities.Duration(10 * time.Second),
+		Total:    3,
+		Timings: []*entities.Timing{
+			{Name: "config_retrieval", Label: "Config retrieval", Seconds: 7.5},
+			{Name: entities.TimingTotal, Label: "Total", Seconds: 10},
+		},
 	})
 	s.Require().NoError(err)
+	s.Require().NoError(s.mockDB.ExpectationsWereMet())
+}
+
+func (s *sqliteSuite) TestSaveRunMetrics() {
+	expSql := regexp.QuoteMeta(`
+	INSERT INTO reports(
+	                    hash,
+	                    fqdn,
+	                    environment,
+	                    state,
+	                    yaml_file,
+	                    executed_at,
+	                    runtime,
+	                    failed,
+	                    changed,
+	                    total,
+	                    skipped
+	                    )
+	values(?,?,?,?,?,?,?,?,?,?,?);
+	`)
+
+	expMetricsSql := regexp.QuoteMeta(`
+	INSERT INTO run_metrics(
+	                        report_hash,
+	                        fqdn,
+	                        environment,
+	                        executed_at,
+	                        metric,
+	                        value
+	                        )
+	values(?,?,?,?,?,?);
+	`)
+
+	ctx := context.Background()
+
+	now, err := time.Parse(time.DateTime, "2024-02-21 10:20:53")
+	s.Require().NoError(err)
+
+	// Expect the report and its metrics to be saved together, in order of their keys.
+	s.mockDB.ExpectBegin()
+	s.mockDB.ExpectPrepare(expSql)
+	s.mockDB.ExpectExec(expSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", "UNCHANGED", "reports/PRODUCTION/fqdn/2024-02-21T10:20:53Z.yaml",
+			now.Format(time.DateTime), "10s", 0, 0, 3, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectPrepare(expMetricsSql)
+	s.mockDB.ExpectExec(expMetricsSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", now.Format(time.DateTime), "events.failure", 0.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectExec(expMetricsSql).
+		WithArgs("hash", "fqdn", "PRODUCTION", now.Format(time.DateTime), "resources.out_of_sync", 3.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockDB.ExpectCommit()
+
+	err = s.dbObject.SaveRun(ctx, &entities.PuppetReport{
+		ID:       "hash",
+		Fqdn:     "fqdn",
+		Env:      summary.Environment_PRODUCTION,
+		State:    summary.State_UNCHANGED,
+		ExecTime: entities.Datetime(now),
+		Runtime:  entities.Duration(10 * time.Second),
+		Total:    3,
+		Metrics: entities.Metrics{
+			"resources": {"out_of_sync": 3},
+			"events":    {"failure": 0},
+		},
+	})
+	s.Require().NoError(err)
+	s.Require().NoError(s.mockDB.ExpectationsWereMet())
 }
 
 func (s *sqliteSuite) TestSaveRunDuplicate() {
@@ -763,13 +911,16 @@ func (s *sqliteSuite) TestSaveRunDuplicate() {
 	now, err := time.Parse(time.DateTime, "2024-02-21 10:20:53")
 	s.Require().NoError(err)
 
-	// Expect the report to be saved.
+	// Expect the report to be saved, and the transaction rolled back.
+	s.mockDB.ExpectBegin()
 	s.mockDB.ExpectPrepare(expSql)
 	s.mockDB.ExpectExec(expSql).
 		WithArgs("hash", "fqdn", "PRODUCTION", "CHANGED", "reports/PRODUCTION/fqdn/2024-02-21T10:20:53Z.yaml",
 			now.Format(time.DateTime), "10s", 1, 2, 3, 0).
 		WillReturnError(errors.New("UNIQUE constraint failed: reports.hash"))
 
+	s.mockDB.ExpectRollback()
+
 	s.mockDB.ExpectClose()
 
 	err = s.dbObject.SaveRun(ctx, &entities.PuppetReport{
@@ -777,7 +928,6 @@ func (s *sqliteSuite) TestSaveRunDuplicate() {
 		Fqdn:     "fqdn",
 		Env:      summary.Environment_PRODUCTION,
 		State:    summary.State_CHANGED,
-		YamlFile: "yaml_file",
 		ExecTime: entities.Datetime(now),
 		Runtime:  entities.Duration(10 * time.Second),
 		Failed:   1,
@@ -786,3 +936,66 @@ func (s *sqliteSuite) TestSaveRunDuplicate() {
 	})
 	s.Require().Equal(ErrDuplicate, err)
 }
+
+func TestSQLiteSetup_MigratesExecTimesToUTC(t *testing.T) {
+	db, err := NewSQLiteFile(filepath.Join(t.TempDir(), "puppet-summary.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close(context.Background()))
+	})
+
+	impl := db.(*sqliteImpl)
+	ctx := context.Background()
+
+	// Store the runs as they were before the execution times were stored in UTC, and forget the migration ran.
+	_, err = impl.client.ExecContext(ctx, "DELETE FROM migrations;")
+	require.NoError(t, err)
+	for _, run := range []struct{ hash, file, execTime string }{
+		{"local", "reports/PRODUCTION/node1/2024-02-13T12:00:00+02:00.yaml", "2024-02-13 12:00:00"},
+		{"utc", "reports/PRODUCTION/node2/2024-02-13T12:00:00Z.yaml", "2024-02-13 12:00:00"},
+	} {
+		_, err = impl.client.ExecContext(ctx, `
+			INSERT INTO reports (hash, fqdn, environment, state, yaml_file, runtime, executed_at, total, skipped, failed, changed)
+			VALUES (?, 'node', 'PRODUCTION', 'CHANGED', ?, '10s', ?, 1, 0, 0, 1);
+		`, run.hash, run.file, run.execTime)
+		require.NoError(t, err)
+
+		_, err = impl.client.ExecContext(ctx, `
+			INSERT INTO run_versions (report_hash, fqdn, environment, state, executed_at, config_version)
+			VALUES (?, 'node', 'PRODUCTION', 'CHANGED', ?, '1');
+		`, run.hash, run.execTime)
+		require.NoError(t, err)
+	}
+
+	execTimes := func(table, column string) map[string]time.Time {
+		rows, err := impl.client.QueryContext(ctx, fmt.Sprintf("SELECT %s, executed_at FROM %s;", column, table))
+		require.NoError(t, err)
+		defer rows.Close()
+
+		got := make(map[string]time.Time)
+		for rows.Next() {
+			var (
+				hash     string
+				execTime time.Time
+			)
+			require.NoError(t, rows.Scan(&hash, &execTime))
+			got[hash] = execTime.UTC()
+		}
+		require.NoError(t, rows.Err())
+		return got
+	}
+
+	want := map[string]time.Time{
+		"local": time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC),
+		"utc":   time.Date(2024, 2, 13, 12, 0, 0, 0, time.UTC),
+	}
+
+	require.NoError(t, impl.setup())
+	require.Equal(t, want, execTimes("reports", "hash"))
+	require.Equal(t, want, execTimes("run_versions", "report_hash"))
+
+	// The migration only runs once.
+	require.NoError(t, impl.setup())
+	require.Equal(t, want, execTimes("reports", "hash"))
+	require.Equal(t, want, execTimes("run_versions", "report_hash"))
+}
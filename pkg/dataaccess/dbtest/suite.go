@@ -0,0 +1,1092 @@
+// Package dbtest provides a conformance test suite for implementations of dataaccess.Database, so that every backend
+// is verified to behave the same way.
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/suite"
+)
+
+// Factory creates a new, empty, database for a test. Any clean up should be registered with t.Cleanup.
+type Factory func(t *testing.T) dataaccess.Database
+
+// Run runs the conformance suite against the databases created by the factory.
+func Run(t *testing.T, factory Factory) {
+	suite.Run(t, &Suite{Factory: factory})
+}
+
+// Suite is the conformance test suite for a dataaccess.Database.
+type Suite struct {
+	suite.Suite
+
+	// Factory creates the database for each test.
+	Factory Factory
+
+	// db is the database under test.
+	db dataaccess.Database
+
+	// ctx is the context used for the database calls.
+	ctx context.Context
+
+	// now is the time the reports are created relative to, truncated to the second as the databases store.
+	now time.Time
+}
+
+func (s *Suite) SetupTest() {
+	s.Require().NotNil(s.Factory, "the suite needs a factory to create the database")
+
+	s.ctx = context.Background()
+	s.db = s.Factory(s.T())
+	s.now = time.Now().UTC().Truncate(time.Second)
+}
+
+// newReport returns a report for the node, executed the given time before now.
+func (s *Suite) newReport(fqdn string, env summary.Environment, state summary.State, ago time.Duration) *entities.PuppetReport {
+	execTime := s.now.Add(-ago)
+	return &entities.PuppetReport{
+		ID:       fmt.Sprintf("%s-%s-%d", fqdn, env, execTime.Unix()),
+		Fqdn:     fqdn,
+		Env:      env,
+		State:    state,
+		ExecTime: entities.Datetime(execTime),
+		Runtime:  entities.Duration(12 * time.Second),
+		Failed:   1,
+		Changed:  2,
+		Total:    10,
+	}
+}
+
+// save saves the reports to the database.
+func (s *Suite) save(reports ...*entities.PuppetReport) {
+	for _, rep := range reports {
+		s.Require().NoError(s.db.SaveRun(s.ctx, rep))
+	}
+}
+
+// runIDs returns the IDs of the runs, in order.
+func runIDs(runs []*entities.PuppetRun) []string {
+	ids := make([]string, len(runs))
+	for i, run := range runs {
+		ids[i] = run.ID
+	}
+	return ids
+}
+
+func (s *Suite) TestPing() {
+	s.Require().NoError(s.db.Ping(s.ctx))
+}
+
+func (s *Suite) TestSaveRunDuplicate() {
+	rep := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour)
+	s.save(rep)
+
+	err := s.db.SaveRun(s.ctx, rep)
+	s.Require().ErrorIs(err, dataaccess.ErrDuplicate)
+
+	runs, err := s.db.GetRuns(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(runs, 1)
+}
+
+func (s *Suite) TestGetReport() {
+	rep := s.newReport("node1", summary.Environment_STAGING, summary.State_FAILED, time.Hour)
+	s.save(rep)
+
+	got, err := s.db.GetReport(s.ctx, rep.ID)
+	s.Require().NoError(err)
+	s.Require().Equal(rep.ID, got.ID)
+	s.Require().Equal(rep.Fqdn, got.Fqdn)
+	s.Require().Equal(rep.Env, got.Env)
+	s.Require().Equal(rep.State, got.State)
+	s.Require().True(rep.ExecTime.Time().Equal(got.ExecTime.Time()), "exec time %s != %s", rep.ExecTime, got.ExecTime)
+	s.Require().Equal(rep.Runtime, got.Runtime)
+	s.Require().Equal(rep.Failed, got.Failed)
+	s.Require().Equal(rep.Changed, got.Changed)
+	s.Require().Equal(rep.Total, got.Total)
+	s.Require().Equal(rep.ReportFilePath(), got.YamlFile)
+}
+
+func (s *Suite) TestGetReportNotFound() {
+	_, err := s.db.GetReport(s.ctx, "missing")
+	s.Require().ErrorIs(err, dataaccess.ErrNotFound)
+}
+
+func (s *Suite) TestGetReports() {
+	older := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 2*time.Hour)
+	newer := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_UNCHANGED, time.Hour)
+	other := s.newReport("node2", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour)
+	s.save(older, newer, other)
+
+	got, err := s.db.GetReports(s.ctx, "node1")
+	s.Require().NoError(err)
+	s.Require().Len(got, 2)
+
+	// The reports are returned newest first.
+	s.Require().Equal(newer.ID, got[0].ID)
+	s.Require().Equal(older.ID, got[1].ID)
+	s.Require().Equal(summary.State_UNCHANGED, got[0].State)
+	s.Require().Equal(newer.ReportFilePath(), got[0].YamlFile)
+}
+
+func (s *Suite) TestGetReportsUnknownNode() {
+	s.save(s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour))
+
+	got, err := s.db.GetReports(s.ctx, "node2")
+	s.Require().NoError(err)
+	s.Require().Empty(got)
+}
+
+func (s *Suite) TestGetRuns() {
+	oldest := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 3*time.Hour)
+	newest := s.newReport("node2", summary.Environment_STAGING, summary.State_FAILED, time.Hour)
+	middle := s.newReport("node3", summary.Environment_DEVELOPMENT, summary.State_UNCHANGED, 2*time.Hour)
+	s.save(oldest, newest, middle)
+
+	runs, err := s.db.GetRuns(s.ctx)
+	s.Require().NoError(err)
+
+	// The runs are returned newest first.
+	s.Require().Equal([]string{newest.ID, middle.ID, oldest.ID}, runIDs(runs))
+	s.Require().Equal("node2", runs[0].Fqdn)
+	s.Require().Equal(summary.Environment_STAGING, runs[0].Env)
+	s.Require().Equal(summary.State_FAILED, runs[0].State)
+	s.Require().True(newest.ExecTime.Time().Equal(runs[0].ExecTime.Time()))
+	s.Require().Equal(newest.Runtime, runs[0].Runtime)
+}
+
+func (s *Suite) TestGetRunsEmpty() {
+	runs, err := s.db.GetRuns(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Empty(runs)
+}
+
+func (s *Suite) TestGetRunsByState() {
+	changed := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 3*time.Hour)
+	failed := s.newReport("node2", summary.Environment_STAGING, summary.State_FAILED, time.Hour)
+	unchanged := s.newReport("node3", summary.Environment_DEVELOPMENT, summary.State_UNCHANGED, 2*time.Hour)
+	s.save(changed, failed, unchanged)
+
+	runs, err := s.db.GetRunsByState(s.ctx, summary.State_FAILED)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{failed.ID}, runIDs(runs))
+	s.Require().Equal(summary.Environment_STAGING, runs[0].Env)
+
+	// The runs are returned newest first.
+	runs, err = s.db.GetRunsByState(s.ctx, summary.State_CHANGED, summary.State_FAILED)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{failed.ID, changed.ID}, runIDs(runs))
+
+	runs, err = s.db.GetRunsByState(s.ctx, summary.State_SKIPPED)
+	s.Require().NoError(err)
+	s.Require().Empty(runs)
+}
+
+func (s *Suite) TestGetEnvironments() {
+	s.save(
+		s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour),
+		s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 2*time.Hour),
+		s.newReport("node2", summary.Environment_STAGING, summary.State_CHANGED, time.Hour),
+	)
+
+	envs, err := s.db.GetEnvironments(s.ctx)
+	s.Require().NoError(err)
+	s.Require().ElementsMatch([]summary.Environment{summary.Environment_PRODUCTION, summary.Environment_STAGING}, envs)
+}
+
+func (s *Suite) TestGetHistory() {
+	day := 24 * time.Hour
+	s.save(
+		s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 2*day),
+		s.newReport("node2", summary.Environment_PRODUCTION, summary.State_FAILED, 2*day),
+		s.newReport("node3", summary.Environment_STAGING, summary.State_UNCHANGED, 2*day),
+		s.newReport("node1", summary.Environment_PRODUCTION, summary.State_UNCHANGED, 5*day),
+	)
+
+	history, err := s.db.GetHistory(s.ctx, summary.Environment_PRODUCTION)
+	s.Require().NoError(err)
+	s.Require().ElementsMatch([]*entities.PuppetHistory{
+		{Date: s.now.Add(-2 * day).Format(time.DateOnly), Changed: 1, Failed: 1},
+		{Date: s.now.Add(-5 * day).Format(time.DateOnly), Unchanged: 1},
+	}, history)
+
+	history, err = s.db.GetHistory(s.ctx)
+	s.Require().NoError(err)
+	s.Require().ElementsMatch([]*entities.PuppetHistory{
+		{Date: s.now.Add(-2 * day).Format(time.DateOnly), Changed: 1, Failed: 1, Unchanged: 1},
+		{Date: s.now.Add(-5 * day).Format(time.DateOnly), Unchanged: 1},
+	}, history)
+}
+
+func (s *Suite) TestGetHistoryOrderAndLimit() {
+	day := 24 * time.Hour
+	for i := 0; i < 35; i++ {
+		s.save(s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Duration(i)*day))
+	}
+
+	history, err := s.db.GetHistory(s.ctx)
+	s.Require().NoError(err)
+
+	// The 30 most recent days are returned, oldest first.
+	s.Require().Len(history, 30)
+	s.Require().Equal(s.now.Add(-29*day).Format(time.DateOnly), history[0].Date)
+	s.Require().Equal(s.now.Format(time.DateOnly), history[29].Date)
+	for _, h := range history {
+		s.Require().Equal(1, h.Changed)
+	}
+}
+
+func (s *Suite) TestGetHistoryInvalidEnvironment() {
+	_, err := s.db.GetHistory(s.ctx, summary.Environment("INVALID"))
+	s.Require().Error(err)
+}
+
+func (s *Suite) TestGetHistoryBuckets() {
+	day := 24 * time.Hour
+
+	// The reports are executed relative to the Monday two weeks ago, so that every bucket is in the past.
+	today := s.now.Truncate(day)
+	start := today.AddDate(0, 0, -(int(today.Weekday())+6)%7-14)
+	at := func(fqdn string, env summary.Environment, state summary.State, offset time.Duration) *entities.PuppetReport {
+		return s.newReport(fqdn, env, state, s.now.Sub(start.Add(offset)))
+	}
+
+	s.save(
+		at("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour),
+		at("node2", summary.Environment_PRODUCTION, summary.State_FAILED, time.Hour+30*time.Minute),
+		at("node3", summary.Environment_STAGING, summary.State_UNCHANGED, 2*time.Hour),
+		at("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 3*day),
+		at("node1", summary.Environment_PRODUCTION, summary.State_UNCHANGED, 8*day),
+		// Before the range, so it is not counted.
+		at("node1", summary.Environment_PRODUCTION, summary.State_FAILED, -time.Hour),
+	)
+
+	from, to := start, start.Add(14*day)
+
+	history, err := s.db.GetHistoryBuckets(s.ctx, summary.HistoryBucket_hour, from, start.Add(day), nil, summary.Environment_PRODUCTION)
+	s.Require().NoError(err)
+	s.Require().Equal([]*entities.PuppetHistory{
+		{Date: start.Add(time.Hour).Format("2006-01-02T15:00"), Changed: 1, Failed: 1},
+	}, history)
+
+	history, err = s.db.GetHistoryBuckets(s.ctx, summary.HistoryBucket_day, from, to, nil)
+	s.Require().NoError(err)
+	s.Require().Equal([]*entities.PuppetHistory{
+		{Date: start.Format(time.DateOnly), Changed: 1, Failed: 1, Unchanged: 1},
+		{Date: start.Add(3 * day).Format(time.DateOnly), Changed: 1},
+		{Date: start.Add(8 * day).Format(time.DateOnly), Unchanged: 1},
+	}, history)
+
+	history, err = s.db.GetHistoryBuckets(s.ctx, summary.HistoryBucket_week, from, to, nil)
+	s.Require().NoError(err)
+	s.Require().Equal([]*entities.PuppetHistory{
+		{Date: start.Format(time.DateOnly), Changed: 2, Failed: 1, Unchanged: 1},
+		{Date: start.Add(7 * day).Format(time.DateOnly), Unchanged: 1},
+	}, history)
+
+	// Only the runs of the given nodes are counted.
+	history, err = s.db.GetHistoryBuckets(s.ctx, summary.HistoryBucket_week, from, to, []string{"node2", "node3"})
+	s.Require().NoError(err)
+	s.Require().Equal([]*entities.PuppetHistory{
+		{Date: start.Format(time.DateOnly), Failed: 1, Unchanged: 1},
+	}, history)
+
+	history, err = s.db.GetHistoryBuckets(s.ctx, summary.HistoryBucket_week, from, to, []string{})
+	s.Require().NoError(err)
+	s.Require().Empty(history)
+}
+
+func (s *Suite) TestGetHistoryBucketsInvalidBucket() {
+	_, err := s.db.GetHistoryBuckets(s.ctx, summary.HistoryBucket("month"), time.Time{}, time.Time{}, nil)
+	s.Require().Error(err)
+}
+
+func (s *Suite) TestGetResourceFailures() {
+	exec := &entities.PuppetResource{Name: "/usr/bin/deploy", Type: "Exec", File: "/etc/puppet/modules/app/manifests/init.pp", Line: "12"}
+	file := &entities.PuppetResource{Name: "/etc/app.conf", Type: "File", File: "/etc/puppet/modules/app/manifests/config.pp", Line: "4"}
+
+	rep1 := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_FAILED, time.Hour)
+	rep1.ResourcesFailed = []*entities.PuppetResource{exec, file}
+	rep2 := s.newReport("node2", summary.Environment_STAGING, summary.State_FAILED, 2*time.Hour)
+	rep2.ResourcesFailed = []*entities.PuppetResource{exec}
+	old := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_FAILED, 48*time.Hour)
+	old.ResourcesFailed = []*entities.PuppetResource{file}
+	ok := s.newReport("node3", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour)
+	s.save(rep1, rep2, old, ok)
+
+	failures, err := s.db.GetResourceFailures(s.ctx, time.Time{}, time.Time{})
+	s.Require().NoError(err)
+	s.Require().Len(failures, 4)
+	s.Require().Equal(rep1.ID, failures[0].ReportID)
+	s.Require().Equal(rep2.ID, failures[2].ReportID)
+	s.Require().Equal(old.ID, failures[3].ReportID)
+
+	got := failures[2]
+	s.Require().Equal("node2", got.Fqdn)
+	s.Require().Equal(summary.Environment_STAGING, got.Env)
+	s.Require().True(rep2.ExecTime.Time().Equal(got.ExecTime.Time()), "exec time %s != %s", rep2.ExecTime, got.ExecTime)
+	s.Require().Equal(exec, got.Resource)
+
+	// Only the failures in the range and the environments are returned.
+	failures, err = s.db.GetResourceFailures(s.ctx, s.now.Add(-24*time.Hour), s.now, summary.Environment_PRODUCTION)
+	s.Require().NoError(err)
+	s.Require().Len(failures, 2)
+	s.Require().Equal(rep1.ID, failures[0].ReportID)
+	s.Require().Equal(rep1.ID, failures[1].ReportID)
+
+	// The failures are deleted with their reports.
+	_, err = s.db.DeleteReports(s.ctx, rep1.ID)
+	s.Require().NoError(err)
+	_, err = s.db.Purge(s.ctx, s.now.Add(-24*time.Hour))
+	s.Require().NoError(err)
+
+	failures, err = s.db.GetResourceFailures(s.ctx, time.Time{}, time.Time{})
+	s.Require().NoError(err)
+	s.Require().Len(failures, 1)
+	s.Require().Equal(rep2.ID, failures[0].ReportID)
+
+	_, err = s.db.GetResourceFailures(s.ctx, time.Time{}, time.Time{}, summary.Environment("INVALID"))
+	s.Require().Error(err)
+}
+
+func (s *Suite) TestGetRunVersions() {
+	rep1 := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 2*time.Hour)
+	rep1.ConfigVersion = "1708135209"
+	rep2 := s.newReport("node2", summary.Environment_PRODUCTION, summary.State_FAILED, time.Hour)
+	rep2.ConfigVersion = "1708135999"
+	rep2.CodeID = "urn:puppet:code-id:1:abc"
+	staging := s.newReport("node3", summary.Environment_STAGING, summary.State_CHANGED, time.Hour)
+	staging.ConfigVersion = "1708135999"
+	old := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 48*time.Hour)
+	old.ConfigVersion = "1708130000"
+	unversioned := s.newReport("node4", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour)
+	s.save(rep1, rep2, staging, old, unversioned)
+
+	versions, err := s.db.GetRunVersions(s.ctx, time.Time{}, time.Time{})
+	s.Require().NoError(err)
+	s.Require().Len(versions, 4)
+	s.Require().Equal(old.ID, versions[0].ReportID)
+	s.Require().Equal(rep1.ID, versions[1].ReportID)
+
+	// Only the versions in the range and the environments are returned, oldest first.
+	versions, err = s.db.GetRunVersions(s.ctx, s.now.Add(-24*time.Hour), s.now, summary.Environment_PRODUCTION)
+	s.Require().NoError(err)
+	s.Require().Len(versions, 2)
+	s.Require().Equal(rep1.ID, versions[0].ReportID)
+
+	got := versions[1]
+	s.Require().Equal(rep2.ID, got.ReportID)
+	s.Require().Equal("node2", got.Fqdn)
+	s.Require().Equal(summary.Environment_PRODUCTION, got.Env)
+	s.Require().Equal(summary.State_FAILED, got.State)
+	s.Require().True(rep2.ExecTime.Time().Equal(got.ExecTime.Time()), "exec time %s != %s", rep2.ExecTime, got.ExecTime)
+	s.Require().Equal("1708135999", got.ConfigVersion)
+	s.Require().Equal("urn:puppet:code-id:1:abc", got.CodeID)
+
+	// The versions are deleted with their reports.
+	_, err = s.db.DeleteReports(s.ctx, rep1.ID)
+	s.Require().NoError(err)
+	_, err = s.db.Purge(s.ctx, s.now.Add(-24*time.Hour))
+	s.Require().NoError(err)
+
+	versions, err = s.db.GetRunVersions(s.ctx, time.Time{}, time.Time{})
+	s.Require().NoError(err)
+	s.Require().Len(versions, 2)
+	s.Require().Equal(rep2.ID, versions[0].ReportID)
+	s.Require().Equal(staging.ID, versions[1].ReportID)
+
+	_, err = s.db.GetRunVersions(s.ctx, time.Time{}, time.Time{}, summary.Environment("INVALID"))
+	s.Require().Error(err)
+}
+
+func (s *Suite) TestGetTimings() {
+	rep1 := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 2*time.Hour)
+	rep1.Timings = []*entities.Timing{
+		{Name: "config_retrieval", Label: "Config retrieval", Seconds: 4},
+		{Name: "file", Label: "File", Seconds: 1.5},
+		{Name: entities.TimingTotal, Label: "Total", Seconds: 6},
+	}
+	rep2 := s.newReport("node2", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour)
+	rep2.Timings = []*entities.Timing{
+		{Name: "config_retrieval", Label: "Config retrieval", Seconds: 2},
+		{Name: "exec", Label: "Exec", Seconds: 8},
+		{Name: entities.TimingTotal, Label: "Total", Seconds: 11},
+	}
+	staging := s.newReport("node3", summary.Environment_STAGING, summary.State_CHANGED, time.Hour)
+	staging.Timings = []*entities.Timing{
+		{Name: entities.TimingTotal, Label: "Total", Seconds: 30},
+	}
+	old := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 48*time.Hour)
+	old.Timings = []*entities.Timing{
+		{Name: "package", Label: "Package", Seconds: 20},
+	}
+	s.save(rep1, rep2, staging, old)
+
+	timings, err := s.db.GetTimings(s.ctx, time.Time{}, time.Time{}, nil)
+	s.Require().NoError(err)
+	s.Require().Len(timings, 5)
+	s.Require().Equal(entities.TimingTotal, timings[0].Name)
+	s.Require().Equal(47.0, timings[0].Seconds)
+
+	// Only the timings of the runs in the range and the environments are aggregated, most time spent first.
+	timings, err = s.db.GetTimings(s.ctx, s.now.Add(-24*time.Hour), s.now, nil, summary.Environment_PRODUCTION)
+	s.Require().NoError(err)
+	s.Require().Len(timings, 4)
+
+	got := timings[0]
+	s.Require().Equal(entities.TimingTotal, got.Name)
+	s.Require().Equal("Total", got.Label)
+	s.Require().Equal(2, got.Runs)
+	s.Require().Equal(17.0, got.Seconds)
+	s.Require().Equal(11.0, got.Max)
+
+	s.Require().Equal("exec", timings[1].Name)
+	s.Require().Equal(1, timings[1].Runs)
+
+	got = timings[2]
+	s.Require().Equal("config_retrieval", got.Name)
+	s.Require().Equal("Config retrieval", got.Label)
+	s.Require().Equal(2, got.Runs)
+	s.Require().Equal(6.0, got.Seconds)
+	s.Require().Equal(4.0, got.Max)
+
+	s.Require().Equal("file", timings[3].Name)
+
+	// Only the timings of the runs of the given nodes are aggregated.
+	timings, err = s.db.GetTimings(s.ctx, s.now.Add(-24*time.Hour), s.now, []string{"node2", "node3"})
+	s.Require().NoError(err)
+	s.Require().Len(timings, 3)
+	s.Require().Equal(entities.TimingTotal, timings[0].Name)
+	s.Require().Equal(41.0, timings[0].Seconds)
+
+	timings, err = s.db.GetTimings(s.ctx, time.Time{}, time.Time{}, []string{})
+	s.Require().NoError(err)
+	s.Require().Empty(timings)
+
+	// The timings are deleted with their reports.
+	_, err = s.db.DeleteReports(s.ctx, rep1.ID)
+	s.Require().NoError(err)
+	_, err = s.db.Purge(s.ctx, s.now.Add(-24*time.Hour))
+	s.Require().NoError(err)
+
+	timings, err = s.db.GetTimings(s.ctx, time.Time{}, time.Time{}, nil)
+	s.Require().NoError(err)
+	s.Require().Len(timings, 3)
+	s.Require().Equal(entities.TimingTotal, timings[0].Name)
+	s.Require().Equal(41.0, timings[0].Seconds)
+
+	_, err = s.db.GetTimings(s.ctx, time.Time{}, time.Time{}, nil, summary.Environment("INVALID"))
+	s.Require().Error(err)
+}
+
+func (s *Suite) TestGetRunMetrics() {
+	rep1 := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 2*time.Hour)
+	rep1.Metrics = entities.Metrics{
+		"events":    {"failure": 1, "success": 4},
+		"resources": {"out_of_sync": 5},
+	}
+	rep2 := s.newReport("node2", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour)
+	rep2.Metrics = entities.Metrics{
+		"events": {"failure": 2},
+	}
+	staging := s.newReport("node3", summary.Environment_STAGING, summary.State_CHANGED, time.Hour)
+	staging.Metrics = entities.Metrics{
+		"events": {"failure": 7},
+	}
+	old := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 48*time.Hour)
+	old.Metrics = entities.Metrics{
+		"changes": {"total": 3},
+	}
+	s.save(rep1, rep2, staging, old)
+
+	values, err := s.db.GetRunMetrics(s.ctx, time.Time{}, time.Time{}, nil)
+	s.Require().NoError(err)
+	s.Require().Len(values, 6)
+
+	// Only the given metrics of the runs in the range and the environments are returned, oldest first.
+	values, err = s.db.GetRunMetrics(s.ctx, s.now.Add(-24*time.Hour), s.now, []string{"events.failure", "resources.out_of_sync"},
+		summary.Environment_PRODUCTION)
+	s.Require().NoError(err)
+	s.Require().Len(values, 3)
+
+	got := values[0]
+	s.Require().Equal(rep1.ID, got.ReportID)
+	s.Require().Equal("node1", got.Fqdn)
+	s.Require().Equal(summary.Environment_PRODUCTION, got.Env)
+	s.Require().True(got.ExecTime.Time().Equal(rep1.ExecTime.Time()))
+	s.Require().Equal("events.failure", got.Metric)
+	s.Require().Equal(1.0, got.Value)
+
+	s.Require().Equal(rep1.ID, values[1].ReportID)
+	s.Require().Equal("resources.out_of_sync", values[1].Metric)
+	s.Require().Equal(5.0, values[1].Value)
+
+	s.Require().Equal(rep2.ID, values[2].ReportID)
+	s.Require().Equal("events.failure", values[2].Metric)
+	s.Require().Equal(2.0, values[2].Value)
+
+	// The metrics are deleted with their reports.
+	_, err = s.db.DeleteReports(s.ctx, rep1.ID)
+	s.Require().NoError(err)
+	_, err = s.db.Purge(s.ctx, s.now.Add(-24*time.Hour))
+	s.Require().NoError(err)
+
+	values, err = s.db.GetRunMetrics(s.ctx, time.Time{}, time.Time{}, nil)
+	s.Require().NoError(err)
+	s.Require().Len(values, 2)
+
+	_, err = s.db.GetRunMetrics(s.ctx, time.Time{}, time.Time{}, nil, summary.Environment("INVALID"))
+	s.Require().Error(err)
+}
+
+func (s *Suite) TestDeleteReports() {
+	keep := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour)
+	del1 := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 2*time.Hour)
+	del2 := s.newReport("node2", summary.Environment_PRODUCTION, summary.State_CHANGED, 2*time.Hour)
+	s.save(keep, del1, del2)
+
+	affected, err := s.db.DeleteReports(s.ctx, del1.ID, del2.ID, "missing")
+	s.Require().NoError(err)
+	s.Require().Equal(2, affected)
+
+	runs, err := s.db.GetRuns(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{keep.ID}, runIDs(runs))
+
+	affected, err = s.db.DeleteReports(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Zero(affected)
+}
+
+func (s *Suite) TestPurge() {
+	day := 24 * time.Hour
+	keep := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, day)
+	old1 := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 10*day)
+	old2 := s.newReport("node2", summary.Environment_STAGING, summary.State_FAILED, 20*day)
+	s.save(keep, old1, old2)
+
+	affected, err := s.db.Purge(s.ctx, s.now.Add(-5*day))
+	s.Require().NoError(err)
+	s.Require().Equal(2, affected)
+
+	runs, err := s.db.GetRuns(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Equal([]string{keep.ID}, runIDs(runs))
+}
+
+func (s *Suite) TestLocks() {
+	held, err := s.db.AcquireLock(s.ctx, "purge", "holder1", time.Minute)
+	s.Require().NoError(err)
+	s.Require().True(held)
+
+	// The holder can extend the lock, and another holder cannot take it until it expires.
+	held, err = s.db.AcquireLock(s.ctx, "purge", "holder1", time.Minute)
+	s.Require().NoError(err)
+	s.Require().True(held)
+
+	held, err = s.db.AcquireLock(s.ctx, "purge", "holder2", time.Minute)
+	s.Require().NoError(err)
+	s.Require().False(held)
+
+	// Releasing a lock held by another holder does nothing.
+	s.Require().NoError(s.db.ReleaseLock(s.ctx, "purge", "holder2"))
+	held, err = s.db.AcquireLock(s.ctx, "purge", "holder2", time.Minute)
+	s.Require().NoError(err)
+	s.Require().False(held)
+
+	// Once the holder shortens the lock, another holder takes it over when it expires.
+	held, err = s.db.AcquireLock(s.ctx, "purge", "holder1", time.Second)
+	s.Require().NoError(err)
+	s.Require().True(held)
+	s.Require().Eventually(func() bool {
+		held, err := s.db.AcquireLock(s.ctx, "purge", "holder2", time.Minute)
+		return err == nil && held
+	}, 5*time.Second, 100*time.Millisecond)
+
+	s.Require().NoError(s.db.ReleaseLock(s.ctx, "purge", "holder2"))
+
+	held, err = s.db.AcquireLock(s.ctx, "purge", "holder2", time.Minute)
+	s.Require().NoError(err)
+	s.Require().True(held)
+	s.Require().NoError(s.db.ReleaseLock(s.ctx, "purge", "holder2"))
+}
+
+func (s *Suite) TestJobRuns() {
+	runs, err := s.db.GetJobRuns(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Empty(runs)
+
+	purge := &entities.JobRun{
+		Name:      "purge",
+		Instance:  "holder1",
+		StartedAt: entities.Datetime(s.now.Add(-time.Hour).In(time.FixedZone("", 60*60))),
+		Duration:  entities.Duration(90 * time.Second),
+		Outcome:   "FAILURE",
+		Error:     "error purging",
+	}
+	s.Require().NoError(s.db.SaveJobRun(s.ctx, purge))
+	s.Require().NoError(s.db.SaveJobRun(s.ctx, &entities.JobRun{
+		Name:      "fsck",
+		Instance:  "holder2",
+		StartedAt: entities.Datetime(s.now),
+		Outcome:   "SUCCESS",
+	}))
+
+	// The next run of a job, on any instance, replaces the previous one.
+	purge.Instance = "holder2"
+	purge.Outcome = "SUCCESS"
+	purge.Error = ""
+	s.Require().NoError(s.db.SaveJobRun(s.ctx, purge))
+
+	runs, err = s.db.GetJobRuns(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(runs, 2)
+	s.Require().Equal("fsck", runs[0].Name)
+	s.Require().Equal("purge", runs[1].Name)
+	s.Require().Equal("holder2", runs[1].Instance)
+	s.Require().Equal("SUCCESS", runs[1].Outcome)
+	s.Require().Empty(runs[1].Error)
+	s.Require().Equal(90*time.Second, runs[1].Duration.Time())
+	s.Require().True(purge.StartedAt.Time().Equal(runs[1].StartedAt.Time()), "got %s", runs[1].StartedAt)
+}
+
+func (s *Suite) TestDecommissions() {
+	decommissions, err := s.db.GetDecommissions(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Empty(decommissions)
+
+	at := s.now.Add(-time.Hour)
+	s.Require().NoError(s.db.DecommissionNode(s.ctx, "node1", at))
+	s.Require().NoError(s.db.DecommissionNode(s.ctx, "node2", at))
+
+	// Decommissioning again moves the time.
+	s.Require().NoError(s.db.DecommissionNode(s.ctx, "node1", s.now))
+
+	decommissions, err = s.db.GetDecommissions(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(decommissions, 2)
+	s.Require().WithinDuration(s.now, decommissions["node1"], time.Second)
+	s.Require().WithinDuration(at, decommissions["node2"], time.Second)
+
+	s.Require().NoError(s.db.RecommissionNode(s.ctx, "node1"))
+	s.Require().NoError(s.db.RecommissionNode(s.ctx, "missing"))
+
+	decommissions, err = s.db.GetDecommissions(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(decommissions, 1)
+	s.Require().Contains(decommissions, "node2")
+}
+
+func (s *Suite) TestNodeMetadata() {
+	_, err := s.db.GetNodeMetadata(s.ctx, "node1")
+	s.Require().ErrorIs(err, dataaccess.ErrNotFound)
+
+	md := &entities.NodeMetadata{
+		Fqdn:      "node1",
+		Owner:     "team-a",
+		Role:      "web",
+		Notes:     "Serves the public site",
+		Labels:    entities.Labels{"dc": "lon", "tier": "frontend"},
+		UpdatedAt: entities.Datetime(s.now.Add(-time.Hour)),
+	}
+	s.Require().NoError(s.db.SaveNodeMetadata(s.ctx, md))
+	s.Require().NoError(s.db.SaveNodeMetadata(s.ctx, &entities.NodeMetadata{
+		Fqdn:      "node2",
+		UpdatedAt: entities.Datetime(s.now),
+	}))
+
+	got, err := s.db.GetNodeMetadata(s.ctx, "node1")
+	s.Require().NoError(err)
+	s.Require().Equal(md.Owner, got.Owner)
+	s.Require().Equal(md.Role, got.Role)
+	s.Require().Equal(md.Notes, got.Notes)
+	s.Require().Equal(md.Labels, got.Labels)
+	s.Require().WithinDuration(md.UpdatedAt.Time(), got.UpdatedAt.Time(), time.Second)
+
+	// Saving again replaces the metadata.
+	md.Owner = "team-b"
+	md.Labels = entities.Labels{"dc": "man"}
+	s.Require().NoError(s.db.SaveNodeMetadata(s.ctx, md))
+
+	all, err := s.db.GetAllNodeMetadata(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(all, 2)
+	s.Require().Equal("node1", all[0].Fqdn)
+	s.Require().Equal("team-b", all[0].Owner)
+	s.Require().Equal(entities.Labels{"dc": "man"}, all[0].Labels)
+	s.Require().Equal("node2", all[1].Fqdn)
+	s.Require().Empty(all[1].Labels)
+
+	s.Require().NoError(s.db.DeleteNodeMetadata(s.ctx, "node1"))
+	s.Require().ErrorIs(s.db.DeleteNodeMetadata(s.ctx, "node1"), dataaccess.ErrNotFound)
+
+	_, err = s.db.GetNodeMetadata(s.ctx, "node1")
+	s.Require().ErrorIs(err, dataaccess.ErrNotFound)
+}
+
+func (s *Suite) TestSilences() {
+	silences, err := s.db.GetSilences(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Empty(silences)
+
+	ack := &entities.Silence{
+		ID:        "ack",
+		Kind:      entities.SilenceKindAck,
+		Fqdn:      "node1",
+		CreatedBy: "alice",
+		Reason:    "Looking into it",
+		StartsAt:  entities.Datetime(s.now.Add(-time.Hour)),
+		EndsAt:    entities.Datetime(s.now.Add(time.Hour)),
+		CreatedAt: entities.Datetime(s.now.Add(-time.Hour)),
+	}
+	maintenance := &entities.Silence{
+		ID:        "maintenance",
+		Kind:      entities.SilenceKindSilence,
+		Owner:     "team-a",
+		Labels:    entities.Labels{"dc": "lon"},
+		CreatedBy: "bob",
+		Reason:    "Datacentre maintenance",
+		StartsAt:  entities.Datetime(s.now.Add(time.Hour)),
+		EndsAt:    entities.Datetime(s.now.Add(2 * time.Hour)),
+		CreatedAt: entities.Datetime(s.now),
+	}
+	s.Require().NoError(s.db.SaveSilence(s.ctx, maintenance))
+	s.Require().NoError(s.db.SaveSilence(s.ctx, ack))
+
+	silences, err = s.db.GetSilences(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(silences, 2)
+	s.Require().Equal("ack", silences[0].ID)
+	s.Require().Equal(entities.SilenceKindAck, silences[0].Kind)
+	s.Require().Equal("node1", silences[0].Fqdn)
+	s.Require().Equal("alice", silences[0].CreatedBy)
+	s.Require().Equal("Looking into it", silences[0].Reason)
+	s.Require().Empty(silences[0].Labels)
+	s.Require().WithinDuration(ack.EndsAt.Time(), silences[0].EndsAt.Time(), time.Second)
+	s.Require().Equal("maintenance", silences[1].ID)
+	s.Require().Equal("team-a", silences[1].Owner)
+	s.Require().Equal(entities.Labels{"dc": "lon"}, silences[1].Labels)
+	s.Require().WithinDuration(maintenance.StartsAt.Time(), silences[1].StartsAt.Time(), time.Second)
+
+	// Saving again replaces the silence.
+	ack.EndsAt = entities.Datetime(s.now.Add(3 * time.Hour))
+	s.Require().NoError(s.db.SaveSilence(s.ctx, ack))
+
+	silences, err = s.db.GetSilences(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(silences, 2)
+	s.Require().WithinDuration(ack.EndsAt.Time(), silences[0].EndsAt.Time(), time.Second)
+
+	s.Require().NoError(s.db.DeleteSilence(s.ctx, "ack"))
+	s.Require().ErrorIs(s.db.DeleteSilence(s.ctx, "ack"), dataaccess.ErrNotFound)
+
+	silences, err = s.db.GetSilences(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(silences, 1)
+	s.Require().Equal("maintenance", silences[0].ID)
+}
+
+func (s *Suite) TestIncidents() {
+	_, err := s.db.GetIncident(s.ctx, "missing")
+	s.Require().ErrorIs(err, dataaccess.ErrNotFound)
+
+	prod := &entities.Incident{
+		ID:            "prod",
+		Env:           summary.Environment_PRODUCTION,
+		ConfigVersion: "1708135209",
+		Resources:     entities.Strings{"Package[nginx]"},
+		Message:       "Could not find package nginx",
+		FirstSeen:     entities.Datetime(s.now.Add(-2 * time.Hour)),
+		LastSeen:      entities.Datetime(s.now.Add(-time.Hour)),
+		Nodes:         entities.Strings{"node1", "node2"},
+		Reports:       3,
+		Notified:      true,
+	}
+	staging := &entities.Incident{
+		ID:        "staging",
+		Env:       summary.Environment_STAGING,
+		Message:   "Catalog failed to compile",
+		FirstSeen: entities.Datetime(s.now.Add(-30 * time.Minute)),
+		LastSeen:  entities.Datetime(s.now.Add(-30 * time.Minute)),
+		Nodes:     entities.Strings{"node3"},
+		Reports:   1,
+	}
+	s.Require().NoError(s.db.SaveIncident(s.ctx, prod))
+	s.Require().NoError(s.db.SaveIncident(s.ctx, staging))
+
+	got, err := s.db.GetIncident(s.ctx, "prod")
+	s.Require().NoError(err)
+	s.Require().Equal(prod.Env, got.Env)
+	s.Require().Equal(prod.ConfigVersion, got.ConfigVersion)
+	s.Require().Equal(prod.Resources, got.Resources)
+	s.Require().Equal(prod.Message, got.Message)
+	s.Require().Equal(prod.Nodes, got.Nodes)
+	s.Require().Equal(3, got.Reports)
+	s.Require().True(got.Notified)
+	s.Require().WithinDuration(prod.FirstSeen.Time(), got.FirstSeen.Time(), time.Second)
+	s.Require().WithinDuration(prod.LastSeen.Time(), got.LastSeen.Time(), time.Second)
+
+	// The incidents are listed most recently seen first.
+	incidents, err := s.db.GetIncidents(s.ctx, s.now.Add(-24*time.Hour), s.now)
+	s.Require().NoError(err)
+	s.Require().Len(incidents, 2)
+	s.Require().Equal("staging", incidents[0].ID)
+	s.Require().Empty(incidents[0].Resources)
+	s.Require().Equal("prod", incidents[1].ID)
+
+	incidents, err = s.db.GetIncidents(s.ctx, s.now.Add(-24*time.Hour), s.now, summary.Environment_PRODUCTION)
+	s.Require().NoError(err)
+	s.Require().Len(incidents, 1)
+	s.Require().Equal("prod", incidents[0].ID)
+
+	incidents, err = s.db.GetIncidents(s.ctx, s.now.Add(-45*time.Minute), s.now)
+	s.Require().NoError(err)
+	s.Require().Len(incidents, 1)
+	s.Require().Equal("staging", incidents[0].ID)
+
+	// Saving again replaces the incident.
+	prod.LastSeen = entities.Datetime(s.now)
+	prod.Nodes = entities.Strings{"node1", "node2", "node4"}
+	prod.Reports = 4
+	s.Require().NoError(s.db.SaveIncident(s.ctx, prod))
+
+	got, err = s.db.GetIncident(s.ctx, "prod")
+	s.Require().NoError(err)
+	s.Require().Equal(prod.Nodes, got.Nodes)
+	s.Require().Equal(4, got.Reports)
+
+	// Only the first caller setting the incident as notified changes it.
+	claimed, err := s.db.SetIncidentNotified(s.ctx, "staging", true)
+	s.Require().NoError(err)
+	s.Require().True(claimed)
+	claimed, err = s.db.SetIncidentNotified(s.ctx, "staging", true)
+	s.Require().NoError(err)
+	s.Require().False(claimed)
+
+	_, err = s.db.SetIncidentNotified(s.ctx, "missing", true)
+	s.Require().ErrorIs(err, dataaccess.ErrNotFound)
+
+	// Saving the incident again keeps whether it has been notified.
+	staging.Reports = 2
+	s.Require().NoError(s.db.SaveIncident(s.ctx, staging))
+
+	got, err = s.db.GetIncident(s.ctx, "staging")
+	s.Require().NoError(err)
+	s.Require().Equal(2, got.Reports)
+	s.Require().True(got.Notified)
+
+	claimed, err = s.db.SetIncidentNotified(s.ctx, "staging", false)
+	s.Require().NoError(err)
+	s.Require().True(claimed)
+
+	got, err = s.db.GetIncident(s.ctx, "staging")
+	s.Require().NoError(err)
+	s.Require().False(got.Notified)
+
+	// The incidents last seen before the purge are deleted.
+	_, err = s.db.Purge(s.ctx, s.now.Add(-10*time.Minute))
+	s.Require().NoError(err)
+
+	_, err = s.db.GetIncident(s.ctx, "staging")
+	s.Require().ErrorIs(err, dataaccess.ErrNotFound)
+	_, err = s.db.GetIncident(s.ctx, "prod")
+	s.Require().NoError(err)
+}
+
+func (s *Suite) TestRuntimeAnomalies() {
+	newAnomaly := func(rep *entities.PuppetReport, runtime time.Duration) *entities.RuntimeAnomaly {
+		return &entities.RuntimeAnomaly{
+			ReportID:  rep.ID,
+			Fqdn:      rep.Fqdn,
+			Env:       rep.Env,
+			ExecTime:  rep.ExecTime,
+			Runtime:   entities.Duration(runtime),
+			Median:    entities.Duration(40 * time.Second),
+			Deviation: entities.Duration(1500 * time.Millisecond),
+			Score:     12.5,
+		}
+	}
+
+	rep1 := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour)
+	rep2 := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 2*time.Hour)
+	rep3 := s.newReport("node2", summary.Environment_STAGING, summary.State_CHANGED, 3*time.Hour)
+	s.save(rep1, rep2, rep3)
+
+	for _, a := range []*entities.RuntimeAnomaly{
+		newAnomaly(rep2, 10*time.Minute),
+		newAnomaly(rep1, 15*time.Minute),
+		newAnomaly(rep3, 5*time.Minute),
+	} {
+		s.Require().NoError(s.db.SaveRuntimeAnomaly(s.ctx, a))
+	}
+
+	// The anomalies are listed newest first.
+	anomalies, err := s.db.GetRuntimeAnomalies(s.ctx, "", time.Time{}, time.Time{})
+	s.Require().NoError(err)
+	s.Require().Len(anomalies, 3)
+	s.Require().Equal(rep1.ID, anomalies[0].ReportID)
+	s.Require().Equal(rep2.ID, anomalies[1].ReportID)
+	s.Require().Equal(rep3.ID, anomalies[2].ReportID)
+
+	got := anomalies[0]
+	s.Require().Equal("node1", got.Fqdn)
+	s.Require().Equal(summary.Environment_PRODUCTION, got.Env)
+	s.Require().Equal(entities.Duration(15*time.Minute), got.Runtime)
+	s.Require().Equal(entities.Duration(40*time.Second), got.Median)
+	s.Require().Equal(entities.Duration(1500*time.Millisecond), got.Deviation)
+	s.Require().Equal(12.5, got.Score)
+	s.Require().WithinDuration(rep1.ExecTime.Time(), got.ExecTime.Time(), time.Second)
+
+	anomalies, err = s.db.GetRuntimeAnomalies(s.ctx, "node1", time.Time{}, time.Time{})
+	s.Require().NoError(err)
+	s.Require().Len(anomalies, 2)
+
+	anomalies, err = s.db.GetRuntimeAnomalies(s.ctx, "", time.Time{}, time.Time{}, summary.Environment_STAGING)
+	s.Require().NoError(err)
+	s.Require().Len(anomalies, 1)
+	s.Require().Equal(rep3.ID, anomalies[0].ReportID)
+
+	anomalies, err = s.db.GetRuntimeAnomalies(s.ctx, "", s.now.Add(-150*time.Minute), s.now.Add(-time.Hour))
+	s.Require().NoError(err)
+	s.Require().Len(anomalies, 1)
+	s.Require().Equal(rep2.ID, anomalies[0].ReportID)
+
+	// Saving again replaces the anomaly.
+	s.Require().NoError(s.db.SaveRuntimeAnomaly(s.ctx, newAnomaly(rep1, 20*time.Minute)))
+	anomalies, err = s.db.GetRuntimeAnomalies(s.ctx, "node1", s.now.Add(-90*time.Minute), time.Time{})
+	s.Require().NoError(err)
+	s.Require().Len(anomalies, 1)
+	s.Require().Equal(entities.Duration(20*time.Minute), anomalies[0].Runtime)
+
+	// The anomalies are deleted with their reports, and by the purge.
+	_, err = s.db.DeleteReports(s.ctx, rep1.ID)
+	s.Require().NoError(err)
+	_, err = s.db.Purge(s.ctx, s.now.Add(-150*time.Minute))
+	s.Require().NoError(err)
+
+	anomalies, err = s.db.GetRuntimeAnomalies(s.ctx, "", time.Time{}, time.Time{})
+	s.Require().NoError(err)
+	s.Require().Len(anomalies, 1)
+	s.Require().Equal(rep2.ID, anomalies[0].ReportID)
+}
+
+func (s *Suite) TestGetLatestReports() {
+	older := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 3*time.Hour)
+	old := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 2*time.Hour)
+	latest := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour)
+	other := s.newReport("node2", summary.Environment_PRODUCTION, summary.State_CHANGED, 2*time.Hour)
+	s.save(older, old, latest, other)
+
+	// Only the reports of the node executed before the time are returned, newest first, up to the limit.
+	got, err := s.db.GetLatestReports(s.ctx, "node1", latest.ExecTime.Time(), 1)
+	s.Require().NoError(err)
+	s.Require().Len(got, 1)
+	s.Require().Equal(old.ID, got[0].ID)
+
+	got, err = s.db.GetLatestReports(s.ctx, "node1", s.now, 10)
+	s.Require().NoError(err)
+	s.Require().Len(got, 3)
+	s.Require().Equal(latest.ID, got[0].ID)
+	s.Require().Equal(older.ID, got[2].ID)
+}
+
+func (s *Suite) TestOffsetExecTime() {
+	// The node reports its local time, an hour ahead of UTC.
+	zone := time.FixedZone("", 60*60)
+
+	rep := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, 2*time.Hour)
+	rep.ExecTime = entities.Datetime(rep.ExecTime.Time().In(zone))
+	rep.Metrics = entities.Metrics{"events": {"failure": 1}}
+	s.save(rep)
+	s.Require().NoError(s.db.SaveRuntimeAnomaly(s.ctx, &entities.RuntimeAnomaly{
+		ReportID: rep.ID,
+		Fqdn:     rep.Fqdn,
+		Env:      rep.Env,
+		ExecTime: rep.ExecTime,
+		Runtime:  entities.Duration(time.Minute),
+		Score:    5,
+	}))
+
+	// The report is read back at the same instant it was executed.
+	got, err := s.db.GetReport(s.ctx, rep.ID)
+	s.Require().NoError(err)
+	s.Require().True(got.ExecTime.Time().Equal(rep.ExecTime.Time()), "got %s", got.ExecTime)
+
+	// The file of the report keeps the time it was uploaded with.
+	s.Require().Equal(rep.ReportFilePath(), got.YamlFile)
+	s.Require().Contains(got.YamlFile, "+01:00")
+
+	runs, err := s.db.GetRuns(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(runs, 1)
+	s.Require().True(runs[0].ExecTime.Time().Equal(rep.ExecTime.Time()), "got %s", runs[0].ExecTime)
+
+	// The ranges are compared by instant, whatever the offset of the times.
+	execTime := rep.ExecTime.Time().UTC()
+	values, err := s.db.GetRunMetrics(s.ctx, execTime, execTime.Add(time.Second), nil)
+	s.Require().NoError(err)
+	s.Require().Len(values, 1)
+
+	anomalies, err := s.db.GetRuntimeAnomalies(s.ctx, "", execTime, execTime.Add(time.Second))
+	s.Require().NoError(err)
+	s.Require().Len(anomalies, 1)
+
+	latest, err := s.db.GetLatestReports(s.ctx, rep.Fqdn, execTime, 10)
+	s.Require().NoError(err)
+	s.Require().Empty(latest)
+	latest, err = s.db.GetLatestReports(s.ctx, rep.Fqdn, execTime.Add(time.Second), 10)
+	s.Require().NoError(err)
+	s.Require().Len(latest, 1)
+
+	// Purging up to the instant keeps the report and its anomaly, and purging past it removes both together.
+	_, err = s.db.Purge(s.ctx, execTime)
+	s.Require().NoError(err)
+	runs, err = s.db.GetRuns(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(runs, 1)
+
+	_, err = s.db.Purge(s.ctx, execTime.Add(time.Second))
+	s.Require().NoError(err)
+	runs, err = s.db.GetRuns(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Empty(runs)
+	anomalies, err = s.db.GetRuntimeAnomalies(s.ctx, "", time.Time{}, time.Time{})
+	s.Require().NoError(err)
+	s.Require().Empty(anomalies)
+}
+
+func (s *Suite) TestRunsYamlFile() {
+	// The node reports its local time, an hour ahead of UTC, which the file is named after.
+	local := s.newReport("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour)
+	local.ExecTime = entities.Datetime(local.ExecTime.Time().In(time.FixedZone("", 60*60)))
+
+	// An imported report keeps the file it was imported from.
+	imported := s.newReport("node2", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour)
+	imported.YamlFile = "reports/PRODUCTION/node2/imported.yaml"
+	s.save(local, imported)
+
+	runs, err := s.db.GetRuns(s.ctx)
+	s.Require().NoError(err)
+	s.Require().Len(runs, 2)
+
+	files := make(map[string]string, len(runs))
+	for _, run := range runs {
+		files[run.ID] = run.YamlFile
+	}
+	s.Require().Equal(map[string]string{
+		local.ID:    "reports/PRODUCTION/node1/" + local.ExecTime.Time().Format(time.RFC3339) + ".yaml",
+		imported.ID: "reports/PRODUCTION/node2/imported.yaml",
+	}, files)
+	s.Require().Contains(files[local.ID], "+01:00")
+
+	runs, err = s.db.GetRunsByState(s.ctx, summary.State_CHANGED)
+	s.Require().NoError(err)
+	s.Require().Len(runs, 2)
+	s.Require().NotEmpty(runs[0].YamlFile)
+}
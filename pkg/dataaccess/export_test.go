@@ -0,0 +1,23 @@
+package dataaccess
+
+import (
+	"context"
+	"fmt"
+)
+
+// DropMongoDatabase drops the database of a MongoDB connection, so that tests can clean up after themselves.
+func DropMongoDatabase(ctx context.Context, db Database) error {
+	m := db.(*mongodbImpl)
+	return m.client.Database(m.database).Drop(ctx)
+}
+
+// TruncateMySQLTables deletes everything from the tables of a MySQL connection, so that tests start from empty.
+func TruncateMySQLTables(ctx context.Context, db Database) error {
+	m := db.(*mysqlImpl)
+	for _, table := range []string{"failed_resources", "run_versions", "run_timings", "run_metrics", "runtime_anomalies", "reports", "locks", "job_runs", "decommissions", "node_metadata", "silences", "incidents"} {
+		if _, err := m.client.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return fmt.Errorf("error deleting from %s: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -21,6 +22,25 @@ type fileHandler interface {
 
 	// Purge purges the data from storage out of the given range.
 	Purge(ctx context.Context, from time.Time) (int, error)
+
+	// ListFiles lists the paths of all the report files in storage.
+	ListFiles(ctx context.Context) ([]string, error)
+}
+
+// ReportFileTime returns the time a report was executed, parsed from the name of its file.
+func ReportFileTime(filePath string) (time.Time, bool) {
+	// Remove the path (report/environment/fqdn) from the file name.
+	fileName := filePath[strings.LastIndex(filePath, "/")+1:]
+
+	// Remove the file extension.
+	fileName = strings.TrimSuffix(fileName, ".yaml")
+
+	fileDate, err := time.Parse(time.RFC3339, fileName)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return fileDate, true
 }
 
 func ConnectStorage(ctx context.Context, storeType StoreType, bucketName string) error {
@@ -34,6 +54,26 @@ func ConnectStorage(ctx context.Context, storeType StoreType, bucketName string)
 		return nil
 	case StoreTypeGCS:
 		return connectGCS(ctx, bucketName)
+	case StoreTypeMemory:
+		Files = newMemoryStore()
+		return nil
+	case StoreTypeS3:
+		return errors.New("s3 fileHandler not implemented yet")
+	default:
+		return fmt.Errorf("invalid fileHandler type: %s", storeType)
+	}
+}
+
+// SetStorageCredentials connects to the given storage using the given credentials. If the storage is already
+// connected, the credentials of the existing connection are replaced so that in-flight requests are not affected.
+func SetStorageCredentials(ctx context.Context, storeType StoreType, bucketName string, credentials []byte) error {
+	switch storeType {
+	case StoreTypeLocal:
+		return errors.New("local fileHandler does not use credentials")
+	case StoreTypeMemory:
+		return errors.New("memory fileHandler does not use credentials")
+	case StoreTypeGCS:
+		return connectGCSWithCredentials(ctx, bucketName, credentials)
 	case StoreTypeS3:
 		return errors.New("s3 fileHandler not implemented yet")
 	default:
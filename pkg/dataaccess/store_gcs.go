@@ -8,9 +8,11 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/storage"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
 	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
@@ -20,18 +22,96 @@ const (
 	envGCSCredentials = "GCS_CREDENTIALS"
 )
 
+// gcsClient is a Google Cloud Storage client, counted by the requests using it so that it is only closed once it has
+// been replaced and the last request using it has finished.
+type gcsClient struct {
+	*storage.Client
+
+	// users is the number of requests using the client.
+	users int
+
+	// replaced is whether the client has been replaced by one with rotated credentials.
+	replaced bool
+}
+
 type gcsImpl struct {
+	// mtx guards the client, which is replaced when the credentials are rotated.
+	mtx *sync.Mutex
+
 	// gcs is the Google Cloud fileHandler client.
-	gcs *storage.Client
+	gcs *gcsClient
 
 	// bucket is the name of the bucket to use.
 	bucket string
+
+	// closeClient closes a client that is no longer used.
+	closeClient func(client *storage.Client) error
 }
 
-func newGCS(gcs *storage.Client, bucket string) fileHandler {
+func newGCS(gcs *storage.Client, bucket string) *gcsImpl {
 	return &gcsImpl{
-		gcs:    gcs,
+		mtx:    new(sync.Mutex),
+		gcs:    &gcsClient{Client: gcs},
 		bucket: bucket,
+		closeClient: func(client *storage.Client) error {
+			return client.Close()
+		},
+	}
+}
+
+// client returns the current Google Cloud fileHandler client, and a function to call once the request using the
+// client has finished.
+func (s *gcsImpl) client() (*storage.Client, func()) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	c := s.gcs
+	c.users++
+
+	return c.Client, func() {
+		s.mtx.Lock()
+		c.users--
+		unused := c.replaced && c.users == 0
+		s.mtx.Unlock()
+
+		if unused {
+			s.close(c)
+		}
+	}
+}
+
+// rotateCredentials replaces the client with one using the given credentials, once the new client has been
+// validated.
+func (s *gcsImpl) rotateCredentials(ctx context.Context, credentials []byte) error {
+	client, err := newGCSClient(ctx, s.bucket, credentials)
+	if err != nil {
+		return err
+	}
+
+	s.replaceClient(client)
+
+	slog.Info("GCS credentials rotated")
+	return nil
+}
+
+// replaceClient replaces the client. The old client is closed once the requests using it have finished.
+func (s *gcsImpl) replaceClient(client *storage.Client) {
+	s.mtx.Lock()
+	old := s.gcs
+	s.gcs = &gcsClient{Client: client}
+	old.replaced = true
+	unused := old.users == 0
+	s.mtx.Unlock()
+
+	if unused {
+		s.close(old)
+	}
+}
+
+// close closes a client that has been replaced and is no longer used.
+func (s *gcsImpl) close(c *gcsClient) {
+	if err := s.closeClient(c.Client); err != nil {
+		slog.Warn("Error closing old GCS client", slog.String(logging.KeyError, err.Error()))
 	}
 }
 
@@ -41,7 +121,9 @@ func (s *gcsImpl) SaveFile(ctx context.Context, filePath string, file []byte) er
 	defer t.ObserveDuration()
 
 	// Connect to the bucket.
-	bkt := s.gcs.Bucket(s.bucket)
+	client, done := s.client()
+	defer done()
+	bkt := client.Bucket(s.bucket)
 
 	// Create a new file in the bucket.
 	w := bkt.Object(filePath).NewWriter(ctx)
@@ -67,7 +149,9 @@ func (s *gcsImpl) DownloadFile(ctx context.Context, filePath string) ([]byte, er
 	defer t.ObserveDuration()
 
 	// Connect to the bucket.
-	bkt := s.gcs.Bucket(s.bucket)
+	client, done := s.client()
+	defer done()
+	bkt := client.Bucket(s.bucket)
 
 	// Open the file.
 	r, err := bkt.Object(filePath).NewReader(ctx)
@@ -96,7 +180,9 @@ func (s *gcsImpl) DeleteFile(ctx context.Context, filePath string) error {
 	defer t.ObserveDuration()
 
 	// Connect to the bucket.
-	bkt := s.gcs.Bucket(s.bucket)
+	client, done := s.client()
+	defer done()
+	bkt := client.Bucket(s.bucket)
 
 	// Delete the file.
 	err := bkt.Object(filePath).Delete(ctx)
@@ -113,7 +199,9 @@ func (s *gcsImpl) Purge(ctx context.Context, from time.Time) (int, error) {
 	defer t.ObserveDuration()
 
 	// Connect to the bucket.
-	bkt := s.gcs.Bucket(s.bucket)
+	client, done := s.client()
+	defer done()
+	bkt := client.Bucket(s.bucket)
 
 	// Get a list of all the files in the bucket.
 	it := bkt.Objects(ctx, nil)
@@ -129,24 +217,15 @@ func (s *gcsImpl) Purge(ctx context.Context, from time.Time) (int, error) {
 			break
 		}
 
-		// Get the file name.
-		fileName := attrs.Name
-
 		// Ignore non-parser files.
-		if !strings.HasSuffix(fileName, ".yaml") {
+		if !strings.HasSuffix(attrs.Name, ".yaml") {
 			continue
 		}
 
-		// Remove the path (report/environment/fqdn) from the file name.
-		fileName = fileName[strings.LastIndex(fileName, "/")+1:]
-
-		// Remove the file extension.
-		fileName = fileName[:len(fileName)-len(".yaml")]
-
 		// Parse the file date from the file name.
-		fileDate, err := time.Parse(time.RFC3339, fileName)
-		if err != nil {
-			slog.Warn(fmt.Sprintf("Error parsing file date from file name: %s", fileName))
+		fileDate, ok := ReportFileTime(attrs.Name)
+		if !ok {
+			slog.Warn(fmt.Sprintf("Error parsing file date from file name: %s", attrs.Name))
 			continue
 		}
 
@@ -167,29 +246,82 @@ func (s *gcsImpl) Purge(ctx context.Context, from time.Time) (int, error) {
 	return count, nil
 }
 
+func (s *gcsImpl) ListFiles(ctx context.Context) ([]string, error) {
+	// Start the prometheus timer.
+	t := prometheus.NewTimer(StorageLatency.With(prometheus.Labels{"query": "list_files"}))
+	defer t.ObserveDuration()
+
+	// Get a list of all the files in the bucket.
+	client, done := s.client()
+	defer done()
+	it := client.Bucket(s.bucket).Objects(ctx, nil)
+
+	files := make([]string, 0)
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error listing files: %w", err)
+		}
+
+		// Ignore non-parser files.
+		if !strings.HasSuffix(attrs.Name, ".yaml") {
+			continue
+		}
+
+		files = append(files, attrs.Name)
+	}
+
+	return files, nil
+}
+
 func connectGCS(ctx context.Context, gcsBucket string) error {
 	// Get the service account credentials from the environment variable.
 	gcsCredentials := os.Getenv(envGCSCredentials)
 	if gcsCredentials == "" {
 		return errors.New("no GCS credentials provided")
 	}
-	client, err := storage.NewClient(ctx, option.WithCredentialsJSON([]byte(gcsCredentials)))
-	if err != nil {
-		return fmt.Errorf("error connecting to Files: %w", err)
-	}
-	cs := client
+	return connectGCSWithCredentials(ctx, gcsBucket, []byte(gcsCredentials))
+}
 
-	// Get the bucket name from the environment variable and validate that it exists.
-	if gcsBucket == "" {
-		return errors.New("no GCS bucket provided")
+func connectGCSWithCredentials(ctx context.Context, gcsBucket string, credentials []byte) error {
+	// If we are already connected to the bucket, rotate the credentials rather than replacing the handler.
+	if g, ok := Files.(*gcsImpl); ok && g.bucket == gcsBucket {
+		return g.rotateCredentials(ctx, credentials)
 	}
 
-	_, err = cs.Bucket(gcsBucket).Attrs(ctx)
+	cs, err := newGCSClient(ctx, gcsBucket, credentials)
 	if err != nil {
-		return fmt.Errorf("error validating GCS bucket: %w", err)
+		return err
 	}
 
 	Files = newGCS(cs, gcsBucket)
 	slog.Debug("Connected to GCS")
 	return nil
 }
+
+// newGCSClient creates a new GCS client with the given credentials and validates that the bucket exists.
+func newGCSClient(ctx context.Context, gcsBucket string, credentials []byte) (*storage.Client, error) {
+	if len(credentials) == 0 {
+		return nil, errors.New("no GCS credentials provided")
+	}
+	if gcsBucket == "" {
+		return nil, errors.New("no GCS bucket provided")
+	}
+	client, err := storage.NewClient(ctx, option.WithCredentialsJSON(credentials))
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to Files: %w", err)
+	}
+
+	// Validate that the bucket exists.
+	_, err = client.Bucket(gcsBucket).Attrs(ctx)
+	if err != nil {
+		if err := client.Close(); err != nil {
+			slog.Error("Error closing GCS client", slog.String(logging.KeyError, err.Error()))
+		}
+		return nil, fmt.Errorf("error validating GCS bucket: %w", err)
+	}
+
+	return client, nil
+}
@@ -0,0 +1,102 @@
+package dataaccess
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+// newTestGCSClient returns a client for the GCS JSON API served by the server.
+func newTestGCSClient(t *testing.T, srv *httptest.Server) *storage.Client {
+	client, err := storage.NewClient(context.Background(),
+		option.WithEndpoint(srv.URL+"/storage/v1/"),
+		option.WithoutAuthentication(),
+	)
+	require.NoError(t, err)
+	return client
+}
+
+func TestGCS_RotateCredentialsDuringRequest(t *testing.T) {
+	// The server holds the delete until it is released, so the request is in flight while the client is replaced.
+	received := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			close(received)
+			<-release
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	old := newTestGCSClient(t, srv)
+	g := newGCS(old, "bucket")
+
+	var mtx sync.Mutex
+	closed := make([]*storage.Client, 0)
+	g.closeClient = func(client *storage.Client) error {
+		mtx.Lock()
+		defer mtx.Unlock()
+		closed = append(closed, client)
+		return nil
+	}
+	closedClients := func() []*storage.Client {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return append([]*storage.Client(nil), closed...)
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- g.DeleteFile(context.Background(), "reports/PRODUCTION/node1/2024-01-01T00:00:00Z.yaml")
+	}()
+	<-received
+
+	// The old client is still in use, so it is not closed when it is replaced.
+	rotated := newTestGCSClient(t, srv)
+	g.replaceClient(rotated)
+	require.Empty(t, closedClients())
+
+	// New requests use the rotated client, and do not keep the old client open.
+	client, done := g.client()
+	require.Same(t, rotated, client)
+	done()
+	require.Empty(t, closedClients())
+
+	// The old client is closed once the request using it has finished.
+	close(release)
+	select {
+	case err := <-errs:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the delete")
+	}
+	require.Equal(t, []*storage.Client{old}, closedClients())
+}
+
+func TestGCS_RotateCredentialsIdle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	old := newTestGCSClient(t, srv)
+	g := newGCS(old, "bucket")
+
+	closed := make([]*storage.Client, 0)
+	g.closeClient = func(client *storage.Client) error {
+		closed = append(closed, client)
+		return nil
+	}
+
+	// Without a request in flight, the old client is closed straight away.
+	g.replaceClient(newTestGCSClient(t, srv))
+	require.Equal(t, []*storage.Client{old}, closed)
+}
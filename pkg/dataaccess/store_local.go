@@ -2,10 +2,14 @@ package dataaccess
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -118,91 +122,92 @@ func (l *localImpl) DeleteFile(_ context.Context, filePath string) error {
 	return nil
 }
 
-func (l *localImpl) Purge(_ context.Context, from time.Time) (int, error) {
+func (l *localImpl) ListFiles(_ context.Context) ([]string, error) {
+	// Start the prometheus timer.
+	t := prometheus.NewTimer(StorageLatency.With(prometheus.Labels{"query": "list_files"}))
+	defer t.ObserveDuration()
+
+	files := make([]string, 0)
+	err := filepath.WalkDir(l.reportsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Ignore directories and non-report files.
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".yaml") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.reportsDir, path)
+		if err != nil {
+			return fmt.Errorf("error getting relative path: %w", err)
+		}
+
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		// Nothing has been saved yet.
+		return files, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading directory: %w", err)
+	}
+
+	return files, nil
+}
+
+func (l *localImpl) Purge(ctx context.Context, from time.Time) (int, error) {
 	// Start the prometheus timer.
 	t := prometheus.NewTimer(StorageLatency.With(prometheus.Labels{"query": "purge"}))
 	defer t.ObserveDuration()
 
-	// Read the files in the directory.
-	files, err := os.ReadDir(l.reportsDir)
+	files, err := l.ListFiles(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("error reading directory: %w", err)
+		return 0, fmt.Errorf("error listing files: %w", err)
 	}
 
 	// Purge the files.
 	count := 0
+	dirs := make(map[string]struct{})
 	for _, file := range files {
-		// Here we are at the environment level
-		// We need to go into the environment and then into the node
+		// Get the timestamp of the report.
+		timestamp, ok := ReportFileTime(file)
+		if !ok {
+			slog.Warn(fmt.Sprintf("Error parsing file date from file name: %s", file))
+			continue
+		}
 
-		// Get the environment directory.
-		envDir := filepath.Join(l.reportsDir, file.Name())
-		envFiles, err := os.ReadDir(envDir)
-		if err != nil {
-			return 0, fmt.Errorf("error reading directory: %w", err)
+		// Check if the file is older than the purge date.
+		if timestamp.After(from) {
+			continue
 		}
 
-		// Purge the files.
-		for _, envFile := range envFiles {
-			// Now we are at the Node level
-			// We need to go into the node and then into the report
-
-			// Get the node directory.
-			nodeDir := filepath.Join(envDir, envFile.Name())
-			nodeFiles, err := os.ReadDir(nodeDir)
-			if err != nil {
-				return 0, fmt.Errorf("error reading directory: %w", err)
-			}
-
-			// Purge the files.
-			for _, nodeFile := range nodeFiles {
-				// Now we are at the report level
-				// We can now purge the report
-
-				// Get the report file.
-				reportFile := filepath.Join(nodeDir, nodeFile.Name())
-
-				// Remove the path (report/environment/fqdn) from the file name.
-				reportFile = reportFile[strings.LastIndex(reportFile, "/")+1:]
-
-				// Remove the file extension.
-				reportFile = reportFile[:len(reportFile)-len(".yaml")]
-
-				// Get the timestamp of the report.
-				timestamp, err := time.Parse(time.RFC3339, reportFile)
-				if err != nil {
-					return 0, fmt.Errorf("error parsing file name: %w", err)
-				}
-
-				// Check if the file is older than the purge date.
-				if timestamp.After(from) {
-					continue
-				}
-
-				// Delete the file.
-				err = os.Remove(filepath.Join(nodeDir, nodeFile.Name()))
-				if err != nil {
-					return 0, fmt.Errorf("error deleting file: %w", err)
-				}
-
-				count++
-			}
-
-			err = checkEmptyDir(nodeDir)
-			if err != nil {
-				return 0, fmt.Errorf("error checking empty directory: %w", err)
-			}
+		// Delete the file.
+		reportFile := filepath.Join(l.reportsDir, filepath.FromSlash(file))
+		if err := os.Remove(reportFile); err != nil {
+			return 0, fmt.Errorf("error deleting file: %w", err)
 		}
 
-		err = checkEmptyDir(envDir)
-		if err != nil {
-			return 0, fmt.Errorf("error checking empty directory: %w", err)
+		// Record the directories of the report, so they can be removed if they are now empty.
+		for dir := filepath.Dir(reportFile); dir != l.reportsDir && strings.HasPrefix(dir, l.reportsDir); dir = filepath.Dir(dir) {
+			dirs[dir] = struct{}{}
 		}
+
+		count++
 	}
 
-	err = checkEmptyDir(l.reportsDir)
-	if err != nil {
-		return 0, fmt.Errorf("error checking empty directory: %w", err)
+	// Remove the empty directories, deepest first.
+	sortedDirs := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sortedDirs = append(sortedDirs, dir)
+	}
+	sort.Slice(sortedDirs, func(i, j int) bool {
+		return len(sortedDirs[i]) > len(sortedDirs[j])
+	})
+	for _, dir := range sortedDirs {
+		if err := checkEmptyDir(dir); err != nil {
+			return 0, fmt.Errorf("error checking empty directory: %w", err)
+		}
 	}
 
 	return count, nil
@@ -0,0 +1,56 @@
+package dataaccess
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocal_ListFilesAndPurge(t *testing.T) {
+	l := &localImpl{
+		reportsDir: t.TempDir(),
+	}
+
+	ctx := context.Background()
+	files := []string{
+		"reports/PRODUCTION/node1/2024-01-01T00:00:00Z.yaml",
+		"reports/PRODUCTION/node1/2024-03-01T00:00:00Z.yaml",
+		"reports/STAGING/node2/2024-01-02T00:00:00Z.yaml",
+	}
+	for _, file := range files {
+		require.NoError(t, l.SaveFile(ctx, file, []byte("report")))
+	}
+
+	got, err := l.ListFiles(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, files, got)
+
+	from, err := time.Parse(time.RFC3339, "2024-02-01T00:00:00Z")
+	require.NoError(t, err)
+
+	count, err := l.Purge(ctx, from)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	got, err = l.ListFiles(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"reports/PRODUCTION/node1/2024-03-01T00:00:00Z.yaml"}, got)
+
+	// The empty node and environment directories are removed.
+	_, err = os.Stat(filepath.Join(l.reportsDir, "reports", "STAGING"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLocal_ListFilesNoDirectory(t *testing.T) {
+	l := &localImpl{
+		reportsDir: filepath.Join(t.TempDir(), "missing"),
+	}
+
+	got, err := l.ListFiles(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
@@ -0,0 +1,120 @@
+package dataaccess
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// memoryStoreImpl is a fileHandler that holds the files in memory. Nothing is persisted, so the files are lost when the
+// process exits.
+type memoryStoreImpl struct {
+	// mtx guards the files.
+	mtx sync.RWMutex
+
+	// files are the contents of the files, keyed by the file path.
+	files map[string][]byte
+}
+
+func newMemoryStore() *memoryStoreImpl {
+	return &memoryStoreImpl{
+		files: make(map[string][]byte),
+	}
+}
+
+func (m *memoryStoreImpl) SaveFile(_ context.Context, filePath string, file []byte) error {
+	// Start the prometheus timer.
+	t := prometheus.NewTimer(StorageLatency.With(prometheus.Labels{"query": "save_file"}))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	// Copy the file, so that the caller can reuse the slice.
+	m.files[filePath] = append([]byte(nil), file...)
+
+	return nil
+}
+
+func (m *memoryStoreImpl) DownloadFile(_ context.Context, filePath string) ([]byte, error) {
+	// Start the prometheus timer.
+	t := prometheus.NewTimer(StorageLatency.With(prometheus.Labels{"query": "download_file"}))
+	defer t.ObserveDuration()
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	file, ok := m.files[filePath]
+	if !ok {
+		return nil, fmt.Errorf("error opening file %s: %w", filePath, ErrNotFound)
+	}
+
+	return append([]byte(nil), file...), nil
+}
+
+func (m *memoryStoreImpl) DeleteFile(_ context.Context, filePath string) error {
+	// Start the prometheus timer.
+	t := prometheus.NewTimer(StorageLatency.With(prometheus.Labels{"query": "delete_file"}))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.files[filePath]; !ok {
+		return fmt.Errorf("error deleting file %s: %w", filePath, ErrNotFound)
+	}
+	delete(m.files, filePath)
+
+	return nil
+}
+
+func (m *memoryStoreImpl) ListFiles(_ context.Context) ([]string, error) {
+	// Start the prometheus timer.
+	t := prometheus.NewTimer(StorageLatency.With(prometheus.Labels{"query": "list_files"}))
+	defer t.ObserveDuration()
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	files := make([]string, 0, len(m.files))
+	for file := range m.files {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+func (m *memoryStoreImpl) Purge(_ context.Context, from time.Time) (int, error) {
+	// Start the prometheus timer.
+	t := prometheus.NewTimer(StorageLatency.With(prometheus.Labels{"query": "purge"}))
+	defer t.ObserveDuration()
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	count := 0
+	for file := range m.files {
+		// Get the timestamp of the report.
+		timestamp, ok := ReportFileTime(file)
+		if !ok {
+			slog.Warn(fmt.Sprintf("Error parsing file date from file name: %s", file))
+			continue
+		}
+
+		// Check if the file is older than the purge date.
+		if timestamp.After(from) {
+			continue
+		}
+
+		delete(m.files, file)
+		count++
+	}
+
+	return count, nil
+}
@@ -0,0 +1,64 @@
+package dataaccess
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_SaveDownloadDelete(t *testing.T) {
+	m := newMemoryStore()
+	ctx := context.Background()
+
+	file := "reports/PRODUCTION/node1/2024-01-01T00:00:00Z.yaml"
+	require.NoError(t, m.SaveFile(ctx, file, []byte("report")))
+
+	got, err := m.DownloadFile(ctx, file)
+	require.NoError(t, err)
+	require.Equal(t, []byte("report"), got)
+
+	// Saving again replaces the file.
+	require.NoError(t, m.SaveFile(ctx, file, []byte("replaced")))
+	got, err = m.DownloadFile(ctx, file)
+	require.NoError(t, err)
+	require.Equal(t, []byte("replaced"), got)
+
+	require.NoError(t, m.DeleteFile(ctx, file))
+
+	_, err = m.DownloadFile(ctx, file)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	err = m.DeleteFile(ctx, file)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemoryStore_ListFilesAndPurge(t *testing.T) {
+	m := newMemoryStore()
+	ctx := context.Background()
+
+	files := []string{
+		"reports/PRODUCTION/node1/2024-01-01T00:00:00Z.yaml",
+		"reports/PRODUCTION/node1/2024-03-01T00:00:00Z.yaml",
+		"reports/STAGING/node2/2024-01-02T00:00:00Z.yaml",
+	}
+	for _, file := range files {
+		require.NoError(t, m.SaveFile(ctx, file, []byte("report")))
+	}
+
+	got, err := m.ListFiles(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, files, got)
+
+	from, err := time.Parse(time.RFC3339, "2024-02-01T00:00:00Z")
+	require.NoError(t, err)
+
+	count, err := m.Purge(ctx, from)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	got, err = m.ListFiles(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"reports/PRODUCTION/node1/2024-03-01T00:00:00Z.yaml"}, got)
+}
@@ -30,3 +30,8 @@ func (m *MockStorage) Purge(ctx context.Context, from time.Time) (int, error) {
 	args := m.Called(ctx, from)
 	return args.Int(0), args.Error(1)
 }
+
+func (m *MockStorage) ListFiles(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
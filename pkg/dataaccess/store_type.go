@@ -11,4 +11,7 @@ const (
 
 	// StoreTypeGCS is a Google Cloud fileHandler store
 	StoreTypeGCS StoreType = "GCS"
+
+	// StoreTypeMemory is an in-memory store, which is lost when the process exits
+	StoreTypeMemory StoreType = "MEMORY"
 )
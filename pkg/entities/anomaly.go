@@ -0,0 +1,33 @@
+package entities
+
+import (
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+)
+
+// RuntimeAnomaly is a run of a node that took much longer than the baseline of the runs of the node before it.
+type RuntimeAnomaly struct {
+	// ReportID is the ID of the report of the run.
+	ReportID string `json:"report_id" bson:"id"`
+
+	// Fqdn of the node.
+	Fqdn string `json:"fqdn" bson:"fqdn"`
+
+	// Env of the node.
+	Env summary.Environment `json:"env" bson:"env"`
+
+	// ExecTime is the time the puppet-run was completed.
+	ExecTime Datetime `json:"exec_time" bson:"exec_time"`
+
+	// Runtime is the runtime of the run.
+	Runtime Duration `json:"runtime" bson:"runtime"`
+
+	// Median is the median runtime of the baseline runs.
+	Median Duration `json:"median" bson:"median"`
+
+	// Deviation is the median absolute deviation of the runtimes of the baseline runs from the median.
+	Deviation Duration `json:"deviation" bson:"deviation"`
+
+	// Score is how many deviations the runtime is above the median, scaled so that it is comparable to a standard
+	// score.
+	Score float64 `json:"score" bson:"score"`
+}
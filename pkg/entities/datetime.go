@@ -34,18 +34,43 @@ func (d *Datetime) UnmarshalJSON(text []byte) error {
 	return nil
 }
 
+// MarshalBSON implements the bson.Marshaler interface. The time is stored in UTC, so the stored times sort in order.
 func (d *Datetime) MarshalBSON() ([]byte, error) {
 	if d == nil {
 		return bson.Marshal(nil)
 	}
-	return bson.Marshal(d.String())
+	return bson.Marshal(d.utc())
 }
 
+// MarshalBSONValue implements the bson.ValueMarshaler interface. The time is stored as an RFC3339 string in UTC, so
+// the stored times can be compared as strings.
 func (d *Datetime) MarshalBSONValue() (bsontype.Type, []byte, error) {
 	if d == nil {
 		return bson.TypeNull, nil, nil
 	}
-	return bson.MarshalValue(d.String())
+	return bson.MarshalValue(d.utc())
+}
+
+// UnmarshalBSONValue implements the bson.ValueUnmarshaler interface. Both RFC3339 strings and BSON dates are accepted.
+func (d *Datetime) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bson.TypeNull:
+		return nil
+	case bson.TypeDateTime:
+		*d = Datetime(raw.Time().UTC())
+		return nil
+	case bson.TypeString:
+		str := raw.StringValue()
+		parsed, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return fmt.Errorf("%s is not in the RFC3339 format", str)
+		}
+		*d = Datetime(parsed)
+		return nil
+	default:
+		return fmt.Errorf("unsupported bson type %s", t)
+	}
 }
 
 func (d *Datetime) UnmarshalBSON(bytes []byte) error {
@@ -57,7 +82,7 @@ func (d *Datetime) UnmarshalBSON(bytes []byte) error {
 	str = strings.ReplaceAll(str, `\`, ``)
 
 	// Transform \u0015\u0000\u0000\u00002017-07-29T23:17:01Z\u0000 to 2017-07-29T23:17:01Z
-	str = regexp.MustCompile(`[^a-zA-Z0-9-:]`).ReplaceAllString(str, ``)
+	str = regexp.MustCompile(`[^a-zA-Z0-9-:+]`).ReplaceAllString(str, ``)
 
 	// Parse the time.
 	t, err := time.Parse(time.RFC3339, str)
@@ -101,3 +126,8 @@ func (d Datetime) String() string {
 func (d Datetime) Time() time.Time {
 	return time.Time(d)
 }
+
+// utc returns the time in the RFC3339 format in UTC.
+func (d Datetime) utc() string {
+	return time.Time(d).UTC().Format(time.RFC3339)
+}
@@ -6,6 +6,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
 )
 
 func TestDatetime_MarshalJSON(t *testing.T) {
@@ -127,3 +130,55 @@ func TestDatetime_String(t *testing.T) {
 		})
 	}
 }
+
+func TestDatetime_BSON(t *testing.T) {
+	local := time.Date(2024, 2, 17, 3, 0, 9, 0, time.FixedZone("", 60*60))
+
+	tests := []struct {
+		name string
+		t    bsontype.Type
+		data []byte
+		want time.Time
+	}{
+		{
+			name: "Offset",
+			t:    bson.TypeString,
+			data: bsoncore.AppendString(nil, "2024-02-17T03:00:09+01:00"),
+			want: local,
+		},
+		{
+			name: "UTC",
+			t:    bson.TypeString,
+			data: bsoncore.AppendString(nil, "2024-02-17T02:00:09Z"),
+			want: local,
+		},
+		{
+			name: "Date",
+			t:    bson.TypeDateTime,
+			data: bsoncore.AppendDateTime(nil, local.UnixMilli()),
+			want: local,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d Datetime
+			require.NoError(t, d.UnmarshalBSONValue(tt.t, tt.data))
+			require.True(t, tt.want.Equal(d.Time()), "Datetime.UnmarshalBSONValue() = %v, want %v", d.Time(), tt.want)
+		})
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		doc := struct {
+			ExecTime Datetime `bson:"exec_time"`
+		}{ExecTime: Datetime(local)}
+
+		data, err := bson.Marshal(&doc)
+		require.NoError(t, err)
+		require.Equal(t, "2024-02-17T02:00:09Z", bson.Raw(data).Lookup("exec_time").StringValue())
+
+		doc.ExecTime = Datetime{}
+		require.NoError(t, bson.Unmarshal(data, &doc))
+		require.True(t, local.Equal(doc.ExecTime.Time()))
+	})
+}
@@ -0,0 +1,103 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+)
+
+// Incident groups the failed runs of nodes that are likely to share a cause: runs in the same environment, on the same
+// configuration version, failing on the same resources within a window of time of each other.
+type Incident struct {
+	// ID is the ID of the incident, which is the ID of the report that opened it.
+	ID string `json:"id" bson:"id"`
+
+	// Env is the environment of the failed runs.
+	Env summary.Environment `json:"env" bson:"env"`
+
+	// ConfigVersion is the configuration version the failed runs applied.
+	ConfigVersion string `json:"configuration_version" bson:"configuration_version"`
+
+	// Resources are the resources that failed in the report that opened the incident, as Type[title]. A run joins the
+	// incident by failing on any of them.
+	Resources Strings `json:"resources" bson:"resources"`
+
+	// Message is a log message of the report that opened the incident, representative of the failure.
+	Message string `json:"message" bson:"message"`
+
+	// FirstSeen is the time of the earliest failed run.
+	FirstSeen Datetime `json:"first_seen" bson:"first_seen"`
+
+	// LastSeen is the time of the latest failed run.
+	LastSeen Datetime `json:"last_seen" bson:"last_seen"`
+
+	// Nodes are the FQDNs of the nodes that failed, sorted.
+	Nodes Strings `json:"nodes" bson:"nodes"`
+
+	// Reports is the number of failed runs.
+	Reports int `json:"reports" bson:"reports"`
+
+	// Notified is whether the incident has been notified.
+	Notified bool `json:"notified" bson:"notified"`
+}
+
+// AddRun adds the failed run of the node at the given time to the incident.
+func (i *Incident) AddRun(fqdn string, at Datetime) {
+	if i.Reports == 0 || at.Time().Before(i.FirstSeen.Time()) {
+		i.FirstSeen = at
+	}
+	if i.Reports == 0 || at.Time().After(i.LastSeen.Time()) {
+		i.LastSeen = at
+	}
+	i.Reports++
+
+	n := sort.SearchStrings(i.Nodes, fqdn)
+	if n < len(i.Nodes) && i.Nodes[n] == fqdn {
+		return
+	}
+	i.Nodes = append(i.Nodes, "")
+	copy(i.Nodes[n+1:], i.Nodes[n:])
+	i.Nodes[n] = fqdn
+}
+
+// Strings are a list of strings. They are stored as JSON in the SQL databases.
+type Strings []string
+
+// Value implements the driver.Valuer interface.
+func (s Strings) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling strings: %w", err)
+	}
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *Strings) Scan(src any) error {
+	var b []byte
+	switch t := src.(type) {
+	case nil:
+		*s = Strings{}
+		return nil
+	case string:
+		b = []byte(t)
+	case []uint8:
+		b = t
+	default:
+		return fmt.Errorf("unsupported type %T", src)
+	}
+
+	strs := make(Strings, 0)
+	if err := json.Unmarshal(b, &strs); err != nil {
+		return fmt.Errorf("error unmarshalling strings: %w", err)
+	}
+	*s = strs
+	return nil
+}
@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncident_AddRun(t *testing.T) {
+	at := time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+
+	inc := new(Incident)
+	inc.AddRun("node2", Datetime(at))
+	inc.AddRun("node1", Datetime(at.Add(-time.Minute)))
+	inc.AddRun("node3", Datetime(at.Add(time.Minute)))
+	inc.AddRun("node1", Datetime(at))
+
+	require.Equal(t, Strings{"node1", "node2", "node3"}, inc.Nodes)
+	require.Equal(t, 4, inc.Reports)
+	require.Equal(t, at.Add(-time.Minute), inc.FirstSeen.Time())
+	require.Equal(t, at.Add(time.Minute), inc.LastSeen.Time())
+}
+
+func TestStrings_ValueScan(t *testing.T) {
+	v, err := Strings{"a", "b"}.Value()
+	require.NoError(t, err)
+	require.Equal(t, `["a","b"]`, v)
+
+	v, err = Strings(nil).Value()
+	require.NoError(t, err)
+	require.Equal(t, "[]", v)
+
+	var s Strings
+	require.NoError(t, s.Scan([]byte(`["a","b"]`)))
+	require.Equal(t, Strings{"a", "b"}, s)
+
+	require.NoError(t, s.Scan(nil))
+	require.Equal(t, Strings{}, s)
+
+	require.Error(t, s.Scan(1))
+}
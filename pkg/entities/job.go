@@ -0,0 +1,22 @@
+package entities
+
+// JobRun is the outcome of the last run of a scheduled job, whichever instance ran it.
+type JobRun struct {
+	// Name is the name of the job.
+	Name string `json:"name" bson:"name"`
+
+	// Instance is the instance that ran the job.
+	Instance string `json:"instance" bson:"instance"`
+
+	// StartedAt is the time the run started.
+	StartedAt Datetime `json:"started_at" bson:"started_at"`
+
+	// Duration is how long the run took.
+	Duration Duration `json:"duration" bson:"duration"`
+
+	// Outcome is the outcome of the run, such as SUCCESS or FAILURE.
+	Outcome string `json:"outcome" bson:"outcome"`
+
+	// Error is the error returned by the run, if it failed.
+	Error string `json:"error" bson:"error"`
+}
@@ -0,0 +1,86 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// NodeMetadata is the metadata attached to a node, that is not carried by its reports.
+type NodeMetadata struct {
+	// Fqdn is the FQDN of the node.
+	Fqdn string `json:"fqdn" bson:"fqdn"`
+
+	// Owner is the team that owns the node.
+	Owner string `json:"owner" bson:"owner"`
+
+	// Role is the role of the node.
+	Role string `json:"role" bson:"role"`
+
+	// Notes are free-form notes about the node.
+	Notes string `json:"notes" bson:"notes"`
+
+	// Labels are the key/value labels of the node.
+	Labels Labels `json:"labels" bson:"labels"`
+
+	// UpdatedAt is the time the metadata was last updated.
+	UpdatedAt Datetime `json:"updated_at" bson:"updated_at"`
+}
+
+// Matches returns whether the metadata has the given owner, if it is not empty, and all the given labels. Nil
+// metadata only matches when there is nothing to match.
+func (m *NodeMetadata) Matches(owner string, labels map[string]string) bool {
+	if m == nil {
+		return owner == "" && len(labels) == 0
+	}
+
+	if owner != "" && m.Owner != owner {
+		return false
+	}
+
+	for k, v := range labels {
+		if got, ok := m.Labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Labels are the key/value labels of a node. They are stored as JSON in the SQL databases.
+type Labels map[string]string
+
+// Value implements the driver.Valuer interface.
+func (l Labels) Value() (driver.Value, error) {
+	if l == nil {
+		return "{}", nil
+	}
+
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling labels: %w", err)
+	}
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (l *Labels) Scan(src any) error {
+	var b []byte
+	switch t := src.(type) {
+	case nil:
+		*l = Labels{}
+		return nil
+	case string:
+		b = []byte(t)
+	case []uint8:
+		b = t
+	default:
+		return fmt.Errorf("unsupported type %T", src)
+	}
+
+	labels := make(Labels)
+	if err := json.Unmarshal(b, &labels); err != nil {
+		return fmt.Errorf("error unmarshalling labels: %w", err)
+	}
+	*l = labels
+	return nil
+}
@@ -0,0 +1,94 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeMetadata_Matches(t *testing.T) {
+	md := &NodeMetadata{
+		Fqdn:  "node1",
+		Owner: "team-a",
+		Labels: Labels{
+			"role": "web",
+			"dc":   "lon",
+		},
+	}
+
+	tests := []struct {
+		name   string
+		md     *NodeMetadata
+		owner  string
+		labels map[string]string
+		want   bool
+	}{
+		{
+			name: "NoFilter",
+			md:   md,
+			want: true,
+		},
+		{
+			name:  "Owner",
+			md:    md,
+			owner: "team-a",
+			want:  true,
+		},
+		{
+			name:  "OtherOwner",
+			md:    md,
+			owner: "team-b",
+			want:  false,
+		},
+		{
+			name:   "Labels",
+			md:     md,
+			labels: map[string]string{"role": "web", "dc": "lon"},
+			want:   true,
+		},
+		{
+			name:   "OtherLabelValue",
+			md:     md,
+			labels: map[string]string{"role": "db"},
+			want:   false,
+		},
+		{
+			name:   "MissingLabel",
+			md:     md,
+			labels: map[string]string{"rack": "1"},
+			want:   false,
+		},
+		{
+			name: "NilNoFilter",
+			want: true,
+		},
+		{
+			name:  "NilOwner",
+			owner: "team-a",
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.md.Matches(tt.owner, tt.labels))
+		})
+	}
+}
+
+func TestLabels_ValueScan(t *testing.T) {
+	labels := Labels{"role": "web"}
+
+	v, err := labels.Value()
+	require.NoError(t, err)
+	require.Equal(t, `{"role":"web"}`, v)
+
+	var got Labels
+	require.NoError(t, got.Scan([]uint8(`{"role":"web"}`)))
+	require.Equal(t, labels, got)
+
+	require.NoError(t, got.Scan(nil))
+	require.Equal(t, Labels{}, got)
+
+	require.Error(t, got.Scan(1))
+}
@@ -0,0 +1,65 @@
+package entities
+
+import (
+	"strings"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+)
+
+// Metrics are the metrics reported by a puppet-run, keyed by the group (e.g. resources, events, changes, time) and
+// then by the name of the metric in the group (e.g. failed, corrective_change).
+type Metrics map[string]map[string]float64
+
+// MetricKey returns the key of the metric with the name in the group, as group.name (e.g. events.failure).
+func MetricKey(group, name string) string {
+	return group + "." + name
+}
+
+// SplitMetricKey splits the key of a metric into its group and name. Returns false if the key is not of the form
+// group.name.
+func SplitMetricKey(key string) (group, name string, ok bool) {
+	group, name, ok = strings.Cut(key, ".")
+	if !ok || group == "" || name == "" {
+		return "", "", false
+	}
+	return group, name, true
+}
+
+// Set sets the value of the metric with the name in the group.
+func (m Metrics) Set(group, name string, value float64) {
+	if m[group] == nil {
+		m[group] = make(map[string]float64)
+	}
+	m[group][name] = value
+}
+
+// Get returns the value of the metric with the key, and whether the run reported it.
+func (m Metrics) Get(key string) (float64, bool) {
+	group, name, ok := SplitMetricKey(key)
+	if !ok {
+		return 0, false
+	}
+	value, ok := m[group][name]
+	return value, ok
+}
+
+// RunMetric is the value of a metric reported by a puppet-run.
+type RunMetric struct {
+	// ReportID is the ID of the report of the run.
+	ReportID string `json:"report_id" bson:"report_id"`
+
+	// Fqdn is the FQDN of the node.
+	Fqdn string `json:"fqdn" bson:"fqdn"`
+
+	// Env is the environment of the node.
+	Env summary.Environment `json:"env" bson:"env"`
+
+	// ExecTime is the time the run was executed.
+	ExecTime Datetime `json:"exec_time" bson:"exec_time"`
+
+	// Metric is the key of the metric, as group.name.
+	Metric string `json:"metric" bson:"metric"`
+
+	// Value is the value of the metric.
+	Value float64 `json:"value" bson:"value"`
+}
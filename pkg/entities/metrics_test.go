@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitMetricKey(t *testing.T) {
+	group, name, ok := SplitMetricKey("resources.corrective_change")
+	require.True(t, ok)
+	require.Equal(t, "resources", group)
+	require.Equal(t, "corrective_change", name)
+	require.Equal(t, "resources.corrective_change", MetricKey(group, name))
+
+	for _, key := range []string{"", "events", ".failure", "events."} {
+		_, _, ok := SplitMetricKey(key)
+		require.False(t, ok, key)
+	}
+}
+
+func TestMetrics_GetSet(t *testing.T) {
+	m := make(Metrics)
+	m.Set("events", "failure", 2)
+	m.Set("events", "success", 7)
+
+	value, ok := m.Get("events.failure")
+	require.True(t, ok)
+	require.Equal(t, 2.0, value)
+
+	_, ok = m.Get("events.noop")
+	require.False(t, ok)
+
+	_, ok = m.Get("events")
+	require.False(t, ok)
+
+	// A run without metrics reports none.
+	_, ok = Metrics(nil).Get("events.failure")
+	require.False(t, ok)
+}
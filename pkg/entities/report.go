@@ -87,6 +87,13 @@ type PuppetReport struct {
 	// Runtime is the time the puppet-run took.
 	Runtime Duration `json:"runtime" bson:"runtime"`
 
+	// ConfigVersion is the version of the catalog applied by the puppet-run, as reported in configuration_version.
+	ConfigVersion string `json:"configuration_version" bson:"configuration_version"`
+
+	// CodeID is the ID of the code applied by the puppet-run, as reported in code_id. It is only set by servers using
+	// static catalogs.
+	CodeID string `json:"code_id" bson:"code_id"`
+
 	// Failed is the number of resources which failed.
 	Failed int64 `json:"failed" bson:"failed"`
 
@@ -102,6 +109,13 @@ type PuppetReport struct {
 	// LogMessages are the messages logged by puppet.
 	LogMessages []string `json:"log_messages" bson:"log_messages"`
 
+	// Timings are the times the puppet-run spent on each resource type and phase, as reported in metrics.time, in the
+	// order they were reported.
+	Timings []*Timing `json:"timings" bson:"timings"`
+
+	// Metrics are the metrics of every group reported in metrics, such as resources, events and changes.
+	Metrics Metrics `json:"metrics" bson:"metrics"`
+
 	// ResourcesFailed are the resources which failed.
 	ResourcesFailed []*PuppetResource `json:"resources_failed" bson:"resources_failed"`
 
@@ -118,10 +132,15 @@ type PuppetReport struct {
 	YamlFile string `json:"-" bson:"yamlFile"`
 }
 
+// ReportFilePath returns the file the report is saved to, setting YamlFile. Unless YamlFile is already set, the file is
+// named after the time the report was executed, as reported by the node.
 func (n *PuppetReport) ReportFilePath() string {
-	path := filepath.Join("reports", string(n.Env), n.Fqdn, n.ExecTime.Time().Format(time.RFC3339)+".yaml")
-	n.YamlFile = path
-	return path
+	if n.YamlFile != "" {
+		return n.YamlFile
+	}
+
+	n.YamlFile = filepath.Join("reports", string(n.Env), n.Fqdn, n.ExecTime.Time().Format(time.RFC3339)+".yaml")
+	return n.YamlFile
 }
 
 func (n *PuppetReport) SortResources() {
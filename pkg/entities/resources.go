@@ -1,5 +1,7 @@
 package entities
 
+import "github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+
 // PuppetResource refers to a resource in your puppet modules, a resource has
 // a name, along with the file & line-number it was defined in within your
 // manifest
@@ -9,3 +11,21 @@ type PuppetResource struct {
 	File string `json:"file" bson:"file"`
 	Line string `json:"line" bson:"line"`
 }
+
+// ResourceFailure is a resource that failed in a report, with the report it failed in.
+type ResourceFailure struct {
+	// ReportID is the ID of the report the resource failed in.
+	ReportID string `json:"report_id" bson:"report_id"`
+
+	// Fqdn of the node.
+	Fqdn string `json:"fqdn" bson:"fqdn"`
+
+	// Env of the node.
+	Env summary.Environment `json:"env" bson:"env"`
+
+	// ExecTime is the time the puppet-run was completed.
+	ExecTime Datetime `json:"exec_time" bson:"exec_time"`
+
+	// Resource is the resource that failed.
+	Resource *PuppetResource `json:"resource" bson:"resource"`
+}
@@ -16,6 +16,33 @@ type PuppetRun struct {
 	ExecTime  Datetime            `json:"exec_time" bson:"exec_time"`
 	Runtime   Duration            `json:"runtime" bson:"runtime"`
 	TimeSince Duration            `json:"-" bson:"time_since"`
+
+	// YamlFile is the file the report of the run was saved to.
+	YamlFile string `json:"-" bson:"yamlFile"`
+}
+
+// RunVersion is the version of the code a puppet-run applied.
+type RunVersion struct {
+	// ReportID is the ID of the report of the run.
+	ReportID string `json:"report_id" bson:"id"`
+
+	// Fqdn of the node.
+	Fqdn string `json:"fqdn" bson:"fqdn"`
+
+	// Env of the node.
+	Env summary.Environment `json:"env" bson:"env"`
+
+	// State of the run.
+	State summary.State `json:"state" bson:"state"`
+
+	// ExecTime is the time the puppet-run was completed.
+	ExecTime Datetime `json:"exec_time" bson:"exec_time"`
+
+	// ConfigVersion is the version of the catalog applied by the run.
+	ConfigVersion string `json:"configuration_version" bson:"configuration_version"`
+
+	// CodeID is the ID of the code applied by the run, if the server uses static catalogs.
+	CodeID string `json:"code_id" bson:"code_id"`
 }
 
 func (p *PuppetRun) CalculateTimeSince() {
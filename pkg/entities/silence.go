@@ -0,0 +1,80 @@
+package entities
+
+import "time"
+
+// SilenceKind is the kind of a silence.
+type SilenceKind string
+
+const (
+	// SilenceKindAck is the acknowledgement of the failure of a node, by whoever is handling it.
+	SilenceKindAck SilenceKind = "ack"
+
+	// SilenceKindSilence is a silence of nodes for a maintenance window.
+	SilenceKindSilence SilenceKind = "silence"
+)
+
+// IsValid returns whether the kind is known.
+func (k SilenceKind) IsValid() bool {
+	switch k {
+	case SilenceKindAck, SilenceKindSilence:
+		return true
+	default:
+		return false
+	}
+}
+
+// Silence mutes the failures of the nodes it matches, from when it starts until it ends. A silence matches a single
+// node by its fqdn, or every node with the owner and labels of its selector.
+type Silence struct {
+	// ID is the ID of the silence.
+	ID string `json:"id" bson:"id"`
+
+	// Kind is the kind of the silence.
+	Kind SilenceKind `json:"kind" bson:"kind"`
+
+	// Fqdn is the FQDN of the node the silence matches. If empty, the silence matches on the owner and labels.
+	Fqdn string `json:"fqdn" bson:"fqdn"`
+
+	// Owner is the owner of the nodes the silence matches, if not empty.
+	Owner string `json:"owner" bson:"owner"`
+
+	// Labels are the labels of the nodes the silence matches.
+	Labels Labels `json:"labels" bson:"labels"`
+
+	// CreatedBy is who created the silence.
+	CreatedBy string `json:"created_by" bson:"created_by"`
+
+	// Reason is why the silence was created.
+	Reason string `json:"reason" bson:"reason"`
+
+	// StartsAt is the time the silence starts.
+	StartsAt Datetime `json:"starts_at" bson:"starts_at"`
+
+	// EndsAt is the time the silence ends.
+	EndsAt Datetime `json:"ends_at" bson:"ends_at"`
+
+	// CreatedAt is the time the silence was created.
+	CreatedAt Datetime `json:"created_at" bson:"created_at"`
+}
+
+// Active returns whether the silence has started and not yet ended at the given time.
+func (s *Silence) Active(at time.Time) bool {
+	return !at.Before(s.StartsAt.Time()) && at.Before(s.EndsAt.Time())
+}
+
+// Expired returns whether the silence has ended at the given time.
+func (s *Silence) Expired(at time.Time) bool {
+	return !at.Before(s.EndsAt.Time())
+}
+
+// Matches returns whether the silence matches the node with the given fqdn and metadata. A silence without an fqdn,
+// owner or labels matches nothing, rather than every node.
+func (s *Silence) Matches(fqdn string, md *NodeMetadata) bool {
+	if s.Fqdn != "" {
+		return s.Fqdn == fqdn
+	}
+	if s.Owner == "" && len(s.Labels) == 0 {
+		return false
+	}
+	return md.Matches(s.Owner, s.Labels)
+}
@@ -0,0 +1,96 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSilence_Active(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := &Silence{
+		StartsAt: Datetime(now),
+		EndsAt:   Datetime(now.Add(time.Hour)),
+	}
+
+	require.False(t, s.Active(now.Add(-time.Second)))
+	require.True(t, s.Active(now))
+	require.True(t, s.Active(now.Add(59*time.Minute)))
+	require.False(t, s.Active(now.Add(time.Hour)))
+
+	require.False(t, s.Expired(now))
+	require.True(t, s.Expired(now.Add(time.Hour)))
+}
+
+func TestSilence_Matches(t *testing.T) {
+	md := &NodeMetadata{
+		Fqdn:  "node1",
+		Owner: "team-a",
+		Labels: Labels{
+			"role": "web",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		silence *Silence
+		fqdn    string
+		md      *NodeMetadata
+		want    bool
+	}{
+		{
+			name:    "Fqdn",
+			silence: &Silence{Fqdn: "node1"},
+			fqdn:    "node1",
+			want:    true,
+		},
+		{
+			name:    "OtherFqdn",
+			silence: &Silence{Fqdn: "node2"},
+			fqdn:    "node1",
+			md:      md,
+			want:    false,
+		},
+		{
+			name:    "Owner",
+			silence: &Silence{Owner: "team-a"},
+			fqdn:    "node1",
+			md:      md,
+			want:    true,
+		},
+		{
+			name:    "Labels",
+			silence: &Silence{Owner: "team-a", Labels: Labels{"role": "web"}},
+			fqdn:    "node1",
+			md:      md,
+			want:    true,
+		},
+		{
+			name:    "OtherLabels",
+			silence: &Silence{Labels: Labels{"role": "db"}},
+			fqdn:    "node1",
+			md:      md,
+			want:    false,
+		},
+		{
+			name:    "NoMetadata",
+			silence: &Silence{Owner: "team-a"},
+			fqdn:    "node1",
+			want:    false,
+		},
+		{
+			name:    "EmptySelector",
+			silence: &Silence{},
+			fqdn:    "node1",
+			md:      md,
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.silence.Matches(tt.fqdn, tt.md))
+		})
+	}
+}
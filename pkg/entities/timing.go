@@ -0,0 +1,37 @@
+package entities
+
+const (
+	// TimingTotal is the name of the timing of the whole puppet-run.
+	TimingTotal = "total"
+)
+
+// Timing is the time a puppet-run spent on a resource type, such as File or Exec, or on a phase of the run, such as
+// config_retrieval or catalog_application.
+type Timing struct {
+	// Name is the name of the timing, as reported by puppet (e.g. file, config_retrieval).
+	Name string `json:"name" bson:"name"`
+
+	// Label is the human-readable name of the timing (e.g. File, Config retrieval).
+	Label string `json:"label" bson:"label"`
+
+	// Seconds is the time spent, in seconds.
+	Seconds float64 `json:"seconds" bson:"seconds"`
+}
+
+// TimingSummary is a timing aggregated across the runs of many reports.
+type TimingSummary struct {
+	// Name is the name of the timing.
+	Name string `json:"name" bson:"_id"`
+
+	// Label is the human-readable name of the timing.
+	Label string `json:"label" bson:"label"`
+
+	// Runs is the number of runs that reported the timing.
+	Runs int `json:"runs" bson:"runs"`
+
+	// Seconds is the time spent across the runs, in seconds.
+	Seconds float64 `json:"seconds" bson:"seconds"`
+
+	// Max is the most time spent by a single run, in seconds.
+	Max float64 `json:"max" bson:"max"`
+}
@@ -0,0 +1,224 @@
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+)
+
+// DefaultBuffer is the number of events a subscriber can fall behind by before it is dropped.
+const DefaultBuffer = 64
+
+// Event is a report that has been ingested.
+type Event struct {
+	// ID is the ID of the report.
+	ID string
+
+	// Fqdn is the FQDN of the node.
+	Fqdn string
+
+	// Env is the environment of the node.
+	Env summary.Environment
+
+	// State is the state of the run.
+	State summary.State
+
+	// ExecTime is the time the run was executed.
+	ExecTime time.Time
+
+	// Runtime is how long the run took.
+	Runtime entities.Duration
+
+	// Failed is the number of resources which failed.
+	Failed int64
+
+	// Changed is the number of resources which changed.
+	Changed int64
+
+	// Total is the total number of resources.
+	Total int64
+}
+
+// NewEvent returns the event of an ingested report.
+func NewEvent(rep *entities.PuppetReport) *Event {
+	return &Event{
+		ID:       rep.ID,
+		Fqdn:     rep.Fqdn,
+		Env:      rep.Env,
+		State:    rep.State,
+		ExecTime: rep.ExecTime.Time(),
+		Runtime:  rep.Runtime,
+		Failed:   rep.Failed,
+		Changed:  rep.Changed,
+		Total:    rep.Total,
+	}
+}
+
+// Filter selects the events a subscriber receives.
+type Filter struct {
+	// Fqdn is the FQDN of the node. Any node if empty.
+	Fqdn string
+
+	// Envs are the environments of the nodes. Any environment if empty.
+	Envs []summary.Environment
+
+	// States are the states of the runs. Any state if empty.
+	States []summary.State
+}
+
+// Matches returns whether the event is selected by the filter.
+func (f *Filter) Matches(e *Event) bool {
+	if f == nil {
+		return true
+	}
+	if f.Fqdn != "" && f.Fqdn != e.Fqdn {
+		return false
+	}
+	if len(f.Envs) > 0 && !slices.Contains(f.Envs, e.Env) {
+		return false
+	}
+	if len(f.States) > 0 && !slices.Contains(f.States, e.State) {
+		return false
+	}
+	return true
+}
+
+// Subscription receives the events published to a broker, until it is unsubscribed or dropped.
+type Subscription struct {
+	// filter selects the events sent to the subscription.
+	filter *Filter
+
+	// events is the buffer of the events not yet received.
+	events chan *Event
+
+	// done is closed when the subscription ends.
+	done chan struct{}
+
+	// once ensures done is only closed once.
+	once sync.Once
+
+	// dropped is whether the subscription ended because it fell behind.
+	dropped bool
+}
+
+// Events returns the channel the events are received on.
+func (s *Subscription) Events() <-chan *Event {
+	return s.events
+}
+
+// Done returns a channel that is closed when the subscription ends. Any events still buffered can be discarded.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Dropped returns whether the subscription ended because it fell too far behind. It is only meaningful once Done
+// is closed.
+func (s *Subscription) Dropped() bool {
+	<-s.done
+	return s.dropped
+}
+
+// end ends the subscription.
+func (s *Subscription) end(dropped bool) {
+	s.once.Do(func() {
+		s.dropped = dropped
+		close(s.done)
+	})
+}
+
+// Broker fans the ingested reports out to the subscribers.
+//
+// Publishing never blocks on a subscriber. A subscriber that falls behind by more than its buffer is dropped, so that
+// a slow client cannot hold up the ingestion of reports or grow the memory used without bound. A dropped subscriber
+// has missed events, and is expected to resynchronise before subscribing again.
+type Broker struct {
+	// mtx guards the subscribers.
+	mtx sync.RWMutex
+
+	// subscribers are the current subscriptions.
+	subscribers map[*Subscription]struct{}
+
+	// buffer is the number of events each subscriber can fall behind by.
+	buffer int
+}
+
+// NewBroker creates a broker whose subscribers can fall behind by the given number of events.
+func NewBroker(buffer int) *Broker {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+
+	return &Broker{
+		subscribers: make(map[*Subscription]struct{}),
+		buffer:      buffer,
+	}
+}
+
+// Subscribe subscribes to the events selected by the filter. The subscription must be unsubscribed once it is no
+// longer used.
+func (b *Broker) Subscribe(filter *Filter) *Subscription {
+	sub := &Subscription{
+		filter: filter,
+		events: make(chan *Event, b.buffer),
+		done:   make(chan struct{}),
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.subscribers[sub] = struct{}{}
+	Subscribers.Inc()
+
+	return sub
+}
+
+// Unsubscribe ends the subscription.
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.remove(sub, false)
+}
+
+// Publish sends the event to every subscriber selected by its filter, dropping the subscribers that have fallen
+// behind.
+func (b *Broker) Publish(e *Event) {
+	Published.Inc()
+
+	slow := make([]*Subscription, 0)
+
+	b.mtx.RLock()
+	for sub := range b.subscribers {
+		if !sub.filter.Matches(e) {
+			continue
+		}
+
+		select {
+		case sub.events <- e:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	b.mtx.RUnlock()
+
+	for _, sub := range slow {
+		b.remove(sub, true)
+	}
+}
+
+// remove removes the subscription from the broker and ends it.
+func (b *Broker) remove(sub *Subscription, dropped bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+
+	delete(b.subscribers, sub)
+	Subscribers.Dec()
+	if dropped {
+		DroppedSubscribers.Inc()
+	}
+
+	sub.end(dropped)
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilter_Matches(t *testing.T) {
+	e := &Event{
+		ID:    "report1",
+		Fqdn:  "node1",
+		Env:   summary.Environment_PRODUCTION,
+		State: summary.State_FAILED,
+	}
+
+	tests := []struct {
+		name   string
+		filter *Filter
+		want   bool
+	}{
+		{"nil", nil, true},
+		{"empty", new(Filter), true},
+		{"fqdn", &Filter{Fqdn: "node1"}, true},
+		{"other fqdn", &Filter{Fqdn: "node2"}, false},
+		{"env", &Filter{Envs: []summary.Environment{summary.Environment_STAGING, summary.Environment_PRODUCTION}}, true},
+		{"other env", &Filter{Envs: []summary.Environment{summary.Environment_STAGING}}, false},
+		{"state", &Filter{States: []summary.State{summary.State_FAILED}}, true},
+		{"other state", &Filter{States: []summary.State{summary.State_CHANGED}}, false},
+		{"all", &Filter{Fqdn: "node1", Envs: []summary.Environment{summary.Environment_PRODUCTION}, States: []summary.State{summary.State_CHANGED}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.filter.Matches(e))
+		})
+	}
+}
+
+func TestBroker_Publish(t *testing.T) {
+	b := NewBroker(2)
+
+	all := b.Subscribe(nil)
+	defer b.Unsubscribe(all)
+	failed := b.Subscribe(&Filter{States: []summary.State{summary.State_FAILED}})
+	defer b.Unsubscribe(failed)
+
+	b.Publish(&Event{ID: "report1", State: summary.State_CHANGED})
+	b.Publish(&Event{ID: "report2", State: summary.State_FAILED})
+
+	require.Equal(t, "report1", (<-all.Events()).ID)
+	require.Equal(t, "report2", (<-all.Events()).ID)
+	require.Equal(t, "report2", (<-failed.Events()).ID)
+	require.Empty(t, failed.Events())
+}
+
+func TestBroker_Publish_Slow(t *testing.T) {
+	b := NewBroker(1)
+
+	slow := b.Subscribe(nil)
+	fast := b.Subscribe(nil)
+	defer b.Unsubscribe(fast)
+
+	b.Publish(&Event{ID: "report1"})
+	require.Equal(t, "report1", (<-fast.Events()).ID)
+
+	// The slow subscriber has not received the first event, so it is dropped rather than holding up the publisher.
+	b.Publish(&Event{ID: "report2"})
+	require.Equal(t, "report2", (<-fast.Events()).ID)
+	require.True(t, slow.Dropped())
+
+	// A dropped subscriber is not sent any more events.
+	b.Publish(&Event{ID: "report3"})
+	require.Len(t, slow.Events(), 1)
+	require.Equal(t, "report3", (<-fast.Events()).ID)
+
+	// Unsubscribing a dropped subscriber does nothing.
+	b.Unsubscribe(slow)
+}
+
+func TestBroker_Unsubscribe(t *testing.T) {
+	b := NewBroker(1)
+
+	sub := b.Subscribe(nil)
+	b.Unsubscribe(sub)
+	require.False(t, sub.Dropped())
+
+	b.Publish(&Event{ID: "report1"})
+	require.Empty(t, sub.Events())
+}
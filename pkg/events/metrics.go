@@ -0,0 +1,30 @@
+package events
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Subscribers is the number of current subscribers to the events.
+var Subscribers = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "events_subscribers",
+		Help: "Number of current subscribers to the events",
+	},
+)
+
+// Published is the number of events published.
+var Published = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Name: "events_published_total",
+		Help: "Number of events published",
+	},
+)
+
+// DroppedSubscribers is the number of subscribers dropped for falling behind.
+var DroppedSubscribers = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Name: "events_dropped_subscribers_total",
+		Help: "Number of subscribers dropped for falling behind",
+	},
+)
@@ -0,0 +1,338 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+)
+
+// defaultRetention is how long a finished job is kept in the registry, so that its outcome can be queried.
+const defaultRetention = 24 * time.Hour
+
+// ErrAlreadyRunning is returned when a job of the same type is already running.
+var ErrAlreadyRunning = errors.New("job already running")
+
+// Status is the status of a background job.
+type Status string
+
+const (
+	// StatusRunning is when the job is running.
+	StatusRunning Status = "RUNNING"
+
+	// StatusSucceeded is when the job finished without an error.
+	StatusSucceeded Status = "SUCCEEDED"
+
+	// StatusFailed is when the job finished with an error.
+	StatusFailed Status = "FAILED"
+
+	// StatusCancelled is when the job was cancelled before it finished.
+	StatusCancelled Status = "CANCELLED"
+)
+
+// Func is the work done by a job. The context is cancelled when the job is cancelled.
+type Func func(ctx context.Context, job *Job) error
+
+// Snapshot is the state of a job at a point in time.
+type Snapshot struct {
+	// ID is the ID of the job.
+	ID string
+
+	// Type is the type of the job, such as purge.
+	Type string
+
+	// Instance is the instance running the job, which is the only instance that knows of it.
+	Instance string
+
+	// Status is the status of the job.
+	Status Status
+
+	// StartedAt is the time the job was started.
+	StartedAt time.Time
+
+	// FinishedAt is the time the job finished. This is zero while the job is running.
+	FinishedAt time.Time
+
+	// Error is the error returned by the job, if it failed.
+	Error string
+
+	// Progress is the progress counters of the job.
+	Progress map[string]int
+}
+
+// Job is a job running in the background.
+type Job struct {
+	// mtx is the mutex protecting the job state.
+	mtx sync.RWMutex
+
+	// id is the ID of the job.
+	id string
+
+	// jobType is the type of the job.
+	jobType string
+
+	// instance is the instance running the job.
+	instance string
+
+	// status is the status of the job.
+	status Status
+
+	// startedAt is the time the job was started.
+	startedAt time.Time
+
+	// finishedAt is the time the job finished.
+	finishedAt time.Time
+
+	// err is the error returned by the job.
+	err string
+
+	// progress is the progress counters of the job.
+	progress map[string]int
+
+	// cancel cancels the context of the job.
+	cancel context.CancelFunc
+
+	// done is closed when the job has finished.
+	done chan struct{}
+}
+
+// ID returns the ID of the job.
+func (j *Job) ID() string {
+	return j.id
+}
+
+// Set sets the named progress counter to the value.
+func (j *Job) Set(name string, value int) {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	j.progress[name] = value
+}
+
+// Add adds delta to the named progress counter.
+func (j *Job) Add(name string, delta int) {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+	j.progress[name] += delta
+}
+
+// Cancel cancels the job. Returns false if the job has already finished.
+func (j *Job) Cancel() bool {
+	select {
+	case <-j.done:
+		return false
+	default:
+	}
+
+	j.cancel()
+	return true
+}
+
+// Done returns a channel that is closed when the job has finished.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Snapshot returns the current state of the job.
+func (j *Job) Snapshot() *Snapshot {
+	j.mtx.RLock()
+	defer j.mtx.RUnlock()
+
+	progress := make(map[string]int, len(j.progress))
+	for k, v := range j.progress {
+		progress[k] = v
+	}
+
+	return &Snapshot{
+		ID:         j.id,
+		Type:       j.jobType,
+		Instance:   j.instance,
+		Status:     j.status,
+		StartedAt:  j.startedAt,
+		FinishedAt: j.finishedAt,
+		Error:      j.err,
+		Progress:   progress,
+	}
+}
+
+// finish records the outcome of the job.
+func (j *Job) finish(ctx context.Context, err error, now time.Time) {
+	j.mtx.Lock()
+	defer j.mtx.Unlock()
+
+	j.finishedAt = now
+	switch {
+	case err == nil:
+		j.status = StatusSucceeded
+	case ctx.Err() != nil:
+		// The job was cancelled, so the error is the result of the cancellation.
+		j.status = StatusCancelled
+	default:
+		j.status = StatusFailed
+		j.err = err.Error()
+	}
+}
+
+// running returns whether the job is still running.
+func (j *Job) running() bool {
+	j.mtx.RLock()
+	defer j.mtx.RUnlock()
+	return j.status == StatusRunning
+}
+
+// expired returns whether the job finished before the given time.
+func (j *Job) expired(before time.Time) bool {
+	j.mtx.RLock()
+	defer j.mtx.RUnlock()
+	return j.status != StatusRunning && j.finishedAt.Before(before)
+}
+
+// Registry keeps track of the jobs running in the background on this instance. The jobs are only held in memory, so
+// a job is only known to the instance that started it.
+type Registry struct {
+	// mtx is the mutex protecting the jobs.
+	mtx sync.Mutex
+
+	// instance identifies this instance in the jobs it starts.
+	instance string
+
+	// jobs is the jobs in the registry, by ID.
+	jobs map[string]*Job
+
+	// retention is how long a finished job is kept for.
+	retention time.Duration
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// NewRegistry creates a new, empty, job registry for the given instance.
+func NewRegistry(instance string) *Registry {
+	return &Registry{
+		instance:  instance,
+		jobs:      make(map[string]*Job),
+		retention: defaultRetention,
+		now:       time.Now,
+	}
+}
+
+// Start starts the function as a job of the given type in the background. Only one job of each type can run at a
+// time; if one is already running, it is returned with ErrAlreadyRunning.
+func (r *Registry) Start(jobType string, fn Func) (*Job, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.prune()
+
+	for _, job := range r.jobs {
+		if job.jobType == jobType && job.running() {
+			return job, ErrAlreadyRunning
+		}
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, fmt.Errorf("error generating job ID: %w", err)
+	}
+
+	// The job outlives the request that started it, so it is not derived from the request context.
+	ctx, cancel := context.WithCancel(context.Background())
+
+	job := &Job{
+		id:        id,
+		jobType:   jobType,
+		instance:  r.instance,
+		status:    StatusRunning,
+		startedAt: r.now(),
+		progress:  make(map[string]int),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	r.jobs[id] = job
+
+	go r.run(ctx, job, fn)
+
+	return job, nil
+}
+
+// run runs the job, recording its outcome.
+func (r *Registry) run(ctx context.Context, job *Job, fn Func) {
+	defer close(job.done)
+	defer job.cancel()
+
+	l := slog.With(slog.String("job", job.jobType), slog.String("id", job.id))
+	l.Info("Job started")
+
+	err := func() (err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = fmt.Errorf("job panicked: %v", rec)
+			}
+		}()
+		return fn(ctx, job)
+	}()
+
+	job.finish(ctx, err, r.now())
+
+	snap := job.Snapshot()
+	if snap.Status == StatusFailed {
+		l.Error("Job failed", slog.String(logging.KeyError, snap.Error))
+		return
+	}
+	l.Info("Job finished", slog.String("status", string(snap.Status)))
+}
+
+// Get returns the job with the given ID.
+func (r *Registry) Get(id string) (*Job, bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.prune()
+
+	job, ok := r.jobs[id]
+	return job, ok
+}
+
+// CancelAll cancels all the running jobs, and waits for them to finish or the context to be done.
+func (r *Registry) CancelAll(ctx context.Context) {
+	r.mtx.Lock()
+	running := make([]*Job, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		if job.Cancel() {
+			running = append(running, job)
+		}
+	}
+	r.mtx.Unlock()
+
+	for _, job := range running {
+		select {
+		case <-job.Done():
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// prune removes the finished jobs that are older than the retention. The caller must hold the lock.
+func (r *Registry) prune() {
+	before := r.now().Add(-r.retention)
+	for id, job := range r.jobs {
+		if job.expired(before) {
+			delete(r.jobs, id)
+		}
+	}
+}
+
+// newID generates a random job ID.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
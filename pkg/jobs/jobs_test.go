@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func waitForJob(t *testing.T, job *Job) *Snapshot {
+	t.Helper()
+
+	select {
+	case <-job.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for job to finish")
+	}
+	return job.Snapshot()
+}
+
+func TestRegistry_Start(t *testing.T) {
+	r := NewRegistry("instance1")
+
+	job, err := r.Start("purge", func(_ context.Context, job *Job) error {
+		job.Set("total", 10)
+		job.Add("deleted", 4)
+		job.Add("deleted", 6)
+		return nil
+	})
+	require.NoError(t, err)
+
+	snap := waitForJob(t, job)
+	require.Equal(t, StatusSucceeded, snap.Status)
+	require.Equal(t, "purge", snap.Type)
+	require.Equal(t, "instance1", snap.Instance)
+	require.Equal(t, map[string]int{"total": 10, "deleted": 10}, snap.Progress)
+	require.False(t, snap.FinishedAt.IsZero())
+
+	got, ok := r.Get(job.ID())
+	require.True(t, ok)
+	require.Same(t, job, got)
+}
+
+func TestRegistry_StartFailed(t *testing.T) {
+	r := NewRegistry("instance1")
+
+	job, err := r.Start("purge", func(context.Context, *Job) error {
+		return errors.New("database unavailable")
+	})
+	require.NoError(t, err)
+
+	snap := waitForJob(t, job)
+	require.Equal(t, StatusFailed, snap.Status)
+	require.Equal(t, "database unavailable", snap.Error)
+}
+
+func TestRegistry_StartPanic(t *testing.T) {
+	r := NewRegistry("instance1")
+
+	job, err := r.Start("purge", func(context.Context, *Job) error {
+		panic("boom")
+	})
+	require.NoError(t, err)
+
+	snap := waitForJob(t, job)
+	require.Equal(t, StatusFailed, snap.Status)
+	require.Equal(t, "job panicked: boom", snap.Error)
+}
+
+func TestRegistry_StartAlreadyRunning(t *testing.T) {
+	r := NewRegistry("instance1")
+
+	block := func(ctx context.Context, _ *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	job, err := r.Start("purge", block)
+	require.NoError(t, err)
+
+	running, err := r.Start("purge", block)
+	require.ErrorIs(t, err, ErrAlreadyRunning)
+	require.Same(t, job, running)
+
+	// A job of another type can still be started.
+	other, err := r.Start("fsck", block)
+	require.NoError(t, err)
+
+	r.CancelAll(context.Background())
+	require.Equal(t, StatusCancelled, waitForJob(t, job).Status)
+	require.Equal(t, StatusCancelled, waitForJob(t, other).Status)
+}
+
+func TestJob_Cancel(t *testing.T) {
+	r := NewRegistry("instance1")
+
+	started := make(chan struct{})
+	job, err := r.Start("purge", func(ctx context.Context, _ *Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+
+	<-started
+	require.True(t, job.Cancel())
+
+	snap := waitForJob(t, job)
+	require.Equal(t, StatusCancelled, snap.Status)
+	require.Empty(t, snap.Error)
+
+	// A finished job cannot be cancelled.
+	require.False(t, job.Cancel())
+}
+
+func TestRegistry_Prune(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	r := NewRegistry("instance1")
+	r.now = func() time.Time {
+		return now
+	}
+
+	job, err := r.Start("purge", func(context.Context, *Job) error {
+		return nil
+	})
+	require.NoError(t, err)
+	waitForJob(t, job)
+
+	_, ok := r.Get(job.ID())
+	require.True(t, ok)
+
+	now = now.Add(defaultRetention + time.Minute)
+
+	_, ok = r.Get(job.ID())
+	require.False(t, ok)
+}
@@ -18,4 +18,7 @@ const (
 
 	// KeyHash represents the key for the hash.
 	KeyHash = `hash`
+
+	// KeyFqdn represents the key for the FQDN of a node.
+	KeyFqdn = `fqdn`
 )
@@ -18,6 +18,12 @@ const (
 
 	// ContentTypePng is the png content type.
 	ContentTypePng ContentType = "image/png"
+
+	// ContentTypeYAML is the YAML content type.
+	ContentTypeYAML ContentType = "application/yaml"
+
+	// ContentTypeCSV is the CSV content type.
+	ContentTypeCSV ContentType = "text/csv"
 )
 
 // String returns the string representation of the ContentType.
@@ -51,6 +57,10 @@ func getContentType(contentType string) ContentType {
 		return ContentTypeText
 	case "image/png":
 		return ContentTypePng
+	case "application/yaml":
+		return ContentTypeYAML
+	case "text/csv":
+		return ContentTypeCSV
 	default:
 		return ContentTypeJSON
 	}
@@ -43,6 +43,16 @@ func Test_getContentType(t *testing.T) {
 			input: "image/png",
 			want:  ContentTypePng,
 		},
+		{
+			name:  "yaml",
+			input: "application/yaml",
+			want:  ContentTypeYAML,
+		},
+		{
+			name:  "csv",
+			input: "text/csv",
+			want:  ContentTypeCSV,
+		},
 		{
 			name:  "invalid",
 			input: "invalid",
@@ -0,0 +1,520 @@
+package request
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// QueryFormat is the query parameter that chooses the format of the response, overriding the Accept header.
+const QueryFormat = "format"
+
+// ErrNotTabular is returned when a response that is not a list of records is rendered as CSV.
+var ErrNotTabular = errors.New("response is not tabular")
+
+// formats are the values of the format query parameter, and the content types they choose.
+var formats = map[string]ContentType{
+	"json": ContentTypeJSON,
+	"yaml": ContentTypeYAML,
+	"yml":  ContentTypeYAML,
+	"csv":  ContentTypeCSV,
+	"xml":  ContentTypeXML,
+}
+
+// mediaTypes are the media types accepted for each content type, other than the content type itself.
+var mediaTypes = map[string]ContentType{
+	"application/x-yaml": ContentTypeYAML,
+	"text/yaml":          ContentTypeYAML,
+	"text/xml":           ContentTypeXML,
+}
+
+// Renderer renders a response body in the format the client asks for, with the format query parameter or the Accept
+// header. JSON is rendered when the client does not ask for a format.
+type Renderer struct {
+	// Root is the name of the root element in XML.
+	Root string
+
+	// Item is the name of the elements of the list in XML, when the body is a list.
+	Item string
+
+	// Tabular is whether the body is a list of records, which can be rendered as CSV.
+	Tabular bool
+}
+
+// offered returns the content types the renderer can render, in order of preference.
+func (rd Renderer) offered() []ContentType {
+	offered := []ContentType{ContentTypeJSON, ContentTypeYAML, ContentTypeXML}
+	if rd.Tabular {
+		offered = append(offered, ContentTypeCSV)
+	}
+	return offered
+}
+
+// Render writes the body with the status code, in the format negotiated with the client. If the client asks for a
+// format that cannot be rendered, a 406 not acceptable is responded with instead.
+func (rd Renderer) Render(w http.ResponseWriter, r *http.Request, status int, body any) {
+	ct, ok := Negotiate(r, rd.offered()...)
+	if !ok {
+		w.WriteHeader(http.StatusNotAcceptable)
+		if err := json.NewEncoder(w).Encode(NewMessage("Unsupported response format, supported formats are %s", rd.formatNames())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	var (
+		bdy []byte
+		err error
+	)
+	switch ct {
+	case ContentTypeYAML:
+		bdy, err = rd.yaml(body)
+	case ContentTypeXML:
+		bdy, err = rd.xml(body)
+	case ContentTypeCSV:
+		bdy, err = rd.csv(body)
+	default:
+		bdy, err = rd.json(body)
+	}
+	if err != nil {
+		slog.Error("Error rendering response", slog.String("content_type", ct.String()), slog.String(logging.KeyError, err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(NewMessage("Error rendering response")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	w.Header().Set("content-type", ct.String())
+	w.WriteHeader(status)
+	if _, err := w.Write(bdy); err != nil {
+		slog.Warn("Error writing response", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// formatNames returns the names of the formats the renderer can render.
+func (rd Renderer) formatNames() string {
+	names := make([]string, 0)
+	for _, ct := range rd.offered() {
+		for name, format := range formats {
+			if format == ct && name != "yml" {
+				names = append(names, name)
+			}
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// Negotiate returns the content type the response should be rendered in, out of the offered content types. The format
+// query parameter is used if set, then the Accept header. The first offered content type is used if the client does
+// not ask for one, or if it is a browser asking for HTML, as browsers also list XML in their Accept header. Returns false
+// if the client only accepts content types that are not offered.
+func Negotiate(r *http.Request, offered ...ContentType) (ContentType, bool) {
+	if len(offered) == 0 {
+		return "", false
+	}
+
+	if format := r.URL.Query().Get(QueryFormat); format != "" {
+		ct, ok := formats[strings.ToLower(format)]
+		if !ok || !ct.IsIn(offered...) {
+			return "", false
+		}
+		return ct, true
+	}
+
+	accept := r.Header.Values("Accept")
+	if len(accept) == 0 {
+		return offered[0], true
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+
+	ranges := make([]mediaRange, 0)
+	for _, header := range accept {
+		for _, part := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+
+			q := 1.0
+			if qStr, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(qStr, 64); err != nil {
+					continue
+				}
+			}
+			if q <= 0 {
+				continue
+			}
+
+			// Browsers ask for HTML first, which is not rendered, so they get the default content type.
+			if mediaType == "text/html" {
+				return offered[0], true
+			}
+
+			ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+		}
+	}
+
+	// The most preferred media ranges are matched first, keeping the order of the header for equal preferences.
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	for _, mr := range ranges {
+		for _, ct := range offered {
+			if mediaTypeMatches(mr.mediaType, ct) {
+				return ct, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// mediaTypeMatches returns whether the media range from an Accept header matches the content type.
+func mediaTypeMatches(mediaRange string, ct ContentType) bool {
+	switch {
+	case mediaRange == "*/*":
+		return true
+	case strings.HasSuffix(mediaRange, "/*"):
+		return strings.HasPrefix(ct.String(), strings.TrimSuffix(mediaRange, "*"))
+	case mediaRange == ct.String():
+		return true
+	default:
+		return mediaTypes[mediaRange] == ct
+	}
+}
+
+// json renders the body as JSON.
+func (rd Renderer) json(body any) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := json.NewEncoder(buf).Encode(body); err != nil {
+		return nil, fmt.Errorf("error encoding json: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// generic returns the body as it is represented in JSON, so that every format has the same field names and values.
+func (rd Renderer) generic(body any) (any, error) {
+	bdy, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding json: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(bdy))
+	dec.UseNumber()
+
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("error decoding json: %w", err)
+	}
+	return v, nil
+}
+
+// yaml renders the body as YAML.
+func (rd Renderer) yaml(body any) ([]byte, error) {
+	v, err := rd.generic(body)
+	if err != nil {
+		return nil, err
+	}
+
+	bdy, err := yaml.Marshal(yamlNumbers(v))
+	if err != nil {
+		return nil, fmt.Errorf("error encoding yaml: %w", err)
+	}
+	return bdy, nil
+}
+
+// yamlNumbers replaces the JSON numbers in the value with YAML numbers, as they would be rendered as strings.
+func yamlNumbers(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			val[k] = yamlNumbers(item)
+		}
+		return val
+	case []any:
+		for i, item := range val {
+			val[i] = yamlNumbers(item)
+		}
+		return val
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val.String()
+	default:
+		return val
+	}
+}
+
+// xml renders the body as XML.
+func (rd Renderer) xml(body any) ([]byte, error) {
+	v, err := rd.generic(body)
+	if err != nil {
+		return nil, err
+	}
+
+	root := rd.Root
+	if root == "" {
+		root = "response"
+	}
+	item := rd.Item
+	if item == "" {
+		item = "item"
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(buf)
+	enc.Indent("", "  ")
+	if err := encodeXML(enc, xml.StartElement{Name: xml.Name{Local: root}}, item, v, reflect.TypeOf(body)); err != nil {
+		return nil, fmt.Errorf("error encoding xml: %w", err)
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, fmt.Errorf("error encoding xml: %w", err)
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
+
+// encodeXML encodes the value as an element with the name. The elements of a list are named item. The type is the Go
+// type the value was rendered from, if known: the fields of a struct are elements named after them, while the entries
+// of a map are entry elements with the key as an attribute, as map keys are not always valid element names.
+func encodeXML(enc *xml.Encoder, start xml.StartElement, item string, v any, t reflect.Type) error {
+	switch val := v.(type) {
+	case nil:
+		// Nulls are left out.
+		return nil
+	case map[string]any:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		t = xmlType(t)
+		var fields map[string]reflect.Type
+		if t != nil && t.Kind() == reflect.Struct {
+			fields = xmlFields(t)
+		}
+		for _, k := range keys {
+			elem, elemType := xmlEntry(k), xmlElem(t)
+			if fieldType, ok := fields[k]; ok && isXMLName(k) {
+				elem, elemType = xml.StartElement{Name: xml.Name{Local: k}}, fieldType
+			}
+			if err := encodeXML(enc, elem, "item", val[k], elemType); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	case []any:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		elemType := xmlElem(xmlType(t))
+		for _, elem := range val {
+			if err := encodeXML(enc, xml.StartElement{Name: xml.Name{Local: item}}, "item", elem, elemType); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	default:
+		return enc.EncodeElement(scalarString(val), start)
+	}
+}
+
+// xmlEntry returns the start of the element of the entry of a map with the key.
+func xmlEntry(key string) xml.StartElement {
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: key}},
+	}
+}
+
+// jsonMarshaler is the type of the json.Marshaler interface.
+var jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// xmlType returns the type with the pointers removed, or nil if the type is unknown or marshals itself to JSON, in
+// which case its JSON form does not follow its fields.
+func xmlType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		if t.Implements(jsonMarshaler) {
+			return nil
+		}
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() == reflect.Interface || t.Implements(jsonMarshaler) || reflect.PointerTo(t).Implements(jsonMarshaler) {
+		return nil
+	}
+	return t
+}
+
+// xmlElem returns the type of the elements of a map, slice or array type, or nil if the type is not one.
+func xmlElem(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return t.Elem()
+	default:
+		return nil
+	}
+}
+
+// xmlFields returns the types of the fields of the struct type, by their names in JSON.
+func xmlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+
+		// The fields of embedded structs without a name are rendered as fields of the struct.
+		if name == "" && f.Anonymous {
+			if ft := xmlType(f.Type); ft != nil && ft.Kind() == reflect.Struct {
+				for k, v := range xmlFields(ft) {
+					if _, ok := fields[k]; !ok {
+						fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// isXMLName returns whether the name is a valid XML element name, without a namespace prefix.
+func isXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '-' || r == '.' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// csv renders the body as CSV. The body must be a list of records, which are rendered as a row each, with a header
+// row of the fields of the records.
+func (rd Renderer) csv(body any) ([]byte, error) {
+	v, err := rd.generic(body)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := v.([]any)
+	if !ok {
+		return nil, ErrNotTabular
+	}
+
+	records := make([]map[string]any, 0, len(list))
+	columns := make(map[string]struct{})
+	for _, elem := range list {
+		record, ok := elem.(map[string]any)
+		if !ok {
+			return nil, ErrNotTabular
+		}
+		for k := range record {
+			columns[k] = struct{}{}
+		}
+		records = append(records, record)
+	}
+
+	header := make([]string, 0, len(columns))
+	for k := range columns {
+		header = append(header, k)
+	}
+	sort.Strings(header)
+
+	buf := new(bytes.Buffer)
+	cw := csv.NewWriter(buf)
+	if err := cw.Write(header); err != nil {
+		return nil, fmt.Errorf("error writing csv: %w", err)
+	}
+	for _, record := range records {
+		row := make([]string, len(header))
+		for i, k := range header {
+			row[i] = csvValue(record[k])
+		}
+		if err := cw.Write(row); err != nil {
+			return nil, fmt.Errorf("error writing csv: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return nil, fmt.Errorf("error writing csv: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// csvValue returns the value of a field as a CSV cell. Nested values are rendered as JSON.
+func csvValue(v any) string {
+	switch val := v.(type) {
+	case map[string]any, []any:
+		bdy, err := json.Marshal(val)
+		if err != nil {
+			return ""
+		}
+		return string(bdy)
+	default:
+		return scalarString(val)
+	}
+}
+
+// scalarString returns the string of a scalar JSON value. Nulls are empty.
+func scalarString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
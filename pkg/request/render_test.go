@@ -0,0 +1,256 @@
+package request
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	offered := []ContentType{ContentTypeJSON, ContentTypeYAML, ContentTypeXML}
+
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   ContentType
+		wantOk bool
+	}{
+		{
+			name:   "no accept",
+			target: "/",
+			want:   ContentTypeJSON,
+			wantOk: true,
+		},
+		{
+			name:   "format",
+			target: "/?format=yaml",
+			accept: "application/xml",
+			want:   ContentTypeYAML,
+			wantOk: true,
+		},
+		{
+			name:   "format alias",
+			target: "/?format=YML",
+			want:   ContentTypeYAML,
+			wantOk: true,
+		},
+		{
+			name:   "format not offered",
+			target: "/?format=csv",
+			wantOk: false,
+		},
+		{
+			name:   "format unknown",
+			target: "/?format=toml",
+			wantOk: false,
+		},
+		{
+			name:   "accept",
+			target: "/",
+			accept: "application/xml",
+			want:   ContentTypeXML,
+			wantOk: true,
+		},
+		{
+			name:   "accept media type alias",
+			target: "/",
+			accept: "text/yaml",
+			want:   ContentTypeYAML,
+			wantOk: true,
+		},
+		{
+			name:   "accept quality",
+			target: "/",
+			accept: "application/json;q=0.5, application/xml;q=0.9",
+			want:   ContentTypeXML,
+			wantOk: true,
+		},
+		{
+			name:   "accept zero quality",
+			target: "/",
+			accept: "application/xml;q=0, */*;q=0.1",
+			want:   ContentTypeJSON,
+			wantOk: true,
+		},
+		{
+			name:   "accept wildcard",
+			target: "/",
+			accept: "*/*",
+			want:   ContentTypeJSON,
+			wantOk: true,
+		},
+		{
+			name:   "accept type wildcard",
+			target: "/",
+			accept: "text/csv, application/*;q=0.8",
+			want:   ContentTypeJSON,
+			wantOk: true,
+		},
+		{
+			name:   "accept browser",
+			target: "/",
+			accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			want:   ContentTypeJSON,
+			wantOk: true,
+		},
+		{
+			name:   "accept browser format",
+			target: "/?format=xml",
+			accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			want:   ContentTypeXML,
+			wantOk: true,
+		},
+		{
+			name:   "accept not offered",
+			target: "/",
+			accept: "text/csv",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			got, ok := Negotiate(r, offered...)
+			require.Equal(t, tt.wantOk, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type renderRecord struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+func TestRenderer_Render(t *testing.T) {
+	rd := Renderer{Root: "records", Item: "record", Tabular: true}
+	body := []renderRecord{
+		{Name: "a", Count: 1, Tags: []string{"x", "y"}},
+		{Name: "b", Count: 2},
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		wantCt string
+		want   string
+	}{
+		{
+			name:   "json",
+			format: "json",
+			wantCt: "application/json",
+			want:   `[{"name":"a","count":1,"tags":["x","y"]},{"name":"b","count":2}]` + "\n",
+		},
+		{
+			name:   "yaml",
+			format: "yaml",
+			wantCt: "application/yaml",
+			want:   "- count: 1\n  name: a\n  tags:\n    - x\n    - \"y\"\n- count: 2\n  name: b\n",
+		},
+		{
+			name:   "xml",
+			format: "xml",
+			wantCt: "application/xml",
+			want: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				"<records>\n" +
+				"  <record>\n    <count>1</count>\n    <name>a</name>\n    <tags>\n      <item>x</item>\n      <item>y</item>\n    </tags>\n  </record>\n" +
+				"  <record>\n    <count>2</count>\n    <name>b</name>\n  </record>\n" +
+				"</records>\n",
+		},
+		{
+			name:   "csv",
+			format: "csv",
+			wantCt: "text/csv",
+			want:   "count,name,tags\n1,a,\"[\"\"x\"\",\"\"y\"\"]\"\n2,b,\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/?format="+tt.format, nil)
+
+			rd.Render(w, r, http.StatusOK, body)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, tt.wantCt, w.Header().Get("Content-Type"))
+			require.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
+
+func TestRenderer_xml_Maps(t *testing.T) {
+	type node struct {
+		Fqdn    string                        `json:"fqdn"`
+		Labels  *map[string]string            `json:"labels,omitempty"`
+		Metrics map[string]map[string]float64 `json:"metrics,omitempty"`
+	}
+	rd := Renderer{Root: "node"}
+
+	// The keys of the maps are not element names, so they are rendered as attributes of entries.
+	bdy, err := rd.xml(&node{
+		Fqdn:   "node1",
+		Labels: &map[string]string{"team/owner": "platform", "1st": "a<b", "role": "web"},
+		Metrics: map[string]map[string]float64{
+			"2024-02-13": {"resources.out_of_sync": 2},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		"<node>\n"+
+		"  <fqdn>node1</fqdn>\n"+
+		"  <labels>\n"+
+		"    <entry key=\"1st\">a&lt;b</entry>\n"+
+		"    <entry key=\"role\">web</entry>\n"+
+		"    <entry key=\"team/owner\">platform</entry>\n"+
+		"  </labels>\n"+
+		"  <metrics>\n"+
+		"    <entry key=\"2024-02-13\">\n"+
+		"      <entry key=\"resources.out_of_sync\">2</entry>\n"+
+		"    </entry>\n"+
+		"  </metrics>\n"+
+		"</node>\n", string(bdy))
+
+	// The rendered XML is well-formed.
+	dec := xml.NewDecoder(bytes.NewReader(bdy))
+	for {
+		_, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestRenderer_Render_NotAcceptable(t *testing.T) {
+	rd := Renderer{Root: "record"}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/?format=csv", nil)
+
+	rd.Render(w, r, http.StatusOK, renderRecord{Name: "a"})
+
+	require.Equal(t, http.StatusNotAcceptable, w.Code)
+	require.JSONEq(t, `{"message":"Unsupported response format, supported formats are json, yaml, xml"}`, w.Body.String())
+}
+
+func TestRenderer_csv_NotTabular(t *testing.T) {
+	rd := Renderer{Tabular: true}
+
+	_, err := rd.csv(renderRecord{Name: "a"})
+	require.ErrorIs(t, err, ErrNotTabular)
+
+	_, err = rd.csv([]string{"a"})
+	require.ErrorIs(t, err, ErrNotTabular)
+}
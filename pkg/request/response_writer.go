@@ -47,6 +47,12 @@ func (c *ClientWriter) WriteHeader(code int) {
 	c.statusCode = code
 }
 
+// Unwrap returns the underlying http.ResponseWriter, so that http.ResponseController can reach it, such as to flush
+// a stream.
+func (c *ClientWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
 // StatusCode returns the status code.
 func (c *ClientWriter) StatusCode() int {
 	if !c.isHeaderWritten || c.statusCode == 0 {
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// JobRuns is the number of times each scheduled job has been triggered, by outcome.
+var JobRuns = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "scheduled_job_runs_total",
+		Help: "Number of times a scheduled job has been triggered, by outcome",
+	},
+	[]string{"job", "outcome"},
+)
+
+// JobLastRun is the time each scheduled job last ran on this instance.
+var JobLastRun = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "scheduled_job_last_run_timestamp_seconds",
+		Help: "Unix time a scheduled job last ran on this instance",
+	},
+	[]string{"job"},
+)
+
+// JobLastDuration is the duration of the last run of each scheduled job on this instance.
+var JobLastDuration = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "scheduled_job_last_duration_seconds",
+		Help: "Duration of the last run of a scheduled job on this instance",
+	},
+	[]string{"job"},
+)
+
+// JobLastSuccess is whether the last run of each scheduled job on this instance succeeded.
+var JobLastSuccess = promauto.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "scheduled_job_last_success",
+		Help: "Whether the last run of a scheduled job on this instance succeeded (1) or failed (0)",
+	},
+	[]string{"job"},
+)
@@ -0,0 +1,493 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/robfig/cron/v3"
+)
+
+// defaultLockTTL is how long the lock for a job is held for while it runs. The lock is renewed while the holder runs
+// the job, so the ttl only matters if the holder exits without releasing it.
+const defaultLockTTL = time.Hour
+
+// defaultLockHold is how long the lock for a job is kept after the job has run, so that the other instances skip the
+// same trigger without blocking a run of the job started through the API for long.
+const defaultLockHold = time.Minute
+
+// ErrLocked is returned when a job is run while it is already running, on this instance or another.
+var ErrLocked = errors.New("job is already running")
+
+// errLockLost is the cause of the cancellation of a job whose lock was taken over by another instance.
+var errLockLost = errors.New("job lock lost to another instance")
+
+// Locker is used to make sure only one instance runs a scheduled job, and to share the outcome of the runs between
+// the instances.
+type Locker interface {
+	// AcquireLock acquires the named lock for the holder, until the ttl expires. Returns whether the lock is held.
+	AcquireLock(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+
+	// ReleaseLock releases the named lock, if it is held by the holder.
+	ReleaseLock(ctx context.Context, name, holder string) error
+
+	// SaveJobRun saves the outcome of the last run of a job, replacing the previous run of the job.
+	SaveJobRun(ctx context.Context, run *entities.JobRun) error
+
+	// GetJobRuns returns the outcome of the last run of each job.
+	GetJobRuns(ctx context.Context) ([]*entities.JobRun, error)
+}
+
+// Job is a function that is run on a schedule.
+type Job func(ctx context.Context) error
+
+// Outcome is the outcome of a run of a scheduled job.
+type Outcome string
+
+const (
+	// OutcomeSuccess is when the job ran and succeeded.
+	OutcomeSuccess Outcome = "SUCCESS"
+
+	// OutcomeFailure is when the job ran and failed.
+	OutcomeFailure Outcome = "FAILURE"
+
+	// OutcomeSkipped is when the job did not run, as another instance holds the lock.
+	OutcomeSkipped Outcome = "SKIPPED"
+)
+
+// JobStatus is the status of a scheduled job. With a Locker, the last run is the last run on any instance.
+type JobStatus struct {
+	// Name is the name of the job.
+	Name string
+
+	// Schedule is the cron schedule of the job.
+	Schedule string
+
+	// LastRun is the time the job was last triggered.
+	LastRun time.Time
+
+	// LastInstance is the instance that last ran the job.
+	LastInstance string
+
+	// LastDuration is how long the last run of the job took.
+	LastDuration time.Duration
+
+	// LastOutcome is the outcome of the last run of the job.
+	LastOutcome Outcome
+
+	// LastError is the error returned by the last run of the job, if it failed.
+	LastError string
+
+	// NextRun is the time the job will next be triggered.
+	NextRun time.Time
+}
+
+type job struct {
+	// fn is the function to run.
+	fn Job
+
+	// entryID is the ID of the job in the cron scheduler.
+	entryID cron.EntryID
+
+	// status is the status of the job.
+	status JobStatus
+}
+
+// Scheduler runs jobs on a cron schedule. If a Locker is provided, a job is only run by the instance that holds the
+// lock for the job.
+type Scheduler struct {
+	cron *cron.Cron
+
+	// locker is used to elect the instance that runs a job. If nil, every instance runs the jobs.
+	locker Locker
+
+	// holder identifies this instance when acquiring locks.
+	holder string
+
+	// lockTTL is how long the lock for a job is held for.
+	lockTTL time.Duration
+
+	// renewEvery is how often the lock for a running job is renewed.
+	renewEvery time.Duration
+
+	// lockHold is how long the lock for a job is kept after the job has run.
+	lockHold time.Duration
+
+	// mtx guards the jobs.
+	mtx *sync.RWMutex
+
+	// jobs are the jobs that have been scheduled, keyed by name.
+	jobs map[string]*job
+
+	// running are the names of the jobs running on this instance, whether scheduled or run through RunExclusive.
+	running map[string]bool
+}
+
+// New creates a new Scheduler.
+func New(locker Locker) *Scheduler {
+	return &Scheduler{
+		cron: cron.New(
+			cron.WithLocation(time.UTC),
+			cron.WithParser(
+				cron.NewParser(
+					cron.Minute|cron.Hour|cron.Dom|cron.Month|cron.Dow|cron.Descriptor,
+				),
+			),
+			cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)),
+		),
+		locker:     locker,
+		holder:     holderID(),
+		lockTTL:    defaultLockTTL,
+		renewEvery: defaultLockTTL / 3,
+		lockHold:   defaultLockHold,
+		mtx:        new(sync.RWMutex),
+		jobs:       make(map[string]*job),
+		running:    make(map[string]bool),
+	}
+}
+
+// holderID returns the ID that identifies this instance when acquiring locks.
+func holderID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Holder returns the ID that identifies this instance when acquiring locks.
+func (s *Scheduler) Holder() string {
+	if s == nil {
+		return holderID()
+	}
+	return s.holder
+}
+
+// AddJob schedules the job to run on the given cron schedule.
+func (s *Scheduler) AddJob(name, schedule string, fn Job) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("job %s already scheduled", name)
+	}
+
+	j := &job{
+		fn: fn,
+		status: JobStatus{
+			Name:     name,
+			Schedule: schedule,
+		},
+	}
+
+	id, err := s.cron.AddFunc(schedule, func() {
+		s.run(j)
+	})
+	if err != nil {
+		return fmt.Errorf("error adding job %s to cron scheduler: %w", name, err)
+	}
+
+	j.entryID = id
+	s.jobs[name] = j
+
+	return nil
+}
+
+// Start starts the scheduler in the background.
+func (s *Scheduler) Start() {
+	s.cron.Start()
+	slog.Info("Cron scheduler started", slog.String("holder", s.holder))
+}
+
+// Stop stops the scheduler, waits for any running jobs and releases the locks held by this instance.
+func (s *Scheduler) Stop(ctx context.Context) {
+	<-s.cron.Stop().Done()
+
+	if s.locker == nil {
+		return
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	for name := range s.jobs {
+		if err := s.locker.ReleaseLock(ctx, lockName(name), s.holder); err != nil {
+			slog.Warn("Error releasing job lock", slog.String("job", name), slog.String(logging.KeyError, err.Error()))
+		}
+	}
+}
+
+// Status returns the status of the scheduled jobs, ordered by name. With a Locker, the last run of a job is the
+// latest of the run stored by the instance that ran it and the run on this instance.
+func (s *Scheduler) Status(ctx context.Context) ([]*JobStatus, error) {
+	if s == nil {
+		return []*JobStatus{}, nil
+	}
+
+	stored := make(map[string]*entities.JobRun)
+	if s.locker != nil {
+		runs, err := s.locker.GetJobRuns(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting job runs: %w", err)
+		}
+		for _, run := range runs {
+			stored[run.Name] = run
+		}
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	statuses := make([]*JobStatus, 0, len(s.jobs))
+	for name, j := range s.jobs {
+		status := j.status
+		status.NextRun = s.cron.Entry(j.entryID).Next
+
+		// A skipped run is only the trigger on this instance, so the run on the instance that held the lock is shown.
+		// The stored times are truncated to the second.
+		if run, ok := stored[name]; ok && (status.LastOutcome == OutcomeSkipped ||
+			!run.StartedAt.Time().Before(status.LastRun.Truncate(time.Second))) {
+			status.LastRun = run.StartedAt.Time()
+			status.LastInstance = run.Instance
+			status.LastDuration = run.Duration.Time()
+			status.LastOutcome = Outcome(run.Outcome)
+			status.LastError = run.Error
+		}
+
+		statuses = append(statuses, &status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+
+	return statuses, nil
+}
+
+// run runs the job, if this instance holds the lock for it.
+func (s *Scheduler) run(j *job) {
+	ctx := context.Background()
+	name := j.status.Name
+	start := time.Now().UTC()
+
+	var err error
+	outcome := OutcomeSuccess
+
+	if !s.begin(name) {
+		slog.Info("Job already running on this instance, skipping", slog.String("job", name))
+		s.record(ctx, j, start, time.Since(start), OutcomeSkipped, nil)
+		return
+	}
+	defer s.end(name)
+
+	held := true
+	if s.locker != nil {
+		held, err = s.locker.AcquireLock(ctx, lockName(name), s.holder, s.lockTTL)
+		if err != nil {
+			err = fmt.Errorf("error acquiring lock: %w", err)
+		}
+	}
+
+	switch {
+	case err != nil:
+		outcome = OutcomeFailure
+	case !held:
+		outcome = OutcomeSkipped
+		slog.Info("Job lock held by another instance, skipping", slog.String("job", name))
+	default:
+		if s.locker != nil {
+			defer s.hold(name)
+		}
+
+		slog.Info("Running scheduled job", slog.String("job", name))
+		err = s.runLocked(ctx, name, j.fn)
+		if err != nil {
+			outcome = OutcomeFailure
+		}
+	}
+
+	duration := time.Since(start)
+	if err != nil {
+		slog.Error("Scheduled job failed", slog.String("job", name), slog.String(logging.KeyError, err.Error()))
+	}
+
+	s.record(ctx, j, start, duration, outcome, err)
+}
+
+// RunExclusive runs the function as the named job outside of its schedule. The lock for the job is held as for a
+// scheduled run, so the function never runs at the same time as the job on any instance. Returns ErrLocked if the
+// job is already running.
+func (s *Scheduler) RunExclusive(ctx context.Context, name string, fn Job) error {
+	if s == nil {
+		return fn(ctx)
+	}
+
+	if !s.begin(name) {
+		return ErrLocked
+	}
+	defer s.end(name)
+
+	if s.locker != nil {
+		held, err := s.locker.AcquireLock(ctx, lockName(name), s.holder, s.lockTTL)
+		if err != nil {
+			return fmt.Errorf("error acquiring lock: %w", err)
+		} else if !held {
+			return ErrLocked
+		}
+		defer s.hold(name)
+	}
+
+	return s.runLocked(ctx, name, fn)
+}
+
+// begin marks the job as running on this instance. Returns false if it is already running.
+func (s *Scheduler) begin(name string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.running[name] {
+		return false
+	}
+	s.running[name] = true
+	return true
+}
+
+// end marks the job as no longer running on this instance.
+func (s *Scheduler) end(name string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.running, name)
+}
+
+// hold shortens the lock for the job, once it has run, to expire after the lock hold.
+func (s *Scheduler) hold(name string) {
+	if _, err := s.locker.AcquireLock(context.Background(), lockName(name), s.holder, s.lockHold); err != nil {
+		slog.Warn("Error shortening job lock", slog.String("job", name), slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// runLocked runs the job function, renewing the lock for the job until the function returns. If the lock is lost to
+// another instance, the context of the job is cancelled.
+func (s *Scheduler) runLocked(ctx context.Context, name string, fn Job) error {
+	if s.locker == nil {
+		return s.runJob(ctx, fn)
+	}
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		s.renewLock(ctx, name, done, cancel)
+	}()
+
+	err := s.runJob(jobCtx, fn)
+	close(done)
+	<-renewed
+
+	if err != nil && errors.Is(context.Cause(jobCtx), errLockLost) {
+		return fmt.Errorf("%w: %w", errLockLost, err)
+	}
+	return err
+}
+
+// renewLock renews the lock for the job until done is closed. If the lock is held by another instance, the job is
+// cancelled. A failure to renew the lock is retried, as the lock is held until the ttl expires.
+func (s *Scheduler) renewLock(ctx context.Context, name string, done <-chan struct{}, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(s.renewEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		held, err := s.locker.AcquireLock(ctx, lockName(name), s.holder, s.lockTTL)
+		switch {
+		case err != nil:
+			slog.Warn("Error renewing job lock", slog.String("job", name), slog.String(logging.KeyError, err.Error()))
+		case !held:
+			slog.Error("Job lock lost to another instance, cancelling job", slog.String("job", name))
+			cancel(errLockLost)
+			return
+		}
+	}
+}
+
+// runJob runs the job function, recovering from any panics.
+func (s *Scheduler) runJob(ctx context.Context, fn Job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("job panicked: %v", rec)
+		}
+	}()
+
+	if fn == nil {
+		return errors.New("no job function")
+	}
+
+	return fn(ctx)
+}
+
+// record updates the status and metrics of the job. With a Locker, the runs are stored for the other instances.
+func (s *Scheduler) record(ctx context.Context, j *job, start time.Time, duration time.Duration, outcome Outcome, err error) {
+	name := j.status.Name
+
+	JobRuns.WithLabelValues(name, string(outcome)).Inc()
+	if outcome != OutcomeSkipped {
+		JobLastRun.WithLabelValues(name).Set(float64(start.Unix()))
+		JobLastDuration.WithLabelValues(name).Set(duration.Seconds())
+		if outcome == OutcomeSuccess {
+			JobLastSuccess.WithLabelValues(name).Set(1)
+		} else {
+			JobLastSuccess.WithLabelValues(name).Set(0)
+		}
+	}
+
+	errStr := ""
+	if err != nil {
+		errStr = err.Error()
+	}
+
+	// A skipped run is not stored, so that it does not hide the run on the instance that held the lock.
+	if s.locker != nil && outcome != OutcomeSkipped {
+		if saveErr := s.locker.SaveJobRun(ctx, &entities.JobRun{
+			Name:      name,
+			Instance:  s.holder,
+			StartedAt: entities.Datetime(start),
+			Duration:  entities.Duration(duration),
+			Outcome:   string(outcome),
+			Error:     errStr,
+		}); saveErr != nil {
+			slog.Warn("Error saving job run", slog.String("job", name), slog.String(logging.KeyError, saveErr.Error()))
+		}
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	j.status.LastRun = start
+	j.status.LastInstance = ""
+	if outcome != OutcomeSkipped {
+		j.status.LastInstance = s.holder
+	}
+	j.status.LastDuration = duration
+	j.status.LastOutcome = outcome
+	j.status.LastError = errStr
+}
+
+// lockName returns the name of the lock for the job.
+func lockName(job string) string {
+	return "job_" + job
+}
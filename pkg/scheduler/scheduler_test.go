@@ -0,0 +1,351 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLocker struct {
+	mtx sync.Mutex
+
+	held bool
+	err  error
+
+	// loseAfter is the number of acquisitions after which the lock is held by another instance, if not zero.
+	loseAfter int
+
+	acquired []string
+	ttls     []time.Duration
+	released []string
+	runs     map[string]*entities.JobRun
+}
+
+func (f *fakeLocker) AcquireLock(_ context.Context, name, _ string, ttl time.Duration) (bool, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.acquired = append(f.acquired, name)
+	f.ttls = append(f.ttls, ttl)
+	if f.loseAfter > 0 && len(f.acquired) > f.loseAfter {
+		return false, nil
+	}
+	return f.held, f.err
+}
+
+func (f *fakeLocker) ReleaseLock(_ context.Context, name, _ string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.released = append(f.released, name)
+	return nil
+}
+
+func (f *fakeLocker) SaveJobRun(_ context.Context, run *entities.JobRun) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if f.runs == nil {
+		f.runs = make(map[string]*entities.JobRun)
+	}
+	cp := *run
+	cp.StartedAt = entities.Datetime(cp.StartedAt.Time().Truncate(time.Second))
+	f.runs[run.Name] = &cp
+	return nil
+}
+
+func (f *fakeLocker) GetJobRuns(_ context.Context) ([]*entities.JobRun, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	runs := make([]*entities.JobRun, 0, len(f.runs))
+	for _, run := range f.runs {
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// acquisitions returns the number of times a lock has been acquired.
+func (f *fakeLocker) acquisitions() int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return len(f.acquired)
+}
+
+func TestScheduler_Run(t *testing.T) {
+	tests := []struct {
+		name        string
+		locker      *fakeLocker
+		jobErr      error
+		wantOutcome Outcome
+		wantError   string
+		wantRan     bool
+	}{
+		{
+			name:        "success",
+			locker:      &fakeLocker{held: true},
+			wantOutcome: OutcomeSuccess,
+			wantRan:     true,
+		},
+		{
+			name:        "job failed",
+			locker:      &fakeLocker{held: true},
+			jobErr:      errors.New("boom"),
+			wantOutcome: OutcomeFailure,
+			wantError:   "boom",
+			wantRan:     true,
+		},
+		{
+			name:        "lock held by another instance",
+			locker:      &fakeLocker{held: false},
+			wantOutcome: OutcomeSkipped,
+			wantRan:     false,
+		},
+		{
+			name:        "lock error",
+			locker:      &fakeLocker{err: errors.New("db down")},
+			wantOutcome: OutcomeFailure,
+			wantError:   "error acquiring lock: db down",
+			wantRan:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.locker)
+
+			ran := false
+			err := s.AddJob("purge", "0 3 * * *", func(_ context.Context) error {
+				ran = true
+				return tt.jobErr
+			})
+			require.NoError(t, err)
+
+			s.run(s.jobs["purge"])
+
+			require.Equal(t, tt.wantRan, ran)
+			require.Equal(t, "job_purge", tt.locker.acquired[0])
+			if tt.wantRan {
+				// Once the job has run, the lock is only kept long enough for the other instances to skip the trigger.
+				require.Equal(t, []time.Duration{defaultLockTTL, defaultLockHold}, tt.locker.ttls)
+			} else {
+				require.Len(t, tt.locker.acquired, 1)
+			}
+
+			statuses, err := s.Status(context.Background())
+			require.NoError(t, err)
+			require.Len(t, statuses, 1)
+			require.Equal(t, "purge", statuses[0].Name)
+			require.Equal(t, "0 3 * * *", statuses[0].Schedule)
+			require.Equal(t, tt.wantOutcome, statuses[0].LastOutcome)
+			require.Equal(t, tt.wantError, statuses[0].LastError)
+			require.False(t, statuses[0].LastRun.IsZero())
+		})
+	}
+}
+
+func TestScheduler_RunWithoutLocker(t *testing.T) {
+	s := New(nil)
+
+	ran := false
+	require.NoError(t, s.AddJob("purge", "@daily", func(_ context.Context) error {
+		ran = true
+		return nil
+	}))
+
+	s.run(s.jobs["purge"])
+
+	require.True(t, ran)
+	statuses, err := s.Status(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, OutcomeSuccess, statuses[0].LastOutcome)
+	require.Equal(t, s.holder, statuses[0].LastInstance)
+}
+
+func TestScheduler_StatusSharedBetweenInstances(t *testing.T) {
+	locker := &fakeLocker{held: true}
+
+	pod1 := New(locker)
+	pod1.holder = "pod1"
+	require.NoError(t, pod1.AddJob("purge", "@daily", func(_ context.Context) error {
+		return errors.New("boom")
+	}))
+
+	pod2 := New(locker)
+	pod2.holder = "pod2"
+	require.NoError(t, pod2.AddJob("purge", "@daily", func(_ context.Context) error {
+		t.Fatal("job ran on the instance without the lock")
+		return nil
+	}))
+
+	pod1.run(pod1.jobs["purge"])
+
+	// The other instance skips the same trigger, but reports the run on the instance that held the lock.
+	locker.held = false
+	pod2.run(pod2.jobs["purge"])
+
+	statuses, err := pod2.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	require.Equal(t, OutcomeFailure, statuses[0].LastOutcome)
+	require.Equal(t, "pod1", statuses[0].LastInstance)
+	require.Equal(t, "boom", statuses[0].LastError)
+	require.False(t, statuses[0].LastRun.IsZero())
+}
+
+func TestScheduler_RunRenewsLock(t *testing.T) {
+	locker := &fakeLocker{held: true}
+	s := New(locker)
+	s.renewEvery = time.Millisecond
+
+	// The job runs until the lock has been renewed a few times.
+	require.NoError(t, s.AddJob("purge", "@daily", func(ctx context.Context) error {
+		for locker.acquisitions() < 3 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Millisecond):
+			}
+		}
+		return nil
+	}))
+
+	s.run(s.jobs["purge"])
+
+	statuses, err := s.Status(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, OutcomeSuccess, statuses[0].LastOutcome)
+	require.GreaterOrEqual(t, locker.acquisitions(), 3)
+}
+
+func TestScheduler_RunLockLost(t *testing.T) {
+	// The lock is taken over by another instance after it is first acquired.
+	locker := &fakeLocker{held: true, loseAfter: 1}
+	s := New(locker)
+	s.renewEvery = time.Millisecond
+
+	require.NoError(t, s.AddJob("purge", "@daily", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	s.run(s.jobs["purge"])
+
+	statuses, err := s.Status(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, OutcomeFailure, statuses[0].LastOutcome)
+	require.Equal(t, "job lock lost to another instance: context canceled", statuses[0].LastError)
+}
+
+func TestScheduler_RunExclusive(t *testing.T) {
+	locker := &fakeLocker{held: true}
+	s := New(locker)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.RunExclusive(context.Background(), "purge", func(_ context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	// The job cannot run again on this instance while it is running, even though this instance holds the lock.
+	require.ErrorIs(t, s.RunExclusive(context.Background(), "purge", func(_ context.Context) error {
+		t.Fatal("job ran twice at the same time")
+		return nil
+	}), ErrLocked)
+
+	close(release)
+	require.NoError(t, <-errs)
+
+	locker.mtx.Lock()
+	require.Equal(t, []string{"job_purge", "job_purge"}, locker.acquired)
+	require.Equal(t, []time.Duration{defaultLockTTL, defaultLockHold}, locker.ttls)
+	locker.mtx.Unlock()
+
+	// Another instance holds the lock, so the job does not run.
+	locker.held = false
+	require.ErrorIs(t, s.RunExclusive(context.Background(), "purge", func(_ context.Context) error {
+		t.Fatal("job ran without the lock")
+		return nil
+	}), ErrLocked)
+}
+
+func TestScheduler_RunExclusiveAfterHold(t *testing.T) {
+	db, err := dataaccess.NewSQLiteFile(filepath.Join(t.TempDir(), "puppet-summary.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close(context.Background()))
+	})
+
+	first := New(db)
+	first.holder = "instance-1"
+	first.lockHold = time.Second
+	second := New(db)
+	second.holder = "instance-2"
+
+	noop := func(_ context.Context) error { return nil }
+	require.NoError(t, first.RunExclusive(context.Background(), "purge", noop))
+
+	// The lock is held for a while after the run, so the other instance skips the same trigger.
+	require.ErrorIs(t, second.RunExclusive(context.Background(), "purge", noop), ErrLocked)
+
+	// The other instance runs the job once the hold has expired.
+	require.Eventually(t, func() bool {
+		return second.RunExclusive(context.Background(), "purge", noop) == nil
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestScheduler_RunSkippedWhileRunExclusive(t *testing.T) {
+	s := New(&fakeLocker{held: true})
+
+	ran := false
+	require.NoError(t, s.AddJob("purge", "@daily", func(_ context.Context) error {
+		ran = true
+		return nil
+	}))
+
+	require.NoError(t, s.RunExclusive(context.Background(), "purge", func(_ context.Context) error {
+		s.run(s.jobs["purge"])
+		return nil
+	}))
+
+	require.False(t, ran)
+	statuses, err := s.Status(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, OutcomeSkipped, statuses[0].LastOutcome)
+}
+
+func TestScheduler_AddJobInvalidSchedule(t *testing.T) {
+	s := New(nil)
+
+	err := s.AddJob("purge", "not a schedule", nil)
+	require.Error(t, err)
+	statuses, err := s.Status(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, statuses)
+}
+
+func TestScheduler_AddJobDuplicate(t *testing.T) {
+	s := New(nil)
+
+	require.NoError(t, s.AddJob("purge", "@daily", nil))
+	require.EqualError(t, s.AddJob("purge", "@daily", nil), "job purge already scheduled")
+}
+
+func TestScheduler_StopReleasesLocks(t *testing.T) {
+	locker := &fakeLocker{held: true}
+	s := New(locker)
+	require.NoError(t, s.AddJob("purge", "@daily", nil))
+
+	s.Start()
+	s.Stop(context.Background())
+
+	require.Equal(t, []string{"job_purge"}, locker.released)
+}
@@ -0,0 +1,52 @@
+// Package aggregate holds the helpers shared by the services that aggregate the runs of the nodes over a window of
+// time.
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+)
+
+// ErrInvalidOptions is returned when the options of an aggregation are out of range.
+var ErrInvalidOptions = errors.New("invalid options")
+
+// Window returns the window from and to, with the defaults filled in: to defaults to now, and from to the given length
+// before to. Returns an error if the window is empty, or if one of the environments it is in is invalid.
+func Window(from, to, now time.Time, length time.Duration, envs ...summary.Environment) (time.Time, time.Time, error) {
+	if to.IsZero() {
+		to = now
+	}
+	if from.IsZero() {
+		from = to.Add(-length)
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be before to", ErrInvalidOptions)
+	}
+	for _, env := range envs {
+		if !env.IsValid() {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid environment %s", ErrInvalidOptions, env)
+		}
+	}
+	return from, to, nil
+}
+
+// Median returns the median of the durations, averaging the middle two of an even number, or 0 if there are none.
+func Median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
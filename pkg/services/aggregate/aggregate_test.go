@@ -0,0 +1,98 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to time.Time
+		envs     []summary.Environment
+		wantFrom time.Time
+		wantTo   time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "defaults",
+			wantFrom: testNow.Add(-time.Hour),
+			wantTo:   testNow,
+		},
+		{
+			name:     "from before to",
+			to:       testNow.Add(-time.Hour),
+			wantFrom: testNow.Add(-2 * time.Hour),
+			wantTo:   testNow.Add(-time.Hour),
+		},
+		{
+			name:     "given",
+			from:     testNow.Add(-3 * time.Hour),
+			to:       testNow.Add(-time.Hour),
+			envs:     []summary.Environment{summary.Environment_PRODUCTION},
+			wantFrom: testNow.Add(-3 * time.Hour),
+			wantTo:   testNow.Add(-time.Hour),
+		},
+		{
+			name:    "empty",
+			from:    testNow,
+			to:      testNow,
+			wantErr: true,
+		},
+		{
+			name:    "from after now",
+			from:    testNow.Add(time.Hour),
+			wantErr: true,
+		},
+		{
+			name:    "invalid environment",
+			envs:    []summary.Environment{"invalid"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := Window(tt.from, tt.to, testNow, time.Hour, tt.envs...)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidOptions)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantFrom, from)
+			require.Equal(t, tt.wantTo, to)
+		})
+	}
+}
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      time.Duration
+	}{
+		{
+			name: "empty",
+			want: 0,
+		},
+		{
+			name:      "odd",
+			durations: []time.Duration{3 * time.Second, time.Second, 2 * time.Second},
+			want:      2 * time.Second,
+		},
+		{
+			name:      "even",
+			durations: []time.Duration{4 * time.Second, time.Second, 2 * time.Second, 3 * time.Second},
+			want:      2500 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Median(tt.durations))
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// collectInterval is how often the failing nodes are counted.
+	collectInterval = time.Minute
+
+	// collectTimeout is how long the failing nodes can take to be counted.
+	collectTimeout = 10 * time.Second
+)
+
+// failingNodesCollector counts the nodes whose latest run failed, per environment. The nodes are counted in the
+// background, so that a scrape does not load the runs from the database.
+type failingNodesCollector struct {
+	db dataaccess.Database
+
+	// silencer is used to count the muted nodes apart.
+	silencer Silencer
+
+	// failing is the number of failing nodes that are not muted.
+	failing *prometheus.Desc
+
+	// muted is the number of failing nodes that are acknowledged or silenced.
+	muted *prometheus.Desc
+
+	// mtx guards the counts.
+	mtx sync.RWMutex
+
+	// failingCount and mutedCount are the latest counts, per environment. Nil until the nodes have been counted.
+	failingCount, mutedCount map[summary.Environment]int
+
+	// err is the error of the latest count, if it failed.
+	err error
+}
+
+// NewFailingNodesCollector creates a collector of the number of nodes whose latest run failed, per environment. The
+// acknowledged and silenced nodes are left out, and counted on their own. The nodes are counted every minute until
+// the context is cancelled.
+func NewFailingNodesCollector(ctx context.Context, db dataaccess.Database, silencer Silencer) prometheus.Collector {
+	c := newFailingNodesCollector(db, silencer)
+	go c.run(ctx, collectInterval)
+	return c
+}
+
+func newFailingNodesCollector(db dataaccess.Database, silencer Silencer) *failingNodesCollector {
+	return &failingNodesCollector{
+		db:       db,
+		silencer: silencer,
+		failing: prometheus.NewDesc(
+			"failing_nodes",
+			"Number of nodes whose latest run failed, that are not acknowledged or silenced",
+			[]string{"environment"}, nil,
+		),
+		muted: prometheus.NewDesc(
+			"muted_failing_nodes",
+			"Number of nodes whose latest run failed, that are acknowledged or silenced",
+			[]string{"environment"}, nil,
+		),
+	}
+}
+
+// run counts the nodes on every interval until the context is cancelled.
+func (c *failingNodesCollector) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh counts the nodes, replacing the counts reported on a scrape.
+func (c *failingNodesCollector) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, collectTimeout)
+	defer cancel()
+
+	failing, muted, err := c.count(ctx)
+	if err != nil {
+		slog.Warn("Error counting failing nodes", slog.String(logging.KeyError, err.Error()))
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.failingCount, c.mutedCount, c.err = failing, muted, err
+}
+
+func (c *failingNodesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.failing
+	ch <- c.muted
+}
+
+func (c *failingNodesCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	if c.err != nil {
+		ch <- prometheus.NewInvalidMetric(c.failing, c.err)
+		ch <- prometheus.NewInvalidMetric(c.muted, c.err)
+		return
+	}
+
+	for env, n := range c.failingCount {
+		ch <- prometheus.MustNewConstMetric(c.failing, prometheus.GaugeValue, float64(n), string(env))
+	}
+	for env, n := range c.mutedCount {
+		ch <- prometheus.MustNewConstMetric(c.muted, prometheus.GaugeValue, float64(n), string(env))
+	}
+}
+
+// count returns the number of failing nodes that are not muted, and that are muted, per environment. Every
+// environment with a listed node is counted, so that the gauges drop to zero rather than disappear.
+func (c *failingNodesCollector) count(ctx context.Context) (failing, muted map[summary.Environment]int, err error) {
+	runs, err := c.db.GetRuns(ctx)
+	if err != nil && !errors.Is(err, dataaccess.ErrNotFound) {
+		return nil, nil, fmt.Errorf("error getting runs: %w", err)
+	}
+
+	runs, err = nodes.Listed(ctx, c.db, runs)
+	if err != nil {
+		return nil, nil, err
+	}
+	runs = nodes.Latest(runs)
+
+	mutes, err := c.silencer.Muted(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	failing = make(map[summary.Environment]int)
+	muted = make(map[summary.Environment]int)
+	for _, run := range runs {
+		if _, ok := failing[run.Env]; !ok {
+			failing[run.Env] = 0
+			muted[run.Env] = 0
+		}
+		if run.State != summary.State_FAILED {
+			continue
+		}
+
+		if mutes.For(run.Fqdn) != nil {
+			muted[run.Env]++
+		} else {
+			failing[run.Env]++
+		}
+	}
+
+	return failing, muted, nil
+}
@@ -0,0 +1,76 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailingNodesCollector(t *testing.T) {
+	svc, db := newTestService(t, "node1", "node2", "node3")
+	ctx := context.Background()
+
+	// node4 is in another environment and has not failed.
+	require.NoError(t, db.SaveRun(ctx, &entities.PuppetReport{
+		ID:       "report-node4",
+		Fqdn:     "node4",
+		Env:      summary.Environment_STAGING,
+		State:    summary.State_CHANGED,
+		ExecTime: entities.Datetime(testNow),
+	}))
+
+	// node5 has recovered since it failed, so it is not failing.
+	require.NoError(t, db.SaveRun(ctx, &entities.PuppetReport{
+		ID:       "report-node5-failed",
+		Fqdn:     "node5",
+		Env:      summary.Environment_PRODUCTION,
+		State:    summary.State_FAILED,
+		ExecTime: entities.Datetime(testNow.Add(-time.Hour)),
+	}))
+	require.NoError(t, db.SaveRun(ctx, &entities.PuppetReport{
+		ID:       "report-node5",
+		Fqdn:     "node5",
+		Env:      summary.Environment_PRODUCTION,
+		State:    summary.State_CHANGED,
+		ExecTime: entities.Datetime(testNow),
+	}))
+
+	// node3 was decommissioned, so it is not counted at all.
+	require.NoError(t, db.DecommissionNode(ctx, "node3", testNow))
+
+	_, err := svc.Acknowledge(ctx, "node1", "alice", "Looking into it", testNow.Add(time.Hour))
+	require.NoError(t, err)
+
+	c := newFailingNodesCollector(db, svc)
+	c.refresh(ctx)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	got := make(map[string]map[string]float64)
+	for _, family := range families {
+		got[family.GetName()] = make(map[string]float64)
+		for _, m := range family.GetMetric() {
+			got[family.GetName()][m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+		}
+	}
+
+	require.Equal(t, map[string]map[string]float64{
+		"failing_nodes": {
+			"PRODUCTION": 1,
+			"STAGING":    0,
+		},
+		"muted_failing_nodes": {
+			"PRODUCTION": 1,
+			"STAGING":    0,
+		},
+	}, got)
+}
@@ -0,0 +1,47 @@
+package alerting
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+)
+
+type Silencer interface {
+	// Acknowledge acknowledges the failure of the node with the given fqdn until the given time, replacing any existing
+	// acknowledgement of the node. Returns dataaccess.ErrNotFound if the node has no reports.
+	Acknowledge(ctx context.Context, fqdn, by, reason string, expiresAt time.Time) (*entities.Silence, error)
+
+	// Unacknowledge removes the acknowledgement of the node with the given fqdn. Returns dataaccess.ErrNotFound if the
+	// node is not acknowledged.
+	Unacknowledge(ctx context.Context, fqdn string) error
+
+	// CreateSilence creates a silence of the nodes matched by its fqdn, or its owner and labels, for a maintenance
+	// window. The ID and the creation time are set on the silence.
+	CreateSilence(ctx context.Context, silence *entities.Silence) (*entities.Silence, error)
+
+	// DeleteSilence deletes the silence or acknowledgement with the given ID. Returns dataaccess.ErrNotFound if there is
+	// no such silence.
+	DeleteSilence(ctx context.Context, id string) error
+
+	// Silences returns the silences and acknowledgements that have not ended, or every one if expired is true.
+	Silences(ctx context.Context, expired bool) ([]*entities.Silence, error)
+
+	// Muted returns the silences and acknowledgements in effect now, to look up which nodes are muted.
+	Muted(ctx context.Context) (*Mutes, error)
+}
+
+type service struct {
+	db dataaccess.Database
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+func NewService(db dataaccess.Database) Silencer {
+	return &service{
+		db:  db,
+		now: time.Now,
+	}
+}
@@ -0,0 +1,223 @@
+package alerting
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+)
+
+// ErrInvalidSilence is returned when a silence or acknowledgement is missing what it needs, or ends before it starts.
+var ErrInvalidSilence = errors.New("invalid silence")
+
+// ackPrefix prefixes the fqdn of a node to make the ID of its acknowledgement, so that a node only has the one.
+const ackPrefix = "ack-"
+
+func (s *service) Acknowledge(ctx context.Context, fqdn, by, reason string, expiresAt time.Time) (*entities.Silence, error) {
+	reports, err := s.db.GetReports(ctx, fqdn)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reports: %w", err)
+	} else if len(reports) == 0 {
+		return nil, dataaccess.ErrNotFound
+	}
+
+	now := s.now().UTC().Truncate(time.Second)
+	ack := &entities.Silence{
+		ID:        ackPrefix + fqdn,
+		Kind:      entities.SilenceKindAck,
+		Fqdn:      fqdn,
+		Labels:    make(entities.Labels),
+		CreatedBy: strings.TrimSpace(by),
+		Reason:    strings.TrimSpace(reason),
+		StartsAt:  entities.Datetime(now),
+		EndsAt:    entities.Datetime(expiresAt.UTC().Truncate(time.Second)),
+		CreatedAt: entities.Datetime(now),
+	}
+	if err := validate(ack, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.SaveSilence(ctx, ack); err != nil {
+		return nil, fmt.Errorf("error saving acknowledgement: %w", err)
+	}
+
+	return ack, nil
+}
+
+func (s *service) Unacknowledge(ctx context.Context, fqdn string) error {
+	if err := s.db.DeleteSilence(ctx, ackPrefix+fqdn); err != nil {
+		return fmt.Errorf("error deleting acknowledgement: %w", err)
+	}
+	return nil
+}
+
+func (s *service) CreateSilence(ctx context.Context, silence *entities.Silence) (*entities.Silence, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, fmt.Errorf("error generating silence ID: %w", err)
+	}
+
+	now := s.now().UTC().Truncate(time.Second)
+	sil := &entities.Silence{
+		ID:        id,
+		Kind:      entities.SilenceKindSilence,
+		Fqdn:      strings.TrimSpace(silence.Fqdn),
+		Owner:     strings.TrimSpace(silence.Owner),
+		Labels:    make(entities.Labels, len(silence.Labels)),
+		CreatedBy: strings.TrimSpace(silence.CreatedBy),
+		Reason:    strings.TrimSpace(silence.Reason),
+		StartsAt:  entities.Datetime(silence.StartsAt.Time().UTC().Truncate(time.Second)),
+		EndsAt:    entities.Datetime(silence.EndsAt.Time().UTC().Truncate(time.Second)),
+		CreatedAt: entities.Datetime(now),
+	}
+	for k, v := range silence.Labels {
+		sil.Labels[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+
+	// A silence without a start starts now.
+	if silence.StartsAt.Time().IsZero() {
+		sil.StartsAt = entities.Datetime(now)
+	}
+
+	if err := validate(sil, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.SaveSilence(ctx, sil); err != nil {
+		return nil, fmt.Errorf("error saving silence: %w", err)
+	}
+
+	return sil, nil
+}
+
+func (s *service) DeleteSilence(ctx context.Context, id string) error {
+	if err := s.db.DeleteSilence(ctx, id); err != nil {
+		return fmt.Errorf("error deleting silence: %w", err)
+	}
+	return nil
+}
+
+func (s *service) Silences(ctx context.Context, expired bool) ([]*entities.Silence, error) {
+	silences, err := s.db.GetSilences(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting silences: %w", err)
+	}
+
+	if expired {
+		return silences, nil
+	}
+
+	now := s.now()
+	current := make([]*entities.Silence, 0, len(silences))
+	for _, sil := range silences {
+		if !sil.Expired(now) {
+			current = append(current, sil)
+		}
+	}
+	return current, nil
+}
+
+func (s *service) Muted(ctx context.Context) (*Mutes, error) {
+	silences, err := s.db.GetSilences(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting silences: %w", err)
+	}
+
+	now := s.now()
+	mutes := new(Mutes)
+	selectors := false
+	for _, sil := range silences {
+		if !sil.Active(now) {
+			continue
+		}
+		mutes.silences = append(mutes.silences, sil)
+		selectors = selectors || sil.Fqdn == ""
+	}
+
+	// The metadata is only needed to match the silences on the owner and labels of the nodes.
+	if !selectors {
+		return mutes, nil
+	}
+
+	metadata, err := s.db.GetAllNodeMetadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting node metadata: %w", err)
+	}
+
+	mutes.metadata = make(map[string]*entities.NodeMetadata, len(metadata))
+	for _, md := range metadata {
+		mutes.metadata[md.Fqdn] = md
+	}
+
+	return mutes, nil
+}
+
+// Mutes are the silences and acknowledgements in effect at a point in time.
+type Mutes struct {
+	// silences are the silences in effect.
+	silences []*entities.Silence
+
+	// metadata is the metadata of the nodes, keyed by the fqdn, to match the silences on.
+	metadata map[string]*entities.NodeMetadata
+}
+
+// For returns the silence muting the node with the given fqdn, or nil if the node is not muted. An acknowledgement of
+// the node is returned over any silence matching it.
+func (m *Mutes) For(fqdn string) *entities.Silence {
+	if m == nil {
+		return nil
+	}
+
+	var found *entities.Silence
+	for _, sil := range m.silences {
+		if !sil.Matches(fqdn, m.metadata[fqdn]) {
+			continue
+		}
+		if sil.Kind == entities.SilenceKindAck {
+			return sil
+		}
+		if found == nil {
+			found = sil
+		}
+	}
+	return found
+}
+
+// validate checks the silence has who created it, why, and something to match, and ends after it starts and now.
+func validate(sil *entities.Silence, now time.Time) error {
+	switch {
+	case sil.CreatedBy == "":
+		return fmt.Errorf("%w: missing who created it", ErrInvalidSilence)
+	case sil.Reason == "":
+		return fmt.Errorf("%w: missing the reason", ErrInvalidSilence)
+	case sil.Fqdn == "" && sil.Owner == "" && len(sil.Labels) == 0:
+		return fmt.Errorf("%w: missing the fqdn, owner or labels to match", ErrInvalidSilence)
+	case !sil.EndsAt.Time().After(sil.StartsAt.Time()):
+		return fmt.Errorf("%w: ends before it starts", ErrInvalidSilence)
+	case !sil.EndsAt.Time().After(now):
+		return fmt.Errorf("%w: ends in the past", ErrInvalidSilence)
+	}
+
+	for k := range sil.Labels {
+		// The labels are matched as key=value, so the key can not contain an =.
+		if k == "" || strings.Contains(k, "=") {
+			return fmt.Errorf("%w: invalid label key %q", ErrInvalidSilence, k)
+		}
+	}
+	return nil
+}
+
+// newID generates a random silence ID.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,198 @@
+package alerting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+
+// newTestService returns a service over an in-memory database, with the given nodes having reported a failed run.
+func newTestService(t *testing.T, fqdns ...string) (*service, dataaccess.Database) {
+	t.Helper()
+
+	db := dataaccess.NewMemory()
+	for _, fqdn := range fqdns {
+		require.NoError(t, db.SaveRun(context.Background(), &entities.PuppetReport{
+			ID:       "report-" + fqdn,
+			Fqdn:     fqdn,
+			Env:      summary.Environment_PRODUCTION,
+			State:    summary.State_FAILED,
+			ExecTime: entities.Datetime(testNow.Add(-time.Minute)),
+		}))
+	}
+
+	return &service{
+		db:  db,
+		now: func() time.Time { return testNow },
+	}, db
+}
+
+func TestService_Acknowledge(t *testing.T) {
+	svc, _ := newTestService(t, "node1")
+	ctx := context.Background()
+
+	ack, err := svc.Acknowledge(ctx, "node1", " alice ", "Looking into it", testNow.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, "ack-node1", ack.ID)
+	require.Equal(t, entities.SilenceKindAck, ack.Kind)
+	require.Equal(t, "alice", ack.CreatedBy)
+
+	// Acknowledging again replaces the acknowledgement.
+	_, err = svc.Acknowledge(ctx, "node1", "bob", "Taking over", testNow.Add(2*time.Hour))
+	require.NoError(t, err)
+
+	silences, err := svc.Silences(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	require.Equal(t, "bob", silences[0].CreatedBy)
+
+	require.NoError(t, svc.Unacknowledge(ctx, "node1"))
+	require.ErrorIs(t, svc.Unacknowledge(ctx, "node1"), dataaccess.ErrNotFound)
+}
+
+func TestService_Acknowledge_Invalid(t *testing.T) {
+	svc, _ := newTestService(t, "node1")
+	ctx := context.Background()
+
+	_, err := svc.Acknowledge(ctx, "node2", "alice", "Looking into it", testNow.Add(time.Hour))
+	require.ErrorIs(t, err, dataaccess.ErrNotFound)
+
+	_, err = svc.Acknowledge(ctx, "node1", "", "Looking into it", testNow.Add(time.Hour))
+	require.ErrorIs(t, err, ErrInvalidSilence)
+
+	_, err = svc.Acknowledge(ctx, "node1", "alice", " ", testNow.Add(time.Hour))
+	require.ErrorIs(t, err, ErrInvalidSilence)
+
+	_, err = svc.Acknowledge(ctx, "node1", "alice", "Looking into it", testNow.Add(-time.Hour))
+	require.ErrorIs(t, err, ErrInvalidSilence)
+}
+
+func TestService_CreateSilence(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	sil, err := svc.CreateSilence(ctx, &entities.Silence{
+		Labels:    entities.Labels{"dc": "lon"},
+		CreatedBy: "alice",
+		Reason:    "Datacentre maintenance",
+		EndsAt:    entities.Datetime(testNow.Add(time.Hour)),
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, sil.ID)
+	require.Equal(t, entities.SilenceKindSilence, sil.Kind)
+	require.Equal(t, testNow, sil.StartsAt.Time())
+
+	_, err = svc.CreateSilence(ctx, &entities.Silence{
+		CreatedBy: "alice",
+		Reason:    "Everything",
+		EndsAt:    entities.Datetime(testNow.Add(time.Hour)),
+	})
+	require.ErrorIs(t, err, ErrInvalidSilence)
+
+	_, err = svc.CreateSilence(ctx, &entities.Silence{
+		Fqdn:      "node1",
+		CreatedBy: "alice",
+		Reason:    "Backwards",
+		StartsAt:  entities.Datetime(testNow.Add(2 * time.Hour)),
+		EndsAt:    entities.Datetime(testNow.Add(time.Hour)),
+	})
+	require.ErrorIs(t, err, ErrInvalidSilence)
+
+	_, err = svc.CreateSilence(ctx, &entities.Silence{
+		Labels:    entities.Labels{"dc=lon": ""},
+		CreatedBy: "alice",
+		Reason:    "Bad label",
+		EndsAt:    entities.Datetime(testNow.Add(time.Hour)),
+	})
+	require.ErrorIs(t, err, ErrInvalidSilence)
+
+	require.NoError(t, svc.DeleteSilence(ctx, sil.ID))
+	require.ErrorIs(t, svc.DeleteSilence(ctx, sil.ID), dataaccess.ErrNotFound)
+}
+
+func TestService_Silences(t *testing.T) {
+	svc, db := newTestService(t)
+	ctx := context.Background()
+
+	require.NoError(t, db.SaveSilence(ctx, &entities.Silence{
+		ID:       "ended",
+		Kind:     entities.SilenceKindSilence,
+		Fqdn:     "node1",
+		StartsAt: entities.Datetime(testNow.Add(-2 * time.Hour)),
+		EndsAt:   entities.Datetime(testNow.Add(-time.Hour)),
+	}))
+	require.NoError(t, db.SaveSilence(ctx, &entities.Silence{
+		ID:       "upcoming",
+		Kind:     entities.SilenceKindSilence,
+		Fqdn:     "node1",
+		StartsAt: entities.Datetime(testNow.Add(time.Hour)),
+		EndsAt:   entities.Datetime(testNow.Add(2 * time.Hour)),
+	}))
+
+	silences, err := svc.Silences(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, silences, 1)
+	require.Equal(t, "upcoming", silences[0].ID)
+
+	silences, err = svc.Silences(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, silences, 2)
+}
+
+func TestService_Muted(t *testing.T) {
+	svc, db := newTestService(t, "node1", "node2", "node3", "node4")
+	ctx := context.Background()
+
+	require.NoError(t, db.SaveNodeMetadata(ctx, &entities.NodeMetadata{
+		Fqdn:   "node2",
+		Labels: entities.Labels{"dc": "lon"},
+	}))
+	require.NoError(t, db.SaveNodeMetadata(ctx, &entities.NodeMetadata{
+		Fqdn:   "node3",
+		Labels: entities.Labels{"dc": "lon"},
+	}))
+
+	_, err := svc.Acknowledge(ctx, "node1", "alice", "Looking into it", testNow.Add(time.Hour))
+	require.NoError(t, err)
+	_, err = svc.Acknowledge(ctx, "node3", "alice", "Looking into it", testNow.Add(time.Hour))
+	require.NoError(t, err)
+	maintenance, err := svc.CreateSilence(ctx, &entities.Silence{
+		Labels:    entities.Labels{"dc": "lon"},
+		CreatedBy: "bob",
+		Reason:    "Datacentre maintenance",
+		EndsAt:    entities.Datetime(testNow.Add(time.Hour)),
+	})
+	require.NoError(t, err)
+
+	// node4 is only silenced later on.
+	_, err = svc.CreateSilence(ctx, &entities.Silence{
+		Fqdn:      "node4",
+		CreatedBy: "bob",
+		Reason:    "Rebuild",
+		StartsAt:  entities.Datetime(testNow.Add(time.Hour)),
+		EndsAt:    entities.Datetime(testNow.Add(2 * time.Hour)),
+	})
+	require.NoError(t, err)
+
+	mutes, err := svc.Muted(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "ack-node1", mutes.For("node1").ID)
+	require.Equal(t, maintenance.ID, mutes.For("node2").ID)
+	require.Equal(t, "ack-node3", mutes.For("node3").ID, "the acknowledgement is preferred over the silence")
+	require.Nil(t, mutes.For("node4"))
+
+	// The acknowledgements expire.
+	svc.now = func() time.Time { return testNow.Add(time.Hour) }
+	mutes, err = svc.Muted(ctx)
+	require.NoError(t, err)
+	require.Nil(t, mutes.For("node1"))
+	require.Nil(t, mutes.For("node2"))
+	require.NotNil(t, mutes.For("node4"))
+}
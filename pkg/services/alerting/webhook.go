@@ -0,0 +1,268 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+)
+
+const (
+	// EventIncidentOpened is the event sent when the failed runs of the nodes open an incident.
+	EventIncidentOpened = "incident_opened"
+
+	// EventRuntimeAnomaly is the event sent when a run of a node takes much longer than the runs of the node before it.
+	EventRuntimeAnomaly = "runtime_anomaly"
+
+	// HeaderEvent is the header carrying the event of a notification.
+	HeaderEvent = "X-Summary-Event"
+
+	// HeaderSignature is the header carrying the HMAC-SHA256 signature of the body of a notification, as
+	// sha256=<hex>. It is only set when the webhook has a secret.
+	HeaderSignature = "X-Summary-Signature"
+
+	// webhookTimeout is how long a notification can take to be delivered.
+	webhookTimeout = 10 * time.Second
+)
+
+type Notifier interface {
+	// Notify notifies of the incident the failed run of the report is part of, unless the node is muted. Returns
+	// whether the notification was sent.
+	Notify(ctx context.Context, report *entities.PuppetReport, incident *entities.Incident) (bool, error)
+}
+
+type AnomalyNotifier interface {
+	// NotifyAnomaly notifies of the runtime anomaly of the run of the report, unless the node is muted. Returns whether
+	// the notification was sent.
+	NotifyAnomaly(ctx context.Context, report *entities.PuppetReport, anomaly *entities.RuntimeAnomaly) (bool, error)
+}
+
+// Notification is the body posted to the webhook.
+type Notification struct {
+	// Event is the event being notified of.
+	Event string `json:"event"`
+
+	// Fqdn is the FQDN of the node.
+	Fqdn string `json:"fqdn"`
+
+	// Env is the environment of the node.
+	Env summary.Environment `json:"env"`
+
+	// State is the state of the run.
+	State summary.State `json:"state"`
+
+	// ReportID is the ID of the report of the run.
+	ReportID string `json:"report_id"`
+
+	// ExecTime is the time the run was executed.
+	ExecTime time.Time `json:"exec_time"`
+
+	// Failed is the number of resources which failed.
+	Failed int64 `json:"failed"`
+
+	// IncidentID is the ID of the incident.
+	IncidentID string `json:"incident_id"`
+
+	// ConfigVersion is the configuration version of the incident.
+	ConfigVersion string `json:"configuration_version"`
+
+	// Resources are the resources that failed in the incident, as Type[title].
+	Resources []string `json:"resources"`
+
+	// Message is the representative message of the incident.
+	Message string `json:"message"`
+
+	// FirstSeen is the time of the earliest failed run of the incident.
+	FirstSeen time.Time `json:"first_seen"`
+
+	// Nodes is the number of nodes that failed in the incident.
+	Nodes int `json:"nodes"`
+}
+
+// AnomalyNotification is the body posted to the webhook for a runtime anomaly.
+type AnomalyNotification struct {
+	// Event is the event being notified of.
+	Event string `json:"event"`
+
+	// Fqdn is the FQDN of the node.
+	Fqdn string `json:"fqdn"`
+
+	// Env is the environment of the node.
+	Env summary.Environment `json:"env"`
+
+	// State is the state of the run.
+	State summary.State `json:"state"`
+
+	// ReportID is the ID of the report of the run.
+	ReportID string `json:"report_id"`
+
+	// ExecTime is the time the run was executed.
+	ExecTime time.Time `json:"exec_time"`
+
+	// Runtime is the runtime of the run, as a duration string (e.g. 15m0s).
+	Runtime string `json:"runtime"`
+
+	// Median is the median runtime of the runs of the node before, as a duration string.
+	Median string `json:"median"`
+
+	// Deviation is the median absolute deviation of the runtimes of the runs of the node before, as a duration string.
+	Deviation string `json:"deviation"`
+
+	// Score is how many deviations the runtime is above the median.
+	Score float64 `json:"score"`
+}
+
+// Webhook posts the incidents of the failed runs and the runtime anomalies to a URL, skipping the nodes that are
+// acknowledged or silenced.
+type Webhook struct {
+	// url is the URL the notifications are posted to.
+	url string
+
+	// silencer is used to skip the muted nodes.
+	silencer Silencer
+
+	// client is the client the notifications are posted with.
+	client *http.Client
+
+	// secret returns the key the notifications are signed with. It is read on every notification, so that it can be
+	// rotated. If it returns empty, the notifications are not signed.
+	secret func() string
+}
+
+// NewWebhook creates a webhook posting to the given URL, signing the notifications with the key returned by secret.
+// If secret is nil, the notifications are not signed.
+func NewWebhook(url string, secret func() string, silencer Silencer) *Webhook {
+	if secret == nil {
+		secret = func() string { return "" }
+	}
+
+	return &Webhook{
+		url:      url,
+		silencer: silencer,
+		client:   &http.Client{Timeout: webhookTimeout},
+		secret:   secret,
+	}
+}
+
+func (w *Webhook) Notify(ctx context.Context, report *entities.PuppetReport, incident *entities.Incident) (bool, error) {
+	if report.State != summary.State_FAILED {
+		return false, nil
+	}
+
+	if muted, err := w.muted(ctx, report.Fqdn); err != nil || muted {
+		return false, err
+	}
+
+	err := w.post(ctx, EventIncidentOpened, &Notification{
+		Event:         EventIncidentOpened,
+		Fqdn:          report.Fqdn,
+		Env:           report.Env,
+		State:         report.State,
+		ReportID:      report.ID,
+		ExecTime:      report.ExecTime.Time(),
+		Failed:        report.Failed,
+		IncidentID:    incident.ID,
+		ConfigVersion: incident.ConfigVersion,
+		Resources:     incident.Resources,
+		Message:       incident.Message,
+		FirstSeen:     incident.FirstSeen.Time(),
+		Nodes:         len(incident.Nodes),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (w *Webhook) NotifyAnomaly(ctx context.Context, report *entities.PuppetReport, anomaly *entities.RuntimeAnomaly) (bool, error) {
+	if muted, err := w.muted(ctx, report.Fqdn); err != nil || muted {
+		return false, err
+	}
+
+	err := w.post(ctx, EventRuntimeAnomaly, &AnomalyNotification{
+		Event:     EventRuntimeAnomaly,
+		Fqdn:      report.Fqdn,
+		Env:       report.Env,
+		State:     report.State,
+		ReportID:  report.ID,
+		ExecTime:  report.ExecTime.Time(),
+		Runtime:   anomaly.Runtime.Time().String(),
+		Median:    anomaly.Median.Time().String(),
+		Deviation: anomaly.Deviation.Time().String(),
+		Score:     anomaly.Score,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// muted returns whether the node with the given fqdn is acknowledged or silenced.
+func (w *Webhook) muted(ctx context.Context, fqdn string) (bool, error) {
+	mutes, err := w.silencer.Muted(ctx)
+	if err != nil {
+		return false, fmt.Errorf("error getting silences: %w", err)
+	}
+	if sil := mutes.For(fqdn); sil != nil {
+		slog.Debug("Node is muted, not notifying",
+			slog.String(logging.KeyFqdn, fqdn),
+			slog.String("silence", sil.ID),
+		)
+		return true, nil
+	}
+	return false, nil
+}
+
+// post posts the notification of the event to the webhook, signed if the webhook has a secret.
+func (w *Webhook) post(ctx context.Context, event string, notification any) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("error marshalling notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, event)
+
+	if secret := w.secret(); secret != "" {
+		req.Header.Set(HeaderSignature, Sign([]byte(secret), body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error posting notification: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Warn("Error closing response body", slog.String(logging.KeyError, err.Error()))
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d from webhook", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign returns the signature of the body with the secret, as sent in the HeaderSignature header.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
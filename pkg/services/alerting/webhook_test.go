@@ -0,0 +1,188 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedRequest is a request received by the test webhook.
+type recordedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newTestWebhook starts a server recording the requests it receives, responding with the given status code.
+func newTestWebhook(t *testing.T, status int) (*httptest.Server, *[]recordedRequest) {
+	t.Helper()
+
+	received := make([]recordedRequest, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received = append(received, recordedRequest{header: r.Header.Clone(), body: body})
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &received
+}
+
+func newFailedReport(fqdn string) *entities.PuppetReport {
+	return &entities.PuppetReport{
+		ID:       "report-" + fqdn,
+		Fqdn:     fqdn,
+		Env:      summary.Environment_PRODUCTION,
+		State:    summary.State_FAILED,
+		ExecTime: entities.Datetime(testNow),
+		Failed:   2,
+	}
+}
+
+// newTestIncident returns the incident opened by the failed run of the node.
+func newTestIncident(fqdn string) *entities.Incident {
+	return &entities.Incident{
+		ID:            "report-" + fqdn,
+		Env:           summary.Environment_PRODUCTION,
+		ConfigVersion: "1708135209",
+		Resources:     entities.Strings{"Package[nginx]"},
+		Message:       "/Stage[main]/Nginx/Package[nginx]/ensure : change failed",
+		FirstSeen:     entities.Datetime(testNow),
+		LastSeen:      entities.Datetime(testNow),
+		Nodes:         entities.Strings{fqdn},
+		Reports:       1,
+	}
+}
+
+func TestWebhook_Notify(t *testing.T) {
+	srv, received := newTestWebhook(t, http.StatusOK)
+	svc, _ := newTestService(t)
+
+	wh := NewWebhook(srv.URL, func() string { return "secret" }, svc)
+
+	sent, err := wh.Notify(context.Background(), newFailedReport("node1"), newTestIncident("node1"))
+	require.NoError(t, err)
+	require.True(t, sent)
+	require.Len(t, *received, 1)
+
+	req := (*received)[0]
+	require.Equal(t, EventIncidentOpened, req.header.Get(HeaderEvent))
+	require.Equal(t, Sign([]byte("secret"), req.body), req.header.Get(HeaderSignature))
+
+	got := new(Notification)
+	require.NoError(t, json.Unmarshal(req.body, got))
+	require.Equal(t, &Notification{
+		Event:         EventIncidentOpened,
+		Fqdn:          "node1",
+		Env:           summary.Environment_PRODUCTION,
+		State:         summary.State_FAILED,
+		ReportID:      "report-node1",
+		ExecTime:      testNow,
+		Failed:        2,
+		IncidentID:    "report-node1",
+		ConfigVersion: "1708135209",
+		Resources:     []string{"Package[nginx]"},
+		Message:       "/Stage[main]/Nginx/Package[nginx]/ensure : change failed",
+		FirstSeen:     testNow,
+		Nodes:         1,
+	}, got)
+}
+
+func TestWebhook_Notify_Skipped(t *testing.T) {
+	srv, received := newTestWebhook(t, http.StatusOK)
+	svc, _ := newTestService(t, "node1")
+
+	wh := NewWebhook(srv.URL, nil, svc)
+
+	// Only the failed runs are notified.
+	rep := newFailedReport("node2")
+	rep.State = summary.State_CHANGED
+	sent, err := wh.Notify(context.Background(), rep, newTestIncident("node2"))
+	require.NoError(t, err)
+	require.False(t, sent)
+
+	// The acknowledged nodes are not notified.
+	_, err = svc.Acknowledge(context.Background(), "node1", "alice", "Looking into it", testNow.Add(time.Hour))
+	require.NoError(t, err)
+	sent, err = wh.Notify(context.Background(), newFailedReport("node1"), newTestIncident("node1"))
+	require.NoError(t, err)
+	require.False(t, sent)
+
+	require.Empty(t, *received)
+
+	// Without a secret, the notifications are not signed.
+	sent, err = wh.Notify(context.Background(), newFailedReport("node2"), newTestIncident("node2"))
+	require.NoError(t, err)
+	require.True(t, sent)
+	require.Len(t, *received, 1)
+	require.Empty(t, (*received)[0].header.Get(HeaderSignature))
+}
+
+func TestWebhook_Notify_Error(t *testing.T) {
+	srv, _ := newTestWebhook(t, http.StatusInternalServerError)
+	svc, _ := newTestService(t)
+
+	sent, err := NewWebhook(srv.URL, nil, svc).Notify(context.Background(), newFailedReport("node1"), newTestIncident("node1"))
+	require.Error(t, err)
+	require.False(t, sent)
+}
+
+func TestWebhook_NotifyAnomaly(t *testing.T) {
+	srv, received := newTestWebhook(t, http.StatusOK)
+	svc, _ := newTestService(t, "node2")
+
+	wh := NewWebhook(srv.URL, func() string { return "secret" }, svc)
+
+	rep := newFailedReport("node1")
+	rep.State = summary.State_CHANGED
+	anomaly := &entities.RuntimeAnomaly{
+		ReportID:  rep.ID,
+		Fqdn:      rep.Fqdn,
+		Env:       rep.Env,
+		ExecTime:  rep.ExecTime,
+		Runtime:   entities.Duration(15 * time.Minute),
+		Median:    entities.Duration(40 * time.Second),
+		Deviation: entities.Duration(2 * time.Second),
+		Score:     291.9,
+	}
+
+	sent, err := wh.NotifyAnomaly(context.Background(), rep, anomaly)
+	require.NoError(t, err)
+	require.True(t, sent)
+	require.Len(t, *received, 1)
+
+	req := (*received)[0]
+	require.Equal(t, EventRuntimeAnomaly, req.header.Get(HeaderEvent))
+	require.Equal(t, Sign([]byte("secret"), req.body), req.header.Get(HeaderSignature))
+
+	got := new(AnomalyNotification)
+	require.NoError(t, json.Unmarshal(req.body, got))
+	require.Equal(t, &AnomalyNotification{
+		Event:     EventRuntimeAnomaly,
+		Fqdn:      "node1",
+		Env:       summary.Environment_PRODUCTION,
+		State:     summary.State_CHANGED,
+		ReportID:  "report-node1",
+		ExecTime:  testNow,
+		Runtime:   "15m0s",
+		Median:    "40s",
+		Deviation: "2s",
+		Score:     291.9,
+	}, got)
+
+	// The acknowledged nodes are not notified.
+	_, err = svc.Acknowledge(context.Background(), "node2", "alice", "Looking into it", testNow.Add(time.Hour))
+	require.NoError(t, err)
+	sent, err = wh.NotifyAnomaly(context.Background(), newFailedReport("node2"), anomaly)
+	require.NoError(t, err)
+	require.False(t, sent)
+	require.Len(t, *received, 1)
+}
@@ -0,0 +1,187 @@
+package anomalies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+)
+
+const (
+	// DefaultThreshold is the score above which a run is anomalous, when the threshold is not given.
+	DefaultThreshold = 3.5
+
+	// BaselineRuns is the number of the latest runs of a node before a run that its baseline is computed from.
+	BaselineRuns = 20
+
+	// MinRuns is the fewest runs of a node before a run that a baseline is computed from. The runs of the nodes with
+	// fewer runs are not checked.
+	MinRuns = 5
+
+	// DefaultRange is how far back the anomalies are listed from, when the start of the range is not given.
+	DefaultRange = 7 * 24 * time.Hour
+
+	// madScale scales the median absolute deviation to the standard deviation of normally distributed runtimes, so that
+	// the scores are comparable to standard scores.
+	madScale = 1.4826
+
+	// minSpread is the smallest spread of a baseline, as a fraction of its median. It keeps the nodes whose runs take
+	// almost exactly the same time from flagging every run a few seconds slower.
+	minSpread = 0.1
+)
+
+// Options select the anomalies that are listed.
+type Options struct {
+	// Fqdn is the node of the anomalies. Every node if empty.
+	Fqdn string
+
+	// From is the start of the range the runs were executed in, inclusive. Defaults to DefaultRange before To.
+	From time.Time
+
+	// To is the end of the range the runs were executed in, exclusive. Defaults to now.
+	To time.Time
+
+	// Envs are the environments of the anomalies. Every environment if empty.
+	Envs []summary.Environment
+}
+
+// withDefaults returns the options with the defaults filled in, or an error if they are out of range.
+func (o *Options) withDefaults(now time.Time) (*Options, error) {
+	res := new(Options)
+	if o != nil {
+		*res = *o
+	}
+
+	var err error
+	res.From, res.To, err = aggregate.Window(res.From, res.To, now, DefaultRange, res.Envs...)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *service) Check(ctx context.Context, report *entities.PuppetReport) (*entities.RuntimeAnomaly, error) {
+	if report.Runtime <= 0 {
+		return nil, nil
+	}
+
+	baseline, err := s.baseline(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+
+	anomaly := Score(report.Runtime.Time(), baseline)
+	if anomaly == nil || anomaly.Score <= s.threshold {
+		return nil, nil
+	}
+	anomaly.ReportID = report.ID
+	anomaly.Fqdn = report.Fqdn
+	anomaly.Env = report.Env
+	anomaly.ExecTime = report.ExecTime
+
+	slog.Info("Runtime anomaly detected",
+		slog.String(logging.KeyFqdn, report.Fqdn),
+		slog.String("runtime", anomaly.Runtime.Time().String()),
+		slog.String("median", anomaly.Median.Time().String()),
+		slog.Float64("score", anomaly.Score),
+	)
+
+	if err := s.db.SaveRuntimeAnomaly(ctx, anomaly); err != nil {
+		return nil, fmt.Errorf("error saving runtime anomaly: %w", err)
+	}
+
+	if s.notifier != nil {
+		if _, err := s.notifier.NotifyAnomaly(ctx, report, anomaly); err != nil {
+			return anomaly, fmt.Errorf("error notifying of runtime anomaly: %w", err)
+		}
+	}
+	return anomaly, nil
+}
+
+// baseline returns the runtimes of the latest BaselineRuns runs of the node of the report executed before it. The runs
+// without a runtime are skipped.
+func (s *service) baseline(ctx context.Context, report *entities.PuppetReport) ([]time.Duration, error) {
+	reports, err := s.db.GetLatestReports(ctx, report.Fqdn, report.ExecTime.Time(), BaselineRuns)
+	if err != nil && !errors.Is(err, dataaccess.ErrNotFound) {
+		return nil, fmt.Errorf("error getting reports: %w", err)
+	}
+
+	res := make([]time.Duration, 0, len(reports))
+	for _, rep := range reports {
+		if rep.ID == report.ID || rep.Runtime <= 0 {
+			continue
+		}
+		res = append(res, rep.Runtime.Time())
+	}
+	return res, nil
+}
+
+// Score returns how far the runtime is above the baseline runtimes, as the number of deviations from their median, with
+// the median and the median absolute deviation of the baseline. Only the runtimes above the median are scored, so the
+// runs that were faster than usual are never anomalous. Returns nil if the baseline has fewer than MinRuns runtimes or
+// the runtime is not above the median.
+func Score(runtime time.Duration, baseline []time.Duration) *entities.RuntimeAnomaly {
+	if len(baseline) < MinRuns {
+		return nil
+	}
+
+	med := aggregate.Median(baseline)
+	if runtime <= med {
+		return nil
+	}
+
+	deviations := make([]time.Duration, len(baseline))
+	for i, d := range baseline {
+		deviations[i] = d - med
+		if deviations[i] < 0 {
+			deviations[i] = -deviations[i]
+		}
+	}
+	mad := aggregate.Median(deviations)
+
+	spread := math.Max(madScale*mad.Seconds(), minSpread*med.Seconds())
+	if spread <= 0 {
+		return nil
+	}
+
+	return &entities.RuntimeAnomaly{
+		Runtime:   entities.Duration(runtime),
+		Median:    entities.Duration(med),
+		Deviation: entities.Duration(mad),
+		Score:     (runtime - med).Seconds() / spread,
+	}
+}
+
+func (s *service) Anomalies(ctx context.Context, filter *nodes.Filter, opts *Options) ([]*entities.RuntimeAnomaly, error) {
+	opts, err := opts.withDefaults(s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	anomalies, err := s.db.GetRuntimeAnomalies(ctx, opts.Fqdn, opts.From, opts.To, opts.Envs...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting runtime anomalies: %w", err)
+	}
+
+	selected, err := filter.SelectedFunc(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]*entities.RuntimeAnomaly, 0, len(anomalies))
+	for _, anomaly := range anomalies {
+		if selected(anomaly.Fqdn) {
+			filtered = append(filtered, anomaly)
+		}
+	}
+	return filtered, nil
+}
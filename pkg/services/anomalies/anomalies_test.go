@@ -0,0 +1,158 @@
+package anomalies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+
+// recordingNotifier records the anomalies it is notified of, returning the given error.
+type recordingNotifier struct {
+	err error
+
+	notified []*entities.RuntimeAnomaly
+}
+
+func (n *recordingNotifier) NotifyAnomaly(_ context.Context, _ *entities.PuppetReport, anomaly *entities.RuntimeAnomaly) (bool, error) {
+	cp := *anomaly
+	n.notified = append(n.notified, &cp)
+	return n.err == nil, n.err
+}
+
+func newTestService(notifier *recordingNotifier) (*service, dataaccess.Database) {
+	db := dataaccess.NewMemory()
+	svc := &service{
+		db:        db,
+		threshold: DefaultThreshold,
+		now:       func() time.Time { return testNow },
+	}
+	if notifier != nil {
+		svc.notifier = notifier
+	}
+	return svc, db
+}
+
+// newReport returns a report of the node, executed the given number of hours before the test time, that took the given
+// runtime.
+func newReport(fqdn string, hours int, runtime time.Duration) *entities.PuppetReport {
+	return &entities.PuppetReport{
+		ID:       fmt.Sprintf("report-%s-%d", fqdn, hours),
+		Fqdn:     fqdn,
+		Env:      summary.Environment_PRODUCTION,
+		State:    summary.State_UNCHANGED,
+		ExecTime: entities.Datetime(testNow.Add(-time.Duration(hours) * time.Hour)),
+		Runtime:  entities.Duration(runtime),
+	}
+}
+
+// saveBaseline saves the runs of the node that the runs after them are compared with, the runs each an hour apart up
+// to the given number of hours before the test time.
+func saveBaseline(t *testing.T, db dataaccess.Database, fqdn string, hours int, runtimes ...time.Duration) {
+	t.Helper()
+
+	for i, runtime := range runtimes {
+		require.NoError(t, db.SaveRun(context.Background(), newReport(fqdn, hours+len(runtimes)-i, runtime)))
+	}
+}
+
+func TestScore(t *testing.T) {
+	baseline := []time.Duration{38 * time.Second, 40 * time.Second, 41 * time.Second, 42 * time.Second, 40 * time.Second}
+
+	got := Score(15*time.Minute, baseline)
+	require.NotNil(t, got)
+	require.Equal(t, entities.Duration(40*time.Second), got.Median)
+	require.Equal(t, entities.Duration(time.Second), got.Deviation)
+
+	// The spread is floored at a tenth of the median, as the deviation is so small.
+	require.InDelta(t, 860/4.0, got.Score, 0.001)
+
+	// A run a little slower than usual scores low.
+	got = Score(44*time.Second, baseline)
+	require.NotNil(t, got)
+	require.InDelta(t, 1, got.Score, 0.001)
+
+	// The runs that are not slower than the median, or without enough runs before them, are not scored.
+	require.Nil(t, Score(40*time.Second, baseline))
+	require.Nil(t, Score(10*time.Second, baseline))
+	require.Nil(t, Score(15*time.Minute, baseline[:MinRuns-1]))
+
+	// With a wide spread, the deviation sets the score.
+	got = Score(3*time.Minute, []time.Duration{time.Minute, 2 * time.Minute, 30 * time.Second, 90 * time.Second, time.Minute})
+	require.NotNil(t, got)
+	require.Equal(t, entities.Duration(time.Minute), got.Median)
+	require.Equal(t, entities.Duration(30*time.Second), got.Deviation)
+	require.InDelta(t, 120/(madScale*30), got.Score, 0.001)
+}
+
+func TestService_Check(t *testing.T) {
+	notifier := new(recordingNotifier)
+	svc, db := newTestService(notifier)
+	ctx := context.Background()
+
+	saveBaseline(t, db, "node1", 3, 38*time.Second, 40*time.Second, 41*time.Second, 42*time.Second, 40*time.Second)
+
+	// A run slower than usual, but within the spread, is not anomalous.
+	got, err := svc.Check(ctx, newReport("node1", 3, 45*time.Second))
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	slow := newReport("node1", 2, 15*time.Minute)
+	require.NoError(t, db.SaveRun(ctx, slow))
+	got, err = svc.Check(ctx, slow)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	require.Equal(t, slow.ID, got.ReportID)
+	require.Equal(t, "node1", got.Fqdn)
+	require.Equal(t, summary.Environment_PRODUCTION, got.Env)
+	require.Equal(t, entities.Duration(40*time.Second), got.Median)
+	require.Len(t, notifier.notified, 1)
+
+	anomalies, err := svc.Anomalies(ctx, nil, &Options{Fqdn: "node1"})
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+	require.Equal(t, slow.ID, anomalies[0].ReportID)
+
+	// The anomalous run is part of the baseline of the runs after it, but the median holds.
+	got, err = svc.Check(ctx, newReport("node1", 1, 16*time.Minute))
+	require.NoError(t, err)
+	require.NotNil(t, got)
+}
+
+func TestService_Check_NotEnoughRuns(t *testing.T) {
+	svc, db := newTestService(nil)
+
+	saveBaseline(t, db, "node1", 2, 40*time.Second, 40*time.Second)
+
+	got, err := svc.Check(context.Background(), newReport("node1", 1, 15*time.Minute))
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	anomalies, err := svc.Anomalies(context.Background(), nil, nil)
+	require.NoError(t, err)
+	require.Empty(t, anomalies)
+}
+
+func TestService_Check_NotifyError(t *testing.T) {
+	notifier := &recordingNotifier{err: errors.New("some error")}
+	svc, db := newTestService(notifier)
+
+	saveBaseline(t, db, "node1", 1, 40*time.Second, 40*time.Second, 40*time.Second, 40*time.Second, 40*time.Second)
+
+	got, err := svc.Check(context.Background(), newReport("node1", 1, 15*time.Minute))
+	require.EqualError(t, err, "error notifying of runtime anomaly: some error")
+	require.NotNil(t, got)
+
+	// The anomaly is saved though it was not notified.
+	anomalies, err := svc.Anomalies(context.Background(), nil, nil)
+	require.NoError(t, err)
+	require.Len(t, anomalies, 1)
+}
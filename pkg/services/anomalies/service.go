@@ -0,0 +1,49 @@
+package anomalies
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/alerting"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+)
+
+type Detector interface {
+	// Check compares the runtime of the run of the report with the baseline of the runs of the node before it, saving
+	// and notifying of the run if it is anomalous. Returns nil if the run is not anomalous, or the node has too few runs
+	// for a baseline.
+	Check(ctx context.Context, report *entities.PuppetReport) (*entities.RuntimeAnomaly, error)
+
+	// Anomalies returns the runtime anomalies of the nodes selected by the filter and the options, newest first.
+	Anomalies(ctx context.Context, filter *nodes.Filter, opts *Options) ([]*entities.RuntimeAnomaly, error)
+}
+
+type service struct {
+	db dataaccess.Database
+
+	// threshold is the score above which a run is anomalous.
+	threshold float64
+
+	// notifier is notified of the anomalies. If nil, nothing is notified.
+	notifier alerting.AnomalyNotifier
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// NewService creates a detector flagging the runs scoring above threshold as anomalous. If threshold is not positive,
+// DefaultThreshold is used.
+func NewService(db dataaccess.Database, threshold float64, notifier alerting.AnomalyNotifier) Detector {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+
+	return &service{
+		db:        db,
+		threshold: threshold,
+		notifier:  notifier,
+		now:       time.Now,
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+)
+
+func (s service) GetScheduledJobs(w http.ResponseWriter, r *http.Request) {
+	statuses, err := s.scheduler.Status(r.Context())
+	if err != nil {
+		slog.Error("failed to get scheduled jobs", slog.String(logging.KeyError, err.Error()))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("failed to get scheduled jobs")); err != nil {
+			slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	jobs := make([]summary.ScheduledJob, len(statuses))
+	for i, status := range statuses {
+		job := summary.ScheduledJob{
+			Name:     summary.Point(status.Name),
+			Schedule: summary.Point(status.Schedule),
+		}
+		if !status.LastRun.IsZero() {
+			job.LastRun = summary.Point(status.LastRun)
+			job.LastDuration = summary.Point(status.LastDuration.String())
+			job.LastOutcome = summary.Point(summary.JobOutcome(status.LastOutcome))
+		}
+		if status.LastInstance != "" {
+			job.LastInstance = summary.Point(status.LastInstance)
+		}
+		if status.LastError != "" {
+			job.LastError = summary.Point(status.LastError)
+		}
+		if !status.NextRun.IsZero() {
+			job.NextRun = summary.Point(status.NextRun)
+		}
+		jobs[i] = job
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(&summary.ScheduledJobsResponse{Jobs: &jobs}); err != nil {
+		slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/anomalies"
+)
+
+func (s service) GetAnomalies(w http.ResponseWriter, r *http.Request, params summary.GetAnomaliesParams) {
+	filter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
+	opts := new(anomalies.Options)
+	if params.Env != nil {
+		opts.Envs = *params.Env
+	}
+	if params.Fqdn != nil {
+		opts.Fqdn = *params.Fqdn
+	}
+	if params.From != nil {
+		opts.From = *params.From
+	}
+	if params.To != nil {
+		opts.To = *params.To
+	}
+
+	list, err := s.anomalies.Anomalies(r.Context(), filter, opts)
+	if errors.Is(err, aggregate.ErrInvalidOptions) {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage(err.Error())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting runtime anomalies", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting runtime anomalies")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	resp := make([]*summary.RuntimeAnomaly, 0, len(list))
+	for _, anomaly := range list {
+		resp = append(resp, newRuntimeAnomaly(anomaly))
+	}
+
+	anomaliesRenderer.Render(w, r, http.StatusOK, resp)
+}
+
+// newRuntimeAnomaly maps the runtime anomaly to its API model.
+func newRuntimeAnomaly(anomaly *entities.RuntimeAnomaly) *summary.RuntimeAnomaly {
+	return &summary.RuntimeAnomaly{
+		Deviation: summary.Point(anomaly.Deviation.Time().String()),
+		Env:       &anomaly.Env,
+		ExecTime:  summary.Point(anomaly.ExecTime.Time()),
+		Fqdn:      &anomaly.Fqdn,
+		Median:    summary.Point(anomaly.Median.Time().String()),
+		ReportId:  &anomaly.ReportID,
+		Runtime:   summary.Point(anomaly.Runtime.Time().String()),
+		Score:     &anomaly.Score,
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/anomalies"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type GetAnomaliesSuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db *dataaccess.MockDb
+
+	svc *service
+}
+
+func TestGetAnomaliesSuite(t *testing.T) {
+	suite.Run(t, new(GetAnomaliesSuite))
+}
+
+func (s *GetAnomaliesSuite) SetupTest() {
+	s.db = new(dataaccess.MockDb)
+	s.svc = &service{
+		r:         s.db,
+		anomalies: anomalies.NewService(s.db, 0, nil),
+	}
+}
+
+func (s *GetAnomaliesSuite) TearDownTest() {
+	s.db.AssertExpectations(s.T())
+	s.db = nil
+}
+
+var (
+	anomaliesFrom = time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC)
+	anomaliesTo   = time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC)
+)
+
+func (s *GetAnomaliesSuite) TestGetAnomalies() {
+	anomaly := &entities.RuntimeAnomaly{
+		ReportID:  "r1",
+		Fqdn:      "node1",
+		Env:       summary.Environment_PRODUCTION,
+		ExecTime:  entities.Datetime(anomaliesFrom.Add(time.Hour)),
+		Runtime:   entities.Duration(15 * time.Minute),
+		Median:    entities.Duration(40 * time.Second),
+		Deviation: entities.Duration(1500 * time.Millisecond),
+		Score:     215,
+	}
+	s.db.On("GetRuntimeAnomalies", mock.Anything, "node1", anomaliesFrom, anomaliesTo, []summary.Environment{summary.Environment_PRODUCTION}).Return([]*entities.RuntimeAnomaly{anomaly}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/anomalies", nil)
+
+	s.svc.GetAnomalies(w, r, summary.GetAnomaliesParams{
+		Env:  &[]summary.Environment{summary.Environment_PRODUCTION},
+		Fqdn: summary.Point("node1"),
+		From: &anomaliesFrom,
+		To:   &anomaliesTo,
+	})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`[
+		{
+			"report_id": "r1",
+			"fqdn": "node1",
+			"env": "PRODUCTION",
+			"exec_time": "2024-02-13T01:00:00Z",
+			"runtime": "15m0s",
+			"median": "40s",
+			"deviation": "1.5s",
+			"score": 215
+		}
+	]`, w.Body.String())
+}
+
+func (s *GetAnomaliesSuite) TestGetAnomalies_Owner() {
+	s.db.On("GetRuntimeAnomalies", mock.Anything, "", anomaliesFrom, anomaliesTo, []summary.Environment(nil)).Return([]*entities.RuntimeAnomaly{
+		{ReportID: "r1", Fqdn: "node1", Env: summary.Environment_PRODUCTION, ExecTime: entities.Datetime(anomaliesFrom.Add(time.Hour))},
+		{ReportID: "r2", Fqdn: "node2", Env: summary.Environment_PRODUCTION, ExecTime: entities.Datetime(anomaliesFrom.Add(time.Hour))},
+	}, nil).Once()
+	s.db.On("GetAllNodeMetadata", mock.Anything).Return([]*entities.NodeMetadata{
+		{Fqdn: "node1", Owner: "platform"},
+		{Fqdn: "node2", Owner: "web"},
+	}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/anomalies?owner=web", nil)
+
+	s.svc.GetAnomalies(w, r, summary.GetAnomaliesParams{
+		From:  &anomaliesFrom,
+		To:    &anomaliesTo,
+		Owner: summary.Point("web"),
+	})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	var resp []*summary.RuntimeAnomaly
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	s.Require().Len(resp, 1)
+	s.Require().Equal("r2", *resp[0].ReportId)
+}
+
+func (s *GetAnomaliesSuite) TestGetAnomalies_Invalid() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/anomalies", nil)
+
+	s.svc.GetAnomalies(w, r, summary.GetAnomaliesParams{
+		From: &anomaliesTo,
+		To:   &anomaliesFrom,
+	})
+
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+	s.Require().JSONEq(`{"message":"invalid options: from must be before to"}`, w.Body.String())
+}
+
+func (s *GetAnomaliesSuite) TestGetAnomalies_Error() {
+	s.db.On("GetRuntimeAnomalies", mock.Anything, "", mock.Anything, mock.Anything, mock.Anything).Return([]*entities.RuntimeAnomaly(nil), errors.New("some error")).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/anomalies", nil)
+
+	s.svc.GetAnomalies(w, r, summary.GetAnomaliesParams{})
+
+	s.Require().Equal(http.StatusInternalServerError, w.Code)
+	s.Require().JSONEq(`{"message":"Error getting runtime anomalies"}`, w.Body.String())
+}
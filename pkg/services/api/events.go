@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/events"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+)
+
+const (
+	// eventsHeartbeat is how often a comment is sent on an idle stream, so that proxies keep it open and closed
+	// clients are noticed.
+	eventsHeartbeat = 15 * time.Second
+
+	// eventsRetry is how long a client waits before reconnecting, in milliseconds.
+	eventsRetry = 5000
+
+	// eventReport is the event sent for an ingested report.
+	eventReport = "report"
+
+	// eventOverflow is the event sent before a client that fell too far behind is disconnected.
+	eventOverflow = "overflow"
+)
+
+func (s service) GetEvents(w http.ResponseWriter, r *http.Request, params summary.GetEventsParams) {
+	filter := new(events.Filter)
+	if params.Fqdn != nil {
+		filter.Fqdn = strings.TrimSpace(*params.Fqdn)
+	}
+	if params.Env != nil {
+		for _, env := range *params.Env {
+			if !env.IsValid() {
+				w.WriteHeader(http.StatusBadRequest)
+				if err := json.NewEncoder(w).Encode(request.NewMessage("Invalid environment %s", env)); err != nil {
+					slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+				}
+				return
+			}
+		}
+		filter.Envs = *params.Env
+	}
+	if params.State != nil {
+		for _, state := range *params.State {
+			if !state.IsValid() {
+				w.WriteHeader(http.StatusBadRequest)
+				if err := json.NewEncoder(w).Encode(request.NewMessage("Invalid state %s", state)); err != nil {
+					slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+				}
+				return
+			}
+		}
+		filter.States = *params.State
+	}
+
+	mdFilter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
+	// Subscribe before responding, so that no event is missed once the client sees the stream open.
+	sub := s.broker.Subscribe(filter)
+	defer s.broker.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream.
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", eventsRetry); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		slog.Error("Error flushing event stream", slog.String(logging.KeyError, err.Error()))
+		return
+	}
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.Done():
+			// The subscription only ends without the request ending when the client fell too far behind.
+			slog.Warn("Event stream fell behind, disconnecting", slog.String("remote_addr", r.RemoteAddr))
+			if err := writeEvent(w, eventOverflow, request.NewMessage("Too many events were missed, resynchronise and reconnect")); err == nil {
+				_ = rc.Flush()
+			}
+			return
+		case e := <-sub.Events():
+			match, matchErr := s.eventMatches(r.Context(), e, mdFilter)
+			if matchErr != nil {
+				slog.Warn("Error filtering event", slog.String(logging.KeyError, matchErr.Error()))
+				continue
+			} else if !match {
+				continue
+			}
+
+			err = writeEvent(w, eventReport, &summary.Event{
+				Changed:  summary.Point(int(e.Changed)),
+				Env:      &e.Env,
+				ExecTime: &e.ExecTime,
+				Failed:   summary.Point(int(e.Failed)),
+				Fqdn:     &e.Fqdn,
+				Id:       &e.ID,
+				Runtime:  summary.Point(e.Runtime.String()),
+				State:    &e.State,
+				Total:    summary.Point(int(e.Total)),
+			})
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		}
+
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			// The client has gone away.
+			return
+		}
+	}
+}
+
+// eventMatches returns whether the node of the event is selected by the metadata filter.
+func (s service) eventMatches(ctx context.Context, e *events.Event, filter *nodes.Filter) (bool, error) {
+	if filter.IsEmpty() {
+		return true, nil
+	}
+
+	md, err := s.r.GetNodeMetadata(ctx, e.Fqdn)
+	if errors.Is(err, dataaccess.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error getting node metadata: %w", err)
+	}
+
+	return md.Matches(filter.Owner, filter.Labels), nil
+}
+
+// writeEvent writes the named event to the stream, with the data encoded as JSON.
+func writeEvent(w io.Writer, name string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("error marshalling event: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, b); err != nil {
+		return fmt.Errorf("error writing event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/events"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type EventsSuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db *dataaccess.MockDb
+
+	svc *service
+}
+
+func TestEventsSuite(t *testing.T) {
+	suite.Run(t, new(EventsSuite))
+}
+
+func (s *EventsSuite) SetupTest() {
+	s.db = new(dataaccess.MockDb)
+	s.svc = &service{
+		r:      s.db,
+		broker: events.NewBroker(events.DefaultBuffer),
+	}
+}
+
+func (s *EventsSuite) TearDownTest() {
+	s.db.AssertExpectations(s.T())
+	s.db = nil
+}
+
+// stream opens an event stream with the given parameters, returning a reader of its lines. The stream is open, and
+// subscribed to the broker, once the function returns.
+func (s *EventsSuite) stream(params summary.GetEventsParams) *bufio.Scanner {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.svc.GetEvents(w, r, params)
+	}))
+	s.T().Cleanup(srv.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	s.T().Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	s.Require().NoError(err)
+
+	resp, err := http.DefaultClient.Do(req)
+	s.Require().NoError(err)
+	s.T().Cleanup(func() {
+		s.Require().NoError(resp.Body.Close())
+	})
+
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	s.Require().Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := bufio.NewScanner(resp.Body)
+	s.Require().True(lines.Scan())
+	s.Require().Equal("retry: 5000", lines.Text())
+	s.Require().True(lines.Scan())
+	s.Require().Empty(lines.Text())
+
+	return lines
+}
+
+// next returns the name and data of the next event on the stream.
+func (s *EventsSuite) next(lines *bufio.Scanner) (string, string) {
+	var name, data string
+	for lines.Scan() {
+		line := lines.Text()
+		switch {
+		case line == "":
+			return name, data
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	s.Require().NoError(lines.Err())
+	s.FailNow("stream ended")
+	return "", ""
+}
+
+func newTestEvent(fqdn string, state summary.State) *events.Event {
+	return &events.Event{
+		ID:       "report-" + fqdn,
+		Fqdn:     fqdn,
+		Env:      summary.Environment_PRODUCTION,
+		State:    state,
+		ExecTime: time.Date(2024, 2, 13, 10, 0, 9, 0, time.UTC),
+		Runtime:  entities.Duration(23 * time.Second),
+		Failed:   1,
+		Changed:  2,
+		Total:    10,
+	}
+}
+
+func (s *EventsSuite) TestGetEvents() {
+	lines := s.stream(summary.GetEventsParams{
+		State: &[]summary.State{summary.State_FAILED},
+	})
+
+	s.svc.broker.Publish(newTestEvent("node1", summary.State_CHANGED))
+	s.svc.broker.Publish(newTestEvent("node2", summary.State_FAILED))
+
+	name, data := s.next(lines)
+	s.Require().Equal("report", name)
+	s.Require().JSONEq(`{
+		"id": "report-node2",
+		"fqdn": "node2",
+		"env": "PRODUCTION",
+		"state": "FAILED",
+		"exec_time": "2024-02-13T10:00:09Z",
+		"runtime": "23s",
+		"failed": 1,
+		"changed": 2,
+		"total": 10
+	}`, data)
+}
+
+func (s *EventsSuite) TestGetEvents_Owner() {
+	s.db.On("GetNodeMetadata", mock.Anything, "node1").Return((*entities.NodeMetadata)(nil), dataaccess.ErrNotFound).Once()
+	s.db.On("GetNodeMetadata", mock.Anything, "node2").Return(&entities.NodeMetadata{
+		Fqdn:  "node2",
+		Owner: "platform",
+	}, nil).Once()
+
+	lines := s.stream(summary.GetEventsParams{
+		Owner: summary.Point("platform"),
+	})
+
+	s.svc.broker.Publish(newTestEvent("node1", summary.State_CHANGED))
+	s.svc.broker.Publish(newTestEvent("node2", summary.State_CHANGED))
+
+	_, data := s.next(lines)
+	s.Require().Contains(data, `"fqdn":"node2"`)
+}
+
+func (s *EventsSuite) TestGetEvents_Overflow() {
+	s.svc.broker = events.NewBroker(1)
+
+	// The lookup of the metadata holds the stream up, so that it falls behind.
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	s.db.On("GetNodeMetadata", mock.Anything, "node1").Run(func(mock.Arguments) {
+		select {
+		case entered <- struct{}{}:
+		default:
+		}
+		<-release
+	}).Return(&entities.NodeMetadata{Fqdn: "node1", Owner: "platform"}, nil)
+
+	lines := s.stream(summary.GetEventsParams{
+		Owner: summary.Point("platform"),
+	})
+
+	s.svc.broker.Publish(newTestEvent("node1", summary.State_CHANGED))
+	<-entered
+	s.svc.broker.Publish(newTestEvent("node1", summary.State_CHANGED))
+	s.svc.broker.Publish(newTestEvent("node1", summary.State_CHANGED))
+	close(release)
+
+	// The events already taken from the buffer may be sent before the overflow.
+	for {
+		name, _ := s.next(lines)
+		if name == "overflow" {
+			break
+		}
+		s.Require().Equal("report", name)
+	}
+	s.Require().False(lines.Scan(), "the stream is closed after the overflow")
+}
+
+func (s *EventsSuite) TestGetEvents_InvalidState() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/events", nil)
+
+	s.svc.GetEvents(w, r, summary.GetEventsParams{
+		State: &[]summary.State{"BROKEN"},
+	})
+
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+	s.Require().JSONEq(`{"message": "Invalid state BROKEN"}`, w.Body.String())
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/failures"
+)
+
+func (s service) GetFailureLeaderboard(w http.ResponseWriter, r *http.Request, params summary.GetFailureLeaderboardParams) {
+	filter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
+	opts := &failures.Options{
+		Filter: filter,
+	}
+	if params.Env != nil {
+		opts.Envs = *params.Env
+	}
+	if params.From != nil {
+		opts.From = *params.From
+	}
+	if params.To != nil {
+		opts.To = *params.To
+	}
+	if params.Limit != nil {
+		opts.Limit = *params.Limit
+	}
+	if params.Metric != nil {
+		opts.Metrics = *params.Metric
+	}
+
+	leaderboard, err := s.failures.Leaderboard(r.Context(), opts)
+	if errors.Is(err, aggregate.ErrInvalidOptions) {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage(err.Error())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting failure leaderboard", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting failure leaderboard")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	failureLeaderboardRenderer.Render(w, r, http.StatusOK, &summary.FailureLeaderboard{
+		From:      &leaderboard.From,
+		Locations: newFailureEntries(leaderboard.Locations),
+		Nodes:     newMetricNodes(leaderboard.Nodes),
+		Resources: newFailureEntries(leaderboard.Resources),
+		To:        &leaderboard.To,
+		Types:     newFailureEntries(leaderboard.Types),
+	})
+}
+
+func (s service) GetFailureNodes(w http.ResponseWriter, r *http.Request, params summary.GetFailureNodesParams) {
+	filter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
+	key := new(failures.Key)
+	if params.Type != nil {
+		key.Type = *params.Type
+	}
+	if params.Name != nil {
+		key.Name = *params.Name
+	}
+	if params.File != nil {
+		key.File = *params.File
+	}
+	if params.Line != nil {
+		key.Line = *params.Line
+	}
+
+	opts := &failures.Options{
+		Filter: filter,
+	}
+	if params.Env != nil {
+		opts.Envs = *params.Env
+	}
+	if params.From != nil {
+		opts.From = *params.From
+	}
+	if params.To != nil {
+		opts.To = *params.To
+	}
+
+	affected, err := s.failures.Affected(r.Context(), opts, key)
+	if errors.Is(err, aggregate.ErrInvalidOptions) {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage(err.Error())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting failing nodes", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting failing nodes")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	resp := make([]*summary.FailureNode, 0, len(affected))
+	for _, node := range affected {
+		resp = append(resp, newFailureNode(node))
+	}
+
+	failureNodesRenderer.Render(w, r, http.StatusOK, resp)
+}
+
+// newFailureEntries maps the entries of a ranking of failures to the API model. The empty fields of the keys are left
+// out.
+func newFailureEntries(entries []*failures.Entry) *[]summary.FailureEntry {
+	optional := func(v string) *string {
+		if v == "" {
+			return nil
+		}
+		return &v
+	}
+
+	resp := make([]summary.FailureEntry, 0, len(entries))
+	for _, entry := range entries {
+		resp = append(resp, summary.FailureEntry{
+			Failures:   summary.Point(entry.Failures),
+			File:       optional(entry.File),
+			LastFailed: summary.Point(entry.LastFailed),
+			Line:       optional(entry.Line),
+			Name:       optional(entry.Name),
+			Nodes:      summary.Point(entry.Nodes),
+			Type:       optional(entry.Type),
+		})
+	}
+	return &resp
+}
+
+// newFailureNode maps the node failing on resources, and the reports it failed in, to the API model.
+func newFailureNode(node *failures.AffectedNode) *summary.FailureNode {
+	reports := make([]summary.FailureReport, 0, len(node.Reports))
+	for _, rep := range node.Reports {
+		resources := make([]summary.Resource, 0, len(rep.Resources))
+		for _, res := range rep.Resources {
+			resources = append(resources, summary.Resource{
+				File: &res.File,
+				Line: &res.Line,
+				Name: &res.Name,
+				Type: &res.Type,
+			})
+		}
+
+		reports = append(reports, summary.FailureReport{
+			ExecTime:  summary.Point(rep.ExecTime),
+			Id:        summary.Point(rep.ID),
+			Resources: &resources,
+		})
+	}
+
+	return &summary.FailureNode{
+		Env:      summary.Point(node.Env),
+		Failures: summary.Point(len(node.Reports)),
+		Fqdn:     summary.Point(node.Fqdn),
+		Reports:  &reports,
+	}
+}
+
+// newMetricNodes maps the ranking of the nodes by their metrics to the API model. Nil if the nodes were not ranked.
+func newMetricNodes(entries []*failures.NodeEntry) *[]summary.MetricNode {
+	if entries == nil {
+		return nil
+	}
+
+	resp := make([]summary.MetricNode, 0, len(entries))
+	for _, entry := range entries {
+		resp = append(resp, summary.MetricNode{
+			Env:     summary.Point(entry.Env),
+			Fqdn:    summary.Point(entry.Fqdn),
+			Metrics: &entry.Metrics,
+			Reports: summary.Point(entry.Reports),
+		})
+	}
+	return &resp
+}
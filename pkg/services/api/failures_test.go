@@ -0,0 +1,194 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/failures"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type GetFailuresSuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db *dataaccess.MockDb
+
+	svc *service
+}
+
+func TestGetFailuresSuite(t *testing.T) {
+	suite.Run(t, new(GetFailuresSuite))
+}
+
+func (s *GetFailuresSuite) SetupTest() {
+	s.db = new(dataaccess.MockDb)
+	s.svc = &service{
+		r:        s.db,
+		failures: failures.NewService(s.db),
+	}
+}
+
+func (s *GetFailuresSuite) TearDownTest() {
+	s.db.AssertExpectations(s.T())
+	s.db = nil
+}
+
+var (
+	failuresFrom = time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC)
+	failuresTo   = time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC)
+)
+
+// failures returns the failures of node1, which failed on two resources of a manifest in one report, and node2,
+// which failed on one of them, newest first.
+func (s *GetFailuresSuite) failures() []*entities.ResourceFailure {
+	pkg := &entities.PuppetResource{Type: "Package", Name: "nginx", File: "/etc/puppet/nginx.pp", Line: "3"}
+	svc := &entities.PuppetResource{Type: "Service", Name: "nginx", File: "/etc/puppet/nginx.pp", Line: "9"}
+
+	return []*entities.ResourceFailure{
+		{ReportID: "r2", Fqdn: "node2", Env: summary.Environment_PRODUCTION, ExecTime: entities.Datetime(failuresFrom.Add(2 * time.Hour)), Resource: pkg},
+		{ReportID: "r1", Fqdn: "node1", Env: summary.Environment_PRODUCTION, ExecTime: entities.Datetime(failuresFrom.Add(time.Hour)), Resource: pkg},
+		{ReportID: "r1", Fqdn: "node1", Env: summary.Environment_PRODUCTION, ExecTime: entities.Datetime(failuresFrom.Add(time.Hour)), Resource: svc},
+	}
+}
+
+func (s *GetFailuresSuite) TestGetFailureLeaderboard() {
+	s.db.On("GetResourceFailures", mock.Anything, failuresFrom, failuresTo, []summary.Environment{summary.Environment_PRODUCTION}).Return(s.failures(), nil).Once()
+	s.db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/failures", nil)
+
+	s.svc.GetFailureLeaderboard(w, r, summary.GetFailureLeaderboardParams{
+		Env:   &[]summary.Environment{summary.Environment_PRODUCTION},
+		From:  &failuresFrom,
+		To:    &failuresTo,
+		Limit: summary.Point(1),
+	})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`{
+		"from": "2024-02-13T00:00:00Z",
+		"to": "2024-02-14T00:00:00Z",
+		"resources": [{"type": "Package", "name": "nginx", "failures": 2, "nodes": 2, "last_failed": "2024-02-13T02:00:00Z"}],
+		"types": [{"type": "Package", "failures": 2, "nodes": 2, "last_failed": "2024-02-13T02:00:00Z"}],
+		"locations": [{"file": "/etc/puppet/nginx.pp", "line": "3", "failures": 2, "nodes": 2, "last_failed": "2024-02-13T02:00:00Z"}]
+	}`, w.Body.String())
+}
+
+func (s *GetFailuresSuite) TestGetFailureLeaderboard_Metrics() {
+	metrics := []string{"resources.out_of_sync"}
+
+	s.db.On("GetResourceFailures", mock.Anything, failuresFrom, failuresTo, []summary.Environment(nil)).Return(s.failures(), nil).Once()
+	s.db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil).Twice()
+	s.db.On("GetRunMetrics", mock.Anything, failuresFrom, failuresTo, metrics, []summary.Environment(nil)).Return([]*entities.RunMetric{
+		{ReportID: "r1", Fqdn: "node1", Env: summary.Environment_PRODUCTION, ExecTime: entities.Datetime(failuresFrom.Add(time.Hour)), Metric: "resources.out_of_sync", Value: 2},
+		{ReportID: "r2", Fqdn: "node2", Env: summary.Environment_PRODUCTION, ExecTime: entities.Datetime(failuresFrom.Add(2 * time.Hour)), Metric: "resources.out_of_sync", Value: 5},
+	}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/failures", nil)
+
+	s.svc.GetFailureLeaderboard(w, r, summary.GetFailureLeaderboardParams{
+		From:   &failuresFrom,
+		To:     &failuresTo,
+		Limit:  summary.Point(1),
+		Metric: &metrics,
+	})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`{
+		"from": "2024-02-13T00:00:00Z",
+		"to": "2024-02-14T00:00:00Z",
+		"resources": [{"type": "Package", "name": "nginx", "failures": 2, "nodes": 2, "last_failed": "2024-02-13T02:00:00Z"}],
+		"types": [{"type": "Package", "failures": 2, "nodes": 2, "last_failed": "2024-02-13T02:00:00Z"}],
+		"locations": [{"file": "/etc/puppet/nginx.pp", "line": "3", "failures": 2, "nodes": 2, "last_failed": "2024-02-13T02:00:00Z"}],
+		"nodes": [{"fqdn": "node2", "env": "PRODUCTION", "reports": 1, "metrics": {"resources.out_of_sync": 5}}]
+	}`, w.Body.String())
+}
+
+func (s *GetFailuresSuite) TestGetFailureLeaderboard_InvalidMetric() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/failures?metric=out_of_sync", nil)
+
+	s.svc.GetFailureLeaderboard(w, r, summary.GetFailureLeaderboardParams{Metric: &[]string{"out_of_sync"}})
+
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+	s.Require().JSONEq(`{"message":"invalid options: invalid metric out_of_sync"}`, w.Body.String())
+}
+
+func (s *GetFailuresSuite) TestGetFailureLeaderboard_InvalidLimit() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/failures?limit=101", nil)
+
+	s.svc.GetFailureLeaderboard(w, r, summary.GetFailureLeaderboardParams{Limit: summary.Point(101)})
+
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+	s.Require().JSONEq(`{"message":"invalid options: limit must be between 1 and 100"}`, w.Body.String())
+}
+
+func (s *GetFailuresSuite) TestGetFailureLeaderboard_Error() {
+	s.db.On("GetResourceFailures", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*entities.ResourceFailure(nil), errors.New("some error")).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/failures", nil)
+
+	s.svc.GetFailureLeaderboard(w, r, summary.GetFailureLeaderboardParams{})
+
+	s.Require().Equal(http.StatusInternalServerError, w.Code)
+	s.Require().JSONEq(`{"message":"Error getting failure leaderboard"}`, w.Body.String())
+}
+
+func (s *GetFailuresSuite) TestGetFailureNodes() {
+	s.db.On("GetResourceFailures", mock.Anything, failuresFrom, failuresTo, []summary.Environment(nil)).Return(s.failures(), nil).Once()
+	s.db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/failures/nodes", nil)
+
+	s.svc.GetFailureNodes(w, r, summary.GetFailureNodesParams{
+		File: summary.Point("/etc/puppet/nginx.pp"),
+		From: &failuresFrom,
+		To:   &failuresTo,
+	})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`[
+		{
+			"fqdn": "node2",
+			"env": "PRODUCTION",
+			"failures": 1,
+			"reports": [
+				{"id": "r2", "exec_time": "2024-02-13T02:00:00Z", "resources": [{"type": "Package", "name": "nginx", "file": "/etc/puppet/nginx.pp", "line": "3"}]}
+			]
+		},
+		{
+			"fqdn": "node1",
+			"env": "PRODUCTION",
+			"failures": 1,
+			"reports": [
+				{"id": "r1", "exec_time": "2024-02-13T01:00:00Z", "resources": [
+					{"type": "Package", "name": "nginx", "file": "/etc/puppet/nginx.pp", "line": "3"},
+					{"type": "Service", "name": "nginx", "file": "/etc/puppet/nginx.pp", "line": "9"}
+				]}
+			]
+		}
+	]`, w.Body.String())
+}
+
+func (s *GetFailuresSuite) TestGetFailureNodes_NoKey() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/failures/nodes", nil)
+
+	s.svc.GetFailureNodes(w, r, summary.GetFailureNodesParams{})
+
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+	s.Require().JSONEq(`{"message":"invalid options: a type, name, file or line is required"}`, w.Body.String())
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/flapping"
+)
+
+func (s service) GetFlappingNodes(w http.ResponseWriter, r *http.Request, params summary.GetFlappingNodesParams) {
+	filter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
+	opts := new(flapping.Options)
+	if params.Window != nil {
+		opts.Window = *params.Window
+	}
+	if params.Threshold != nil {
+		opts.Threshold = *params.Threshold
+	}
+
+	flappingNodes, err := s.flapping.Flapping(r.Context(), filter, opts)
+	if errors.Is(err, flapping.ErrInvalidOptions) {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage(err.Error())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		slog.Error("Error getting flapping nodes", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting flapping nodes")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	resp := make([]*summary.FlappingNode, 0, len(flappingNodes))
+	for _, node := range flappingNodes {
+		resources, err := s.flapping.ChangingResources(r.Context(), node)
+		if err != nil {
+			slog.Error("Error getting changing resources", slog.String(logging.KeyError, err.Error()))
+			// Respond with 500 internal server error.
+			w.WriteHeader(http.StatusInternalServerError)
+			if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting changing resources")); err != nil {
+				slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+			}
+			return
+		}
+
+		resp = append(resp, newFlappingNode(node, resources))
+	}
+
+	flappingRenderer.Render(w, r, http.StatusOK, resp)
+}
+
+// newFlappingNode maps the flapping node and the resources changed by its runs to the API model.
+func newFlappingNode(node *flapping.Node, resources []*entities.PuppetResource) *summary.FlappingNode {
+	runs := make([]summary.PuppetReportSummary, 0, len(node.Runs))
+	for _, run := range node.Runs {
+		runs = append(runs, summary.PuppetReportSummary{
+			Env:      &run.Env,
+			ExecTime: summary.Point(run.ExecTime.Time()),
+			Fqdn:     &run.Fqdn,
+			Id:       &run.ID,
+			Runtime:  summary.Point(run.Runtime.String()),
+			State:    &run.State,
+		})
+	}
+
+	changing := make([]summary.Resource, 0, len(resources))
+	for _, res := range resources {
+		changing = append(changing, summary.Resource{
+			File: &res.File,
+			Line: &res.Line,
+			Name: &res.Name,
+			Type: &res.Type,
+		})
+	}
+
+	return &summary.FlappingNode{
+		ChangingResources: &changing,
+		Env:               &node.Env,
+		Fqdn:              &node.Fqdn,
+		Runs:              &runs,
+		Score:             &node.Score,
+		Transitions:       &node.Transitions,
+	}
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/flapping"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type GetFlappingNodesSuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db *dataaccess.MockDb
+
+	// files is the storage used for testing.
+	files *dataaccess.MockStorage
+
+	svc *service
+}
+
+func TestGetFlappingNodesSuite(t *testing.T) {
+	suite.Run(t, new(GetFlappingNodesSuite))
+}
+
+func (s *GetFlappingNodesSuite) SetupTest() {
+	s.db = new(dataaccess.MockDb)
+	s.files = new(dataaccess.MockStorage)
+	dataaccess.Files = s.files
+	s.svc = &service{
+		r:        s.db,
+		flapping: flapping.NewService(s.db),
+	}
+}
+
+func (s *GetFlappingNodesSuite) TearDownTest() {
+	s.db.AssertExpectations(s.T())
+	s.files.AssertExpectations(s.T())
+	s.db = nil
+	s.files = nil
+	dataaccess.Files = nil
+}
+
+// runs returns the runs of node1, which alternates between changed and unchanged, and node2, which is stable.
+func (s *GetFlappingNodesSuite) runs() []*entities.PuppetRun {
+	now := time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+
+	runs := make([]*entities.PuppetRun, 0, 2*flapping.DefaultWindow)
+	for i := 0; i < flapping.DefaultWindow; i++ {
+		state := summary.State_CHANGED
+		if i%2 == 1 {
+			state = summary.State_UNCHANGED
+		}
+		execTime := entities.Datetime(now.Add(time.Duration(i) * 30 * time.Minute))
+
+		runs = append(runs,
+			&entities.PuppetRun{ID: "node1-" + string(rune('a'+i)), Fqdn: "node1", Env: summary.Environment_PRODUCTION, State: state, ExecTime: execTime,
+				YamlFile: "reports/PRODUCTION/node1/" + execTime.String() + ".yaml"},
+			&entities.PuppetRun{ID: "node2-" + string(rune('a'+i)), Fqdn: "node2", Env: summary.Environment_PRODUCTION, State: summary.State_UNCHANGED, ExecTime: execTime,
+				YamlFile: "reports/PRODUCTION/node2/" + execTime.String() + ".yaml"},
+		)
+	}
+	return runs
+}
+
+func (s *GetFlappingNodesSuite) TestGetFlappingNodes() {
+	example, err := os.ReadFile("../parser/testdata/example.yaml")
+	s.Require().NoError(err)
+
+	runs := s.runs()
+	s.db.On("GetRuns", mock.Anything).Return(runs, nil).Once()
+	s.db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil).Once()
+	for _, run := range runs {
+		if run.State == summary.State_CHANGED {
+			s.files.On("DownloadFile", mock.Anything, run.YamlFile).Return(example, nil).Once()
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/nodes/flapping", nil)
+
+	s.svc.GetFlappingNodes(w, r, summary.GetFlappingNodesParams{})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+
+	var resp []*summary.FlappingNode
+	s.Require().NoError(json.NewDecoder(w.Body).Decode(&resp))
+	s.Require().Len(resp, 1)
+	s.Require().Equal("node1", *resp[0].Fqdn)
+	s.Require().Equal(1.0, *resp[0].Score)
+	s.Require().Equal(flapping.DefaultWindow-1, *resp[0].Transitions)
+	s.Require().Len(*resp[0].Runs, flapping.DefaultWindow)
+	s.Require().Equal(summary.State_CHANGED, *(*resp[0].Runs)[0].State)
+	s.Require().Len(*resp[0].ChangingResources, 1)
+	s.Require().Equal("example-command1", *(*resp[0].ChangingResources)[0].Name)
+}
+
+func (s *GetFlappingNodesSuite) TestGetFlappingNodes_Owner() {
+	s.db.On("GetRuns", mock.Anything).Return(s.runs(), nil).Once()
+	s.db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil).Once()
+	s.db.On("GetAllNodeMetadata", mock.Anything).Return([]*entities.NodeMetadata{
+		{Fqdn: "node1", Owner: "platform"},
+		{Fqdn: "node2", Owner: "web"},
+	}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/nodes/flapping?owner=web", nil)
+
+	s.svc.GetFlappingNodes(w, r, summary.GetFlappingNodesParams{Owner: summary.Point("web")})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`[]`, w.Body.String())
+}
+
+func (s *GetFlappingNodesSuite) TestGetFlappingNodes_InvalidWindow() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/nodes/flapping?window=2", nil)
+
+	s.svc.GetFlappingNodes(w, r, summary.GetFlappingNodesParams{Window: summary.Point(2)})
+
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+	s.Require().JSONEq(`{"message":"invalid flapping options: window must be between 4 and 100"}`, w.Body.String())
+}
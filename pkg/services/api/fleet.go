@@ -0,0 +1,220 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+)
+
+// DefaultStaleAfter is how long a node can go without reporting before it is stale, when it is not configured.
+const DefaultStaleAfter = 24 * time.Hour
+
+// recentReportsWindow is the window the recently received reports are counted in.
+const recentReportsWindow = 24 * time.Hour
+
+// latestRuns returns the latest run of each node, keyed by the FQDN and the environment of the node.
+func latestRuns(runs []*entities.PuppetRun) map[string]*entities.PuppetRun {
+	nodesMap := make(map[string]*entities.PuppetRun)
+	for _, node := range runs {
+		node.CalculateTimeSince()
+
+		// Create a key for the node. This should be the FQDN and the environment.
+		key := fmt.Sprintf("%s-%s", node.Fqdn, node.Env)
+
+		// Keep the node if it is not in the map yet, or if it is newer than the one in the map.
+		if existing, ok := nodesMap[key]; !ok || node.ExecTime.Time().After(existing.ExecTime.Time()) {
+			nodesMap[key] = node
+		}
+	}
+	return nodesMap
+}
+
+// listedRuns returns the runs shown in the listings of the nodes selected by the filter, leaving out the
+// decommissioned nodes.
+func (s service) listedRuns(ctx context.Context, filter *nodes.Filter) ([]*entities.PuppetRun, error) {
+	runs, err := s.r.GetRuns(ctx)
+	if err != nil && !errors.Is(err, dataaccess.ErrNotFound) {
+		return nil, err
+	}
+
+	runs, err = nodes.Listed(ctx, s.r, runs)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter.Apply(ctx, s.r, runs)
+}
+
+// parseFilter parses the filter of the nodes from the owner and label parameters. If they are invalid, it responds
+// with 400 bad request and returns false.
+func parseFilter(w http.ResponseWriter, owner *string, labels *[]string) (*nodes.Filter, bool) {
+	filter, err := nodes.ParseFilter(owner, labels)
+	if err != nil {
+		slog.Warn("Invalid node filter", slog.String(logging.KeyError, err.Error()))
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage(err.Error())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return nil, false
+	}
+	return filter, true
+}
+
+// isStale returns whether the node has not reported within the stale threshold.
+func (s service) isStale(node *entities.PuppetRun, now time.Time) bool {
+	return now.Sub(node.ExecTime.Time()) > s.staleAfter
+}
+
+func (s service) GetEnvironments(w http.ResponseWriter, r *http.Request, params summary.GetEnvironmentsParams) {
+	filter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
+	envs, err := s.r.GetEnvironments(r.Context())
+	if err != nil && !errors.Is(err, dataaccess.ErrNotFound) {
+		slog.Error("Error getting environments", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting environments")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	runs, err := s.listedRuns(r.Context(), filter)
+	if err != nil {
+		slog.Error("Error getting nodes", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting nodes")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	now := time.Now()
+	envsMap := make(map[summary.Environment]*summary.EnvironmentSummary, len(envs))
+	for _, env := range envs {
+		envsMap[env] = newEnvironmentSummary(env)
+	}
+
+	for _, node := range latestRuns(runs) {
+		envSummary, ok := envsMap[node.Env]
+		if !ok {
+			envSummary = newEnvironmentSummary(node.Env)
+			envsMap[node.Env] = envSummary
+		}
+
+		*envSummary.Nodes++
+		switch node.State {
+		case summary.State_CHANGED:
+			*envSummary.Changed++
+		case summary.State_UNCHANGED:
+			*envSummary.Unchanged++
+		case summary.State_FAILED:
+			*envSummary.Failed++
+		case summary.State_SKIPPED:
+			*envSummary.Skipped++
+		}
+		if s.isStale(node, now) {
+			*envSummary.Stale++
+		}
+	}
+
+	resp := make([]*summary.EnvironmentSummary, 0, len(envsMap))
+	for _, envSummary := range envsMap {
+		resp = append(resp, envSummary)
+	}
+
+	// Sort the environments by name, so the order is stable.
+	sort.Slice(resp, func(i, j int) bool {
+		return *resp[i].Env < *resp[j].Env
+	})
+
+	environmentsRenderer.Render(w, r, http.StatusOK, resp)
+}
+
+// newEnvironmentSummary returns the summary of an environment with no nodes.
+func newEnvironmentSummary(env summary.Environment) *summary.EnvironmentSummary {
+	return &summary.EnvironmentSummary{
+		Env:       summary.Point(env),
+		Nodes:     summary.Point(0),
+		Changed:   summary.Point(0),
+		Unchanged: summary.Point(0),
+		Failed:    summary.Point(0),
+		Skipped:   summary.Point(0),
+		Stale:     summary.Point(0),
+	}
+}
+
+func (s service) GetFleetSummary(w http.ResponseWriter, r *http.Request, params summary.GetFleetSummaryParams) {
+	filter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
+	runs, err := s.listedRuns(r.Context(), filter)
+	if err != nil {
+		slog.Error("Error getting nodes", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting nodes")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	now := time.Now()
+	var nodes, failing, changed, unchanged, stale, recent int
+
+	latest := latestRuns(runs)
+	runtimes := make([]time.Duration, 0, len(latest))
+	for _, node := range latest {
+		nodes++
+		switch node.State {
+		case summary.State_FAILED:
+			failing++
+		case summary.State_CHANGED:
+			changed++
+		case summary.State_UNCHANGED:
+			unchanged++
+		}
+		if s.isStale(node, now) {
+			stale++
+		}
+		runtimes = append(runtimes, node.Runtime.Time())
+	}
+
+	for _, run := range runs {
+		if run.ExecTime.Time().After(now.Add(-recentReportsWindow)) {
+			recent++
+		}
+	}
+
+	resp := &summary.FleetSummary{
+		Nodes:          &nodes,
+		Failing:        &failing,
+		Changed:        &changed,
+		Unchanged:      &unchanged,
+		Stale:          &stale,
+		MedianRuntime:  summary.Point(entities.Duration(aggregate.Median(runtimes)).String()),
+		ReportsLast24h: &recent,
+	}
+
+	fleetSummaryRenderer.Render(w, r, http.StatusOK, resp)
+}
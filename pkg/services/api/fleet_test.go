@@ -0,0 +1,146 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type FleetSuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db *dataaccess.MockDb
+
+	svc *service
+}
+
+func TestFleetSuite(t *testing.T) {
+	suite.Run(t, new(FleetSuite))
+}
+
+func (s *FleetSuite) SetupTest() {
+	s.db = new(dataaccess.MockDb)
+	s.svc = &service{
+		r:          s.db,
+		staleAfter: DefaultStaleAfter,
+	}
+}
+
+func (s *FleetSuite) TearDownTest() {
+	s.db.AssertExpectations(s.T())
+	s.db = nil
+}
+
+// runs returns the runs of the fleet used by the tests. The latest runs are node1 changed, node2 unchanged and stale,
+// and node3 failed.
+func (s *FleetSuite) runs() []*entities.PuppetRun {
+	now := time.Now().UTC()
+	run := func(fqdn string, env summary.Environment, state summary.State, ago, runtime time.Duration) *entities.PuppetRun {
+		return &entities.PuppetRun{
+			Fqdn:     fqdn,
+			Env:      env,
+			State:    state,
+			ExecTime: entities.Datetime(now.Add(-ago)),
+			Runtime:  entities.Duration(runtime),
+		}
+	}
+
+	return []*entities.PuppetRun{
+		run("node1", summary.Environment_PRODUCTION, summary.State_FAILED, 3*time.Hour, 10*time.Second),
+		run("node1", summary.Environment_PRODUCTION, summary.State_CHANGED, time.Hour, 20*time.Second),
+		run("node2", summary.Environment_STAGING, summary.State_UNCHANGED, 48*time.Hour, 30*time.Second),
+		run("node3", summary.Environment_PRODUCTION, summary.State_FAILED, 2*time.Hour, 40*time.Second),
+	}
+}
+
+func (s *FleetSuite) TestGetFleetSummary() {
+	s.db.On("GetRuns", mock.Anything).Return(s.runs(), nil).Once()
+	s.db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/summary", nil)
+
+	s.svc.GetFleetSummary(w, r, summary.GetFleetSummaryParams{})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`{
+		"nodes": 3,
+		"failing": 1,
+		"changed": 1,
+		"unchanged": 1,
+		"stale": 1,
+		"median_runtime": "30s",
+		"reports_last_24h": 3
+	}`, w.Body.String())
+}
+
+func (s *FleetSuite) TestGetFleetSummaryEmpty() {
+	s.db.On("GetRuns", mock.Anything).Return([]*entities.PuppetRun{}, nil).Once()
+	s.db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/summary", nil)
+
+	s.svc.GetFleetSummary(w, r, summary.GetFleetSummaryParams{})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`{
+		"nodes": 0,
+		"failing": 0,
+		"changed": 0,
+		"unchanged": 0,
+		"stale": 0,
+		"median_runtime": "",
+		"reports_last_24h": 0
+	}`, w.Body.String())
+}
+
+func (s *FleetSuite) TestGetFleetSummaryError() {
+	s.db.On("GetRuns", mock.Anything).Return([]*entities.PuppetRun(nil), errors.New("database down")).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/summary", nil)
+
+	s.svc.GetFleetSummary(w, r, summary.GetFleetSummaryParams{})
+
+	s.Require().Equal(http.StatusInternalServerError, w.Code)
+}
+
+func (s *FleetSuite) TestGetEnvironments() {
+	s.db.On("GetEnvironments", mock.Anything).
+		Return([]summary.Environment{summary.Environment_STAGING, summary.Environment_PRODUCTION, summary.Environment_DEVELOPMENT}, nil).Once()
+	s.db.On("GetRuns", mock.Anything).Return(s.runs(), nil).Once()
+	s.db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/environments", nil)
+
+	s.svc.GetEnvironments(w, r, summary.GetEnvironmentsParams{})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`[
+		{"env":"DEVELOPMENT","nodes":0,"changed":0,"unchanged":0,"failed":0,"skipped":0,"stale":0},
+		{"env":"PRODUCTION","nodes":2,"changed":1,"unchanged":0,"failed":1,"skipped":0,"stale":0},
+		{"env":"STAGING","nodes":1,"changed":0,"unchanged":1,"failed":0,"skipped":0,"stale":1}
+	]`, w.Body.String())
+}
+
+func (s *FleetSuite) TestGetEnvironmentsError() {
+	s.db.On("GetEnvironments", mock.Anything).Return([]summary.Environment(nil), errors.New("database down")).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/environments", nil)
+
+	s.svc.GetEnvironments(w, r, summary.GetEnvironmentsParams{})
+
+	s.Require().Equal(http.StatusInternalServerError, w.Code)
+}
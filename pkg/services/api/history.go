@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+)
+
+// defaultHistoryRange is the range of the history returned when from is not set.
+const defaultHistoryRange = 30 * 24 * time.Hour
+
+func (s service) GetHistory(w http.ResponseWriter, r *http.Request, params summary.GetHistoryParams) {
+	bucket := summary.HistoryBucket_day
+	if params.Bucket != nil {
+		bucket = *params.Bucket
+	}
+	if !bucket.IsValid() {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Invalid bucket %s", bucket)); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	envs := make([]summary.Environment, 0)
+	if params.Env != nil {
+		envs = *params.Env
+	}
+	for _, env := range envs {
+		if !env.IsValid() {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(request.NewMessage("Invalid environment %s", env)); err != nil {
+				slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+			}
+			return
+		}
+	}
+
+	metrics := make([]string, 0)
+	if params.Metric != nil {
+		metrics = *params.Metric
+	}
+	for _, metric := range metrics {
+		if _, _, ok := entities.SplitMetricKey(metric); !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(request.NewMessage("Invalid metric %s", metric)); err != nil {
+				slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+			}
+			return
+		}
+	}
+
+	filter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
+	to := time.Now().UTC()
+	if params.To != nil {
+		to = *params.To
+	}
+	from := to.Add(-defaultHistoryRange)
+	if params.From != nil {
+		from = *params.From
+	}
+	if !from.Before(to) {
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("from must be before to")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	fqdns, err := filter.Fqdns(r.Context(), s.r)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting nodes", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting history")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	history, err := s.r.GetHistoryBuckets(r.Context(), bucket, from, to, fqdns, envs...)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting history", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting history")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	// The metrics are summed over the runs of each bucket, keyed by the date of the bucket.
+	sums := make(map[string]map[string]float64)
+	if len(metrics) > 0 {
+		// The metrics are read for every node, so only the values of the nodes selected by the filter are summed.
+		selected := make(map[string]struct{}, len(fqdns))
+		for _, fqdn := range fqdns {
+			selected[fqdn] = struct{}{}
+		}
+
+		values, err := s.r.GetRunMetrics(r.Context(), from, to, metrics, envs...)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				slog.Error("Error getting run metrics", slog.String(logging.KeyError, err.Error()))
+			}
+			// Respond with 500 internal server error.
+			w.WriteHeader(http.StatusInternalServerError)
+			if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting history")); err != nil {
+				slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+			}
+			return
+		}
+
+		for _, value := range values {
+			if _, ok := selected[value.Fqdn]; fqdns != nil && !ok {
+				continue
+			}
+
+			date := dataaccess.HistoryBucketDate(value.ExecTime.Time(), bucket)
+			if sums[date] == nil {
+				sums[date] = make(map[string]float64)
+			}
+			sums[date][value.Metric] += value.Value
+		}
+	}
+
+	resp := make([]summary.History, 0, len(history))
+	for _, h := range history {
+		entry := summary.History{
+			Changed:   &h.Changed,
+			Date:      &h.Date,
+			Failed:    &h.Failed,
+			Unchanged: &h.Unchanged,
+		}
+
+		if len(metrics) > 0 {
+			// Every requested metric is returned, so the buckets without it read as zero.
+			bucketMetrics := make(map[string]float64, len(metrics))
+			for _, metric := range metrics {
+				bucketMetrics[metric] = sums[h.Date][metric]
+			}
+			entry.Metrics = &bucketMetrics
+		}
+
+		resp = append(resp, entry)
+	}
+
+	historyRenderer.Render(w, r, http.StatusOK, resp)
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type GetHistorySuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db *dataaccess.MockDb
+
+	svc *service
+}
+
+func TestGetHistorySuite(t *testing.T) {
+	suite.Run(t, new(GetHistorySuite))
+}
+
+func (s *GetHistorySuite) SetupTest() {
+	s.db = new(dataaccess.MockDb)
+	s.svc = &service{
+		r: s.db,
+	}
+}
+
+func (s *GetHistorySuite) TearDownTest() {
+	s.db.AssertExpectations(s.T())
+	s.db = nil
+}
+
+func (s *GetHistorySuite) TestGetHistory() {
+	from := time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	history := []*entities.PuppetHistory{
+		{Date: "2024-02-13T01:00", Changed: 2, Failed: 1},
+		{Date: "2024-02-13T05:00", Unchanged: 3},
+	}
+
+	s.db.On("GetHistoryBuckets", mock.Anything, summary.HistoryBucket_hour, from, to, []string(nil), []summary.Environment{summary.Environment_PRODUCTION}).
+		Return(history, nil).Twice()
+
+	params := summary.GetHistoryParams{
+		Env:    &[]summary.Environment{summary.Environment_PRODUCTION},
+		From:   &from,
+		To:     &to,
+		Bucket: summary.Point(summary.HistoryBucket_hour),
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/history", nil)
+
+	s.svc.GetHistory(w, r, params)
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Equal("application/json", w.Header().Get("Content-Type"))
+	s.Require().JSONEq(`[
+		{"date":"2024-02-13T01:00","changed":2,"unchanged":0,"failed":1},
+		{"date":"2024-02-13T05:00","changed":0,"unchanged":3,"failed":0}
+	]`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/history?format=csv", nil)
+
+	s.svc.GetHistory(w, r, params)
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Equal("text/csv", w.Header().Get("Content-Type"))
+	s.Require().Equal("changed,date,failed,unchanged\n2,2024-02-13T01:00,1,0\n0,2024-02-13T05:00,0,3\n", w.Body.String())
+}
+
+func (s *GetHistorySuite) TestGetHistoryMetrics() {
+	from := time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC)
+	to := from.Add(48 * time.Hour)
+
+	history := []*entities.PuppetHistory{
+		{Date: "2024-02-13", Changed: 2},
+		{Date: "2024-02-14", Unchanged: 1},
+	}
+	metrics := []string{"resources.out_of_sync", "events.failure"}
+
+	s.db.On("GetHistoryBuckets", mock.Anything, summary.HistoryBucket_day, from, to, []string(nil), []summary.Environment{}).
+		Return(history, nil).Once()
+	s.db.On("GetRunMetrics", mock.Anything, from, to, metrics, []summary.Environment{}).
+		Return([]*entities.RunMetric{
+			{ReportID: "hash1", ExecTime: entities.Datetime(from.Add(time.Hour)), Metric: "resources.out_of_sync", Value: 4},
+			{ReportID: "hash2", ExecTime: entities.Datetime(from.Add(2 * time.Hour)), Metric: "events.failure", Value: 1},
+			{ReportID: "hash2", ExecTime: entities.Datetime(from.Add(2 * time.Hour)), Metric: "resources.out_of_sync", Value: 2},
+		}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/history", nil)
+
+	s.svc.GetHistory(w, r, summary.GetHistoryParams{
+		From:   &from,
+		To:     &to,
+		Metric: &metrics,
+	})
+
+	// The metrics are summed per bucket, and every requested metric is returned.
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`[
+		{"date":"2024-02-13","changed":2,"unchanged":0,"failed":0,"metrics":{"resources.out_of_sync":6,"events.failure":1}},
+		{"date":"2024-02-14","changed":0,"unchanged":1,"failed":0,"metrics":{"resources.out_of_sync":0,"events.failure":0}}
+	]`, w.Body.String())
+}
+
+func (s *GetHistorySuite) TestGetHistoryOwner() {
+	from := time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	metrics := []string{"resources.out_of_sync"}
+
+	s.db.On("GetAllNodeMetadata", mock.Anything).Return([]*entities.NodeMetadata{
+		{Fqdn: "node1", Owner: "platform"},
+		{Fqdn: "node2", Owner: "web", Labels: entities.Labels{"role": "proxy"}},
+	}, nil).Once()
+	s.db.On("GetHistoryBuckets", mock.Anything, summary.HistoryBucket_day, from, to, []string{"node2"}, []summary.Environment{}).
+		Return([]*entities.PuppetHistory{{Date: "2024-02-13", Failed: 1}}, nil).Once()
+	s.db.On("GetRunMetrics", mock.Anything, from, to, metrics, []summary.Environment{}).
+		Return([]*entities.RunMetric{
+			{ReportID: "hash1", Fqdn: "node1", ExecTime: entities.Datetime(from.Add(time.Hour)), Metric: "resources.out_of_sync", Value: 4},
+			{ReportID: "hash2", Fqdn: "node2", ExecTime: entities.Datetime(from.Add(2 * time.Hour)), Metric: "resources.out_of_sync", Value: 2},
+		}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/history?owner=web&label=role=proxy", nil)
+
+	s.svc.GetHistory(w, r, summary.GetHistoryParams{
+		From:   &from,
+		To:     &to,
+		Metric: &metrics,
+		Owner:  summary.Point("web"),
+		Label:  &[]string{"role=proxy"},
+	})
+
+	// Only the runs and the metrics of the selected nodes are counted.
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`[
+		{"date":"2024-02-13","changed":0,"unchanged":0,"failed":1,"metrics":{"resources.out_of_sync":2}}
+	]`, w.Body.String())
+}
+
+func (s *GetHistorySuite) TestGetHistoryDefaults() {
+	s.db.On("GetHistoryBuckets", mock.Anything, summary.HistoryBucket_day, mock.Anything, mock.Anything, []string(nil), []summary.Environment{}).
+		Run(func(args mock.Arguments) {
+			from, to := args.Get(2).(time.Time), args.Get(3).(time.Time)
+			s.Require().Equal(defaultHistoryRange, to.Sub(from))
+			s.Require().WithinDuration(time.Now(), to, time.Minute)
+		}).
+		Return([]*entities.PuppetHistory{}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/history", nil)
+
+	s.svc.GetHistory(w, r, summary.GetHistoryParams{})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Equal("[]\n", w.Body.String())
+}
+
+func (s *GetHistorySuite) TestGetHistoryBadRequest() {
+	from := time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]summary.GetHistoryParams{
+		"invalid bucket":      {Bucket: summary.Point(summary.HistoryBucket("month"))},
+		"invalid environment": {Env: &[]summary.Environment{"INVALID"}},
+		"from after to":       {From: summary.Point(from.Add(time.Hour)), To: &from},
+		"invalid metric":      {Metric: &[]string{"out_of_sync"}},
+		"invalid label":       {Label: &[]string{"role"}},
+	}
+	for name, params := range tests {
+		s.Run(name, func() {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/api/history", nil)
+
+			s.svc.GetHistory(w, r, params)
+
+			s.Require().Equal(http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func (s *GetHistorySuite) TestGetHistoryError() {
+	s.db.On("GetHistoryBuckets", mock.Anything, summary.HistoryBucket_day, mock.Anything, mock.Anything, []string(nil), []summary.Environment{}).
+		Return([]*entities.PuppetHistory(nil), errors.New("database down")).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/history", nil)
+
+	s.svc.GetHistory(w, r, summary.GetHistoryParams{})
+
+	s.Require().Equal(http.StatusInternalServerError, w.Code)
+}
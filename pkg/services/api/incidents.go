@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/incidents"
+)
+
+func (s service) GetIncidents(w http.ResponseWriter, r *http.Request, params summary.GetIncidentsParams) {
+	filter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
+	opts := new(incidents.Options)
+	if params.Env != nil {
+		opts.Envs = *params.Env
+	}
+	if params.From != nil {
+		opts.From = *params.From
+	}
+	if params.To != nil {
+		opts.To = *params.To
+	}
+
+	list, err := s.incidents.Incidents(r.Context(), filter, opts)
+	if errors.Is(err, aggregate.ErrInvalidOptions) {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage(err.Error())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting incidents", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting incidents")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	resp := make([]*summary.Incident, 0, len(list))
+	for _, inc := range list {
+		resp = append(resp, newIncident(inc))
+	}
+
+	incidentsRenderer.Render(w, r, http.StatusOK, resp)
+}
+
+func (s service) GetIncident(w http.ResponseWriter, r *http.Request, id string) {
+	inc, err := s.incidents.Incident(r.Context(), id)
+	if errors.Is(err, dataaccess.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Incident not found")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting incident", slog.String(logging.KeyError, err.Error()))
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting incident")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	incidentRenderer.Render(w, r, http.StatusOK, newIncident(inc))
+}
+
+// newIncident maps the incident to its API model.
+func newIncident(inc *entities.Incident) *summary.Incident {
+	resources := []string(inc.Resources)
+	if resources == nil {
+		resources = make([]string, 0)
+	}
+	nodes := []string(inc.Nodes)
+	if nodes == nil {
+		nodes = make([]string, 0)
+	}
+
+	return &summary.Incident{
+		ConfigurationVersion: &inc.ConfigVersion,
+		Env:                  &inc.Env,
+		FirstSeen:            summary.Point(inc.FirstSeen.Time()),
+		Id:                   &inc.ID,
+		LastSeen:             summary.Point(inc.LastSeen.Time()),
+		Message:              &inc.Message,
+		NodeCount:            summary.Point(len(nodes)),
+		Nodes:                &nodes,
+		Notified:             &inc.Notified,
+		Reports:              &inc.Reports,
+		Resources:            &resources,
+	}
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/incidents"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type GetIncidentsSuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db *dataaccess.MockDb
+
+	svc *service
+}
+
+func TestGetIncidentsSuite(t *testing.T) {
+	suite.Run(t, new(GetIncidentsSuite))
+}
+
+func (s *GetIncidentsSuite) SetupTest() {
+	s.db = new(dataaccess.MockDb)
+	s.svc = &service{
+		r:         s.db,
+		incidents: incidents.NewService(s.db, 0, nil),
+	}
+}
+
+func (s *GetIncidentsSuite) TearDownTest() {
+	s.db.AssertExpectations(s.T())
+	s.db = nil
+}
+
+var (
+	incidentsFrom = time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC)
+	incidentsTo   = time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC)
+)
+
+// incident returns an incident of two nodes failing on a package.
+func (s *GetIncidentsSuite) incident() *entities.Incident {
+	return &entities.Incident{
+		ID:            "r1",
+		Env:           summary.Environment_PRODUCTION,
+		ConfigVersion: "1708135209",
+		Resources:     entities.Strings{"Package[nginx]"},
+		Message:       "/Stage[main]/Nginx/Package[nginx]/ensure : change failed",
+		FirstSeen:     entities.Datetime(incidentsFrom.Add(time.Hour)),
+		LastSeen:      entities.Datetime(incidentsFrom.Add(2 * time.Hour)),
+		Nodes:         entities.Strings{"node1", "node2"},
+		Reports:       3,
+		Notified:      true,
+	}
+}
+
+func (s *GetIncidentsSuite) TestGetIncidents() {
+	s.db.On("GetIncidents", mock.Anything, incidentsFrom, incidentsTo, []summary.Environment{summary.Environment_PRODUCTION}).Return([]*entities.Incident{s.incident()}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/incidents", nil)
+
+	s.svc.GetIncidents(w, r, summary.GetIncidentsParams{
+		Env:  &[]summary.Environment{summary.Environment_PRODUCTION},
+		From: &incidentsFrom,
+		To:   &incidentsTo,
+	})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`[
+		{
+			"id": "r1",
+			"env": "PRODUCTION",
+			"configuration_version": "1708135209",
+			"resources": ["Package[nginx]"],
+			"message": "/Stage[main]/Nginx/Package[nginx]/ensure : change failed",
+			"first_seen": "2024-02-13T01:00:00Z",
+			"last_seen": "2024-02-13T02:00:00Z",
+			"nodes": ["node1", "node2"],
+			"node_count": 2,
+			"reports": 3,
+			"notified": true
+		}
+	]`, w.Body.String())
+}
+
+func (s *GetIncidentsSuite) TestGetIncidents_Owner() {
+	other := s.incident()
+	other.ID = "r2"
+	other.Nodes = entities.Strings{"node3"}
+	s.db.On("GetIncidents", mock.Anything, incidentsFrom, incidentsTo, []summary.Environment(nil)).Return([]*entities.Incident{s.incident(), other}, nil).Once()
+	s.db.On("GetAllNodeMetadata", mock.Anything).Return([]*entities.NodeMetadata{
+		{Fqdn: "node2", Owner: "web", Labels: entities.Labels{"role": "proxy"}},
+		{Fqdn: "node3", Owner: "platform"},
+	}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/incidents?owner=web&label=role=proxy", nil)
+
+	s.svc.GetIncidents(w, r, summary.GetIncidentsParams{
+		From:  &incidentsFrom,
+		To:    &incidentsTo,
+		Owner: summary.Point("web"),
+		Label: &[]string{"role=proxy"},
+	})
+
+	// Only the incidents with any of the selected nodes are listed.
+	s.Require().Equal(http.StatusOK, w.Code)
+	var resp []*summary.Incident
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	s.Require().Len(resp, 1)
+	s.Require().Equal("r1", *resp[0].Id)
+}
+
+func (s *GetIncidentsSuite) TestGetIncidents_InvalidLabel() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/incidents?label=role", nil)
+
+	s.svc.GetIncidents(w, r, summary.GetIncidentsParams{Label: &[]string{"role"}})
+
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+	s.Require().JSONEq(`{"message":"invalid label \"role\", expected key=value"}`, w.Body.String())
+}
+
+func (s *GetIncidentsSuite) TestGetIncidents_Invalid() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/incidents", nil)
+
+	s.svc.GetIncidents(w, r, summary.GetIncidentsParams{
+		From: &incidentsTo,
+		To:   &incidentsFrom,
+	})
+
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+	s.Require().JSONEq(`{"message":"invalid options: from must be before to"}`, w.Body.String())
+}
+
+func (s *GetIncidentsSuite) TestGetIncidents_Error() {
+	s.db.On("GetIncidents", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*entities.Incident(nil), errors.New("some error")).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/incidents", nil)
+
+	s.svc.GetIncidents(w, r, summary.GetIncidentsParams{})
+
+	s.Require().Equal(http.StatusInternalServerError, w.Code)
+	s.Require().JSONEq(`{"message":"Error getting incidents"}`, w.Body.String())
+}
+
+func (s *GetIncidentsSuite) TestGetIncident() {
+	s.db.On("GetIncident", mock.Anything, "r1").Return(s.incident(), nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/incidents/r1", nil)
+
+	s.svc.GetIncident(w, r, "r1")
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), `"node_count":2`)
+}
+
+func (s *GetIncidentsSuite) TestGetIncident_NotFound() {
+	s.db.On("GetIncident", mock.Anything, "missing").Return((*entities.Incident)(nil), dataaccess.ErrNotFound).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/incidents/missing", nil)
+
+	s.svc.GetIncident(w, r, "missing")
+
+	s.Require().Equal(http.StatusNotFound, w.Code)
+	s.Require().JSONEq(`{"message":"Incident not found"}`, w.Body.String())
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/jobs"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+)
+
+// jobTypePurge is the type of the background job that purges reports.
+const jobTypePurge = "purge"
+
+func (s service) GetJob(w http.ResponseWriter, _ *http.Request, id string) {
+	job, ok := s.jobs.Get(id)
+	if !ok {
+		s.jobNotFound(w, id)
+		return
+	}
+
+	s.writeJob(w, http.StatusOK, job)
+}
+
+func (s service) CancelJob(w http.ResponseWriter, _ *http.Request, id string) {
+	job, ok := s.jobs.Get(id)
+	if !ok {
+		s.jobNotFound(w, id)
+		return
+	}
+
+	if !job.Cancel() {
+		slog.Debug("job already finished", slog.String("job", id))
+		s.writeJob(w, http.StatusConflict, job)
+		return
+	}
+
+	slog.Info("job cancellation requested", slog.String("job", id))
+	s.writeJob(w, http.StatusAccepted, job)
+}
+
+// jobNotFound responds that the job with the given ID does not exist.
+func (s service) jobNotFound(w http.ResponseWriter, id string) {
+	slog.Debug("job not found", slog.String("job", id))
+
+	w.WriteHeader(http.StatusNotFound)
+	if err := json.NewEncoder(w).Encode(request.NewMessage("job not found")); err != nil {
+		slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// writeJob responds with the current state of the job.
+func (s service) writeJob(w http.ResponseWriter, status int, job *jobs.Job) {
+	snap := job.Snapshot()
+
+	resp := &summary.Job{
+		Id:        summary.Point(snap.ID),
+		Instance:  summary.Point(snap.Instance),
+		Progress:  &snap.Progress,
+		StartedAt: summary.Point(snap.StartedAt),
+		Status:    summary.Point(summary.JobStatus(snap.Status)),
+		Type:      summary.Point(snap.Type),
+	}
+	if !snap.FinishedAt.IsZero() {
+		resp.FinishedAt = summary.Point(snap.FinishedAt)
+	}
+	if snap.Error != "" {
+		resp.Error = summary.Point(snap.Error)
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
+	}
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/jobs"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/scheduler"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/purge"
+	"github.com/stretchr/testify/suite"
+)
+
+// blockingPurger is a purger that blocks until it is released or cancelled.
+type blockingPurger struct {
+	release chan struct{}
+}
+
+func (p *blockingPurger) PurgePuppetReports(ctx context.Context, _ int, progress purge.Progress) error {
+	progress.Set(purge.CounterReportsTotal, 10)
+
+	select {
+	case <-p.release:
+		progress.Add(purge.CounterReportsDeleted, 10)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *blockingPurger) PreviewPurge(context.Context, int) (*purge.Preview, error) {
+	return new(purge.Preview), nil
+}
+
+func (p *blockingPurger) SetupPurge(*scheduler.Scheduler, string, int) error {
+	return nil
+}
+
+type PurgeJobSuite struct {
+	suite.Suite
+
+	purger *blockingPurger
+
+	svc *service
+}
+
+func TestPurgeJobSuite(t *testing.T) {
+	suite.Run(t, new(PurgeJobSuite))
+}
+
+func (s *PurgeJobSuite) SetupTest() {
+	s.purger = &blockingPurger{release: make(chan struct{})}
+	s.svc = &service{
+		purger: s.purger,
+		jobs:   jobs.NewRegistry("instance1"),
+	}
+}
+
+// startPurge starts a purge through the API, returning the response code and job.
+func (s *PurgeJobSuite) startPurge() (int, *summary.Job) {
+	date := time.Now().UTC().AddDate(0, 0, -10).Format(time.DateOnly)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("DELETE", "/api/purge", strings.NewReader(`{"date": "`+date+`"}`))
+	s.svc.PurgePuppetReports(w, r)
+
+	job := new(summary.Job)
+	s.Require().NoError(json.NewDecoder(w.Body).Decode(job))
+	return w.Code, job
+}
+
+// getJob gets the job through the API, returning the response code and job.
+func (s *PurgeJobSuite) getJob(id string) (int, *summary.Job) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api/jobs/"+id, nil)
+	s.svc.GetJob(w, r, id)
+
+	job := new(summary.Job)
+	s.Require().NoError(json.NewDecoder(w.Body).Decode(job))
+	return w.Code, job
+}
+
+// waitForJob waits for the job with the given ID to finish.
+func (s *PurgeJobSuite) waitForJob(id string) {
+	job, ok := s.svc.jobs.Get(id)
+	s.Require().True(ok)
+
+	select {
+	case <-job.Done():
+	case <-time.After(5 * time.Second):
+		s.FailNow("timed out waiting for job to finish")
+	}
+}
+
+func (s *PurgeJobSuite) TestPurgeRunsInBackground() {
+	code, job := s.startPurge()
+	s.Require().Equal(202, code)
+	s.Require().Equal(summary.JobStatus_RUNNING, *job.Status)
+	s.Require().Equal(jobTypePurge, *job.Type)
+	s.Require().Equal("instance1", *job.Instance)
+
+	// A second purge is rejected while the first is running.
+	code, running := s.startPurge()
+	s.Require().Equal(409, code)
+	s.Require().Equal(*job.Id, *running.Id)
+
+	close(s.purger.release)
+	s.waitForJob(*job.Id)
+
+	code, job = s.getJob(*job.Id)
+	s.Require().Equal(200, code)
+	s.Require().Equal(summary.JobStatus_SUCCEEDED, *job.Status)
+	s.Require().NotNil(job.FinishedAt)
+	s.Require().Equal(map[string]int{
+		purge.CounterReportsTotal:   10,
+		purge.CounterReportsDeleted: 10,
+	}, *job.Progress)
+}
+
+func (s *PurgeJobSuite) TestCancelJob() {
+	_, job := s.startPurge()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("DELETE", "/api/jobs/"+*job.Id, nil)
+	s.svc.CancelJob(w, r, *job.Id)
+	s.Require().Equal(202, w.Code)
+
+	s.waitForJob(*job.Id)
+
+	code, job := s.getJob(*job.Id)
+	s.Require().Equal(200, code)
+	s.Require().Equal(summary.JobStatus_CANCELLED, *job.Status)
+
+	// A finished job cannot be cancelled.
+	w = httptest.NewRecorder()
+	s.svc.CancelJob(w, r, *job.Id)
+	s.Require().Equal(409, w.Code)
+}
+
+func (s *PurgeJobSuite) TestGetJobNotFound() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api/jobs/missing", nil)
+	s.svc.GetJob(w, r, "missing")
+
+	s.Require().Equal(404, w.Code)
+	s.Require().JSONEq(`{"message": "job not found"}`, w.Body.String())
+}
+
+func (s *PurgeJobSuite) TestPurgeLockedByAnotherInstance() {
+	db := dataaccess.NewMemory()
+	s.svc.scheduler = scheduler.New(db)
+
+	// Another instance is running the scheduled purge, so holds its lock.
+	held, err := db.AcquireLock(context.Background(), "job_"+purge.JobName, "instance2", time.Hour)
+	s.Require().NoError(err)
+	s.Require().True(held)
+
+	code, job := s.startPurge()
+	s.Require().Equal(202, code)
+	s.waitForJob(*job.Id)
+
+	code, job = s.getJob(*job.Id)
+	s.Require().Equal(200, code)
+	s.Require().Equal(summary.JobStatus_FAILED, *job.Status)
+	s.Require().Equal(scheduler.ErrLocked.Error(), *job.Error)
+
+	// Once the other instance has finished, the purge runs.
+	s.Require().NoError(db.ReleaseLock(context.Background(), "job_"+purge.JobName, "instance2"))
+	close(s.purger.release)
+
+	_, job = s.startPurge()
+	s.waitForJob(*job.Id)
+
+	_, job = s.getJob(*job.Id)
+	s.Require().Equal(summary.JobStatus_SUCCEEDED, *job.Status)
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+)
+
+func (s service) GetNodeMetadata(w http.ResponseWriter, r *http.Request, fqdn string) {
+	md, err := s.r.GetNodeMetadata(r.Context(), fqdn)
+	if errors.Is(err, dataaccess.ErrNotFound) {
+		// Respond with 404 not found.
+		w.WriteHeader(http.StatusNotFound)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("No metadata found for node %s", fqdn)); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		slog.Error("Error getting node metadata", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting node metadata")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	metadataRenderer.Render(w, r, http.StatusOK, newNodeMetadata(md))
+}
+
+func (s service) PutNodeMetadata(w http.ResponseWriter, r *http.Request, fqdn string) {
+	if r.Body == http.NoBody {
+		slog.Warn("missing request body")
+
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("missing request body")); err != nil {
+			slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	// Decode the request.
+	req := new(summary.PutNodeMetadataJSONRequestBody)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		slog.Warn("failed to decode request", slog.String(logging.KeyError, err.Error()))
+
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("failed to decode request")); err != nil {
+			slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	md := &entities.NodeMetadata{
+		Fqdn:      fqdn,
+		Labels:    make(entities.Labels),
+		UpdatedAt: entities.Datetime(time.Now().UTC().Truncate(time.Second)),
+	}
+	if req.Owner != nil {
+		md.Owner = strings.TrimSpace(*req.Owner)
+	}
+	if req.Role != nil {
+		md.Role = strings.TrimSpace(*req.Role)
+	}
+	if req.Notes != nil {
+		md.Notes = *req.Notes
+	}
+	if req.Labels != nil {
+		for k, v := range *req.Labels {
+			// The labels are filtered on as key=value, so the key can not contain an =.
+			k = strings.TrimSpace(k)
+			if k == "" || strings.Contains(k, "=") {
+				w.WriteHeader(http.StatusBadRequest)
+				if err := json.NewEncoder(w).Encode(request.NewMessage("Invalid label key %q", k)); err != nil {
+					slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+				}
+				return
+			}
+			md.Labels[k] = strings.TrimSpace(v)
+		}
+	}
+
+	if err := s.r.SaveNodeMetadata(r.Context(), md); err != nil {
+		slog.Error("Error saving node metadata", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error saving node metadata")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	metadataRenderer.Render(w, r, http.StatusOK, newNodeMetadata(md))
+}
+
+func (s service) DeleteNodeMetadata(w http.ResponseWriter, r *http.Request, fqdn string) {
+	err := s.r.DeleteNodeMetadata(r.Context(), fqdn)
+	if errors.Is(err, dataaccess.ErrNotFound) {
+		// Respond with 404 not found.
+		w.WriteHeader(http.StatusNotFound)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("No metadata found for node %s", fqdn)); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		slog.Error("Error deleting node metadata", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error deleting node metadata")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newNodeMetadata returns the API model of the metadata of a node.
+func newNodeMetadata(md *entities.NodeMetadata) *summary.NodeMetadata {
+	labels := map[string]string(md.Labels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	return &summary.NodeMetadata{
+		Fqdn:      &md.Fqdn,
+		Owner:     &md.Owner,
+		Role:      &md.Role,
+		Notes:     &md.Notes,
+		Labels:    &labels,
+		UpdatedAt: summary.Point(md.UpdatedAt.Time()),
+	}
+}
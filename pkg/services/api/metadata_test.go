@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/suite"
+)
+
+type NodeMetadataSuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db dataaccess.Database
+
+	svc *service
+}
+
+func TestNodeMetadataSuite(t *testing.T) {
+	suite.Run(t, new(NodeMetadataSuite))
+}
+
+func (s *NodeMetadataSuite) SetupTest() {
+	s.db = dataaccess.NewMemory()
+	s.svc = &service{
+		r:          s.db,
+		staleAfter: DefaultStaleAfter,
+	}
+}
+
+// put sets the metadata of the node with the given body.
+func (s *NodeMetadataSuite) put(fqdn, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/api/nodes/"+fqdn+"/metadata", strings.NewReader(body))
+
+	s.svc.PutNodeMetadata(w, r, fqdn)
+	return w
+}
+
+func (s *NodeMetadataSuite) TestPutGetDelete() {
+	w := s.put("node1", `{"owner":"team-a","role":"web","notes":"Public site","labels":{"dc":"lon"}}`)
+	s.Require().Equal(http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/nodes/node1/metadata", nil)
+	s.svc.GetNodeMetadata(w, r, "node1")
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), `"owner":"team-a"`)
+	s.Require().Contains(w.Body.String(), `"labels":{"dc":"lon"}`)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/nodes/node1/metadata", nil)
+	s.svc.DeleteNodeMetadata(w, r, "node1")
+	s.Require().Equal(http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/api/nodes/node1/metadata", nil)
+	s.svc.GetNodeMetadata(w, r, "node1")
+	s.Require().Equal(http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/api/nodes/node1/metadata", nil)
+	s.svc.DeleteNodeMetadata(w, r, "node1")
+	s.Require().Equal(http.StatusNotFound, w.Code)
+}
+
+func (s *NodeMetadataSuite) TestPutBadRequest() {
+	tests := map[string]string{
+		"invalid json":     `{`,
+		"empty label key":  `{"labels":{"":"x"}}`,
+		"label key with =": `{"labels":{"a=b":"x"}}`,
+	}
+	for name, body := range tests {
+		s.Run(name, func() {
+			w := s.put("node1", body)
+			s.Require().Equal(http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
+func (s *NodeMetadataSuite) TestGetAllNodesFiltered() {
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, fqdn := range []string{"node1", "node2", "node3"} {
+		s.Require().NoError(s.db.SaveRun(context.Background(), &entities.PuppetReport{
+			ID:       fqdn,
+			Fqdn:     fqdn,
+			Env:      summary.Environment_PRODUCTION,
+			State:    summary.State_CHANGED,
+			ExecTime: entities.Datetime(now),
+		}))
+	}
+
+	s.Require().Equal(http.StatusOK, s.put("node1", `{"owner":"team-a","labels":{"role":"web"}}`).Code)
+	s.Require().Equal(http.StatusOK, s.put("node2", `{"owner":"team-a","labels":{"role":"db"}}`).Code)
+	s.Require().Equal(http.StatusOK, s.put("node3", `{"owner":"team-b","labels":{"role":"web"}}`).Code)
+
+	tests := []struct {
+		name   string
+		params summary.GetAllNodesParams
+		want   []string
+	}{
+		{
+			name:   "owner",
+			params: summary.GetAllNodesParams{Owner: summary.Point("team-a")},
+			want:   []string{"node1", "node2"},
+		},
+		{
+			name:   "label",
+			params: summary.GetAllNodesParams{Label: &[]string{"role=web"}},
+			want:   []string{"node1", "node3"},
+		},
+		{
+			name:   "owner and label",
+			params: summary.GetAllNodesParams{Owner: summary.Point("team-a"), Label: &[]string{"role=web"}},
+			want:   []string{"node1"},
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/api/nodes", nil)
+
+			s.svc.GetAllNodes(w, r, tt.params)
+
+			s.Require().Equal(http.StatusOK, w.Code)
+			for _, fqdn := range []string{"node1", "node2", "node3"} {
+				if slices.Contains(tt.want, fqdn) {
+					s.Require().Contains(w.Body.String(), `"fqdn":"`+fqdn+`"`)
+				} else {
+					s.Require().NotContains(w.Body.String(), `"fqdn":"`+fqdn+`"`)
+				}
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/nodes", nil)
+	s.svc.GetAllNodes(w, r, summary.GetAllNodesParams{Label: &[]string{"role"}})
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"log/slog"
 	"net/http"
 	"sort"
@@ -15,11 +14,17 @@ import (
 	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
 )
 
-func (s service) GetAllNodes(w http.ResponseWriter, r *http.Request) {
-	nodes, err := s.r.GetRuns(r.Context())
-	if err != nil && !errors.Is(err, dataaccess.ErrNotFound) {
+func (s service) GetAllNodes(w http.ResponseWriter, r *http.Request, params summary.GetAllNodesParams) {
+	filter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
+	nodes, err := s.listedRuns(r.Context(), filter)
+	if err != nil {
 		// Respond with 500 internal server error.
 		w.WriteHeader(http.StatusInternalServerError)
 		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting nodes")); err != nil {
@@ -28,23 +33,7 @@ func (s service) GetAllNodes(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	nodesMap := make(map[string]*entities.PuppetRun)
-	for _, node := range nodes {
-		node.CalculateTimeSince()
-
-		// Create a key for the node. This should be the FQDN and the environment.
-		key := fmt.Sprintf("%s-%s", node.Fqdn, node.Env)
-
-		// Now check if the node is already in the map.
-		if _, ok := nodesMap[key]; !ok {
-			nodesMap[key] = node
-		} else {
-			// The node is already in the map, so we need to check if the node has a newer timestamp.
-			if node.ExecTime.Time().After(nodesMap[key].ExecTime.Time()) {
-				nodesMap[key] = node
-			}
-		}
-	}
+	nodesMap := latestRuns(nodes)
 
 	// Create the response.
 	mappedNodes := make([]summary.Node, 0, len(nodesMap))
@@ -76,16 +65,17 @@ func (s service) GetAllNodes(w http.ResponseWriter, r *http.Request) {
 	nodesResponse := new(summary.NodesResponse)
 	nodesResponse.Nodes = &mappedNodes
 
-	w.Header().Set("content-type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(mappedNodes); err != nil {
-		slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
-	}
+	nodesRenderer.Render(w, r, http.StatusOK, mappedNodes)
 }
 
-func (s service) GetAllNodesByEnvironment(w http.ResponseWriter, r *http.Request, env summary.Environment) {
-	nodes, err := s.r.GetRuns(r.Context())
-	if err != nil && !errors.Is(err, dataaccess.ErrNotFound) {
+func (s service) GetAllNodesByEnvironment(w http.ResponseWriter, r *http.Request, env summary.Environment, params summary.GetAllNodesByEnvironmentParams) {
+	filter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
+	nodes, err := s.listedRuns(r.Context(), filter)
+	if err != nil {
 		// Respond with 500 internal server error.
 		w.WriteHeader(http.StatusInternalServerError)
 		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting nodes")); err != nil {
@@ -94,27 +84,13 @@ func (s service) GetAllNodesByEnvironment(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	nodesMap := make(map[string]*entities.PuppetRun)
+	envNodes := make([]*entities.PuppetRun, 0, len(nodes))
 	for _, node := range nodes {
-		if node.Env != env {
-			continue
-		}
-
-		node.CalculateTimeSince()
-
-		// Create a key for the node. This should be the FQDN and the environment.
-		key := fmt.Sprintf("%s-%s", node.Fqdn, node.Env)
-
-		// Now check if the node is already in the map.
-		if _, ok := nodesMap[key]; !ok {
-			nodesMap[key] = node
-		} else {
-			// The node is already in the map, so we need to check if the node has a newer timestamp.
-			if node.ExecTime.Time().After(nodesMap[key].ExecTime.Time()) {
-				nodesMap[key] = node
-			}
+		if node.Env == env {
+			envNodes = append(envNodes, node)
 		}
 	}
+	nodesMap := latestRuns(envNodes)
 
 	// Create the response.
 	mappedNodes := make([]summary.Node, 0, len(nodesMap))
@@ -131,11 +107,7 @@ func (s service) GetAllNodesByEnvironment(w http.ResponseWriter, r *http.Request
 	nodesResponse := new(summary.NodesResponse)
 	nodesResponse.Nodes = &mappedNodes
 
-	w.Header().Set("content-type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(mappedNodes); err != nil {
-		slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
-	}
+	nodesRenderer.Render(w, r, http.StatusOK, mappedNodes)
 }
 
 func (s service) GetNodeByFqdn(w http.ResponseWriter, r *http.Request, fqdn string) {
@@ -179,7 +151,52 @@ func (s service) GetNodeByFqdn(w http.ResponseWriter, r *http.Request, fqdn stri
 		})
 	}
 
-	w.Header().Set("content-type", "application/json")
+	reportsRenderer.Render(w, r, http.StatusOK, resp)
+}
+
+func (s service) DeleteNode(w http.ResponseWriter, r *http.Request, fqdn string, params summary.DeleteNodeParams) {
+	resp := &summary.NodeDeletion{
+		Fqdn:           &fqdn,
+		Decommissioned: summary.Point(params.Decommission != nil && *params.Decommission),
+		ReportsDeleted: summary.Point(0),
+		FilesDeleted:   summary.Point(0),
+		FilesFailed:    summary.Point(0),
+	}
+
+	var err error
+	if *resp.Decommissioned {
+		err = s.nodes.Decommission(r.Context(), fqdn)
+	} else {
+		var res *nodes.Result
+		res, err = s.nodes.Delete(r.Context(), fqdn)
+		if err == nil {
+			resp.ReportsDeleted = &res.ReportsDeleted
+			resp.FilesDeleted = &res.FilesDeleted
+			resp.FilesFailed = &res.FilesFailed
+		}
+	}
+
+	switch {
+	case errors.Is(err, dataaccess.ErrNotFound):
+		// Respond with 404 not found.
+		w.WriteHeader(http.StatusNotFound)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("No reports found for node %s", fqdn)); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	case err != nil:
+		slog.Error("Error deleting node",
+			slog.String(logging.KeyFqdn, fqdn),
+			slog.String(logging.KeyError, err.Error()),
+		)
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error deleting node")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
@@ -1,6 +1,8 @@
 package api
 
 import (
+	"errors"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
@@ -8,6 +10,8 @@ import (
 	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -69,8 +73,9 @@ func (s *GetAllNodesSuite) TestGetAllNodes() {
 
 	// Test the index handler with the API.
 	m.On("GetRuns", r.Context()).Return(runs, nil).Once()
+	m.On("GetDecommissions", r.Context()).Return(map[string]time.Time{}, nil).Once()
 
-	s.svc.GetAllNodes(w, r)
+	s.svc.GetAllNodes(w, r, summary.GetAllNodesParams{})
 
 	s.Equal(200, w.Code)
 	s.Equal("application/json", w.Header().Get("Content-Type"))
@@ -81,3 +86,155 @@ func (s *GetAllNodesSuite) TestGetAllNodes() {
 
 	s.db.AssertExpectations(s.T())
 }
+
+func (s *GetAllNodesSuite) TestGetAllNodesDecommissioned() {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	runs := []*entities.PuppetRun{
+		{
+			Fqdn:     "test1",
+			Env:      summary.Environment_PRODUCTION,
+			ExecTime: entities.Datetime(now),
+			Runtime:  entities.Duration(10 * time.Second),
+			State:    summary.State_CHANGED,
+		},
+		{
+			Fqdn:     "test2",
+			Env:      summary.Environment_PRODUCTION,
+			ExecTime: entities.Datetime(now),
+			Runtime:  entities.Duration(10 * time.Second),
+			State:    summary.State_FAILED,
+		},
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/nodes", nil)
+
+	s.db.On("GetRuns", mock.Anything).Return(runs, nil).Once()
+	s.db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{"test2": now.Add(time.Minute)}, nil).Once()
+
+	s.svc.GetAllNodes(w, r, summary.GetAllNodesParams{})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`[
+		{"env":"PRODUCTION","exec_time":"`+now.Format(time.RFC3339)+`","fqdn":"test1","runtime":"10s","state":"CHANGED"}
+	]`, w.Body.String())
+
+	s.db.AssertExpectations(s.T())
+}
+
+type DeleteNodeSuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db *dataaccess.MockDb
+
+	// files is the storage used for testing.
+	files *dataaccess.MockStorage
+
+	svc *service
+}
+
+func TestDeleteNodeSuite(t *testing.T) {
+	suite.Run(t, new(DeleteNodeSuite))
+}
+
+func (s *DeleteNodeSuite) SetupTest() {
+	s.db = new(dataaccess.MockDb)
+	s.files = new(dataaccess.MockStorage)
+	dataaccess.Files = s.files
+	s.svc = &service{
+		r:     s.db,
+		nodes: nodes.NewService(s.db),
+	}
+}
+
+func (s *DeleteNodeSuite) TearDownTest() {
+	s.db.AssertExpectations(s.T())
+	s.files.AssertExpectations(s.T())
+	dataaccess.Files = nil
+	s.db = nil
+	s.files = nil
+}
+
+// reports returns the reports of the node used by the tests.
+func (s *DeleteNodeSuite) reports() []*entities.PuppetReportSummary {
+	return []*entities.PuppetReportSummary{
+		{
+			ID:       "1",
+			Fqdn:     "test1",
+			Env:      summary.Environment_PRODUCTION,
+			ExecTime: entities.Datetime(time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)),
+			// The node reported its local time, so the file is named after it.
+			YamlFile: "reports/PRODUCTION/test1/2024-02-13T11:00:00+01:00.yaml",
+		},
+	}
+}
+
+func (s *DeleteNodeSuite) TestDeleteNode() {
+	s.db.On("GetReports", mock.Anything, "test1").Return(s.reports(), nil).Once()
+	s.db.On("DeleteReports", mock.Anything, []string{"1"}).Return(1, nil).Once()
+	s.db.On("RecommissionNode", mock.Anything, "test1").Return(nil).Once()
+	s.db.On("DeleteNodeMetadata", mock.Anything, "test1").Return(nil).Once()
+	s.files.On("DeleteFile", mock.Anything, "reports/PRODUCTION/test1/2024-02-13T11:00:00+01:00.yaml").Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/api/nodes/test1", nil)
+
+	s.svc.DeleteNode(w, r, "test1", summary.DeleteNodeParams{})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`{"fqdn":"test1","decommissioned":false,"reports_deleted":1,"files_deleted":1,"files_failed":0}`, w.Body.String())
+}
+
+func (s *DeleteNodeSuite) TestDeleteNodeFileFailed() {
+	s.db.On("GetReports", mock.Anything, "test1").Return(s.reports(), nil).Once()
+	s.db.On("DeleteReports", mock.Anything, []string{"1"}).Return(1, nil).Once()
+	s.db.On("RecommissionNode", mock.Anything, "test1").Return(nil).Once()
+	s.db.On("DeleteNodeMetadata", mock.Anything, "test1").Return(nil).Once()
+	s.files.On("DeleteFile", mock.Anything, "reports/PRODUCTION/test1/2024-02-13T11:00:00+01:00.yaml").
+		Return(errors.New("permission denied")).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/api/nodes/test1", nil)
+
+	s.svc.DeleteNode(w, r, "test1", summary.DeleteNodeParams{})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`{"fqdn":"test1","decommissioned":false,"reports_deleted":1,"files_deleted":0,"files_failed":1}`, w.Body.String())
+}
+
+func (s *DeleteNodeSuite) TestDeleteNodeDecommission() {
+	s.db.On("GetReports", mock.Anything, "test1").Return(s.reports(), nil).Once()
+	s.db.On("DecommissionNode", mock.Anything, "test1", mock.Anything).Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/api/nodes/test1?decommission=true", nil)
+
+	s.svc.DeleteNode(w, r, "test1", summary.DeleteNodeParams{Decommission: summary.Point(true)})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`{"fqdn":"test1","decommissioned":true,"reports_deleted":0,"files_deleted":0,"files_failed":0}`, w.Body.String())
+}
+
+func (s *DeleteNodeSuite) TestDeleteNodeNotFound() {
+	s.db.On("GetReports", mock.Anything, "test1").Return([]*entities.PuppetReportSummary{}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/api/nodes/test1", nil)
+
+	s.svc.DeleteNode(w, r, "test1", summary.DeleteNodeParams{})
+
+	s.Require().Equal(http.StatusNotFound, w.Code)
+}
+
+func (s *DeleteNodeSuite) TestDeleteNodeError() {
+	s.db.On("GetReports", mock.Anything, "test1").Return([]*entities.PuppetReportSummary(nil), errors.New("database down")).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/api/nodes/test1", nil)
+
+	s.svc.DeleteNode(w, r, "test1", summary.DeleteNodeParams{})
+
+	s.Require().Equal(http.StatusInternalServerError, w.Code)
+}
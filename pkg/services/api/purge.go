@@ -1,14 +1,18 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/jobs"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/purge"
 )
 
 func (s service) PurgePuppetReports(w http.ResponseWriter, r *http.Request) {
@@ -48,19 +52,90 @@ func (s service) PurgePuppetReports(w http.ResponseWriter, r *http.Request) {
 	// Calculate the number of days between the date and now.
 	days := int(time.Since(req.Date.Time).Hours() / 24)
 
-	// Purge the reports.
-	if err := s.purger.PurgePuppetReports(days); err != nil {
-		slog.Error("failed to purge reports", slog.String(logging.KeyError, err.Error()))
+	if req.DryRun != nil && *req.DryRun {
+		s.previewPurge(w, r, days)
+		return
+	}
+
+	// Purge the reports in the background, as a large purge can take longer than the request is allowed to. The purge
+	// holds the lock of the scheduled purge, so it fails rather than run alongside a purge on another instance.
+	job, err := s.jobs.Start(jobTypePurge, func(ctx context.Context, job *jobs.Job) error {
+		return s.scheduler.RunExclusive(ctx, purge.JobName, func(ctx context.Context) error {
+			return s.purger.PurgePuppetReports(ctx, days, job)
+		})
+	})
+	switch {
+	case errors.Is(err, jobs.ErrAlreadyRunning):
+		slog.Warn("purge already running", slog.String("job", job.ID()))
+		s.writeJob(w, http.StatusConflict, job)
+		return
+	case err != nil:
+		slog.Error("failed to start purge", slog.String(logging.KeyError, err.Error()))
+
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("failed to start purge")); err != nil {
+			slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	s.writeJob(w, http.StatusAccepted, job)
+}
+
+func (s service) PreviewPurgePuppetReports(w http.ResponseWriter, r *http.Request, params summary.PreviewPurgePuppetReportsParams) {
+	// Ensure that the date is in the past.
+	if params.Date.IsZero() || params.Date.After(time.Now().UTC()) {
+		slog.Warn("invalid date", slog.String("date", params.Date.String()))
+
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("invalid date")); err != nil {
+			slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	// Calculate the number of days between the date and now.
+	days := int(time.Since(params.Date.Time).Hours() / 24)
+
+	s.previewPurge(w, r, days)
+}
+
+// previewPurge responds with the reports that would be purged for the given number of days.
+func (s service) previewPurge(w http.ResponseWriter, r *http.Request, days int) {
+	preview, err := s.purger.PreviewPurge(r.Context(), days)
+	if err != nil {
+		slog.Error("failed to preview purge", slog.String(logging.KeyError, err.Error()))
 
 		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(request.NewMessage("failed to purge reports")); err != nil {
+		if err := json.NewEncoder(w).Encode(request.NewMessage("failed to preview purge")); err != nil {
 			slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
 		}
 		return
 	}
 
-	w.WriteHeader(http.StatusAccepted)
-	if err := json.NewEncoder(w).Encode(request.NewMessage("reports purged")); err != nil {
+	groups := make([]summary.PurgePreviewGroup, len(preview.Groups))
+	for i, group := range preview.Groups {
+		groups[i] = summary.PurgePreviewGroup{
+			Env:     summary.Point(group.Environment),
+			Files:   summary.Point(group.Files),
+			Reports: summary.Point(group.Reports),
+			State:   summary.Point(group.State),
+		}
+	}
+
+	resp := &summary.PurgePreview{
+		Files:   summary.Point(preview.Files),
+		Groups:  &groups,
+		Reports: summary.Point(preview.Reports),
+	}
+	if !preview.Oldest.IsZero() {
+		resp.Oldest = summary.Point(preview.Oldest)
+		resp.Newest = summary.Point(preview.Newest)
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
 		slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
 	}
 }
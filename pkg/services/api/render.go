@@ -0,0 +1,59 @@
+package api
+
+import "github.com/Jacobbrewer1/puppet-summary/pkg/request"
+
+var (
+	// nodesRenderer renders lists of nodes.
+	nodesRenderer = request.Renderer{Root: "nodes", Item: "node", Tabular: true}
+
+	// reportsRenderer renders lists of report summaries.
+	reportsRenderer = request.Renderer{Root: "reports", Item: "report", Tabular: true}
+
+	// environmentsRenderer renders the summaries of the environments.
+	environmentsRenderer = request.Renderer{Root: "environments", Item: "environment", Tabular: true}
+
+	// fleetSummaryRenderer renders the summary of the fleet.
+	fleetSummaryRenderer = request.Renderer{Root: "summary"}
+
+	// historyRenderer renders the buckets of history.
+	historyRenderer = request.Renderer{Root: "history", Item: "bucket", Tabular: true}
+
+	// metadataRenderer renders the metadata of a node.
+	metadataRenderer = request.Renderer{Root: "metadata"}
+
+	// silenceRenderer renders a single silence.
+	silenceRenderer = request.Renderer{Root: "silence"}
+
+	// silencesRenderer renders lists of silences.
+	silencesRenderer = request.Renderer{Root: "silences", Item: "silence", Tabular: true}
+
+	// flappingRenderer renders lists of flapping nodes. The nodes hold lists of runs and resources, so they are not
+	// tabular.
+	flappingRenderer = request.Renderer{Root: "nodes", Item: "node"}
+
+	// failureLeaderboardRenderer renders the leaderboard of the failing resources.
+	failureLeaderboardRenderer = request.Renderer{Root: "leaderboard"}
+
+	// failureNodesRenderer renders lists of nodes failing on resources. The nodes hold lists of reports, so they are not
+	// tabular.
+	failureNodesRenderer = request.Renderer{Root: "nodes", Item: "node"}
+
+	// incidentRenderer renders a single incident.
+	incidentRenderer = request.Renderer{Root: "incident"}
+
+	// incidentsRenderer renders lists of incidents. The incidents hold lists of resources and nodes, so they are not
+	// tabular.
+	incidentsRenderer = request.Renderer{Root: "incidents", Item: "incident"}
+
+	// anomaliesRenderer renders lists of runtime anomalies.
+	anomaliesRenderer = request.Renderer{Root: "anomalies", Item: "anomaly", Tabular: true}
+
+	// rolloutRenderer renders the rollout of the code in an environment.
+	rolloutRenderer = request.Renderer{Root: "rollout"}
+
+	// timingsRenderer renders the breakdown of the time of the runs.
+	timingsRenderer = request.Renderer{Root: "timings"}
+
+	// reportRenderer renders a single report.
+	reportRenderer = request.Renderer{Root: "report"}
+)
@@ -44,7 +44,7 @@ func (s service) GetReportById(w http.ResponseWriter, r *http.Request, id string
 	}
 
 	// Get the Yaml report from Files.
-	file, err := dataaccess.Files.DownloadFile(r.Context(), rep.ReportFilePath())
+	file, err := dataaccess.Files.DownloadFile(r.Context(), rep.YamlFile)
 	if err != nil {
 		slog.Error("Error downloading yaml file", slog.String(logging.KeyError, err.Error()))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -77,6 +77,7 @@ func (s service) GetReportById(w http.ResponseWriter, r *http.Request, id string
 		Fqdn:             &rep.Fqdn,
 		Id:               &rep.ID,
 		LogMessages:      &rep.LogMessages,
+		Metrics:          nil, // Map later.
 		PuppetVersion:    summary.Point(float32(rep.PuppetVersion)),
 		ResourcesChanged: nil, // Map later.
 		ResourcesFailed:  nil, // Map later.
@@ -85,6 +86,7 @@ func (s service) GetReportById(w http.ResponseWriter, r *http.Request, id string
 		Runtime:          summary.Point(rep.Runtime.String()),
 		Skipped:          summary.Point(int(rep.Skipped)),
 		State:            &rep.State,
+		Timings:          nil, // Map later.
 		Total:            summary.Point(int(rep.Total)),
 	}
 
@@ -142,9 +144,24 @@ func (s service) GetReportById(w http.ResponseWriter, r *http.Request, id string
 		resp.ResourcesSkipped = &skipped
 	}
 
-	w.Header().Set("content-type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(resp); err != nil {
-		slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+	// Map the timings.
+	if len(rep.Timings) > 0 {
+		timings := make([]summary.Timing, 0, len(rep.Timings))
+		for _, timing := range rep.Timings {
+			timings = append(timings, summary.Timing{
+				Label:   &timing.Label,
+				Name:    &timing.Name,
+				Seconds: &timing.Seconds,
+			})
+		}
+		resp.Timings = &timings
+	}
+
+	// Map the metrics.
+	if len(rep.Metrics) > 0 {
+		metrics := map[string]map[string]float64(rep.Metrics)
+		resp.Metrics = &metrics
 	}
+
+	reportRenderer.Render(w, r, http.StatusOK, resp)
 }
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReportsSuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db dataaccess.Database
+
+	// report is the example report.
+	report []byte
+
+	svc *service
+}
+
+func TestReportsSuite(t *testing.T) {
+	suite.Run(t, new(ReportsSuite))
+}
+
+func (s *ReportsSuite) SetupTest() {
+	var err error
+	s.report, err = os.ReadFile("../parser/testdata/example.yaml")
+	s.Require().NoError(err)
+
+	s.Require().NoError(dataaccess.ConnectStorage(context.Background(), dataaccess.StoreTypeMemory, ""))
+
+	s.db = dataaccess.NewMemory()
+	s.svc = &service{
+		r: s.db,
+	}
+}
+
+func (s *ReportsSuite) TearDownTest() {
+	dataaccess.Files = nil
+}
+
+// upload uploads the example report.
+func (s *ReportsSuite) upload() *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/upload", bytes.NewReader(s.report))
+
+	s.svc.UploadPuppetReport(w, r)
+
+	return w
+}
+
+func (s *ReportsSuite) TestUploadAndGetReport() {
+	w := s.upload()
+	s.Require().Equal(http.StatusOK, w.Code)
+
+	uploaded := new(summary.PuppetReport)
+	s.Require().NoError(json.NewDecoder(w.Body).Decode(uploaded))
+	s.Require().NotNil(uploaded.Id)
+
+	// The report is saved to both the database and storage.
+	runs, err := s.db.GetRuns(context.Background())
+	s.Require().NoError(err)
+	s.Require().Len(runs, 1)
+	s.Require().Equal(*uploaded.Id, runs[0].ID)
+
+	files, err := dataaccess.Files.ListFiles(context.Background())
+	s.Require().NoError(err)
+	s.Require().Equal([]string{runs[0].YamlFile}, files)
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/reports/"+*uploaded.Id, nil)
+
+	s.svc.GetReportById(w, r, *uploaded.Id)
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Equal("application/json", w.Header().Get("Content-Type"))
+
+	got := new(summary.PuppetReport)
+	s.Require().NoError(json.NewDecoder(w.Body).Decode(got))
+	s.Require().Equal(uploaded.Fqdn, got.Fqdn)
+	s.Require().Equal(uploaded.State, got.State)
+	s.Require().Equal(uploaded.Total, got.Total)
+
+	// The time the run spent per resource type and phase is included.
+	s.Require().NotNil(got.Timings)
+	s.Require().Len(*got.Timings, 13)
+
+	timings, err := s.db.GetTimings(context.Background(), time.Time{}, time.Time{}, nil)
+	s.Require().NoError(err)
+	s.Require().Len(timings, 13)
+
+	// Every group of metrics is included, and stored for the aggregations.
+	s.Require().NotNil(got.Metrics)
+	s.Require().Len(*got.Metrics, 4)
+	s.Require().Equal(9.0, (*got.Metrics)["events"]["success"])
+	s.Require().Equal(9.0, (*got.Metrics)["changes"]["total"])
+
+	values, err := s.db.GetRunMetrics(context.Background(), time.Time{}, time.Time{}, []string{"resources.out_of_sync"})
+	s.Require().NoError(err)
+	s.Require().Len(values, 1)
+	s.Require().Equal(6.0, values[0].Value)
+}
+
+func (s *ReportsSuite) TestUploadDuplicate() {
+	s.Require().Equal(http.StatusOK, s.upload().Code)
+	s.Require().Equal(http.StatusConflict, s.upload().Code)
+}
+
+func (s *ReportsSuite) TestUploadEmptyBody() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/upload", http.NoBody)
+
+	s.svc.UploadPuppetReport(w, r)
+
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *ReportsSuite) TestGetReportNotFound() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/reports/missing", nil)
+
+	s.svc.GetReportById(w, r, "missing")
+
+	s.Require().Equal(http.StatusNotFound, w.Code)
+}
+
+func (s *ReportsSuite) TestGetReportMissingFile() {
+	s.Require().Equal(http.StatusOK, s.upload().Code)
+
+	runs, err := s.db.GetRuns(context.Background())
+	s.Require().NoError(err)
+	s.Require().NoError(dataaccess.Files.DeleteFile(context.Background(), runs[0].YamlFile))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/reports/"+runs[0].ID, nil)
+
+	s.svc.GetReportById(w, r, runs[0].ID)
+
+	s.Require().Equal(http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/rollout"
+)
+
+func (s service) GetRollout(w http.ResponseWriter, r *http.Request, env summary.Environment, params summary.GetRolloutParams) {
+	opts := &rollout.Options{
+		Env: env,
+	}
+	if params.From != nil {
+		opts.From = *params.From
+	}
+	if params.To != nil {
+		opts.To = *params.To
+	}
+	if params.Bucket != nil {
+		opts.Bucket = *params.Bucket
+	}
+
+	res, err := s.rollout.Rollout(r.Context(), opts)
+	if errors.Is(err, aggregate.ErrInvalidOptions) {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage(err.Error())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting rollout", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting rollout")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	rolloutRenderer.Render(w, r, http.StatusOK, newRollout(res))
+}
+
+// newRollout maps the rollout to its API model.
+func newRollout(res *rollout.Rollout) *summary.Rollout {
+	versions := make([]summary.RolloutVersion, 0, len(res.Versions))
+	for _, v := range res.Versions {
+		versions = append(versions, summary.RolloutVersion{
+			CodeId:               optionalString(v.CodeID),
+			ConfigurationVersion: summary.Point(v.ConfigVersion),
+			FirstSeen:            summary.Point(v.FirstSeen),
+			LastSeen:             summary.Point(v.LastSeen),
+			Nodes:                summary.Point(v.Nodes),
+			Share:                summary.Point(v.Share),
+		})
+	}
+
+	nodesList := make([]summary.RolloutNode, 0, len(res.Nodes))
+	for _, n := range res.Nodes {
+		nodesList = append(nodesList, summary.RolloutNode{
+			CodeId:               optionalString(n.CodeID),
+			ConfigurationVersion: summary.Point(n.ConfigVersion),
+			ExecTime:             summary.Point(n.ExecTime),
+			Fqdn:                 summary.Point(n.Fqdn),
+			ReportId:             summary.Point(n.ReportID),
+			State:                summary.Point(n.State),
+		})
+	}
+
+	timeline := make([]summary.RolloutPoint, 0, len(res.Timeline))
+	for _, p := range res.Timeline {
+		timeline = append(timeline, summary.RolloutPoint{
+			Nodes:    summary.Point(p.Nodes),
+			OnNewest: summary.Point(p.OnNewest),
+			Share:    summary.Point(p.Share),
+			Time:     summary.Point(p.Time),
+		})
+	}
+
+	failed := make([]summary.RolloutFailure, 0, len(res.Failures))
+	for _, f := range res.Failures {
+		failed = append(failed, summary.RolloutFailure{
+			ConfigurationVersion: summary.Point(f.ConfigVersion),
+			ExecTime:             summary.Point(f.ExecTime),
+			Fqdn:                 summary.Point(f.Fqdn),
+			PreviousVersion:      summary.Point(f.Previous),
+			ReportId:             summary.Point(f.ReportID),
+		})
+	}
+
+	return &summary.Rollout{
+		Bucket:   summary.Point(res.Bucket),
+		Env:      summary.Point(res.Env),
+		Failures: &failed,
+		From:     summary.Point(res.From),
+		Newest:   optionalString(res.Newest),
+		Nodes:    &nodesList,
+		Timeline: &timeline,
+		To:       summary.Point(res.To),
+		Versions: &versions,
+	}
+}
+
+// optionalString returns a pointer to the string, or nil if it is empty so that it is left out of the response.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
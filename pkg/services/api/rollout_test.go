@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/rollout"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type GetRolloutSuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db *dataaccess.MockDb
+
+	svc *service
+}
+
+func TestGetRolloutSuite(t *testing.T) {
+	suite.Run(t, new(GetRolloutSuite))
+}
+
+func (s *GetRolloutSuite) SetupTest() {
+	s.db = new(dataaccess.MockDb)
+	s.svc = &service{
+		r:       s.db,
+		rollout: rollout.NewService(s.db),
+	}
+}
+
+func (s *GetRolloutSuite) TearDownTest() {
+	s.db.AssertExpectations(s.T())
+	s.db = nil
+}
+
+var (
+	rolloutFrom = time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC)
+	rolloutTo   = time.Date(2024, 2, 13, 2, 0, 0, 0, time.UTC)
+)
+
+func (s *GetRolloutSuite) TestGetRollout() {
+	// node1 was on the old version before the window, and failed on its first run of the new one.
+	s.db.On("GetRunVersions", mock.Anything, time.Time{}, rolloutTo, []summary.Environment{summary.Environment_PRODUCTION}).Return([]*entities.RunVersion{
+		{ReportID: "r1", Fqdn: "node1", Env: summary.Environment_PRODUCTION, State: summary.State_UNCHANGED, ExecTime: entities.Datetime(rolloutFrom.Add(-time.Hour)), ConfigVersion: "100"},
+		{ReportID: "r2", Fqdn: "node1", Env: summary.Environment_PRODUCTION, State: summary.State_FAILED, ExecTime: entities.Datetime(rolloutFrom.Add(90 * time.Minute)), ConfigVersion: "200", CodeID: "abc"},
+	}, nil).Once()
+	s.db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/rollout/PRODUCTION", nil)
+
+	s.svc.GetRollout(w, r, summary.Environment_PRODUCTION, summary.GetRolloutParams{
+		From: &rolloutFrom,
+		To:   &rolloutTo,
+	})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`{
+		"env": "PRODUCTION",
+		"from": "2024-02-13T00:00:00Z",
+		"to": "2024-02-13T02:00:00Z",
+		"bucket": "hour",
+		"newest": "200",
+		"versions": [
+			{"configuration_version": "200", "code_id": "abc", "first_seen": "2024-02-13T01:30:00Z", "last_seen": "2024-02-13T01:30:00Z", "nodes": 1, "share": 1}
+		],
+		"nodes": [
+			{"fqdn": "node1", "report_id": "r2", "state": "FAILED", "exec_time": "2024-02-13T01:30:00Z", "configuration_version": "200", "code_id": "abc"}
+		],
+		"timeline": [
+			{"time": "2024-02-13T01:00:00Z", "nodes": 1, "on_newest": 0, "share": 0},
+			{"time": "2024-02-13T02:00:00Z", "nodes": 1, "on_newest": 1, "share": 1}
+		],
+		"failures": [
+			{"fqdn": "node1", "report_id": "r2", "exec_time": "2024-02-13T01:30:00Z", "configuration_version": "200", "previous_version": "100"}
+		]
+	}`, w.Body.String())
+}
+
+func (s *GetRolloutSuite) TestGetRollout_InvalidBucket() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/rollout/PRODUCTION?bucket=month", nil)
+
+	s.svc.GetRollout(w, r, summary.Environment_PRODUCTION, summary.GetRolloutParams{Bucket: summary.Point(summary.HistoryBucket("month"))})
+
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+	s.Require().JSONEq(`{"message":"invalid options: invalid bucket month"}`, w.Body.String())
+}
+
+func (s *GetRolloutSuite) TestGetRollout_Error() {
+	s.db.On("GetRunVersions", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*entities.RunVersion(nil), errors.New("some error")).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/rollout/PRODUCTION", nil)
+
+	s.svc.GetRollout(w, r, summary.Environment_PRODUCTION, summary.GetRolloutParams{})
+
+	s.Require().Equal(http.StatusInternalServerError, w.Code)
+	s.Require().JSONEq(`{"message":"Error getting rollout"}`, w.Body.String())
+}
@@ -1,9 +1,22 @@
 package api
 
 import (
+	"time"
+
 	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/events"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/jobs"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/scheduler"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/alerting"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/anomalies"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/failures"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/flapping"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/incidents"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/services/purge"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/rollout"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/timings"
 )
 
 type service struct {
@@ -12,11 +25,104 @@ type service struct {
 
 	// purger is the purge service used by the service.
 	purger purge.Purger
+
+	// nodes is the node service used by the service.
+	nodes nodes.Manager
+
+	// silencer is the service used to acknowledge and silence the failing nodes.
+	silencer alerting.Silencer
+
+	// incidents groups the failed runs of the uploaded reports into incidents. If nil, nothing is recorded.
+	incidents incidents.Correlator
+
+	// anomalies checks the runtimes of the uploaded reports for anomalies. If nil, nothing is checked.
+	anomalies anomalies.Detector
+
+	// flapping detects the nodes that are flapping.
+	flapping flapping.Detector
+
+	// failures ranks the resources that failed.
+	failures failures.Ranker
+
+	// rollout tracks the rollout of the code across the nodes.
+	rollout rollout.Tracker
+
+	// timings breaks down where the time of the runs was spent.
+	timings timings.Profiler
+
+	// broker publishes the uploaded reports to the event streams.
+	broker *events.Broker
+
+	// scheduler is the scheduler running the background jobs.
+	scheduler *scheduler.Scheduler
+
+	// jobs is the registry of the jobs started in the background by the API.
+	jobs *jobs.Registry
+
+	// staleAfter is how long a node can go without reporting before it is stale.
+	staleAfter time.Duration
+}
+
+// Deps are the dependencies of the API service.
+type Deps struct {
+	// DB is the repository used by the service.
+	DB dataaccess.Database
+
+	// Purger is the purge service.
+	Purger purge.Purger
+
+	// Nodes is the node service.
+	Nodes nodes.Manager
+
+	// Silencer acknowledges and silences the failing nodes.
+	Silencer alerting.Silencer
+
+	// Incidents groups the failed runs of the uploaded reports into incidents. If nil, nothing is recorded.
+	Incidents incidents.Correlator
+
+	// Anomalies checks the runtimes of the uploaded reports for anomalies. If nil, nothing is checked.
+	Anomalies anomalies.Detector
+
+	// Flapping detects the nodes that are flapping.
+	Flapping flapping.Detector
+
+	// Failures ranks the resources that failed.
+	Failures failures.Ranker
+
+	// Rollout tracks the rollout of the code across the nodes.
+	Rollout rollout.Tracker
+
+	// Timings breaks down where the time of the runs was spent.
+	Timings timings.Profiler
+
+	// Broker publishes the uploaded reports to the event streams.
+	Broker *events.Broker
+
+	// Scheduler is the scheduler running the background jobs.
+	Scheduler *scheduler.Scheduler
+
+	// Jobs is the registry of the jobs started in the background by the API.
+	Jobs *jobs.Registry
+
+	// StaleAfter is how long a node can go without reporting before it is stale.
+	StaleAfter time.Duration
 }
 
-func NewService(r dataaccess.Database, purger purge.Purger) summary.ServerInterface {
+func NewService(deps Deps) summary.ServerInterface {
 	return &service{
-		r:      r,
-		purger: purger,
+		r:          deps.DB,
+		purger:     deps.Purger,
+		nodes:      deps.Nodes,
+		silencer:   deps.Silencer,
+		incidents:  deps.Incidents,
+		anomalies:  deps.Anomalies,
+		flapping:   deps.Flapping,
+		failures:   deps.Failures,
+		rollout:    deps.Rollout,
+		timings:    deps.Timings,
+		broker:     deps.Broker,
+		scheduler:  deps.Scheduler,
+		jobs:       deps.Jobs,
+		staleAfter: deps.StaleAfter,
 	}
 }
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/alerting"
+)
+
+func (s service) AcknowledgeNode(w http.ResponseWriter, r *http.Request, fqdn string) {
+	if r.Body == http.NoBody {
+		slog.Warn("missing request body")
+
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("missing request body")); err != nil {
+			slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	// Decode the request.
+	req := new(summary.AcknowledgeNodeJSONRequestBody)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		slog.Warn("failed to decode request", slog.String(logging.KeyError, err.Error()))
+
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("failed to decode request")); err != nil {
+			slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	ack, err := s.silencer.Acknowledge(r.Context(), fqdn, req.By, req.Reason, req.ExpiresAt)
+	if errors.Is(err, dataaccess.ErrNotFound) {
+		// Respond with 404 not found.
+		w.WriteHeader(http.StatusNotFound)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("No reports found for node %s", fqdn)); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if errors.Is(err, alerting.ErrInvalidSilence) {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage(err.Error())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		slog.Error("Error acknowledging node", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error acknowledging node")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	slog.Info("Node acknowledged",
+		slog.String(logging.KeyFqdn, fqdn),
+		slog.String("by", ack.CreatedBy),
+		slog.Time("expires_at", ack.EndsAt.Time()),
+	)
+
+	silenceRenderer.Render(w, r, http.StatusOK, newSilence(ack, time.Now()))
+}
+
+func (s service) UnacknowledgeNode(w http.ResponseWriter, r *http.Request, fqdn string) {
+	err := s.silencer.Unacknowledge(r.Context(), fqdn)
+	if errors.Is(err, dataaccess.ErrNotFound) {
+		// Respond with 404 not found.
+		w.WriteHeader(http.StatusNotFound)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Node %s is not acknowledged", fqdn)); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		slog.Error("Error removing acknowledgement", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error removing acknowledgement")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s service) GetSilences(w http.ResponseWriter, r *http.Request, params summary.GetSilencesParams) {
+	expired := params.Expired != nil && *params.Expired
+
+	silences, err := s.silencer.Silences(r.Context(), expired)
+	if err != nil {
+		slog.Error("Error getting silences", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting silences")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	now := time.Now()
+	resp := make([]*summary.Silence, 0, len(silences))
+	for _, sil := range silences {
+		resp = append(resp, newSilence(sil, now))
+	}
+
+	silencesRenderer.Render(w, r, http.StatusOK, resp)
+}
+
+func (s service) CreateSilence(w http.ResponseWriter, r *http.Request) {
+	if r.Body == http.NoBody {
+		slog.Warn("missing request body")
+
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("missing request body")); err != nil {
+			slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	// Decode the request.
+	req := new(summary.CreateSilenceJSONRequestBody)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		slog.Warn("failed to decode request", slog.String(logging.KeyError, err.Error()))
+
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("failed to decode request")); err != nil {
+			slog.Warn("failed to encode response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	sil := &entities.Silence{
+		CreatedBy: req.By,
+		Reason:    req.Reason,
+		EndsAt:    entities.Datetime(req.EndsAt),
+	}
+	if req.Fqdn != nil {
+		sil.Fqdn = *req.Fqdn
+	}
+	if req.Owner != nil {
+		sil.Owner = *req.Owner
+	}
+	if req.Labels != nil {
+		sil.Labels = *req.Labels
+	}
+	if req.StartsAt != nil {
+		sil.StartsAt = entities.Datetime(*req.StartsAt)
+	}
+
+	sil, err := s.silencer.CreateSilence(r.Context(), sil)
+	if errors.Is(err, alerting.ErrInvalidSilence) {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage(err.Error())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		slog.Error("Error creating silence", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error creating silence")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	slog.Info("Silence created",
+		slog.String("silence", sil.ID),
+		slog.String("by", sil.CreatedBy),
+		slog.Time("ends_at", sil.EndsAt.Time()),
+	)
+
+	silenceRenderer.Render(w, r, http.StatusCreated, newSilence(sil, time.Now()))
+}
+
+func (s service) DeleteSilence(w http.ResponseWriter, r *http.Request, id string) {
+	err := s.silencer.DeleteSilence(r.Context(), id)
+	if errors.Is(err, dataaccess.ErrNotFound) {
+		// Respond with 404 not found.
+		w.WriteHeader(http.StatusNotFound)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Silence %s not found", id)); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		slog.Error("Error deleting silence", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error deleting silence")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newSilence returns the API model of a silence, active if it is in effect at the given time.
+func newSilence(sil *entities.Silence, at time.Time) *summary.Silence {
+	labels := map[string]string(sil.Labels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	return &summary.Silence{
+		Id:        &sil.ID,
+		Kind:      summary.Point(summary.SilenceKind(sil.Kind)),
+		Fqdn:      &sil.Fqdn,
+		Owner:     &sil.Owner,
+		Labels:    &labels,
+		CreatedBy: &sil.CreatedBy,
+		Reason:    &sil.Reason,
+		StartsAt:  summary.Point(sil.StartsAt.Time()),
+		EndsAt:    summary.Point(sil.EndsAt.Time()),
+		CreatedAt: summary.Point(sil.CreatedAt.Time()),
+		Active:    summary.Point(sil.Active(at)),
+	}
+}
@@ -9,9 +9,10 @@ import (
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
 )
 
-func (s service) GetAllNodesByState(w http.ResponseWriter, r *http.Request, state summary.State) {
+func (s service) GetAllNodesByState(w http.ResponseWriter, r *http.Request, state summary.State, params summary.GetAllNodesByStateParams) {
 	if !state.IsValid() {
 		slog.Warn("Invalid state provided", slog.String("state", string(state)))
 		w.WriteHeader(http.StatusBadRequest)
@@ -21,8 +22,20 @@ func (s service) GetAllNodesByState(w http.ResponseWriter, r *http.Request, stat
 		return
 	}
 
+	filter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
 	// Get the state from the database.
 	runs, err := s.r.GetRunsByState(r.Context(), state)
+	if err == nil {
+		// Leave out the decommissioned nodes.
+		runs, err = nodes.Listed(r.Context(), s.r, runs)
+	}
+	if err == nil {
+		runs, err = filter.Apply(r.Context(), s.r, runs)
+	}
 	if err != nil {
 		if !errors.Is(err, context.Canceled) {
 			slog.Error("Error getting runs from database", slog.String("error", err.Error()))
@@ -45,12 +58,5 @@ func (s service) GetAllNodesByState(w http.ResponseWriter, r *http.Request, stat
 		})
 	}
 
-	if err := json.NewEncoder(w).Encode(nodes); err != nil {
-		slog.Warn("Error encoding response", slog.String("error", err.Error()))
-		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(request.NewMessage("Error encoding response")); err != nil {
-			slog.Warn("Error encoding response", slog.String("error", err.Error()))
-		}
-		return
-	}
+	nodesRenderer.Render(w, r, http.StatusOK, nodes)
 }
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/timings"
+)
+
+func (s service) GetTimings(w http.ResponseWriter, r *http.Request, params summary.GetTimingsParams) {
+	filter, ok := parseFilter(w, params.Owner, params.Label)
+	if !ok {
+		return
+	}
+
+	opts := new(timings.Options)
+	if params.Env != nil {
+		opts.Envs = *params.Env
+	}
+	if params.From != nil {
+		opts.From = *params.From
+	}
+	if params.To != nil {
+		opts.To = *params.To
+	}
+
+	b, err := s.timings.Breakdown(r.Context(), filter, opts)
+	if errors.Is(err, aggregate.ErrInvalidOptions) {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage(err.Error())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting timings", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting timings")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	timingsRenderer.Render(w, r, http.StatusOK, newTimingBreakdown(b))
+}
+
+// newTimingBreakdown maps the breakdown of the time of the runs to its API model.
+func newTimingBreakdown(b *timings.Breakdown) *summary.TimingBreakdown {
+	entries := make([]summary.TimingEntry, 0, len(b.Timings))
+	for _, entry := range b.Timings {
+		entries = append(entries, *newTimingEntry(entry))
+	}
+
+	resp := &summary.TimingBreakdown{
+		From:    summary.Point(b.From),
+		Timings: &entries,
+		To:      summary.Point(b.To),
+	}
+	if b.Total != nil {
+		resp.Total = newTimingEntry(b.Total)
+	}
+	return resp
+}
+
+// newTimingEntry maps the entry of a breakdown to its API model.
+func newTimingEntry(entry *timings.Entry) *summary.TimingEntry {
+	return &summary.TimingEntry{
+		Average: &entry.Average,
+		Label:   &entry.Label,
+		Max:     &entry.Max,
+		Name:    &entry.Name,
+		Runs:    &entry.Runs,
+		Seconds: &entry.Seconds,
+		Share:   &entry.Share,
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/timings"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type GetTimingsSuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db *dataaccess.MockDb
+
+	svc *service
+}
+
+func TestGetTimingsSuite(t *testing.T) {
+	suite.Run(t, new(GetTimingsSuite))
+}
+
+func (s *GetTimingsSuite) SetupTest() {
+	s.db = new(dataaccess.MockDb)
+	s.svc = &service{
+		r:       s.db,
+		timings: timings.NewService(s.db),
+	}
+}
+
+func (s *GetTimingsSuite) TearDownTest() {
+	s.db.AssertExpectations(s.T())
+	s.db = nil
+}
+
+var (
+	timingsFrom = time.Date(2024, 2, 13, 0, 0, 0, 0, time.UTC)
+	timingsTo   = time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC)
+)
+
+func (s *GetTimingsSuite) TestGetTimings() {
+	s.db.On("GetTimings", mock.Anything, timingsFrom, timingsTo, []string(nil), []summary.Environment{summary.Environment_PRODUCTION}).Return([]*entities.TimingSummary{
+		{Name: entities.TimingTotal, Label: "Total", Runs: 2, Seconds: 20, Max: 12},
+		{Name: "config_retrieval", Label: "Config retrieval", Runs: 2, Seconds: 15, Max: 9},
+	}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/timings", nil)
+
+	s.svc.GetTimings(w, r, summary.GetTimingsParams{
+		Env:  &[]summary.Environment{summary.Environment_PRODUCTION},
+		From: &timingsFrom,
+		To:   &timingsTo,
+	})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().JSONEq(`{
+		"from": "2024-02-13T00:00:00Z",
+		"to": "2024-02-14T00:00:00Z",
+		"total": {
+			"name": "total",
+			"label": "Total",
+			"runs": 2,
+			"seconds": 20,
+			"average": 10,
+			"max": 12,
+			"share": 1
+		},
+		"timings": [
+			{
+				"name": "config_retrieval",
+				"label": "Config retrieval",
+				"runs": 2,
+				"seconds": 15,
+				"average": 7.5,
+				"max": 9,
+				"share": 0.75
+			}
+		]
+	}`, w.Body.String())
+}
+
+func (s *GetTimingsSuite) TestGetTimings_Owner() {
+	s.db.On("GetAllNodeMetadata", mock.Anything).Return([]*entities.NodeMetadata{
+		{Fqdn: "node1", Owner: "platform"},
+		{Fqdn: "node2", Owner: "web"},
+	}, nil).Once()
+	s.db.On("GetTimings", mock.Anything, timingsFrom, timingsTo, []string{"node2"}, []summary.Environment(nil)).Return([]*entities.TimingSummary{
+		{Name: entities.TimingTotal, Label: "Total", Runs: 1, Seconds: 8, Max: 8},
+	}, nil).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/timings?owner=web", nil)
+
+	s.svc.GetTimings(w, r, summary.GetTimingsParams{
+		From:  &timingsFrom,
+		To:    &timingsTo,
+		Owner: summary.Point("web"),
+	})
+
+	s.Require().Equal(http.StatusOK, w.Code)
+	var resp summary.TimingBreakdown
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	s.Require().Equal(1, *resp.Total.Runs)
+}
+
+func (s *GetTimingsSuite) TestGetTimings_Invalid() {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/timings", nil)
+
+	s.svc.GetTimings(w, r, summary.GetTimingsParams{
+		From: &timingsTo,
+		To:   &timingsFrom,
+	})
+
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+	s.Require().JSONEq(`{"message":"invalid options: from must be before to"}`, w.Body.String())
+}
+
+func (s *GetTimingsSuite) TestGetTimings_Error() {
+	s.db.On("GetTimings", mock.Anything, mock.Anything, mock.Anything, []string(nil), []summary.Environment(nil)).Return([]*entities.TimingSummary(nil), errors.New("some error")).Once()
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/timings", nil)
+
+	s.svc.GetTimings(w, r, summary.GetTimingsParams{})
+
+	s.Require().Equal(http.StatusInternalServerError, w.Code)
+	s.Require().JSONEq(`{"message":"Error getting timings"}`, w.Body.String())
+}
@@ -1,20 +1,28 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/events"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/messages"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/services/parser"
 )
 
+// recordTimeout is how long the recording of an uploaded report into its incident, the check of its runtime, and their
+// notifications, can take.
+const recordTimeout = 30 * time.Second
+
 func (s service) UploadPuppetReport(w http.ResponseWriter, r *http.Request) {
 	if r.Body == http.NoBody {
 		slog.Warn("Request body is empty")
@@ -77,6 +85,15 @@ func (s service) UploadPuppetReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.broker != nil {
+		s.broker.Publish(events.NewEvent(rep))
+	}
+
+	// The run is recorded in the background, so that the upload does not wait on the webhook.
+	if s.incidents != nil || s.anomalies != nil {
+		go s.record(rep)
+	}
+
 	resp := summary.PuppetReport{
 		Changed:          summary.Point(int(rep.Changed)),
 		Env:              &rep.Env,
@@ -160,3 +177,29 @@ func (s service) UploadPuppetReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// record groups the run of the uploaded report into its incident, and checks its runtime for an anomaly, logging any
+// error.
+func (s service) record(rep *entities.PuppetReport) {
+	// The upload has been responded to by the time the run is recorded, so the request context is not used.
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+
+	if s.incidents != nil {
+		if _, err := s.incidents.Record(ctx, rep); err != nil {
+			slog.Warn("Error recording incident of report",
+				slog.String(logging.KeyFqdn, rep.Fqdn),
+				slog.String(logging.KeyError, err.Error()),
+			)
+		}
+	}
+
+	if s.anomalies != nil {
+		if _, err := s.anomalies.Check(ctx, rep); err != nil {
+			slog.Warn("Error checking runtime of report",
+				slog.String(logging.KeyFqdn, rep.Fqdn),
+				slog.String(logging.KeyError, err.Error()),
+			)
+		}
+	}
+}
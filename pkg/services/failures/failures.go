@@ -0,0 +1,477 @@
+package failures
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+)
+
+const (
+	// DefaultWindow is how far back the failures are counted from, when the start of the window is not given.
+	DefaultWindow = 7 * 24 * time.Hour
+
+	// DefaultLimit is the number of entries of each ranking, when the limit is not given.
+	DefaultLimit = 10
+
+	// MaxLimit is the most entries of each ranking.
+	MaxLimit = 100
+)
+
+// Options select the failures that are ranked.
+type Options struct {
+	// From is the start of the window the failures are counted in, inclusive. Defaults to DefaultWindow before To.
+	From time.Time
+
+	// To is the end of the window the failures are counted in, exclusive. Defaults to now.
+	To time.Time
+
+	// Envs are the environments the failures are counted in. Every environment if empty.
+	Envs []summary.Environment
+
+	// Filter selects the nodes by their metadata. Every node if nil.
+	Filter *nodes.Filter
+
+	// Limit is the number of entries of each ranking. Defaults to DefaultLimit.
+	Limit int
+
+	// Metrics are the metrics, as group.name, the nodes are ranked by, the first metric first. The nodes are not
+	// ranked if empty.
+	Metrics []string
+}
+
+// withDefaults returns the options with the defaults filled in, or an error if they are out of range.
+func (o *Options) withDefaults(now time.Time) (*Options, error) {
+	res := new(Options)
+	if o != nil {
+		*res = *o
+	}
+
+	var err error
+	res.From, res.To, err = aggregate.Window(res.From, res.To, now, DefaultWindow, res.Envs...)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Limit == 0 {
+		res.Limit = DefaultLimit
+	}
+	if res.Limit < 1 || res.Limit > MaxLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", aggregate.ErrInvalidOptions, MaxLimit)
+	}
+	for _, metric := range res.Metrics {
+		if _, _, ok := entities.SplitMetricKey(metric); !ok {
+			return nil, fmt.Errorf("%w: invalid metric %s", aggregate.ErrInvalidOptions, metric)
+		}
+	}
+	return res, nil
+}
+
+// Key identifies the resources an entry of a ranking is made of. The empty fields match any resource, so a resource
+// is keyed by its type and name, a resource type by the type, and a manifest location by the file and line.
+type Key struct {
+	// Type is the type of the resources, such as File or Exec.
+	Type string
+
+	// Name is the title of the resources.
+	Name string
+
+	// File is the manifest the resources are declared in.
+	File string
+
+	// Line is the line of the manifest the resources are declared on.
+	Line string
+}
+
+// IsEmpty returns whether the key matches every resource.
+func (k *Key) IsEmpty() bool {
+	return k == nil || *k == Key{}
+}
+
+// Matches returns whether the resource is one of those identified by the key.
+func (k *Key) Matches(res *entities.PuppetResource) bool {
+	return (k.Type == "" || k.Type == res.Type) &&
+		(k.Name == "" || k.Name == res.Name) &&
+		(k.File == "" || k.File == res.File) &&
+		(k.Line == "" || k.Line == res.Line)
+}
+
+// Entry is an entry of a ranking of failures.
+type Entry struct {
+	Key
+
+	// Failures is the number of reports the resources failed in.
+	Failures int
+
+	// Nodes is the number of nodes the resources failed on.
+	Nodes int
+
+	// LastFailed is the time of the latest report the resources failed in.
+	LastFailed time.Time
+}
+
+// Leaderboard ranks the resources that failed in a window by the number of reports they failed in.
+type Leaderboard struct {
+	// From is the start of the window, inclusive.
+	From time.Time
+
+	// To is the end of the window, exclusive.
+	To time.Time
+
+	// Resources are the resources, keyed by their type and name.
+	Resources []*Entry
+
+	// Types are the resource types.
+	Types []*Entry
+
+	// Locations are the manifest locations the resources are declared at, keyed by the file and line. The resources
+	// without a manifest are left out.
+	Locations []*Entry
+
+	// Nodes are the nodes, ranked by the sums of the metrics of the options over their reports. Only set if any
+	// metrics are given.
+	Nodes []*NodeEntry
+}
+
+// NodeEntry is an entry of the ranking of the nodes by their metrics.
+type NodeEntry struct {
+	// Fqdn is the FQDN of the node.
+	Fqdn string
+
+	// Env is the environment of the node.
+	Env summary.Environment
+
+	// Reports is the number of reports of the node with any of the metrics.
+	Reports int
+
+	// Metrics are the sums of the metrics over the reports of the node, keyed as group.name.
+	Metrics map[string]float64
+}
+
+// AffectedNode is a node that failed on the resources of an entry of a ranking.
+type AffectedNode struct {
+	// Fqdn is the FQDN of the node.
+	Fqdn string
+
+	// Env is the environment of the node.
+	Env summary.Environment
+
+	// Reports are the reports the resources failed in, newest first. A report is only included once.
+	Reports []*Report
+}
+
+// Report is a report the resources of an entry of a ranking failed in.
+type Report struct {
+	// ID is the ID of the report.
+	ID string
+
+	// ExecTime is the time the report was executed.
+	ExecTime time.Time
+
+	// Resources are the resources of the entry that failed in the report.
+	Resources []*entities.PuppetResource
+}
+
+// ranking counts the failures of the entries of a ranking.
+type ranking struct {
+	// entries are the entries, keyed by their key.
+	entries map[Key]*Entry
+
+	// reports are the reports counted for each entry, so that a report failing on several resources of an entry is
+	// counted once.
+	reports map[Key]map[string]struct{}
+
+	// nodes are the nodes counted for each entry.
+	nodes map[Key]map[string]struct{}
+}
+
+func newRanking() *ranking {
+	return &ranking{
+		entries: make(map[Key]*Entry),
+		reports: make(map[Key]map[string]struct{}),
+		nodes:   make(map[Key]map[string]struct{}),
+	}
+}
+
+// add counts the failure towards the entry with the key.
+func (r *ranking) add(key Key, failure *entities.ResourceFailure) {
+	entry, ok := r.entries[key]
+	if !ok {
+		entry = &Entry{Key: key}
+		r.entries[key] = entry
+		r.reports[key] = make(map[string]struct{})
+		r.nodes[key] = make(map[string]struct{})
+	}
+
+	if _, ok := r.reports[key][failure.ReportID]; !ok {
+		r.reports[key][failure.ReportID] = struct{}{}
+		entry.Failures++
+	}
+
+	node := fmt.Sprintf("%s-%s", failure.Fqdn, failure.Env)
+	if _, ok := r.nodes[key][node]; !ok {
+		r.nodes[key][node] = struct{}{}
+		entry.Nodes++
+	}
+
+	if execTime := failure.ExecTime.Time(); execTime.After(entry.LastFailed) {
+		entry.LastFailed = execTime
+	}
+}
+
+// top returns the entries that failed in the most reports, then on the most nodes, up to the limit.
+func (r *ranking) top(limit int) []*Entry {
+	entries := make([]*Entry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Failures != entries[j].Failures {
+			return entries[i].Failures > entries[j].Failures
+		}
+		if entries[i].Nodes != entries[j].Nodes {
+			return entries[i].Nodes > entries[j].Nodes
+		}
+		if !entries[i].LastFailed.Equal(entries[j].LastFailed) {
+			return entries[i].LastFailed.After(entries[j].LastFailed)
+		}
+		return keyLess(entries[i].Key, entries[j].Key)
+	})
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// keyLess orders the keys by their fields, so that the entries that are otherwise tied are in a stable order.
+func keyLess(a, b Key) bool {
+	if a.Type != b.Type {
+		return a.Type < b.Type
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	return a.Line < b.Line
+}
+
+func (s *service) Leaderboard(ctx context.Context, opts *Options) (*Leaderboard, error) {
+	opts, err := opts.withDefaults(s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	failures, err := s.failures(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := newRanking()
+	types := newRanking()
+	locations := newRanking()
+	for _, failure := range failures {
+		res := failure.Resource
+		resources.add(Key{Type: res.Type, Name: res.Name}, failure)
+		types.add(Key{Type: res.Type}, failure)
+		if res.File != "" {
+			locations.add(Key{File: res.File, Line: res.Line}, failure)
+		}
+	}
+
+	board := &Leaderboard{
+		From:      opts.From,
+		To:        opts.To,
+		Resources: resources.top(opts.Limit),
+		Types:     types.top(opts.Limit),
+		Locations: locations.top(opts.Limit),
+	}
+
+	if len(opts.Metrics) > 0 {
+		board.Nodes, err = s.nodeMetrics(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return board, nil
+}
+
+func (s *service) Affected(ctx context.Context, opts *Options, key *Key) ([]*AffectedNode, error) {
+	if key.IsEmpty() {
+		return nil, fmt.Errorf("%w: a type, name, file or line is required", aggregate.ErrInvalidOptions)
+	}
+
+	opts, err := opts.withDefaults(s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	failures, err := s.failures(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The failures are newest first, so the reports of each node are too.
+	affected := make(map[string]*AffectedNode)
+	reports := make(map[string]*Report)
+	for _, failure := range failures {
+		if !key.Matches(failure.Resource) {
+			continue
+		}
+
+		nodeKey := fmt.Sprintf("%s-%s", failure.Fqdn, failure.Env)
+		node, ok := affected[nodeKey]
+		if !ok {
+			node = &AffectedNode{
+				Fqdn: failure.Fqdn,
+				Env:  failure.Env,
+			}
+			affected[nodeKey] = node
+		}
+
+		rep, ok := reports[failure.ReportID]
+		if !ok {
+			rep = &Report{
+				ID:       failure.ReportID,
+				ExecTime: failure.ExecTime.Time(),
+			}
+			reports[failure.ReportID] = rep
+			node.Reports = append(node.Reports, rep)
+		}
+		rep.Resources = append(rep.Resources, failure.Resource)
+	}
+
+	nodesList := make([]*AffectedNode, 0, len(affected))
+	for _, node := range affected {
+		nodesList = append(nodesList, node)
+	}
+
+	sort.Slice(nodesList, func(i, j int) bool {
+		if len(nodesList[i].Reports) != len(nodesList[j].Reports) {
+			return len(nodesList[i].Reports) > len(nodesList[j].Reports)
+		}
+		if !nodesList[i].Reports[0].ExecTime.Equal(nodesList[j].Reports[0].ExecTime) {
+			return nodesList[i].Reports[0].ExecTime.After(nodesList[j].Reports[0].ExecTime)
+		}
+		if nodesList[i].Fqdn != nodesList[j].Fqdn {
+			return nodesList[i].Fqdn < nodesList[j].Fqdn
+		}
+		return nodesList[i].Env < nodesList[j].Env
+	})
+
+	return nodesList, nil
+}
+
+// failures returns the failures in the window and environments of the options, of the listed nodes selected by the
+// filter, newest first.
+func (s *service) failures(ctx context.Context, opts *Options) ([]*entities.ResourceFailure, error) {
+	failures, err := s.db.GetResourceFailures(ctx, opts.From, opts.To, opts.Envs...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting failed resources: %w", err)
+	}
+
+	included, err := s.includedFunc(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*entities.ResourceFailure, 0, len(failures))
+	for _, failure := range failures {
+		if included(failure.Fqdn, failure.ExecTime.Time()) {
+			res = append(res, failure)
+		}
+	}
+	return res, nil
+}
+
+// nodeMetrics returns the listed nodes selected by the filter, ranked by the sums of the metrics of the options over
+// their reports in the window and environments, up to the limit.
+func (s *service) nodeMetrics(ctx context.Context, opts *Options) ([]*NodeEntry, error) {
+	values, err := s.db.GetRunMetrics(ctx, opts.From, opts.To, opts.Metrics, opts.Envs...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting run metrics: %w", err)
+	}
+
+	included, err := s.includedFunc(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	byNode := make(map[string]*NodeEntry)
+	reports := make(map[string]struct{})
+	for _, value := range values {
+		if !included(value.Fqdn, value.ExecTime.Time()) {
+			continue
+		}
+
+		nodeKey := fmt.Sprintf("%s-%s", value.Fqdn, value.Env)
+		node, ok := byNode[nodeKey]
+		if !ok {
+			// Every metric is set, so the nodes without one read as zero.
+			node = &NodeEntry{
+				Fqdn:    value.Fqdn,
+				Env:     value.Env,
+				Metrics: make(map[string]float64, len(opts.Metrics)),
+			}
+			for _, metric := range opts.Metrics {
+				node.Metrics[metric] = 0
+			}
+			byNode[nodeKey] = node
+		}
+
+		if _, ok := reports[value.ReportID]; !ok {
+			reports[value.ReportID] = struct{}{}
+			node.Reports++
+		}
+		node.Metrics[value.Metric] += value.Value
+	}
+
+	entries := make([]*NodeEntry, 0, len(byNode))
+	for _, node := range byNode {
+		entries = append(entries, node)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		for _, metric := range opts.Metrics {
+			if entries[i].Metrics[metric] != entries[j].Metrics[metric] {
+				return entries[i].Metrics[metric] > entries[j].Metrics[metric]
+			}
+		}
+		if entries[i].Fqdn != entries[j].Fqdn {
+			return entries[i].Fqdn < entries[j].Fqdn
+		}
+		return entries[i].Env < entries[j].Env
+	})
+
+	if len(entries) > opts.Limit {
+		entries = entries[:opts.Limit]
+	}
+	return entries, nil
+}
+
+// includedFunc returns a function reporting whether the runs of a node at a time are included in the rankings, which
+// are those of the listed nodes selected by the filter of the options.
+func (s *service) includedFunc(ctx context.Context, opts *Options) (func(fqdn string, execTime time.Time) bool, error) {
+	isListed, err := nodes.ListedFunc(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := opts.Filter.SelectedFunc(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(fqdn string, execTime time.Time) bool {
+		return isListed(fqdn, execTime) && selected(fqdn)
+	}, nil
+}
@@ -0,0 +1,234 @@
+package failures
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+
+var (
+	resPkg  = &entities.PuppetResource{Type: "Package", Name: "nginx", File: "/etc/puppet/nginx.pp", Line: "3"}
+	resSvc  = &entities.PuppetResource{Type: "Service", Name: "nginx", File: "/etc/puppet/nginx.pp", Line: "9"}
+	resExec = &entities.PuppetResource{Type: "Exec", Name: "migrate", File: "/etc/puppet/app.pp", Line: "12"}
+	resUser = &entities.PuppetResource{Type: "Package", Name: "curl"}
+)
+
+// newFailure returns a failure of the resource in the report, hours before the test time.
+func newFailure(id, fqdn string, hours int, res *entities.PuppetResource) *entities.ResourceFailure {
+	return &entities.ResourceFailure{
+		ReportID: id,
+		Fqdn:     fqdn,
+		Env:      summary.Environment_PRODUCTION,
+		ExecTime: entities.Datetime(testNow.Add(-time.Duration(hours) * time.Hour)),
+		Resource: res,
+	}
+}
+
+// testFailures are the failures of the tests, newest first as returned by the database.
+func testFailures() []*entities.ResourceFailure {
+	return []*entities.ResourceFailure{
+		newFailure("r1", "node1", 1, resPkg),
+		newFailure("r1", "node1", 1, resSvc),
+		newFailure("r2", "node2", 2, resPkg),
+		newFailure("r3", "node1", 3, resPkg),
+		newFailure("r4", "node3", 4, resExec),
+		newFailure("r5", "node2", 5, resUser),
+	}
+}
+
+func newTestService(db dataaccess.Database) *service {
+	return &service{
+		db:  db,
+		now: func() time.Time { return testNow },
+	}
+}
+
+func TestOptions_withDefaults(t *testing.T) {
+	opts, err := (*Options)(nil).withDefaults(testNow)
+	require.NoError(t, err)
+	require.Equal(t, testNow, opts.To)
+	require.Equal(t, testNow.Add(-DefaultWindow), opts.From)
+	require.Equal(t, DefaultLimit, opts.Limit)
+
+	invalid := []*Options{
+		{From: testNow.Add(time.Hour)},
+		{Limit: -1},
+		{Limit: MaxLimit + 1},
+		{Metrics: []string{"out_of_sync"}},
+	}
+	for _, o := range invalid {
+		_, err := o.withDefaults(testNow)
+		require.ErrorIs(t, err, aggregate.ErrInvalidOptions)
+	}
+}
+
+func TestService_Leaderboard(t *testing.T) {
+	db := new(dataaccess.MockDb)
+	db.On("GetResourceFailures", mock.Anything, testNow.Add(-DefaultWindow), testNow, []summary.Environment(nil)).Return(testFailures(), nil)
+	db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil)
+
+	got, err := newTestService(db).Leaderboard(context.Background(), nil)
+	require.NoError(t, err)
+	require.Equal(t, testNow.Add(-DefaultWindow), got.From)
+	require.Equal(t, testNow, got.To)
+
+	last := func(hours int) time.Time {
+		return testNow.Add(-time.Duration(hours) * time.Hour)
+	}
+
+	require.Equal(t, []*Entry{
+		{Key: Key{Type: "Package", Name: "nginx"}, Failures: 3, Nodes: 2, LastFailed: last(1)},
+		{Key: Key{Type: "Service", Name: "nginx"}, Failures: 1, Nodes: 1, LastFailed: last(1)},
+		{Key: Key{Type: "Exec", Name: "migrate"}, Failures: 1, Nodes: 1, LastFailed: last(4)},
+		{Key: Key{Type: "Package", Name: "curl"}, Failures: 1, Nodes: 1, LastFailed: last(5)},
+	}, got.Resources)
+
+	// The report failing on two resources of a type is counted once.
+	require.Equal(t, []*Entry{
+		{Key: Key{Type: "Package"}, Failures: 4, Nodes: 2, LastFailed: last(1)},
+		{Key: Key{Type: "Service"}, Failures: 1, Nodes: 1, LastFailed: last(1)},
+		{Key: Key{Type: "Exec"}, Failures: 1, Nodes: 1, LastFailed: last(4)},
+	}, got.Types)
+
+	// The resource without a manifest has no location.
+	require.Equal(t, []*Entry{
+		{Key: Key{File: "/etc/puppet/nginx.pp", Line: "3"}, Failures: 3, Nodes: 2, LastFailed: last(1)},
+		{Key: Key{File: "/etc/puppet/nginx.pp", Line: "9"}, Failures: 1, Nodes: 1, LastFailed: last(1)},
+		{Key: Key{File: "/etc/puppet/app.pp", Line: "12"}, Failures: 1, Nodes: 1, LastFailed: last(4)},
+	}, got.Locations)
+}
+
+func TestService_Leaderboard_Limit(t *testing.T) {
+	db := new(dataaccess.MockDb)
+	db.On("GetResourceFailures", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testFailures(), nil)
+	db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil)
+
+	got, err := newTestService(db).Leaderboard(context.Background(), &Options{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, got.Resources, 1)
+	require.Len(t, got.Types, 1)
+	require.Len(t, got.Locations, 1)
+	require.Equal(t, "Package", got.Types[0].Type)
+}
+
+func TestService_Leaderboard_Filtered(t *testing.T) {
+	db := new(dataaccess.MockDb)
+	db.On("GetResourceFailures", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testFailures(), nil)
+
+	// node1 was decommissioned after its failures, and node3 is not owned by the team.
+	db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{"node1": testNow.Add(-30 * time.Minute)}, nil)
+	db.On("GetAllNodeMetadata", mock.Anything).Return([]*entities.NodeMetadata{
+		{Fqdn: "node1", Owner: "team-a"},
+		{Fqdn: "node2", Owner: "team-a"},
+		{Fqdn: "node3", Owner: "team-b"},
+	}, nil)
+
+	got, err := newTestService(db).Leaderboard(context.Background(), &Options{Filter: &nodes.Filter{Owner: "team-a"}})
+	require.NoError(t, err)
+	require.Len(t, got.Resources, 2)
+	require.Equal(t, Key{Type: "Package", Name: "nginx"}, got.Resources[0].Key)
+	require.Equal(t, 1, got.Resources[0].Failures)
+	require.Equal(t, Key{Type: "Package", Name: "curl"}, got.Resources[1].Key)
+}
+
+func TestService_Leaderboard_Metrics(t *testing.T) {
+	metrics := []string{"resources.out_of_sync", "events.failure"}
+
+	newMetric := func(id, fqdn string, metric string, value float64) *entities.RunMetric {
+		return &entities.RunMetric{
+			ReportID: id,
+			Fqdn:     fqdn,
+			Env:      summary.Environment_PRODUCTION,
+			ExecTime: entities.Datetime(testNow.Add(-time.Hour)),
+			Metric:   metric,
+			Value:    value,
+		}
+	}
+
+	db := new(dataaccess.MockDb)
+	db.On("GetResourceFailures", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testFailures(), nil)
+	db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil)
+	db.On("GetRunMetrics", mock.Anything, testNow.Add(-DefaultWindow), testNow, metrics, []summary.Environment(nil)).
+		Return([]*entities.RunMetric{
+			newMetric("r1", "node1", "events.failure", 1),
+			newMetric("r1", "node1", "resources.out_of_sync", 2),
+			newMetric("r2", "node2", "events.failure", 3),
+			newMetric("r2", "node2", "resources.out_of_sync", 2),
+			newMetric("r3", "node1", "resources.out_of_sync", 4),
+			newMetric("r4", "node3", "events.failure", 5),
+		}, nil)
+
+	got, err := newTestService(db).Leaderboard(context.Background(), &Options{Metrics: metrics})
+	require.NoError(t, err)
+
+	// The nodes are ranked by the first metric, then the next, and the metrics they never reported read as zero.
+	require.Equal(t, []*NodeEntry{
+		{Fqdn: "node1", Env: summary.Environment_PRODUCTION, Reports: 2, Metrics: map[string]float64{"resources.out_of_sync": 6, "events.failure": 1}},
+		{Fqdn: "node2", Env: summary.Environment_PRODUCTION, Reports: 1, Metrics: map[string]float64{"resources.out_of_sync": 2, "events.failure": 3}},
+		{Fqdn: "node3", Env: summary.Environment_PRODUCTION, Reports: 1, Metrics: map[string]float64{"resources.out_of_sync": 0, "events.failure": 5}},
+	}, got.Nodes)
+
+	got, err = newTestService(db).Leaderboard(context.Background(), &Options{Metrics: metrics, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, got.Nodes, 1)
+	require.Equal(t, "node1", got.Nodes[0].Fqdn)
+}
+
+func TestService_Leaderboard_Error(t *testing.T) {
+	db := new(dataaccess.MockDb)
+	db.On("GetResourceFailures", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*entities.ResourceFailure(nil), errors.New("some error"))
+
+	_, err := newTestService(db).Leaderboard(context.Background(), nil)
+	require.EqualError(t, err, "error getting failed resources: some error")
+
+	_, err = newTestService(db).Leaderboard(context.Background(), &Options{Limit: -1})
+	require.ErrorIs(t, err, aggregate.ErrInvalidOptions)
+}
+
+func TestService_Affected(t *testing.T) {
+	db := new(dataaccess.MockDb)
+	db.On("GetResourceFailures", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testFailures(), nil)
+	db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil)
+
+	svc := newTestService(db)
+
+	got, err := svc.Affected(context.Background(), nil, &Key{Type: "Package", Name: "nginx"})
+	require.NoError(t, err)
+	require.Equal(t, []*AffectedNode{
+		{
+			Fqdn: "node1",
+			Env:  summary.Environment_PRODUCTION,
+			Reports: []*Report{
+				{ID: "r1", ExecTime: testNow.Add(-time.Hour), Resources: []*entities.PuppetResource{resPkg}},
+				{ID: "r3", ExecTime: testNow.Add(-3 * time.Hour), Resources: []*entities.PuppetResource{resPkg}},
+			},
+		},
+		{
+			Fqdn: "node2",
+			Env:  summary.Environment_PRODUCTION,
+			Reports: []*Report{
+				{ID: "r2", ExecTime: testNow.Add(-2 * time.Hour), Resources: []*entities.PuppetResource{resPkg}},
+			},
+		},
+	}, got)
+
+	// A manifest file matches every resource declared in it.
+	got, err = svc.Affected(context.Background(), nil, &Key{File: "/etc/puppet/nginx.pp"})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, []*entities.PuppetResource{resPkg, resSvc}, got[0].Reports[0].Resources)
+
+	_, err = svc.Affected(context.Background(), nil, &Key{})
+	require.ErrorIs(t, err, aggregate.ErrInvalidOptions)
+}
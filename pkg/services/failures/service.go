@@ -0,0 +1,32 @@
+package failures
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+)
+
+type Ranker interface {
+	// Leaderboard returns the resources, resource types and manifest locations that failed in the most reports of the
+	// listed nodes selected by the options, and those nodes ranked by the metrics of the options.
+	Leaderboard(ctx context.Context, opts *Options) (*Leaderboard, error)
+
+	// Affected returns the listed nodes selected by the options that failed on the resources matched by the key, with
+	// the reports they failed in, most failing first.
+	Affected(ctx context.Context, opts *Options, key *Key) ([]*AffectedNode, error)
+}
+
+type service struct {
+	db dataaccess.Database
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+func NewService(db dataaccess.Database) Ranker {
+	return &service{
+		db:  db,
+		now: time.Now,
+	}
+}
@@ -0,0 +1,241 @@
+package flapping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/parser"
+)
+
+const (
+	// DefaultWindow is the number of the latest runs of a node its flap score is worked out over.
+	DefaultWindow = 10
+
+	// DefaultThreshold is the flap score from which a node is flapping.
+	DefaultThreshold = 0.5
+
+	// MinWindow is the fewest runs a node needs to be scored. With fewer, a single change of state would look like
+	// flapping.
+	MinWindow = 4
+
+	// MaxWindow is the most runs of a node that can be scored, which bounds the reports read for the resources.
+	MaxWindow = 100
+)
+
+// ErrInvalidOptions is returned when the options of the detection are out of range.
+var ErrInvalidOptions = errors.New("invalid flapping options")
+
+// Options are the options of the detection.
+type Options struct {
+	// Window is the number of the latest runs of each node to score. Defaults to DefaultWindow.
+	Window int
+
+	// Threshold is the flap score from which a node is flapping, between 0 and 1. Defaults to DefaultThreshold.
+	Threshold float64
+}
+
+// withDefaults returns the options with the defaults filled in, or an error if they are out of range.
+func (o *Options) withDefaults() (*Options, error) {
+	res := &Options{
+		Window:    DefaultWindow,
+		Threshold: DefaultThreshold,
+	}
+	if o != nil {
+		if o.Window != 0 {
+			res.Window = o.Window
+		}
+		if o.Threshold != 0 {
+			res.Threshold = o.Threshold
+		}
+	}
+
+	if res.Window < MinWindow || res.Window > MaxWindow {
+		return nil, fmt.Errorf("%w: window must be between %d and %d", ErrInvalidOptions, MinWindow, MaxWindow)
+	}
+	if res.Threshold <= 0 || res.Threshold > 1 {
+		return nil, fmt.Errorf("%w: threshold must be greater than 0 and at most 1", ErrInvalidOptions)
+	}
+	return res, nil
+}
+
+// Node is a node that is flapping.
+type Node struct {
+	// Fqdn is the FQDN of the node.
+	Fqdn string
+
+	// Env is the environment of the node.
+	Env summary.Environment
+
+	// Score is the share of the runs that changed state from the run before, between 0 and 1.
+	Score float64
+
+	// Transitions is the number of times the state changed between the runs.
+	Transitions int
+
+	// Runs are the runs that were scored, oldest first.
+	Runs []*entities.PuppetRun
+}
+
+// States returns the states of the scored runs, oldest first.
+func (n *Node) States() []summary.State {
+	states := make([]summary.State, len(n.Runs))
+	for i, run := range n.Runs {
+		states[i] = run.State
+	}
+	return states
+}
+
+// Score returns the flap score of the runs, ordered oldest first, and the number of times their state changed. The
+// score is the share of the runs whose state differs from the run before, so a node alternating between two states
+// on every run scores 1, and a node that always ends in the same state scores 0.
+func Score(runs []*entities.PuppetRun) (float64, int) {
+	if len(runs) < 2 {
+		return 0, 0
+	}
+
+	transitions := 0
+	for i := 1; i < len(runs); i++ {
+		if runs[i].State != runs[i-1].State {
+			transitions++
+		}
+	}
+	return float64(transitions) / float64(len(runs)-1), transitions
+}
+
+// Detect returns the nodes of the runs that are flapping, most flapping first. Each node in each environment is scored
+// over its latest runs, and is flapping if it has a full window of runs and its score reaches the threshold.
+func Detect(runs []*entities.PuppetRun, opts *Options) ([]*Node, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		fqdn string
+		env  summary.Environment
+	}
+
+	byNode := make(map[key][]*entities.PuppetRun)
+	for _, run := range runs {
+		k := key{fqdn: run.Fqdn, env: run.Env}
+		byNode[k] = append(byNode[k], run)
+	}
+
+	flapping := make([]*Node, 0)
+	for k, nodeRuns := range byNode {
+		if len(nodeRuns) < opts.Window {
+			continue
+		}
+
+		sort.Slice(nodeRuns, func(i, j int) bool {
+			return nodeRuns[i].ExecTime.Time().Before(nodeRuns[j].ExecTime.Time())
+		})
+		nodeRuns = nodeRuns[len(nodeRuns)-opts.Window:]
+
+		score, transitions := Score(nodeRuns)
+		if score < opts.Threshold {
+			continue
+		}
+
+		flapping = append(flapping, &Node{
+			Fqdn:        k.fqdn,
+			Env:         k.env,
+			Score:       score,
+			Transitions: transitions,
+			Runs:        nodeRuns,
+		})
+	}
+
+	sort.Slice(flapping, func(i, j int) bool {
+		if flapping[i].Score != flapping[j].Score {
+			return flapping[i].Score > flapping[j].Score
+		}
+		if flapping[i].Fqdn != flapping[j].Fqdn {
+			return flapping[i].Fqdn < flapping[j].Fqdn
+		}
+		return flapping[i].Env < flapping[j].Env
+	})
+
+	return flapping, nil
+}
+
+func (s *service) ChangingResources(ctx context.Context, node *Node) ([]*entities.PuppetResource, error) {
+	type key struct {
+		typ  string
+		name string
+	}
+
+	var common map[key]*entities.PuppetResource
+	changedRuns := 0
+	for _, run := range node.Runs {
+		if run.State == summary.State_UNCHANGED {
+			continue
+		}
+
+		file, err := dataaccess.Files.DownloadFile(ctx, run.YamlFile)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			// The file may have been purged, so the resources are worked out from the rest of the runs.
+			slog.Warn("Error downloading report file",
+				slog.String(logging.KeyHash, run.ID),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			continue
+		}
+
+		rep, err := parser.ParsePuppetReport(file)
+		if err != nil {
+			slog.Warn("Error parsing report file",
+				slog.String(logging.KeyHash, run.ID),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			continue
+		}
+		if len(rep.ResourcesChanged) == 0 {
+			continue
+		}
+		changedRuns++
+
+		changed := make(map[key]*entities.PuppetResource, len(rep.ResourcesChanged))
+		for _, res := range rep.ResourcesChanged {
+			changed[key{typ: res.Type, name: res.Name}] = res
+		}
+
+		if common == nil {
+			common = changed
+			continue
+		}
+		for k := range common {
+			if _, ok := changed[k]; !ok {
+				delete(common, k)
+			}
+		}
+	}
+
+	// A resource changed by a single run is not evidence of anything.
+	if changedRuns < 2 {
+		return make([]*entities.PuppetResource, 0), nil
+	}
+
+	resources := make([]*entities.PuppetResource, 0, len(common))
+	for _, res := range common {
+		resources = append(resources, res)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Type != resources[j].Type {
+			return resources[i].Type < resources[j].Type
+		}
+		return resources[i].Name < resources[j].Name
+	})
+	return resources, nil
+}
@@ -0,0 +1,177 @@
+package flapping
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+
+// newRuns returns the runs of the node in the states given, oldest first, half an hour apart.
+func newRuns(fqdn string, env summary.Environment, states ...summary.State) []*entities.PuppetRun {
+	runs := make([]*entities.PuppetRun, len(states))
+	for i, state := range states {
+		execTime := testNow.Add(time.Duration(i-len(states)) * 30 * time.Minute)
+		runs[i] = &entities.PuppetRun{
+			ID:       fqdn + "-" + string(rune('a'+i)),
+			Fqdn:     fqdn,
+			Env:      env,
+			State:    state,
+			ExecTime: entities.Datetime(execTime),
+			// The node reports its local time, so the file is named after it rather than the stored UTC time.
+			YamlFile: filepath.Join("reports", string(env), fqdn, execTime.In(time.FixedZone("", 60*60)).Format(time.RFC3339)+".yaml"),
+		}
+	}
+	return runs
+}
+
+// repeat returns the states repeated n times.
+func repeat(n int, states ...summary.State) []summary.State {
+	res := make([]summary.State, 0, n*len(states))
+	for i := 0; i < n; i++ {
+		res = append(res, states...)
+	}
+	return res
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name            string
+		states          []summary.State
+		wantScore       float64
+		wantTransitions int
+	}{
+		{"none", nil, 0, 0},
+		{"single", []summary.State{summary.State_CHANGED}, 0, 0},
+		{"stable", repeat(4, summary.State_UNCHANGED), 0, 0},
+		{"alternating", repeat(3, summary.State_CHANGED, summary.State_UNCHANGED), 1, 5},
+		{"recovered", []summary.State{summary.State_FAILED, summary.State_FAILED, summary.State_CHANGED, summary.State_UNCHANGED, summary.State_UNCHANGED}, 0.5, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, transitions := Score(newRuns("node1", summary.Environment_PRODUCTION, tt.states...))
+			require.InDelta(t, tt.wantScore, score, 0.0001)
+			require.Equal(t, tt.wantTransitions, transitions)
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	runs := make([]*entities.PuppetRun, 0)
+
+	// node1 alternates between failed and changed on every run.
+	runs = append(runs, newRuns("node1", summary.Environment_PRODUCTION, repeat(5, summary.State_FAILED, summary.State_CHANGED)...)...)
+
+	// node2 alternated in the past, but has been stable for its latest runs.
+	runs = append(runs, newRuns("node2", summary.Environment_PRODUCTION, append(
+		repeat(5, summary.State_CHANGED, summary.State_UNCHANGED),
+		repeat(10, summary.State_UNCHANGED)...,
+	)...)...)
+
+	// node3 changes state on two runs in every three.
+	runs = append(runs, newRuns("node3", summary.Environment_STAGING, repeat(5, summary.State_CHANGED, summary.State_UNCHANGED, summary.State_UNCHANGED)...)...)
+
+	// node4 alternates, but has not run enough to be scored.
+	runs = append(runs, newRuns("node4", summary.Environment_PRODUCTION, repeat(3, summary.State_CHANGED, summary.State_UNCHANGED)...)...)
+
+	got, err := Detect(runs, nil)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+
+	require.Equal(t, "node1", got[0].Fqdn)
+	require.Equal(t, summary.Environment_PRODUCTION, got[0].Env)
+	require.InDelta(t, 1, got[0].Score, 0.0001)
+	require.Equal(t, 9, got[0].Transitions)
+	require.Len(t, got[0].Runs, DefaultWindow)
+	require.Equal(t, repeat(5, summary.State_FAILED, summary.State_CHANGED), got[0].States())
+
+	require.Equal(t, "node3", got[1].Fqdn)
+	require.InDelta(t, 6.0/9, got[1].Score, 0.0001)
+
+	// A higher threshold leaves node3 out, and a smaller window scores node4.
+	got, err = Detect(runs, &Options{Window: 6, Threshold: 0.9})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, "node1", got[0].Fqdn)
+	require.Equal(t, "node4", got[1].Fqdn)
+}
+
+func TestDetect_InvalidOptions(t *testing.T) {
+	for _, opts := range []*Options{
+		{Window: MinWindow - 1},
+		{Window: MaxWindow + 1},
+		{Threshold: -0.5},
+		{Threshold: 1.5},
+	} {
+		_, err := Detect(nil, opts)
+		require.ErrorIs(t, err, ErrInvalidOptions)
+	}
+}
+
+func TestService_ChangingResources(t *testing.T) {
+	example, err := os.ReadFile("../parser/testdata/example.yaml")
+	require.NoError(t, err)
+
+	// The other report changes another resource.
+	other := []byte(strings.ReplaceAll(string(example), "example-command1", "example-command3"))
+
+	runs := newRuns("node1", summary.Environment_PRODUCTION,
+		summary.State_CHANGED,
+		summary.State_UNCHANGED,
+		summary.State_CHANGED,
+		summary.State_UNCHANGED,
+		summary.State_CHANGED,
+	)
+
+	files := new(dataaccess.MockStorage)
+	files.On("DownloadFile", mock.Anything, runs[0].YamlFile).Return(example, nil)
+	files.On("DownloadFile", mock.Anything, runs[2].YamlFile).Return(example, nil)
+	files.On("DownloadFile", mock.Anything, runs[4].YamlFile).Return([]byte(nil), errors.New("file not found"))
+	dataaccess.Files = files
+	t.Cleanup(func() {
+		dataaccess.Files = nil
+	})
+
+	svc := NewService(dataaccess.NewMemory())
+	node := &Node{Fqdn: "node1", Env: summary.Environment_PRODUCTION, Runs: runs}
+
+	// The unchanged runs are not read, and the missing file is skipped.
+	got, err := svc.ChangingResources(context.Background(), node)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, "Exec", got[0].Type)
+	require.Equal(t, "example-command1", got[0].Name)
+	files.AssertExpectations(t)
+
+	// A resource only changed by some of the runs is left out.
+	files = new(dataaccess.MockStorage)
+	files.On("DownloadFile", mock.Anything, runs[0].YamlFile).Return(example, nil)
+	files.On("DownloadFile", mock.Anything, runs[2].YamlFile).Return(other, nil)
+	files.On("DownloadFile", mock.Anything, runs[4].YamlFile).Return(example, nil)
+	dataaccess.Files = files
+
+	got, err = svc.ChangingResources(context.Background(), node)
+	require.NoError(t, err)
+	require.NotContains(t, resourceNames(got), "example-command1")
+	require.NotContains(t, resourceNames(got), "example-command3")
+}
+
+func resourceNames(resources []*entities.PuppetResource) []string {
+	names := make([]string, len(resources))
+	for i, res := range resources {
+		names[i] = res.Name
+	}
+	return names
+}
@@ -0,0 +1,141 @@
+package flapping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// collectInterval is how often the flapping nodes are counted.
+	collectInterval = time.Minute
+
+	// collectTimeout is how long the flapping nodes can take to be counted.
+	collectTimeout = 10 * time.Second
+)
+
+// flappingNodesCollector counts the flapping nodes, per environment. The nodes are counted in the background, so that
+// a scrape does not load the runs from the database.
+type flappingNodesCollector struct {
+	db dataaccess.Database
+
+	// flapping is the number of flapping nodes.
+	flapping *prometheus.Desc
+
+	// mtx guards the counts.
+	mtx sync.RWMutex
+
+	// counts are the latest counts, per environment. Nil until the nodes have been counted.
+	counts map[summary.Environment]int
+
+	// err is the error of the latest count, if it failed.
+	err error
+}
+
+// NewFlappingNodesCollector creates a collector of the number of nodes that are flapping, per environment, with the
+// default window and threshold. The nodes are counted every minute until the context is cancelled.
+func NewFlappingNodesCollector(ctx context.Context, db dataaccess.Database) prometheus.Collector {
+	c := newFlappingNodesCollector(db)
+	go c.run(ctx, collectInterval)
+	return c
+}
+
+func newFlappingNodesCollector(db dataaccess.Database) *flappingNodesCollector {
+	return &flappingNodesCollector{
+		db: db,
+		flapping: prometheus.NewDesc(
+			"flapping_nodes",
+			"Number of nodes whose state changes between their latest runs often enough to be flapping",
+			[]string{"environment"}, nil,
+		),
+	}
+}
+
+// run counts the nodes on every interval until the context is cancelled.
+func (c *flappingNodesCollector) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh counts the nodes, replacing the counts reported on a scrape.
+func (c *flappingNodesCollector) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, collectTimeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		slog.Warn("Error counting flapping nodes", slog.String(logging.KeyError, err.Error()))
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.counts, c.err = counts, err
+}
+
+func (c *flappingNodesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.flapping
+}
+
+func (c *flappingNodesCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	if c.err != nil {
+		ch <- prometheus.NewInvalidMetric(c.flapping, c.err)
+		return
+	}
+
+	for env, n := range c.counts {
+		ch <- prometheus.MustNewConstMetric(c.flapping, prometheus.GaugeValue, float64(n), string(env))
+	}
+}
+
+// count returns the number of flapping nodes per environment. Every environment with a listed node is counted, so
+// that the gauge drops to zero rather than disappears.
+func (c *flappingNodesCollector) count(ctx context.Context) (map[summary.Environment]int, error) {
+	runs, err := c.db.GetRuns(ctx)
+	if err != nil && !errors.Is(err, dataaccess.ErrNotFound) {
+		return nil, fmt.Errorf("error getting runs: %w", err)
+	}
+
+	runs, err = nodes.Listed(ctx, c.db, runs)
+	if err != nil {
+		return nil, err
+	}
+
+	flapping := make(map[summary.Environment]int)
+	for _, run := range runs {
+		if _, ok := flapping[run.Env]; !ok {
+			flapping[run.Env] = 0
+		}
+	}
+
+	detected, err := Detect(runs, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range detected {
+		flapping[node.Env]++
+	}
+
+	return flapping, nil
+}
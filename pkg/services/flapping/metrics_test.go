@@ -0,0 +1,61 @@
+package flapping
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlappingNodesCollector(t *testing.T) {
+	db := dataaccess.NewMemory()
+	ctx := context.Background()
+
+	saveRuns := func(runs []*entities.PuppetRun) {
+		for _, run := range runs {
+			require.NoError(t, db.SaveRun(ctx, &entities.PuppetReport{
+				ID:       run.ID,
+				Fqdn:     run.Fqdn,
+				Env:      run.Env,
+				State:    run.State,
+				ExecTime: run.ExecTime,
+			}))
+		}
+	}
+
+	// node1 and node2 flap, node3 is stable.
+	saveRuns(newRuns("node1", summary.Environment_PRODUCTION, repeat(5, summary.State_FAILED, summary.State_CHANGED)...))
+	saveRuns(newRuns("node2", summary.Environment_PRODUCTION, repeat(5, summary.State_CHANGED, summary.State_UNCHANGED)...))
+	saveRuns(newRuns("node3", summary.Environment_STAGING, repeat(10, summary.State_UNCHANGED)...))
+
+	// node2 was decommissioned, so it is not counted at all.
+	require.NoError(t, db.DecommissionNode(ctx, "node2", testNow))
+
+	c := newFlappingNodesCollector(db)
+	c.refresh(ctx)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	got := make(map[string]map[string]float64)
+	for _, family := range families {
+		got[family.GetName()] = make(map[string]float64)
+		for _, m := range family.GetMetric() {
+			got[family.GetName()][m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+		}
+	}
+
+	require.Equal(t, map[string]map[string]float64{
+		"flapping_nodes": {
+			"PRODUCTION": 1,
+			"STAGING":    0,
+		},
+	}, got)
+}
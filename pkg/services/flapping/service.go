@@ -0,0 +1,53 @@
+package flapping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+)
+
+type Detector interface {
+	// Flapping returns the listed nodes selected by the filter that are flapping, most flapping first.
+	Flapping(ctx context.Context, filter *nodes.Filter, opts *Options) ([]*Node, error)
+
+	// ChangingResources returns the resources changed by every run of the node that changed any resource.
+	ChangingResources(ctx context.Context, node *Node) ([]*entities.PuppetResource, error)
+}
+
+type service struct {
+	db dataaccess.Database
+}
+
+func NewService(db dataaccess.Database) Detector {
+	return &service{
+		db: db,
+	}
+}
+
+func (s *service) Flapping(ctx context.Context, filter *nodes.Filter, opts *Options) ([]*Node, error) {
+	// Check the options before reading the runs.
+	if _, err := opts.withDefaults(); err != nil {
+		return nil, err
+	}
+
+	runs, err := s.db.GetRuns(ctx)
+	if err != nil && !errors.Is(err, dataaccess.ErrNotFound) {
+		return nil, fmt.Errorf("error getting runs: %w", err)
+	}
+
+	runs, err = nodes.Listed(ctx, s.db, runs)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err = filter.Apply(ctx, s.db, runs)
+	if err != nil {
+		return nil, err
+	}
+
+	return Detect(runs, opts)
+}
@@ -0,0 +1,292 @@
+package incidents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+)
+
+const (
+	// DefaultWindow is how far apart in time the failed runs of an incident can be, when the window is not given.
+	DefaultWindow = 30 * time.Minute
+
+	// DefaultRange is how far back the incidents are listed from, when the start of the range is not given.
+	DefaultRange = 7 * 24 * time.Hour
+
+	// lockName is the name of the lock serialising the recording of the runs between instances.
+	lockName = "incidents"
+
+	// lockTTL is how long the lock is held for, should the instance recording a run stop before releasing it.
+	lockTTL = time.Minute
+
+	// lockRetry is how often the lock held by another instance is tried again.
+	lockRetry = 100 * time.Millisecond
+
+	// defaultLockWait is how long the recording of a run waits for the lock held by another instance.
+	defaultLockWait = 30 * time.Second
+)
+
+// errLockTimeout is returned when the lock serialising the recording of the runs is held by another instance for
+// longer than the run waits for it.
+var errLockTimeout = errors.New("timed out waiting for the incidents lock")
+
+// Options select the incidents that are listed.
+type Options struct {
+	// From is the start of the range the incidents were last seen in, inclusive. Defaults to DefaultRange before To.
+	From time.Time
+
+	// To is the end of the range the incidents were last seen in, exclusive. Defaults to now.
+	To time.Time
+
+	// Envs are the environments of the incidents. Every environment if empty.
+	Envs []summary.Environment
+}
+
+// withDefaults returns the options with the defaults filled in, or an error if they are out of range.
+func (o *Options) withDefaults(now time.Time) (*Options, error) {
+	res := new(Options)
+	if o != nil {
+		*res = *o
+	}
+
+	var err error
+	res.From, res.To, err = aggregate.Window(res.From, res.To, now, DefaultRange, res.Envs...)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *service) Record(ctx context.Context, report *entities.PuppetReport) (*entities.Incident, error) {
+	if report.State != summary.State_FAILED {
+		return nil, nil
+	}
+
+	inc, notify, err := s.record(ctx, report)
+	if err != nil || !notify {
+		return inc, err
+	}
+
+	sent, err := s.notifier.Notify(ctx, report, inc)
+	if err == nil && sent {
+		return inc, nil
+	}
+
+	// The incident was claimed for the notification when it was recorded, so the claim is released for the next run
+	// of the incident to notify instead.
+	inc.Notified = false
+	if _, unclaimErr := s.db.SetIncidentNotified(ctx, inc.ID, false); unclaimErr != nil {
+		slog.Warn("Error releasing incident notification",
+			slog.String("incident", inc.ID),
+			slog.String(logging.KeyError, unclaimErr.Error()),
+		)
+	}
+
+	if err != nil {
+		return inc, fmt.Errorf("error notifying of incident: %w", err)
+	}
+	return inc, nil
+}
+
+// record adds the failed run of the report to the incident it is correlated with, or opens a new one, and saves it.
+// Returns whether the incident is to be notified, in which case the notification has been claimed in the database so
+// that no other run, on this instance or another, notifies of it too.
+func (s *service) record(ctx context.Context, report *entities.PuppetReport) (*entities.Incident, bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// The incidents are read and saved again under the lock, so that correlated runs recorded by other instances at
+	// the same time join the same incident, and none of their runs are lost.
+	if err := s.lock(ctx); err != nil {
+		return nil, false, err
+	}
+	defer s.unlock()
+
+	resources := failedResources(report)
+	message := representativeMessage(report, resources)
+
+	inc, err := s.correlated(ctx, report, resources, message)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if inc == nil {
+		inc = &entities.Incident{
+			ID:            report.ID,
+			Env:           report.Env,
+			ConfigVersion: report.ConfigVersion,
+			Resources:     resources,
+			Message:       message,
+			Nodes:         make(entities.Strings, 0, 1),
+		}
+
+		slog.Info("Incident opened",
+			slog.String("incident", inc.ID),
+			slog.String(logging.KeyFqdn, report.Fqdn),
+		)
+	}
+	inc.AddRun(report.Fqdn, report.ExecTime)
+
+	if err := s.db.SaveIncident(ctx, inc); err != nil {
+		return nil, false, fmt.Errorf("error saving incident: %w", err)
+	}
+
+	if s.notifier == nil || inc.Notified {
+		return inc, false, nil
+	}
+
+	// Only the run that changes the incident to notified notifies of it.
+	claimed, err := s.db.SetIncidentNotified(ctx, inc.ID, true)
+	if err != nil {
+		return nil, false, fmt.Errorf("error claiming incident notification: %w", err)
+	}
+	inc.Notified = true
+	return inc, claimed, nil
+}
+
+// lock acquires the lock serialising the recording of the runs between instances, waiting for it while it is held by
+// another instance.
+func (s *service) lock(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.lockWait)
+	defer cancel()
+
+	for {
+		ok, err := s.db.AcquireLock(ctx, lockName, s.holder, lockTTL)
+		if err != nil {
+			return fmt.Errorf("error acquiring incidents lock: %w", err)
+		} else if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errLockTimeout
+		case <-time.After(lockRetry):
+		}
+	}
+}
+
+// unlock releases the lock serialising the recording of the runs between instances.
+func (s *service) unlock() {
+	// The lock is released even if the context of the run is done, so that other instances do not wait for its ttl.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.db.ReleaseLock(ctx, lockName, s.holder); err != nil {
+		slog.Warn("Error releasing incidents lock", slog.String(logging.KeyError, err.Error()))
+	}
+}
+
+// correlated returns the most recently seen incident the failed run of the report is correlated with, or nil if there
+// is none. A run is correlated with an incident in the same environment, on the same configuration version, that was
+// seen within the window of the run, and that failed on any of the same resources. The runs that did not fail on any
+// resource are correlated by their representative message instead.
+func (s *service) correlated(ctx context.Context, report *entities.PuppetReport, resources []string, message string) (*entities.Incident, error) {
+	execTime := report.ExecTime.Time()
+
+	// The incidents are most recently seen first, so the first that correlates is the one the run joins.
+	incidents, err := s.db.GetIncidents(ctx, execTime.Add(-s.window), time.Time{}, report.Env)
+	if err != nil {
+		return nil, fmt.Errorf("error getting incidents: %w", err)
+	}
+
+	for _, inc := range incidents {
+		if inc.ConfigVersion != report.ConfigVersion {
+			continue
+		}
+		if execTime.Before(inc.FirstSeen.Time().Add(-s.window)) {
+			continue
+		}
+		if len(inc.Resources) == 0 && len(resources) == 0 {
+			if inc.Message == message {
+				return inc, nil
+			}
+			continue
+		}
+		for _, res := range resources {
+			if slices.Contains(inc.Resources, res) {
+				return inc, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// failedResources returns the resources that failed in the report, as Type[title], sorted and without duplicates.
+func failedResources(report *entities.PuppetReport) entities.Strings {
+	resources := make(entities.Strings, 0, len(report.ResourcesFailed))
+	seen := make(map[string]struct{})
+	for _, res := range report.ResourcesFailed {
+		key := fmt.Sprintf("%s[%s]", res.Type, res.Name)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		resources = append(resources, key)
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+// representativeMessage returns the first log message of the report about any of the failed resources, or the first
+// log message if none is.
+func representativeMessage(report *entities.PuppetReport, resources []string) string {
+	for _, msg := range report.LogMessages {
+		for _, res := range resources {
+			if strings.Contains(msg, res) {
+				return msg
+			}
+		}
+	}
+
+	if len(report.LogMessages) > 0 {
+		return report.LogMessages[0]
+	}
+	return ""
+}
+
+func (s *service) Incidents(ctx context.Context, filter *nodes.Filter, opts *Options) ([]*entities.Incident, error) {
+	opts, err := opts.withDefaults(s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	incidents, err := s.db.GetIncidents(ctx, opts.From, opts.To, opts.Envs...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting incidents: %w", err)
+	} else if filter.IsEmpty() {
+		return incidents, nil
+	}
+
+	selected, err := filter.SelectedFunc(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]*entities.Incident, 0, len(incidents))
+	for _, inc := range incidents {
+		if slices.ContainsFunc(inc.Nodes, selected) {
+			filtered = append(filtered, inc)
+		}
+	}
+	return filtered, nil
+}
+
+func (s *service) Incident(ctx context.Context, id string) (*entities.Incident, error) {
+	inc, err := s.db.GetIncident(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting incident: %w", err)
+	}
+	return inc, nil
+}
@@ -0,0 +1,287 @@
+package incidents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+
+// recordingNotifier records the incidents it is notified of, returning the given result.
+type recordingNotifier struct {
+	sent bool
+	err  error
+
+	mtx      sync.Mutex
+	notified []*entities.Incident
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, _ *entities.PuppetReport, incident *entities.Incident) (bool, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	cp := *incident
+	n.notified = append(n.notified, &cp)
+	return n.sent, n.err
+}
+
+func newTestService(notifier *recordingNotifier) (*service, dataaccess.Database) {
+	db := dataaccess.NewMemory()
+	return newTestInstance(db, "instance1", notifier), db
+}
+
+// newTestInstance returns the service of the instance with the given holder ID, recording the runs in the database.
+func newTestInstance(db dataaccess.Database, holder string, notifier *recordingNotifier) *service {
+	svc := &service{
+		db:       db,
+		window:   DefaultWindow,
+		holder:   holder,
+		lockWait: time.Second,
+		now:      func() time.Time { return testNow },
+	}
+	if notifier != nil {
+		svc.notifier = notifier
+	}
+	return svc
+}
+
+// newFailedReport returns a failed report of the node, executed the given number of minutes before the test time,
+// failing on the given resources.
+func newFailedReport(fqdn string, minutes int, resources ...*entities.PuppetResource) *entities.PuppetReport {
+	return &entities.PuppetReport{
+		ID:              fmt.Sprintf("report-%s-%d", fqdn, minutes),
+		Fqdn:            fqdn,
+		Env:             summary.Environment_PRODUCTION,
+		State:           summary.State_FAILED,
+		ExecTime:        entities.Datetime(testNow.Add(-time.Duration(minutes) * time.Minute)),
+		ConfigVersion:   "1708135209",
+		ResourcesFailed: resources,
+		LogMessages: []string{
+			"Puppet : Applying configuration version '1708135209'",
+			"/Stage[main]/Nginx/Package[nginx]/ensure : change from 'absent' to 'present' failed",
+		},
+	}
+}
+
+var (
+	resPkg = &entities.PuppetResource{Type: "Package", Name: "nginx"}
+	resSvc = &entities.PuppetResource{Type: "Service", Name: "nginx"}
+	resCfg = &entities.PuppetResource{Type: "File", Name: "/etc/app.conf"}
+)
+
+func TestService_Record(t *testing.T) {
+	notifier := &recordingNotifier{sent: true}
+	svc, _ := newTestService(notifier)
+	ctx := context.Background()
+
+	// The runs that did not fail are not recorded.
+	rep := newFailedReport("node0", 50, resPkg)
+	rep.State = summary.State_CHANGED
+	inc, err := svc.Record(ctx, rep)
+	require.NoError(t, err)
+	require.Nil(t, inc)
+
+	opened, err := svc.Record(ctx, newFailedReport("node1", 40, resPkg, resSvc))
+	require.NoError(t, err)
+	require.Equal(t, &entities.Incident{
+		ID:            "report-node1-40",
+		Env:           summary.Environment_PRODUCTION,
+		ConfigVersion: "1708135209",
+		Resources:     entities.Strings{"Package[nginx]", "Service[nginx]"},
+		Message:       "/Stage[main]/Nginx/Package[nginx]/ensure : change from 'absent' to 'present' failed",
+		FirstSeen:     entities.Datetime(testNow.Add(-40 * time.Minute)),
+		LastSeen:      entities.Datetime(testNow.Add(-40 * time.Minute)),
+		Nodes:         entities.Strings{"node1"},
+		Reports:       1,
+		Notified:      true,
+	}, opened)
+
+	// The runs sharing a failed resource within the window join the incident, and it is only notified once.
+	for _, rep := range []*entities.PuppetReport{
+		newFailedReport("node2", 20, resSvc),
+		newFailedReport("node1", 10, resPkg),
+	} {
+		inc, err := svc.Record(ctx, rep)
+		require.NoError(t, err)
+		require.Equal(t, opened.ID, inc.ID)
+	}
+
+	// The runs on another configuration version, or failing on other resources, open their own incidents.
+	other := newFailedReport("node3", 5, resPkg)
+	other.ConfigVersion = "1708135999"
+	for _, rep := range []*entities.PuppetReport{other, newFailedReport("node4", 5, resCfg)} {
+		inc, err := svc.Record(ctx, rep)
+		require.NoError(t, err)
+		require.Equal(t, rep.ID, inc.ID)
+	}
+
+	got, err := svc.Incident(ctx, opened.ID)
+	require.NoError(t, err)
+	require.Equal(t, entities.Strings{"node1", "node2"}, got.Nodes)
+	require.Equal(t, 3, got.Reports)
+	require.Equal(t, entities.Datetime(testNow.Add(-40*time.Minute)), got.FirstSeen)
+	require.Equal(t, entities.Datetime(testNow.Add(-10*time.Minute)), got.LastSeen)
+
+	require.Len(t, notifier.notified, 3)
+	require.Equal(t, opened.ID, notifier.notified[0].ID)
+
+	incidents, err := svc.Incidents(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, incidents, 3)
+}
+
+func TestService_Record_Window(t *testing.T) {
+	svc, _ := newTestService(nil)
+	ctx := context.Background()
+
+	first, err := svc.Record(ctx, newFailedReport("node1", 120, resPkg))
+	require.NoError(t, err)
+
+	// A run further than the window from the incident opens a new one.
+	later, err := svc.Record(ctx, newFailedReport("node2", 60, resPkg))
+	require.NoError(t, err)
+	require.NotEqual(t, first.ID, later.ID)
+
+	// The runs without failed resources are correlated by their message.
+	noRes := newFailedReport("node3", 50)
+	noRes.LogMessages = []string{"Puppet : Could not retrieve catalog from remote server"}
+	inc, err := svc.Record(ctx, noRes)
+	require.NoError(t, err)
+	require.Equal(t, noRes.ID, inc.ID)
+	require.Empty(t, inc.Resources)
+	require.Equal(t, "Puppet : Could not retrieve catalog from remote server", inc.Message)
+
+	noRes = newFailedReport("node4", 45)
+	noRes.LogMessages = []string{"Puppet : Could not retrieve catalog from remote server"}
+	inc, err = svc.Record(ctx, noRes)
+	require.NoError(t, err)
+	require.Equal(t, "report-node3-50", inc.ID)
+	require.False(t, inc.Notified)
+}
+
+func TestService_Record_NotSent(t *testing.T) {
+	notifier := &recordingNotifier{err: errors.New("some error")}
+	svc, _ := newTestService(notifier)
+	ctx := context.Background()
+
+	inc, err := svc.Record(ctx, newFailedReport("node1", 20, resPkg))
+	require.EqualError(t, err, "error notifying of incident: some error")
+	require.False(t, inc.Notified)
+
+	got, err := svc.Incident(ctx, inc.ID)
+	require.NoError(t, err)
+	require.False(t, got.Notified)
+
+	// The next run of the incident notifies of it instead, until it is sent.
+	notifier.err = nil
+	_, err = svc.Record(ctx, newFailedReport("node2", 15, resPkg))
+	require.NoError(t, err)
+
+	notifier.sent = true
+	_, err = svc.Record(ctx, newFailedReport("node3", 10, resPkg))
+	require.NoError(t, err)
+	_, err = svc.Record(ctx, newFailedReport("node4", 5, resPkg))
+	require.NoError(t, err)
+
+	require.Len(t, notifier.notified, 3)
+
+	got, err = svc.Incident(ctx, inc.ID)
+	require.NoError(t, err)
+	require.True(t, got.Notified)
+	require.Equal(t, 4, got.Reports)
+}
+
+func TestService_Record_SharedBetweenInstances(t *testing.T) {
+	db := dataaccess.NewMemory()
+	notifier := &recordingNotifier{sent: true}
+	instances := []*service{
+		newTestInstance(db, "instance1", notifier),
+		newTestInstance(db, "instance2", notifier),
+		newTestInstance(db, "instance3", notifier),
+	}
+	ctx := context.Background()
+
+	// The correlated runs recorded by the instances at the same time join a single incident, which is notified once.
+	var wg sync.WaitGroup
+	errs := make(chan error, 9)
+	for i := 0; i < 9; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := instances[i%len(instances)].Record(ctx, newFailedReport(fmt.Sprintf("node%d", i), 20-i, resPkg))
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	incidents, err := instances[0].Incidents(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, incidents, 1)
+	require.Equal(t, 9, incidents[0].Reports)
+	require.True(t, incidents[0].Notified)
+	require.Len(t, notifier.notified, 1)
+}
+
+func TestService_Record_NotifiedByAnotherInstance(t *testing.T) {
+	notifier := &recordingNotifier{sent: true}
+	svc, db := newTestService(notifier)
+	ctx := context.Background()
+
+	inc, err := newTestInstance(db, "instance2", nil).Record(ctx, newFailedReport("node1", 20, resPkg))
+	require.NoError(t, err)
+
+	// The notification has been claimed by another instance, so it is not notified again.
+	claimed, err := db.SetIncidentNotified(ctx, inc.ID, true)
+	require.NoError(t, err)
+	require.True(t, claimed)
+
+	got, err := svc.Record(ctx, newFailedReport("node2", 10, resPkg))
+	require.NoError(t, err)
+	require.Equal(t, inc.ID, got.ID)
+	require.True(t, got.Notified)
+	require.Empty(t, notifier.notified)
+}
+
+func TestService_Record_Locked(t *testing.T) {
+	svc, db := newTestService(nil)
+	svc.lockWait = 50 * time.Millisecond
+	ctx := context.Background()
+
+	ok, err := db.AcquireLock(ctx, lockName, "instance2", time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The run is not recorded while another instance is recording.
+	_, err = svc.Record(ctx, newFailedReport("node1", 20, resPkg))
+	require.ErrorIs(t, err, errLockTimeout)
+
+	incidents, err := svc.Incidents(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Empty(t, incidents)
+
+	// Once the other instance is done, the run is recorded.
+	require.NoError(t, db.ReleaseLock(ctx, lockName, "instance2"))
+	inc, err := svc.Record(ctx, newFailedReport("node1", 20, resPkg))
+	require.NoError(t, err)
+	require.Equal(t, 1, inc.Reports)
+}
+
+func TestService_Incident_NotFound(t *testing.T) {
+	svc, _ := newTestService(nil)
+
+	_, err := svc.Incident(context.Background(), "missing")
+	require.ErrorIs(t, err, dataaccess.ErrNotFound)
+}
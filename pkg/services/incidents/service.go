@@ -0,0 +1,76 @@
+package incidents
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/alerting"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+)
+
+type Correlator interface {
+	// Record groups the failed run of the report into the incident it is correlated with, opening a new incident if
+	// there is none, and notifies of the incident the first time it is recorded for a node that is not muted. Returns
+	// nil if the run did not fail.
+	Record(ctx context.Context, report *entities.PuppetReport) (*entities.Incident, error)
+
+	// Incidents returns the incidents last seen in the window of the options, most recently seen first. Only the
+	// incidents with any of the nodes selected by the filter are returned.
+	Incidents(ctx context.Context, filter *nodes.Filter, opts *Options) ([]*entities.Incident, error)
+
+	// Incident returns the incident with the given ID. Returns dataaccess.ErrNotFound if there is no such incident.
+	Incident(ctx context.Context, id string) (*entities.Incident, error)
+}
+
+type service struct {
+	db dataaccess.Database
+
+	// window is how far apart in time the failed runs of an incident can be.
+	window time.Duration
+
+	// notifier is notified of the incidents. If nil, nothing is notified.
+	notifier alerting.Notifier
+
+	// mtx serialises the recording of the runs, so that correlated runs uploaded together join the same incident.
+	mtx sync.Mutex
+
+	// holder identifies this instance when acquiring the lock serialising the recording of the runs between instances.
+	holder string
+
+	// lockWait is how long the recording of a run waits for the lock held by another instance.
+	lockWait time.Duration
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// NewService creates a correlator grouping the failed runs that are at most window apart into incidents. If window is
+// not positive, DefaultWindow is used.
+func NewService(db dataaccess.Database, window time.Duration, notifier alerting.Notifier) Correlator {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+
+	return &service{
+		db:       db,
+		window:   window,
+		notifier: notifier,
+		holder:   holderID(),
+		lockWait: defaultLockWait,
+		now:      time.Now,
+	}
+}
+
+// holderID returns the ID identifying this instance when acquiring locks.
+func holderID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
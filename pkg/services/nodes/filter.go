@@ -0,0 +1,109 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+)
+
+// Filter selects the nodes by their metadata.
+type Filter struct {
+	// Owner is the owner the nodes must have. Any owner if empty.
+	Owner string
+
+	// Labels are the labels the nodes must all have.
+	Labels map[string]string
+}
+
+// ParseFilter parses the filter from the owner and the labels, given as key=value.
+func ParseFilter(owner *string, labels *[]string) (*Filter, error) {
+	f := new(Filter)
+	if owner != nil {
+		f.Owner = strings.TrimSpace(*owner)
+	}
+
+	if labels == nil {
+		return f, nil
+	}
+
+	f.Labels = make(map[string]string, len(*labels))
+	for _, label := range *labels {
+		k, v, ok := strings.Cut(label, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid label %q, expected key=value", label)
+		}
+		f.Labels[k] = strings.TrimSpace(v)
+	}
+	return f, nil
+}
+
+// IsEmpty returns whether the filter selects every node.
+func (f *Filter) IsEmpty() bool {
+	return f == nil || (f.Owner == "" && len(f.Labels) == 0)
+}
+
+// SelectedFunc returns a function reporting whether the node with the given fqdn is selected by the filter.
+func (f *Filter) SelectedFunc(ctx context.Context, db dataaccess.Database) (func(fqdn string) bool, error) {
+	if f.IsEmpty() {
+		return func(string) bool { return true }, nil
+	}
+
+	metadata, err := db.GetAllNodeMetadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting node metadata: %w", err)
+	}
+
+	byFqdn := make(map[string]*entities.NodeMetadata, len(metadata))
+	for _, md := range metadata {
+		byFqdn[md.Fqdn] = md
+	}
+
+	return func(fqdn string) bool {
+		return byFqdn[fqdn].Matches(f.Owner, f.Labels)
+	}, nil
+}
+
+// Fqdns returns the FQDNs of the nodes selected by the filter, or nil if it selects every node. Only the nodes with
+// metadata can be selected by a filter that is not empty.
+func (f *Filter) Fqdns(ctx context.Context, db dataaccess.Database) ([]string, error) {
+	if f.IsEmpty() {
+		return nil, nil
+	}
+
+	metadata, err := db.GetAllNodeMetadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting node metadata: %w", err)
+	}
+
+	fqdns := make([]string, 0)
+	for _, md := range metadata {
+		if md.Matches(f.Owner, f.Labels) {
+			fqdns = append(fqdns, md.Fqdn)
+		}
+	}
+	return fqdns, nil
+}
+
+// Apply returns the runs of the nodes selected by the filter.
+func (f *Filter) Apply(ctx context.Context, db dataaccess.Database, runs []*entities.PuppetRun) ([]*entities.PuppetRun, error) {
+	if f.IsEmpty() {
+		return runs, nil
+	}
+
+	selected, err := f.SelectedFunc(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]*entities.PuppetRun, 0, len(runs))
+	for _, run := range runs {
+		if selected(run.Fqdn) {
+			filtered = append(filtered, run)
+		}
+	}
+	return filtered, nil
+}
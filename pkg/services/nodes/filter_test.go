@@ -0,0 +1,61 @@
+package nodes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	owner := " team-a "
+	f, err := ParseFilter(&owner, &[]string{"role=web", "dc = lon", "empty="})
+	require.NoError(t, err)
+	require.Equal(t, &Filter{
+		Owner:  "team-a",
+		Labels: map[string]string{"role": "web", "dc": "lon", "empty": ""},
+	}, f)
+
+	f, err = ParseFilter(nil, nil)
+	require.NoError(t, err)
+	require.True(t, f.IsEmpty())
+
+	_, err = ParseFilter(nil, &[]string{"role"})
+	require.Error(t, err)
+
+	_, err = ParseFilter(nil, &[]string{"=web"})
+	require.Error(t, err)
+}
+
+func TestFilter_Apply(t *testing.T) {
+	runs := []*entities.PuppetRun{
+		{ID: "1", Fqdn: "node1"},
+		{ID: "2", Fqdn: "node2"},
+		{ID: "3", Fqdn: "node3"},
+	}
+
+	db := new(dataaccess.MockDb)
+	db.On("GetAllNodeMetadata", mock.Anything).Return([]*entities.NodeMetadata{
+		{Fqdn: "node1", Owner: "team-a", Labels: entities.Labels{"role": "web"}},
+		{Fqdn: "node2", Owner: "team-a", Labels: entities.Labels{"role": "db"}},
+	}, nil)
+
+	// node3 has no metadata, so it is never selected by a filter.
+	f := &Filter{Owner: "team-a"}
+	got, err := f.Apply(context.Background(), db, runs)
+	require.NoError(t, err)
+	require.Equal(t, runs[:2], got)
+
+	f = &Filter{Owner: "team-a", Labels: map[string]string{"role": "db"}}
+	got, err = f.Apply(context.Background(), db, runs)
+	require.NoError(t, err)
+	require.Equal(t, runs[1:2], got)
+
+	// An empty filter does not look at the metadata.
+	got, err = new(Filter).Apply(context.Background(), new(dataaccess.MockDb), runs)
+	require.NoError(t, err)
+	require.Equal(t, runs, got)
+}
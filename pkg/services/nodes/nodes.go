@@ -0,0 +1,155 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+)
+
+// Result is the outcome of deleting a node.
+type Result struct {
+	// ReportsDeleted is the number of reports deleted from the database.
+	ReportsDeleted int
+
+	// FilesDeleted is the number of report files deleted from storage.
+	FilesDeleted int
+
+	// FilesFailed is the number of report files that could not be deleted from storage.
+	FilesFailed int
+}
+
+func (s *service) Delete(ctx context.Context, fqdn string) (*Result, error) {
+	reports, err := s.db.GetReports(ctx, fqdn)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reports: %w", err)
+	} else if len(reports) == 0 {
+		return nil, dataaccess.ErrNotFound
+	}
+
+	ids := make([]string, len(reports))
+	for i, rep := range reports {
+		ids[i] = rep.ID
+	}
+
+	affected, err := s.db.DeleteReports(ctx, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("error deleting reports: %w", err)
+	}
+
+	res := &Result{
+		ReportsDeleted: affected,
+	}
+
+	for _, rep := range reports {
+		if err := dataaccess.Files.DeleteFile(ctx, rep.YamlFile); err != nil {
+			// The report has already been removed from the database, so carry on with the rest of the files.
+			slog.Warn("Error deleting report file",
+				slog.String(logging.KeyHash, rep.ID),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			res.FilesFailed++
+			continue
+		}
+		res.FilesDeleted++
+	}
+
+	// The node no longer exists, so there is nothing left to hide or describe.
+	if err := s.db.RecommissionNode(ctx, fqdn); err != nil {
+		return nil, fmt.Errorf("error removing decommission: %w", err)
+	}
+
+	if err := s.db.DeleteNodeMetadata(ctx, fqdn); err != nil && !errors.Is(err, dataaccess.ErrNotFound) {
+		return nil, fmt.Errorf("error deleting node metadata: %w", err)
+	}
+
+	slog.Info("Node deleted",
+		slog.String(logging.KeyFqdn, fqdn),
+		slog.Int("reports", res.ReportsDeleted),
+		slog.Int("files", res.FilesDeleted),
+		slog.Int("files_failed", res.FilesFailed),
+	)
+
+	return res, nil
+}
+
+func (s *service) Decommission(ctx context.Context, fqdn string) error {
+	reports, err := s.db.GetReports(ctx, fqdn)
+	if err != nil {
+		return fmt.Errorf("error getting reports: %w", err)
+	} else if len(reports) == 0 {
+		return dataaccess.ErrNotFound
+	}
+
+	if err := s.db.DecommissionNode(ctx, fqdn, s.now()); err != nil {
+		return fmt.Errorf("error decommissioning node: %w", err)
+	}
+
+	slog.Info("Node decommissioned", slog.String(logging.KeyFqdn, fqdn))
+
+	return nil
+}
+
+// ListedFunc returns a function reporting whether a run of the node with the given fqdn, executed at the given time,
+// is shown in the listings. The runs of decommissioned nodes executed before the node was decommissioned are not.
+func ListedFunc(ctx context.Context, db dataaccess.Database) (func(fqdn string, execTime time.Time) bool, error) {
+	decommissions, err := db.GetDecommissions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting decommissions: %w", err)
+	}
+
+	return func(fqdn string, execTime time.Time) bool {
+		at, ok := decommissions[fqdn]
+		return !ok || execTime.After(at)
+	}, nil
+}
+
+// Listed returns the runs that are shown in the listings, leaving out the runs of decommissioned nodes that were
+// executed before the node was decommissioned.
+func Listed(ctx context.Context, db dataaccess.Database, runs []*entities.PuppetRun) ([]*entities.PuppetRun, error) {
+	isListed, err := ListedFunc(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make([]*entities.PuppetRun, 0, len(runs))
+	for _, run := range runs {
+		if isListed(run.Fqdn, run.ExecTime.Time()) {
+			listed = append(listed, run)
+		}
+	}
+	return listed, nil
+}
+
+// Latest returns the latest run of each node in each environment, in the order the nodes first appear in the runs.
+func Latest(runs []*entities.PuppetRun) []*entities.PuppetRun {
+	type key struct {
+		fqdn string
+		env  summary.Environment
+	}
+
+	latest := make(map[key]*entities.PuppetRun)
+	order := make([]key, 0)
+	for _, run := range runs {
+		k := key{fqdn: run.Fqdn, env: run.Env}
+		existing, ok := latest[k]
+		if !ok {
+			order = append(order, k)
+		}
+		if !ok || run.ExecTime.Time().After(existing.ExecTime.Time()) {
+			latest[k] = run
+		}
+	}
+
+	res := make([]*entities.PuppetRun, 0, len(order))
+	for _, k := range order {
+		res = append(res, latest[k])
+	}
+	return res
+}
@@ -0,0 +1,146 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newReport returns the summary of a report of the node, executed at the given time.
+func newReport(id, fqdn string, execTime time.Time) *entities.PuppetReportSummary {
+	return &entities.PuppetReportSummary{
+		ID:       id,
+		Fqdn:     fqdn,
+		Env:      summary.Environment_PRODUCTION,
+		State:    summary.State_CHANGED,
+		ExecTime: entities.Datetime(execTime),
+		YamlFile: filepath.Join("reports", "PRODUCTION", fqdn, execTime.Format(time.RFC3339)+".yaml"),
+	}
+}
+
+func TestService_Delete(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	reports := []*entities.PuppetReportSummary{
+		newReport("1", "node1", now),
+		newReport("2", "node1", now.Add(-time.Hour)),
+		// The node reported its local time, so the file is named after it.
+		newReport("3", "node1", now.Add(-2*time.Hour).In(time.FixedZone("", 60*60))),
+	}
+
+	db := new(dataaccess.MockDb)
+	db.On("GetReports", mock.Anything, "node1").Return(reports, nil)
+	db.On("DeleteReports", mock.Anything, []string{"1", "2", "3"}).Return(3, nil)
+	db.On("RecommissionNode", mock.Anything, "node1").Return(nil)
+	db.On("DeleteNodeMetadata", mock.Anything, "node1").Return(dataaccess.ErrNotFound)
+
+	files := new(dataaccess.MockStorage)
+	files.On("DeleteFile", mock.Anything, reports[0].YamlFile).Return(nil)
+	files.On("DeleteFile", mock.Anything, reports[1].YamlFile).Return(errors.New("file not found"))
+	files.On("DeleteFile", mock.Anything, reports[2].YamlFile).Return(nil)
+	dataaccess.Files = files
+	t.Cleanup(func() {
+		dataaccess.Files = nil
+	})
+
+	res, err := NewService(db).Delete(context.Background(), "node1")
+	require.NoError(t, err)
+	require.Equal(t, &Result{ReportsDeleted: 3, FilesDeleted: 2, FilesFailed: 1}, res)
+
+	db.AssertExpectations(t)
+	files.AssertExpectations(t)
+}
+
+func TestService_Delete_NotFound(t *testing.T) {
+	db := new(dataaccess.MockDb)
+	db.On("GetReports", mock.Anything, "node1").Return([]*entities.PuppetReportSummary{}, nil)
+
+	_, err := NewService(db).Delete(context.Background(), "node1")
+	require.ErrorIs(t, err, dataaccess.ErrNotFound)
+
+	db.AssertExpectations(t)
+}
+
+func TestService_Decommission(t *testing.T) {
+	now := time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+
+	db := new(dataaccess.MockDb)
+	db.On("GetReports", mock.Anything, "node1").Return([]*entities.PuppetReportSummary{newReport("1", "node1", now)}, nil)
+	db.On("DecommissionNode", mock.Anything, "node1", now).Return(nil)
+
+	svc := &service{
+		db:  db,
+		now: func() time.Time { return now },
+	}
+	require.NoError(t, svc.Decommission(context.Background(), "node1"))
+
+	db.AssertExpectations(t)
+}
+
+func TestService_Decommission_NotFound(t *testing.T) {
+	db := new(dataaccess.MockDb)
+	db.On("GetReports", mock.Anything, "node1").Return([]*entities.PuppetReportSummary{}, nil)
+
+	err := NewService(db).Decommission(context.Background(), "node1")
+	require.ErrorIs(t, err, dataaccess.ErrNotFound)
+
+	db.AssertExpectations(t)
+}
+
+func TestListed(t *testing.T) {
+	decommissioned := time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+	run := func(id, fqdn string, execTime time.Time) *entities.PuppetRun {
+		return &entities.PuppetRun{
+			ID:       id,
+			Fqdn:     fqdn,
+			ExecTime: entities.Datetime(execTime),
+		}
+	}
+
+	runs := []*entities.PuppetRun{
+		run("1", "node1", decommissioned.Add(-time.Hour)),
+		run("2", "node1", decommissioned),
+		run("3", "node2", decommissioned.Add(-time.Hour)),
+		run("4", "node3", decommissioned.Add(time.Hour)),
+		run("5", "node3", decommissioned.Add(-time.Hour)),
+	}
+
+	db := new(dataaccess.MockDb)
+	db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{
+		"node1": decommissioned,
+		"node3": decommissioned,
+	}, nil)
+
+	// node3 reported after it was decommissioned, so its new run is listed again.
+	got, err := Listed(context.Background(), db, runs)
+	require.NoError(t, err)
+	require.Equal(t, []*entities.PuppetRun{runs[2], runs[3]}, got)
+}
+
+func TestLatest(t *testing.T) {
+	at := time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+	run := func(id, fqdn string, env summary.Environment, execTime time.Time) *entities.PuppetRun {
+		return &entities.PuppetRun{
+			ID:       id,
+			Fqdn:     fqdn,
+			Env:      env,
+			ExecTime: entities.Datetime(execTime),
+		}
+	}
+
+	runs := []*entities.PuppetRun{
+		run("1", "node1", summary.Environment_PRODUCTION, at.Add(-time.Hour)),
+		run("2", "node2", summary.Environment_PRODUCTION, at),
+		run("3", "node1", summary.Environment_PRODUCTION, at),
+		run("4", "node1", summary.Environment_STAGING, at.Add(-2*time.Hour)),
+	}
+
+	require.Equal(t, []*entities.PuppetRun{runs[2], runs[1], runs[3]}, Latest(runs))
+}
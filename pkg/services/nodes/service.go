@@ -0,0 +1,32 @@
+package nodes
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+)
+
+type Manager interface {
+	// Delete deletes the reports and the metadata of the node with the given fqdn from the database and storage. Returns
+	// dataaccess.ErrNotFound if the node has no reports.
+	Delete(ctx context.Context, fqdn string) (*Result, error)
+
+	// Decommission hides the node with the given fqdn from the listings, keeping its reports. The node is listed again
+	// if it reports after being decommissioned. Returns dataaccess.ErrNotFound if the node has no reports.
+	Decommission(ctx context.Context, fqdn string) error
+}
+
+type service struct {
+	db dataaccess.Database
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+func NewService(db dataaccess.Database) Manager {
+	return &service{
+		db:  db,
+		now: time.Now,
+	}
+}
@@ -125,6 +125,74 @@ func parseRuntime(y *simpleyaml.Yaml, out *entities.PuppetReport) error {
 	return nil
 }
 
+// parseTimings reads the times spent on each resource type and phase of the run from `metrics.time.values`, and
+// populates the given report-structure with them. Each value is a list of the name, the label and the time in seconds,
+// and the values which are not are skipped.
+func parseTimings(y *simpleyaml.Yaml, out *entities.PuppetReport) {
+	values := y.Get("metrics").Get("time").Get("values")
+	size, err := values.GetArraySize()
+	if err != nil {
+		return
+	}
+
+	timings := make([]*entities.Timing, 0, size)
+	for i := 0; i < size; i++ {
+		value := values.GetIndex(i)
+
+		name, err := value.GetIndex(0).String()
+		if err != nil || name == "" {
+			continue
+		}
+		label, _ := value.GetIndex(1).String()
+		seconds, ok := scalarFloat(value.GetIndex(2))
+		if !ok {
+			continue
+		}
+
+		timings = append(timings, &entities.Timing{
+			Name:    name,
+			Label:   label,
+			Seconds: seconds,
+		})
+	}
+	out.Timings = timings
+}
+
+// parseMetrics reads the values of every metric group under `metrics`, such as resources, events, changes and time,
+// and populates the given report-structure with them. Each value is a list of the name, the label and the value, and
+// the values which are not are skipped, as are the groups without any.
+func parseMetrics(y *simpleyaml.Yaml, out *entities.PuppetReport) {
+	groups, err := y.Get("metrics").GetMapKeys()
+	if err != nil {
+		return
+	}
+
+	metrics := make(entities.Metrics, len(groups))
+	for _, group := range groups {
+		values := y.Get("metrics").Get(group).Get("values")
+		size, err := values.GetArraySize()
+		if err != nil {
+			continue
+		}
+
+		for i := 0; i < size; i++ {
+			value := values.GetIndex(i)
+
+			name, err := value.GetIndex(0).String()
+			if err != nil || name == "" {
+				continue
+			}
+			v, ok := scalarFloat(value.GetIndex(2))
+			if !ok {
+				continue
+			}
+
+			metrics.Set(group, name, v)
+		}
+	}
+	out.Metrics = metrics
+}
+
 // parseResources looks for the counts of resources which have been
 // failed, changed, skipped, etc, and updates the given report-structure
 // with those values.
@@ -199,6 +267,49 @@ func parseResources(y *simpleyaml.Yaml, out *entities.PuppetReport) error {
 	return nil
 }
 
+// parseConfigVersion reads the optional `configuration_version` parameter from the YAML and populates the given
+// report-structure with it. The version is a timestamp by default, but can be any string set by the server.
+func parseConfigVersion(y *simpleyaml.Yaml, out *entities.PuppetReport) {
+	if version, ok := scalarString(y.Get("configuration_version")); ok {
+		out.ConfigVersion = version
+	}
+}
+
+// parseCodeID reads the optional `code_id` parameter from the YAML and populates the given report-structure with it.
+// It is only set when the server uses static catalogs, and is null otherwise.
+func parseCodeID(y *simpleyaml.Yaml, out *entities.PuppetReport) {
+	if codeID, ok := scalarString(y.Get("code_id")); ok {
+		out.CodeID = codeID
+	}
+}
+
+// scalarString returns the value of a string or number in the YAML as a string, or false if it is missing or of
+// another type.
+func scalarString(v *simpleyaml.Yaml) (string, bool) {
+	if !v.IsFound() {
+		return "", false
+	}
+
+	if str, err := v.String(); err == nil {
+		return str, true
+	} else if i, err := v.Int(); err == nil {
+		return strconv.Itoa(i), true
+	} else if f, err := v.Float(); err == nil {
+		return strconv.FormatFloat(f, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// scalarFloat returns the value of a number in the YAML as a float, or false if it is missing or of another type.
+func scalarFloat(v *simpleyaml.Yaml) (float64, bool) {
+	if f, err := v.Float(); err == nil {
+		return f, true
+	} else if i, err := v.Int(); err == nil {
+		return float64(i), true
+	}
+	return 0, false
+}
+
 // parseLogs updates the given report with any logged messages.
 func parseLogs(y *simpleyaml.Yaml, out *entities.PuppetReport) error {
 	logs, err := y.Get("logs").Array()
@@ -371,6 +482,9 @@ func ParsePuppetReport(content []byte) (*entities.PuppetReport, error) {
 		return nil, fmt.Errorf("failed to parse time: %w", err)
 	}
 
+	parseConfigVersion(yaml, rep)
+	parseCodeID(yaml, rep)
+
 	err = parseStatus(yaml, rep)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse status: %w", err)
@@ -381,6 +495,9 @@ func ParsePuppetReport(content []byte) (*entities.PuppetReport, error) {
 		return nil, fmt.Errorf("failed to parse runtime: %w", err)
 	}
 
+	parseTimings(yaml, rep)
+	parseMetrics(yaml, rep)
+
 	err = parseResources(yaml, rep)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse resources: %w", err)
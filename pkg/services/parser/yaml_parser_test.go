@@ -110,6 +110,100 @@ func (s *ParsePuppetReportSuite) TestParseRuntime() {
 	s.Equal(entities.Duration(runtime), s.report.Runtime)
 }
 
+func (s *ParsePuppetReportSuite) TestParseTimings() {
+	parseTimings(s.sy, s.report)
+	s.Require().Len(s.report.Timings, 13)
+	s.Equal(&entities.Timing{Name: "anchor", Label: "Anchor", Seconds: 8.0641e-05}, s.report.Timings[0])
+	s.Equal(&entities.Timing{Name: "exec", Label: "Exec", Seconds: 26.105695294999997}, s.report.Timings[2])
+	s.Equal(&entities.Timing{Name: "config_retrieval", Label: "Config retrieval", Seconds: 0.43629771}, s.report.Timings[9])
+	s.Equal(&entities.Timing{Name: entities.TimingTotal, Label: "Total", Seconds: 26.67511224}, s.report.Timings[12])
+
+	// The values which are not a name, label and number are skipped, and the whole times are read as numbers.
+	sy, err := simpleyaml.NewYaml([]byte(`
+metrics:
+  time:
+    values:
+      - - fact_generation
+        - Fact generation
+        - 2
+      - - broken
+      - not a value
+`))
+	s.Require().NoError(err)
+	report := new(entities.PuppetReport)
+	parseTimings(sy, report)
+	s.Equal([]*entities.Timing{{Name: "fact_generation", Label: "Fact generation", Seconds: 2}}, report.Timings)
+}
+
+func (s *ParsePuppetReportSuite) TestParseMetrics() {
+	parseMetrics(s.sy, s.report)
+	s.Require().Len(s.report.Metrics, 4)
+	s.Equal(map[string]float64{"total": 9, "failure": 0, "success": 9}, s.report.Metrics["events"])
+	s.Equal(map[string]float64{"total": 9}, s.report.Metrics["changes"])
+	s.Len(s.report.Metrics["resources"], 9)
+	s.Len(s.report.Metrics["time"], 13)
+
+	value, ok := s.report.Metrics.Get("resources.out_of_sync")
+	s.True(ok)
+	s.Equal(6.0, value)
+
+	value, ok = s.report.Metrics.Get("time.total")
+	s.True(ok)
+	s.Equal(26.67511224, value)
+
+	_, ok = s.report.Metrics.Get("events.noop")
+	s.False(ok)
+
+	// The values which are not a name, label and number are skipped, as are the groups without values.
+	sy, err := simpleyaml.NewYaml([]byte(`
+metrics:
+  events:
+    values:
+      - - noop
+        - Noop
+        - 2
+      - - broken
+      - not a value
+  empty:
+    name: empty
+`))
+	s.Require().NoError(err)
+	report := new(entities.PuppetReport)
+	parseMetrics(sy, report)
+	s.Equal(entities.Metrics{"events": {"noop": 2}}, report.Metrics)
+}
+
+func (s *ParsePuppetReportSuite) TestParseConfigVersion() {
+	parseConfigVersion(s.sy, s.report)
+	s.Equal("1708135209", s.report.ConfigVersion)
+
+	// A version set by the server can be any string, and the version is optional.
+	sy, err := simpleyaml.NewYaml([]byte("configuration_version: 'abc123-production'"))
+	s.Require().NoError(err)
+	parseConfigVersion(sy, s.report)
+	s.Equal("abc123-production", s.report.ConfigVersion)
+
+	sy, err = simpleyaml.NewYaml([]byte("host: example-host"))
+	s.Require().NoError(err)
+	report := new(entities.PuppetReport)
+	parseConfigVersion(sy, report)
+	s.Empty(report.ConfigVersion)
+}
+
+func (s *ParsePuppetReportSuite) TestParseCodeID() {
+	// The code ID is null unless the server uses static catalogs.
+	sy, err := simpleyaml.NewYaml([]byte("code_id: ~"))
+	s.Require().NoError(err)
+	report := new(entities.PuppetReport)
+	parseCodeID(sy, report)
+	s.Empty(report.CodeID)
+
+	sy, err = simpleyaml.NewYaml([]byte("code_id: 'urn:puppet:code-id:1:4f8a2f1e;production'"))
+	s.Require().NoError(err)
+	parseCodeID(sy, report)
+	s.Equal("urn:puppet:code-id:1:4f8a2f1e;production", report.CodeID)
+}
+
 func (s *ParsePuppetReportSuite) TestParseResources() {
 	err := parseResources(s.sy, s.report)
 	s.NoError(err, "Unexpected error parsing resources")
@@ -0,0 +1,134 @@
+package purge
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+)
+
+// Preview is a summary of the data that a purge would remove.
+type Preview struct {
+	// Reports is the number of reports that would be removed from the database.
+	Reports int
+
+	// Files is the number of files that would be removed from storage.
+	Files int
+
+	// Groups break down the reports that would be removed by environment and state.
+	Groups []*PreviewGroup
+
+	// Oldest is the execution time of the oldest report that would be removed.
+	Oldest time.Time
+
+	// Newest is the execution time of the newest report that would be removed.
+	Newest time.Time
+}
+
+// PreviewGroup is the data that a purge would remove for an environment and state.
+type PreviewGroup struct {
+	// Environment is the environment of the reports.
+	Environment summary.Environment
+
+	// State is the state of the reports.
+	State summary.State
+
+	// Reports is the number of reports that would be removed from the database.
+	Reports int
+
+	// Files is the number of files of the reports that would be removed from storage.
+	Files int
+}
+
+func (s service) PreviewPurge(ctx context.Context, purgeDays int) (*Preview, error) {
+	files, err := dataaccess.Files.ListFiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing files: %w", err)
+	}
+
+	existing := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		existing[file] = struct{}{}
+	}
+
+	preview := new(Preview)
+
+	var expired []*entities.PuppetRun
+	if purgeDays < 0 {
+		from := purgeAllFrom()
+
+		runs, err := s.db.GetRuns(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error getting runs: %w", err)
+		}
+
+		for _, run := range runs {
+			if run.ExecTime.Time().Before(from) {
+				expired = append(expired, run)
+			}
+		}
+
+		// All the files before the date are purged, whether they have a report or not.
+		for _, file := range files {
+			fileTime, ok := dataaccess.ReportFileTime(file)
+			if !ok || fileTime.After(from) {
+				continue
+			}
+			preview.Files++
+			preview.observe(fileTime)
+		}
+	} else {
+		expired, err = s.expired(ctx, purgeDays)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	groups := make(map[string]*PreviewGroup)
+	for _, run := range expired {
+		key := string(run.Env) + "/" + string(run.State)
+		group, ok := groups[key]
+		if !ok {
+			group = &PreviewGroup{
+				Environment: run.Env,
+				State:       run.State,
+			}
+			groups[key] = group
+			preview.Groups = append(preview.Groups, group)
+		}
+
+		group.Reports++
+		preview.Reports++
+		preview.observe(run.ExecTime.Time())
+
+		if _, ok := existing[run.YamlFile]; ok {
+			group.Files++
+			if purgeDays >= 0 {
+				preview.Files++
+			}
+		}
+	}
+
+	sort.Slice(preview.Groups, func(i, j int) bool {
+		if preview.Groups[i].Environment != preview.Groups[j].Environment {
+			return preview.Groups[i].Environment < preview.Groups[j].Environment
+		}
+		return preview.Groups[i].State < preview.Groups[j].State
+	})
+
+	return preview, nil
+}
+
+// observe updates the oldest and newest times of the preview with the given time.
+func (p *Preview) observe(t time.Time) {
+	if p.Oldest.IsZero() || t.Before(p.Oldest) {
+		p.Oldest = t
+	}
+	if p.Newest.IsZero() || t.After(p.Newest) {
+		p.Newest = t
+	}
+}
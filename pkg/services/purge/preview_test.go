@@ -0,0 +1,84 @@
+package purge
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_PreviewPurge(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	daysAgo := func(days int) time.Time {
+		return now.AddDate(0, 0, -days)
+	}
+
+	runs := []*entities.PuppetRun{
+		newRun("1", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(1)),
+		newRun("2", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(20)),
+		newRun("3", "a", summary.Environment_PRODUCTION, summary.State_FAILED, daysAgo(30)),
+		newRun("4", "b", summary.Environment_STAGING, summary.State_UNCHANGED, daysAgo(2)),
+		newRun("5", "b", summary.Environment_STAGING, summary.State_UNCHANGED, daysAgo(40)),
+	}
+
+	// The node of report 2 reported its local time, which the file is named after, while the database returns the time
+	// in UTC.
+	runs[1].YamlFile = filepath.Join("reports", "PRODUCTION", "a",
+		daysAgo(20).In(time.FixedZone("", 60*60)).Format(time.RFC3339)+".yaml")
+
+	db := new(dataaccess.MockDb)
+	db.On("GetRuns", mock.Anything).Return(runs, nil)
+
+	// The file for report 3 is missing from storage.
+	files := new(dataaccess.MockStorage)
+	files.On("ListFiles", mock.Anything).Return([]string{
+		runs[0].YamlFile,
+		runs[1].YamlFile,
+		runs[3].YamlFile,
+		runs[4].YamlFile,
+	}, nil)
+	dataaccess.Files = files
+	t.Cleanup(func() {
+		dataaccess.Files = nil
+	})
+
+	svc := NewService(db, nil)
+
+	preview, err := svc.PreviewPurge(context.Background(), 10)
+	require.NoError(t, err)
+	require.Equal(t, &Preview{
+		Reports: 3,
+		Files:   2,
+		Groups: []*PreviewGroup{
+			{Environment: summary.Environment_PRODUCTION, State: summary.State_FAILED, Reports: 1, Files: 0},
+			{Environment: summary.Environment_PRODUCTION, State: summary.State_UNCHANGED, Reports: 1, Files: 1},
+			{Environment: summary.Environment_STAGING, State: summary.State_UNCHANGED, Reports: 1, Files: 1},
+		},
+		Oldest: daysAgo(40),
+		Newest: daysAgo(20),
+	}, preview)
+
+	db.AssertExpectations(t)
+	files.AssertExpectations(t)
+}
+
+func TestService_PreviewPurgeNoRetention(t *testing.T) {
+	files := new(dataaccess.MockStorage)
+	files.On("ListFiles", mock.Anything).Return([]string{}, nil)
+	dataaccess.Files = files
+	t.Cleanup(func() {
+		dataaccess.Files = nil
+	})
+
+	svc := NewService(new(dataaccess.MockDb), nil)
+
+	preview, err := svc.PreviewPurge(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, &Preview{}, preview)
+}
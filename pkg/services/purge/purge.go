@@ -7,56 +7,157 @@ import (
 	"time"
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
-	"github.com/robfig/cron/v3"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/scheduler"
 )
 
-func (s service) SetupPurge(purgeDays int) error {
-	c := cron.New(
-		cron.WithLocation(time.UTC),
-		cron.WithParser(
-			cron.NewParser(
-				cron.Minute|cron.Hour|cron.Dom|cron.Month|cron.Dow|cron.Descriptor,
-			),
-		),
-	)
-
-	// Add a new entry to the cron scheduler to purge every day at 03:00.
-	if _, err := c.AddFunc("0 3 * * *", func() {
-		err := s.PurgePuppetReports(purgeDays)
-		if err != nil {
-			slog.Error("Error purging data", slog.String(logging.KeyError, err.Error()))
-		}
+// DefaultSchedule is the cron schedule the purge runs on if no schedule is configured, every day at 03:00.
+const DefaultSchedule = "0 3 * * *"
+
+// JobName is the name of the scheduled purge job. A purge started through the API runs as the same job, so that only
+// one purge runs at a time across the instances.
+const JobName = "purge"
+
+func (s service) SetupPurge(sched *scheduler.Scheduler, schedule string, purgeDays int) error {
+	if schedule == "" {
+		schedule = DefaultSchedule
+	}
+
+	if err := sched.AddJob(JobName, schedule, func(ctx context.Context) error {
+		return s.PurgePuppetReports(ctx, purgeDays, nil)
 	}); err != nil {
-		return fmt.Errorf("error adding purge job to cron scheduler: %w", err)
+		return fmt.Errorf("error adding purge job to scheduler: %w", err)
 	}
 
-	c.Start()
-	slog.Info("Cron scheduler started")
+	slog.Info("Purge scheduled", slog.String("schedule", schedule))
 	return nil
 }
 
-func (s service) PurgePuppetReports(purgeDays int) error {
+// deleteBatchSize is the maximum number of reports deleted from the database in a single query.
+const deleteBatchSize = 500
+
+const (
+	// CounterReportsTotal is the progress counter for the number of reports to purge.
+	CounterReportsTotal = "reports_total"
+
+	// CounterReportsDeleted is the progress counter for the number of reports removed from the database.
+	CounterReportsDeleted = "reports_deleted"
+
+	// CounterFilesDeleted is the progress counter for the number of files removed from storage.
+	CounterFilesDeleted = "files_deleted"
+
+	// CounterFilesFailed is the progress counter for the number of files that could not be removed from storage.
+	CounterFilesFailed = "files_failed"
+)
+
+// Progress receives the progress counters of a purge.
+type Progress interface {
+	// Set sets the named progress counter to the value.
+	Set(name string, value int)
+
+	// Add adds delta to the named progress counter.
+	Add(name string, delta int)
+}
+
+// noopProgress is used when the caller does not track the progress of a purge.
+type noopProgress struct{}
+
+func (noopProgress) Set(string, int) {}
+
+func (noopProgress) Add(string, int) {}
+
+func (s service) PurgePuppetReports(ctx context.Context, purgeDays int, progress Progress) error {
 	slog.Info("Purging data")
 
-	if purgeDays == 0 {
+	if progress == nil {
+		progress = noopProgress{}
+	}
+
+	if purgeDays < 0 {
+		return s.purgeAll(ctx, progress)
+	}
+
+	expired, err := s.expired(ctx, purgeDays)
+	if err != nil {
+		return err
+	} else if expired == nil {
 		slog.Warn("Purge days not set, will not purge any data")
 		return nil
 	}
 
-	// Get the start and end dates for the purge.
-	now := time.Now()
-	from := now.AddDate(0, 0, -purgeDays)
-	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	progress.Set(CounterReportsTotal, len(expired))
 
-	if purgeDays < 0 {
-		// If the purgeDays is <= 0, purge all data.
-		slog.Info("Purge days set to 0, purging all data")
-		from = time.Now().AddDate(0, 0, 1)
-		from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	// Each batch is removed from the database and then from storage before moving on, so that a cancelled purge does
+	// not leave files behind for reports that no longer exist.
+	dbAffected, filesAffected := 0, 0
+	for start := 0; start < len(expired); start += deleteBatchSize {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("purge stopped: %w", err)
+		}
+
+		batch := expired[start:min(start+deleteBatchSize, len(expired))]
+
+		ids := make([]string, len(batch))
+		for i, run := range batch {
+			ids[i] = run.ID
+		}
+
+		affected, err := s.db.DeleteReports(ctx, ids...)
+		if err != nil {
+			return fmt.Errorf("error purging data: %w", err)
+		}
+		dbAffected += affected
+		progress.Add(CounterReportsDeleted, affected)
+
+		for _, run := range batch {
+			if err := dataaccess.Files.DeleteFile(ctx, run.YamlFile); err != nil {
+				// The report has already been removed from the database, so carry on with the rest of the files.
+				slog.Warn("Error deleting report file",
+					slog.String(logging.KeyHash, run.ID),
+					slog.String(logging.KeyError, err.Error()),
+				)
+				progress.Add(CounterFilesFailed, 1)
+				continue
+			}
+			filesAffected++
+			progress.Add(CounterFilesDeleted, 1)
+		}
 	}
 
-	ctx := context.Background()
+	slog.Info("Data purged from Database interface", slog.Int("affected", dbAffected))
+	slog.Info("Data purged from Files interface", slog.Int("affected", filesAffected))
+	slog.Info("Purging complete")
+
+	return nil
+}
+
+// expired returns the runs that have expired under the retention policies, where the purge days (if set) replace
+// the default retention window. If no retention is configured, nil is returned.
+func (s service) expired(ctx context.Context, purgeDays int) ([]*entities.PuppetRun, error) {
+	retention := s.retention.withDefaultDays(purgeDays)
+	if !retention.Enabled() {
+		return nil, nil
+	}
+
+	runs, err := s.db.GetRuns(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting runs: %w", err)
+	}
+
+	return retention.Expired(runs, time.Now()), nil
+}
+
+// purgeAllFrom returns the time before which all data is purged when purging all data.
+func purgeAllFrom() time.Time {
+	from := time.Now().AddDate(0, 0, 1)
+	return time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+}
+
+// purgeAll purges all data, ignoring the retention policies.
+func (s service) purgeAll(ctx context.Context, progress Progress) error {
+	slog.Info("Purge days set to less than 0, purging all data")
+	from := purgeAllFrom()
 
 	dbAffected, err := s.db.Purge(ctx, from)
 	if err != nil {
@@ -64,11 +165,13 @@ func (s service) PurgePuppetReports(purgeDays int) error {
 	}
 
 	slog.Info("Data purged from Database interface", slog.Int("affected", dbAffected))
+	progress.Set(CounterReportsDeleted, dbAffected)
 
 	filesAffected, err := dataaccess.Files.Purge(ctx, from)
 	if err != nil {
 		return fmt.Errorf("error purging data: %w", err)
 	}
+	progress.Set(CounterFilesDeleted, filesAffected)
 	slog.Info("Data purged from Files interface", slog.Int("affected", filesAffected))
 	slog.Info("Purging complete")
 
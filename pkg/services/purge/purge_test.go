@@ -0,0 +1,107 @@
+package purge
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedProgress records the progress counters of a purge.
+type recordedProgress map[string]int
+
+func (p recordedProgress) Set(name string, value int) {
+	p[name] = value
+}
+
+func (p recordedProgress) Add(name string, delta int) {
+	p[name] += delta
+}
+
+// oldRuns returns n runs for a single node, the newest of which is 100 days old.
+func oldRuns(n int) []*entities.PuppetRun {
+	now := time.Now().UTC().Truncate(time.Second)
+	runs := make([]*entities.PuppetRun, n)
+	for i := range runs {
+		runs[i] = newRun(strconv.Itoa(i), "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED,
+			now.AddDate(0, 0, -(i+100)))
+	}
+	return runs
+}
+
+func TestService_PurgePuppetReports(t *testing.T) {
+	runs := oldRuns(3)
+
+	// The node of the second run reported its local time, which the file is named after, while the database returns
+	// the time in UTC.
+	runs[1].YamlFile = filepath.Join("reports", "PRODUCTION", "a",
+		runs[1].ExecTime.Time().In(time.FixedZone("", 60*60)).Format(time.RFC3339)+".yaml")
+
+	db := new(dataaccess.MockDb)
+	db.On("GetRuns", mock.Anything).Return(runs, nil)
+	db.On("DeleteReports", mock.Anything, []string{"1", "2"}).Return(2, nil)
+
+	files := new(dataaccess.MockStorage)
+	files.On("DeleteFile", mock.Anything, runs[1].YamlFile).Return(nil)
+	files.On("DeleteFile", mock.Anything, runs[2].YamlFile).Return(errors.New("file not found"))
+	dataaccess.Files = files
+	t.Cleanup(func() {
+		dataaccess.Files = nil
+	})
+
+	progress := make(recordedProgress)
+	err := NewService(db, nil).PurgePuppetReports(context.Background(), 10, progress)
+	require.NoError(t, err)
+	require.Equal(t, recordedProgress{
+		CounterReportsTotal:   2,
+		CounterReportsDeleted: 2,
+		CounterFilesDeleted:   1,
+		CounterFilesFailed:    1,
+	}, progress)
+
+	db.AssertExpectations(t)
+	files.AssertExpectations(t)
+}
+
+func TestService_PurgePuppetReportsCancelled(t *testing.T) {
+	// The latest run is kept, leaving one more than a full batch to purge.
+	runs := oldRuns(deleteBatchSize + 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db := new(dataaccess.MockDb)
+	db.On("GetRuns", mock.Anything).Return(runs, nil)
+	db.On("DeleteReports", mock.Anything, mock.Anything).Return(deleteBatchSize, nil).Once().Run(func(mock.Arguments) {
+		cancel()
+	})
+
+	files := new(dataaccess.MockStorage)
+	files.On("DeleteFile", mock.Anything, mock.Anything).Return(nil)
+	dataaccess.Files = files
+	t.Cleanup(func() {
+		dataaccess.Files = nil
+	})
+
+	progress := make(recordedProgress)
+	err := NewService(db, nil).PurgePuppetReports(ctx, 10, progress)
+	require.ErrorIs(t, err, context.Canceled)
+
+	// The files of the batch removed from the database are still removed.
+	require.Equal(t, recordedProgress{
+		CounterReportsTotal:   deleteBatchSize + 1,
+		CounterReportsDeleted: deleteBatchSize,
+		CounterFilesDeleted:   deleteBatchSize,
+	}, progress)
+
+	db.AssertExpectations(t)
+	files.AssertNumberOfCalls(t, "DeleteFile", deleteBatchSize)
+}
@@ -0,0 +1,207 @@
+package purge
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/spf13/viper"
+)
+
+// Policy is a retention window for the reports that match it.
+type Policy struct {
+	// Environment is the environment the policy applies to. If empty, the policy applies to all environments.
+	Environment summary.Environment `mapstructure:"environment"`
+
+	// State is the state the policy applies to. If empty, the policy applies to all states.
+	State summary.State `mapstructure:"state"`
+
+	// Days is the number of days to keep the matching reports for. If 0, the matching reports are kept forever.
+	Days int `mapstructure:"days"`
+}
+
+// matches returns whether the policy applies to the given environment and state.
+func (p *Policy) matches(env summary.Environment, state summary.State) bool {
+	if p.Environment != "" && p.Environment != env {
+		return false
+	}
+	if p.State != "" && p.State != state {
+		return false
+	}
+	return true
+}
+
+// specificity returns how specific the policy is. An environment is more specific than a state.
+func (p *Policy) specificity() int {
+	score := 0
+	if p.Environment != "" {
+		score += 2
+	}
+	if p.State != "" {
+		score++
+	}
+	return score
+}
+
+// Retention is the set of retention policies applied when purging.
+type Retention struct {
+	// DefaultDays is the number of days to keep reports that do not match a policy. If 0, they are kept forever.
+	DefaultDays int `mapstructure:"default_days"`
+
+	// KeepLast is the number of reports to keep for each node, whatever their age. The latest report of a node is
+	// always kept.
+	KeepLast int `mapstructure:"keep_last"`
+
+	// Policies are the retention policies. The most specific policy that matches a report is applied.
+	Policies []Policy `mapstructure:"policies"`
+}
+
+// RetentionFromViper reads the retention policies from the purge section of the config.
+func RetentionFromViper(v *viper.Viper) (*Retention, error) {
+	r := new(Retention)
+	if v == nil || !v.IsSet("purge") {
+		return r, nil
+	}
+
+	if err := v.UnmarshalKey("purge", r); err != nil {
+		return nil, fmt.Errorf("error reading retention policies: %w", err)
+	}
+
+	if err := r.validate(); err != nil {
+		return nil, fmt.Errorf("invalid retention policies: %w", err)
+	}
+
+	return r, nil
+}
+
+// validate normalises the policies and checks that they are valid.
+func (r *Retention) validate() error {
+	if r.DefaultDays < 0 {
+		return errors.New("default_days must not be negative")
+	}
+	if r.KeepLast < 0 {
+		return errors.New("keep_last must not be negative")
+	}
+
+	for i := range r.Policies {
+		p := &r.Policies[i]
+		p.Environment = summary.Environment(strings.ToUpper(strings.TrimSpace(string(p.Environment))))
+		p.State = summary.State(strings.ToUpper(strings.TrimSpace(string(p.State))))
+
+		if p.Environment != "" && !p.Environment.IsValid() {
+			return fmt.Errorf("policy %d has an invalid environment: %s", i, p.Environment)
+		}
+		if p.State != "" && !p.State.IsValid() {
+			return fmt.Errorf("policy %d has an invalid state: %s", i, p.State)
+		}
+		if p.Days < 0 {
+			return fmt.Errorf("policy %d has negative days", i)
+		}
+	}
+
+	return nil
+}
+
+// Enabled returns whether any reports would expire under the retention.
+func (r *Retention) Enabled() bool {
+	if r == nil {
+		return false
+	}
+	if r.DefaultDays > 0 {
+		return true
+	}
+	for _, p := range r.Policies {
+		if p.Days > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// withDefaultDays returns a copy of the retention with the default days replaced, if days is greater than 0.
+func (r *Retention) withDefaultDays(days int) *Retention {
+	cp := new(Retention)
+	if r != nil {
+		*cp = *r
+	}
+	if days > 0 {
+		cp.DefaultDays = days
+	}
+	return cp
+}
+
+// days returns the number of days to keep a report with the given environment and state for.
+func (r *Retention) days(env summary.Environment, state summary.State) int {
+	var match *Policy
+	for i := range r.Policies {
+		p := &r.Policies[i]
+		if !p.matches(env, state) {
+			continue
+		}
+		// The first policy wins when two policies are equally specific.
+		if match == nil || p.specificity() > match.specificity() {
+			match = p
+		}
+	}
+
+	if match == nil {
+		return r.DefaultDays
+	}
+	return match.Days
+}
+
+// Expired returns the runs that have expired under the retention at the given time.
+func (r *Retention) Expired(runs []*entities.PuppetRun, now time.Time) []*entities.PuppetRun {
+	keepLast := r.KeepLast
+	if keepLast < 1 {
+		keepLast = 1
+	}
+
+	// Group the runs by node.
+	nodes := make(map[string][]*entities.PuppetRun)
+	keys := make([]string, 0)
+	for _, run := range runs {
+		key := string(run.Env) + "/" + run.Fqdn
+		if _, ok := nodes[key]; !ok {
+			keys = append(keys, key)
+		}
+		nodes[key] = append(nodes[key], run)
+	}
+
+	expired := make([]*entities.PuppetRun, 0)
+	for _, key := range keys {
+		nodeRuns := nodes[key]
+
+		// Sort the runs newest first, so the latest reports are kept.
+		sort.SliceStable(nodeRuns, func(i, j int) bool {
+			return nodeRuns[i].ExecTime.Time().After(nodeRuns[j].ExecTime.Time())
+		})
+
+		for i, run := range nodeRuns {
+			if i < keepLast {
+				continue
+			}
+
+			days := r.days(run.Env, run.State)
+			if days <= 0 {
+				continue
+			}
+
+			if run.ExecTime.Time().Before(cutoff(now, days)) {
+				expired = append(expired, run)
+			}
+		}
+	}
+
+	return expired
+}
+
+// cutoff returns the start of the day the given number of days before now.
+func cutoff(now time.Time, days int) time.Time {
+	from := now.AddDate(0, 0, -days)
+	return time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+}
@@ -0,0 +1,178 @@
+package purge
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func newRun(id, fqdn string, env summary.Environment, state summary.State, execTime time.Time) *entities.PuppetRun {
+	return &entities.PuppetRun{
+		ID:       id,
+		Fqdn:     fqdn,
+		Env:      env,
+		State:    state,
+		ExecTime: entities.Datetime(execTime),
+		YamlFile: filepath.Join("reports", string(env), fqdn, execTime.Format(time.RFC3339)+".yaml"),
+	}
+}
+
+func expiredIDs(runs []*entities.PuppetRun) []string {
+	ids := make([]string, len(runs))
+	for i, run := range runs {
+		ids[i] = run.ID
+	}
+	return ids
+}
+
+func TestRetention_Expired(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time {
+		return now.AddDate(0, 0, -days)
+	}
+
+	tests := []struct {
+		name      string
+		retention *Retention
+		runs      []*entities.PuppetRun
+		want      []string
+	}{
+		{
+			name:      "default days",
+			retention: &Retention{DefaultDays: 10},
+			runs: []*entities.PuppetRun{
+				newRun("1", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(1)),
+				newRun("2", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(20)),
+				newRun("3", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(30)),
+			},
+			want: []string{"2", "3"},
+		},
+		{
+			name:      "latest report is always kept",
+			retention: &Retention{DefaultDays: 10},
+			runs: []*entities.PuppetRun{
+				newRun("1", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(30)),
+				newRun("2", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(20)),
+				newRun("3", "b", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(40)),
+			},
+			want: []string{"1"},
+		},
+		{
+			name:      "keep last",
+			retention: &Retention{DefaultDays: 10, KeepLast: 2},
+			runs: []*entities.PuppetRun{
+				newRun("1", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(20)),
+				newRun("2", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(30)),
+				newRun("3", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(40)),
+			},
+			want: []string{"3"},
+		},
+		{
+			name: "state policies",
+			retention: &Retention{Policies: []Policy{
+				{State: summary.State_FAILED, Days: 180},
+				{State: summary.State_UNCHANGED, Days: 14},
+			}},
+			runs: []*entities.PuppetRun{
+				newRun("1", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(1)),
+				newRun("2", "a", summary.Environment_PRODUCTION, summary.State_FAILED, daysAgo(100)),
+				newRun("3", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(100)),
+				newRun("4", "a", summary.Environment_PRODUCTION, summary.State_CHANGED, daysAgo(1000)),
+				newRun("5", "a", summary.Environment_PRODUCTION, summary.State_FAILED, daysAgo(200)),
+			},
+			want: []string{"3", "5"},
+		},
+		{
+			name: "environment policy is more specific than state policy",
+			retention: &Retention{Policies: []Policy{
+				{State: summary.State_UNCHANGED, Days: 14},
+				{Environment: summary.Environment_DEVELOPMENT, Days: 2},
+				{Environment: summary.Environment_PRODUCTION, State: summary.State_UNCHANGED, Days: 60},
+			}},
+			runs: []*entities.PuppetRun{
+				newRun("1", "a", summary.Environment_DEVELOPMENT, summary.State_UNCHANGED, daysAgo(1)),
+				newRun("2", "a", summary.Environment_DEVELOPMENT, summary.State_UNCHANGED, daysAgo(5)),
+				newRun("3", "b", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(1)),
+				newRun("4", "b", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(30)),
+				newRun("5", "c", summary.Environment_STAGING, summary.State_UNCHANGED, daysAgo(1)),
+				newRun("6", "c", summary.Environment_STAGING, summary.State_UNCHANGED, daysAgo(30)),
+			},
+			want: []string{"2", "6"},
+		},
+		{
+			name:      "no retention",
+			retention: &Retention{},
+			runs: []*entities.PuppetRun{
+				newRun("1", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(1)),
+				newRun("2", "a", summary.Environment_PRODUCTION, summary.State_UNCHANGED, daysAgo(1000)),
+			},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.retention.Expired(tt.runs, now)
+			require.ElementsMatch(t, tt.want, expiredIDs(got))
+		})
+	}
+}
+
+func TestRetentionFromViper(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("json")
+	err := v.ReadConfig(strings.NewReader(`{
+		"purge": {
+			"default_days": 30,
+			"keep_last": 5,
+			"policies": [
+				{"state": "failed", "days": 180},
+				{"environment": "production", "state": "unchanged", "days": 14}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+
+	got, err := RetentionFromViper(v)
+	require.NoError(t, err)
+	require.Equal(t, &Retention{
+		DefaultDays: 30,
+		KeepLast:    5,
+		Policies: []Policy{
+			{State: summary.State_FAILED, Days: 180},
+			{Environment: summary.Environment_PRODUCTION, State: summary.State_UNCHANGED, Days: 14},
+		},
+	}, got)
+	require.True(t, got.Enabled())
+}
+
+func TestRetentionFromViperInvalidState(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("json")
+	err := v.ReadConfig(strings.NewReader(`{"purge": {"policies": [{"state": "broken", "days": 1}]}}`))
+	require.NoError(t, err)
+
+	_, err = RetentionFromViper(v)
+	require.EqualError(t, err, "invalid retention policies: policy 0 has an invalid state: BROKEN")
+}
+
+func TestRetentionFromViperInvalidEnvironment(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("json")
+	err := v.ReadConfig(strings.NewReader(`{"purge": {"policies": [{"environment": "prod", "days": 1}]}}`))
+	require.NoError(t, err)
+
+	_, err = RetentionFromViper(v)
+	require.EqualError(t, err, "invalid retention policies: policy 0 has an invalid environment: PROD")
+}
+
+func TestRetentionFromViperNotSet(t *testing.T) {
+	got, err := RetentionFromViper(viper.New())
+	require.NoError(t, err)
+	require.False(t, got.Enabled())
+}
@@ -1,21 +1,37 @@
 package purge
 
 import (
+	"context"
+
 	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/scheduler"
 )
 
 type Purger interface {
-	PurgePuppetReports(purgeDays int) error
+	// PurgePuppetReports purges the reports that have expired, reporting its progress to the given progress (if not
+	// nil). The purge stops between batches when the context is cancelled.
+	PurgePuppetReports(ctx context.Context, purgeDays int, progress Progress) error
+
+	// PreviewPurge returns what PurgePuppetReports would remove, without removing anything.
+	PreviewPurge(ctx context.Context, purgeDays int) (*Preview, error)
 
-	SetupPurge(purgeDays int) error
+	// SetupPurge schedules the purge to run on the given cron schedule.
+	SetupPurge(sched *scheduler.Scheduler, schedule string, purgeDays int) error
 }
 
 type service struct {
 	db dataaccess.Database
+
+	// retention is the retention policies applied when purging.
+	retention *Retention
 }
 
-func NewService(db dataaccess.Database) Purger {
+func NewService(db dataaccess.Database, retention *Retention) Purger {
+	if retention == nil {
+		retention = new(Retention)
+	}
 	return &service{
-		db: db,
+		db:        db,
+		retention: retention,
 	}
 }
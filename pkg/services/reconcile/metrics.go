@@ -0,0 +1,23 @@
+package reconcile
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// MissingFiles is the number of reports in the database whose file was missing from storage at the last
+// reconciliation.
+var MissingFiles = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "reconcile_missing_files",
+		Help: "Number of reports in the database whose file was missing from storage at the last reconciliation",
+	},
+)
+
+// OrphanFiles is the number of files in storage with no report in the database at the last reconciliation.
+var OrphanFiles = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "reconcile_orphan_files",
+		Help: "Number of files in storage with no report in the database at the last reconciliation",
+	},
+)
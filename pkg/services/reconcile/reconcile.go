@@ -0,0 +1,246 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/scheduler"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/parser"
+)
+
+// gracePeriod is how recent a report file has to be to be left alone. An upload saves the file before the report, so
+// a recent file may not have its report in the database yet.
+const gracePeriod = time.Hour
+
+// deleteBatchSize is the maximum number of reports deleted from the database in a single query.
+const deleteBatchSize = 500
+
+// Repair is how the orphans found by a reconciliation are repaired.
+type Repair string
+
+const (
+	// RepairNone only reports the orphans.
+	RepairNone Repair = "none"
+
+	// RepairDelete deletes the reports whose file is missing, and the files with no report.
+	RepairDelete Repair = "delete"
+
+	// RepairImport imports the files with no report into the database. Reports whose file is missing are left alone,
+	// as there is nothing to import them from.
+	RepairImport Repair = "import"
+)
+
+// ParseRepair parses the repair mode, where an empty string is RepairNone.
+func ParseRepair(s string) (Repair, error) {
+	repair := Repair(strings.ToLower(strings.TrimSpace(s)))
+	switch repair {
+	case "":
+		return RepairNone, nil
+	case RepairNone, RepairDelete, RepairImport:
+		return repair, nil
+	default:
+		return "", fmt.Errorf("invalid repair mode: %s", s)
+	}
+}
+
+// Result is the outcome of a reconciliation.
+type Result struct {
+	// MissingFiles are the reports in the database whose file is missing from storage.
+	MissingFiles []*entities.PuppetRun
+
+	// OrphanFiles are the files in storage with no report in the database.
+	OrphanFiles []string
+
+	// Repaired is the number of orphans that were repaired.
+	Repaired int
+
+	// Failed is the number of orphans that could not be repaired.
+	Failed int
+}
+
+// DefaultSchedule is the cron schedule the reconciliation runs on if no schedule is configured, every day at 04:00.
+const DefaultSchedule = "0 4 * * *"
+
+func (s service) SetupReconcile(sched *scheduler.Scheduler, schedule string, repair Repair) error {
+	if schedule == "" {
+		schedule = DefaultSchedule
+	}
+
+	if err := sched.AddJob("reconcile", schedule, func(ctx context.Context) error {
+		_, err := s.Reconcile(ctx, repair)
+		return err
+	}); err != nil {
+		return fmt.Errorf("error adding reconcile job to scheduler: %w", err)
+	}
+
+	slog.Info("Reconcile scheduled", slog.String("schedule", schedule), slog.String("repair", string(repair)))
+	return nil
+}
+
+func (s service) Reconcile(ctx context.Context, repair Repair) (*Result, error) {
+	slog.Info("Reconciling database and storage", slog.String("repair", string(repair)))
+
+	// The reports are read before the files. An upload saves the file before the report, so every report read has
+	// its file saved.
+	runs, err := s.db.GetRuns(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error getting runs: %w", err)
+	}
+
+	files, err := dataaccess.Files.ListFiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing files: %w", err)
+	}
+
+	res := s.find(runs, files)
+
+	MissingFiles.Set(float64(len(res.MissingFiles)))
+	OrphanFiles.Set(float64(len(res.OrphanFiles)))
+
+	slog.Info("Reconciliation complete",
+		slog.Int("missing_files", len(res.MissingFiles)),
+		slog.Int("orphan_files", len(res.OrphanFiles)),
+	)
+
+	switch repair {
+	case RepairDelete:
+		if err := s.deleteMissing(ctx, res); err != nil {
+			return res, err
+		}
+		s.deleteOrphans(ctx, res)
+	case RepairImport:
+		s.importOrphans(ctx, res)
+	default:
+		return res, nil
+	}
+
+	slog.Info("Repair complete", slog.Int("repaired", res.Repaired), slog.Int("failed", res.Failed))
+
+	return res, nil
+}
+
+// find compares the reports with the files, returning the orphans.
+func (s service) find(runs []*entities.PuppetRun, files []string) *Result {
+	res := &Result{
+		MissingFiles: make([]*entities.PuppetRun, 0),
+		OrphanFiles:  make([]string, 0),
+	}
+
+	existing := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		existing[file] = struct{}{}
+	}
+
+	reported := make(map[string]struct{}, len(runs))
+	for _, run := range runs {
+		reported[run.YamlFile] = struct{}{}
+
+		if _, ok := existing[run.YamlFile]; !ok {
+			res.MissingFiles = append(res.MissingFiles, run)
+		}
+	}
+
+	graceFrom := s.now().Add(-gracePeriod)
+	for _, file := range files {
+		if _, ok := reported[file]; ok {
+			continue
+		}
+
+		// Leave recent files alone, as their report may not have been saved yet.
+		if execTime, ok := dataaccess.ReportFileTime(file); ok && execTime.After(graceFrom) {
+			continue
+		}
+
+		res.OrphanFiles = append(res.OrphanFiles, file)
+	}
+	sort.Strings(res.OrphanFiles)
+
+	return res
+}
+
+// deleteMissing deletes the reports whose file is missing from the database.
+func (s service) deleteMissing(ctx context.Context, res *Result) error {
+	for start := 0; start < len(res.MissingFiles); start += deleteBatchSize {
+		batch := res.MissingFiles[start:min(start+deleteBatchSize, len(res.MissingFiles))]
+
+		ids := make([]string, len(batch))
+		for i, run := range batch {
+			ids[i] = run.ID
+		}
+
+		affected, err := s.db.DeleteReports(ctx, ids...)
+		if err != nil {
+			return fmt.Errorf("error deleting reports: %w", err)
+		}
+		res.Repaired += affected
+	}
+
+	return nil
+}
+
+// deleteOrphans deletes the files with no report from storage.
+func (s service) deleteOrphans(ctx context.Context, res *Result) {
+	for _, file := range res.OrphanFiles {
+		if err := dataaccess.Files.DeleteFile(ctx, file); err != nil {
+			slog.Warn("Error deleting orphan file",
+				slog.String("file", file),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			res.Failed++
+			continue
+		}
+		res.Repaired++
+	}
+}
+
+// importOrphans imports the files with no report into the database.
+func (s service) importOrphans(ctx context.Context, res *Result) {
+	for _, file := range res.OrphanFiles {
+		if err := s.importFile(ctx, file); err != nil {
+			slog.Warn("Error importing orphan file",
+				slog.String("file", file),
+				slog.String(logging.KeyError, err.Error()),
+			)
+			res.Failed++
+			continue
+		}
+		res.Repaired++
+	}
+}
+
+// importFile parses the report in the file and saves it to the database.
+func (s service) importFile(ctx context.Context, file string) error {
+	bdy, err := dataaccess.Files.DownloadFile(ctx, file)
+	if err != nil {
+		return fmt.Errorf("error downloading file: %w", err)
+	}
+
+	rep, err := parser.ParsePuppetReport(bdy)
+	if err != nil {
+		return fmt.Errorf("error parsing report: %w", err)
+	}
+
+	// The file must be in the directory of the environment and node of the report, and named after the time it was
+	// executed, whatever offset that time is written with.
+	fileTime, ok := dataaccess.ReportFileTime(file)
+	if dir := path.Join("reports", string(rep.Env), rep.Fqdn); path.Dir(file) != dir || !ok || !fileTime.Equal(rep.ExecTime.Time()) {
+		return fmt.Errorf("file does not match the report it contains, expected %s", rep.ReportFilePath())
+	}
+
+	// The report is saved against the file it was imported from, so that it is no longer an orphan.
+	rep.YamlFile = file
+
+	if err := s.db.SaveRun(ctx, rep); err != nil {
+		return fmt.Errorf("error saving report: %w", err)
+	}
+
+	return nil
+}
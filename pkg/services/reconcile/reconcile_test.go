@@ -0,0 +1,197 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// exampleFile is the path the example report in the parser test data is saved to.
+const exampleFile = "reports/PRODUCTION/example-host/2024-02-17T02:00:09Z.yaml"
+
+var now = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newRun(id, fqdn string, execTime time.Time) *entities.PuppetRun {
+	return &entities.PuppetRun{
+		ID:       id,
+		Fqdn:     fqdn,
+		Env:      summary.Environment_PRODUCTION,
+		State:    summary.State_UNCHANGED,
+		ExecTime: entities.Datetime(execTime),
+		YamlFile: "reports/PRODUCTION/" + fqdn + "/" + execTime.Format(time.RFC3339) + ".yaml",
+	}
+}
+
+func newTestService(t *testing.T, db dataaccess.Database, files *dataaccess.MockStorage) *service {
+	dataaccess.Files = files
+	t.Cleanup(func() {
+		dataaccess.Files = nil
+	})
+
+	return &service{
+		db: db,
+		now: func() time.Time {
+			return now
+		},
+	}
+}
+
+func TestService_Reconcile(t *testing.T) {
+	runs := []*entities.PuppetRun{
+		newRun("1", "a", now.AddDate(0, 0, -2)),
+		newRun("2", "a", now.AddDate(0, 0, -1)),
+	}
+
+	db := new(dataaccess.MockDb)
+	db.On("GetRuns", mock.Anything).Return(runs, nil)
+
+	// The file of report 2 is missing, the second file has no report and the third file is too recent to tell.
+	files := new(dataaccess.MockStorage)
+	files.On("ListFiles", mock.Anything).Return([]string{
+		runs[0].YamlFile,
+		"reports/PRODUCTION/b/" + now.AddDate(0, 0, -3).Format(time.RFC3339) + ".yaml",
+		"reports/PRODUCTION/b/" + now.Add(-time.Minute).Format(time.RFC3339) + ".yaml",
+	}, nil)
+
+	res, err := newTestService(t, db, files).Reconcile(context.Background(), RepairNone)
+	require.NoError(t, err)
+	require.Equal(t, &Result{
+		MissingFiles: []*entities.PuppetRun{runs[1]},
+		OrphanFiles:  []string{"reports/PRODUCTION/b/" + now.AddDate(0, 0, -3).Format(time.RFC3339) + ".yaml"},
+	}, res)
+
+	db.AssertExpectations(t)
+	files.AssertExpectations(t)
+}
+
+func TestService_ReconcileOffsetTime(t *testing.T) {
+	// The node reported its local time, which the file is named after, while the database returns the time in UTC.
+	local := now.AddDate(0, 0, -2).In(time.FixedZone("", 60*60))
+	run := newRun("1", "a", local)
+	run.ExecTime = entities.Datetime(local.UTC())
+
+	db := new(dataaccess.MockDb)
+	db.On("GetRuns", mock.Anything).Return([]*entities.PuppetRun{run}, nil)
+
+	files := new(dataaccess.MockStorage)
+	files.On("ListFiles", mock.Anything).Return([]string{
+		"reports/PRODUCTION/a/" + local.Format(time.RFC3339) + ".yaml",
+	}, nil)
+
+	// The report and its file match, so there is nothing to delete.
+	res, err := newTestService(t, db, files).Reconcile(context.Background(), RepairDelete)
+	require.NoError(t, err)
+	require.Empty(t, res.MissingFiles)
+	require.Empty(t, res.OrphanFiles)
+	require.Zero(t, res.Repaired)
+
+	db.AssertExpectations(t)
+	files.AssertExpectations(t)
+}
+
+func TestService_ReconcileImportOffsetTime(t *testing.T) {
+	example, err := os.ReadFile("../parser/testdata/example.yaml")
+	require.NoError(t, err)
+
+	// The file is named after the time of the report written with another offset.
+	file := "reports/PRODUCTION/example-host/2024-02-17T03:00:09+01:00.yaml"
+
+	db := new(dataaccess.MockDb)
+	db.On("GetRuns", mock.Anything).Return([]*entities.PuppetRun{}, nil)
+	db.On("SaveRun", mock.Anything, mock.MatchedBy(func(rep *entities.PuppetReport) bool {
+		return rep.YamlFile == file
+	})).Return(nil)
+
+	files := new(dataaccess.MockStorage)
+	files.On("ListFiles", mock.Anything).Return([]string{file}, nil)
+	files.On("DownloadFile", mock.Anything, file).Return(example, nil)
+
+	res, err := newTestService(t, db, files).Reconcile(context.Background(), RepairImport)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Repaired)
+	require.Zero(t, res.Failed)
+
+	db.AssertExpectations(t)
+	files.AssertExpectations(t)
+}
+
+func TestService_ReconcileDelete(t *testing.T) {
+	runs := []*entities.PuppetRun{
+		newRun("1", "a", now.AddDate(0, 0, -2)),
+	}
+
+	db := new(dataaccess.MockDb)
+	db.On("GetRuns", mock.Anything).Return(runs, nil)
+	db.On("DeleteReports", mock.Anything, []string{"1"}).Return(1, nil)
+
+	files := new(dataaccess.MockStorage)
+	files.On("ListFiles", mock.Anything).Return([]string{"reports/PRODUCTION/b/one.yaml", "reports/PRODUCTION/b/two.yaml"}, nil)
+	files.On("DeleteFile", mock.Anything, "reports/PRODUCTION/b/one.yaml").Return(nil)
+	files.On("DeleteFile", mock.Anything, "reports/PRODUCTION/b/two.yaml").Return(errors.New("permission denied"))
+
+	res, err := newTestService(t, db, files).Reconcile(context.Background(), RepairDelete)
+	require.NoError(t, err)
+	require.Equal(t, 2, res.Repaired)
+	require.Equal(t, 1, res.Failed)
+
+	db.AssertExpectations(t)
+	files.AssertExpectations(t)
+}
+
+func TestService_ReconcileImport(t *testing.T) {
+	example, err := os.ReadFile("../parser/testdata/example.yaml")
+	require.NoError(t, err)
+
+	db := new(dataaccess.MockDb)
+	db.On("GetRuns", mock.Anything).Return([]*entities.PuppetRun{}, nil)
+	db.On("SaveRun", mock.Anything, mock.MatchedBy(func(rep *entities.PuppetReport) bool {
+		return rep.Fqdn == "example-host" && rep.YamlFile == exampleFile
+	})).Return(nil)
+
+	// The second file holds the example report, but is not where the report would be saved.
+	files := new(dataaccess.MockStorage)
+	files.On("ListFiles", mock.Anything).Return([]string{exampleFile, "reports/PRODUCTION/other/report.yaml"}, nil)
+	files.On("DownloadFile", mock.Anything, exampleFile).Return(example, nil)
+	files.On("DownloadFile", mock.Anything, "reports/PRODUCTION/other/report.yaml").Return(example, nil)
+
+	res, err := newTestService(t, db, files).Reconcile(context.Background(), RepairImport)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Repaired)
+	require.Equal(t, 1, res.Failed)
+
+	db.AssertExpectations(t)
+	files.AssertExpectations(t)
+}
+
+func TestParseRepair(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Repair
+		wantErr bool
+	}{
+		{in: "", want: RepairNone},
+		{in: "none", want: RepairNone},
+		{in: " Delete ", want: RepairDelete},
+		{in: "IMPORT", want: RepairImport},
+		{in: "fix", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRepair(tt.in)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package reconcile
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/scheduler"
+)
+
+type Reconciler interface {
+	// Reconcile finds the reports in the database whose file is missing from storage, and the files in storage with no
+	// report in the database, repairing them as requested.
+	Reconcile(ctx context.Context, repair Repair) (*Result, error)
+
+	// SetupReconcile schedules the reconciliation to run on the given cron schedule.
+	SetupReconcile(sched *scheduler.Scheduler, schedule string, repair Repair) error
+}
+
+type service struct {
+	db dataaccess.Database
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+func NewService(db dataaccess.Database) Reconciler {
+	return &service{
+		db:  db,
+		now: time.Now,
+	}
+}
@@ -0,0 +1,379 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+)
+
+const (
+	// DefaultWindow is how far back the rollout is tracked from, when the start of the window is not given.
+	DefaultWindow = 7 * 24 * time.Hour
+
+	// DefaultBucket is the step of the timeline, when the bucket is not given.
+	DefaultBucket = summary.HistoryBucket_hour
+
+	// MaxPoints is the most points of a timeline, which bounds the window for the bucket.
+	MaxPoints = 1000
+)
+
+// Options select the rollout that is tracked.
+type Options struct {
+	// Env is the environment the rollout is tracked in. Required.
+	Env summary.Environment
+
+	// From is the start of the window the rollout is tracked in, inclusive. Defaults to DefaultWindow before To.
+	From time.Time
+
+	// To is the end of the window the rollout is tracked in, exclusive. Defaults to now.
+	To time.Time
+
+	// Bucket is the step of the timeline. Defaults to DefaultBucket.
+	Bucket summary.HistoryBucket
+}
+
+// withDefaults returns the options with the defaults filled in, or an error if they are out of range.
+func (o *Options) withDefaults(now time.Time) (*Options, error) {
+	res := new(Options)
+	if o != nil {
+		*res = *o
+	}
+
+	if res.Env == "" {
+		return nil, fmt.Errorf("%w: an environment is required", aggregate.ErrInvalidOptions)
+	}
+
+	var err error
+	res.From, res.To, err = aggregate.Window(res.From, res.To, now, DefaultWindow, res.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Bucket == "" {
+		res.Bucket = DefaultBucket
+	}
+	if !res.Bucket.IsValid() {
+		return nil, fmt.Errorf("%w: invalid bucket %s", aggregate.ErrInvalidOptions, res.Bucket)
+	}
+	if points(res.From, res.To, bucketStep(res.Bucket)) > MaxPoints {
+		return nil, fmt.Errorf("%w: the window is more than %d %ss", aggregate.ErrInvalidOptions, MaxPoints, res.Bucket)
+	}
+	return res, nil
+}
+
+// bucketStep returns the duration of the bucket.
+func bucketStep(bucket summary.HistoryBucket) time.Duration {
+	switch bucket {
+	case summary.HistoryBucket_week:
+		return 7 * 24 * time.Hour
+	case summary.HistoryBucket_day:
+		return 24 * time.Hour
+	default:
+		return time.Hour
+	}
+}
+
+// points returns the number of points of the timeline of the window, one at the end of every step and one at the end
+// of the window.
+func points(from, to time.Time, step time.Duration) int {
+	return int((to.Sub(from) + step - 1) / step)
+}
+
+// Rollout is how far the versions of the code have spread across the nodes of an environment.
+type Rollout struct {
+	// Env is the environment of the rollout.
+	Env summary.Environment
+
+	// From is the start of the window, inclusive.
+	From time.Time
+
+	// To is the end of the window, exclusive.
+	To time.Time
+
+	// Bucket is the step of the timeline.
+	Bucket summary.HistoryBucket
+
+	// Newest is the configuration version that was first seen last, or empty if no run reported a version.
+	Newest string
+
+	// Versions are the versions that nodes are on, or that were first seen in the window, newest first.
+	Versions []*Version
+
+	// Nodes are the nodes with the version of their latest run, those not on the newest version first.
+	Nodes []*Node
+
+	// Timeline is the share of the nodes on the newest version at the end of every step of the window.
+	Timeline []*Point
+
+	// Failures are the runs in the window that failed on the first run of a node on a new version, newest first.
+	Failures []*Failure
+}
+
+// Version is a version of the code.
+type Version struct {
+	// ConfigVersion is the configuration version of the catalog.
+	ConfigVersion string
+
+	// CodeID is the latest code ID reported with the version, if any.
+	CodeID string
+
+	// FirstSeen is the time of the first run on the version.
+	FirstSeen time.Time
+
+	// LastSeen is the time of the latest run on the version.
+	LastSeen time.Time
+
+	// Nodes is the number of nodes whose latest run is on the version.
+	Nodes int
+
+	// Share is the share of the nodes whose latest run is on the version, between 0 and 1.
+	Share float64
+}
+
+// Node is a node with the version of its latest run.
+type Node struct {
+	// Fqdn is the FQDN of the node.
+	Fqdn string
+
+	// ReportID is the ID of the report of the latest run.
+	ReportID string
+
+	// State is the state of the latest run.
+	State summary.State
+
+	// ExecTime is the time of the latest run.
+	ExecTime time.Time
+
+	// ConfigVersion is the configuration version of the latest run.
+	ConfigVersion string
+
+	// CodeID is the code ID of the latest run, if any.
+	CodeID string
+}
+
+// Point is a point of the timeline of a rollout.
+type Point struct {
+	// Time is the time of the point. The runs before it are counted.
+	Time time.Time
+
+	// Nodes is the number of nodes that had run.
+	Nodes int
+
+	// OnNewest is the number of nodes whose latest run was on the newest version.
+	OnNewest int
+
+	// Share is the share of the nodes whose latest run was on the newest version, between 0 and 1.
+	Share float64
+}
+
+// Failure is the failed first run of a node on a new version.
+type Failure struct {
+	// Fqdn is the FQDN of the node.
+	Fqdn string
+
+	// ReportID is the ID of the report of the run.
+	ReportID string
+
+	// ExecTime is the time of the run.
+	ExecTime time.Time
+
+	// ConfigVersion is the version the run was on.
+	ConfigVersion string
+
+	// Previous is the version of the run of the node before.
+	Previous string
+}
+
+func (s *service) Rollout(ctx context.Context, opts *Options) (*Rollout, error) {
+	opts, err := opts.withDefaults(s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err := s.runs(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Rollout{
+		Env:      opts.Env,
+		From:     opts.From,
+		To:       opts.To,
+		Bucket:   opts.Bucket,
+		Versions: make([]*Version, 0),
+		Nodes:    make([]*Node, 0),
+		Timeline: make([]*Point, 0),
+		Failures: make([]*Failure, 0),
+	}
+
+	// The runs are oldest first, so the last run of each node is its latest, and a version first seen by a node is new
+	// to it when the node had run on another before.
+	versions := make(map[string]*Version)
+	latest := make(map[string]*entities.RunVersion)
+	seen := make(map[string]map[string]struct{})
+	for _, run := range runs {
+		execTime := run.ExecTime.Time()
+
+		v, ok := versions[run.ConfigVersion]
+		if !ok {
+			v = &Version{
+				ConfigVersion: run.ConfigVersion,
+				FirstSeen:     execTime,
+			}
+			versions[run.ConfigVersion] = v
+		}
+		v.LastSeen = execTime
+		if run.CodeID != "" {
+			v.CodeID = run.CodeID
+		}
+
+		if seen[run.Fqdn] == nil {
+			seen[run.Fqdn] = make(map[string]struct{})
+		}
+		if _, ok := seen[run.Fqdn][run.ConfigVersion]; !ok {
+			prev, hadRun := latest[run.Fqdn]
+			if hadRun && run.State == summary.State_FAILED && !execTime.Before(opts.From) {
+				res.Failures = append(res.Failures, &Failure{
+					Fqdn:          run.Fqdn,
+					ReportID:      run.ReportID,
+					ExecTime:      execTime,
+					ConfigVersion: run.ConfigVersion,
+					Previous:      prev.ConfigVersion,
+				})
+			}
+			seen[run.Fqdn][run.ConfigVersion] = struct{}{}
+		}
+		latest[run.Fqdn] = run
+	}
+
+	if len(versions) == 0 {
+		return res, nil
+	}
+
+	res.Newest = newest(versions)
+
+	for _, run := range latest {
+		versions[run.ConfigVersion].Nodes++
+		res.Nodes = append(res.Nodes, &Node{
+			Fqdn:          run.Fqdn,
+			ReportID:      run.ReportID,
+			State:         run.State,
+			ExecTime:      run.ExecTime.Time(),
+			ConfigVersion: run.ConfigVersion,
+			CodeID:        run.CodeID,
+		})
+	}
+
+	for _, v := range versions {
+		if v.Nodes == 0 && v.FirstSeen.Before(opts.From) {
+			continue
+		}
+		v.Share = float64(v.Nodes) / float64(len(latest))
+		res.Versions = append(res.Versions, v)
+	}
+
+	sort.Slice(res.Versions, func(i, j int) bool {
+		if !res.Versions[i].FirstSeen.Equal(res.Versions[j].FirstSeen) {
+			return res.Versions[i].FirstSeen.After(res.Versions[j].FirstSeen)
+		}
+		return res.Versions[i].ConfigVersion > res.Versions[j].ConfigVersion
+	})
+
+	sort.Slice(res.Nodes, func(i, j int) bool {
+		iNewest := res.Nodes[i].ConfigVersion == res.Newest
+		jNewest := res.Nodes[j].ConfigVersion == res.Newest
+		if iNewest != jNewest {
+			return jNewest
+		}
+		return res.Nodes[i].Fqdn < res.Nodes[j].Fqdn
+	})
+
+	for i, j := 0, len(res.Failures)-1; i < j; i, j = i+1, j-1 {
+		res.Failures[i], res.Failures[j] = res.Failures[j], res.Failures[i]
+	}
+
+	res.Timeline = timeline(runs, res.Newest, opts)
+
+	return res, nil
+}
+
+// newest returns the configuration version that was first seen last. The versions first seen together are ordered by
+// the version, as the versions are usually timestamps or commits counted up.
+func newest(versions map[string]*Version) string {
+	var res *Version
+	for _, v := range versions {
+		if res == nil || v.FirstSeen.After(res.FirstSeen) ||
+			(v.FirstSeen.Equal(res.FirstSeen) && v.ConfigVersion > res.ConfigVersion) {
+			res = v
+		}
+	}
+	return res.ConfigVersion
+}
+
+// timeline returns the share of the nodes on the newest version at the end of every step of the window of the options.
+// The runs are oldest first.
+func timeline(runs []*entities.RunVersion, newest string, opts *Options) []*Point {
+	step := bucketStep(opts.Bucket)
+	n := points(opts.From, opts.To, step)
+
+	res := make([]*Point, 0, n)
+	current := make(map[string]string)
+	onNewest := 0
+	next := 0
+	for i := 1; i <= n; i++ {
+		at := opts.From.Add(time.Duration(i) * step)
+		if at.After(opts.To) {
+			at = opts.To
+		}
+
+		for ; next < len(runs) && runs[next].ExecTime.Time().Before(at); next++ {
+			run := runs[next]
+			if prev, ok := current[run.Fqdn]; ok && prev == newest {
+				onNewest--
+			}
+			if run.ConfigVersion == newest {
+				onNewest++
+			}
+			current[run.Fqdn] = run.ConfigVersion
+		}
+
+		point := &Point{
+			Time:     at,
+			Nodes:    len(current),
+			OnNewest: onNewest,
+		}
+		if point.Nodes > 0 {
+			point.Share = float64(point.OnNewest) / float64(point.Nodes)
+		}
+		res = append(res, point)
+	}
+	return res
+}
+
+// runs returns the runs of the listed nodes in the environment of the options that reported a version, up to the end
+// of the window, oldest first. The runs before the window are included for the versions the nodes were on.
+func (s *service) runs(ctx context.Context, opts *Options) ([]*entities.RunVersion, error) {
+	runs, err := s.db.GetRunVersions(ctx, time.Time{}, opts.To, opts.Env)
+	if err != nil {
+		return nil, fmt.Errorf("error getting run versions: %w", err)
+	}
+
+	isListed, err := nodes.ListedFunc(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*entities.RunVersion, 0, len(runs))
+	for _, run := range runs {
+		if isListed(run.Fqdn, run.ExecTime.Time()) {
+			res = append(res, run)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,184 @@
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+
+const (
+	oldVersion = "1708135209"
+	newVersion = "1708135999"
+)
+
+// newRun returns a run of the node on the version, the given number of hours before the test time.
+func newRun(fqdn string, hours int, state summary.State, version string) *entities.RunVersion {
+	return &entities.RunVersion{
+		ReportID:      fmt.Sprintf("%s-%d", fqdn, hours),
+		Fqdn:          fqdn,
+		Env:           summary.Environment_PRODUCTION,
+		State:         state,
+		ExecTime:      entities.Datetime(testNow.Add(-time.Duration(hours) * time.Hour)),
+		ConfigVersion: version,
+	}
+}
+
+// testRuns are the runs of the tests, oldest first as returned by the database. The new version is deployed three
+// hours before the test time, and reaches node1 and node2, failing on the first run of node2.
+func testRuns() []*entities.RunVersion {
+	deployed := newRun("node1", 3, summary.State_CHANGED, newVersion)
+	deployed.CodeID = "urn:puppet:code-id:1:abc"
+
+	return []*entities.RunVersion{
+		newRun("node1", 30, summary.State_UNCHANGED, oldVersion),
+		newRun("node2", 30, summary.State_UNCHANGED, oldVersion),
+		newRun("node3", 30, summary.State_UNCHANGED, oldVersion),
+		deployed,
+		newRun("node2", 2, summary.State_FAILED, newVersion),
+		newRun("node3", 2, summary.State_FAILED, oldVersion),
+		newRun("node2", 1, summary.State_CHANGED, newVersion),
+	}
+}
+
+func newTestService(db dataaccess.Database) *service {
+	return &service{
+		db:  db,
+		now: func() time.Time { return testNow },
+	}
+}
+
+func TestOptions_withDefaults(t *testing.T) {
+	opts, err := (&Options{Env: summary.Environment_PRODUCTION}).withDefaults(testNow)
+	require.NoError(t, err)
+	require.Equal(t, testNow, opts.To)
+	require.Equal(t, testNow.Add(-DefaultWindow), opts.From)
+	require.Equal(t, DefaultBucket, opts.Bucket)
+
+	invalid := []*Options{
+		nil,
+		{Env: "invalid"},
+		{Env: summary.Environment_PRODUCTION, Bucket: "invalid"},
+		{Env: summary.Environment_PRODUCTION, From: testNow.Add(-(MaxPoints + 1) * time.Hour)},
+	}
+	for _, o := range invalid {
+		_, err := o.withDefaults(testNow)
+		require.ErrorIs(t, err, aggregate.ErrInvalidOptions)
+	}
+}
+
+func TestService_Rollout(t *testing.T) {
+	from := testNow.Add(-4 * time.Hour)
+
+	db := new(dataaccess.MockDb)
+	db.On("GetRunVersions", mock.Anything, time.Time{}, testNow, []summary.Environment{summary.Environment_PRODUCTION}).Return(testRuns(), nil)
+	db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil)
+
+	got, err := newTestService(db).Rollout(context.Background(), &Options{
+		Env:  summary.Environment_PRODUCTION,
+		From: from,
+	})
+	require.NoError(t, err)
+	require.Equal(t, newVersion, got.Newest)
+	require.Equal(t, summary.HistoryBucket_hour, got.Bucket)
+
+	require.Equal(t, []*Version{
+		{
+			ConfigVersion: newVersion,
+			CodeID:        "urn:puppet:code-id:1:abc",
+			FirstSeen:     testNow.Add(-3 * time.Hour),
+			LastSeen:      testNow.Add(-time.Hour),
+			Nodes:         2,
+			Share:         2.0 / 3,
+		},
+		{
+			ConfigVersion: oldVersion,
+			FirstSeen:     testNow.Add(-30 * time.Hour),
+			LastSeen:      testNow.Add(-2 * time.Hour),
+			Nodes:         1,
+			Share:         1.0 / 3,
+		},
+	}, got.Versions)
+
+	// The nodes not on the newest version are first.
+	require.Len(t, got.Nodes, 3)
+	require.Equal(t, "node3", got.Nodes[0].Fqdn)
+	require.Equal(t, oldVersion, got.Nodes[0].ConfigVersion)
+	require.Equal(t, "node1", got.Nodes[1].Fqdn)
+	require.Equal(t, "node2", got.Nodes[2].Fqdn)
+	require.Equal(t, "node2-1", got.Nodes[2].ReportID)
+
+	// Only the failed first run of node2 on the new version is a failure, not the failed run of node3 on the version it
+	// was already on.
+	require.Equal(t, []*Failure{
+		{
+			Fqdn:          "node2",
+			ReportID:      "node2-2",
+			ExecTime:      testNow.Add(-2 * time.Hour),
+			ConfigVersion: newVersion,
+			Previous:      oldVersion,
+		},
+	}, got.Failures)
+
+	share := func(hours int, onNewest int) *Point {
+		return &Point{
+			Time:     from.Add(time.Duration(hours) * time.Hour),
+			Nodes:    3,
+			OnNewest: onNewest,
+			Share:    float64(onNewest) / 3,
+		}
+	}
+	require.Equal(t, []*Point{share(1, 0), share(2, 1), share(3, 2), share(4, 2)}, got.Timeline)
+}
+
+func TestService_Rollout_Decommissioned(t *testing.T) {
+	db := new(dataaccess.MockDb)
+	db.On("GetRunVersions", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testRuns(), nil)
+
+	// node3 was decommissioned after its runs, so it is not counted.
+	db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{"node3": testNow.Add(-30 * time.Minute)}, nil)
+
+	got, err := newTestService(db).Rollout(context.Background(), &Options{Env: summary.Environment_PRODUCTION})
+	require.NoError(t, err)
+	require.Len(t, got.Nodes, 2)
+	require.Len(t, got.Versions, 2)
+	require.Equal(t, 1.0, got.Versions[0].Share)
+
+	// The old version was first seen in the window, so it is listed though no node is on it.
+	require.Zero(t, got.Versions[1].Nodes)
+	require.Equal(t, 1.0, got.Timeline[len(got.Timeline)-1].Share)
+}
+
+func TestService_Rollout_NoVersions(t *testing.T) {
+	db := new(dataaccess.MockDb)
+	db.On("GetRunVersions", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*entities.RunVersion{}, nil)
+	db.On("GetDecommissions", mock.Anything).Return(map[string]time.Time{}, nil)
+
+	got, err := newTestService(db).Rollout(context.Background(), &Options{Env: summary.Environment_PRODUCTION})
+	require.NoError(t, err)
+	require.Empty(t, got.Newest)
+	require.Empty(t, got.Versions)
+	require.Empty(t, got.Nodes)
+	require.Empty(t, got.Timeline)
+}
+
+func TestService_Rollout_Error(t *testing.T) {
+	db := new(dataaccess.MockDb)
+	db.On("GetRunVersions", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*entities.RunVersion(nil), errors.New("some error"))
+
+	_, err := newTestService(db).Rollout(context.Background(), &Options{Env: summary.Environment_PRODUCTION})
+	require.EqualError(t, err, "error getting run versions: some error")
+
+	_, err = newTestService(db).Rollout(context.Background(), nil)
+	require.ErrorIs(t, err, aggregate.ErrInvalidOptions)
+}
@@ -0,0 +1,28 @@
+package rollout
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+)
+
+type Tracker interface {
+	// Rollout returns how far the versions of the code have spread across the listed nodes of the environment of the
+	// options.
+	Rollout(ctx context.Context, opts *Options) (*Rollout, error)
+}
+
+type service struct {
+	db dataaccess.Database
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+func NewService(db dataaccess.Database) Tracker {
+	return &service{
+		db:  db,
+		now: time.Now,
+	}
+}
@@ -0,0 +1,29 @@
+package timings
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+)
+
+type Profiler interface {
+	// Breakdown returns where the time of the runs of the nodes selected by the filter and the options was spent,
+	// aggregated across the runs per resource type and phase of the run.
+	Breakdown(ctx context.Context, filter *nodes.Filter, opts *Options) (*Breakdown, error)
+}
+
+type service struct {
+	db dataaccess.Database
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+func NewService(db dataaccess.Database) Profiler {
+	return &service{
+		db:  db,
+		now: time.Now,
+	}
+}
@@ -0,0 +1,164 @@
+package timings
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
+)
+
+// DefaultWindow is how far back the timings are aggregated from, when the start of the window is not given.
+const DefaultWindow = 7 * 24 * time.Hour
+
+// Options select the runs whose timings are aggregated.
+type Options struct {
+	// From is the start of the window the runs are aggregated in, inclusive. Defaults to DefaultWindow before To.
+	From time.Time
+
+	// To is the end of the window the runs are aggregated in, exclusive. Defaults to now.
+	To time.Time
+
+	// Envs are the environments the runs are aggregated in. Every environment if empty.
+	Envs []summary.Environment
+}
+
+// withDefaults returns the options with the defaults filled in, or an error if they are out of range.
+func (o *Options) withDefaults(now time.Time) (*Options, error) {
+	res := new(Options)
+	if o != nil {
+		*res = *o
+	}
+
+	var err error
+	res.From, res.To, err = aggregate.Window(res.From, res.To, now, DefaultWindow, res.Envs...)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Entry is the time spent on a resource type or a phase of the runs.
+type Entry struct {
+	// Name is the name of the timing, as reported by puppet (e.g. file, config_retrieval).
+	Name string
+
+	// Label is the human-readable name of the timing.
+	Label string
+
+	// Runs is the number of runs that reported the timing.
+	Runs int
+
+	// Seconds is the time spent across the runs, in seconds.
+	Seconds float64
+
+	// Average is the time spent by a run that reported the timing, on average, in seconds.
+	Average float64
+
+	// Max is the most time spent by a single run, in seconds.
+	Max float64
+
+	// Share is the share of the total time of the runs that was spent, between 0 and 1. Zero if no run reported its
+	// total time.
+	Share float64
+}
+
+// Breakdown is where the time of a set of runs was spent.
+type Breakdown struct {
+	// From is the start of the window, inclusive. Zero for the breakdown of a single report.
+	From time.Time
+
+	// To is the end of the window, exclusive. Zero for the breakdown of a single report.
+	To time.Time
+
+	// Total is the total time of the runs, or nil if no run reported it.
+	Total *Entry
+
+	// Timings are the resource types and phases of the runs, most time spent first.
+	Timings []*Entry
+}
+
+// ReportBreakdown returns where the time of the run of the report was spent.
+func ReportBreakdown(rep *entities.PuppetReport) *Breakdown {
+	sums := make([]*entities.TimingSummary, 0, len(rep.Timings))
+	for _, timing := range rep.Timings {
+		sums = append(sums, &entities.TimingSummary{
+			Name:    timing.Name,
+			Label:   timing.Label,
+			Runs:    1,
+			Seconds: timing.Seconds,
+			Max:     timing.Seconds,
+		})
+	}
+	return newBreakdown(sums)
+}
+
+// newBreakdown returns the breakdown of the aggregated timings, with the total split out of the entries.
+func newBreakdown(sums []*entities.TimingSummary) *Breakdown {
+	b := &Breakdown{
+		Timings: make([]*Entry, 0, len(sums)),
+	}
+	for _, sum := range sums {
+		entry := &Entry{
+			Name:    sum.Name,
+			Label:   sum.Label,
+			Runs:    sum.Runs,
+			Seconds: sum.Seconds,
+			Max:     sum.Max,
+		}
+		if entry.Label == "" {
+			entry.Label = entry.Name
+		}
+		if sum.Runs > 0 {
+			entry.Average = sum.Seconds / float64(sum.Runs)
+		}
+
+		if sum.Name == entities.TimingTotal {
+			b.Total = entry
+			continue
+		}
+		b.Timings = append(b.Timings, entry)
+	}
+
+	if b.Total != nil && b.Total.Seconds > 0 {
+		b.Total.Share = 1
+		for _, entry := range b.Timings {
+			entry.Share = entry.Seconds / b.Total.Seconds
+		}
+	}
+
+	sort.SliceStable(b.Timings, func(i, j int) bool {
+		if b.Timings[i].Seconds != b.Timings[j].Seconds {
+			return b.Timings[i].Seconds > b.Timings[j].Seconds
+		}
+		return b.Timings[i].Name < b.Timings[j].Name
+	})
+
+	return b
+}
+
+func (s *service) Breakdown(ctx context.Context, filter *nodes.Filter, opts *Options) (*Breakdown, error) {
+	opts, err := opts.withDefaults(s.now())
+	if err != nil {
+		return nil, err
+	}
+
+	fqdns, err := filter.Fqdns(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := s.db.GetTimings(ctx, opts.From, opts.To, fqdns, opts.Envs...)
+	if err != nil {
+		return nil, fmt.Errorf("error getting timings: %w", err)
+	}
+
+	b := newBreakdown(sums)
+	b.From = opts.From
+	b.To = opts.To
+	return b, nil
+}
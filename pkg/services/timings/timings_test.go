@@ -0,0 +1,96 @@
+package timings
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2024, 2, 13, 10, 0, 0, 0, time.UTC)
+
+func newTestService(db dataaccess.Database) *service {
+	return &service{
+		db:  db,
+		now: func() time.Time { return testNow },
+	}
+}
+
+func TestService_Breakdown(t *testing.T) {
+	from := testNow.Add(-24 * time.Hour)
+	envs := []summary.Environment{summary.Environment_PRODUCTION}
+
+	db := new(dataaccess.MockDb)
+	db.On("GetTimings", mock.Anything, from, testNow, []string(nil), envs).Return([]*entities.TimingSummary{
+		{Name: entities.TimingTotal, Label: "Total", Runs: 4, Seconds: 80, Max: 30},
+		{Name: "config_retrieval", Label: "Config retrieval", Runs: 4, Seconds: 40, Max: 20},
+		{Name: "exec", Label: "Exec", Runs: 2, Seconds: 20, Max: 15},
+	}, nil)
+
+	b, err := newTestService(db).Breakdown(context.Background(), nil, &Options{From: from, Envs: envs})
+	require.NoError(t, err)
+	db.AssertExpectations(t)
+
+	require.Equal(t, from, b.From)
+	require.Equal(t, testNow, b.To)
+	require.Equal(t, &Entry{Name: entities.TimingTotal, Label: "Total", Runs: 4, Seconds: 80, Average: 20, Max: 30, Share: 1}, b.Total)
+	require.Equal(t, []*Entry{
+		{Name: "config_retrieval", Label: "Config retrieval", Runs: 4, Seconds: 40, Average: 10, Max: 20, Share: 0.5},
+		{Name: "exec", Label: "Exec", Runs: 2, Seconds: 20, Average: 10, Max: 15, Share: 0.25},
+	}, b.Timings)
+}
+
+func TestService_BreakdownInvalidOptions(t *testing.T) {
+	db := new(dataaccess.MockDb)
+
+	_, err := newTestService(db).Breakdown(context.Background(), nil, &Options{From: testNow.Add(time.Hour)})
+	require.ErrorIs(t, err, aggregate.ErrInvalidOptions)
+	db.AssertExpectations(t)
+}
+
+func TestService_BreakdownError(t *testing.T) {
+	db := new(dataaccess.MockDb)
+	db.On("GetTimings", mock.Anything, mock.Anything, mock.Anything, []string(nil), []summary.Environment(nil)).
+		Return([]*entities.TimingSummary(nil), errors.New("boom"))
+
+	_, err := newTestService(db).Breakdown(context.Background(), nil, nil)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, aggregate.ErrInvalidOptions)
+}
+
+func TestReportBreakdown(t *testing.T) {
+	b := ReportBreakdown(&entities.PuppetReport{
+		Timings: []*entities.Timing{
+			{Name: "file", Label: "File", Seconds: 1},
+			{Name: "config_retrieval", Label: "Config retrieval", Seconds: 3},
+			{Name: "schedule", Seconds: 1},
+			{Name: entities.TimingTotal, Label: "Total", Seconds: 5},
+		},
+	})
+
+	require.True(t, b.From.IsZero())
+	require.Equal(t, 5.0, b.Total.Seconds)
+	require.Len(t, b.Timings, 3)
+	require.Equal(t, "config_retrieval", b.Timings[0].Name)
+	require.Equal(t, 0.6, b.Timings[0].Share)
+	require.Equal(t, 3.0, b.Timings[0].Average)
+
+	// The timings that took as long are ordered by name, and those without a label are labelled by their name.
+	require.Equal(t, "file", b.Timings[1].Name)
+	require.Equal(t, "schedule", b.Timings[2].Label)
+	require.Equal(t, 0.2, b.Timings[2].Share)
+
+	// The shares are left out when the run did not report its total time.
+	b = ReportBreakdown(&entities.PuppetReport{
+		Timings: []*entities.Timing{{Name: "file", Label: "File", Seconds: 1}},
+	})
+	require.Nil(t, b.Total)
+	require.Zero(t, b.Timings[0].Share)
+}
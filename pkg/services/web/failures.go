@@ -0,0 +1,229 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/aggregate"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/failures"
+)
+
+const (
+	// defaultFailureDays is the number of days the failures are counted over, when the days are not given.
+	defaultFailureDays = 7
+
+	// maxFailureDays is the most days the failures can be counted over.
+	maxFailureDays = 90
+)
+
+// failureDays are the windows offered by the failure pages, in days.
+var failureDays = []int{1, 7, 30}
+
+// failureRow is an entry of a ranking of failures, with the link to the nodes that failed on it.
+type failureRow struct {
+	*failures.Entry
+
+	// Link is the link to the page of the nodes that failed on the resources of the entry.
+	Link string
+}
+
+// failureTable is a ranking of failures shown on the leaderboard page.
+type failureTable struct {
+	// Title is the heading of the table.
+	Title string
+
+	// Kind is what the entries are keyed by: resource, type or location.
+	Kind string
+
+	// Rows are the entries of the ranking.
+	Rows []*failureRow
+}
+
+// daysQuery reads the window of the pages from the days of the query, writing a 400 bad request if it is invalid.
+func daysQuery(w http.ResponseWriter, r *http.Request) (int, bool) {
+	if !r.URL.Query().Has("days") {
+		return defaultFailureDays, true
+	}
+
+	days, err := strconv.Atoi(r.URL.Query().Get("days"))
+	if err != nil || days < 1 || days > maxFailureDays {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("days must be between 1 and %d", maxFailureDays)); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return 0, false
+	}
+	return days, true
+}
+
+// failureQuery reads the window and environment of the failure pages from the query, writing a 400 bad request if
+// they are invalid.
+func failureQuery(w http.ResponseWriter, r *http.Request) (int, summary.Environment, bool) {
+	days, ok := daysQuery(w, r)
+	if !ok {
+		return 0, "", false
+	}
+
+	env := summary.Environment(r.URL.Query().Get("env"))
+	if env != "" && !env.IsValid() {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Invalid environment provided")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return 0, "", false
+	}
+
+	return days, env, true
+}
+
+// failureOptions returns the options of the failures in the window of days before now, in the environment if set.
+func failureOptions(days int, env summary.Environment) *failures.Options {
+	opts := &failures.Options{
+		From: time.Now().Add(-time.Duration(days) * 24 * time.Hour),
+	}
+	if env != "" {
+		opts.Envs = []summary.Environment{env}
+	}
+	return opts
+}
+
+// failureQueryValues returns the query of the failure pages selecting the key, window and environment.
+func failureQueryValues(key failures.Key, days int, env summary.Environment) url.Values {
+	query := url.Values{}
+	for name, value := range map[string]string{
+		"type": key.Type,
+		"name": key.Name,
+		"file": key.File,
+		"line": key.Line,
+		"env":  string(env),
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	query.Set("days", strconv.Itoa(days))
+	return query
+}
+
+func (s service) failuresHandler(w http.ResponseWriter, r *http.Request) {
+	days, env, ok := failureQuery(w, r)
+	if !ok {
+		return
+	}
+
+	leaderboard, err := s.failures.Leaderboard(r.Context(), failureOptions(days, env))
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting failure leaderboard", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting failure leaderboard")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	rows := func(entries []*failures.Entry) []*failureRow {
+		res := make([]*failureRow, 0, len(entries))
+		for _, entry := range entries {
+			res = append(res, &failureRow{
+				Entry: entry,
+				Link:  s.urlPrefix + pathFailureNodes + "?" + failureQueryValues(entry.Key, days, env).Encode(),
+			})
+		}
+		return res
+	}
+
+	type PageData struct {
+		Days         int
+		DaysOptions  []int
+		Environment  summary.Environment
+		Environments []summary.Environment
+		Tables       []*failureTable
+		URLPrefix    string
+	}
+
+	pd := &PageData{
+		Days:         days,
+		DaysOptions:  failureDays,
+		Environment:  env,
+		Environments: summary.Environments,
+		Tables: []*failureTable{
+			{Title: "Resources", Kind: "resource", Rows: rows(leaderboard.Resources)},
+			{Title: "Resource types", Kind: "type", Rows: rows(leaderboard.Types)},
+			{Title: "Manifests", Kind: "location", Rows: rows(leaderboard.Locations)},
+		},
+		URLPrefix: s.urlPrefix,
+	}
+
+	s.templates.render(w, pageFailures, pd)
+}
+
+func (s service) failureNodesHandler(w http.ResponseWriter, r *http.Request) {
+	days, env, ok := failureQuery(w, r)
+	if !ok {
+		return
+	}
+
+	key := failures.Key{
+		Type: r.URL.Query().Get("type"),
+		Name: r.URL.Query().Get("name"),
+		File: r.URL.Query().Get("file"),
+		Line: r.URL.Query().Get("line"),
+	}
+
+	affected, err := s.failures.Affected(r.Context(), failureOptions(days, env), &key)
+	if errors.Is(err, aggregate.ErrInvalidOptions) {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage(err.Error())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	} else if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting failing nodes", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting failing nodes")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	type PageData struct {
+		Key       failures.Key
+		Days      int
+		BackLink  string
+		Nodes     []*failures.AffectedNode
+		URLPrefix string
+	}
+
+	back := url.Values{"days": {strconv.Itoa(days)}}
+	if env != "" {
+		back.Set("env", string(env))
+	}
+
+	pd := &PageData{
+		Key:       key,
+		Days:      days,
+		BackLink:  s.urlPrefix + pathFailures + "?" + back.Encode(),
+		Nodes:     affected,
+		URLPrefix: s.urlPrefix,
+	}
+
+	s.templates.render(w, pageFailureNodes, pd)
+}
@@ -0,0 +1,60 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/incidents"
+)
+
+func (s service) incidentsHandler(w http.ResponseWriter, r *http.Request) {
+	// The incidents are listed over the same windows as the failing resources.
+	days, env, ok := failureQuery(w, r)
+	if !ok {
+		return
+	}
+
+	failureOpts := failureOptions(days, env)
+	list, err := s.incidents.Incidents(r.Context(), nil, &incidents.Options{
+		From: failureOpts.From,
+		Envs: failureOpts.Envs,
+	})
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting incidents", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting incidents")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	type PageData struct {
+		Days         int
+		DaysOptions  []int
+		Environment  summary.Environment
+		Environments []summary.Environment
+		Incidents    []*entities.Incident
+		URLPrefix    string
+	}
+
+	pd := &PageData{
+		Days:         days,
+		DaysOptions:  failureDays,
+		Environment:  env,
+		Environments: summary.Environments,
+		Incidents:    list,
+		URLPrefix:    s.urlPrefix,
+	}
+
+	s.templates.render(w, pageIncidents, pd)
+}
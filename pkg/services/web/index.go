@@ -4,11 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"html/template"
-	"io"
 	"log/slog"
 	"net/http"
-	"os"
+	"net/url"
 	"sort"
 	"strings"
 	"time"
@@ -18,6 +16,8 @@ import (
 	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/flapping"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/nodes"
 	"github.com/gorilla/mux"
 	"github.com/oapi-codegen/runtime"
 )
@@ -35,9 +35,37 @@ func (s service) indexHandler(w http.ResponseWriter, r *http.Request) {
 			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
 		}
 		return
+	} else if envOk && !env.IsValid() {
+		slog.Warn("Invalid environment", slog.String("env", envStr))
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Invalid environment provided")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
 	}
 
-	nodes, err := s.db.GetRuns(r.Context())
+	// See if the nodes should be filtered by their owner or labels.
+	var owner *string
+	if r.URL.Query().Has("owner") {
+		owner = summary.Point(r.URL.Query().Get("owner"))
+	}
+	var labels *[]string
+	if l, ok := r.URL.Query()["label"]; ok {
+		labels = &l
+	}
+	filter, err := nodes.ParseFilter(owner, labels)
+	if err != nil {
+		slog.Warn("Invalid node filter", slog.String(logging.KeyError, err.Error()))
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage(err.Error())); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	runs, err := s.db.GetRuns(r.Context())
 	if err != nil && !errors.Is(err, dataaccess.ErrNotFound) {
 		// Respond with 500 internal server error.
 		slog.Error("Error getting nodes", slog.String(logging.KeyError, err.Error()))
@@ -48,6 +76,21 @@ func (s service) indexHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Leave out the decommissioned nodes, and the nodes not selected by the filter.
+	nodes, err := nodes.Listed(r.Context(), s.db, runs)
+	if err == nil {
+		nodes, err = filter.Apply(r.Context(), s.db, nodes)
+	}
+	if err != nil {
+		// Respond with 500 internal server error.
+		slog.Error("Error getting nodes", slog.String(logging.KeyError, err.Error()))
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting nodes")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
 	// Filter the nodes by environment.
 	filteredNodesMap := make(map[string]*entities.PuppetRun)
 	for _, node := range nodes {
@@ -138,68 +181,77 @@ func (s service) indexHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	type PageData struct {
-		Graph        []*entities.PuppetHistory
-		Nodes        []*entities.PuppetRun
-		Environment  summary.Environment
-		Environments []summary.Environment
-		URLPrefix    string
-	}
-
-	pd := &PageData{
-		Graph:        history,
-		Nodes:        filteredNodes,
-		Environment:  env,
-		Environments: envs,
-		URLPrefix:    "",
-	}
-
-	// Read the page template from the file.
-	page, err := os.OpenFile("assets/index.gohtml", os.O_RDONLY, os.ModePerm)
+	mutes, err := s.silencer.Muted(r.Context())
 	if err != nil {
-		slog.Error("Error opening page file", slog.String(logging.KeyError, err.Error()))
+		slog.Error("Error getting silences", slog.String(logging.KeyError, err.Error()))
 		// Respond with 500 internal server error.
 		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(request.NewMessage("Error reading page template")); err != nil {
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting silences")); err != nil {
 			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
 		}
 		return
 	}
 
-	defer func() {
-		if err := page.Close(); err != nil {
-			slog.Error("Error closing page file", slog.String(logging.KeyError, err.Error()))
+	// The acknowledged and silenced nodes are marked, keyed by the fqdn.
+	muted := make(map[string]*entities.Silence)
+	for _, node := range filteredNodes {
+		if sil := mutes.For(node.Fqdn); sil != nil {
+			muted[node.Fqdn] = sil
 		}
-	}()
+	}
 
-	pt, err := io.ReadAll(page)
+	// The flapping nodes are marked, keyed by the fqdn and the environment. They are worked out from the runs already
+	// read, with the default window and threshold.
+	detected, err := flapping.Detect(nodes, nil)
 	if err != nil {
-		slog.Error("Error reading page file", slog.String(logging.KeyError, err.Error()))
+		slog.Error("Error detecting flapping nodes", slog.String(logging.KeyError, err.Error()))
 		// Respond with 500 internal server error.
 		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(request.NewMessage("Error reading page template")); err != nil {
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error detecting flapping nodes")); err != nil {
 			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
 		}
 		return
 	}
+	flappingNodes := make(map[string]*flapping.Node, len(detected))
+	for _, node := range detected {
+		flappingNodes[fmt.Sprintf("%s-%s", node.Fqdn, node.Env)] = node
+	}
 
-	// Parse the template.
-	tmpl := template.Must(template.New("").Funcs(template.FuncMap{
-		"prettyDuration": prettyDuration,
-	}).Parse(string(pt)))
+	type PageData struct {
+		Graph        []*entities.PuppetHistory
+		Nodes        []*entities.PuppetRun
+		Muted        map[string]*entities.Silence
+		Flapping     map[string]*flapping.Node
+		Environment  summary.Environment
+		Environments []summary.Environment
+		EventsURL    string
+		URLPrefix    string
+	}
 
-	// Execute the template.
-	w.Header().Set("content-type", "text/html")
-	w.WriteHeader(http.StatusOK)
-	if err := tmpl.Execute(w, pd); err != nil {
-		slog.Warn("Error executing template", slog.String(logging.KeyError, err.Error()))
-		// Respond with 500 internal server error.
-		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(request.NewMessage("Error executing template")); err != nil {
-			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
-		}
-		return
+	// The page is updated live from the reports of the nodes it lists.
+	eventsQuery := make(url.Values)
+	if envOk {
+		eventsQuery.Set("env", string(env))
+	}
+	if filter.Owner != "" {
+		eventsQuery.Set("owner", filter.Owner)
+	}
+	if labels != nil {
+		eventsQuery["label"] = *labels
 	}
+
+	pd := &PageData{
+		Graph:        history,
+		Nodes:        filteredNodes,
+		Muted:        muted,
+		Flapping:     flappingNodes,
+		Environment:  env,
+		Environments: envs,
+		EventsURL:    s.eventsURL(eventsQuery),
+		URLPrefix:    s.urlPrefix,
+	}
+
+	s.templates.render(w, pageIndex, pd)
 }
 
 func prettyDuration(d *entities.Duration) string {
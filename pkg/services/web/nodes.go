@@ -4,13 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"html/template"
-	"io"
 	"log/slog"
 	"net/http"
-	"os"
+	"net/url"
 	"sort"
-	"strconv"
+	"time"
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
@@ -57,68 +55,42 @@ func (s service) nodeFqdnHandler(w http.ResponseWriter, r *http.Request) {
 		return reps[i].ExecTime.Time().Before(reps[j].ExecTime.Time())
 	})
 
-	type PageData struct {
-		Fqdn      string
-		Nodes     []*entities.PuppetReportSummary
-		URLPrefix string
-	}
-
-	pd := &PageData{
-		Fqdn:      nodeFqdn,
-		Nodes:     reps,
-		URLPrefix: "",
-	}
-
-	// Read the page template from the file.
-	page, err := os.OpenFile("assets/node.gohtml", os.O_RDONLY, os.ModePerm)
+	list, err := s.db.GetRuntimeAnomalies(r.Context(), nodeFqdn, time.Time{}, time.Time{})
 	if err != nil {
-		slog.Error("Error opening page file", slog.String(logging.KeyError, err.Error()))
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting runtime anomalies", slog.String(logging.KeyError, err.Error()))
+		}
 		// Respond with 500 internal server error.
 		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(request.NewMessage("Error reading page template")); err != nil {
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting runtime anomalies")); err != nil {
 			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
 		}
 		return
 	}
 
-	defer func() {
-		if err := page.Close(); err != nil {
-			slog.Error("Error closing page file", slog.String(logging.KeyError, err.Error()))
-		}
-	}()
-
-	pt, err := io.ReadAll(page)
-	if err != nil {
-		slog.Error("Error reading page file", slog.String(logging.KeyError, err.Error()))
-		// Respond with 500 internal server error.
-		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(request.NewMessage("Error reading page template")); err != nil {
-			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
-		}
-		return
+	// The anomalies are looked up by the report, to mark the runs on the graph and in the table.
+	anomalies := make(map[string]*entities.RuntimeAnomaly, len(list))
+	for _, anomaly := range list {
+		anomalies[anomaly.ReportID] = anomaly
 	}
 
-	// Parse the template.
-	tmpl := template.Must(template.New("node").Funcs(template.FuncMap{
-		"inc": func(i int) string {
-			return strconv.Itoa(i + 1)
-		},
-		"graphConvert":   graphRuntime,
-		"prettyDuration": prettyDuration,
-	}).Parse(string(pt)))
+	type PageData struct {
+		Fqdn      string
+		Nodes     []*entities.PuppetReportSummary
+		Anomalies map[string]*entities.RuntimeAnomaly
+		EventsURL string
+		URLPrefix string
+	}
 
-	// Execute the template.
-	w.Header().Set("content-type", "text/html")
-	w.WriteHeader(http.StatusOK)
-	if err := tmpl.Execute(w, pd); err != nil {
-		slog.Warn("Error executing template", slog.String(logging.KeyError, err.Error()))
-		// Respond with 500 internal server error.
-		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(request.NewMessage("Error executing template")); err != nil {
-			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
-		}
-		return
+	pd := &PageData{
+		Fqdn:      nodeFqdn,
+		Nodes:     reps,
+		Anomalies: anomalies,
+		EventsURL: s.eventsURL(url.Values{"fqdn": {nodeFqdn}}),
+		URLPrefix: s.urlPrefix,
 	}
+
+	s.templates.render(w, pageNode, pd)
 }
 
 // graphRuntime takes in the duration of time to graph, and returns the duration as seconds as an int.
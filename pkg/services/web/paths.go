@@ -9,4 +9,16 @@ const (
 
 	pathReports  = "/reports"
 	pathReportID = pathReports + "/{report_id}"
+
+	pathFailures     = "/failures"
+	pathFailureNodes = pathFailures + "/nodes"
+
+	pathIncidents = "/incidents"
+
+	pathTimings = "/timings"
+
+	pathRollout = "/rollout/{env}"
+
+	// pathEvents is the path of the event stream of the API, which the pages subscribe to for live updates.
+	pathEvents = "/api/events"
 )
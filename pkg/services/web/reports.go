@@ -4,13 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"html/template"
-	"io"
 	"log/slog"
 	"net/http"
-	"os"
-	"strconv"
 	"time"
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
@@ -18,6 +13,7 @@ import (
 	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
 	"github.com/Jacobbrewer1/puppet-summary/pkg/services/parser"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/timings"
 	"github.com/gorilla/mux"
 	"github.com/oapi-codegen/runtime"
 )
@@ -64,7 +60,7 @@ func (s service) reportIDHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the Yaml report from Files.
-	file, err := dataaccess.Files.DownloadFile(r.Context(), rep.ReportFilePath())
+	file, err := dataaccess.Files.DownloadFile(r.Context(), rep.YamlFile)
 	if err != nil {
 		slog.Error("Error downloading yaml file", slog.String(logging.KeyError, err.Error()))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -77,7 +73,7 @@ func (s service) reportIDHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse the yaml file.
 	report, err := parser.ParsePuppetReport(file)
 	if err != nil {
-		slog.Error("Error parsing yaml file", slog.String(logging.KeyError, err.Error()), slog.String("file", rep.ReportFilePath()))
+		slog.Error("Error parsing yaml file", slog.String(logging.KeyError, err.Error()), slog.String("file", rep.YamlFile))
 		w.WriteHeader(http.StatusInternalServerError)
 		if err := json.NewEncoder(w).Encode(request.NewMessage("Error parsing yaml file")); err != nil {
 			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
@@ -91,65 +87,17 @@ func (s service) reportIDHandler(w http.ResponseWriter, r *http.Request) {
 
 	type PageData struct {
 		Report    *entities.PuppetReport
+		Timings   *timings.Breakdown
 		URLPrefix string
 	}
 
 	pd := &PageData{
 		Report:    rep,
-		URLPrefix: "",
+		Timings:   timings.ReportBreakdown(rep),
+		URLPrefix: s.urlPrefix,
 	}
 
-	// Read the page template from the file.
-	page, err := os.OpenFile("assets/report.gohtml", os.O_RDONLY, os.ModePerm)
-	if err != nil {
-		slog.Error("Error opening page file", slog.String(logging.KeyError, err.Error()))
-		// Respond with 500 internal server error.
-		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(request.NewMessage("Error reading page template")); err != nil {
-			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
-		}
-		return
-	}
-
-	defer func() {
-		if err := page.Close(); err != nil {
-			slog.Error("Error closing page file", slog.String(logging.KeyError, err.Error()))
-		}
-	}()
-
-	pt, err := io.ReadAll(page)
-	if err != nil {
-		slog.Error("Error reading page file", slog.String(logging.KeyError, err.Error()))
-		// Respond with 500 internal server error.
-		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(request.NewMessage("Error reading page template")); err != nil {
-			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
-		}
-		return
-	}
-
-	// Parse the template.
-	tmpl := template.Must(template.New("").Funcs(template.FuncMap{
-		"truncate": func(s string) string {
-			f, _ := strconv.ParseFloat(s, 64)
-			s = fmt.Sprintf("%.2f", f)
-			return s
-		},
-		"prettyTime": prettyTime,
-	}).Parse(string(pt)))
-
-	// Execute the template.
-	w.Header().Set("content-type", "text/html")
-	w.WriteHeader(http.StatusOK)
-	if err := tmpl.Execute(w, pd); err != nil {
-		slog.Warn("Error executing template", slog.String(logging.KeyError, err.Error()))
-		// Respond with 500 internal server error.
-		w.WriteHeader(http.StatusInternalServerError)
-		if err := json.NewEncoder(w).Encode(request.NewMessage("Error executing template")); err != nil {
-			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
-		}
-		return
-	}
+	s.templates.render(w, pageReport, pd)
 }
 
 func prettyTime(t entities.Datetime) string {
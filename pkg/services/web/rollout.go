@@ -0,0 +1,83 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/rollout"
+	"github.com/gorilla/mux"
+)
+
+// rolloutBucket returns the step of the timeline of the rollout page over the window of days, so that the timeline
+// stays short enough to read.
+func rolloutBucket(days int) summary.HistoryBucket {
+	switch {
+	case days <= 2:
+		return summary.HistoryBucket_hour
+	case days <= 30:
+		return summary.HistoryBucket_day
+	default:
+		return summary.HistoryBucket_week
+	}
+}
+
+func (s service) rolloutHandler(w http.ResponseWriter, r *http.Request) {
+	env := summary.Environment(mux.Vars(r)["env"])
+	if !env.IsValid() {
+		// Respond with 400 bad request.
+		w.WriteHeader(http.StatusBadRequest)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Invalid environment provided")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	days, ok := daysQuery(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := s.rollout.Rollout(r.Context(), &rollout.Options{
+		Env:    env,
+		From:   time.Now().Add(-time.Duration(days) * 24 * time.Hour),
+		Bucket: rolloutBucket(days),
+	})
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting rollout", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting rollout")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	type PageData struct {
+		Days         int
+		DaysOptions  []int
+		Environment  summary.Environment
+		Environments []summary.Environment
+		Rollout      *rollout.Rollout
+		URLPrefix    string
+	}
+
+	pd := &PageData{
+		Days:         days,
+		DaysOptions:  failureDays,
+		Environment:  env,
+		Environments: summary.Environments,
+		Rollout:      res,
+		URLPrefix:    s.urlPrefix,
+	}
+
+	s.templates.render(w, pageRollout, pd)
+}
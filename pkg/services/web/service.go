@@ -2,25 +2,61 @@ package web
 
 import (
 	"net/http"
+	"net/url"
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/alerting"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/failures"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/incidents"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/rollout"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/timings"
 	"github.com/gorilla/mux"
 )
 
 type service struct {
 	r  *mux.Router
 	db dataaccess.Database
+
+	// silencer is used to mark the acknowledged and silenced nodes.
+	silencer alerting.Silencer
+
+	// failures ranks the resources that failed.
+	failures failures.Ranker
+
+	// incidents lists the incidents of the failed runs.
+	incidents incidents.Correlator
+
+	// rollout tracks the rollout of the code across the nodes.
+	rollout rollout.Tracker
+
+	// timings breaks down where the time of the runs was spent.
+	timings timings.Profiler
+
+	// templates are the templates of the web pages.
+	templates *Templates
+
+	// urlPrefix is the path the web pages are served under, which prefixes the links in the pages.
+	urlPrefix string
 }
 
-func NewService(db dataaccess.Database) http.Handler {
+func NewService(db dataaccess.Database, templates *Templates) http.Handler {
 	r := mux.NewRouter()
-	return NewServiceFromRouter(r, db, nil)
+	return NewServiceFromRouter(r, db, templates, "", nil)
 }
 
-func NewServiceFromRouter(r *mux.Router, db dataaccess.Database, middlewareFunc func(handler http.HandlerFunc) http.HandlerFunc) http.Handler {
+// NewServiceFromRouter registers the web pages on the router. The urlPrefix is the path the router is served under,
+// such as "/puppet" when the router is a subrouter of that path.
+func NewServiceFromRouter(r *mux.Router, db dataaccess.Database, templates *Templates, urlPrefix string, middlewareFunc func(handler http.HandlerFunc) http.HandlerFunc) http.Handler {
 	svc := &service{
-		r:  r,
-		db: db,
+		r:         r,
+		db:        db,
+		silencer:  alerting.NewService(db),
+		failures:  failures.NewService(db),
+		incidents: incidents.NewService(db, 0, nil),
+		rollout:   rollout.NewService(db),
+		timings:   timings.NewService(db),
+		templates: templates,
+		urlPrefix: urlPrefix,
 	}
 
 	if middlewareFunc == nil {
@@ -33,6 +69,20 @@ func NewServiceFromRouter(r *mux.Router, db dataaccess.Database, middlewareFunc
 	r.HandleFunc(pathIndexEnv, middlewareFunc(svc.indexHandler)).Methods(http.MethodGet)
 	r.HandleFunc(pathNodeFqdn, middlewareFunc(svc.nodeFqdnHandler)).Methods(http.MethodGet)
 	r.HandleFunc(pathReportID, middlewareFunc(svc.reportIDHandler)).Methods(http.MethodGet)
+	r.HandleFunc(pathFailures, middlewareFunc(svc.failuresHandler)).Methods(http.MethodGet)
+	r.HandleFunc(pathFailureNodes, middlewareFunc(svc.failureNodesHandler)).Methods(http.MethodGet)
+	r.HandleFunc(pathIncidents, middlewareFunc(svc.incidentsHandler)).Methods(http.MethodGet)
+	r.HandleFunc(pathRollout, middlewareFunc(svc.rolloutHandler)).Methods(http.MethodGet)
+	r.HandleFunc(pathTimings, middlewareFunc(svc.timingsHandler)).Methods(http.MethodGet)
 
 	return r
 }
+
+// eventsURL returns the URL of the event stream, filtered by the query.
+func (s service) eventsURL(query url.Values) string {
+	u := s.urlPrefix + pathEvents
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
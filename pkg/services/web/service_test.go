@@ -0,0 +1,370 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/dataaccess"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/entities"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/flapping"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/parser"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/suite"
+)
+
+type WebSuite struct {
+	suite.Suite
+
+	// db is the database used for testing.
+	db dataaccess.Database
+
+	// handler is the web service under test.
+	handler http.Handler
+}
+
+func TestWebSuite(t *testing.T) {
+	suite.Run(t, new(WebSuite))
+}
+
+func (s *WebSuite) SetupTest() {
+	s.Require().NoError(dataaccess.ConnectStorage(context.Background(), dataaccess.StoreTypeMemory, ""))
+
+	templates, err := NewTemplates(os.DirFS("../../../cmd/summary/assets"), false)
+	s.Require().NoError(err)
+
+	s.db = dataaccess.NewMemory()
+	s.handler = NewService(s.db, templates)
+}
+
+func (s *WebSuite) TearDownTest() {
+	dataaccess.Files = nil
+}
+
+// get makes a GET request to the web service.
+func (s *WebSuite) get(path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+
+	s.handler.ServeHTTP(w, r)
+
+	return w
+}
+
+// saveExample saves the example report in the parser test data to the database, returning it. If withFile is set, the
+// report file is saved to storage too.
+func (s *WebSuite) saveExample(withFile bool) *entities.PuppetReport {
+	bdy, err := os.ReadFile("../parser/testdata/example.yaml")
+	s.Require().NoError(err)
+
+	rep, err := parser.ParsePuppetReport(bdy)
+	s.Require().NoError(err)
+	s.Require().NoError(s.db.SaveRun(context.Background(), rep))
+
+	if withFile {
+		s.Require().NoError(dataaccess.Files.SaveFile(context.Background(), rep.ReportFilePath(), bdy))
+	}
+
+	return rep
+}
+
+func (s *WebSuite) TestIndex() {
+	rep := s.saveExample(false)
+
+	w := s.get("/")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Equal("text/html", w.Header().Get("Content-Type"))
+	s.Require().Contains(w.Body.String(), rep.Fqdn)
+}
+
+func (s *WebSuite) TestIndexMuted() {
+	rep := s.saveExample(false)
+
+	s.Require().NoError(s.db.SaveSilence(context.Background(), &entities.Silence{
+		ID:        "ack-" + rep.Fqdn,
+		Kind:      entities.SilenceKindAck,
+		Fqdn:      rep.Fqdn,
+		CreatedBy: "alice",
+		Reason:    "Looking into it",
+		StartsAt:  entities.Datetime(time.Now().Add(-time.Hour)),
+		EndsAt:    entities.Datetime(time.Now().Add(time.Hour)),
+	}))
+
+	w := s.get("/")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), "Acknowledged</span>")
+	s.Require().Contains(w.Body.String(), "alice: Looking into it")
+}
+
+func (s *WebSuite) TestIndexFlapping() {
+	rep := s.saveExample(false)
+
+	// The node alternates between changed and unchanged on every run.
+	for i := 1; i < flapping.DefaultWindow; i++ {
+		state := summary.State_UNCHANGED
+		if i%2 == 0 {
+			state = summary.State_CHANGED
+		}
+		s.Require().NoError(s.db.SaveRun(context.Background(), &entities.PuppetReport{
+			ID:       fmt.Sprintf("%s-%d", rep.ID, i),
+			Fqdn:     rep.Fqdn,
+			Env:      rep.Env,
+			State:    state,
+			ExecTime: entities.Datetime(rep.ExecTime.Time().Add(time.Duration(i) * 30 * time.Minute)),
+		}))
+	}
+
+	w := s.get("/")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), "Flapping</span>")
+	s.Require().Contains(w.Body.String(), "Changed state 9 times over its last 10 runs")
+}
+
+func (s *WebSuite) TestNode() {
+	rep := s.saveExample(false)
+
+	w := s.get("/nodes/" + rep.Fqdn)
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), rep.ID)
+}
+
+func (s *WebSuite) TestNodeAnomalies() {
+	rep := s.saveExample(false)
+
+	w := s.get("/nodes/" + rep.Fqdn)
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().NotContains(w.Body.String(), ">Slow</span>")
+
+	s.Require().NoError(s.db.SaveRuntimeAnomaly(context.Background(), &entities.RuntimeAnomaly{
+		ReportID:  rep.ID,
+		Fqdn:      rep.Fqdn,
+		Env:       rep.Env,
+		ExecTime:  rep.ExecTime,
+		Runtime:   entities.Duration(15 * time.Minute),
+		Median:    entities.Duration(40 * time.Second),
+		Deviation: entities.Duration(time.Second),
+		Score:     215,
+	}))
+
+	w = s.get("/nodes/" + rep.Fqdn)
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), ">Slow</span>")
+	s.Require().Contains(w.Body.String(), "Took 15m, usually 40s")
+}
+
+func (s *WebSuite) TestReport() {
+	rep := s.saveExample(true)
+
+	w := s.get("/reports/" + rep.ID)
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), rep.Fqdn)
+
+	// The time of the run is broken down by resource type and phase.
+	s.Require().Contains(w.Body.String(), `<td title="config_retrieval">Config retrieval</td>`)
+	s.Require().Contains(w.Body.String(), "<td>0.44</td>")
+	s.Require().Contains(w.Body.String(), "<th>26.68</th>")
+}
+
+func (s *WebSuite) TestURLPrefix() {
+	rep := s.saveExample(false)
+
+	templates, err := NewTemplates(os.DirFS("../../../cmd/summary/assets"), false)
+	s.Require().NoError(err)
+
+	r := mux.NewRouter()
+	s.handler = r
+	NewServiceFromRouter(r.PathPrefix("/puppet").Subrouter(), s.db, templates, "/puppet", nil)
+
+	w := s.get("/puppet/")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), `href="/puppet/assets/css/bootstrap.min.css"`)
+	s.Require().Contains(w.Body.String(), `data-href="/puppet/nodes/`+rep.Fqdn+`"`)
+	s.Require().Contains(w.Body.String(), `new EventSource("/puppet/api/events")`)
+
+	w = s.get("/puppet/nodes/" + rep.Fqdn)
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), `data-href="/puppet/reports/`+rep.ID+`"`)
+	s.Require().Contains(w.Body.String(), `new EventSource("/puppet/api/events?fqdn=`+rep.Fqdn+`")`)
+}
+
+func (s *WebSuite) TestIndexEventsFilter() {
+	s.saveExample(false)
+
+	w := s.get("/environment/PRODUCTION?owner=platform&label=dc%3Dlon")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), `new EventSource("/api/events?env=PRODUCTION\u0026label=dc%3Dlon\u0026owner=platform")`)
+}
+
+func (s *WebSuite) TestIndexInvalidEnvironment() {
+	w := s.get("/environment/INVALID")
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *WebSuite) TestNodeNoReports() {
+	w := s.get("/nodes/missing-host")
+	s.Require().Equal(http.StatusNotFound, w.Code)
+}
+
+func (s *WebSuite) TestReportNotFound() {
+	w := s.get("/reports/missing")
+	s.Require().Equal(http.StatusNotFound, w.Code)
+}
+
+func (s *WebSuite) TestReportMissingFile() {
+	// The report is in the database, but its file was never saved.
+	rep := s.saveExample(false)
+
+	w := s.get("/reports/" + rep.ID)
+	s.Require().Equal(http.StatusInternalServerError, w.Code)
+}
+
+// saveFailed saves a failed report of the node, an hour ago, failing on an nginx package.
+func (s *WebSuite) saveFailed(id, fqdn string) {
+	s.Require().NoError(s.db.SaveRun(context.Background(), &entities.PuppetReport{
+		ID:       id,
+		Fqdn:     fqdn,
+		Env:      summary.Environment_PRODUCTION,
+		State:    summary.State_FAILED,
+		ExecTime: entities.Datetime(time.Now().Add(-time.Hour).UTC().Truncate(time.Second)),
+		ResourcesFailed: []*entities.PuppetResource{
+			{Type: "Package", Name: "nginx", File: "/etc/puppet/nginx.pp", Line: "3"},
+		},
+	}))
+}
+
+func (s *WebSuite) TestFailures() {
+	s.saveFailed("report1", "node1.example.com")
+	s.saveFailed("report2", "node2.example.com")
+
+	w := s.get("/failures")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Equal("text/html", w.Header().Get("Content-Type"))
+	s.Require().Contains(w.Body.String(), "<td>nginx</td>")
+	s.Require().Contains(w.Body.String(), "<td>/etc/puppet/nginx.pp:3</td>")
+	s.Require().Contains(w.Body.String(), `data-href="/failures/nodes?days=7&amp;name=nginx&amp;type=Package"`)
+
+	w = s.get("/failures/nodes?days=7&name=nginx&type=Package")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), `href="/nodes/node1.example.com"`)
+	s.Require().Contains(w.Body.String(), `href="/reports/report2"`)
+}
+
+func (s *WebSuite) TestFailuresInvalid() {
+	w := s.get("/failures?days=0")
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+
+	w = s.get("/failures?env=INVALID")
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+
+	// The nodes of every resource are not listed.
+	w = s.get("/failures/nodes")
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *WebSuite) TestIncidents() {
+	w := s.get("/incidents")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), "No incidents in this window.")
+
+	now := time.Now().UTC().Truncate(time.Second)
+	s.Require().NoError(s.db.SaveIncident(context.Background(), &entities.Incident{
+		ID:            "report1",
+		Env:           summary.Environment_PRODUCTION,
+		ConfigVersion: "1708135209",
+		Resources:     entities.Strings{"Package[nginx]"},
+		Message:       "Could not find package nginx",
+		FirstSeen:     entities.Datetime(now.Add(-time.Hour)),
+		LastSeen:      entities.Datetime(now.Add(-time.Minute)),
+		Nodes:         entities.Strings{"node1.example.com", "node2.example.com"},
+		Reports:       2,
+	}))
+
+	w = s.get("/incidents?days=1")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), "<code>Package[nginx]</code>")
+	s.Require().Contains(w.Body.String(), `<a href="/reports/report1">Could not find package nginx</a>`)
+	s.Require().Contains(w.Body.String(), `href="/nodes/node2.example.com"`)
+
+	w = s.get("/incidents?env=STAGING")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), "No incidents in this window.")
+
+	w = s.get("/incidents?env=INVALID")
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *WebSuite) TestRollout() {
+	w := s.get("/rollout/PRODUCTION")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), "No run in this environment reported a configuration version.")
+
+	// node1 moved to the new version and failed on its first run of it, while node2 is still on the old one.
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, rep := range []*entities.PuppetReport{
+		{ID: "report1", Fqdn: "node1.example.com", State: summary.State_UNCHANGED, ExecTime: entities.Datetime(now.Add(-3 * time.Hour)), ConfigVersion: "1708135209"},
+		{ID: "report2", Fqdn: "node2.example.com", State: summary.State_UNCHANGED, ExecTime: entities.Datetime(now.Add(-3 * time.Hour)), ConfigVersion: "1708135209"},
+		{ID: "report3", Fqdn: "node1.example.com", State: summary.State_FAILED, ExecTime: entities.Datetime(now.Add(-time.Hour)), ConfigVersion: "1708135999"},
+	} {
+		rep.Env = summary.Environment_PRODUCTION
+		s.Require().NoError(s.db.SaveRun(context.Background(), rep))
+	}
+
+	w = s.get("/rollout/PRODUCTION?days=1")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), `<code>1708135999</code> <span class="label label-primary">newest</span>`)
+	s.Require().Contains(w.Body.String(), "<td>1 (50%)</td>")
+	s.Require().Contains(w.Body.String(), `<a href="/reports/report3">`)
+	s.Require().Contains(w.Body.String(), "<td><code>1708135209</code></td>")
+
+	w = s.get("/rollout/STAGING")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), "No run in this environment reported a configuration version.")
+
+	w = s.get("/rollout/INVALID")
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+
+	w = s.get("/rollout/PRODUCTION?days=0")
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+}
+
+func (s *WebSuite) TestTimings() {
+	w := s.get("/timings")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), "No timings in this window.")
+
+	// Two runs spent most of their time retrieving the catalog.
+	now := time.Now().UTC().Truncate(time.Second)
+	for i, fqdn := range []string{"node1.example.com", "node2.example.com"} {
+		s.Require().NoError(s.db.SaveRun(context.Background(), &entities.PuppetReport{
+			ID:       fmt.Sprintf("report%d", i+1),
+			Fqdn:     fqdn,
+			Env:      summary.Environment_PRODUCTION,
+			State:    summary.State_UNCHANGED,
+			ExecTime: entities.Datetime(now.Add(-time.Hour)),
+			Timings: []*entities.Timing{
+				{Name: "config_retrieval", Label: "Config retrieval", Seconds: 6},
+				{Name: "file", Label: "File", Seconds: 1},
+				{Name: entities.TimingTotal, Label: "Total", Seconds: 8},
+			},
+		}))
+	}
+
+	w = s.get("/timings?days=1&env=PRODUCTION")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), `<td title="config_retrieval">Config retrieval</td>`)
+	s.Require().Contains(w.Body.String(), "<td>12.00</td>")
+	s.Require().Contains(w.Body.String(), ">75%</div>")
+	s.Require().Contains(w.Body.String(), "<th>16.00</th>")
+
+	w = s.get("/timings?env=STAGING")
+	s.Require().Equal(http.StatusOK, w.Code)
+	s.Require().Contains(w.Body.String(), "No timings in this window.")
+
+	w = s.get("/timings?env=INVALID")
+	s.Require().Equal(http.StatusBadRequest, w.Code)
+}
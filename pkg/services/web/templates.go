@@ -0,0 +1,161 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+)
+
+const (
+	// pageIndex is the template of the index page.
+	pageIndex = "index.gohtml"
+
+	// pageNode is the template of the node page.
+	pageNode = "node.gohtml"
+
+	// pageReport is the template of the report page.
+	pageReport = "report.gohtml"
+
+	// pageFailures is the template of the failing resources leaderboard page.
+	pageFailures = "failures.gohtml"
+
+	// pageFailureNodes is the template of the page of the nodes failing on resources.
+	pageFailureNodes = "failure_nodes.gohtml"
+
+	// pageIncidents is the template of the incidents page.
+	pageIncidents = "incidents.gohtml"
+
+	// pageRollout is the template of the rollout page.
+	pageRollout = "rollout.gohtml"
+
+	// pageTimings is the template of the timings page.
+	pageTimings = "timings.gohtml"
+)
+
+// pages are the templates of the web pages.
+var pages = []string{
+	pageIndex,
+	pageNode,
+	pageReport,
+	pageFailures,
+	pageFailureNodes,
+	pageIncidents,
+	pageRollout,
+	pageTimings,
+}
+
+// funcs are the functions available to the templates.
+var funcs = template.FuncMap{
+	"prettyDuration": prettyDuration,
+	"prettyTime":     prettyTime,
+	"graphConvert":   graphRuntime,
+	"inc": func(i int) string {
+		return strconv.Itoa(i + 1)
+	},
+	"percent": func(share float64) string {
+		return fmt.Sprintf("%.0f", share*100)
+	},
+	"seconds": func(f float64) string {
+		return fmt.Sprintf("%.2f", f)
+	},
+	"truncate": func(s string) string {
+		f, _ := strconv.ParseFloat(s, 64)
+		s = fmt.Sprintf("%.2f", f)
+		return s
+	},
+}
+
+// Templates are the templates of the web pages.
+type Templates struct {
+	// fsys is the file system the templates are read from.
+	fsys fs.FS
+
+	// reload is whether the templates are parsed again on every request.
+	reload bool
+
+	// parsed are the templates parsed at startup, keyed by the page.
+	parsed map[string]*template.Template
+}
+
+// NewTemplates parses the templates of the web pages in the file system. If reload is set, the templates are parsed
+// again on every request, so that changes to them are picked up without a restart.
+func NewTemplates(fsys fs.FS, reload bool) (*Templates, error) {
+	t := &Templates{
+		fsys:   fsys,
+		reload: reload,
+		parsed: make(map[string]*template.Template, len(pages)),
+	}
+
+	// The templates are parsed even when reloading, so that a bad template fails at startup.
+	for _, page := range pages {
+		tmpl, err := t.parse(page)
+		if err != nil {
+			return nil, err
+		}
+		t.parsed[page] = tmpl
+	}
+
+	return t, nil
+}
+
+// parse parses the template of the page.
+func (t *Templates) parse(page string) (*template.Template, error) {
+	tmpl, err := template.New(page).Funcs(funcs).ParseFS(t.fsys, page)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %s: %w", page, err)
+	}
+	return tmpl, nil
+}
+
+// get returns the template of the page.
+func (t *Templates) get(page string) (*template.Template, error) {
+	if t.reload {
+		return t.parse(page)
+	}
+
+	tmpl, ok := t.parsed[page]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found", page)
+	}
+	return tmpl, nil
+}
+
+// render executes the template of the page with the data, and writes it to the response. The page is executed before
+// anything is written, so that an error can still be responded with.
+func (t *Templates) render(w http.ResponseWriter, page string, data any) {
+	tmpl, err := t.get(page)
+	if err != nil {
+		slog.Error("Error reading page template", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error reading page template")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		slog.Warn("Error executing template", slog.String(logging.KeyError, err.Error()))
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error executing template")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	w.Header().Set("content-type", "text/html")
+	w.WriteHeader(http.StatusOK)
+	if _, err := buf.WriteTo(w); err != nil {
+		slog.Warn("Error writing page", slog.String(logging.KeyError, err.Error()))
+	}
+}
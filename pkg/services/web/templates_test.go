@@ -0,0 +1,88 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testPages returns a file system with a template for every page.
+func testPages() fstest.MapFS {
+	fsys := make(fstest.MapFS)
+	for _, page := range pages {
+		fsys[page] = &fstest.MapFile{Data: []byte(`<p>{{ .Name }}</p>`)}
+	}
+	return fsys
+}
+
+func TestNewTemplates_BadTemplate(t *testing.T) {
+	fsys := testPages()
+	fsys[pageNode] = &fstest.MapFile{Data: []byte(`{{ .Name `)}
+
+	_, err := NewTemplates(fsys, false)
+	require.ErrorContains(t, err, pageNode)
+}
+
+func TestNewTemplates_MissingTemplate(t *testing.T) {
+	fsys := testPages()
+	delete(fsys, pageReport)
+
+	_, err := NewTemplates(fsys, false)
+	require.ErrorContains(t, err, pageReport)
+}
+
+func TestTemplates_Render(t *testing.T) {
+	tests := []struct {
+		name     string
+		reload   bool
+		wantBody string
+	}{
+		{
+			name:     "parsed once",
+			reload:   false,
+			wantBody: "<p>node1</p>",
+		},
+		{
+			name:     "reloaded",
+			reload:   true,
+			wantBody: "<h1>node1</h1>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := testPages()
+
+			templates, err := NewTemplates(fsys, tt.reload)
+			require.NoError(t, err)
+
+			// Change the template after it has been parsed.
+			fsys[pageIndex] = &fstest.MapFile{Data: []byte(`<h1>{{ .Name }}</h1>`)}
+
+			w := httptest.NewRecorder()
+			templates.render(w, pageIndex, struct{ Name string }{Name: "node1"})
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, "text/html", w.Header().Get("Content-Type"))
+			require.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+func TestTemplates_RenderError(t *testing.T) {
+	fsys := testPages()
+	fsys[pageIndex] = &fstest.MapFile{Data: []byte(`{{ .Missing.Field }}`)}
+
+	templates, err := NewTemplates(fsys, false)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	templates.render(w, pageIndex, struct{ Name string }{Name: "node1"})
+
+	// Nothing of the page is written when it fails.
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.NotContains(t, w.Body.String(), "<p>")
+}
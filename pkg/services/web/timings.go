@@ -0,0 +1,59 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/Jacobbrewer1/puppet-summary/pkg/codegen/apis/summary"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/request"
+	"github.com/Jacobbrewer1/puppet-summary/pkg/services/timings"
+)
+
+func (s service) timingsHandler(w http.ResponseWriter, r *http.Request) {
+	// The timings are aggregated over the same windows as the failing resources.
+	days, env, ok := failureQuery(w, r)
+	if !ok {
+		return
+	}
+
+	failureOpts := failureOptions(days, env)
+	breakdown, err := s.timings.Breakdown(r.Context(), nil, &timings.Options{
+		From: failureOpts.From,
+		Envs: failureOpts.Envs,
+	})
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			slog.Error("Error getting timings", slog.String(logging.KeyError, err.Error()))
+		}
+		// Respond with 500 internal server error.
+		w.WriteHeader(http.StatusInternalServerError)
+		if err := json.NewEncoder(w).Encode(request.NewMessage("Error getting timings")); err != nil {
+			slog.Warn("Error encoding response", slog.String(logging.KeyError, err.Error()))
+		}
+		return
+	}
+
+	type PageData struct {
+		Days         int
+		DaysOptions  []int
+		Environment  summary.Environment
+		Environments []summary.Environment
+		Breakdown    *timings.Breakdown
+		URLPrefix    string
+	}
+
+	pd := &PageData{
+		Days:         days,
+		DaysOptions:  failureDays,
+		Environment:  env,
+		Environments: summary.Environments,
+		Breakdown:    breakdown,
+		URLPrefix:    s.urlPrefix,
+	}
+
+	s.templates.render(w, pageTimings, pd)
+}
@@ -2,6 +2,7 @@ package vault
 
 import (
 	"context"
+	"fmt"
 
 	vault "github.com/hashicorp/vault/api"
 )
@@ -16,10 +17,35 @@ type Secrets struct {
 	*vault.Secret
 }
 
+// GetString returns the string value of the given key. Secrets read from a KV version 2 engine nest their values
+// under a "data" key, so this is checked if the key is not found at the top level.
+func (s *Secrets) GetString(key string) (string, bool) {
+	if s == nil || s.Secret == nil || s.Data == nil {
+		return "", false
+	}
+
+	if v, ok := s.Data[key]; ok {
+		return fmt.Sprintf("%v", v), true
+	}
+
+	nested, ok := s.Data["data"].(map[string]any)
+	if !ok {
+		return "", false
+	}
+
+	v, ok := nested[key]
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprintf("%v", v), true
+}
+
 type Client interface {
 	// GetSecrets returns a map of secrets for the given path.
 	GetSecrets(path string) (*Secrets, error)
 
-	// RenewLease renews the lease of the given credentials.
+	// RenewLease renews the lease of the given credentials. Secrets that do not have a lease (e.g. KV secrets) are
+	// re-read on the refresh interval so that rotations are picked up.
 	RenewLease(ctx context.Context, name string, credentials *vault.Secret, renewFunc renewalFunc) error
 }
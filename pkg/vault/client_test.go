@@ -0,0 +1,87 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecrets_GetString(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets *Secrets
+		key     string
+		want    string
+		wantOk  bool
+	}{
+		{
+			name:    "nil secret",
+			secrets: &Secrets{},
+			key:     "token",
+			want:    "",
+			wantOk:  false,
+		},
+		{
+			name: "kv version 1",
+			secrets: &Secrets{&vault.Secret{Data: map[string]any{
+				"token": "abc",
+			}}},
+			key:    "token",
+			want:   "abc",
+			wantOk: true,
+		},
+		{
+			name: "kv version 2",
+			secrets: &Secrets{&vault.Secret{Data: map[string]any{
+				"data":     map[string]any{"token": "abc"},
+				"metadata": map[string]any{"version": 2},
+			}}},
+			key:    "token",
+			want:   "abc",
+			wantOk: true,
+		},
+		{
+			name: "missing key",
+			secrets: &Secrets{&vault.Secret{Data: map[string]any{
+				"data": map[string]any{"token": "abc"},
+			}}},
+			key:    "password",
+			want:   "",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.secrets.GetString(tt.key)
+			require.Equal(t, tt.wantOk, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUserPassClient_RenewLease_RefreshError(t *testing.T) {
+	vip := viper.New()
+	vip.Set("vault.refresh_interval", time.Millisecond)
+	c := &userPassClient{vip: vip}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The refresh fails first, and the secret is re-read on the next interval rather than exiting.
+	calls := 0
+	err := c.RenewLease(ctx, "kv", &vault.Secret{}, func() (*vault.Secret, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("vault unavailable")
+		}
+		cancel()
+		return &vault.Secret{}, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+}
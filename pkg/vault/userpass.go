@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/Jacobbrewer1/puppet-summary/pkg/logging"
 	vault "github.com/hashicorp/vault/api"
@@ -115,7 +116,12 @@ func (c *userPassClient) RenewLease(ctx context.Context, name string, credential
 
 		err = handleWatcherResult(res, func() {
 			newCreds, err := renewFunc()
-			if err != nil {
+			if err != nil && !hasLease(currentCreds) {
+				// Secrets without a lease are still valid, so keep the current value and retry on the next refresh.
+				slog.Error("unable to refresh secret, retrying on the next interval", slog.String("secret", name),
+					slog.String(logging.KeyError, err.Error()))
+				return
+			} else if err != nil {
 				slog.Error("unable to renew credentials", slog.String(logging.KeyError, err.Error()))
 				os.Exit(1) // Forces new credentials to be fetched
 			}
@@ -131,6 +137,12 @@ func (c *userPassClient) RenewLease(ctx context.Context, name string, credential
 }
 
 func (c *userPassClient) leaseRenew(ctx context.Context, name string, credentials *vault.Secret) (renewResult, error) {
+	// Secrets without a lease (e.g. KV secrets) cannot be renewed, so they are re-read on the refresh interval to
+	// pick up any rotations.
+	if !hasLease(credentials) {
+		return waitForRefresh(ctx, name, c.refreshInterval()), nil
+	}
+
 	credentialsWatcher, err := c.v.NewLifetimeWatcher(&vault.LifetimeWatcherInput{
 		Secret:    credentials,
 		Increment: 3600,
@@ -150,6 +162,21 @@ func (c *userPassClient) leaseRenew(ctx context.Context, name string, credential
 	return res, nil
 }
 
+// hasLease returns true if the credentials have a lease or token that can be renewed.
+func hasLease(credentials *vault.Secret) bool {
+	return credentials.LeaseID != "" || credentials.Auth != nil
+}
+
+// refreshInterval returns how often secrets without a lease are re-read.
+func (c *userPassClient) refreshInterval() time.Duration {
+	if c.vip.IsSet("vault.refresh_interval") {
+		if d := c.vip.GetDuration("vault.refresh_interval"); d > 0 {
+			return d
+		}
+	}
+	return defaultRefreshInterval
+}
+
 func (c *userPassClient) GetSecrets(path string) (*Secrets, error) {
 	secret, err := c.v.Logical().Read(path)
 	if err != nil {
@@ -4,10 +4,27 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	vault "github.com/hashicorp/vault/api"
 )
 
+// defaultRefreshInterval is how often secrets without a lease are re-read if no interval is configured.
+const defaultRefreshInterval = 5 * time.Minute
+
+// waitForRefresh waits for the refresh interval to pass before marking the secret as expiring, so that it is re-read.
+func waitForRefresh(ctx context.Context, name string, interval time.Duration) renewResult {
+	slog.Debug("secret has no lease, waiting to refresh", slog.String("secret", name),
+		slog.String("interval", interval.String()))
+
+	select {
+	case <-ctx.Done():
+		return exitRequested
+	case <-time.After(interval):
+		return expiring
+	}
+}
+
 func monitorWatcher(ctx context.Context, name string, watcher *vault.LifetimeWatcher) (renewResult, error) {
 	for {
 		select {